@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Modes of sending queries
+const (
+	modeQuery     = "query"
+	modeAPQ       = "apq"
+	modePersisted = "persisted"
+)
+
+type (
+	config struct {
+		target      string
+		concurrency int
+		ramp        time.Duration
+		duration    time.Duration
+		timeout     time.Duration
+		mode        string
+		headers     map[string]string
+	}
+
+	// result of a request
+	result struct {
+		op      *operation
+		latency time.Duration
+		failed  bool
+		errors  bool
+		apqMiss bool
+	}
+
+	// graphQLResponse is the part of responses inspected by the benchmark
+	graphQLResponse struct {
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+)
+
+func validMode(mode string) bool {
+	return mode == modeQuery || mode == modeAPQ || mode == modePersisted
+}
+
+// run workers until the context is done, and report results
+func run(ctx context.Context, cfg config, ops []*operation) *report {
+	client := &http.Client{Timeout: cfg.timeout}
+	results := make(chan result, cfg.concurrency)
+	collected := make(chan *report)
+	go func() {
+		r := newReport()
+		for res := range results {
+			r.add(res)
+		}
+		collected <- r
+	}()
+
+	total := 0
+	for _, op := range ops {
+		total += op.weight
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if delay := cfg.ramp * time.Duration(i) / time.Duration(cfg.concurrency); delay > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(delay):
+				}
+			}
+
+			rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))
+			for ctx.Err() == nil {
+				op := pick(rnd, ops, total)
+				res := send(ctx, client, cfg, op, op.variants[rnd.Intn(len(op.variants))])
+				if ctx.Err() != nil {
+					// requests interrupted by the end of the test are not accounted for
+					return
+				}
+				results <- res
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	r := <-collected
+	r.finish(time.Since(start))
+	return r
+}
+
+// pick an operation at random, according to weights
+func pick(rnd *rand.Rand, ops []*operation, total int) *operation {
+	n := rnd.Intn(total)
+	for _, op := range ops {
+		if n < op.weight {
+			return op
+		}
+		n -= op.weight
+	}
+	return ops[len(ops)-1]
+}
+
+// send an operation, following the automatic persisted query flow in apq mode
+func send(ctx context.Context, client *http.Client, cfg config, op *operation, variables map[string]interface{}) result {
+	start := time.Now()
+	res := result{op: op}
+
+	withQuery := cfg.mode == modeQuery
+	resp, err := post(ctx, client, cfg, op, variables, withQuery)
+	if err == nil && cfg.mode == modeAPQ && persistedQueryNotFound(resp) {
+		res.apqMiss = true
+		resp, err = post(ctx, client, cfg, op, variables, true)
+	}
+
+	res.latency = time.Since(start)
+	res.failed = err != nil
+	res.errors = err == nil && len(resp.Errors) > 0
+	return res
+}
+
+func post(ctx context.Context, client *http.Client, cfg config, op *operation, variables map[string]interface{}, withQuery bool) (graphQLResponse, error) {
+	var gqlResp graphQLResponse
+
+	params := map[string]interface{}{
+		"variables": variables,
+	}
+	if op.name != "" {
+		params["operationName"] = op.name
+	}
+	if withQuery {
+		params["query"] = op.query
+	}
+	if cfg.mode != modeQuery {
+		params["extensions"] = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": op.hash},
+		}
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return gqlResp, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cfg.target, bytes.NewReader(body))
+	if err != nil {
+		return gqlResp, err
+	}
+	req = req.WithContext(ctx)
+	for name, value := range op.headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range cfg.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return gqlResp, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&gqlResp); err != nil {
+		return gqlResp, err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if persistedQueryNotFound(gqlResp) {
+			// gqlgen answers unknown persisted queries with a 422 status
+			return gqlResp, nil
+		}
+		return gqlResp, errStatus(resp.StatusCode)
+	}
+	return gqlResp, nil
+}
+
+func persistedQueryNotFound(resp graphQLResponse) bool {
+	for _, err := range resp.Errors {
+		if err.Message == "PersistedQueryNotFound" || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
+
+type errStatus int
+
+func (e errStatus) Error() string {
+	return http.StatusText(int(e))
+}
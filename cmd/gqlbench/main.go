@@ -0,0 +1,181 @@
+// Command gqlbench load tests a GraphQL endpoint with a realistic mix of operations, and reports latency
+// percentiles per operation.
+//
+// Usage:
+//
+//   gqlbench -target https://staging.example.com/query [-replay requests.jsonl] [-manifest manifest.json]
+//     [-variables variables.json] [-weight GetTodos=5] [-concurrency 10] [-ramp 10s] [-duration 1m]
+//     [-mode query|apq|persisted] [-header 'Authorization: Bearer ...'] [-json]
+//
+// Operations are loaded from requests recorded by gqlreplay (JSON lines), or from a persisted query manifest built
+// by gqlmanifest. Recorded operations are weighted by their number of occurrences, so that the mix follows
+// production traffic. Manifest operations have a weight of 1, and no variables unless given by -variables,
+// a JSON object of variables by operation name. Weights are overridden per operation with -weight: a weight
+// of 0 excludes an operation.
+//
+// Workers are started progressively over the ramp duration. Requests are sent in one of these modes:
+//
+//   query      the query is sent with each request
+//   apq        automatic persisted queries: only the hash is sent, then the query when the server does not know it
+//   persisted  only the hash is sent, for servers allowing a manifest of persisted queries
+//
+// The exit status is 1 when some request failed, and 2 on usage errors.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errUsage = errors.New("invalid usage")
+
+// repeated is a flag which may be repeated
+type repeated []string
+
+func (r *repeated) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *repeated) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// args of the command line
+type args struct {
+	cfg       config
+	replay    string
+	manifest  string
+	variables string
+	weights   repeated
+	asJSON    bool
+}
+
+func main() {
+	a, err := parseArgs(os.Args[0], os.Args[1:], os.Stderr)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	ops, err := loadOperations(a.replay, a.manifest, a.variables)
+	if err == nil {
+		ops, err = applyWeights(ops, a.weights)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.duration)
+	defer cancel()
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	go func() {
+		<-interrupted
+		cancel()
+	}()
+
+	report := run(ctx, a.cfg, ops)
+	if err := writeReport(os.Stdout, report, a.asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if report.Total.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses the command line. Usage errors are reported to output.
+func parseArgs(name string, arguments []string, output io.Writer) (args, error) {
+	var (
+		a       args
+		headers repeated
+	)
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&a.replay, "replay", "", "requests recorded by gqlreplay, as JSON lines")
+	fs.StringVar(&a.manifest, "manifest", "", "persisted query manifest built by gqlmanifest")
+	fs.StringVar(&a.variables, "variables", "", "JSON object of variables by operation name, for manifest operations")
+	fs.BoolVar(&a.asJSON, "json", false, "print the report as JSON")
+	fs.StringVar(&a.cfg.target, "target", "", "GraphQL endpoint")
+	fs.IntVar(&a.cfg.concurrency, "concurrency", 10, "number of concurrent workers")
+	fs.DurationVar(&a.cfg.ramp, "ramp", 0, "duration over which workers are started")
+	fs.DurationVar(&a.cfg.duration, "duration", 30*time.Second, "duration of the test, including the ramp")
+	fs.DurationVar(&a.cfg.timeout, "timeout", 10*time.Second, "timeout of requests")
+	fs.StringVar(&a.cfg.mode, "mode", modeQuery, "how queries are sent: query, apq or persisted")
+	fs.Var(&a.weights, "weight", "weight of an operation, as name=weight (repeatable)")
+	fs.Var(&headers, "header", "request header, as 'Name: value' (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(output, "usage: %s -target url [-replay file] [-manifest file] [flags]\n", name)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(arguments); err != nil {
+		return a, err
+	}
+
+	if a.cfg.target == "" || (a.replay == "" && a.manifest == "") || a.cfg.concurrency < 1 || !validMode(a.cfg.mode) {
+		fs.Usage()
+		return a, errUsage
+	}
+
+	var err error
+	a.cfg.headers, err = parseHeaders(headers)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return a, err
+	}
+	return a, nil
+}
+
+func applyWeights(ops []*operation, weights []string) ([]*operation, error) {
+	overrides := make(map[string]int, len(weights))
+	for _, w := range weights {
+		sep := strings.LastIndexByte(w, '=')
+		if sep < 0 {
+			return nil, fmt.Errorf("invalid weight %q: expected name=weight", w)
+		}
+		weight, err := strconv.Atoi(w[sep+1:])
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q: expected a positive integer", w)
+		}
+		overrides[w[:sep]] = weight
+	}
+
+	kept := make([]*operation, 0, len(ops))
+	for _, op := range ops {
+		if weight, ok := overrides[op.name]; ok {
+			op.weight = weight
+		}
+		if op.weight > 0 {
+			kept = append(kept, op)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no operation to run")
+	}
+	return kept, nil
+}
+
+func parseHeaders(headers []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(headers))
+	for _, h := range headers {
+		sep := strings.IndexByte(h, ':')
+		if sep < 0 {
+			return nil, fmt.Errorf("invalid header %q: expected 'Name: value'", h)
+		}
+		parsed[strings.TrimSpace(h[:sep])] = strings.TrimSpace(h[sep+1:])
+	}
+	return parsed, nil
+}
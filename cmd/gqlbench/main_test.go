@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		a, err := parseArgs("gqlbench", []string{"-target", "http://localhost/query", "-replay", "requests.jsonl"}, ioutil.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost/query", a.cfg.target)
+		assert.Equal(t, "requests.jsonl", a.replay)
+		assert.Equal(t, 10, a.cfg.concurrency)
+		assert.Equal(t, 30*time.Second, a.cfg.duration)
+		assert.Equal(t, modeQuery, a.cfg.mode)
+		assert.Empty(t, a.cfg.headers)
+		assert.False(t, a.asJSON)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		a, err := parseArgs("gqlbench", []string{
+			"-target", "http://localhost/query",
+			"-manifest", "manifest.json",
+			"-variables", "variables.json",
+			"-weight", "GetTodos=5", "-weight", "GetUser=0",
+			"-header", "Authorization: Bearer token", "-header", "X-Test:1",
+			"-concurrency", "2", "-ramp", "1s", "-duration", "1m",
+			"-mode", modeAPQ,
+			"-json",
+		}, ioutil.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "manifest.json", a.manifest)
+		assert.Equal(t, "variables.json", a.variables)
+		assert.Equal(t, repeated{"GetTodos=5", "GetUser=0"}, a.weights)
+		assert.Equal(t, map[string]string{"Authorization": "Bearer token", "X-Test": "1"}, a.cfg.headers)
+		assert.Equal(t, 2, a.cfg.concurrency)
+		assert.Equal(t, time.Second, a.cfg.ramp)
+		assert.Equal(t, time.Minute, a.cfg.duration)
+		assert.Equal(t, modeAPQ, a.cfg.mode)
+		assert.True(t, a.asJSON)
+	})
+
+	for _, args := range [][]string{
+		{"-replay", "requests.jsonl"},
+		{"-target", "http://localhost/query"},
+		{"-target", "http://localhost/query", "-replay", "requests.jsonl", "-concurrency", "0"},
+		{"-target", "http://localhost/query", "-replay", "requests.jsonl", "-mode", "unknown"},
+		{"-target", "http://localhost/query", "-replay", "requests.jsonl", "-header", "invalid"},
+		{"-unknown"},
+	} {
+		args := args
+		t.Run("invalid "+strings.Join(args, " "), func(t *testing.T) {
+			var output bytes.Buffer
+			_, err := parseArgs("gqlbench", args, &output)
+			assert.Error(t, err)
+			assert.NotEmpty(t, output.String(), "usage errors are reported")
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		var output bytes.Buffer
+		_, err := parseArgs("gqlbench", []string{"-h"}, &output)
+		assert.Equal(t, flag.ErrHelp, err)
+		assert.Contains(t, output.String(), "usage: gqlbench -target url")
+	})
+}
+
+func TestApplyWeights(t *testing.T) {
+	ops := func() []*operation {
+		return []*operation{{name: "a", weight: 1}, {name: "b", weight: 3}}
+	}
+
+	kept, err := applyWeights(ops(), []string{"a=5", "b=0"})
+	require.NoError(t, err)
+	require.Len(t, kept, 1, "a weight of 0 excludes an operation")
+	assert.Equal(t, "a", kept[0].name)
+	assert.Equal(t, 5, kept[0].weight)
+
+	_, err = applyWeights(ops(), []string{"a"})
+	assert.Error(t, err)
+	_, err = applyWeights(ops(), []string{"a=-1"})
+	assert.Error(t, err)
+	_, err = applyWeights(ops(), []string{"a=0", "b=0"})
+	assert.Error(t, err, "some operation must be left to run")
+}
+
+func TestSmoke(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var params struct {
+			OperationName string `json:"operationName"`
+			Query         string `json:"query"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		assert.NotEmpty(t, params.Query)
+
+		w.Header().Set("Content-Type", "application/json")
+		if params.OperationName == "broken" {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"broken"}],"data":null}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"todos":[]}}`))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gqlbench")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	replay := filepath.Join(dir, "requests.jsonl")
+	require.NoError(t, ioutil.WriteFile(replay, []byte(
+		`{"operationName":"todos","query":"query todos { todos { id } }"}
+{"operationName":"todos","query":"query todos { todos { id } }","variables":{"first":10}}
+{"operationName":"broken","query":"query broken { broken }"}
+`), 0600))
+
+	a, err := parseArgs("gqlbench", []string{
+		"-target", srv.URL,
+		"-replay", replay,
+		"-header", "Authorization: Bearer token",
+		"-concurrency", "2",
+		"-duration", "200ms",
+	}, ioutil.Discard)
+	require.NoError(t, err)
+
+	ops, err := loadOperations(a.replay, a.manifest, a.variables)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	assert.Equal(t, "todos", ops[0].name)
+	assert.Equal(t, 2, ops[0].weight, "operations are weighted by their occurrences")
+	assert.Len(t, ops[0].variants, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.duration)
+	defer cancel()
+	r := run(ctx, a.cfg, ops)
+
+	require.True(t, r.Total.Requests > 0)
+	assert.True(t, int(atomic.LoadInt32(&requests)) >= r.Total.Requests)
+	assert.Equal(t, 0, r.Total.Failed)
+	require.Len(t, r.Operations, 2)
+	assert.Equal(t, "broken", r.Operations[0].Operation)
+	assert.Equal(t, r.Operations[0].Requests, r.Operations[0].Errors, "responses with errors are accounted for")
+	assert.Equal(t, "todos", r.Operations[1].Operation)
+	assert.Equal(t, 0, r.Operations[1].Errors)
+	assert.Equal(t, r.Total.Requests, r.Operations[0].Requests+r.Operations[1].Requests)
+	assert.True(t, r.Total.P50 <= r.Total.P99 && r.Total.P99 <= r.Total.Max)
+
+	t.Run("table", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, writeReport(&output, r, false))
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		require.Len(t, lines, 6)
+		assert.Contains(t, lines[0], "requests in")
+		assert.Contains(t, lines[2], "operation")
+		assert.Contains(t, lines[2], "p99 ms")
+		assert.Contains(t, lines[3], "broken")
+		assert.Contains(t, lines[4], "todos")
+		assert.Contains(t, lines[5], "total")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, writeReport(&output, r, true))
+		var decoded report
+		require.NoError(t, json.Unmarshal(output.Bytes(), &decoded))
+		assert.Equal(t, r.Total.Requests, decoded.Total.Requests)
+		require.Len(t, decoded.Operations, 2)
+		assert.Equal(t, "broken", decoded.Operations[0].Operation)
+	})
+}
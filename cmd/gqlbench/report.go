@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type (
+	// report of a benchmark
+	report struct {
+		Duration   string   `json:"duration"`
+		Throughput float64  `json:"throughput"`
+		Total      *stats   `json:"total"`
+		Operations []*stats `json:"operations"`
+
+		byName map[string]*stats
+	}
+
+	// stats of the requests of an operation
+	stats struct {
+		Operation string  `json:"operation"`
+		Requests  int     `json:"requests"`
+		Failed    int     `json:"failed"`
+		Errors    int     `json:"errors"`
+		APQMisses int     `json:"apqMisses,omitempty"`
+		P50       float64 `json:"p50Ms"`
+		P90       float64 `json:"p90Ms"`
+		P99       float64 `json:"p99Ms"`
+		Max       float64 `json:"maxMs"`
+		Mean      float64 `json:"meanMs"`
+
+		latencies []time.Duration
+	}
+)
+
+func newReport() *report {
+	return &report{
+		Total:  &stats{Operation: "total"},
+		byName: make(map[string]*stats),
+	}
+}
+
+func (r *report) add(res result) {
+	s, ok := r.byName[res.op.name]
+	if !ok {
+		s = &stats{Operation: res.op.name}
+		r.byName[res.op.name] = s
+		r.Operations = append(r.Operations, s)
+	}
+	s.add(res)
+	r.Total.add(res)
+}
+
+func (r *report) finish(elapsed time.Duration) {
+	r.Duration = elapsed.Round(time.Millisecond).String()
+	if elapsed > 0 {
+		r.Throughput = float64(r.Total.Requests) / elapsed.Seconds()
+	}
+	sort.Slice(r.Operations, func(i, j int) bool { return r.Operations[i].Operation < r.Operations[j].Operation })
+	for _, s := range r.Operations {
+		s.finish()
+	}
+	r.Total.finish()
+}
+
+// writeReport prints a report, as a table or as JSON
+func writeReport(w io.Writer, r *report, asJSON bool) error {
+	if asJSON {
+		buf, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(buf))
+		return err
+	}
+	r.print(w)
+	return nil
+}
+
+func (r *report) print(w io.Writer) {
+	fmt.Fprintf(w, "%d requests in %s (%.1f req/s)\n\n", r.Total.Requests, r.Duration, r.Throughput)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\trequests\tfailed\terrors\tapq misses\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, s := range append(r.Operations, r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			s.Operation, s.Requests, s.Failed, s.Errors, s.APQMisses, s.P50, s.P90, s.P99, s.Max)
+	}
+	_ = tw.Flush()
+}
+
+func (s *stats) add(res result) {
+	s.Requests++
+	if res.failed {
+		s.Failed++
+	}
+	if res.errors {
+		s.Errors++
+	}
+	if res.apqMiss {
+		s.APQMisses++
+	}
+	s.latencies = append(s.latencies, res.latency)
+}
+
+func (s *stats) finish() {
+	if len(s.latencies) == 0 {
+		return
+	}
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+
+	var sum time.Duration
+	for _, latency := range s.latencies {
+		sum += latency
+	}
+	s.Mean = ms(sum / time.Duration(len(s.latencies)))
+	s.P50 = ms(percentile(s.latencies, 0.50))
+	s.P90 = ms(percentile(s.latencies, 0.90))
+	s.P99 = ms(percentile(s.latencies, 0.99))
+	s.Max = ms(s.latencies[len(s.latencies)-1])
+}
+
+// percentile of sorted latencies, with the nearest-rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
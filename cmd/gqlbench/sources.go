@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/99designs/gqlgen-contrib/gqlmanifest"
+	"github.com/99designs/gqlgen-contrib/gqlreplay"
+)
+
+// operation in the mix. Variants are the sets of variables of the operation, picked at random.
+type operation struct {
+	name     string
+	query    string
+	hash     string
+	weight   int
+	variants []map[string]interface{}
+	headers  map[string]string
+}
+
+// loadOperations from recorded requests, and from a manifest
+func loadOperations(replay, manifest, variables string) ([]*operation, error) {
+	byKey := make(map[string]*operation)
+	var ops []*operation
+	add := func(name, query string) *operation {
+		key := name + "\x00" + query
+		if op, ok := byKey[key]; ok {
+			return op
+		}
+		hash := gqlmanifest.Hash(query)
+		if name == "" {
+			name = "anonymous-" + hash[:8]
+		}
+		op := &operation{name: name, query: query, hash: hash}
+		byKey[key] = op
+		ops = append(ops, op)
+		return op
+	}
+
+	if replay != "" {
+		f, err := os.Open(replay)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		decoder := json.NewDecoder(f)
+		for {
+			var entry gqlreplay.Entry
+			if err := decoder.Decode(&entry); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			op := add(entry.OperationName, entry.Query)
+			op.weight++
+			op.variants = append(op.variants, entry.Variables)
+			if op.headers == nil {
+				op.headers = entry.Headers
+			}
+		}
+	}
+
+	if manifest != "" {
+		m, err := gqlmanifest.Load(manifest)
+		if err != nil {
+			return nil, err
+		}
+		vars := make(map[string]map[string]interface{})
+		if variables != "" {
+			buf, err := ioutil.ReadFile(variables)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(buf, &vars); err != nil {
+				return nil, err
+			}
+		}
+
+		hashes := make([]string, 0, len(m.Operations))
+		for hash := range m.Operations {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+		for _, hash := range hashes {
+			manifestOp := m.Operations[hash]
+			name := ""
+			if len(manifestOp.Names) > 0 {
+				name = manifestOp.Names[0]
+			}
+			op := add(name, manifestOp.Query)
+			if op.weight == 0 {
+				op.weight = 1
+				op.variants = append(op.variants, vars[op.name])
+			}
+		}
+	}
+
+	return ops, nil
+}
@@ -0,0 +1,105 @@
+// Command gqlcontract checks the operations of clients against a schema, and exits with a non-zero status when
+// an operation is broken by the schema.
+//
+// Usage:
+//
+//   gqlcontract -schema 'graph/*.graphqls' [-json] [-deprecated-errors] path...
+//
+// Paths are GraphQL documents (.graphql, .gql) holding client operations, or directories containing such documents.
+//
+// Issues are printed on stdout, one per line, or as a JSON report with -json. The exit status is 1 when some
+// operation is broken, and 2 on usage errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlcontract"
+)
+
+func main() {
+	schemaGlob := flag.String("schema", "", "glob matching the schema files")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	deprecatedErrors := flag.Bool("deprecated-errors", false, "report usages of deprecated fields as breaking")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -schema glob [flags] path...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *schemaGlob == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	breaking, err := run(os.Stdout, *schemaGlob, *asJSON, *deprecatedErrors, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if breaking {
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, schemaGlob string, asJSON, deprecatedErrors bool, paths []string) (bool, error) {
+	schema, err := loadSchema(schemaGlob)
+	if err != nil {
+		return false, err
+	}
+
+	report, err := gqlcontract.New(paths, gqlcontract.WithDeprecationsAsErrors(deprecatedErrors)).Check(schema)
+	if err != nil {
+		return false, err
+	}
+
+	if asJSON {
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		if _, err := w.Write(append(buf, '\n')); err != nil {
+			return false, err
+		}
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Fprintln(w, issue)
+		}
+		fmt.Fprintf(w, "%d operation(s) checked, %d issue(s)\n", report.Operations, len(report.Issues))
+	}
+	return report.Breaking(), nil
+}
+
+func loadSchema(glob string) (*ast.Schema, error) {
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no schema file matches %s", glob)
+	}
+
+	sources := make([]*ast.Source, 0, len(files))
+	for _, file := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &ast.Source{Name: file, Input: string(buf)})
+	}
+
+	schema, gqlErr := gqlparser.LoadSchema(sources...)
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	return schema, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// assertGolden compares the output with the golden file in testdata, or rewrites the golden file with -update
+func assertGolden(t *testing.T, name string, output []byte) {
+	golden := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, ioutil.WriteFile(golden, output, 0644))
+	}
+	expected, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(output))
+}
+
+func TestRun(t *testing.T) {
+	const schemaGlob = "testdata/schema/*.graphqls"
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		breaking, err := run(&out, schemaGlob, false, false, []string{"testdata/operations"})
+		require.NoError(t, err)
+		assert.True(t, breaking)
+		assertGolden(t, "report.golden", out.Bytes())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		breaking, err := run(&out, schemaGlob, true, false, []string{"testdata/operations"})
+		require.NoError(t, err)
+		assert.True(t, breaking)
+		assertGolden(t, "report.json.golden", out.Bytes())
+	})
+
+	t.Run("deprecations only", func(t *testing.T) {
+		paths := []string{"testdata/operations/archived.graphql", "testdata/operations/todos.graphql"}
+
+		var out bytes.Buffer
+		breaking, err := run(&out, schemaGlob, false, false, paths)
+		require.NoError(t, err)
+		assert.False(t, breaking)
+
+		out.Reset()
+		breaking, err = run(&out, schemaGlob, false, true, paths)
+		require.NoError(t, err)
+		assert.True(t, breaking)
+		assertGolden(t, "deprecated-errors.golden", out.Bytes())
+	})
+
+	t.Run("no schema", func(t *testing.T) {
+		_, err := run(ioutil.Discard, "testdata/missing/*.graphqls", false, false, []string{"testdata/operations"})
+		assert.EqualError(t, err, "no schema file matches testdata/missing/*.graphqls")
+	})
+}
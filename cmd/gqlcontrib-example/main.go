@@ -0,0 +1,140 @@
+// Command gqlcontrib-example scaffolds a runnable gqlgen server, wired with a selection of contrib extensions,
+// and a docker-compose file running Jaeger and Prometheus to explore their traces and metrics.
+//
+// Usage:
+//
+//   gqlcontrib-example [-o dir] [-module path] [-with tracing,metrics,logging,apq] [-replace dir] [-force]
+//
+// Then, in the generated directory:
+//
+//   go mod tidy && go generate ./... && docker-compose up -d && go run .
+//
+// With -replace, the generated module uses a local checkout of gqlgen-contrib, e.g. to try unreleased extensions.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// features which may be selected with -with
+var features = map[string]string{
+	"tracing": "OpenCensus tracing, exported to Jaeger",
+	"metrics": "Prometheus metrics, served on /metrics",
+	"logging": "logs of errors, deduplicated by fingerprint",
+	"apq":     "automatic persisted queries",
+}
+
+type params struct {
+	Module  string
+	Replace string
+	Tracing bool
+	Metrics bool
+	Logging bool
+	APQ     bool
+}
+
+func main() {
+	output := flag.String("o", "gqlcontrib-example", "output directory")
+	module := flag.String("module", "example.com/gqlcontrib-example", "module path of the generated server")
+	with := flag.String("with", "tracing,metrics,logging,apq", "comma-separated contrib features: "+featureList())
+	replace := flag.String("replace", "", "local checkout of gqlgen-contrib to use instead of the released modules")
+	force := flag.Bool("force", false, "overwrite the files of an existing output directory")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	p, err := newParams(*module, *with, *replace)
+	if err == nil {
+		err = run(os.Stdout, *output, p, *force)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func featureList() string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func newParams(module, with, replace string) (params, error) {
+	p := params{Module: module}
+	if replace != "" {
+		abs, err := filepath.Abs(replace)
+		if err != nil {
+			return p, err
+		}
+		p.Replace = filepath.ToSlash(abs)
+	}
+
+	for _, feature := range strings.Split(with, ",") {
+		switch strings.TrimSpace(feature) {
+		case "":
+		case "tracing":
+			p.Tracing = true
+		case "metrics":
+			p.Metrics = true
+		case "logging":
+			p.Logging = true
+		case "apq":
+			p.APQ = true
+		default:
+			return p, fmt.Errorf("unknown feature %q, expected one of: %s", feature, featureList())
+		}
+	}
+	return p, nil
+}
+
+func run(w io.Writer, output string, p params, force bool) error {
+	if !force {
+		if entries, err := ioutil.ReadDir(output); err == nil && len(entries) > 0 {
+			return fmt.Errorf("%s is not empty: use -force to overwrite its files", output)
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tpl, err := template.New(name).Parse(files[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, p); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if buf.Len() == 0 {
+			continue
+		}
+
+		path := filepath.Join(output, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, path)
+	}
+	return nil
+}
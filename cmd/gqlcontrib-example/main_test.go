@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gopkg.in/yaml.v2"
+)
+
+func TestTemplates(t *testing.T) {
+	for _, with := range []string{"", "tracing", "metrics", "logging", "apq", "tracing,metrics,logging,apq"} {
+		with := with
+		t.Run("with "+with, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gqlcontrib-example")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			p, err := newParams("example.com/server", with, "")
+			require.NoError(t, err)
+			var out bytes.Buffer
+			require.NoError(t, run(&out, dir, p, false))
+			require.FileExists(t, filepath.Join(dir, "server.go"))
+
+			var written []string
+			for name := range files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				content, err := ioutil.ReadFile(path)
+				if os.IsNotExist(err) {
+					// templates yielding nothing are not written
+					continue
+				}
+				require.NoError(t, err)
+				written = append(written, path)
+
+				switch {
+				case strings.HasSuffix(name, ".go"):
+					_, err := parser.ParseFile(token.NewFileSet(), name, content, parser.AllErrors)
+					assert.NoError(t, err, name)
+				case strings.HasSuffix(name, ".yml"):
+					var doc map[string]interface{}
+					assert.NoError(t, yaml.UnmarshalStrict(content, &doc), name)
+				case strings.HasSuffix(name, ".graphqls"):
+					_, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: name, Input: string(content)})
+					assert.Nil(t, gqlErr, name)
+				case name == "go.mod":
+					assert.True(t, strings.HasPrefix(string(content), "module example.com/server\n"), name)
+				}
+			}
+
+			// the paths of written files are printed, one per line, in the order of their template names
+			sort.Strings(written)
+			printed := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			assert.Equal(t, written, printed)
+		})
+	}
+
+	t.Run("unknown feature", func(t *testing.T) {
+		_, err := newParams("example.com/server", "tracing,unknown", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("existing output", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "gqlcontrib-example")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "server.go"), []byte("package main\n"), 0644))
+
+		var out bytes.Buffer
+		assert.Error(t, run(&out, dir, params{Module: "example.com/server"}, false), "existing files are not overwritten")
+		assert.Empty(t, out.String())
+	})
+}
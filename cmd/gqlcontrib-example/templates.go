@@ -0,0 +1,321 @@
+package main
+
+// files of the generated server, as templates of params, by path. Templates yielding nothing are not written.
+var files = map[string]string{
+	"go.mod":                    goMod,
+	"tools.go":                  toolsGo,
+	"gqlgen.yml":                gqlgenYml,
+	"server.go":                 serverGo,
+	"graph/schema.graphqls":     schemaGraphqls,
+	"graph/resolver.go":         resolverGo,
+	"graph/schema.resolvers.go": schemaResolversGo,
+	"docker-compose.yml":        dockerComposeYml,
+	"prometheus.yml":            prometheusYml,
+	"README.md":                 readmeMd,
+}
+
+const goMod = `module {{ .Module }}
+
+go 1.13
+
+require github.com/99designs/gqlgen v0.11.3
+{{- if .Replace }}
+
+replace github.com/99designs/gqlgen-contrib => {{ .Replace }}
+{{- if .Metrics }}
+
+replace github.com/99designs/gqlgen-contrib/prometheus => {{ .Replace }}/prometheus
+{{- end }}
+{{- end }}
+`
+
+const toolsGo = `// +build tools
+
+package main
+
+import (
+	_ "github.com/99designs/gqlgen"
+)
+`
+
+const gqlgenYml = `schema:
+  - graph/*.graphqls
+
+exec:
+  filename: graph/generated/generated.go
+  package: generated
+
+model:
+  filename: graph/model/models_gen.go
+  package: model
+
+resolver:
+  layout: follow-schema
+  dir: graph
+  package: graph
+`
+
+const serverGo = `//go:generate go run github.com/99designs/gqlgen
+
+package main
+
+import (
+{{- if .Logging }}
+	"context"
+{{- end }}
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+{{ if .Tracing }}	"contrib.go.opencensus.io/exporter/jaeger"
+{{ end }}	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+{{- if .Metrics }}
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+{{- end }}
+{{- if .Tracing }}
+	"go.opencensus.io/trace"
+{{- end }}
+
+	"{{ .Module }}/graph"
+	"{{ .Module }}/graph/generated"
+{{- if .Logging }}
+
+	"github.com/99designs/gqlgen-contrib/gqlfingerprint"
+{{- end }}
+{{- if .Tracing }}
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+{{- end }}
+{{- if .Metrics }}
+	"github.com/99designs/gqlgen-contrib/prometheus"
+{{- end }}
+)
+
+func main() {
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: &graph.Resolver{}}))
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New(1000))
+	srv.Use(extension.Introspection{})
+{{- if .APQ }}
+
+	// automatic persisted queries: clients send the hash of known queries instead of their text
+	srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New(100)})
+{{- end }}
+{{- if .Tracing }}
+
+	// tracing: operations and fields are traced with OpenCensus, and exported to Jaeger
+	exporter, err := jaeger.NewExporter(jaeger.Options{
+		CollectorEndpoint: env("JAEGER_ENDPOINT", "http://localhost:14268/api/traces"),
+		Process:           jaeger.Process{ServiceName: "gqlcontrib-example"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	trace.RegisterExporter(exporter)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	srv.Use(gqlopencensus.New())
+{{- end }}
+{{- if .Metrics }}
+
+	// metrics: latencies of operations and fields, scraped by Prometheus
+	prometheus.Register()
+	srv.Use(prometheus.New())
+	http.Handle("/metrics", promhttp.Handler())
+{{- end }}
+{{- if .Logging }}
+
+	// logging: errors are logged once per fingerprint and per minute, then summarized with their occurrences
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dedup := gqlfingerprint.New(gqlfingerprint.WithHandler(func(o gqlfingerprint.Occurrence) {
+		log.Printf("error [%s] %v (occurrences: %d)", o.Fingerprint, o.Error, o.Occurrences)
+	}))
+	go dedup.Run(ctx)
+	srv.SetErrorPresenter(gqlfingerprint.ErrorPresenter(dedup, graphql.DefaultErrorPresenter))
+{{- else }}
+	srv.SetErrorPresenter(graphql.DefaultErrorPresenter)
+{{- end }}
+
+	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
+	http.Handle("/query", srv)
+
+	port := env("PORT", "8080")
+	log.Printf("connect to http://localhost:%s/ for the GraphQL playground", port)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
+
+func env(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+`
+
+const schemaGraphqls = `type Todo {
+  id: ID!
+  text: String!
+  done: Boolean!
+}
+
+type Query {
+  todos: [Todo!]!
+}
+
+type Mutation {
+  createTodo(text: String!): Todo!
+  completeTodo(id: ID!): Todo!
+}
+`
+
+const resolverGo = `package graph
+
+import (
+	"sync"
+
+	"{{ .Module }}/graph/model"
+)
+
+// This file will not be regenerated automatically.
+//
+// It serves as dependency injection for your app, add any dependencies you require here.
+
+// Resolver keeps todos in memory
+type Resolver struct {
+	mx    sync.Mutex
+	todos []*model.Todo
+}
+`
+
+const schemaResolversGo = `package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"fmt"
+
+	"{{ .Module }}/graph/generated"
+	"{{ .Module }}/graph/model"
+)
+
+func (r *mutationResolver) CreateTodo(ctx context.Context, text string) (*model.Todo, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	todo := &model.Todo{ID: fmt.Sprintf("T%d", len(r.todos)+1), Text: text}
+	r.todos = append(r.todos, todo)
+	return todo, nil
+}
+
+func (r *mutationResolver) CompleteTodo(ctx context.Context, id string) (*model.Todo, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	for _, todo := range r.todos {
+		if todo.ID == id {
+			todo.Done = true
+			return todo, nil
+		}
+	}
+	return nil, fmt.Errorf("todo %q not found", id)
+}
+
+func (r *queryResolver) Todos(ctx context.Context) ([]*model.Todo, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	return append([]*model.Todo(nil), r.todos...), nil
+}
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+// Query returns generated.QueryResolver implementation.
+func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+`
+
+const dockerComposeYml = `{{ if or .Tracing .Metrics -}}
+version: "3"
+
+services:
+{{- if .Tracing }}
+  jaeger:
+    image: jaegertracing/all-in-one:1.18
+    ports:
+      - "16686:16686"
+      - "14268:14268"
+{{- end }}
+{{- if .Metrics }}
+  prometheus:
+    image: prom/prometheus:v2.19.0
+    ports:
+      - "9090:9090"
+    volumes:
+      - ./prometheus.yml:/etc/prometheus/prometheus.yml:ro
+    extra_hosts:
+      - "host.docker.internal:host-gateway"
+{{- end }}
+{{ end -}}
+`
+
+const prometheusYml = `{{ if .Metrics -}}
+global:
+  scrape_interval: 5s
+
+scrape_configs:
+  - job_name: gqlcontrib-example
+    static_configs:
+      - targets: ["host.docker.internal:8080"]
+{{ end -}}
+`
+
+const readmeMd = `# gqlcontrib-example
+
+A gqlgen server wired with gqlgen-contrib extensions:
+{{ if .Tracing }}
+* OpenCensus tracing, exported to Jaeger
+{{- end }}
+{{- if .Metrics }}
+* Prometheus metrics, served on /metrics
+{{- end }}
+{{- if .Logging }}
+* logs of errors, deduplicated by fingerprint
+{{- end }}
+{{- if .APQ }}
+* automatic persisted queries
+{{- end }}
+
+## Run
+
+    go mod tidy
+    go generate ./...
+{{- if or .Tracing .Metrics }}
+    docker-compose up -d
+{{- end }}
+    go run .
+
+Then open the playground at http://localhost:8080, and run:
+
+    mutation { createTodo(text: "try gqlgen-contrib") { id } }
+    query { todos { id text done } }
+{{ if .Tracing }}
+Traces are displayed by Jaeger at http://localhost:16686.
+{{- end }}
+{{- if .Metrics }}
+Metrics are displayed by Prometheus at http://localhost:9090, e.g. graphql_request_duration_ms_bucket.
+{{- end }}
+`
@@ -0,0 +1,102 @@
+// Command gqlmanifest builds the manifest of persisted queries known to clients, to be used as an allowlist
+// by the server.
+//
+// Usage:
+//
+//   gqlmanifest [-version v] [-o manifest.json] [-diff previous.json] [-format relay|apollo] [-names pattern,...] path...
+//
+// Paths may be GraphQL documents (.graphql, .gql), relay or apollo persisted query manifests (.json),
+// or directories containing such files. In directories, only JSON files named like manifests are loaded:
+// by default persisted_queries.json, persisted-queries.json and persisted-query-manifest.json.
+// Queries from relay and apollo manifests retain their ID.
+//
+// With -diff, the hashes added and removed compared to a previous manifest are printed as JSON on stdout,
+// or on stderr when the manifest itself is printed on stdout.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/gqlmanifest"
+)
+
+func main() {
+	version := flag.String("version", "", "version of the manifest")
+	output := flag.String("o", "", "output file (default: stdout)")
+	previous := flag.String("diff", "", "previous manifest to compare with")
+	format := flag.String("format", "", "format of JSON manifests: relay or apollo (default: detected)")
+	names := flag.String("names", strings.Join(gqlmanifest.DefaultManifestNames, ","), "comma-separated patterns of the names of JSON manifests in directories")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] path...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch gqlmanifest.Format(*format) {
+	case gqlmanifest.FormatAuto, gqlmanifest.FormatRelay, gqlmanifest.FormatApollo:
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []gqlmanifest.Option{
+		gqlmanifest.WithFormat(gqlmanifest.Format(*format)),
+		gqlmanifest.WithManifestNames(strings.Split(*names, ",")...),
+	}
+	if err := run(os.Stdout, os.Stderr, *version, *output, *previous, flag.Args(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(stdout, stderr io.Writer, version, output, previous string, paths []string, opts []gqlmanifest.Option) error {
+	m := gqlmanifest.New(version)
+	for _, path := range paths {
+		if err := m.AddPath(path, opts...); err != nil {
+			return err
+		}
+	}
+
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		if _, err := stdout.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+	} else if err := ioutil.WriteFile(output, append(buf, '\n'), 0644); err != nil {
+		return err
+	}
+
+	if previous == "" {
+		return nil
+	}
+
+	prev, err := gqlmanifest.Load(previous)
+	if err != nil {
+		return err
+	}
+	diff, err := json.MarshalIndent(m.Diff(prev), "", "  ")
+	if err != nil {
+		return err
+	}
+	out := stdout
+	if output == "" {
+		out = stderr
+	}
+	_, err = fmt.Fprintln(out, string(diff))
+	return err
+}
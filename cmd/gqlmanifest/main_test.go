@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqlmanifest"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// assertGolden compares the output with the golden file in testdata, or rewrites the golden file with -update
+func assertGolden(t *testing.T, name string, output []byte) {
+	golden := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, ioutil.WriteFile(golden, output, 0644))
+	}
+	expected, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(output))
+}
+
+func TestRun(t *testing.T) {
+	paths := []string{"testdata/queries"}
+
+	t.Run("stdout", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run(&stdout, &stderr, "v2", "", "", paths, nil))
+		assertGolden(t, "manifest.golden", stdout.Bytes())
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("diff on stderr", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run(&stdout, &stderr, "v2", "", "testdata/previous.json", paths, nil))
+		assertGolden(t, "manifest.golden", stdout.Bytes())
+		assertGolden(t, "diff.golden", stderr.Bytes())
+	})
+
+	t.Run("output file, diff on stdout", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "gqlmanifest")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		output := filepath.Join(dir, "manifest.json")
+
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run(&stdout, &stderr, "v2", output, "testdata/previous.json", paths, nil))
+		written, err := ioutil.ReadFile(output)
+		require.NoError(t, err)
+		assertGolden(t, "manifest.golden", written)
+		assertGolden(t, "diff.golden", stdout.Bytes())
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("apollo format", func(t *testing.T) {
+		opts := []gqlmanifest.Option{gqlmanifest.WithFormat(gqlmanifest.FormatApollo)}
+		err := run(ioutil.Discard, ioutil.Discard, "v2", "", "", paths, opts)
+		assert.EqualError(t, err, "testdata/queries/persisted_queries.json: not an apollo manifest")
+	})
+}
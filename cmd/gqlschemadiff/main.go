@@ -0,0 +1,105 @@
+// Command gqlschemadiff compares two versions of a schema, and exits with a non-zero status on breaking changes.
+//
+// Usage:
+//
+//   gqlschemadiff [-json] [-fail-on breaking|dangerous] 'previous/*.graphqls' 'current/*.graphqls'
+//
+// Each version of the schema is given as a glob matching its files. Changes are printed on stdout, one per line,
+// or as JSON with -json. The exit status is 1 when some change is at least as critical as -fail-on,
+// and 2 on usage errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlschemadiff"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print changes as JSON")
+	failOn := flag.String("fail-on", "breaking", "criticality failing the comparison: breaking or dangerous")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] previous current\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	level, err := gqlschemadiff.ParseCriticality(*failOn)
+	if err != nil || level == gqlschemadiff.Safe || flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed, err := run(os.Stdout, flag.Arg(0), flag.Arg(1), *asJSON, level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, previousGlob, currentGlob string, asJSON bool, level gqlschemadiff.Criticality) (bool, error) {
+	previous, err := loadSchema(previousGlob)
+	if err != nil {
+		return false, err
+	}
+	current, err := loadSchema(currentGlob)
+	if err != nil {
+		return false, err
+	}
+
+	changes := gqlschemadiff.Compare(previous, current)
+	if asJSON {
+		if changes == nil {
+			changes = gqlschemadiff.Changes{}
+		}
+		buf, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		if _, err := w.Write(append(buf, '\n')); err != nil {
+			return false, err
+		}
+	} else {
+		for _, change := range changes {
+			fmt.Fprintln(w, change)
+		}
+	}
+	return len(changes.AtLeast(level)) > 0, nil
+}
+
+func loadSchema(glob string) (*ast.Schema, error) {
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no schema file matches %s", glob)
+	}
+
+	sources := make([]*ast.Source, 0, len(files))
+	for _, file := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &ast.Source{Name: file, Input: string(buf)})
+	}
+
+	schema, gqlErr := gqlparser.LoadSchema(sources...)
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	return schema, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqlschemadiff"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// assertGolden compares the output with the golden file in testdata, or rewrites the golden file with -update
+func assertGolden(t *testing.T, name string, output []byte) {
+	golden := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, ioutil.WriteFile(golden, output, 0644))
+	}
+	expected, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(output))
+}
+
+func TestRun(t *testing.T) {
+	const (
+		previous  = "testdata/previous/*.graphqls"
+		current   = "testdata/current/*.graphqls"
+		dangerous = "testdata/dangerous/*.graphqls"
+	)
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		failed, err := run(&out, previous, current, false, gqlschemadiff.Breaking)
+		require.NoError(t, err)
+		assert.True(t, failed)
+		assertGolden(t, "changes.golden", out.Bytes())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		failed, err := run(&out, previous, current, true, gqlschemadiff.Breaking)
+		require.NoError(t, err)
+		assert.True(t, failed)
+		assertGolden(t, "changes.json.golden", out.Bytes())
+	})
+
+	t.Run("fail on dangerous", func(t *testing.T) {
+		var out bytes.Buffer
+		failed, err := run(&out, previous, dangerous, false, gqlschemadiff.Breaking)
+		require.NoError(t, err)
+		assert.False(t, failed)
+		assertGolden(t, "dangerous.golden", out.Bytes())
+
+		failed, err = run(ioutil.Discard, previous, dangerous, false, gqlschemadiff.Dangerous)
+		require.NoError(t, err)
+		assert.True(t, failed)
+	})
+
+	t.Run("no change", func(t *testing.T) {
+		var out bytes.Buffer
+		failed, err := run(&out, previous, previous, true, gqlschemadiff.Dangerous)
+		require.NoError(t, err)
+		assert.False(t, failed)
+		assert.Equal(t, "[]\n", out.String())
+	})
+
+	t.Run("no schema", func(t *testing.T) {
+		_, err := run(ioutil.Discard, "testdata/missing/*.graphqls", current, false, gqlschemadiff.Breaking)
+		assert.EqualError(t, err, "no schema file matches testdata/missing/*.graphqls")
+	})
+}
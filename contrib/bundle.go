@@ -0,0 +1,201 @@
+// Package contrib composes the extensions of this repository into a bundle, sharing common options,
+// and registers them on a gqlgen server in the order they must intercept operations.
+//
+// Shared options are:
+//   - the redaction policy (a gqlpii engine), masking PII in traces and required by data access logs
+//   - the naming of metrics (see gqlmetrics), applied before metrics are registered
+//   - the identification of clients, stored in the operation bag (see gqlctx) for all extensions to use
+//
+// Example:
+//
+//   engine, _ := gqlpii.New(generated.NewExecutableSchema(cfg).Schema())
+//   bundle := contrib.New(
+//     contrib.WithRedaction(engine),
+//     contrib.WithNaming(gqlmetrics.Config{Namespace: "acme"}),
+//     contrib.WithClientHeaders("apollographql-client-name", "apollographql-client-version"),
+//     contrib.WithTracing(gqlopencensus.WithSamplingRate(0.1)),
+//     contrib.WithMetrics(),
+//     contrib.WithLogging(sink),
+//     contrib.WithBudget(gqlbudget.WithTimeout(2*time.Second)),
+//   )
+//   if err := bundle.Register(srv); err != nil {
+//     log.Fatal(err)
+//   }
+//   http.Handle("/query", bundle.Middleware(srv))
+//
+// Extensions are registered in this order, the first one wrapping the others: the operation bag, client
+// identification, tracing, metrics, data access logging, then limits (time budget and bulkhead).
+// Tracing and metrics thereby account for the time spent waiting on limits.
+package contrib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlbudget"
+	"github.com/99designs/gqlgen-contrib/gqlbulkhead"
+	"github.com/99designs/gqlgen-contrib/gqlctx"
+	"github.com/99designs/gqlgen-contrib/gqldataaccess"
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const clientExtensionName = "ClientIdentification"
+
+// ErrLoggingWithoutRedaction is returned when data access logging is enabled without a redaction policy
+var ErrLoggingWithoutRedaction = errors.New("data access logging requires a redaction policy (see WithRedaction)")
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &clientIdentifier{}
+
+type (
+	// Server registers extensions, e.g. *handler.Server
+	Server interface {
+		Use(graphql.HandlerExtension)
+	}
+
+	// Bundle of extensions, built with shared options
+	Bundle struct {
+		*config
+		logger *gqldataaccess.Logger
+	}
+
+	// clientIdentifier stores the client of each operation in its bag
+	clientIdentifier struct {
+		info func(context.Context) (name, version string)
+	}
+
+	clientInfo struct {
+		name    string
+		version string
+	}
+
+	clientKey struct{}
+)
+
+// New Bundle of extensions. Only the extensions enabled by options are included.
+func New(opts ...Option) *Bundle {
+	b := &Bundle{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(b.config)
+	}
+	if b.config.logging && b.config.redaction != nil {
+		b.logger = gqldataaccess.New(b.config.redaction, b.config.sink, b.config.loggingOptions...)
+	}
+	return b
+}
+
+// Extensions yields the extensions of the bundle, in registration order
+func (b *Bundle) Extensions() ([]graphql.HandlerExtension, error) {
+	if b.config.logging && b.config.redaction == nil {
+		return nil, ErrLoggingWithoutRedaction
+	}
+
+	extensions := []graphql.HandlerExtension{gqlctx.New()}
+	if b.config.clientInfo != nil || b.config.clientNameHeader != "" {
+		extensions = append(extensions, &clientIdentifier{info: b.config.clientInfo})
+	}
+	if b.config.tracing {
+		opts := b.config.tracingOptions
+		if b.config.redaction != nil {
+			opts = append([]gqlopencensus.Option{gqlopencensus.WithPII(b.config.redaction)}, opts...)
+		}
+		extensions = append(extensions, gqlopencensus.New(opts...))
+	}
+	if b.config.metrics {
+		extensions = append(extensions, metrics.New(b.config.metricsOptions...))
+	}
+	if b.logger != nil {
+		extensions = append(extensions, b.logger)
+	}
+	if b.config.budget {
+		extensions = append(extensions, gqlbudget.New(b.config.budgetOptions...))
+	}
+	if b.config.bulkhead {
+		extensions = append(extensions, gqlbulkhead.New(b.config.bulkheadOptions...))
+	}
+	return extensions, nil
+}
+
+// Register the extensions of the bundle on a server.
+//
+// When metrics are enabled, the naming of metrics is applied, then the metrics views are registered:
+// this must happen once per process.
+func (b *Bundle) Register(srv Server) error {
+	extensions, err := b.Extensions()
+	if err != nil {
+		return err
+	}
+
+	if b.config.metrics {
+		if b.config.naming != nil {
+			if err := gqlmetrics.SetConfig(*b.config.naming); err != nil {
+				return err
+			}
+		}
+		if err := metrics.Register(); err != nil {
+			return err
+		}
+		if b.config.bulkhead {
+			if err := gqlbulkhead.Register(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, extension := range extensions {
+		srv.Use(extension)
+	}
+	return nil
+}
+
+// Middleware wraps the gqlgen handler with the HTTP middleware required by the bundle: capture of the
+// client headers (see WithClientHeaders) and of the data access logging headers.
+func (b *Bundle) Middleware(next http.Handler) http.Handler {
+	if b.logger != nil {
+		next = b.logger.Middleware(next)
+	}
+	if b.config.clientNameHeader == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientInfo{
+			name:    r.Header.Get(b.config.clientNameHeader),
+			version: r.Header.Get(b.config.clientVersionHeader),
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, client)))
+	})
+}
+
+// ExtensionName yields the extension name: "ClientIdentification"
+func (*clientIdentifier) ExtensionName() string {
+	return clientExtensionName
+}
+
+// Validate this extension. This is a noop
+func (*clientIdentifier) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation sets the client and client version of the operation in its bag
+func (c *clientIdentifier) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	client, _ := ctx.Value(clientKey{}).(clientInfo)
+	if c.info != nil {
+		client.name, client.version = c.info(ctx)
+	}
+	if client.name != "" {
+		gqlctx.Set(ctx, gqlctx.Client, client.name)
+	}
+	if client.version != "" {
+		gqlctx.Set(ctx, gqlctx.ClientVersion, client.version)
+	}
+	return next(ctx)
+}
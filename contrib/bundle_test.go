@@ -0,0 +1,76 @@
+package contrib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqlctx"
+	"github.com/99designs/gqlgen-contrib/gqldataaccess"
+)
+
+type testServer struct {
+	names []string
+}
+
+func (s *testServer) Use(extension graphql.HandlerExtension) {
+	s.names = append(s.names, extension.ExtensionName())
+}
+
+func TestBundleOrder(t *testing.T) {
+	bundle := New(
+		WithBudget(),
+		WithMetrics(),
+		WithClientHeaders("x-client", "x-client-version"),
+		WithTracing(),
+	)
+
+	srv := &testServer{}
+	require.NoError(t, bundle.Register(srv))
+	assert.Equal(t, []string{
+		"OperationBag",
+		"ClientIdentification",
+		"Opencensustracing",
+		"OpencensusMetrics",
+		"TimeBudget",
+	}, srv.names)
+}
+
+func TestBundleLoggingRequiresRedaction(t *testing.T) {
+	sink := gqldataaccess.SinkFunc(func(context.Context, gqldataaccess.Record) error { return nil })
+	bundle := New(WithLogging(sink))
+
+	err := bundle.Register(&testServer{})
+	assert.Equal(t, ErrLoggingWithoutRedaction, err)
+}
+
+func TestClientIdentification(t *testing.T) {
+	bundle := New(WithClientHeaders("x-client", "x-client-version"))
+	extensions, err := bundle.Extensions()
+	require.NoError(t, err)
+	require.Len(t, extensions, 2)
+	identifier := extensions[1].(graphql.OperationInterceptor)
+
+	var client, version string
+	handler := bundle.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx := gqlctx.WithBag(r.Context())
+		identifier.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+			client = gqlctx.String(ctx, gqlctx.Client)
+			version = gqlctx.String(ctx, gqlctx.ClientVersion)
+			return nil
+		})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("x-client", "web")
+	req.Header.Set("x-client-version", "1.2.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "web", client)
+	assert.Equal(t, "1.2.0", version)
+}
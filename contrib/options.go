@@ -0,0 +1,115 @@
+package contrib
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/gqlbudget"
+	"github.com/99designs/gqlgen-contrib/gqlbulkhead"
+	"github.com/99designs/gqlgen-contrib/gqldataaccess"
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlpii"
+)
+
+type (
+	// Option for the bundle
+	Option func(*config)
+
+	config struct {
+		redaction           *gqlpii.Engine
+		naming              *gqlmetrics.Config
+		clientNameHeader    string
+		clientVersionHeader string
+		clientInfo          func(context.Context) (string, string)
+
+		tracing         bool
+		tracingOptions  []gqlopencensus.Option
+		metrics         bool
+		metricsOptions  []metrics.Option
+		logging         bool
+		sink            gqldataaccess.Sink
+		loggingOptions  []gqldataaccess.Option
+		budget          bool
+		budgetOptions   []gqlbudget.Option
+		bulkhead        bool
+		bulkheadOptions []gqlbulkhead.Option
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithRedaction sets the redaction policy shared by extensions: PII is masked in traces,
+// and accesses to PII are logged when logging is enabled.
+func WithRedaction(engine *gqlpii.Engine) Option {
+	return func(c *config) {
+		c.redaction = engine
+	}
+}
+
+// WithNaming sets the naming of metrics, applied by Register before registering metrics
+func WithNaming(naming gqlmetrics.Config) Option {
+	return func(c *config) {
+		c.naming = &naming
+	}
+}
+
+// WithClientHeaders identifies clients by request headers, captured by the Middleware
+func WithClientHeaders(name, version string) Option {
+	return func(c *config) {
+		c.clientNameHeader = name
+		c.clientVersionHeader = version
+	}
+}
+
+// WithClientInfo identifies clients from the context, e.g. from an API key.
+// This takes precedence over the client headers.
+func WithClientInfo(info func(context.Context) (name, version string)) Option {
+	return func(c *config) {
+		c.clientInfo = info
+	}
+}
+
+// WithTracing enables opencensus tracing (see gqlopencensus)
+func WithTracing(opts ...gqlopencensus.Option) Option {
+	return func(c *config) {
+		c.tracing = true
+		c.tracingOptions = opts
+	}
+}
+
+// WithMetrics enables opencensus metrics (see gqlopencensus-metrics)
+func WithMetrics(opts ...metrics.Option) Option {
+	return func(c *config) {
+		c.metrics = true
+		c.metricsOptions = opts
+	}
+}
+
+// WithLogging enables the logging of accesses to PII to a sink (see gqldataaccess).
+// This requires a redaction policy.
+func WithLogging(sink gqldataaccess.Sink, opts ...gqldataaccess.Option) Option {
+	return func(c *config) {
+		c.logging = true
+		c.sink = sink
+		c.loggingOptions = opts
+	}
+}
+
+// WithBudget enables time budgets of operations (see gqlbudget)
+func WithBudget(opts ...gqlbudget.Option) Option {
+	return func(c *config) {
+		c.budget = true
+		c.budgetOptions = opts
+	}
+}
+
+// WithBulkhead enables concurrency limits of resolvers (see gqlbulkhead)
+func WithBulkhead(opts ...gqlbulkhead.Option) Option {
+	return func(c *config) {
+		c.bulkhead = true
+		c.bulkheadOptions = opts
+	}
+}
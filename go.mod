@@ -4,13 +4,11 @@ go 1.13
 
 require (
 	github.com/99designs/gqlgen v0.11.3
-	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
+	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e
 	github.com/golang/protobuf v1.4.2 // indirect
-	github.com/opentracing/opentracing-go v1.1.0
-	github.com/prometheus/client_golang v1.6.0
-	github.com/prometheus/common v0.10.0 // indirect
 	github.com/stretchr/testify v1.4.0
 	github.com/vektah/gqlparser/v2 v2.0.1
 	go.opencensus.io v0.22.3
 	golang.org/x/sys v0.0.0-20200515095857-1151b9dac4a9 // indirect
+	gopkg.in/yaml.v2 v2.2.5
 )
@@ -0,0 +1,275 @@
+// Package gqlanomaly provides a gqlgen extension detecting anomalies in the traffic of GraphQL operations,
+// for lightweight in-process alerting.
+//
+// The Detector keeps a baseline per operation of the request rate, the mean latency and the error ratio,
+// as exponentially weighted moving averages and variances. At the end of each window, the traffic of the window
+// is compared to the baseline: deviations beyond a number of standard deviations are reported as Anomaly events
+// to handlers.
+//
+// Example:
+//
+//   detector := gqlanomaly.New(
+//     gqlanomaly.WithWindow(10*time.Second),
+//     gqlanomaly.WithHandler(func(a gqlanomaly.Anomaly) {
+//       log.Printf("anomaly on %s: %s is %.2f, expected %.2f", a.Operation, a.Kind, a.Value, a.Baseline)
+//     }),
+//   )
+//   srv.Use(detector)
+//   go detector.Run(ctx)
+package gqlanomaly
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "AnomalyDetector"
+
+// Kinds of anomalies
+const (
+	KindRateSpike  Kind = "rate_spike"
+	KindRateDrop   Kind = "rate_drop"
+	KindLatency    Kind = "latency"
+	KindErrorRatio Kind = "error_ratio"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Detector{}
+
+type (
+	// Kind of anomaly
+	Kind string
+
+	// Anomaly detected on the traffic of an operation
+	Anomaly struct {
+		Time      time.Time
+		Operation string
+		Kind      Kind
+
+		// Value observed during the window: requests per second, mean latency in milliseconds, or error ratio
+		Value float64
+
+		// Baseline value, and its standard deviation
+		Baseline float64
+		StdDev   float64
+
+		// Deviation from the baseline, as a number of standard deviations
+		Deviation float64
+	}
+
+	// Detector is a gqlgen extension detecting anomalies in the traffic of operations
+	Detector struct {
+		*config
+
+		mx        sync.Mutex
+		current   map[string]*window
+		baselines map[string]*baseline
+	}
+
+	// window of traffic of an operation
+	window struct {
+		count   int64
+		errors  int64
+		latency time.Duration
+	}
+
+	// baseline of the traffic of an operation
+	baseline struct {
+		windows    int
+		rate       ewma
+		latency    ewma
+		errorRatio ewma
+	}
+
+	// ewma is an exponentially weighted moving average and variance
+	ewma struct {
+		mean     float64
+		variance float64
+		seeded   bool
+	}
+)
+
+// New anomaly Detector
+func New(opts ...Option) *Detector {
+	d := &Detector{
+		config:    defaultConfig(),
+		current:   make(map[string]*window),
+		baselines: make(map[string]*baseline),
+	}
+	for _, apply := range opts {
+		apply(d.config)
+	}
+	return d
+}
+
+// ExtensionName yields the extension name: "AnomalyDetector"
+func (*Detector) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Detector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (d *Detector) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	start := d.config.clock()
+	resp := next(ctx)
+	if resp == nil {
+		// the final pull of websocket transports, past the response of the operation
+		return resp
+	}
+	latency := d.config.clock().Sub(start)
+
+	failed := len(resp.Errors) > 0
+	d.record(d.config.opLabel(oc), latency, failed)
+	return resp
+}
+
+// Run evaluates the traffic at the end of each window, until the context is done
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Evaluate()
+		}
+	}
+}
+
+// Evaluate the traffic of the current window against the baselines, then update the baselines and start a new window.
+//
+// Evaluate is called by Run at the end of each window: call it directly when driving windows otherwise.
+func (d *Detector) Evaluate() {
+	d.mx.Lock()
+	current := d.current
+	d.current = make(map[string]*window)
+
+	now := d.config.clock()
+	var anomalies []Anomaly
+	for operation, b := range d.baselines {
+		w, ok := current[operation]
+		if !ok {
+			w = &window{}
+		}
+		anomalies = append(anomalies, d.evaluate(now, operation, b, w)...)
+	}
+	for operation, w := range current {
+		if _, ok := d.baselines[operation]; !ok {
+			b := &baseline{}
+			d.baselines[operation] = b
+			d.evaluate(now, operation, b, w)
+		}
+	}
+	d.mx.Unlock()
+
+	for _, anomaly := range anomalies {
+		for _, handle := range d.config.handlers {
+			handle(anomaly)
+		}
+	}
+}
+
+func (d *Detector) record(operation string, latency time.Duration, failed bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	w, ok := d.current[operation]
+	if !ok {
+		w = &window{}
+		d.current[operation] = w
+	}
+	w.count++
+	w.latency += latency
+	if failed {
+		w.errors++
+	}
+}
+
+// evaluate a window against the baseline of an operation, then update the baseline
+func (d *Detector) evaluate(now time.Time, operation string, b *baseline, w *window) []Anomaly {
+	var anomalies []Anomaly
+	report := func(kind Kind, value float64, e ewma, sd float64) {
+		anomalies = append(anomalies, Anomaly{
+			Time:      now,
+			Operation: operation,
+			Kind:      kind,
+			Value:     value,
+			Baseline:  e.mean,
+			StdDev:    sd,
+			Deviation: (value - e.mean) / sd,
+		})
+	}
+
+	warm := b.windows >= d.config.warmup
+	alpha := d.config.alpha
+
+	// anomalous values are clamped to the threshold when updating the baseline, so that a single outlier
+	// does not blow up the variance and mask the next anomalies, while a lasting shift is eventually learned
+	threshold := d.config.threshold
+	rate := float64(w.count) / d.config.window.Seconds()
+	if warm {
+		sd := math.Max(b.rate.stdDev(), math.Max(0.1*b.rate.mean, 1/d.config.window.Seconds()))
+		switch {
+		case rate > b.rate.mean+threshold*sd:
+			report(KindRateSpike, rate, b.rate, sd)
+			rate = b.rate.mean + threshold*sd
+		case rate < b.rate.mean-threshold*sd:
+			report(KindRateDrop, rate, b.rate, sd)
+			rate = b.rate.mean - threshold*sd
+		}
+	}
+	b.rate.update(rate, alpha)
+
+	if w.count >= d.config.minRequests {
+		latency := float64(w.latency) / float64(w.count) / float64(time.Millisecond)
+		if warm {
+			sd := math.Max(b.latency.stdDev(), 0.1*b.latency.mean)
+			if sd > 0 && latency > b.latency.mean+threshold*sd {
+				report(KindLatency, latency, b.latency, sd)
+				latency = b.latency.mean + threshold*sd
+			}
+		}
+		b.latency.update(latency, alpha)
+
+		errorRatio := float64(w.errors) / float64(w.count)
+		if warm {
+			sd := math.Max(b.errorRatio.stdDev(), 0.01)
+			if errorRatio > b.errorRatio.mean+threshold*sd {
+				report(KindErrorRatio, errorRatio, b.errorRatio, sd)
+				errorRatio = b.errorRatio.mean + threshold*sd
+			}
+		}
+		b.errorRatio.update(errorRatio, alpha)
+	}
+
+	b.windows++
+	return anomalies
+}
+
+// update the average with a value. The first value seeds the average, which does not ramp up from 0.
+func (e *ewma) update(value, alpha float64) {
+	if !e.seeded {
+		e.mean, e.seeded = value, true
+		return
+	}
+	diff := value - e.mean
+	e.mean += alpha * diff
+	e.variance = (1 - alpha) * (e.variance + alpha*diff*diff)
+}
+
+func (e ewma) stdDev() float64 {
+	return math.Sqrt(e.variance)
+}
@@ -0,0 +1,108 @@
+package gqlanomaly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestDetector(t *testing.T) {
+	var (
+		now       = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		latency   = 10 * time.Millisecond
+		anomalies []Anomaly
+	)
+	clock := func() time.Time {
+		return now
+	}
+	d := New(
+		WithWindow(time.Second),
+		WithWarmup(5),
+		WithClock(clock),
+		WithHandler(func(a Anomaly) {
+			anomalies = append(anomalies, a)
+		}),
+	)
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "todos"})
+	run := func(requests, errors int) {
+		for i := 0; i < requests; i++ {
+			failed := i < errors
+			d.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+				now = now.Add(latency)
+				if failed {
+					return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("failed")}}
+				}
+				return &graphql.Response{}
+			})
+		}
+		d.Evaluate()
+	}
+
+	// steady traffic establishes the baseline
+	for i := 0; i < 10; i++ {
+		run(20, 0)
+	}
+	require.Empty(t, anomalies)
+
+	run(100, 0)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, KindRateSpike, anomalies[0].Kind)
+	assert.Equal(t, "todos", anomalies[0].Operation)
+	assert.Equal(t, float64(100), anomalies[0].Value)
+
+	anomalies = nil
+	latency = 100 * time.Millisecond
+	run(20, 10)
+	kinds := make([]Kind, 0, len(anomalies))
+	for _, a := range anomalies {
+		kinds = append(kinds, a.Kind)
+	}
+	assert.ElementsMatch(t, []Kind{KindLatency, KindErrorRatio}, kinds)
+
+	anomalies = nil
+	d.Evaluate()
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, KindRateDrop, anomalies[0].Kind)
+}
+
+func TestDetectorPullsUntilNil(t *testing.T) {
+	var (
+		now       = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		anomalies []Anomaly
+	)
+	d := New(
+		WithWindow(time.Second),
+		WithWarmup(5),
+		WithClock(func() time.Time { return now }),
+		WithHandler(func(a Anomaly) {
+			anomalies = append(anomalies, a)
+		}),
+	)
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "todos"})
+	run := func(requests int, pullUntilNil bool) {
+		for i := 0; i < requests; i++ {
+			d.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+				now = now.Add(10 * time.Millisecond)
+				return &graphql.Response{}
+			})
+			if pullUntilNil {
+				// websocket transports pull responses until nil
+				assert.Nil(t, d.InterceptResponse(ctx, func(context.Context) *graphql.Response { return nil }))
+			}
+		}
+		d.Evaluate()
+	}
+
+	for i := 0; i < 10; i++ {
+		run(20, false)
+	}
+	run(20, true)
+	assert.Empty(t, anomalies)
+}
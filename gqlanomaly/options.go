@@ -0,0 +1,100 @@
+package gqlanomaly
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the anomaly detector
+	Option func(*config)
+
+	config struct {
+		window      time.Duration
+		alpha       float64
+		threshold   float64
+		warmup      int
+		minRequests int64
+		handlers    []func(Anomaly)
+		opLabel     gqllabel.OperationLabeler
+		clock       func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		window:      10 * time.Second,
+		alpha:       0.1,
+		threshold:   3,
+		warmup:      10,
+		minRequests: 10,
+		opLabel:     gqllabel.OperationName,
+		clock:       graphql.Now,
+	}
+}
+
+// WithWindow sets the duration of the windows of traffic compared to the baselines. The default is 10s.
+func WithWindow(window time.Duration) Option {
+	return func(c *config) {
+		c.window = window
+	}
+}
+
+// WithAlpha sets the smoothing factor of the baselines, between 0 and 1. Higher values adapt faster
+// to changes in traffic. The default is 0.1.
+func WithAlpha(alpha float64) Option {
+	return func(c *config) {
+		c.alpha = alpha
+	}
+}
+
+// WithThreshold sets the number of standard deviations from the baseline beyond which traffic is anomalous.
+// The default is 3.
+func WithThreshold(threshold float64) Option {
+	return func(c *config) {
+		c.threshold = threshold
+	}
+}
+
+// WithWarmup sets the number of windows observed before anomalies are reported for an operation. The default is 10.
+func WithWarmup(windows int) Option {
+	return func(c *config) {
+		c.warmup = windows
+	}
+}
+
+// WithMinRequests sets the minimum number of requests in a window to evaluate the latency and error ratio
+// of an operation. The default is 10.
+func WithMinRequests(requests int64) Option {
+	return func(c *config) {
+		c.minRequests = requests
+	}
+}
+
+// WithHandler adds a handler of anomalies, e.g. to log them or to publish events.
+//
+// Handlers are called synchronously at the end of windows, and should not block.
+func WithHandler(handlers ...func(Anomaly)) Option {
+	return func(c *config) {
+		c.handlers = append(c.handlers, handlers...)
+	}
+}
+
+// WithOperationLabel sets the function identifying operations. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the number of baselines.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
+
+// WithClock sets the clock, e.g. for tests. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,201 @@
+// Package gqlapikey provides a middleware authenticating requests with API keys, validated against a pluggable store.
+//
+// Keys carry scopes and a rate tier, which are exposed through the context to resolvers and other extensions,
+// e.g. to meter usage by client or to assign operations to priority tiers:
+//
+//   auth := gqlapikey.New(gqlapikey.NewCachedStore(gqlapikey.NewSQLStore(db), time.Minute))
+//   srv.Use(gqlmetering.New(sink, gqlmetering.WithClient(gqlapikey.ClientID)))
+//   srv.Use(gqlqueue.New(
+//     gqlqueue.WithTier("gold", 50, 100),
+//     gqlqueue.WithClassifier(func(ctx context.Context, _ *graphql.OperationContext) string { return gqlapikey.Tier(ctx) }),
+//   ))
+//   http.Handle("/query", auth.Middleware(srv))
+//
+// In resolvers:
+//
+//   if !gqlapikey.HasScope(ctx, "orders:write") {
+//     return nil, errors.New("missing scope orders:write")
+//   }
+//
+// Stores only know the SHA-256 hash of keys (see Hash), so that leaking a store does not leak usable keys.
+package gqlapikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// ErrNotFound is returned by stores when a key is not found
+var ErrNotFound = errors.New("api key not found")
+
+// Results of the authentication of requests, reported as a tag of metrics
+const (
+	ResultOK       = "ok"
+	ResultMissing  = "missing"
+	ResultInvalid  = "invalid"
+	ResultExpired  = "expired"
+	ResultDisabled = "disabled"
+	ResultError    = "error"
+)
+
+type (
+	// Key is an API key, as known by stores
+	Key struct {
+		// ID identifies the key, e.g. in metrics and logs. It is not secret.
+		ID    string `yaml:"id"`
+		Name  string `yaml:"name"`
+		Owner string `yaml:"owner"`
+
+		Scopes []string `yaml:"scopes"`
+
+		// Tier of rate limits or priority of the key, e.g. "free" or "gold"
+		Tier string `yaml:"tier"`
+
+		// Expires is the expiry date of the key. A zero time means that the key does not expire.
+		Expires  time.Time `yaml:"expires"`
+		Disabled bool      `yaml:"disabled"`
+	}
+
+	// Store looks up keys by hash (see Hash). It returns ErrNotFound for unknown keys.
+	Store interface {
+		Lookup(ctx context.Context, hash string) (Key, error)
+	}
+
+	// StoreFunc is a function implementing Store
+	StoreFunc func(ctx context.Context, hash string) (Key, error)
+
+	// Authenticator authenticates requests with API keys
+	Authenticator struct {
+		*config
+		store Store
+	}
+
+	keyKey struct{}
+)
+
+// Lookup implements Store
+func (f StoreFunc) Lookup(ctx context.Context, hash string) (Key, error) {
+	return f(ctx, hash)
+}
+
+// Hash yields the hex-encoded SHA-256 hash of a raw key, as known by stores
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// HasScope tells if the key has a scope
+func (k Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// New Authenticator, validating keys against a store
+func New(store Store, opts ...Option) *Authenticator {
+	a := &Authenticator{
+		config: defaultConfig(),
+		store:  store,
+	}
+	for _, apply := range opts {
+		apply(a.config)
+	}
+	return a
+}
+
+// Middleware authenticates requests with the API key of their header.
+//
+// Requests with an unknown, expired or disabled key are rejected with a 401 status. Requests without key
+// are rejected too, unless keys are optional. Requests are rejected with a 503 status when the store fails.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, result := a.authenticate(r)
+		_ = stats.RecordWithTags(r.Context(), []tag.Mutator{
+			tag.Upsert(TagKey, key.ID),
+			tag.Upsert(TagTier, key.Tier),
+			tag.Upsert(TagResult, result),
+		}, RequestCount.M(1))
+
+		switch result {
+		case ResultOK:
+			next.ServeHTTP(w, r.WithContext(WithKey(r.Context(), key)))
+		case ResultMissing:
+			if a.config.optional {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		case ResultError:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		}
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (Key, string) {
+	raw := strings.TrimSpace(r.Header.Get(a.config.header))
+	if raw != "" && a.config.scheme != "" {
+		if !strings.HasPrefix(strings.ToLower(raw), strings.ToLower(a.config.scheme)+" ") {
+			return Key{}, ResultInvalid
+		}
+		raw = strings.TrimSpace(raw[len(a.config.scheme)+1:])
+	}
+	if raw == "" {
+		return Key{}, ResultMissing
+	}
+
+	key, err := a.store.Lookup(r.Context(), Hash(raw))
+	switch {
+	case err == ErrNotFound:
+		return Key{}, ResultInvalid
+	case err != nil:
+		return Key{}, ResultError
+	case key.Disabled:
+		return key, ResultDisabled
+	case !key.Expires.IsZero() && !a.config.clock().Before(key.Expires):
+		return key, ResultExpired
+	default:
+		return key, ResultOK
+	}
+}
+
+// WithKey sets the API key of a request in a context, e.g. in tests
+func WithKey(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext yields the API key of a request, if any
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(keyKey{}).(Key)
+	return key, ok
+}
+
+// ClientID yields the ID of the API key of a request, or an empty string
+func ClientID(ctx context.Context) string {
+	key, _ := FromContext(ctx)
+	return key.ID
+}
+
+// Tier yields the tier of the API key of a request, or an empty string
+func Tier(ctx context.Context) string {
+	key, _ := FromContext(ctx)
+	return key.Tier
+}
+
+// HasScope tells if the API key of a request has a scope
+func HasScope(ctx context.Context, scope string) bool {
+	key, ok := FromContext(ctx)
+	return ok && key.HasScope(scope)
+}
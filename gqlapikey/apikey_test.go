@@ -0,0 +1,178 @@
+package gqlapikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type redisClient map[string]map[string]string
+
+func (c redisClient) HGetAll(_ context.Context, key string) (map[string]string, error) {
+	return c[key], nil
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	store, err := NewStaticStore(
+		StaticKey{Key: Key{ID: "billing", Scopes: []string{"orders:read"}, Tier: "gold"}, Raw: "k1"},
+		StaticKey{Key: Key{ID: "old", Expires: now.Add(-time.Hour)}, Raw: "k2"},
+		StaticKey{Key: Key{ID: "revoked", Disabled: true}, Hash: Hash("k3")},
+	)
+	require.NoError(t, err)
+
+	var (
+		key   Key
+		found bool
+	)
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		key, found = FromContext(r.Context())
+		assert.Equal(t, key.ID, ClientID(r.Context()))
+		assert.Equal(t, key.Tier, Tier(r.Context()))
+	})
+	serve := func(a *Authenticator, header, value string) int {
+		key, found = Key{}, false
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		if value != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		a.Middleware(next).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	a := New(store, WithClock(func() time.Time { return now }))
+	assert.Equal(t, http.StatusOK, serve(a, "X-Api-Key", "k1"))
+	require.True(t, found)
+	assert.Equal(t, "billing", key.ID)
+	assert.True(t, key.HasScope("orders:read"))
+	assert.False(t, key.HasScope("orders:write"))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(a, "X-Api-Key", "k2"), "expired")
+	assert.Equal(t, http.StatusUnauthorized, serve(a, "X-Api-Key", "k3"), "disabled")
+	assert.Equal(t, http.StatusUnauthorized, serve(a, "X-Api-Key", "unknown"))
+	assert.Equal(t, http.StatusUnauthorized, serve(a, "X-Api-Key", ""))
+
+	optional := New(store, WithOptional(true))
+	assert.Equal(t, http.StatusOK, serve(optional, "X-Api-Key", ""))
+	assert.False(t, found)
+	assert.Equal(t, http.StatusUnauthorized, serve(optional, "X-Api-Key", "unknown"))
+
+	scheme := New(store, WithHeader("Authorization", "ApiKey"))
+	assert.Equal(t, http.StatusOK, serve(scheme, "Authorization", "apikey k1"))
+	assert.Equal(t, http.StatusUnauthorized, serve(scheme, "Authorization", "Bearer k1"))
+
+	failing := New(StoreFunc(func(context.Context, string) (Key, error) { return Key{}, errors.New("down") }))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(failing, "X-Api-Key", "k1"))
+}
+
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("static file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "gqlapikey")
+		require.NoError(t, err)
+		defer func() { _ = os.RemoveAll(dir) }()
+
+		path := filepath.Join(dir, "keys.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`
+keys:
+  - id: billing
+    hash: `+Hash("k1")+`
+    scopes: [orders:read, orders:write]
+    tier: gold
+    expires: 2021-01-01T00:00:00Z
+`), 0600))
+
+		store, err := LoadStaticStore(path)
+		require.NoError(t, err)
+		key, err := store.Lookup(ctx, Hash("k1"))
+		require.NoError(t, err)
+		assert.Equal(t, Key{
+			ID:      "billing",
+			Scopes:  []string{"orders:read", "orders:write"},
+			Tier:    "gold",
+			Expires: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, key)
+
+		_, err = store.Lookup(ctx, Hash("k2"))
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		store := NewRedisStore(redisClient{
+			"apikey:" + Hash("k1"): {"id": "search", "scopes": "a, b", "expires": "2021-01-01T00:00:00Z", "disabled": "false"},
+		})
+		key, err := store.Lookup(ctx, Hash("k1"))
+		require.NoError(t, err)
+		assert.Equal(t, "search", key.ID)
+		assert.Equal(t, []string{"a", "b"}, key.Scopes)
+
+		_, err = store.Lookup(ctx, Hash("k2"))
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		lookups := 0
+		store := NewCachedStore(StoreFunc(func(_ context.Context, hash string) (Key, error) {
+			lookups++
+			if hash == Hash("k1") {
+				return Key{ID: "billing"}, nil
+			}
+			return Key{}, ErrNotFound
+		}), time.Minute)
+		now := time.Now()
+		store.clock = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			key, err := store.Lookup(ctx, Hash("k1"))
+			require.NoError(t, err)
+			assert.Equal(t, "billing", key.ID)
+			_, err = store.Lookup(ctx, Hash("k2"))
+			assert.Equal(t, ErrNotFound, err)
+		}
+		assert.Equal(t, 2, lookups)
+
+		now = now.Add(2 * time.Minute)
+		_, _ = store.Lookup(ctx, Hash("k1"))
+		assert.Equal(t, 3, lookups)
+	})
+
+	t.Run("cache is bounded", func(t *testing.T) {
+		lookups := 0
+		store := NewCachedStoreWithSize(StoreFunc(func(_ context.Context, hash string) (Key, error) {
+			lookups++
+			return Key{}, ErrNotFound
+		}), time.Minute, 10)
+		now := time.Now()
+		store.clock = func() time.Time { return now }
+
+		for i := 0; i < 100; i++ {
+			_, err := store.Lookup(ctx, Hash(fmt.Sprintf("random-%d", i)))
+			assert.Equal(t, ErrNotFound, err)
+		}
+		assert.Len(t, store.entries, 10)
+		assert.Equal(t, 10, store.order.Len())
+
+		// the most recent lookups are retained, the oldest ones are evicted
+		_, _ = store.Lookup(ctx, Hash("random-99"))
+		assert.Equal(t, 100, lookups)
+		_, _ = store.Lookup(ctx, Hash("random-0"))
+		assert.Equal(t, 101, lookups)
+
+		// expired lookups are swept
+		now = now.Add(2 * time.Minute)
+		_, _ = store.Lookup(ctx, Hash("random-0"))
+		assert.Len(t, store.entries, 1)
+	})
+}
@@ -0,0 +1,47 @@
+package gqlapikey
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the authenticator.
+func Register() error {
+	return gqlmetrics.Register(RequestCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(RequestCountView)
+}
+
+var (
+	// TagKey is the ID of an API key
+	TagKey = tag.MustNewKey("gql.apikey")
+
+	// TagTier is the tier of an API key
+	TagTier = tag.MustNewKey("gql.apikey_tier")
+
+	// TagResult is the result of the authentication of a request (ok, missing, invalid, expired, disabled or error)
+	TagResult = tag.MustNewKey("gql.apikey_result")
+
+	// RequestCount tracks a count of requests authenticated with API keys
+	RequestCount = stats.Int64(
+		"gql/server/apikey_request_count",
+		"Number of requests authenticated with API keys",
+		stats.UnitDimensionless)
+
+	// RequestCountView reports a count of requests by API key, tier and result
+	RequestCountView = &view.View{
+		Name:        "gql/server/apikey_request_count",
+		Description: "Count of GraphQL requests by API key, tier and authentication result",
+		Measure:     RequestCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagKey, TagTier, TagResult},
+	}
+)
@@ -0,0 +1,49 @@
+package gqlapikey
+
+import (
+	"time"
+)
+
+type (
+	// Option for the authenticator
+	Option func(*config)
+
+	config struct {
+		header   string
+		scheme   string
+		optional bool
+		clock    func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		header: "X-Api-Key",
+		clock:  time.Now,
+	}
+}
+
+// WithHeader sets the header carrying API keys. The default is "X-Api-Key".
+//
+// With a scheme, the key is expected after the scheme, e.g. WithHeader("Authorization", "ApiKey")
+// for "Authorization: ApiKey <key>".
+func WithHeader(name, scheme string) Option {
+	return func(c *config) {
+		c.header = name
+		c.scheme = scheme
+	}
+}
+
+// WithOptional accepts requests without API key, e.g. for public operations. Invalid keys are still rejected.
+func WithOptional(enabled bool) Option {
+	return func(c *config) {
+		c.optional = enabled
+	}
+}
+
+// WithClock sets the clock checking the expiry of keys. The default is time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,290 @@
+package gqlapikey
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	_ Store = &StaticStore{}
+	_ Store = &SQLStore{}
+	_ Store = &RedisStore{}
+	_ Store = &CachedStore{}
+)
+
+// DefaultCacheSize is the default maximum number of lookups retained by a CachedStore
+const DefaultCacheSize = 10000
+
+// DefaultSQLQuery is the default query of SQLStore. It has a single parameter, the hash of the key.
+const DefaultSQLQuery = `SELECT id, name, owner, scopes, tier, expires_at, disabled FROM api_keys WHERE key_hash = $1`
+
+type (
+	// StaticStore is a Store of keys known in advance, e.g. loaded from a file
+	StaticStore struct {
+		keys map[string]Key
+	}
+
+	// StaticKey is a key of a StaticStore, with either the hash of the key or the raw key (e.g. for development)
+	StaticKey struct {
+		Key  `yaml:",inline"`
+		Hash string `yaml:"hash"`
+		Raw  string `yaml:"key"`
+	}
+
+	// SQLStore is a Store of keys in a SQL database
+	SQLStore struct {
+		db    *sql.DB
+		query string
+	}
+
+	// RedisClient is the subset of a Redis client used by RedisStore
+	RedisClient interface {
+		HGetAll(ctx context.Context, key string) (map[string]string, error)
+	}
+
+	// RedisStore is a Store of keys in Redis hashes, keyed by the hash of keys
+	RedisStore struct {
+		client RedisClient
+		prefix string
+	}
+
+	// CachedStore caches the lookups of a Store for a while, including unknown keys,
+	// up to a maximum number of lookups
+	CachedStore struct {
+		store Store
+		ttl   time.Duration
+		size  int
+		clock func() time.Time
+
+		mx      sync.Mutex
+		entries map[string]*list.Element
+		order   *list.List // of *cachedKey, from the most to the least recent lookup
+	}
+
+	cachedKey struct {
+		hash    string
+		key     Key
+		err     error
+		expires time.Time
+	}
+
+	staticFile struct {
+		Keys []StaticKey `yaml:"keys"`
+	}
+)
+
+// NewStaticStore builds a Store of static keys
+func NewStaticStore(keys ...StaticKey) (*StaticStore, error) {
+	s := &StaticStore{keys: make(map[string]Key, len(keys))}
+	for _, key := range keys {
+		if key.Hash == "" && key.Raw == "" {
+			return nil, fmt.Errorf("key %q has neither hash nor raw key", key.ID)
+		}
+		hash := key.Hash
+		if hash == "" {
+			hash = Hash(key.Raw)
+		}
+		s.keys[strings.ToLower(hash)] = key.Key
+	}
+	return s, nil
+}
+
+// LoadStaticStore loads a Store of static keys from a YAML file:
+//
+//   keys:
+//     - id: billing
+//       hash: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//       scopes: [orders:read, orders:write]
+//       tier: gold
+//       expires: 2021-01-01T00:00:00Z
+func LoadStaticStore(path string) (*StaticStore, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file staticFile
+	if err := yaml.UnmarshalStrict(buf, &file); err != nil {
+		return nil, err
+	}
+	return NewStaticStore(file.Keys...)
+}
+
+// Lookup implements Store
+func (s *StaticStore) Lookup(_ context.Context, hash string) (Key, error) {
+	key, ok := s.keys[hash]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+	return key, nil
+}
+
+// NewSQLStore builds a Store of keys in a SQL database, with DefaultSQLQuery.
+//
+// Scopes are stored as a comma-separated list. Name, owner, tier and expiry are nullable.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return NewSQLStoreWithQuery(db, DefaultSQLQuery)
+}
+
+// NewSQLStoreWithQuery builds a Store of keys in a SQL database, with a specific query, e.g. for other
+// placeholders or schemas. The query must select the same columns as DefaultSQLQuery, given the hash of the key.
+func NewSQLStoreWithQuery(db *sql.DB, query string) *SQLStore {
+	return &SQLStore{db: db, query: query}
+}
+
+// Lookup implements Store
+func (s *SQLStore) Lookup(ctx context.Context, hash string) (Key, error) {
+	var (
+		key                       Key
+		name, owner, scopes, tier sql.NullString
+		expires                   sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, s.query, hash).Scan(&key.ID, &name, &owner, &scopes, &tier, &expires, &key.Disabled)
+	if err == sql.ErrNoRows {
+		return Key{}, ErrNotFound
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	key.Name, key.Owner, key.Tier = name.String, owner.String, tier.String
+	key.Scopes = splitScopes(scopes.String)
+	if expires.Valid {
+		key.Expires = expires.Time
+	}
+	return key, nil
+}
+
+// NewRedisStore builds a Store of keys in Redis hashes, at the hash of keys prefixed by "apikey:".
+//
+// Hashes have the fields id, name, owner, scopes (comma-separated), tier, expires (RFC 3339) and disabled.
+//
+// The client plugs into go-redis with a thin adapter:
+//
+//   type redisClient struct{ *redis.Client }
+//
+//   func (c redisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+//     return c.Client.HGetAll(ctx, key).Result()
+//   }
+func NewRedisStore(client RedisClient) *RedisStore {
+	return NewRedisStoreWithPrefix(client, "apikey:")
+}
+
+// NewRedisStoreWithPrefix builds a Store of keys in Redis hashes, with a specific prefix
+func NewRedisStoreWithPrefix(client RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Lookup implements Store
+func (s *RedisStore) Lookup(ctx context.Context, hash string) (Key, error) {
+	fields, err := s.client.HGetAll(ctx, s.prefix+hash)
+	if err != nil {
+		return Key{}, err
+	}
+	if len(fields) == 0 {
+		return Key{}, ErrNotFound
+	}
+
+	key := Key{
+		ID:     fields["id"],
+		Name:   fields["name"],
+		Owner:  fields["owner"],
+		Scopes: splitScopes(fields["scopes"]),
+		Tier:   fields["tier"],
+	}
+	if expires := fields["expires"]; expires != "" {
+		if key.Expires, err = time.Parse(time.RFC3339, expires); err != nil {
+			return Key{}, fmt.Errorf("invalid expiry of key %q: %w", key.ID, err)
+		}
+	}
+	if disabled := fields["disabled"]; disabled != "" {
+		if key.Disabled, err = strconv.ParseBool(disabled); err != nil {
+			return Key{}, fmt.Errorf("invalid disabled flag of key %q: %w", key.ID, err)
+		}
+	}
+	return key, nil
+}
+
+// NewCachedStore caches the lookups of a Store for a while, up to DefaultCacheSize lookups.
+// Store errors are not cached.
+//
+// Revoked keys remain valid until their cached lookup expires.
+func NewCachedStore(store Store, ttl time.Duration) *CachedStore {
+	return NewCachedStoreWithSize(store, ttl, DefaultCacheSize)
+}
+
+// NewCachedStoreWithSize caches the lookups of a Store like NewCachedStore, up to a specific number of lookups.
+//
+// The oldest lookups are evicted first, so that clients sending random keys cannot grow the cache.
+func NewCachedStoreWithSize(store Store, ttl time.Duration, size int) *CachedStore {
+	return &CachedStore{
+		store:   store,
+		ttl:     ttl,
+		size:    size,
+		clock:   time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Lookup implements Store
+func (s *CachedStore) Lookup(ctx context.Context, hash string) (Key, error) {
+	now := s.clock()
+	s.mx.Lock()
+	if elem, ok := s.entries[hash]; ok {
+		entry := elem.Value.(*cachedKey)
+		if now.Before(entry.expires) {
+			s.mx.Unlock()
+			return entry.key, entry.err
+		}
+	}
+	s.mx.Unlock()
+
+	key, err := s.store.Lookup(ctx, hash)
+	if err != nil && err != ErrNotFound {
+		return key, err
+	}
+	if s.size <= 0 {
+		return key, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if elem, ok := s.entries[hash]; ok {
+		s.remove(elem)
+	}
+	s.entries[hash] = s.order.PushFront(&cachedKey{hash: hash, key: key, err: err, expires: now.Add(s.ttl)})
+
+	// lookups are ordered by expiry: only expired lookups at the back are swept, then the oldest ones
+	// beyond the size of the cache
+	for back := s.order.Back(); back != nil && !now.Before(back.Value.(*cachedKey).expires); back = s.order.Back() {
+		s.remove(back)
+	}
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+	return key, err
+}
+
+func (s *CachedStore) remove(elem *list.Element) {
+	delete(s.entries, elem.Value.(*cachedKey).hash)
+	s.order.Remove(elem)
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return nil
+	}
+	parts := strings.Split(scopes, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
@@ -0,0 +1,131 @@
+package gqlapollo
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqldelivery"
+)
+
+type (
+	// Option for the usage reporter
+	Option func(*config)
+
+	config struct {
+		endpoint            string
+		client              *http.Client
+		interval            time.Duration
+		timeout             time.Duration
+		serviceVersion      string
+		clientNameHeader    string
+		clientVersionHeader string
+		clientInfo          func(context.Context) (string, string)
+		apiKey              func() string
+		onError             func(error)
+		delivery            bool
+		deliveryOptions     []gqldelivery.Option
+		clock               func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		endpoint:            DefaultEndpoint,
+		client:              http.DefaultClient,
+		interval:            10 * time.Second,
+		timeout:             10 * time.Second,
+		clientNameHeader:    "apollographql-client-name",
+		clientVersionHeader: "apollographql-client-version",
+		clock:               graphql.Now,
+	}
+}
+
+// WithEndpoint sets the usage reporting endpoint. The default is DefaultEndpoint.
+func WithEndpoint(endpoint string) Option {
+	return func(c *config) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send reports. The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithInterval sets the interval between reports sent by Run. The default is 10s.
+func WithInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithTimeout sets the timeout of the last report, sent when Run returns. The default is 10s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithServiceVersion sets the version of the service, reported in the header of reports
+func WithServiceVersion(version string) Option {
+	return func(c *config) {
+		c.serviceVersion = version
+	}
+}
+
+// WithClientHeaders sets the request headers identifying the client name and version, captured by the Middleware.
+// The defaults are "apollographql-client-name" and "apollographql-client-version".
+func WithClientHeaders(name, version string) Option {
+	return func(c *config) {
+		c.clientNameHeader = name
+		c.clientVersionHeader = version
+	}
+}
+
+// WithClientInfo sets the function identifying the client name and version from the context, e.g. from an API key.
+// This takes precedence over the client headers.
+func WithClientInfo(info func(context.Context) (name, version string)) Option {
+	return func(c *config) {
+		c.clientInfo = info
+	}
+}
+
+// WithAPIKeyFunc sets the function yielding the API key for each report, e.g. the value of a rotated secret.
+// This takes precedence over the API key of the Reporter.
+func WithAPIKeyFunc(apiKey func() string) Option {
+	return func(c *config) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithErrorHandler sets a handler for errors sending reports in Run. By default, errors are ignored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithDelivery sends reports through a gqldelivery.Queue, with these options: reports failing to be sent
+// are retried in the background, and may be spilled to disk or handed over to a dead letter handler.
+//
+// By default, reports failing to be sent are dropped.
+func WithDelivery(opts ...gqldelivery.Option) Option {
+	return func(c *config) {
+		c.delivery = true
+		c.deliveryOptions = append(c.deliveryOptions, opts...)
+	}
+}
+
+// WithClock sets the clock used to measure durations. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,199 @@
+package gqlapollo
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Minimal protobuf encoding of Apollo's usage reporting messages (reports.proto).
+//
+// Only the messages and fields needed to report stats are encoded.
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+type reportHeader struct {
+	graphRef       string
+	hostname       string
+	agentVersion   string
+	serviceVersion string
+	runtimeVersion string
+	uname          string
+}
+
+// encodeReport encodes a Report message
+func encodeReport(header reportHeader, r *report, end time.Time) []byte {
+	var b []byte
+	b = appendMessage(b, 1, encodeHeader(header))
+	b = appendMessage(b, 2, encodeTimestamp(end))
+
+	for _, key := range sortedKeys(r.queries) {
+		entry := appendString(nil, 1, key)
+		entry = appendMessage(entry, 2, encodeTracesAndStats(r.queries[key]))
+		b = appendMessage(b, 5, entry)
+	}
+	b = appendUint64(b, 6, r.operations)
+	return b
+}
+
+// encodeHeader encodes a ReportHeader message
+func encodeHeader(h reportHeader) []byte {
+	var b []byte
+	b = appendString(b, 5, h.hostname)
+	b = appendString(b, 6, h.agentVersion)
+	b = appendString(b, 7, h.serviceVersion)
+	b = appendString(b, 8, h.runtimeVersion)
+	b = appendString(b, 9, h.uname)
+	b = appendString(b, 12, h.graphRef)
+	return b
+}
+
+// encodeTimestamp encodes a google.protobuf.Timestamp message
+func encodeTimestamp(t time.Time) []byte {
+	var b []byte
+	b = appendUint64(b, 1, uint64(t.Unix()))
+	b = appendUint64(b, 2, uint64(t.Nanosecond()))
+	return b
+}
+
+// encodeTracesAndStats encodes a TracesAndStats message
+func encodeTracesAndStats(q *queryStats) []byte {
+	var b []byte
+
+	clients := make([]clientInfo, 0, len(q.contexts))
+	for client := range q.contexts {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].name != clients[j].name {
+			return clients[i].name < clients[j].name
+		}
+		return clients[i].version < clients[j].version
+	})
+	for _, client := range clients {
+		b = appendMessage(b, 2, encodeContextualizedStats(client, q.contexts[client]))
+	}
+
+	for _, typeName := range sortedKeys(q.referenced) {
+		referenced := q.referenced[typeName]
+		var fields []byte
+		for _, name := range referenced.names {
+			fields = appendString(fields, 1, name)
+		}
+		fields = appendBool(fields, 2, referenced.isInterface)
+
+		entry := appendString(nil, 1, typeName)
+		entry = appendMessage(entry, 2, fields)
+		b = appendMessage(b, 4, entry)
+	}
+	return b
+}
+
+// encodeContextualizedStats encodes a ContextualizedStats message
+func encodeContextualizedStats(client clientInfo, s *contextStats) []byte {
+	var statsContext []byte
+	statsContext = appendString(statsContext, 2, client.name)
+	statsContext = appendString(statsContext, 3, client.version)
+
+	var latency []byte
+	latency = appendUint64(latency, 2, s.requests)
+	latency = appendUint64(latency, 8, s.requestsWithErrors)
+	latency = appendPackedSint64(latency, 13, s.latency.encode())
+
+	var b []byte
+	b = appendMessage(b, 1, statsContext)
+	b = appendMessage(b, 2, latency)
+
+	for _, typeName := range sortedKeys(s.types) {
+		fields := s.types[typeName]
+
+		var typeStat []byte
+		for _, name := range sortedKeys(fields) {
+			field := fields[name]
+
+			var fieldStat []byte
+			fieldStat = appendString(fieldStat, 3, field.returnType)
+			fieldStat = appendUint64(fieldStat, 4, field.errors)
+			fieldStat = appendUint64(fieldStat, 5, field.observed)
+			fieldStat = appendUint64(fieldStat, 6, field.requestsWithErrors)
+			fieldStat = appendPackedSint64(fieldStat, 9, field.latency.encode())
+			fieldStat = appendUint64(fieldStat, 10, field.observed)
+
+			entry := appendString(nil, 1, name)
+			entry = appendMessage(entry, 2, fieldStat)
+			typeStat = appendMessage(typeStat, 3, entry)
+		}
+
+		entry := appendString(nil, 1, typeName)
+		entry = appendMessage(entry, 2, typeStat)
+		b = appendMessage(b, 3, entry)
+	}
+	return b
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendUint64(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return appendVarint(b, v)
+}
+
+func appendBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendUint64(b, field, 1)
+}
+
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendMessage(b []byte, field int, msg []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(msg)))
+	return append(b, msg...)
+}
+
+func appendPackedSint64(b []byte, field int, values []int64) []byte {
+	if len(values) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range values {
+		packed = appendVarint(packed, uint64(v<<1)^uint64(v>>63))
+	}
+	return appendMessage(b, field, packed)
+}
+
+// sortedKeys yields the keys of a map with string keys, in order, so that reports are deterministic
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	sorted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, key.String())
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,283 @@
+// Package gqlapollo provides a gqlgen extension reporting usage statistics to Apollo Studio.
+//
+// Operations and fields executed by gqlgen are aggregated in memory, then shipped on an interval to Apollo's
+// usage reporting ingress, in Apollo's protobuf format. The service then appears in the field usage and
+// performance views of Studio, without running the Apollo Router in front of it.
+//
+// Clients are identified by the "apollographql-client-name" and "apollographql-client-version" request headers,
+// captured by the Middleware.
+//
+// Example:
+//
+//   reporter := gqlapollo.New(os.Getenv("APOLLO_KEY"), "my-graph@current")
+//   srv.Use(reporter)
+//   http.Handle("/query", reporter.Middleware(srv))
+//
+//   go reporter.Run(ctx)
+package gqlapollo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqldelivery"
+)
+
+const (
+	extensionName = "ApolloUsageReporting"
+
+	// DefaultEndpoint is the usage reporting ingress of Apollo Studio
+	DefaultEndpoint = "https://usage-reporting.api.apollographql.com/api/ingress/traces"
+
+	agentVersion = "gqlgen-contrib-gqlapollo"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Reporter{}
+
+type (
+	// Reporter is a gqlgen extension reporting usage statistics to Apollo Studio
+	Reporter struct {
+		*config
+		apiKey string
+		header reportHeader
+		queue  *gqldelivery.Queue
+
+		mx      sync.Mutex
+		current *report
+	}
+
+	// collector gathers the field samples of a request
+	collector struct {
+		mx      sync.Mutex
+		samples []fieldSample
+	}
+
+	clientKey    struct{}
+	collectorKey struct{}
+)
+
+// New Apollo usage Reporter, for a graph ref such as "my-graph@current"
+func New(apiKey, graphRef string, opts ...Option) *Reporter {
+	r := &Reporter{
+		config:  defaultConfig(),
+		apiKey:  apiKey,
+		current: newReport(),
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+
+	hostname, _ := os.Hostname()
+	r.header = reportHeader{
+		graphRef:       graphRef,
+		hostname:       hostname,
+		agentVersion:   agentVersion,
+		serviceVersion: r.config.serviceVersion,
+		runtimeVersion: runtime.Version(),
+		uname:          runtime.GOOS + ", " + runtime.GOARCH,
+	}
+	if r.config.delivery {
+		r.queue = gqldelivery.New(gqldelivery.SenderFunc(r.send), r.config.deliveryOptions...)
+	}
+	return r
+}
+
+// Middleware captures the client name and version headers (see WithClientHeaders).
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (r *Reporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		client := clientInfo{
+			name:    req.Header.Get(r.config.clientNameHeader),
+			version: req.Header.Get(r.config.clientVersionHeader),
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), clientKey{}, client)))
+	})
+}
+
+// ExtensionName yields the extension name: "ApolloUsageReporting"
+func (*Reporter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Reporter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor, aggregating the stats of the operation
+func (r *Reporter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil {
+		return next(ctx)
+	}
+
+	start := oc.Stats.OperationStart
+	if start.IsZero() {
+		start = r.config.clock()
+	}
+	c := &collector{}
+	resp := next(context.WithValue(ctx, collectorKey{}, c))
+	if resp == nil {
+		// the final pull of websocket transports, past the response of the operation
+		return resp
+	}
+	duration := r.config.clock().Sub(start)
+
+	client, _ := ctx.Value(clientKey{}).(clientInfo)
+	if r.config.clientInfo != nil {
+		client.name, client.version = r.config.clientInfo(ctx)
+	}
+	failed := len(resp.Errors) > 0
+	key := statsKey(oc.Operation.Name, OperationSignature(ctx))
+
+	c.mx.Lock()
+	samples := c.samples
+	c.mx.Unlock()
+
+	r.mx.Lock()
+	r.current.add(key, client, duration, failed, samples, oc.Operation, oc.Doc)
+	r.mx.Unlock()
+
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor, measuring the execution of fields
+func (r *Reporter) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc == nil || fc.Field.Field == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+
+	start := r.config.clock()
+	res, err := next(ctx)
+
+	sample := fieldSample{
+		parentType: fc.Object,
+		field:      fc.Field.Name,
+		returnType: fc.Field.Definition.Type.String(),
+		duration:   r.config.clock().Sub(start),
+		failed:     err != nil,
+	}
+	c.mx.Lock()
+	c.samples = append(c.samples, sample)
+	c.mx.Unlock()
+
+	return res, err
+}
+
+// Run sends reports on an interval, until the context is done. Pending stats are sent before returning.
+//
+// Errors are reported by the error handler (see WithErrorHandler). With WithDelivery, reports are delivered
+// by the queue, run alongside, and drained before returning.
+func (r *Reporter) Run(ctx context.Context) {
+	if r.queue != nil {
+		done := make(chan struct{})
+		go func() {
+			r.queue.Run(ctx)
+			close(done)
+		}()
+		defer func() {
+			<-done
+			drainCtx, cancel := context.WithTimeout(context.Background(), r.config.timeout)
+			r.queue.Drain(drainCtx)
+			cancel()
+		}()
+	}
+
+	ticker := time.NewTicker(r.config.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), r.config.timeout)
+			r.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			r.flush(ctx)
+		}
+	}
+}
+
+// Flush sends the stats aggregated since the last report, or enqueues them with WithDelivery.
+// This is a noop when no operation was executed.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mx.Lock()
+	pending := r.current
+	r.current = newReport()
+	r.mx.Unlock()
+
+	if pending.operations == 0 {
+		return nil
+	}
+	msg := encodeReport(r.header, pending, r.config.clock())
+	if r.queue != nil {
+		return r.queue.Enqueue(msg)
+	}
+	return r.send(ctx, msg)
+}
+
+func (r *Reporter) flush(ctx context.Context) {
+	if err := r.Flush(ctx); err != nil && r.config.onError != nil {
+		r.config.onError(err)
+	}
+}
+
+func (r *Reporter) send(ctx context.Context, msg []byte) error {
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if _, err := zw.Write(msg); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.config.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", agentVersion)
+	apiKey := r.apiKey
+	if r.config.apiKey != nil {
+		apiKey = r.config.apiKey()
+	}
+	req.Header.Set("X-Api-Key", apiKey)
+
+	resp, err := r.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		text, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("apollo usage reporting failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(text))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
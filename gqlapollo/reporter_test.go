@@ -0,0 +1,131 @@
+package gqlapollo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const testSchema = `
+type User {
+  id: ID!
+  name: String!
+  friends(first: Int): [User!]!
+}
+
+type Query {
+  user(id: ID!): User
+}
+`
+
+func TestSignature(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	doc, gqlErrs := gqlparser.LoadQuery(schema, `
+query GetUser($id: ID!) {
+  me: user(id: $id) {
+    name
+    ...friends
+    id
+  }
+  other: user(id: "42") { id }
+}
+
+fragment friends on User { friends(first: 10) { id } }
+`)
+	require.Empty(t, gqlErrs)
+
+	// unused fragments do not pass validation: add one to the validated document
+	unused, gqlErr := parser.ParseQuery(&ast.Source{Input: `fragment unused on User { id }`})
+	require.Nil(t, gqlErr)
+	doc.Fragments = append(doc.Fragments, unused.Fragments...)
+
+	op := doc.Operations.ForName("GetUser")
+	assert.Equal(t,
+		`fragment friends on User{friends(first:0){id}}query GetUser($id:ID!){user(id:$id){id name...friends}user(id:""){id}}`,
+		Signature(doc, op))
+
+	referenced := referencedFieldsByType(doc, op)
+	assert.Equal(t, []string{"user"}, referenced["Query"].names)
+	assert.Equal(t, []string{"friends", "id", "name"}, referenced["User"].names)
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	h.add(500 * time.Nanosecond)
+	h.add(time.Microsecond)
+	h.add(1100 * time.Nanosecond)
+	h.add(1464 * time.Nanosecond) // 1.1^4 µs
+	h.add(time.Hour)
+
+	encoded := h.encode()
+	assert.Equal(t, int64(2), encoded[0])
+	assert.Equal(t, int64(1), encoded[1])
+	assert.Equal(t, int64(-2), encoded[2])
+	assert.Equal(t, int64(1), encoded[3])
+	assert.Equal(t, int64(1), encoded[len(encoded)-1])
+}
+
+func TestFlush(t *testing.T) {
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "service:key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		received, err = ioutil.ReadAll(zr)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	reporter := New("service:key", "graph@current", WithEndpoint(srv.URL))
+	require.NoError(t, reporter.Flush(context.Background()))
+	assert.Nil(t, received, "empty reports are not sent")
+
+	reporter.current.add(statsKey("GetUser", "{user{id}}"), clientInfo{name: "web", version: "1.0"}, time.Millisecond, false,
+		[]fieldSample{{parentType: "Query", field: "user", returnType: "User", duration: time.Millisecond}}, nil, nil)
+	require.NoError(t, reporter.Flush(context.Background()))
+
+	require.NotEmpty(t, received)
+	assert.True(t, bytes.Contains(received, []byte("graph@current")))
+	assert.True(t, bytes.Contains(received, []byte("# GetUser\n{user{id}}")))
+	assert.True(t, bytes.Contains(received, []byte("web")))
+	assert.Equal(t, uint64(0), reporter.current.operations)
+}
+
+func TestInterceptResponse(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+	doc, gqlErrs := gqlparser.LoadQuery(schema, `query GetUser { user(id: "42") { id } }`)
+	require.Empty(t, gqlErrs)
+
+	reporter := New("service:key", "graph@current")
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Doc:       doc,
+		Operation: doc.Operations[0],
+	})
+
+	// websocket transports pull responses until nil: the operation is reported once
+	require.NotNil(t, reporter.InterceptResponse(ctx, func(context.Context) *graphql.Response { return &graphql.Response{} }))
+	require.Nil(t, reporter.InterceptResponse(ctx, func(context.Context) *graphql.Response { return nil }))
+
+	assert.Equal(t, uint64(1), reporter.current.operations)
+	require.Len(t, reporter.current.queries, 1)
+	for _, stats := range reporter.current.queries {
+		require.Len(t, stats.contexts, 1)
+	}
+}
@@ -0,0 +1,261 @@
+package gqlapollo
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlctx"
+)
+
+var (
+	spaces           = regexp.MustCompile(`\s+`)
+	spaceAfterPunct  = regexp.MustCompile(`([^_a-zA-Z0-9]) `)
+	spaceBeforePunct = regexp.MustCompile(` ([^_a-zA-Z0-9])`)
+)
+
+// Signature yields the usage reporting signature of an operation, as computed by Apollo's reference implementation:
+// unused definitions are dropped, literals are hidden, aliases are removed, definitions and selections are sorted,
+// and whitespace is reduced.
+//
+// For example, the signature of:
+//
+//   query GetUser { user(id: "42") { name, id } }
+//
+// is:
+//
+//   query GetUser{user(id:""){id name}}
+func Signature(doc *ast.QueryDocument, op *ast.OperationDefinition) string {
+	if op == nil {
+		return ""
+	}
+	p := &printer{}
+
+	fragments := usedFragments(doc, op.SelectionSet, make(map[string]*ast.FragmentDefinition))
+	names := make([]string, 0, len(fragments))
+	for name := range fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// fragment definitions sort before operation definitions
+	for _, name := range names {
+		p.fragment(fragments[name])
+		p.write(" ")
+	}
+	p.operation(op)
+
+	signature := spaces.ReplaceAllString(strings.TrimSpace(p.String()), " ")
+	signature = spaceAfterPunct.ReplaceAllString(signature, "$1")
+	return spaceBeforePunct.ReplaceAllString(signature, "$1")
+}
+
+// OperationSignature yields the Signature of the operation of a context.
+//
+// With the gqlctx extension, the signature is computed once per operation, and shared with other extensions.
+func OperationSignature(ctx context.Context) string {
+	return gqlctx.Load(ctx, gqlctx.Signature, func() interface{} {
+		oc := graphql.GetOperationContext(ctx)
+		return Signature(oc.Doc, oc.Operation)
+	}).(string)
+}
+
+// statsKey yields the key of an operation in usage reports
+func statsKey(name, signature string) string {
+	if name == "" {
+		name = "-"
+	}
+	return "# " + name + "\n" + signature
+}
+
+func usedFragments(doc *ast.QueryDocument, selections ast.SelectionSet, used map[string]*ast.FragmentDefinition) map[string]*ast.FragmentDefinition {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			usedFragments(doc, s.SelectionSet, used)
+		case *ast.InlineFragment:
+			usedFragments(doc, s.SelectionSet, used)
+		case *ast.FragmentSpread:
+			if _, ok := used[s.Name]; ok {
+				continue
+			}
+			fragment := s.Definition
+			if fragment == nil && doc != nil {
+				fragment = doc.Fragments.ForName(s.Name)
+			}
+			if fragment == nil {
+				continue
+			}
+			used[s.Name] = fragment
+			usedFragments(doc, fragment.SelectionSet, used)
+		}
+	}
+	return used
+}
+
+type printer struct {
+	strings.Builder
+}
+
+func (p *printer) write(parts ...string) {
+	for _, part := range parts {
+		_, _ = p.WriteString(part)
+	}
+}
+
+func (p *printer) operation(op *ast.OperationDefinition) {
+	if op.Name == "" && len(op.VariableDefinitions) == 0 && len(op.Directives) == 0 && op.Operation == ast.Query {
+		p.selectionSet(op.SelectionSet)
+		return
+	}
+
+	p.write(string(op.Operation))
+	if op.Name != "" {
+		p.write(" ", op.Name)
+	}
+	if len(op.VariableDefinitions) > 0 {
+		variables := make(ast.VariableDefinitionList, len(op.VariableDefinitions))
+		copy(variables, op.VariableDefinitions)
+		sort.SliceStable(variables, func(i, j int) bool { return variables[i].Variable < variables[j].Variable })
+
+		p.write("(")
+		for i, v := range variables {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write("$", v.Variable, ": ", v.Type.String())
+			if v.DefaultValue != nil {
+				p.write(" = ")
+				p.value(v.DefaultValue)
+			}
+		}
+		p.write(")")
+	}
+	p.directives(op.Directives)
+	p.write(" ")
+	p.selectionSet(op.SelectionSet)
+}
+
+func (p *printer) fragment(fragment *ast.FragmentDefinition) {
+	p.write("fragment ", fragment.Name, " on ", fragment.TypeCondition)
+	p.directives(fragment.Directives)
+	p.write(" ")
+	p.selectionSet(fragment.SelectionSet)
+}
+
+func (p *printer) selectionSet(selections ast.SelectionSet) {
+	if len(selections) == 0 {
+		return
+	}
+	sorted := make(ast.SelectionSet, len(selections))
+	copy(sorted, selections)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, ni := selectionSortKey(sorted[i])
+		kj, nj := selectionSortKey(sorted[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return ni < nj
+	})
+
+	p.write("{")
+	for i, selection := range sorted {
+		if i > 0 {
+			p.write(" ")
+		}
+		switch s := selection.(type) {
+		case *ast.Field:
+			// aliases are removed
+			p.write(s.Name)
+			p.arguments(s.Arguments)
+			p.directives(s.Directives)
+			if len(s.SelectionSet) > 0 {
+				p.write(" ")
+				p.selectionSet(s.SelectionSet)
+			}
+		case *ast.FragmentSpread:
+			p.write("...", s.Name)
+			p.directives(s.Directives)
+		case *ast.InlineFragment:
+			p.write("...")
+			if s.TypeCondition != "" {
+				p.write(" on ", s.TypeCondition)
+			}
+			p.directives(s.Directives)
+			p.write(" ")
+			p.selectionSet(s.SelectionSet)
+		}
+	}
+	p.write("}")
+}
+
+// selectionSortKey sorts selections by kind, then by name, like the reference implementation
+func selectionSortKey(selection ast.Selection) (string, string) {
+	switch s := selection.(type) {
+	case *ast.Field:
+		return "Field", s.Name
+	case *ast.FragmentSpread:
+		return "FragmentSpread", s.Name
+	default:
+		return "InlineFragment", ""
+	}
+}
+
+func (p *printer) arguments(args ast.ArgumentList) {
+	if len(args) == 0 {
+		return
+	}
+	sorted := make(ast.ArgumentList, len(args))
+	copy(sorted, args)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	p.write("(")
+	for i, arg := range sorted {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(arg.Name, ": ")
+		p.value(arg.Value)
+	}
+	p.write(")")
+}
+
+func (p *printer) directives(directives ast.DirectiveList) {
+	sorted := make(ast.DirectiveList, len(directives))
+	copy(sorted, directives)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, d := range sorted {
+		p.write(" @", d.Name)
+		p.arguments(d.Arguments)
+	}
+}
+
+// value prints a value, hiding literals
+func (p *printer) value(value *ast.Value) {
+	if value == nil {
+		p.write("null")
+		return
+	}
+	switch value.Kind {
+	case ast.Variable:
+		p.write("$", value.Raw)
+	case ast.IntValue, ast.FloatValue:
+		p.write("0")
+	case ast.StringValue, ast.BlockValue:
+		p.write(`""`)
+	case ast.ListValue:
+		p.write("[]")
+	case ast.ObjectValue:
+		p.write("{}")
+	case ast.NullValue:
+		p.write("null")
+	default:
+		// booleans and enums
+		p.write(value.Raw)
+	}
+}
@@ -0,0 +1,213 @@
+package gqlapollo
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// histogramBuckets is the number of buckets of duration histograms: bucket n holds durations around 1.1^n µs
+const histogramBuckets = 384
+
+type (
+	// histogram of durations, with Apollo's exponential buckets
+	histogram struct {
+		buckets []int64
+	}
+
+	clientInfo struct {
+		name    string
+		version string
+	}
+
+	fieldStats struct {
+		returnType         string
+		errors             uint64
+		observed           uint64
+		requestsWithErrors uint64
+		latency            histogram
+	}
+
+	contextStats struct {
+		requests           uint64
+		requestsWithErrors uint64
+		latency            histogram
+
+		// field stats by parent type, then by field name
+		types map[string]map[string]*fieldStats
+	}
+
+	referencedFields struct {
+		names       []string
+		isInterface bool
+	}
+
+	queryStats struct {
+		contexts   map[clientInfo]*contextStats
+		referenced map[string]*referencedFields
+	}
+
+	// report aggregates the stats of operations between two flushes
+	report struct {
+		operations uint64
+		queries    map[string]*queryStats
+	}
+
+	// fieldSample is the execution of a field, collected during a request
+	fieldSample struct {
+		parentType string
+		field      string
+		returnType string
+		duration   time.Duration
+		failed     bool
+	}
+)
+
+func newReport() *report {
+	return &report{queries: make(map[string]*queryStats)}
+}
+
+// add the duration of an execution to the histogram
+func (h *histogram) add(d time.Duration) {
+	bucket := durationToBucket(d)
+	if bucket >= len(h.buckets) {
+		grown := make([]int64, bucket+1)
+		copy(grown, h.buckets)
+		h.buckets = grown
+	}
+	h.buckets[bucket]++
+}
+
+// encode the histogram as Apollo's latency_count: runs of empty buckets are encoded as negative numbers,
+// and trailing empty buckets are dropped.
+func (h *histogram) encode() []int64 {
+	encoded := make([]int64, 0, len(h.buckets))
+	var zeros int64
+	for _, count := range h.buckets {
+		if count == 0 {
+			zeros++
+			continue
+		}
+		switch {
+		case zeros == 1:
+			encoded = append(encoded, 0)
+		case zeros > 1:
+			encoded = append(encoded, -zeros)
+		}
+		zeros = 0
+		encoded = append(encoded, count)
+	}
+	return encoded
+}
+
+func durationToBucket(d time.Duration) int {
+	bucket := math.Ceil(math.Log(float64(d.Nanoseconds())/1000) / math.Log(1.1))
+	switch {
+	case math.IsNaN(bucket) || bucket <= 0:
+		return 0
+	case bucket >= histogramBuckets:
+		return histogramBuckets - 1
+	default:
+		return int(bucket)
+	}
+}
+
+// add the stats of a request to the report
+func (r *report) add(key string, client clientInfo, duration time.Duration, failed bool, samples []fieldSample, op *ast.OperationDefinition, doc *ast.QueryDocument) {
+	r.operations++
+
+	query, ok := r.queries[key]
+	if !ok {
+		query = &queryStats{
+			contexts:   make(map[clientInfo]*contextStats),
+			referenced: referencedFieldsByType(doc, op),
+		}
+		r.queries[key] = query
+	}
+
+	stats, ok := query.contexts[client]
+	if !ok {
+		stats = &contextStats{types: make(map[string]map[string]*fieldStats)}
+		query.contexts[client] = stats
+	}
+
+	stats.requests++
+	if failed {
+		stats.requestsWithErrors++
+	}
+	stats.latency.add(duration)
+
+	failedFields := make(map[*fieldStats]struct{})
+	for _, sample := range samples {
+		fields, ok := stats.types[sample.parentType]
+		if !ok {
+			fields = make(map[string]*fieldStats)
+			stats.types[sample.parentType] = fields
+		}
+		field, ok := fields[sample.field]
+		if !ok {
+			field = &fieldStats{returnType: sample.returnType}
+			fields[sample.field] = field
+		}
+		field.observed++
+		field.latency.add(sample.duration)
+		if sample.failed {
+			field.errors++
+			failedFields[field] = struct{}{}
+		}
+	}
+	for field := range failedFields {
+		field.requestsWithErrors++
+	}
+}
+
+// referencedFieldsByType lists the fields referenced by an operation, by parent type
+func referencedFieldsByType(doc *ast.QueryDocument, op *ast.OperationDefinition) map[string]*referencedFields {
+	seen := make(map[string]map[string]struct{})
+	interfaces := make(map[string]bool)
+
+	var walk func(ast.SelectionSet, map[string]bool)
+	walk = func(selections ast.SelectionSet, visiting map[string]bool) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if s.ObjectDefinition != nil && s.Name != "__typename" {
+					typeName := s.ObjectDefinition.Name
+					if seen[typeName] == nil {
+						seen[typeName] = make(map[string]struct{})
+					}
+					seen[typeName][s.Name] = struct{}{}
+					interfaces[typeName] = s.ObjectDefinition.Kind == ast.Interface
+				}
+				walk(s.SelectionSet, visiting)
+			case *ast.InlineFragment:
+				walk(s.SelectionSet, visiting)
+			case *ast.FragmentSpread:
+				fragment := s.Definition
+				if fragment == nil && doc != nil {
+					fragment = doc.Fragments.ForName(s.Name)
+				}
+				if fragment != nil && !visiting[fragment.Name] {
+					visiting[fragment.Name] = true
+					walk(fragment.SelectionSet, visiting)
+				}
+			}
+		}
+	}
+	if op != nil {
+		walk(op.SelectionSet, make(map[string]bool))
+	}
+
+	referenced := make(map[string]*referencedFields, len(seen))
+	for typeName, fields := range seen {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		referenced[typeName] = &referencedFields{names: names, isInterface: interfaces[typeName]}
+	}
+	return referenced
+}
@@ -0,0 +1,48 @@
+// Package ocattr builds opencensus span attributes from the key/values sanitized by a gqlattr pipeline.
+//
+// Example:
+//
+//   span.AddAttributes(ocattr.Attributes(gqlattr.Default(), []gqlattr.KeyValue{
+//     {Key: "db.statement", Value: query},
+//   })...)
+package ocattr
+
+import (
+	"fmt"
+
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+// Attributes sanitizes key/values with a pipeline, and builds the corresponding opencensus attributes
+func Attributes(p gqlattr.Pipeline, kvs []gqlattr.KeyValue) []trace.Attribute {
+	attrs := make([]trace.Attribute, 0, len(kvs))
+	for _, kv := range kvs {
+		key, value, ok := p.Sanitize(kv.Key, kv.Value)
+		if !ok {
+			continue
+		}
+		attrs = append(attrs, Attribute(key, value))
+	}
+	return attrs
+}
+
+// Attribute builds an opencensus attribute from a value. Values of other types than the ones supported
+// by opencensus are formatted as strings.
+func Attribute(key string, value interface{}) trace.Attribute {
+	switch v := value.(type) {
+	case string:
+		return trace.StringAttribute(key, v)
+	case bool:
+		return trace.BoolAttribute(key, v)
+	case int64:
+		return trace.Int64Attribute(key, v)
+	case int:
+		return trace.Int64Attribute(key, int64(v))
+	case float64:
+		return trace.Float64Attribute(key, v)
+	default:
+		return trace.StringAttribute(key, fmt.Sprint(v))
+	}
+}
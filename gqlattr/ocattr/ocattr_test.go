@@ -0,0 +1,28 @@
+package ocattr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+func TestAttributes(t *testing.T) {
+	attrs := Attributes(gqlattr.Pipeline{gqlattr.Drop("variables")}, []gqlattr.KeyValue{
+		{Key: "variables", Value: `{"id": 1}`},
+		{Key: "count", Value: int64(42)},
+		{Key: "size", Value: 3},
+		{Key: "query.truncated", Value: true},
+		{Key: "ratio", Value: 0.5},
+		{Key: "path", Value: []string{"a"}},
+	})
+	assert.Equal(t, []trace.Attribute{
+		trace.Int64Attribute("count", 42),
+		trace.Int64Attribute("size", 3),
+		trace.BoolAttribute("query.truncated", true),
+		trace.Float64Attribute("ratio", 0.5),
+		trace.StringAttribute("path", "[a]"),
+	}, attrs)
+}
@@ -0,0 +1,123 @@
+// Package gqlattr provides a pipeline of sanitizers applied to span attributes before they are set,
+// e.g. to mask PII, cap the length of values or rename keys to match organization conventions.
+//
+// The pipeline is shared by the tracers of this repository: gqlopencensus, gqlopentracing, gqlsql and gqlredis
+// apply the default pipeline, unless configured with their own.
+//
+// Example:
+//
+//   gqlattr.SetDefault(gqlattr.Pipeline{
+//     engine.Sanitizer(map[string]gqlpii.Kind{"user.email": gqlpii.Email}),
+//     gqlattr.Drop("variables"),
+//     gqlattr.Rename(map[string]string{"query": "graphql.document"}),
+//     gqlattr.MaxLength(1024),
+//   })
+//
+// This package does not depend on any tracing library. Tracers sanitize the attributes they produce as key/values,
+// then build the attributes of their library, e.g. with the opencensus adapter of package ocattr:
+//
+//   span.AddAttributes(ocattr.Attributes(gqlattr.Default(), []gqlattr.KeyValue{
+//     {Key: "db.statement", Value: query},
+//   })...)
+package gqlattr
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+type (
+	// Sanitizer transforms an attribute before it is set on a span. The attribute is dropped when ok is false.
+	Sanitizer func(key string, value interface{}) (string, interface{}, bool)
+
+	// Pipeline of sanitizers, applied in order
+	Pipeline []Sanitizer
+
+	// KeyValue is an attribute before it is sanitized. The attributes of tracing libraries do not always expose
+	// their key and value, e.g. opencensus attributes: attributes are therefore sanitized as key/values,
+	// and only built as attributes of a tracing library afterwards.
+	KeyValue struct {
+		Key   string
+		Value interface{}
+	}
+
+	holder struct {
+		pipeline Pipeline
+	}
+)
+
+var defaultPipeline atomic.Value
+
+func init() {
+	defaultPipeline.Store(holder{})
+}
+
+// SetDefault sets the default pipeline, applied by the tracers of this repository
+func SetDefault(p Pipeline) {
+	defaultPipeline.Store(holder{pipeline: p})
+}
+
+// Default yields the default pipeline. It is empty unless set with SetDefault.
+func Default() Pipeline {
+	return defaultPipeline.Load().(holder).pipeline
+}
+
+// Sanitize an attribute
+func (p Pipeline) Sanitize(key string, value interface{}) (string, interface{}, bool) {
+	for _, sanitize := range p {
+		var ok bool
+		if key, value, ok = sanitize(key, value); !ok {
+			return "", nil, false
+		}
+	}
+	return key, value, true
+}
+
+// KeyValues sanitizes key/values. Dropped key/values are omitted.
+func (p Pipeline) KeyValues(kvs []KeyValue) []KeyValue {
+	sanitized := make([]KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		key, value, ok := p.Sanitize(kv.Key, kv.Value)
+		if !ok {
+			continue
+		}
+		sanitized = append(sanitized, KeyValue{Key: key, Value: value})
+	}
+	return sanitized
+}
+
+// Rename the keys of attributes. Other attributes are left untouched.
+func Rename(names map[string]string) Sanitizer {
+	return func(key string, value interface{}) (string, interface{}, bool) {
+		if renamed, ok := names[key]; ok {
+			return renamed, value, true
+		}
+		return key, value, true
+	}
+}
+
+// Drop attributes by key
+func Drop(keys ...string) Sanitizer {
+	dropped := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		dropped[key] = true
+	}
+	return func(key string, value interface{}) (string, interface{}, bool) {
+		return key, value, !dropped[key]
+	}
+}
+
+// MaxLength truncates string values to a maximum number of bytes, without splitting multi-byte characters
+func MaxLength(limit int) Sanitizer {
+	return func(key string, value interface{}) (string, interface{}, bool) {
+		str, ok := value.(string)
+		if !ok || len(str) <= limit {
+			return key, value, true
+		}
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(str[cut]) {
+			cut--
+		}
+		return key, str[:cut], true
+	}
+}
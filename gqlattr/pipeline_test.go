@@ -0,0 +1,45 @@
+package gqlattr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	p := Pipeline{
+		Drop("variables"),
+		Rename(map[string]string{"query": "graphql.document"}),
+		MaxLength(5),
+	}
+
+	key, value, ok := p.Sanitize("query", "{ todos { id } }")
+	assert.True(t, ok)
+	assert.Equal(t, "graphql.document", key)
+	assert.Equal(t, "{ tod", value)
+
+	_, _, ok = p.Sanitize("variables", "{}")
+	assert.False(t, ok)
+
+	_, value, _ = MaxLength(2)("name", "héllo")
+	assert.Equal(t, "h", value, "multi-byte characters are not split")
+
+	kvs := p.KeyValues([]KeyValue{
+		{Key: "variables", Value: `{"id": 1}`},
+		{Key: "count", Value: int64(42)},
+		{Key: "query", Value: "{ a }"},
+	})
+	assert.Equal(t, []KeyValue{
+		{Key: "count", Value: int64(42)},
+		{Key: "graphql.document", Value: "{ a }"},
+	}, kvs)
+}
+
+func TestDefault(t *testing.T) {
+	kvs := []KeyValue{{Key: "field", Value: "todos"}}
+	assert.Equal(t, kvs, Default().KeyValues(kvs))
+
+	SetDefault(Pipeline{Drop("field")})
+	defer SetDefault(nil)
+	assert.Empty(t, Default().KeyValues(kvs))
+}
@@ -0,0 +1,77 @@
+// Package gqlattrgen is a gqlgen plugin generating typed field attributers from the schema, to be used
+// with gqlopencensus.WithFieldKeyValues.
+//
+// For each object type with an id or with fields taking scalar arguments, a <Type>FieldAttrs function
+// extracts the id of the parent object as "<Type>.id", and the arguments of the resolved field as
+// "args.<name>". A FieldAttrs function dispatches to the attributer of the object of the field.
+//
+// This spares FieldKeyValuer closures indexing fc.Args with names which silently break when the schema changes:
+// the attributers are regenerated with the rest of the gqlgen code.
+//
+// Example:
+//
+//   // in a custom gqlgen entry point, replacing `go run github.com/99designs/gqlgen`
+//   cfg, _ := config.LoadConfigFromDefaultLocations()
+//   err := api.Generate(cfg, api.AddPlugin(gqlattrgen.New()))
+//
+//   // in the server
+//   tracer := gqlopencensus.New(gqlopencensus.WithFieldKeyValues(generated.FieldAttrs))
+//
+// Alternatively, Generate produces the same code from a parsed schema.
+package gqlattrgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/plugin"
+)
+
+// DefaultFilename is the name of the generated file, in the directory of the generated executable schema
+const DefaultFilename = "attrs_gen.go"
+
+var _ plugin.CodeGenerator = &Plugin{}
+
+// Plugin generating field attributers
+type Plugin struct {
+	*config
+}
+
+// New gqlgen plugin generating field attributers
+func New(opts ...Option) *Plugin {
+	p := &Plugin{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(p.config)
+	}
+	return p
+}
+
+// Name of the plugin
+func (p *Plugin) Name() string {
+	return "attrgen"
+}
+
+// GenerateCode generates the field attributers next to the executable schema
+func (p *Plugin) GenerateCode(data *codegen.Data) error {
+	dir := filepath.Dir(data.Config.Exec.Filename)
+
+	pkg := p.pkg
+	if pkg == "" {
+		pkg = data.Config.Exec.Package
+	}
+	if pkg == "" {
+		pkg = filepath.Base(dir)
+	}
+
+	src, err := Generate(data.Schema, pkg)
+	if err != nil {
+		return err
+	}
+
+	filename := p.filename
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	return ioutil.WriteFile(filename, src, 0644)
+}
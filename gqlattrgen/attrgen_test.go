@@ -0,0 +1,59 @@
+package gqlattrgen
+
+import (
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var schema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	enum Role { ADMIN, MEMBER }
+	input Filter { name: String }
+	type Query {
+		user(id: ID!): User
+		users(first: Int, role: Role, filter: Filter, ids: [ID!]): [User!]!
+	}
+	type User {
+		id: ID!
+		name: String!
+		friends(first: Int = 10): [User!]!
+	}
+	type Tag { name: String! }
+`})
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate(schema, "graph")
+	require.NoError(t, err)
+
+	file, err := parser.ParseFile(token.NewFileSet(), DefaultFilename, src, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "graph", file.Name.Name)
+
+	funcs := make(map[string]bool)
+	for _, obj := range file.Scope.Objects {
+		funcs[obj.Name] = obj.Kind == goast.Fun
+	}
+	assert.True(t, funcs["FieldAttrs"])
+	assert.True(t, funcs["QueryFieldAttrs"])
+	assert.True(t, funcs["UserFieldAttrs"])
+	assert.False(t, funcs["TagFieldAttrs"], "types without id nor scalar arguments are skipped")
+
+	code := string(src)
+	assert.Contains(t, code, `attrgenAppend(attrs, "User.id", attrgenID(fc.Parent.Result))`)
+	assert.Contains(t, code, `attrgenAppend(attrs, "args.first", fc.Args["first"])`)
+	assert.Contains(t, code, `attrgenAppend(attrs, "args.role", fc.Args["role"])`)
+	assert.NotContains(t, code, `fc.Args["filter"]`, "input objects are skipped")
+	assert.NotContains(t, code, `fc.Args["ids"]`, "lists are skipped")
+	assert.NotContains(t, code, "__Type")
+
+	t.Run("no schema", func(t *testing.T) {
+		_, err := Generate(nil, "graph")
+		assert.Error(t, err)
+	})
+}
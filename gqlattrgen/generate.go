@@ -0,0 +1,214 @@
+package gqlattrgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type (
+	fileData struct {
+		Package string
+		Types   []typeData
+	}
+
+	typeData struct {
+		Name   string
+		Func   string
+		HasID  bool
+		Fields []fieldData
+	}
+
+	fieldData struct {
+		Name string
+		Args []string
+	}
+)
+
+// Generate the source of the field attributers for a schema, in package pkg.
+//
+// Only arguments of scalar or enum types are extracted: input objects and lists are skipped.
+func Generate(schema *ast.Schema, pkg string) ([]byte, error) {
+	if schema == nil {
+		return nil, errors.New("no schema")
+	}
+
+	data := fileData{Package: pkg}
+	names := make([]string, 0, len(schema.Types))
+	for name := range schema.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := schema.Types[name]
+		if def.Kind != ast.Object || def.BuiltIn || strings.HasPrefix(name, "__") {
+			continue
+		}
+		if t, ok := buildType(schema, def); ok {
+			data.Types = append(data.Types, t)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+func buildType(schema *ast.Schema, def *ast.Definition) (typeData, bool) {
+	t := typeData{Name: def.Name, Func: exported(def.Name) + "FieldAttrs"}
+	for _, field := range def.Fields {
+		if strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+		if field.Name == "id" && field.Type.Elem == nil && field.Type.NamedType == "ID" {
+			t.HasID = true
+		}
+
+		f := fieldData{Name: field.Name}
+		for _, arg := range field.Arguments {
+			if isLeaf(schema, arg.Type) {
+				f.Args = append(f.Args, arg.Name)
+			}
+		}
+		if len(f.Args) > 0 {
+			t.Fields = append(t.Fields, f)
+		}
+	}
+	return t, t.HasID || len(t.Fields) > 0
+}
+
+func isLeaf(schema *ast.Schema, t *ast.Type) bool {
+	if t.Elem != nil {
+		return false
+	}
+	def := schema.Types[t.NamedType]
+	return def != nil && (def.Kind == ast.Scalar || def.Kind == ast.Enum)
+}
+
+func exported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+var fileTemplate = template.Must(template.New("attrs").Parse(`// Code generated by gqlattrgen, DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+// FieldAttrs yields the trace attributes of a field, according to the type of its object.
+// It may be used with gqlopencensus.WithFieldKeyValues.
+func FieldAttrs(fc *graphql.FieldContext) []gqlattr.KeyValue {
+	if fc == nil {
+		return nil
+	}
+	switch fc.Object {
+{{- range .Types }}
+	case {{ printf "%q" .Name }}:
+		return {{ .Func }}(fc)
+{{- end }}
+	}
+	return nil
+}
+{{ range .Types }}
+// {{ .Func }} yields the trace attributes of the fields of {{ .Name }}.
+func {{ .Func }}(fc *graphql.FieldContext) []gqlattr.KeyValue {
+	if fc == nil || fc.Object != {{ printf "%q" .Name }} {
+		return nil
+	}
+	var attrs []gqlattr.KeyValue
+{{- if .HasID }}
+	if fc.Parent != nil {
+		attrs = attrgenAppend(attrs, {{ printf "%q" (print .Name ".id") }}, attrgenID(fc.Parent.Result))
+	}
+{{- end }}
+{{- if .Fields }}
+	switch fc.Field.Name {
+{{- range .Fields }}
+	case {{ printf "%q" .Name }}:
+{{- range .Args }}
+		attrs = attrgenAppend(attrs, {{ printf "%q" (print "args." .) }}, fc.Args[{{ printf "%q" . }}])
+{{- end }}
+{{- end }}
+	}
+{{- end }}
+	return attrs
+}
+{{ end }}
+// attrgenID yields the ID field, or the result of the ID method, of an object
+func attrgenID(obj interface{}) interface{} {
+	v := reflect.ValueOf(obj)
+	for v.IsValid() {
+		isRef := v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface
+		if isRef && v.IsNil() {
+			return nil
+		}
+		if m := v.MethodByName("ID"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			return m.Call(nil)[0].Interface()
+		}
+		if !isRef {
+			break
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if id := v.FieldByName("ID"); id.IsValid() && id.CanInterface() {
+		return id.Interface()
+	}
+	return nil
+}
+
+// attrgenAppend appends an attribute for a scalar or enum value, skipping null values
+func attrgenAppend(attrs []gqlattr.KeyValue, key string, value interface{}) []gqlattr.KeyValue {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return attrs
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return attrs
+	case reflect.String:
+		return append(attrs, gqlattr.KeyValue{Key: key, Value: v.String()})
+	case reflect.Bool:
+		return append(attrs, gqlattr.KeyValue{Key: key, Value: v.Bool()})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return append(attrs, gqlattr.KeyValue{Key: key, Value: v.Int()})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return append(attrs, gqlattr.KeyValue{Key: key, Value: int64(v.Uint())})
+	case reflect.Float32, reflect.Float64:
+		return append(attrs, gqlattr.KeyValue{Key: key, Value: v.Float()})
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return append(attrs, gqlattr.KeyValue{Key: key, Value: s.String()})
+	}
+	return append(attrs, gqlattr.KeyValue{Key: key, Value: fmt.Sprint(v.Interface())})
+}
+`))
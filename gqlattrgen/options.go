@@ -0,0 +1,32 @@
+package gqlattrgen
+
+type (
+	// Option for the plugin
+	Option func(*config)
+
+	config struct {
+		filename string
+		pkg      string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		filename: DefaultFilename,
+	}
+}
+
+// WithFilename sets the name of the generated file. Relative names are resolved against the directory
+// of the executable schema. The default is DefaultFilename.
+func WithFilename(filename string) Option {
+	return func(c *config) {
+		c.filename = filename
+	}
+}
+
+// WithPackage sets the package of the generated file. The default is the package of the executable schema.
+func WithPackage(pkg string) Option {
+	return func(c *config) {
+		c.pkg = pkg
+	}
+}
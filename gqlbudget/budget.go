@@ -0,0 +1,177 @@
+// Package gqlbudget provides a gqlgen extension propagating the time budget of an operation to its resolvers,
+// so that downstream calls never exceed the deadline of the client.
+//
+// The budget of an operation is set as a deadline on the context of all its resolvers.
+// Resolvers may retrieve the remaining budget with RemainingBudget, and fields may be granted an automatic
+// sub-deadline, as a fraction of the remaining budget.
+//
+// Example:
+//
+//   srv.Use(gqlbudget.New(
+//     gqlbudget.WithTimeout(2*time.Second),
+//     gqlbudget.WithReserve(50*time.Millisecond),
+//     gqlbudget.WithFieldWeight("Query", "search", 0.8),
+//   ))
+package gqlbudget
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const extensionName = "TimeBudget"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = &Budget{}
+
+type (
+	// Budget is a gqlgen extension setting a deadline on the context of operations.
+	//
+	// The deadline of an operation is the earliest of the deadline already set on the request context,
+	// the deadline requested by the client and the configured timeout, minus a reserve kept to
+	// serialize the response.
+	//
+	// Subscriptions are not subject to a budget.
+	Budget struct {
+		*config
+	}
+
+	budgetKey struct{}
+)
+
+// New time budget extension
+func New(opts ...Option) *Budget {
+	b := &Budget{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(b.config)
+	}
+	return b
+}
+
+// RemainingBudget yields the time left before the deadline of the operation.
+//
+// The second return value is false when the context has no deadline.
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// TotalBudget yields the time budget allotted to the operation when it started.
+//
+// The second return value is false when the operation is not subject to a budget.
+func TotalBudget(ctx context.Context) (time.Duration, bool) {
+	total, ok := ctx.Value(budgetKey{}).(time.Duration)
+	return total, ok
+}
+
+// ExtensionName yields the extension name: "TimeBudget"
+func (*Budget) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Budget) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor
+func (b *Budget) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	deadline, ok := b.deadline(ctx)
+	if !ok {
+		return next(ctx)
+	}
+
+	ctx = context.WithValue(ctx, budgetKey{}, time.Until(deadline))
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	responses := next(ctx)
+
+	return func(ctx context.Context) *graphql.Response {
+		// queries and mutations produce a single response
+		defer cancel()
+		return responses(ctx)
+	}
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (b *Budget) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if len(b.config.weights) == 0 {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil {
+		return next(ctx)
+	}
+
+	weight, ok := b.config.weights[fieldKey(fc.Object, fc.Field.Name)]
+	if !ok {
+		return next(ctx)
+	}
+
+	remaining, ok := RemainingBudget(ctx)
+	if !ok {
+		return next(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(float64(remaining)*weight))
+	defer cancel()
+
+	return next(ctx)
+}
+
+// deadline computes the deadline of an operation
+func (b *Budget) deadline(ctx context.Context) (time.Time, bool) {
+	var (
+		deadline time.Time
+		ok       bool
+	)
+	now := time.Now()
+
+	earliest := func(timeout time.Duration) {
+		candidate := now.Add(timeout)
+		if !ok || candidate.Before(deadline) {
+			deadline, ok = candidate, true
+		}
+	}
+
+	if d, isSet := ctx.Deadline(); isSet {
+		earliest(d.Sub(now))
+	}
+	if b.config.clientTimeout != nil {
+		if timeout, isSet := b.config.clientTimeout(ctx); isSet && timeout > 0 {
+			earliest(timeout)
+		}
+	}
+	if b.config.timeout > 0 {
+		earliest(b.config.timeout)
+	}
+
+	if !ok {
+		return deadline, false
+	}
+	return deadline.Add(-b.config.reserve), true
+}
+
+func fieldKey(object, field string) string {
+	return object + "." + field
+}
@@ -0,0 +1,65 @@
+package gqlbudget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestBudget(t *testing.T) {
+	b := New(
+		WithTimeout(time.Minute),
+		WithClientTimeout(func(_ context.Context) (time.Duration, bool) { return 10 * time.Second, true }),
+		WithReserve(time.Second),
+		WithFieldWeight("Query", "search", 0.5),
+	)
+	require.Equal(t, extensionName, b.ExtensionName())
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+
+	var (
+		opCtx    context.Context
+		fieldCtx context.Context
+	)
+	responses := b.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		opCtx = ctx
+		return func(ctx context.Context) *graphql.Response {
+			fc := &graphql.FieldContext{
+				Object: "Query",
+				Field:  graphql.CollectedField{Field: &ast.Field{Name: "search"}},
+			}
+			_, _ = b.InterceptField(graphql.WithFieldContext(ctx, fc), func(ctx context.Context) (interface{}, error) {
+				fieldCtx = ctx
+				return nil, nil
+			})
+			return &graphql.Response{}
+		}
+	})
+
+	remaining, ok := RemainingBudget(opCtx)
+	require.True(t, ok)
+	assert.True(t, remaining <= 9*time.Second && remaining > 8*time.Second, "unexpected budget: %v", remaining)
+
+	total, ok := TotalBudget(opCtx)
+	require.True(t, ok)
+	assert.True(t, total <= 9*time.Second)
+
+	require.NotNil(t, responses(opCtx))
+
+	fieldRemaining, ok := RemainingBudget(fieldCtx)
+	require.True(t, ok)
+	assert.True(t, fieldRemaining <= 5*time.Second, "unexpected field budget: %v", fieldRemaining)
+
+	// the budget is released once the response is produced
+	require.Error(t, opCtx.Err())
+
+	_, ok = RemainingBudget(context.Background())
+	require.False(t, ok)
+}
@@ -0,0 +1,64 @@
+package gqlbudget
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Option for the time budget extension
+	Option func(*config)
+
+	config struct {
+		timeout       time.Duration
+		clientTimeout func(context.Context) (time.Duration, bool)
+		reserve       time.Duration
+		weights       map[string]float64
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithTimeout sets the maximum duration of operations. By default, operations are only bound by the
+// deadline of the request context, if any.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithClientTimeout sets a function to retrieve the timeout requested by the client, e.g. from a header
+// captured in the context. The earliest of the configured and requested timeouts applies.
+func WithClientTimeout(clientTimeout func(context.Context) (time.Duration, bool)) Option {
+	return func(c *config) {
+		c.clientTimeout = clientTimeout
+	}
+}
+
+// WithReserve sets a duration kept out of the budget of resolvers, e.g. to serialize the response and send it
+// before the client gives up. By default, there is no reserve.
+func WithReserve(reserve time.Duration) Option {
+	return func(c *config) {
+		c.reserve = reserve
+	}
+}
+
+// WithFieldWeight grants a sub-deadline to the resolver of a field, as a fraction between 0 and 1 of the budget
+// remaining when the field starts resolving.
+//
+// Example:
+//
+//   WithFieldWeight("Query", "search", 0.5)
+func WithFieldWeight(object, field string, weight float64) Option {
+	return func(c *config) {
+		if weight <= 0 || weight > 1 {
+			return
+		}
+		if c.weights == nil {
+			c.weights = make(map[string]float64)
+		}
+		c.weights[fieldKey(object, field)] = weight
+	}
+}
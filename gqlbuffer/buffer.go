@@ -0,0 +1,217 @@
+// Package gqlbuffer provides a gqlgen extension bounding the buffer of events of each subscription.
+//
+// Without it, a slow websocket consumer blocks its subscription resolver, and the events published meanwhile
+// pile up in the application, with unbounded memory growth. With it, events are pumped from the resolver into
+// a bounded buffer, drained by the transport at the pace of the consumer. On overflow, the buffer drops its oldest
+// event, drops the newest one, or coalesces the newest one into the last buffered event.
+//
+// The occupancy of buffers and the number of dropped events are reported as opencensus metrics.
+//
+// Example:
+//
+//   srv.AddTransport(transport.Websocket{})
+//   srv.Use(gqlbuffer.New(
+//     gqlbuffer.WithBufferSize(50),
+//     gqlbuffer.WithPolicy(gqlbuffer.DropOldest),
+//   ))
+package gqlbuffer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "SubscriptionBuffer"
+
+// Policies applied when a buffer overflows
+const (
+	// DropOldest drops the oldest buffered event, to make room for the newest one
+	DropOldest Policy = iota
+
+	// DropNewest drops the newest event, keeping the buffered ones
+	DropNewest
+
+	// Coalesce merges the newest event into the last buffered event (see WithCoalesce)
+	Coalesce
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Buffer{}
+
+type (
+	// Policy applied when a buffer overflows
+	Policy int
+
+	// Buffer is a gqlgen extension bounding the buffer of events of each subscription
+	Buffer struct {
+		*config
+	}
+
+	// queue of the events of a subscription
+	queue struct {
+		mx       sync.Mutex
+		events   []*graphql.Response
+		done     bool
+		notify   chan struct{}
+		size     int
+		policy   Policy
+		coalesce func(buffered, event *graphql.Response) *graphql.Response
+	}
+)
+
+// New subscription Buffer extension
+func New(opts ...Option) *Buffer {
+	b := &Buffer{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(b.config)
+	}
+	return b
+}
+
+// ExtensionName yields the extension name: "SubscriptionBuffer"
+func (*Buffer) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Buffer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor.
+//
+// The events of subscriptions are pumped into a bounded buffer, as soon as the transport asks for the first one.
+// Other operations are left untouched.
+func (b *Buffer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Subscription {
+		return next(ctx)
+	}
+
+	responses := next(ctx)
+	tags := []tag.Mutator{
+		tag.Upsert(metrics.TagOperation, b.config.opLabel(oc)),
+		tag.Upsert(TagPolicy, b.config.policy.String()),
+	}
+	q := &queue{
+		notify:   make(chan struct{}, 1),
+		size:     b.config.size,
+		policy:   b.config.policy,
+		coalesce: b.config.coalesce,
+	}
+
+	var once sync.Once
+	return func(ctx context.Context) *graphql.Response {
+		once.Do(func() {
+			go q.pump(ctx, responses, tags)
+		})
+		return q.pop(ctx)
+	}
+}
+
+func (p Policy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop_oldest"
+	case DropNewest:
+		return "drop_newest"
+	case Coalesce:
+		return "coalesce"
+	default:
+		return "unknown"
+	}
+}
+
+// pump events from the resolver into the queue, until the subscription ends
+func (q *queue) pump(ctx context.Context, responses graphql.ResponseHandler, tags []tag.Mutator) {
+	for {
+		resp := responses(ctx)
+		if resp == nil {
+			q.close()
+			return
+		}
+
+		occupancy, dropped := q.push(resp)
+		measurements := []stats.Measurement{SubscriptionBufferOccupancy.M(int64(occupancy))}
+		if dropped {
+			measurements = append(measurements, SubscriptionEventsDropped.M(1))
+		}
+		_ = stats.RecordWithTags(ctx, tags, measurements...)
+	}
+}
+
+// push an event, applying the overflow policy. This yields the occupancy of the queue, and tells if an event was dropped.
+func (q *queue) push(event *graphql.Response) (int, bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	dropped := false
+	switch {
+	case len(q.events) < q.size:
+		q.events = append(q.events, event)
+	case q.policy == DropNewest:
+		dropped = true
+	case q.policy == Coalesce && len(q.events) > 0:
+		last := len(q.events) - 1
+		q.events[last] = q.coalesce(q.events[last], event)
+		dropped = true
+	default:
+		q.events[0] = nil
+		q.events = append(q.events[1:], event)
+		dropped = true
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return len(q.events), dropped
+}
+
+func (q *queue) close() {
+	q.mx.Lock()
+	q.done = true
+	q.mx.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop the next event, waiting for it. This yields nil when the subscription ends, or when the context is done.
+func (q *queue) pop(ctx context.Context) *graphql.Response {
+	for {
+		q.mx.Lock()
+		if len(q.events) > 0 {
+			event := q.events[0]
+			q.events[0] = nil
+			q.events = q.events[1:]
+			q.mx.Unlock()
+			return event
+		}
+		done := q.done
+		q.mx.Unlock()
+
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.notify:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package gqlbuffer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func event(i int) *graphql.Response {
+	return &graphql.Response{Data: json.RawMessage(strconv.Itoa(i))}
+}
+
+func drain(q *queue) []string {
+	q.close()
+	var data []string
+	for resp := q.pop(context.Background()); resp != nil; resp = q.pop(context.Background()) {
+		data = append(data, string(resp.Data))
+	}
+	return data
+}
+
+func TestPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy   Policy
+		expected []string
+	}{
+		{policy: DropOldest, expected: []string{"3", "4", "5"}},
+		{policy: DropNewest, expected: []string{"1", "2", "3"}},
+		{policy: Coalesce, expected: []string{"1", "2", "5"}},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			q := &queue{notify: make(chan struct{}, 1), size: 3, policy: tc.policy, coalesce: keepNewest}
+			dropped := 0
+			for i := 1; i <= 5; i++ {
+				occupancy, drop := q.push(event(i))
+				assert.True(t, occupancy <= 3)
+				if drop {
+					dropped++
+				}
+			}
+			assert.Equal(t, 2, dropped)
+			assert.Equal(t, tc.expected, drain(q))
+		})
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	b := New(WithBufferSize(10))
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "OnTodo", Operation: ast.Subscription},
+	})
+
+	sent := 0
+	handler := b.InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler {
+		return func(context.Context) *graphql.Response {
+			if sent == 5 {
+				return nil
+			}
+			sent++
+			return event(sent)
+		}
+	})
+
+	var received []string
+	for resp := handler(ctx); resp != nil; resp = handler(ctx) {
+		received = append(received, string(resp.Data))
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, received)
+}
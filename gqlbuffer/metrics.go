@@ -0,0 +1,66 @@
+package gqlbuffer
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(BufferViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(BufferViews...)
+}
+
+var (
+	// BufferViews contains all opencensus stats views declared by the subscription buffer extension
+	BufferViews = []*view.View{
+		SubscriptionBufferOccupancyView,
+		SubscriptionEventsDroppedView,
+	}
+
+	// SubscriptionBufferOccupancy tracks the number of events buffered for subscriptions, as events are pushed
+	SubscriptionBufferOccupancy = stats.Int64(
+		"gql/server/subscription_buffer_occupancy",
+		"Number of events buffered for GraphQL subscriptions",
+		stats.UnitDimensionless)
+
+	// SubscriptionEventsDropped counts the events of subscriptions dropped or coalesced on buffer overflow
+	SubscriptionEventsDropped = stats.Int64(
+		"gql/server/subscription_events_dropped",
+		"Number of events of GraphQL subscriptions dropped on buffer overflow",
+		stats.UnitDimensionless)
+
+	// OccupancyDistribution constructs buckets for buffer occupancy distributions in views
+	OccupancyDistribution = view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000)
+
+	// SubscriptionBufferOccupancyView reports a distribution of the occupancy of buffers, by operation
+	SubscriptionBufferOccupancyView = &view.View{
+		Name:        "gql/server/subscription_buffer_occupancy",
+		Description: "Distribution of the number of events buffered for GraphQL subscriptions by operation",
+		Measure:     SubscriptionBufferOccupancy,
+		Aggregation: OccupancyDistribution,
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// SubscriptionEventsDroppedView reports the count of dropped events, by operation and policy
+	SubscriptionEventsDroppedView = &view.View{
+		Name:        "gql/server/subscription_events_dropped",
+		Description: "Count of events of GraphQL subscriptions dropped on buffer overflow by operation and policy",
+		Measure:     SubscriptionEventsDropped,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, TagPolicy},
+	}
+
+	// TagPolicy is the overflow policy of the buffer
+	TagPolicy = tag.MustNewKey("gql.buffer_policy")
+)
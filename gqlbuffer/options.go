@@ -0,0 +1,69 @@
+package gqlbuffer
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the subscription buffer extension
+	Option func(*config)
+
+	config struct {
+		size     int
+		policy   Policy
+		coalesce func(buffered, event *graphql.Response) *graphql.Response
+		opLabel  gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		size:     100,
+		policy:   DropOldest,
+		coalesce: keepNewest,
+		opLabel:  gqllabel.OperationName,
+	}
+}
+
+// keepNewest is the default coalescing function: the newest event replaces the last buffered one
+func keepNewest(_, event *graphql.Response) *graphql.Response {
+	return event
+}
+
+// WithBufferSize sets the maximum number of events buffered per subscription. The default is 100.
+func WithBufferSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.size = size
+		}
+	}
+}
+
+// WithPolicy sets the policy applied when a buffer overflows. The default is DropOldest.
+func WithPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithCoalesce applies the Coalesce policy, merging the newest event into the last buffered one with a function.
+//
+// By default, the newest event replaces the last buffered one: this suits subscriptions pushing the latest
+// state of an entity.
+func WithCoalesce(coalesce func(buffered, event *graphql.Response) *graphql.Response) Option {
+	return func(c *config) {
+		c.policy = Coalesce
+		c.coalesce = coalesce
+	}
+}
+
+// WithOperationLabel sets the function identifying operations. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of metrics.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
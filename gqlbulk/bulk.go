@@ -0,0 +1,344 @@
+// Package gqlbulk provides a gqlgen extension guarding mutations operating on large input lists.
+//
+// When a list argument of a mutation exceeds the threshold configured for this mutation, the guard applies one
+// of these actions:
+//
+//   - ActionReject: the mutation fails with the code BULK_OPERATION_REJECTED
+//   - ActionConfirm: the mutation fails with the code BULK_CONFIRMATION_REQUIRED, unless the client explicitly
+//     confirms the bulk operation with a boolean argument, e.g. createUsers(input: [...], confirm: true)
+//   - ActionSplit: the resolver is called on successive chunks of the list, and the results are concatenated.
+//     If a chunk fails, the results of the previous chunks are returned, along with an error with the code
+//     BULK_PARTIAL_FAILURE telling how many items were processed.
+//
+// Every bulk operation is reported as a Record to an audit Sink.
+//
+// Example:
+//
+//   srv.Use(gqlbulk.New(
+//     gqlbulk.WithRule("deleteUsers", 100, gqlbulk.ActionConfirm),
+//     gqlbulk.WithRule("importProducts", 500, gqlbulk.ActionSplit),
+//     gqlbulk.WithDefaultRule(1000, gqlbulk.ActionReject),
+//     gqlbulk.WithSink(gqlbulk.NewWriterSink(auditLog)),
+//   ))
+//
+// Only list arguments of mutation fields are considered, not lists nested in input objects. The confirmation
+// argument must be declared in the schema.
+//
+// Splitting requires the mutation to return a list. It relies on resolvers generated by gqlgen, which get their
+// arguments from the map exposed by the field context: the argument is replaced by each chunk in turn.
+// Mutations returning a single value are rejected rather than split.
+package gqlbulk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const extensionName = "BulkGuard"
+
+// Error codes
+const (
+	CodeRejected             = "BULK_OPERATION_REJECTED"
+	CodeConfirmationRequired = "BULK_CONFIRMATION_REQUIRED"
+	CodePartialFailure       = "BULK_PARTIAL_FAILURE"
+)
+
+// Action taken on bulk operations
+const (
+	ActionReject  Action = "reject"
+	ActionConfirm Action = "confirm"
+	ActionSplit   Action = "split"
+)
+
+// Outcome of bulk operations
+const (
+	OutcomeRejected    Outcome = "rejected"
+	OutcomeUnconfirmed Outcome = "unconfirmed"
+	OutcomeConfirmed   Outcome = "confirmed"
+	OutcomeSplit       Outcome = "split"
+	OutcomePartial     Outcome = "partial"
+	OutcomeFailed      Outcome = "failed"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Guard{}
+
+type (
+	// Action taken when a mutation operates on a list larger than its threshold
+	Action string
+
+	// Outcome of a bulk operation, as recorded for audit
+	Outcome string
+
+	// Rule guarding a mutation
+	Rule struct {
+		Threshold int
+		Action    Action
+
+		// ChunkSize is the size of chunks with ActionSplit. The default is the threshold.
+		ChunkSize int
+	}
+
+	// Record of a bulk operation, for audit
+	Record struct {
+		Time          time.Time `json:"time"`
+		OperationName string    `json:"operationName,omitempty"`
+		Mutation      string    `json:"mutation"`
+		Argument      string    `json:"argument"`
+		Size          int       `json:"size"`
+		Threshold     int       `json:"threshold"`
+		Action        Action    `json:"action"`
+		Outcome       Outcome   `json:"outcome"`
+
+		// Chunks and Processed items, with ActionSplit
+		Chunks    int `json:"chunks,omitempty"`
+		Processed int `json:"processed,omitempty"`
+
+		Error string `json:"error,omitempty"`
+	}
+
+	// Sink stores audit records of bulk operations
+	Sink interface {
+		Record(context.Context, Record) error
+	}
+
+	// SinkFunc is a function implementing Sink
+	SinkFunc func(context.Context, Record) error
+
+	// WriterSink writes audit records as JSON lines. It is safe for concurrent use.
+	WriterSink struct {
+		mx      sync.Mutex
+		encoder *json.Encoder
+	}
+
+	// Guard is a gqlgen extension guarding mutations operating on large input lists
+	Guard struct {
+		*config
+	}
+)
+
+// Record implements Sink
+func (f SinkFunc) Record(ctx context.Context, record Record) error {
+	return f(ctx, record)
+}
+
+// NewWriterSink builds a Sink writing JSON lines to a writer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{encoder: json.NewEncoder(w)}
+}
+
+// Record implements Sink
+func (s *WriterSink) Record(_ context.Context, record Record) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.encoder.Encode(record)
+}
+
+// New bulk mutation Guard
+func New(opts ...Option) *Guard {
+	g := &Guard{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(g.config)
+	}
+	return g
+}
+
+// ExtensionName yields the extension name: "BulkGuard"
+func (*Guard) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Guard) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (g *Guard) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Parent != nil || fc.Object != "Mutation" || fc.Field.Field == nil {
+		return next(ctx)
+	}
+	rule, ok := g.rule(fc.Field.Name)
+	if !ok {
+		return next(ctx)
+	}
+	argument, list := largestList(fc.Args)
+	if list.Len() <= rule.Threshold {
+		return next(ctx)
+	}
+
+	record := Record{
+		Time:          g.config.clock(),
+		OperationName: graphql.GetOperationContext(ctx).OperationName,
+		Mutation:      fc.Field.Name,
+		Argument:      argument,
+		Size:          list.Len(),
+		Threshold:     rule.Threshold,
+		Action:        rule.Action,
+	}
+
+	var (
+		res interface{}
+		err error
+	)
+	switch {
+	case rule.Action == ActionConfirm && g.confirmed(fc):
+		record.Outcome = OutcomeConfirmed
+		res, err = next(ctx)
+		if err != nil {
+			record.Outcome = OutcomeFailed
+		}
+	case rule.Action == ActionConfirm:
+		record.Outcome = OutcomeUnconfirmed
+		err = g.bulkError(fc, CodeConfirmationRequired, record,
+			"this mutation operates on %d items: confirm with the %q argument", record.Size, g.config.confirmArgument)
+	case rule.Action == ActionSplit && fc.Field.Definition != nil && fc.Field.Definition.Type.Elem != nil:
+		res, err = g.split(ctx, fc, argument, list, rule, &record, next)
+	default:
+		record.Outcome = OutcomeRejected
+		err = g.bulkError(fc, CodeRejected, record,
+			"this mutation operates on %d items, exceeding the limit of %d", record.Size, record.Threshold)
+	}
+
+	if err != nil && record.Error == "" {
+		record.Error = err.Error()
+	}
+	g.audit(ctx, record)
+
+	return res, err
+}
+
+// split calls the resolver on successive chunks of the list argument, and concatenates the results
+func (g *Guard) split(ctx context.Context, fc *graphql.FieldContext, argument string, list reflect.Value, rule Rule,
+	record *Record, next graphql.Resolver) (interface{}, error) {
+	original := fc.Args[argument]
+	defer func() {
+		fc.Args[argument] = original
+	}()
+
+	size := rule.ChunkSize
+	if size <= 0 {
+		size = rule.Threshold
+	}
+	if size <= 0 {
+		size = 1
+	}
+
+	var results reflect.Value
+	for start := 0; start < list.Len(); start += size {
+		end := start + size
+		if end > list.Len() {
+			end = list.Len()
+		}
+		fc.Args[argument] = list.Slice(start, end).Interface()
+
+		res, err := next(ctx)
+		if err == nil {
+			rv := reflect.ValueOf(res)
+			if rv.Kind() != reflect.Slice {
+				err = gqlerror.Errorf("cannot split a mutation returning %T", res)
+			} else {
+				if !results.IsValid() {
+					results = reflect.MakeSlice(rv.Type(), 0, list.Len())
+				}
+				results = reflect.AppendSlice(results, rv)
+			}
+		}
+
+		if err != nil {
+			record.Error = err.Error()
+			if record.Chunks == 0 {
+				record.Outcome = OutcomeFailed
+				return nil, err
+			}
+			record.Outcome = OutcomePartial
+			graphql.AddError(ctx, g.bulkError(fc, CodePartialFailure, *record,
+				"bulk operation interrupted after %d of %d items: %v", record.Processed, record.Size, err))
+			return results.Interface(), nil
+		}
+
+		record.Chunks++
+		record.Processed = end
+	}
+
+	record.Outcome = OutcomeSplit
+	if !results.IsValid() {
+		return nil, nil
+	}
+	return results.Interface(), nil
+}
+
+func (g *Guard) rule(mutation string) (Rule, bool) {
+	if rule, ok := g.config.rules[mutation]; ok {
+		return rule, true
+	}
+	if g.config.defaultRule != nil {
+		return *g.config.defaultRule, true
+	}
+	return Rule{}, false
+}
+
+func (g *Guard) confirmed(fc *graphql.FieldContext) bool {
+	switch value := fc.Args[g.config.confirmArgument].(type) {
+	case bool:
+		return value
+	case *bool:
+		return value != nil && *value
+	default:
+		return false
+	}
+}
+
+func (g *Guard) bulkError(fc *graphql.FieldContext, code string, record Record, format string, args ...interface{}) *gqlerror.Error {
+	err := gqlerror.ErrorPathf(fc.Path(), format, args...)
+	err.Extensions = map[string]interface{}{
+		"code":      code,
+		"argument":  record.Argument,
+		"size":      record.Size,
+		"threshold": record.Threshold,
+	}
+	if code == CodePartialFailure {
+		err.Extensions["processed"] = record.Processed
+	}
+	return err
+}
+
+func (g *Guard) audit(ctx context.Context, record Record) {
+	if g.config.sink == nil {
+		return
+	}
+	if err := g.config.sink.Record(ctx, record); err != nil && g.config.onError != nil {
+		g.config.onError(ctx, err)
+	}
+}
+
+// largestList finds the largest list argument
+func largestList(args map[string]interface{}) (string, reflect.Value) {
+	var (
+		argument string
+		largest  = reflect.ValueOf([]interface{}{})
+	)
+	for name, value := range args {
+		rv := reflect.ValueOf(value)
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Slice || rv.Len() <= largest.Len() {
+			continue
+		}
+		argument, largest = name, rv
+	}
+	return argument, largest
+}
@@ -0,0 +1,126 @@
+package gqlbulk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func mutationContext(name string, args map[string]interface{}) (context.Context, *graphql.FieldContext) {
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "bulk"})
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+	fc := &graphql.FieldContext{
+		Object: "Mutation",
+		Args:   args,
+		Field: graphql.CollectedField{
+			Field: &ast.Field{
+				Name:       name,
+				Definition: &ast.FieldDefinition{Name: name, Type: ast.ListType(ast.NamedType("ID", nil), nil)},
+			},
+		},
+	}
+	return graphql.WithFieldContext(ctx, fc), fc
+}
+
+func code(t testing.TB, err error) string {
+	var gqlErr *gqlerror.Error
+	require.True(t, errors.As(err, &gqlErr))
+	return gqlErr.Extensions["code"].(string)
+}
+
+func TestGuard(t *testing.T) {
+	var records []Record
+	g := New(
+		WithRule("deleteUsers", 2, ActionConfirm),
+		WithRule("importUsers", 2, ActionSplit),
+		WithDefaultRule(3, ActionReject),
+		WithSink(SinkFunc(func(_ context.Context, record Record) error {
+			records = append(records, record)
+			return nil
+		})),
+	)
+
+	// echo the ids passed to the resolver, as gqlgen resolvers get their arguments from the field context
+	var calls int
+	resolver := func(fc *graphql.FieldContext) graphql.Resolver {
+		return func(_ context.Context) (interface{}, error) {
+			calls++
+			if ids := fc.Args["ids"].([]string); len(ids) > 0 && ids[0] == "fail" {
+				return nil, errors.New("failed")
+			}
+			return fc.Args["ids"], nil
+		}
+	}
+
+	t.Run("small lists are not guarded", func(t *testing.T) {
+		ctx, fc := mutationContext("deleteUsers", map[string]interface{}{"ids": []string{"1", "2"}})
+		res, err := g.InterceptField(ctx, resolver(fc))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, res)
+		assert.Empty(t, records)
+	})
+
+	t.Run("confirmation is required", func(t *testing.T) {
+		ctx, fc := mutationContext("deleteUsers", map[string]interface{}{"ids": []string{"1", "2", "3"}})
+		_, err := g.InterceptField(ctx, resolver(fc))
+		assert.Equal(t, CodeConfirmationRequired, code(t, err))
+
+		ctx, fc = mutationContext("deleteUsers", map[string]interface{}{"ids": []string{"1", "2", "3"}, "confirm": true})
+		res, err := g.InterceptField(ctx, resolver(fc))
+		require.NoError(t, err)
+		assert.Len(t, res, 3)
+
+		require.Len(t, records, 2)
+		assert.Equal(t, OutcomeUnconfirmed, records[0].Outcome)
+		assert.Equal(t, OutcomeConfirmed, records[1].Outcome)
+		assert.Equal(t, "ids", records[1].Argument)
+		assert.Equal(t, 3, records[1].Size)
+	})
+
+	t.Run("large lists are rejected", func(t *testing.T) {
+		records = nil
+		ctx, fc := mutationContext("updateUsers", map[string]interface{}{"ids": []string{"1", "2", "3", "4"}})
+		_, err := g.InterceptField(ctx, resolver(fc))
+		assert.Equal(t, CodeRejected, code(t, err))
+		require.Len(t, records, 1)
+		assert.Equal(t, OutcomeRejected, records[0].Outcome)
+	})
+
+	t.Run("large lists are split", func(t *testing.T) {
+		records, calls = nil, 0
+		ctx, fc := mutationContext("importUsers", map[string]interface{}{"ids": []string{"1", "2", "3", "4", "5"}})
+		res, err := g.InterceptField(ctx, resolver(fc))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, res)
+		assert.Equal(t, 3, calls)
+		assert.Len(t, fc.Args["ids"], 5, "the argument is restored")
+
+		require.Len(t, records, 1)
+		assert.Equal(t, OutcomeSplit, records[0].Outcome)
+		assert.Equal(t, 3, records[0].Chunks)
+		assert.Equal(t, 5, records[0].Processed)
+	})
+
+	t.Run("split with partial results", func(t *testing.T) {
+		records = nil
+		ctx, fc := mutationContext("importUsers", map[string]interface{}{"ids": []string{"1", "2", "fail", "4"}})
+		res, err := g.InterceptField(ctx, resolver(fc))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, res)
+
+		errs := graphql.GetErrors(ctx)
+		require.Len(t, errs, 1)
+		assert.Equal(t, CodePartialFailure, errs[0].Extensions["code"])
+		assert.Equal(t, 2, errs[0].Extensions["processed"])
+
+		require.Len(t, records, 1)
+		assert.Equal(t, OutcomePartial, records[0].Outcome)
+		assert.Equal(t, "failed", records[0].Error)
+	})
+}
@@ -0,0 +1,83 @@
+package gqlbulk
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Option for the bulk mutation guard
+	Option func(*config)
+
+	config struct {
+		rules           map[string]Rule
+		defaultRule     *Rule
+		confirmArgument string
+		sink            Sink
+		onError         func(context.Context, error)
+		clock           func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		rules:           make(map[string]Rule),
+		confirmArgument: "confirm",
+		clock:           time.Now,
+	}
+}
+
+// WithRule guards a mutation: when one of its list arguments has more items than the threshold, the action applies.
+func WithRule(mutation string, threshold int, action Action) Option {
+	return func(c *config) {
+		c.rules[mutation] = Rule{Threshold: threshold, Action: action}
+	}
+}
+
+// WithDefaultRule guards all mutations without a specific rule. By default, only mutations with a rule are guarded.
+func WithDefaultRule(threshold int, action Action) Option {
+	return func(c *config) {
+		c.defaultRule = &Rule{Threshold: threshold, Action: action}
+	}
+}
+
+// WithChunkSize sets the size of the chunks of a mutation guarded with ActionSplit. The default is its threshold.
+//
+// This option must follow the rule of the mutation.
+func WithChunkSize(mutation string, size int) Option {
+	return func(c *config) {
+		if rule, ok := c.rules[mutation]; ok {
+			rule.ChunkSize = size
+			c.rules[mutation] = rule
+		}
+	}
+}
+
+// WithConfirmArgument sets the name of the boolean argument confirming bulk operations guarded with ActionConfirm.
+// The default is "confirm".
+func WithConfirmArgument(name string) Option {
+	return func(c *config) {
+		c.confirmArgument = name
+	}
+}
+
+// WithSink sets the Sink of the audit records of bulk operations. By default, bulk operations are not audited.
+func WithSink(sink Sink) Option {
+	return func(c *config) {
+		c.sink = sink
+	}
+}
+
+// WithErrorHandler sets a handler for Sink errors. By default, errors are ignored.
+func WithErrorHandler(handler func(context.Context, error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithClock sets the clock timestamping records, e.g. for tests. The default is time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
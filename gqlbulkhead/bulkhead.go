@@ -0,0 +1,165 @@
+// Package gqlbulkhead provides a gqlgen extension limiting the number of concurrent executions of specific resolvers.
+//
+// This protects fragile downstream services behind some fields: each limited field gets a weighted semaphore,
+// and resolvers wait for their turn when the limit is reached. The time spent waiting is recorded as a metric,
+// and as the attribute "bulkhead.wait_ms" of the current trace span.
+//
+// Example:
+//
+//   srv.Use(gqlbulkhead.New(
+//     gqlbulkhead.WithLimit("Query.search", 10),
+//     gqlbulkhead.WithLimit("User.recommendations", 20),
+//     gqlbulkhead.WithWeight("User.recommendations", gqlbulkhead.ArgWeight("first", 10)),
+//     gqlbulkhead.WithMaxWait(500*time.Millisecond),
+//   ))
+package gqlbulkhead
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "Bulkhead"
+
+// AttributeWaitMs is the span attribute set with the time spent waiting for a slot, in milliseconds
+const AttributeWaitMs = "bulkhead.wait_ms"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Bulkhead{}
+
+var (
+	// ErrWaitTimeout is returned when a resolver waited too long for a slot
+	ErrWaitTimeout = errors.New("too many concurrent executions of this field")
+
+	// ErrTooHeavy is returned when the weight of a resolver exceeds the capacity of its limit
+	ErrTooHeavy = errors.New("the weight of this field exceeds its concurrency limit")
+)
+
+type (
+	// Weigher yields the weight of the execution of a resolver, e.g. from the size of the requested page.
+	// The default weight is 1.
+	Weigher func(*graphql.FieldContext) int64
+
+	// Bulkhead is a gqlgen extension limiting concurrent executions of resolvers
+	Bulkhead struct {
+		*config
+		semaphores map[string]*semaphore
+	}
+)
+
+// ArgWeight weighs the execution of a resolver by the value of an integer argument, e.g. the size of a page.
+// The default value applies when the argument is not set.
+func ArgWeight(argument string, defaultValue int64) Weigher {
+	return func(fc *graphql.FieldContext) int64 {
+		switch value := fc.Args[argument].(type) {
+		case int:
+			return int64(value)
+		case int64:
+			return value
+		case *int:
+			if value != nil {
+				return int64(*value)
+			}
+		case *int64:
+			if value != nil {
+				return *value
+			}
+		}
+		return defaultValue
+	}
+}
+
+// New Bulkhead extension
+func New(opts ...Option) *Bulkhead {
+	b := &Bulkhead{
+		config:     defaultConfig(),
+		semaphores: make(map[string]*semaphore),
+	}
+	for _, apply := range opts {
+		apply(b.config)
+	}
+	for field, capacity := range b.config.limits {
+		b.semaphores[field] = newSemaphore(capacity)
+	}
+	return b
+}
+
+// ExtensionName yields the extension name: "Bulkhead"
+func (*Bulkhead) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Bulkhead) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (b *Bulkhead) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil {
+		return next(ctx)
+	}
+	field := fc.Object + "." + fc.Field.Name
+	sem, ok := b.semaphores[field]
+	if !ok {
+		return next(ctx)
+	}
+
+	weight := int64(1)
+	if weigh, ok := b.config.weights[field]; ok {
+		weight = weigh(fc)
+	}
+	if weight < 1 {
+		weight = 1
+	}
+	if weight > sem.capacity {
+		return nil, ErrTooHeavy
+	}
+
+	start := b.config.clock()
+	err := b.acquire(ctx, sem, weight)
+	wait := b.config.clock().Sub(start)
+	b.record(ctx, field, wait)
+	if err != nil {
+		return nil, err
+	}
+	defer sem.release(weight)
+
+	return next(ctx)
+}
+
+func (b *Bulkhead) acquire(ctx context.Context, sem *semaphore, weight int64) error {
+	if b.config.maxWait <= 0 {
+		return sem.acquire(ctx, weight)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, b.config.maxWait)
+	defer cancel()
+	err := sem.acquire(waitCtx, weight)
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		return ErrWaitTimeout
+	}
+	return err
+}
+
+func (b *Bulkhead) record(ctx context.Context, field string, wait time.Duration) {
+	ms := float64(wait) / float64(time.Millisecond)
+	if span := trace.FromContext(ctx); span != nil && span.IsRecordingEvents() {
+		span.AddAttributes(trace.Float64Attribute(AttributeWaitMs, ms))
+	}
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.TagField, field)},
+		BulkheadWait.M(ms),
+	)
+}
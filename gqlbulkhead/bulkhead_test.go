@@ -0,0 +1,97 @@
+package gqlbulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func fieldContext(object, name string, args map[string]interface{}) context.Context {
+	return graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+		Object: object,
+		Args:   args,
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: name}},
+	})
+}
+
+func TestBulkhead(t *testing.T) {
+	b := New(
+		WithLimit("Query.search", 2),
+		WithWeight("Query.search", ArgWeight("first", 1)),
+		WithMaxWait(10*time.Millisecond),
+	)
+
+	ok := func(_ context.Context) (interface{}, error) {
+		return "ok", nil
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := b.InterceptField(fieldContext("Query", "search", nil), func(_ context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	// unlimited fields are not affected
+	res, err := b.InterceptField(fieldContext("Query", "other", nil), ok)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	// one slot left
+	_, err = b.InterceptField(fieldContext("Query", "search", nil), ok)
+	require.NoError(t, err)
+
+	// not enough room for this weight
+	_, err = b.InterceptField(fieldContext("Query", "search", map[string]interface{}{"first": 2}), ok)
+	assert.Equal(t, ErrWaitTimeout, err)
+
+	_, err = b.InterceptField(fieldContext("Query", "search", map[string]interface{}{"first": 3}), ok)
+	assert.Equal(t, ErrTooHeavy, err)
+
+	close(release)
+	require.NoError(t, <-done)
+
+	_, err = b.InterceptField(fieldContext("Query", "search", map[string]interface{}{"first": 2}), ok)
+	require.NoError(t, err)
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	s := newSemaphore(3)
+	require.NoError(t, s.acquire(context.Background(), 2))
+
+	// a heavy waiter is served before lighter ones arriving later
+	heavy := make(chan struct{})
+	go func() {
+		_ = s.acquire(context.Background(), 3)
+		close(heavy)
+	}()
+	for {
+		s.mx.Lock()
+		n := s.waiters.Len()
+		s.mx.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.acquire(ctx, 1))
+
+	s.release(2)
+	<-heavy
+	s.release(3)
+	require.NoError(t, s.acquire(context.Background(), 3))
+}
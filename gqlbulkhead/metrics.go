@@ -0,0 +1,39 @@
+package gqlbulkhead
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(BulkheadWaitView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(BulkheadWaitView)
+}
+
+var (
+	// BulkheadWait tracks the time spent by resolvers waiting for a slot, in milliseconds
+	BulkheadWait = stats.Float64(
+		"gql/server/bulkhead_wait",
+		"Time spent waiting for a concurrency slot",
+		stats.UnitMilliseconds)
+
+	// BulkheadWaitView reports a distribution of the time spent waiting for a slot, by field (in milliseconds)
+	BulkheadWaitView = &view.View{
+		Name:        "gql/server/bulkhead_wait",
+		Description: "Distribution of the time spent by GraphQL resolvers waiting for a concurrency slot by field",
+		Measure:     BulkheadWait,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{metrics.TagField},
+	}
+)
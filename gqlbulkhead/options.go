@@ -0,0 +1,51 @@
+package gqlbulkhead
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the bulkhead extension
+	Option func(*config)
+
+	config struct {
+		limits  map[string]int64
+		weights map[string]Weigher
+		maxWait time.Duration
+		clock   func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		limits:  make(map[string]int64),
+		weights: make(map[string]Weigher),
+		clock:   graphql.Now,
+	}
+}
+
+// WithLimit sets the capacity of concurrent executions of a field, identified as "Object.field", e.g. "Query.search".
+//
+// With the default weight, this is the maximum number of concurrent executions of the resolver.
+func WithLimit(field string, capacity int64) Option {
+	return func(c *config) {
+		c.limits[field] = capacity
+	}
+}
+
+// WithWeight sets the function weighing the executions of a limited field. By default, all executions weigh 1.
+func WithWeight(field string, weigher Weigher) Option {
+	return func(c *config) {
+		c.weights[field] = weigher
+	}
+}
+
+// WithMaxWait sets the maximum time a resolver may wait for a slot, after which the field fails with ErrWaitTimeout.
+// By default, resolvers wait until their context is done.
+func WithMaxWait(maxWait time.Duration) Option {
+	return func(c *config) {
+		c.maxWait = maxWait
+	}
+}
@@ -0,0 +1,87 @@
+package gqlbulkhead
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// semaphore is a weighted semaphore. Waiters are served in FIFO order, so that heavy acquisitions are not starved
+// by light ones.
+type semaphore struct {
+	capacity int64
+
+	mx      sync.Mutex
+	used    int64
+	waiters list.List
+}
+
+type waiter struct {
+	weight int64
+	ready  chan struct{}
+}
+
+func newSemaphore(capacity int64) *semaphore {
+	return &semaphore{capacity: capacity}
+}
+
+// acquire a weight, blocking until it is available or the context is done
+func (s *semaphore) acquire(ctx context.Context, weight int64) error {
+	s.mx.Lock()
+	if s.used+weight <= s.capacity && s.waiters.Len() == 0 {
+		s.used += weight
+		s.mx.Unlock()
+		return nil
+	}
+
+	w := waiter{weight: weight, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mx.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mx.Lock()
+		select {
+		case <-w.ready:
+			// acquired concurrently with the cancellation: give it back
+			s.used -= weight
+			s.notify()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			if front {
+				// a smaller waiter may now be served
+				s.notify()
+			}
+		}
+		s.mx.Unlock()
+		return ctx.Err()
+	}
+}
+
+// release a weight previously acquired
+func (s *semaphore) release(weight int64) {
+	s.mx.Lock()
+	s.used -= weight
+	s.notify()
+	s.mx.Unlock()
+}
+
+// notify waiters which may acquire their weight, in order. Must be called with the lock held.
+func (s *semaphore) notify() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(waiter)
+		if s.used+w.weight > s.capacity {
+			return
+		}
+		s.used += w.weight
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,119 @@
+// Package gqlcache provides cache store adapters for gqlgen caches, such as the one used by automatic persisted
+// queries (APQ), with consistent TTL semantics, context cancellation handling and hit/miss metrics.
+//
+// Adapters are provided for Memcached, DynamoDB and groupcache, as well as an in-memory store. Memcached and DynamoDB
+// clients are plugged in through minimal client interfaces, so this package does not depend on any vendor SDK.
+//
+// Example:
+//
+//   store := gqlcache.NewMemcachedStore(memcachedClient)
+//   srv.Use(extension.AutomaticPersistedQuery{
+//     Cache: gqlcache.New(store, gqlcache.WithTTL(24*time.Hour)),
+//   })
+package gqlcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+var _ graphql.Cache = &Cache{}
+
+// ErrNotSupported is returned by stores which do not support an operation
+var ErrNotSupported = errors.New("operation not supported by this cache store")
+
+type (
+	// Store is a cache store, with expiring entries.
+	//
+	// Get must report expired entries as missing. A zero TTL means that the entry never expires.
+	Store interface {
+		Get(ctx context.Context, key string) (value []byte, found bool, err error)
+		Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	}
+
+	// Cache adapts a Store to the graphql.Cache interface.
+	//
+	// Values are stored as strings, which is what automatic persisted queries expect.
+	Cache struct {
+		*config
+		store Store
+	}
+)
+
+// New Cache on top of a Store
+func New(store Store, opts ...Option) *Cache {
+	c := &Cache{
+		config: defaultConfig(),
+		store:  store,
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// Get implements graphql.Cache. Store errors are reported as misses.
+func (c *Cache) Get(ctx context.Context, key string) (interface{}, bool) {
+	ctx, cancel := c.config.context(ctx)
+	defer cancel()
+
+	value, found, err := c.store.Get(ctx, c.config.prefix+key)
+	tags := []tag.Mutator{tag.Upsert(TagCache, c.config.name)}
+	switch {
+	case err != nil:
+		_ = stats.RecordWithTags(ctx, tags, CacheErrors.M(1), CacheMisses.M(1))
+		return nil, false
+	case !found:
+		_ = stats.RecordWithTags(ctx, tags, CacheMisses.M(1))
+		return nil, false
+	default:
+		_ = stats.RecordWithTags(ctx, tags, CacheHits.M(1))
+		return string(value), true
+	}
+}
+
+// Add implements graphql.Cache. Only string and []byte values are supported: other values are ignored.
+//
+// Store errors are ignored, since a cache may always be missed.
+func (c *Cache) Add(ctx context.Context, key string, value interface{}) {
+	var buf []byte
+	switch v := value.(type) {
+	case string:
+		buf = []byte(v)
+	case []byte:
+		buf = v
+	default:
+		return
+	}
+
+	ctx, cancel := c.config.context(ctx)
+	defer cancel()
+
+	if err := c.store.Set(ctx, c.config.prefix+key, buf, c.config.ttl); err != nil {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagCache, c.config.name)}, CacheErrors.M(1))
+	}
+}
+
+// withContext runs a blocking call which does not support contexts, and returns early when the context is done.
+func withContext(ctx context.Context, call func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- call()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,116 @@
+package gqlcache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMemcached struct {
+	values      map[string][]byte
+	expirations map[string]int32
+}
+
+func (c *testMemcached) Get(key string) ([]byte, bool, error) {
+	v, ok := c.values[key]
+	return v, ok, nil
+}
+
+func (c *testMemcached) Set(key string, value []byte, expiration int32) error {
+	c.values[key] = value
+	c.expirations[key] = expiration
+	return nil
+}
+
+type testDynamoDB map[string]DynamoDBItem
+
+func (c testDynamoDB) GetItem(_ context.Context, table, key string) (DynamoDBItem, bool, error) {
+	item, ok := c[table+"/"+key]
+	return item, ok, nil
+}
+
+func (c testDynamoDB) PutItem(_ context.Context, table string, item DynamoDBItem) error {
+	c[table+"/"+item.Key] = item
+	return nil
+}
+
+func TestMemcached(t *testing.T) {
+	client := &testMemcached{values: make(map[string][]byte), expirations: make(map[string]int32)}
+	cache := New(NewMemcachedStore(client), WithTTL(time.Hour), WithPrefix("apq:"))
+	ctx := context.Background()
+
+	_, ok := cache.Get(ctx, "hash")
+	require.False(t, ok)
+
+	cache.Add(ctx, "hash", "query { todos { id } }")
+	value, ok := cache.Get(ctx, "hash")
+	require.True(t, ok)
+	assert.Equal(t, "query { todos { id } }", value)
+	assert.Equal(t, int32(3600), client.expirations["apq:hash"])
+
+	store := NewMemcachedStore(client)
+	assert.True(t, store.expiration(60*24*time.Hour) > int32(time.Now().Unix()))
+
+	long := strings.Repeat("k", 300)
+	assert.Len(t, memcachedKey(long), 64)
+	assert.Len(t, memcachedKey("with space"), 64)
+	assert.Equal(t, "valid", memcachedKey("valid"))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err := store.Get(cancelled, "apq:hash")
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestDynamoDB(t *testing.T) {
+	now := time.Now()
+	client := make(testDynamoDB)
+	store := NewDynamoDBStore(client, "cache")
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "key", []byte("value"), time.Minute))
+	value, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "value", string(value))
+
+	// expired items may linger in the table
+	store.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, found, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "key", []byte("value"), time.Minute))
+	require.NoError(t, store.Set(ctx, "forever", []byte("value"), 0))
+	value, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "value", string(value))
+
+	store.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, found, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.NotContains(t, store.entries, "key", "expired entries are removed")
+
+	_, found, err = store.Get(ctx, "forever")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = store.Get(cancelled, "forever")
+	assert.Equal(t, context.Canceled, err)
+}
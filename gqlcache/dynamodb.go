@@ -0,0 +1,63 @@
+package gqlcache
+
+import (
+	"context"
+	"time"
+)
+
+var _ Store = &DynamoDBStore{}
+
+type (
+	// DynamoDBItem is a cache entry stored in a DynamoDB table.
+	//
+	// ExpiresAt is a unix timestamp in seconds, which may be declared as the TTL attribute of the table.
+	// A zero ExpiresAt means that the item never expires.
+	DynamoDBItem struct {
+		Key       string
+		Value     []byte
+		ExpiresAt int64
+	}
+
+	// DynamoDBClient is the minimal interface of a DynamoDB client, e.g. implemented with the GetItem and PutItem
+	// operations of the AWS SDK.
+	DynamoDBClient interface {
+		GetItem(ctx context.Context, table, key string) (item DynamoDBItem, found bool, err error)
+		PutItem(ctx context.Context, table string, item DynamoDBItem) error
+	}
+
+	// DynamoDBStore is a Store backed by a DynamoDB table.
+	//
+	// DynamoDB deletes expired items lazily, possibly days after their expiration:
+	// expired items are reported as missing on read.
+	DynamoDBStore struct {
+		client DynamoDBClient
+		table  string
+		now    func() time.Time
+	}
+)
+
+// NewDynamoDBStore builds a Store backed by a DynamoDB table
+func NewDynamoDBStore(client DynamoDBClient, table string) *DynamoDBStore {
+	return &DynamoDBStore{client: client, table: table, now: time.Now}
+}
+
+// Get implements Store
+func (s *DynamoDBStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	item, found, err := s.client.GetItem(ctx, s.table, key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	if item.ExpiresAt > 0 && item.ExpiresAt <= s.now().Unix() {
+		return nil, false, nil
+	}
+	return item.Value, true, nil
+}
+
+// Set implements Store
+func (s *DynamoDBStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	item := DynamoDBItem{Key: key, Value: value}
+	if ttl > 0 {
+		item.ExpiresAt = s.now().Add(ttl + time.Second - 1).Unix()
+	}
+	return s.client.PutItem(ctx, s.table, item)
+}
@@ -0,0 +1,106 @@
+package gqlcache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang/groupcache"
+)
+
+var _ Store = &GroupcacheStore{}
+
+// errNotFound signals a missing key from the groupcache getter
+var errNotFound = errors.New("not found")
+
+// GroupcacheStore is a Store distributing reads over a groupcache group, loading entries from a backing Store.
+//
+// Writes go to the backing store. Since groupcache never invalidates entries, keys are versioned
+// by TTL period: an entry is loaded again from the backing store at the latest one TTL after it was first loaded.
+type GroupcacheStore struct {
+	group   *groupcache.Group
+	backing Store
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewGroupcacheStore builds a Store backed by a new groupcache group, with the given name and size in bytes.
+//
+// The ttl must match the one of the Cache using this store. Notice that groupcache group names must be unique.
+func NewGroupcacheStore(name string, cacheBytes int64, ttl time.Duration, backing Store) *GroupcacheStore {
+	s := &GroupcacheStore{
+		backing: backing,
+		ttl:     ttl,
+		now:     time.Now,
+	}
+	s.group = groupcache.NewGroup(name, cacheBytes, groupcache.GetterFunc(func(ctx context.Context, key string, dest groupcache.Sink) error {
+		entry, err := s.load(ctx, key)
+		if err != nil {
+			return err
+		}
+		return dest.SetBytes(entry)
+	}))
+	return s
+}
+
+// Get implements Store
+func (s *GroupcacheStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var entry []byte
+	if err := s.group.Get(ctx, s.versionedKey(key), groupcache.AllocatingByteSliceSink(&entry)); err != nil {
+		if err == errNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if len(entry) < 8 {
+		return nil, false, errors.New("invalid groupcache entry")
+	}
+
+	expiresAt := int64(binary.BigEndian.Uint64(entry[:8]))
+	if expiresAt > 0 && expiresAt <= s.now().UnixNano() {
+		return nil, false, nil
+	}
+	return entry[8:], true, nil
+}
+
+// Set implements Store
+func (s *GroupcacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.backing.Set(ctx, key, value, ttl)
+}
+
+func (s *GroupcacheStore) versionedKey(key string) string {
+	if s.ttl <= 0 {
+		return key
+	}
+	return key + "@" + strconv.FormatInt(s.now().UnixNano()/int64(s.ttl), 36)
+}
+
+// load an entry from the backing store, prefixed with its expiration time
+func (s *GroupcacheStore) load(ctx context.Context, versionedKey string) ([]byte, error) {
+	key := versionedKey
+	if s.ttl > 0 {
+		for i := len(key) - 1; i >= 0; i-- {
+			if key[i] == '@' {
+				key = key[:i]
+				break
+			}
+		}
+	}
+
+	value, found, err := s.backing.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNotFound
+	}
+
+	entry := make([]byte, 8+len(value))
+	if s.ttl > 0 {
+		binary.BigEndian.PutUint64(entry[:8], uint64(s.now().Add(s.ttl).UnixNano()))
+	}
+	copy(entry[8:], value)
+	return entry, nil
+}
@@ -0,0 +1,105 @@
+package gqlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// maxRelativeExpiration is the largest expiration memcached interprets as relative: beyond, it is a unix timestamp
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+var _ Store = &MemcachedStore{}
+
+type (
+	// MemcachedClient is the minimal interface of a memcached client.
+	//
+	// It is easily implemented on top of github.com/bradfitz/gomemcache:
+	//
+	//   type memcachedClient struct{ *memcache.Client }
+	//
+	//   func (c memcachedClient) Get(key string) ([]byte, bool, error) {
+	//     item, err := c.Client.Get(key)
+	//     if err == memcache.ErrCacheMiss {
+	//       return nil, false, nil
+	//     }
+	//     if err != nil {
+	//       return nil, false, err
+	//     }
+	//     return item.Value, true, nil
+	//   }
+	//
+	//   func (c memcachedClient) Set(key string, value []byte, expiration int32) error {
+	//     return c.Client.Set(&memcache.Item{Key: key, Value: value, Expiration: expiration})
+	//   }
+	MemcachedClient interface {
+		Get(key string) (value []byte, found bool, err error)
+		Set(key string, value []byte, expiration int32) error
+	}
+
+	// MemcachedStore is a Store backed by memcached.
+	//
+	// Since memcached clients do not support contexts, calls return as soon as the context is done,
+	// leaving the pending call to complete in the background.
+	MemcachedStore struct {
+		client MemcachedClient
+		now    func() time.Time
+	}
+)
+
+// NewMemcachedStore builds a Store backed by memcached
+func NewMemcachedStore(client MemcachedClient) *MemcachedStore {
+	return &MemcachedStore{client: client, now: time.Now}
+}
+
+// Get implements Store
+func (s *MemcachedStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var (
+		value []byte
+		found bool
+	)
+	err := withContext(ctx, func() error {
+		var err error
+		value, found, err = s.client.Get(memcachedKey(key))
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return value, found, nil
+}
+
+// Set implements Store
+func (s *MemcachedStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return withContext(ctx, func() error {
+		return s.client.Set(memcachedKey(key), value, s.expiration(ttl))
+	})
+}
+
+// expiration converts a TTL to a memcached expiration: relative seconds up to 30 days, a unix timestamp beyond.
+func (s *MemcachedStore) expiration(ttl time.Duration) int32 {
+	switch {
+	case ttl <= 0:
+		return 0
+	case ttl < time.Second:
+		return 1
+	case ttl <= maxRelativeExpiration:
+		return int32(ttl / time.Second)
+	default:
+		return int32(s.now().Add(ttl).Unix())
+	}
+}
+
+// memcachedKey hashes keys which are not valid memcached keys: too long, or with spaces or control characters
+func memcachedKey(key string) string {
+	valid := len(key) <= 250
+	for i := 0; valid && i < len(key); i++ {
+		valid = key[i] > ' ' && key[i] != 0x7f
+	}
+	if valid {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package gqlcache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = &MemoryStore{}
+
+type (
+	// MemoryStore is a Store keeping entries in memory, e.g. for tests or single instance deployments.
+	//
+	// Expired entries are removed when they are looked up: this store is not suited to an unbounded set of keys.
+	MemoryStore struct {
+		mx      sync.Mutex
+		entries map[string]memoryEntry
+		now     func() time.Time
+	}
+
+	memoryEntry struct {
+		value     []byte
+		expiresAt time.Time
+	}
+)
+
+// NewMemoryStore builds an in-memory Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expiresAt.IsZero() && !s.now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set implements Store
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = s.now().Add(ttl)
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.entries[key] = entry
+	return nil
+}
@@ -0,0 +1,78 @@
+package gqlcache
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the cache.
+func Register() error {
+	return gqlmetrics.Register(CacheViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(CacheViews...)
+}
+
+var (
+	// CacheViews contains all opencensus stats views declared by the cache adapters
+	CacheViews = []*view.View{
+		CacheHitsView,
+		CacheMissesView,
+		CacheErrorsView,
+	}
+
+	// CacheHits tracks a count of cache hits
+	CacheHits = stats.Int64(
+		"gql/server/cache_hits",
+		"Number of cache hits",
+		stats.UnitDimensionless)
+
+	// CacheMisses tracks a count of cache misses
+	CacheMisses = stats.Int64(
+		"gql/server/cache_misses",
+		"Number of cache misses",
+		stats.UnitDimensionless)
+
+	// CacheErrors tracks a count of cache store errors
+	CacheErrors = stats.Int64(
+		"gql/server/cache_errors",
+		"Number of cache store errors",
+		stats.UnitDimensionless)
+
+	// CacheHitsView reports a count of cache hits tagged by cache
+	CacheHitsView = &view.View{
+		Name:        "gql/server/cache_hits",
+		Description: "Count of cache hits by cache",
+		Measure:     CacheHits,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagCache},
+	}
+
+	// CacheMissesView reports a count of cache misses tagged by cache
+	CacheMissesView = &view.View{
+		Name:        "gql/server/cache_misses",
+		Description: "Count of cache misses by cache",
+		Measure:     CacheMisses,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagCache},
+	}
+
+	// CacheErrorsView reports a count of cache store errors tagged by cache
+	CacheErrorsView = &view.View{
+		Name:        "gql/server/cache_errors",
+		Description: "Count of cache store errors by cache",
+		Measure:     CacheErrors,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagCache},
+	}
+
+	// TagCache is the name of the cache
+	TagCache = tag.MustNewKey("gql.cache")
+)
@@ -0,0 +1,68 @@
+package gqlcache
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Option for a Cache
+	Option func(*config)
+
+	config struct {
+		name     string
+		prefix   string
+		ttl      time.Duration
+		staleTTL time.Duration
+		timeout  time.Duration
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		name: "apq",
+	}
+}
+
+func (c config) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// WithName sets the name of the cache, used as the "gql.cache" metrics tag. The default is "apq".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithPrefix sets a prefix for all keys, e.g. to share a store between several caches.
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithTTL sets the time to live of cache entries. By default, entries never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithTimeout sets the timeout of store calls. By default, store calls only end with the context of the request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithStaleWhileRevalidate serves entries of a ReadThrough cache for some time after they expire,
+// while they are refreshed in the background. This is disabled by default.
+func WithStaleWhileRevalidate(stale time.Duration) Option {
+	return func(c *config) {
+		c.staleTTL = stale
+	}
+}
@@ -0,0 +1,178 @@
+package gqlcache
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/internal/detach"
+)
+
+type (
+	// Loader loads the value of a missing cache entry, e.g. by resolving a field.
+	//
+	// The context of a loader carries the values of the request which started the load, but not its cancellation:
+	// loaders should bound their own execution time.
+	Loader func(ctx context.Context) ([]byte, error)
+
+	// ReadThrough is a cache loading missing entries, with protection against cache stampedes.
+	//
+	// Concurrent loads of the same key are coalesced into a single call to the loader.
+	// A request waiting for a load gives up when its own context is done, without interrupting the load.
+	// With WithStaleWhileRevalidate, expired entries are still served for a while, and refreshed in the background,
+	// so that a popular entry expiring does not cause a thundering herd on resolvers.
+	ReadThrough struct {
+		*config
+		store        Store
+		mx           sync.Mutex
+		loads        map[string]*pendingLoad
+		revalidating sync.Map
+		now          func() time.Time
+	}
+
+	pendingLoad struct {
+		done    chan struct{}
+		value   []byte
+		err     error
+		waiters int
+	}
+)
+
+// NewReadThrough builds a read-through cache on top of a Store
+func NewReadThrough(store Store, opts ...Option) *ReadThrough {
+	r := &ReadThrough{
+		config: defaultConfig(),
+		store:  store,
+		loads:  make(map[string]*pendingLoad),
+		now:    time.Now,
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// Get the value of a key, loading it when missing
+func (r *ReadThrough) Get(ctx context.Context, key string, load Loader) ([]byte, error) {
+	key = r.config.prefix + key
+	tags := []tag.Mutator{tag.Upsert(TagCache, r.config.name)}
+
+	value, freshUntil, found := r.lookup(ctx, key)
+	switch {
+	case found && (freshUntil.IsZero() || r.now().Before(freshUntil)):
+		_ = stats.RecordWithTags(ctx, tags, CacheHits.M(1))
+		return value, nil
+	case found:
+		// stale entry: serve it while refreshing in the background
+		_ = stats.RecordWithTags(ctx, tags, CacheHits.M(1))
+		r.revalidate(key, load)
+		return value, nil
+	default:
+		_ = stats.RecordWithTags(ctx, tags, CacheMisses.M(1))
+		return r.load(ctx, key, load)
+	}
+}
+
+func (r *ReadThrough) lookup(ctx context.Context, key string) ([]byte, time.Time, bool) {
+	lookupCtx, cancel := r.config.context(ctx)
+	defer cancel()
+
+	entry, found, err := r.store.Get(lookupCtx, key)
+	if err != nil {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagCache, r.config.name)}, CacheErrors.M(1))
+		return nil, time.Time{}, false
+	}
+	if !found || len(entry) < 8 {
+		return nil, time.Time{}, false
+	}
+
+	var freshUntil time.Time
+	if nanos := int64(binary.BigEndian.Uint64(entry[:8])); nanos > 0 {
+		freshUntil = time.Unix(0, nanos)
+	}
+	return entry[8:], freshUntil, true
+}
+
+// load the value of a key, coalescing concurrent loads
+func (r *ReadThrough) load(ctx context.Context, key string, load Loader) ([]byte, error) {
+	r.mx.Lock()
+	inflight, ok := r.loads[key]
+	if ok {
+		inflight.waiters++
+	} else {
+		inflight = &pendingLoad{done: make(chan struct{})}
+		r.loads[key] = inflight
+		go r.run(detach.Context(ctx), key, inflight, load)
+	}
+	r.mx.Unlock()
+
+	select {
+	case <-inflight.done:
+		return inflight.value, inflight.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run a load, then store the loaded value
+func (r *ReadThrough) run(ctx context.Context, key string, inflight *pendingLoad, load Loader) {
+	defer func() {
+		if p := recover(); p != nil {
+			inflight.value, inflight.err = nil, fmt.Errorf("cache loader panicked: %v", p)
+		}
+
+		r.mx.Lock()
+		delete(r.loads, key)
+		r.mx.Unlock()
+
+		close(inflight.done)
+	}()
+
+	inflight.value, inflight.err = load(ctx)
+	if inflight.err != nil {
+		return
+	}
+
+	setCtx, cancel := r.config.context(ctx)
+	defer cancel()
+	_ = r.store.Set(setCtx, key, r.entry(inflight.value), r.storeTTL())
+}
+
+// revalidate refreshes a stale entry in the background. At most one refresh per key is in flight.
+func (r *ReadThrough) revalidate(key string, load Loader) {
+	if _, inflight := r.revalidating.LoadOrStore(key, struct{}{}); inflight {
+		return
+	}
+
+	go func() {
+		defer r.revalidating.Delete(key)
+
+		ctx, cancel := r.config.context(context.Background())
+		defer cancel()
+
+		_, _ = r.load(ctx, key, load)
+	}()
+}
+
+// entry prefixes a value with the time until which it is fresh
+func (r *ReadThrough) entry(value []byte) []byte {
+	entry := make([]byte, 8+len(value))
+	if r.config.ttl > 0 {
+		binary.BigEndian.PutUint64(entry[:8], uint64(r.now().Add(r.config.ttl).UnixNano()))
+	}
+	copy(entry[8:], value)
+	return entry
+}
+
+// storeTTL is the time entries are retained by the store: fresh, then stale
+func (r *ReadThrough) storeTTL() time.Duration {
+	if r.config.ttl <= 0 {
+		return 0
+	}
+	return r.config.ttl + r.config.staleTTL
+}
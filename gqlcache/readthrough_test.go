@@ -0,0 +1,108 @@
+package gqlcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadThrough(t *testing.T) {
+	const n = 10
+	now := time.Now()
+	cache := NewReadThrough(NewMemoryStore(),
+		WithTTL(time.Minute),
+		WithStaleWhileRevalidate(time.Hour),
+	)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	var loads int32
+	started := make(chan struct{}, n)
+	release := make(chan struct{})
+	load := func(_ context.Context) ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		started <- struct{}{}
+		<-release
+		return []byte("value"), nil
+	}
+
+	// concurrent misses are coalesced into a single load
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.Get(ctx, "key", load)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", string(value))
+		}()
+	}
+	<-started
+	require.Eventually(t, func() bool {
+		cache.mx.Lock()
+		defer cache.mx.Unlock()
+		return cache.loads["key"] != nil && cache.loads["key"].waiters == n-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	// fresh entries are served from the cache
+	_, err := cache.Get(ctx, "key", load)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	// stale entries are served, then refreshed in the background
+	cache.now = func() time.Time { return now.Add(2 * time.Minute) }
+	value, err := cache.Get(ctx, "key", load)
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&loads) == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestReadThroughCancel(t *testing.T) {
+	type valueKey struct{}
+	cache := NewReadThrough(NewMemoryStore())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loaded := make(chan error, 1)
+	load := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		<-release
+		if ctx.Value(valueKey{}) != "request" {
+			loaded <- errors.New("the loader context does not carry request values")
+		} else {
+			loaded <- ctx.Err()
+		}
+		return []byte("value"), nil
+	}
+
+	// the request which started the load gives up, while the load goes on
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), valueKey{}, "request"))
+	errs := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, "key", load)
+		errs <- err
+	}()
+	<-started
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+
+	close(release)
+	require.NoError(t, <-loaded, "the load is not cancelled with the request")
+
+	value, err := cache.Get(context.Background(), "key", func(context.Context) ([]byte, error) {
+		return nil, errors.New("unexpected load")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+}
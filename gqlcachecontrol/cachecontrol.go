@@ -0,0 +1,378 @@
+// Package gqlcachecontrol provides a gqlgen extension computing the cache policy of responses
+// from @cacheControl hints, so that CDNs and edge caches may cache GraphQL GET requests.
+//
+// The directive follows the semantics of Apollo Server, also supported by Stellate (formerly GraphCDN):
+//
+//   enum CacheControlScope { PUBLIC PRIVATE }
+//
+//   directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean)
+//     on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+//
+//   type Post @cacheControl(maxAge: 240) {
+//     id: ID!
+//     votes: Int @cacheControl(maxAge: 30)
+//     readByCurrentUser: Boolean! @cacheControl(scope: PRIVATE)
+//   }
+//
+// Root fields and fields returning composite types default to a max age of 0, unless hinted otherwise:
+// by the field, or by the type it returns. Other fields inherit the max age of their parent.
+// Hints on resolved object types also apply to the fields returning an interface or a union.
+//
+// The policy of a response is the lowest max age of its fields, and is private if any field is private.
+// Responses to mutations, responses with errors and responses with a max age of 0 are not cacheable.
+//
+// GET requests for persisted queries are served by the PersistedGET transport, so that CDNs may cache them.
+//
+// The Middleware sets the corresponding Cache-Control header:
+//
+//   cc := gqlcachecontrol.New()
+//   srv.Use(cc)
+//   http.Handle("/query", cc.Middleware(srv))
+//
+// The directive should be implemented by gqlgen as a noop.
+package gqlcachecontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+const extensionName = "CacheControl"
+
+// Scopes of cache hints
+const (
+	Public  Scope = "PUBLIC"
+	Private Scope = "PRIVATE"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &CacheControl{}
+
+type (
+	// Scope of a cache hint
+	Scope string
+
+	// Hint is the cache hint of a field in a response, as reported in the extensions of the response
+	Hint struct {
+		Path   ast.Path `json:"path"`
+		MaxAge *int     `json:"maxAge,omitempty"`
+		Scope  Scope    `json:"scope,omitempty"`
+	}
+
+	// Policy is the cache policy of a response
+	Policy struct {
+		MaxAge int
+		Scope  Scope
+	}
+
+	// CacheControl is a gqlgen extension computing the cache policy of responses from @cacheControl hints
+	CacheControl struct {
+		*config
+		schema *ast.Schema
+
+		// hints by type, and by "Type.field"
+		typeHints  map[string]hint
+		fieldHints map[string]hint
+	}
+
+	hint struct {
+		maxAge  *int
+		scope   Scope
+		inherit bool
+	}
+
+	// collector gathers the hints of a response
+	collector struct {
+		mx         sync.Mutex
+		restricted bool
+		maxAge     int
+		private    bool
+		types      map[string]struct{}
+		hints      []Hint
+	}
+
+	// holder carries the policy computed by the extension to the Middleware
+	holder struct {
+		policy   *Policy
+		types    []string
+		computed bool
+	}
+
+	holderKey    struct{}
+	collectorKey struct{}
+)
+
+// New cache control extension
+func New(opts ...Option) *CacheControl {
+	cc := &CacheControl{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(cc.config)
+	}
+	return cc
+}
+
+// ExtensionName yields the extension name: "CacheControl"
+func (*CacheControl) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the hints declared in the schema, and index them
+func (cc *CacheControl) Validate(schema graphql.ExecutableSchema) error {
+	return cc.prepare(schema.Schema())
+}
+
+// Middleware sets the Cache-Control header of responses, from the policy computed by the extension:
+// "max-age=60, public" for cacheable responses, "no-store" otherwise.
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (cc *CacheControl) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := &holder{}
+		next.ServeHTTP(httpwriter.New(w, func(status int) int {
+			h.writeHeaders(w.Header(), cc.config)
+			return status
+		}), r.WithContext(context.WithValue(r.Context(), holderKey{}, h)))
+	})
+}
+
+// PolicyFromContext yields the cache policy computed for the response, once it has been executed.
+// The policy is nil when the response is not cacheable.
+func PolicyFromContext(ctx context.Context) *Policy {
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok {
+		return nil
+	}
+	return h.policy
+}
+
+// InterceptResponse implements the gqlgen response interceptor, computing the policy of the response
+func (cc *CacheControl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	c := &collector{types: make(map[string]struct{})}
+	resp := next(context.WithValue(ctx, collectorKey{}, c))
+	if resp == nil {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	var policy *Policy
+	if cc.cacheable(ctx, oc, resp) && c.restricted && c.maxAge > 0 {
+		policy = &Policy{MaxAge: c.maxAge, Scope: Public}
+		if c.private {
+			policy.Scope = Private
+		}
+	}
+
+	if h, ok := ctx.Value(holderKey{}).(*holder); ok {
+		h.policy = policy
+		h.computed = true
+		h.types = make([]string, 0, len(c.types))
+		for typeName := range c.types {
+			h.types = append(h.types, typeName)
+		}
+		sort.Strings(h.types)
+	}
+
+	if cc.config.extensionHints && len(c.hints) > 0 {
+		if resp.Extensions == nil {
+			resp.Extensions = make(map[string]interface{})
+		}
+		resp.Extensions["cacheControl"] = map[string]interface{}{
+			"version": 1,
+			"hints":   c.hints,
+		}
+	}
+	return resp
+}
+
+func (cc *CacheControl) cacheable(ctx context.Context, oc *graphql.OperationContext, resp *graphql.Response) bool {
+	if oc.Operation == nil || oc.Operation.Operation != ast.Query || len(resp.Errors) > 0 {
+		return false
+	}
+	return !cc.config.cacheableRequestsOnly || IsCacheableRequest(ctx)
+}
+
+// InterceptField implements the gqlgen field interceptor, applying the hints of the field
+func (cc *CacheControl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc == nil || fc.Field.Field == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+
+	fieldHint, hasFieldHint := cc.fieldHint(fc.Object, fc.Field.Name)
+	effective := fieldHint
+
+	returnType := cc.schema.Types[fc.Field.Definition.Type.Name()]
+	composite := returnType != nil && returnType.IsCompositeType()
+	if composite {
+		if typeHint, ok := cc.typeHints[returnType.Name]; ok {
+			if effective.maxAge == nil && !effective.inherit {
+				effective.maxAge = typeHint.maxAge
+			}
+			if effective.scope == "" {
+				effective.scope = typeHint.scope
+			}
+		}
+	}
+	if effective.maxAge == nil && !effective.inherit && (composite || fc.Parent == nil) {
+		defaultMaxAge := cc.config.defaultMaxAge
+		effective.maxAge = &defaultMaxAge
+	}
+
+	// the hint of the resolved object type applies, unless the field returning it sets its own max age
+	var objectHint hint
+	if fc.Parent != nil {
+		c.addType(fc.Object)
+		if parentHint, ok := cc.parentFieldHint(fc.Parent); !ok || parentHint.maxAge == nil {
+			objectHint = cc.typeHints[fc.Object]
+		}
+	}
+
+	c.restrict(effective)
+	c.restrict(objectHint)
+	if cc.config.extensionHints && (hasFieldHint || effective.maxAge != nil || effective.scope != "") {
+		c.addHint(Hint{Path: fc.Path(), MaxAge: effective.maxAge, Scope: effective.scope})
+	}
+
+	return next(ctx)
+}
+
+// fieldHint yields the hint of a field, declared on the object type or on the interfaces it implements
+func (cc *CacheControl) fieldHint(typeName, field string) (hint, bool) {
+	if h, ok := cc.fieldHints[typeName+"."+field]; ok {
+		return h, true
+	}
+	if def := cc.schema.Types[typeName]; def != nil {
+		for _, iface := range def.Interfaces {
+			if h, ok := cc.fieldHints[iface+"."+field]; ok {
+				return h, true
+			}
+		}
+	}
+	return hint{}, false
+}
+
+// parentFieldHint yields the hint of the field returning the parent object, skipping the elements of lists
+func (cc *CacheControl) parentFieldHint(parent *graphql.FieldContext) (hint, bool) {
+	for ; parent != nil; parent = parent.Parent {
+		if parent.Field.Field != nil {
+			return cc.fieldHint(parent.Object, parent.Field.Name)
+		}
+	}
+	return hint{}, false
+}
+
+// prepare indexes the hints declared in the schema
+func (cc *CacheControl) prepare(schema *ast.Schema) error {
+	cc.schema = schema
+	cc.typeHints = make(map[string]hint)
+	cc.fieldHints = make(map[string]hint)
+
+	for _, def := range schema.Types {
+		h, ok, err := cc.parseHint(def.Directives)
+		if err != nil {
+			return err
+		}
+		if ok {
+			cc.typeHints[def.Name] = h
+		}
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+		for _, field := range def.Fields {
+			h, ok, err := cc.parseHint(field.Directives)
+			if err != nil {
+				return err
+			}
+			if ok {
+				cc.fieldHints[def.Name+"."+field.Name] = h
+			}
+		}
+	}
+	return nil
+}
+
+func (cc *CacheControl) parseHint(directives ast.DirectiveList) (hint, bool, error) {
+	d := directives.ForName(cc.config.directive)
+	if d == nil {
+		return hint{}, false, nil
+	}
+
+	var h hint
+	if arg := d.Arguments.ForName("maxAge"); arg != nil && arg.Value != nil && arg.Value.Kind == ast.IntValue {
+		maxAge, err := strconv.Atoi(arg.Value.Raw)
+		if err != nil || maxAge < 0 {
+			return hint{}, false, errors.New("invalid @" + cc.config.directive + " maxAge: " + arg.Value.Raw)
+		}
+		h.maxAge = &maxAge
+	}
+	if arg := d.Arguments.ForName("scope"); arg != nil && arg.Value != nil {
+		h.scope = Scope(arg.Value.Raw)
+	}
+	if arg := d.Arguments.ForName("inheritMaxAge"); arg != nil && arg.Value != nil {
+		h.inherit = arg.Value.Raw == "true"
+	}
+	if h.inherit && h.maxAge != nil {
+		return hint{}, false, errors.New("invalid @" + cc.config.directive + ": maxAge and inheritMaxAge are exclusive")
+	}
+	return h, true, nil
+}
+
+func (c *collector) restrict(h hint) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if h.maxAge != nil && (!c.restricted || *h.maxAge < c.maxAge) {
+		c.restricted = true
+		c.maxAge = *h.maxAge
+	}
+	if h.scope == Private {
+		c.private = true
+	}
+}
+
+func (c *collector) addType(typeName string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.types[typeName] = struct{}{}
+}
+
+func (c *collector) addHint(h Hint) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.hints = append(c.hints, h)
+}
+
+// writeHeaders sets the cache headers before the response is written
+func (h *holder) writeHeaders(header http.Header, cfg *config) {
+	if !h.computed {
+		// not a GraphQL response, e.g. a request error
+		return
+	}
+	if header.Get("Cache-Control") != "" {
+		return
+	}
+	policy := h.policy
+	if policy == nil {
+		header.Set("Cache-Control", "no-store")
+		return
+	}
+	header.Set("Cache-Control", "max-age="+strconv.Itoa(policy.MaxAge)+", "+strings.ToLower(string(policy.Scope)))
+	if cfg.surrogateKeyHeader != "" && policy.Scope == Public && len(h.types) > 0 {
+		header.Set(cfg.surrogateKeyHeader, strings.Join(h.types, " "))
+	}
+}
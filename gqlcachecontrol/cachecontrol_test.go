@@ -0,0 +1,170 @@
+package gqlcachecontrol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/internal/fieldtest"
+)
+
+const testSchema = `
+enum CacheControlScope { PUBLIC PRIVATE }
+
+directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean)
+  on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+type Post @cacheControl(maxAge: 240) {
+  id: ID!
+  votes: Int @cacheControl(maxAge: 30)
+  readByCurrentUser: Boolean! @cacheControl(scope: PRIVATE)
+  author: Author!
+}
+
+type Author {
+  name: String!
+}
+
+type Query {
+  post: Post @cacheControl(maxAge: 600)
+  posts: [Post!]!
+  feed: [Post!]! @cacheControl(maxAge: 600)
+  version: String @cacheControl(maxAge: 3600)
+}
+`
+
+type field struct {
+	parent string
+	index  bool // the field is resolved on the elements of its parent list
+	object string
+	name   string
+}
+
+func TestCacheControl(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	cc := New(WithSurrogateKeys("Surrogate-Key"))
+	require.NoError(t, cc.prepare(schema))
+
+	serve := func(operation ast.Operation, fields []field, errs gqlerror.List) http.Header {
+		handler := cc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{
+				Operation: &ast.OperationDefinition{Operation: operation},
+			})
+			cc.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+				parents := map[string]context.Context{"": ctx}
+				for _, f := range fields {
+					parent := parents[f.parent]
+					if f.index {
+						parent = fieldtest.Context(parent, fieldtest.Element(0))
+					}
+					fctx := fieldtest.Context(parent, fieldtest.Field(schema, f.object, f.name))
+					parents[f.name] = fctx
+					_, _ = cc.InterceptField(fctx, func(context.Context) (interface{}, error) {
+						return nil, nil
+					})
+				}
+				return &graphql.Response{Errors: errs}
+			})
+			_, _ = w.Write([]byte(`{}`))
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/query", nil))
+		return rec.Header()
+	}
+
+	t.Run("lowest max age", func(t *testing.T) {
+		header := serve(ast.Query, []field{
+			{object: "Query", name: "post"},
+			{parent: "post", object: "Post", name: "id"},
+			{parent: "post", object: "Post", name: "votes"},
+		}, nil)
+		assert.Equal(t, "max-age=30, public", header.Get("Cache-Control"))
+		assert.Equal(t, "Post", header.Get("Surrogate-Key"))
+	})
+
+	t.Run("field hint overrides type hint", func(t *testing.T) {
+		header := serve(ast.Query, []field{
+			{object: "Query", name: "post"},
+			{parent: "post", object: "Post", name: "id"},
+			{object: "Query", name: "version"},
+		}, nil)
+		assert.Equal(t, "max-age=600, public", header.Get("Cache-Control"))
+	})
+
+	t.Run("list field hint overrides type hint", func(t *testing.T) {
+		header := serve(ast.Query, []field{
+			{object: "Query", name: "feed"},
+			{parent: "feed", index: true, object: "Post", name: "id"},
+		}, nil)
+		assert.Equal(t, "max-age=600, public", header.Get("Cache-Control"))
+	})
+
+	t.Run("private", func(t *testing.T) {
+		header := serve(ast.Query, []field{
+			{object: "Query", name: "post"},
+			{parent: "post", object: "Post", name: "readByCurrentUser"},
+		}, nil)
+		assert.Equal(t, "max-age=600, private", header.Get("Cache-Control"))
+		assert.Empty(t, header.Get("Surrogate-Key"))
+	})
+
+	t.Run("composite field without hint", func(t *testing.T) {
+		header := serve(ast.Query, []field{
+			{object: "Query", name: "post"},
+			{parent: "post", object: "Post", name: "author"},
+		}, nil)
+		assert.Equal(t, "no-store", header.Get("Cache-Control"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		header := serve(ast.Query, []field{{object: "Query", name: "version"}}, gqlerror.List{gqlerror.Errorf("boom")})
+		assert.Equal(t, "no-store", header.Get("Cache-Control"))
+	})
+
+	t.Run("mutation", func(t *testing.T) {
+		header := serve(ast.Mutation, nil, nil)
+		assert.Equal(t, "no-store", header.Get("Cache-Control"))
+	})
+}
+
+func TestPersistedGET(t *testing.T) {
+	const hash = "ecf4edb46db40b5132295c0291d62fb65d6759a9eedfa4d5d612dd5ec54a6b38"
+	tr := PersistedGET{MaxURLLength: 300, MaxVariablesLength: 20}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "full query", query: `query={todos{id}}`, expected: http.StatusBadRequest},
+		{name: "missing hash", query: `operationName=todos`, expected: http.StatusBadRequest},
+		{name: "invalid hash", query: `extensions={"persistedQuery":{"version":1,"sha256Hash":"abc"}}`, expected: http.StatusBadRequest},
+		{name: "invalid version", query: `extensions={"persistedQuery":{"version":2,"sha256Hash":"` + hash + `"}}`, expected: http.StatusBadRequest},
+		{name: "long variables", query: `variables={"first":1000000000000000}&extensions={"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}`, expected: http.StatusBadRequest},
+		{name: "long URL", query: `extensions={"persistedQuery":{"version":1,"sha256Hash":"` + hash + hash + hash + hash + `"}}`, expected: http.StatusRequestURITooLong},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/query", nil)
+			req.URL.RawQuery = tc.query
+			require.True(t, tr.Supports(req))
+
+			rec := httptest.NewRecorder()
+			tr.Do(rec, req, nil)
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+
+	assert.False(t, tr.Supports(httptest.NewRequest(http.MethodPost, "/query", nil)))
+}
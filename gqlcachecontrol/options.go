@@ -0,0 +1,62 @@
+package gqlcachecontrol
+
+type (
+	// Option for the cache control extension
+	Option func(*config)
+
+	config struct {
+		directive             string
+		defaultMaxAge         int
+		extensionHints        bool
+		surrogateKeyHeader    string
+		cacheableRequestsOnly bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		directive: "cacheControl",
+	}
+}
+
+// WithDirective sets the name of the cache hint directive. The default is "cacheControl".
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
+
+// WithDefaultMaxAge sets the max age, in seconds, of root fields and fields returning composite types without hint.
+// The default is 0, i.e. responses are not cacheable unless all such fields are hinted.
+func WithDefaultMaxAge(seconds int) Option {
+	return func(c *config) {
+		if seconds >= 0 {
+			c.defaultMaxAge = seconds
+		}
+	}
+}
+
+// WithExtensionHints reports the hints of fields in the "cacheControl" extension of responses, in the format
+// of Apollo's legacy cache control extension understood by some CDNs. This is disabled by default.
+func WithExtensionHints(enabled bool) Option {
+	return func(c *config) {
+		c.extensionHints = enabled
+	}
+}
+
+// WithSurrogateKeys lists the object types resolved by public cacheable responses in this header,
+// e.g. "Surrogate-Key", so that CDNs supporting it may purge cached responses by type.
+// By default, no surrogate key is set.
+func WithSurrogateKeys(header string) Option {
+	return func(c *config) {
+		c.surrogateKeyHeader = header
+	}
+}
+
+// WithCacheableRequestsOnly restricts cacheable policies to the requests served by the PersistedGET transport.
+// Other responses are not cacheable. This is disabled by default.
+func WithCacheableRequestsOnly(enabled bool) Option {
+	return func(c *config) {
+		c.cacheableRequestsOnly = enabled
+	}
+}
@@ -0,0 +1,177 @@
+package gqlcachecontrol
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var (
+	_ graphql.Transport = PersistedGET{}
+
+	sha256Hash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+type (
+	// PersistedGET is a gqlgen transport serving GET requests for persisted queries only, for CDNs to cache them.
+	//
+	// Requests reference the query by its hash, with the extensions of automatic persisted queries,
+	// and must not carry the full query in the URL:
+	//
+	//   GET /query?operationName=posts&variables={"first":10}&extensions={"persistedQuery":{"version":1,"sha256Hash":"..."}}
+	//
+	// The transport must be used with the gqlgen extension resolving persisted queries, e.g.
+	// extension.AutomaticPersistedQuery. Since full queries are rejected, queries are registered with POST requests.
+	//
+	// Requests served by this transport are marked as cacheable (see IsCacheableRequest), and are the only ones
+	// given a cacheable policy by the Middleware with WithCacheableRequestsOnly.
+	//
+	// Example:
+	//
+	//   srv := handler.New(generated.NewExecutableSchema(cfg))
+	//   srv.AddTransport(gqlcachecontrol.PersistedGET{})
+	//   srv.AddTransport(transport.POST{})
+	//   srv.Use(extension.AutomaticPersistedQuery{Cache: cache})
+	PersistedGET struct {
+		// MaxURLLength is the maximum length of the query string of requests. The default is 2048.
+		MaxURLLength int
+
+		// MaxVariablesLength is the maximum length of the variables of requests. The default is 1024.
+		MaxVariablesLength int
+	}
+
+	cacheableKey struct{}
+)
+
+// IsCacheableRequest tells if a request was served by the PersistedGET transport
+func IsCacheableRequest(ctx context.Context) bool {
+	cacheable, _ := ctx.Value(cacheableKey{}).(bool)
+	return cacheable
+}
+
+// Supports implements graphql.Transport: all GET requests are supported, except websocket upgrades
+func (t PersistedGET) Supports(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	return r.Method == http.MethodGet
+}
+
+// Do implements graphql.Transport
+func (t PersistedGET) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	w.Header().Set("Content-Type", "application/json")
+
+	maxURLLength := t.MaxURLLength
+	if maxURLLength <= 0 {
+		maxURLLength = 2048
+	}
+	maxVariablesLength := t.MaxVariablesLength
+	if maxVariablesLength <= 0 {
+		maxVariablesLength = 1024
+	}
+
+	if len(r.URL.RawQuery) > maxURLLength {
+		writeError(w, http.StatusRequestURITooLong, "request URL is too long")
+		return
+	}
+
+	values := r.URL.Query()
+	if values.Get("query") != "" {
+		writeError(w, http.StatusBadRequest, "GET requests only accept persisted queries: the query must be sent with a POST request")
+		return
+	}
+
+	raw := &graphql.RawParams{
+		OperationName: values.Get("operationName"),
+	}
+	raw.ReadTime.Start = graphql.Now()
+
+	if variables := values.Get("variables"); variables != "" {
+		if len(variables) > maxVariablesLength {
+			writeError(w, http.StatusBadRequest, "variables are too long")
+			return
+		}
+		if err := decode(variables, &raw.Variables); err != nil {
+			writeError(w, http.StatusBadRequest, "variables could not be decoded")
+			return
+		}
+	}
+
+	extensions := values.Get("extensions")
+	if extensions == "" {
+		writeError(w, http.StatusBadRequest, "GET requests require a persisted query hash")
+		return
+	}
+	if err := decode(extensions, &raw.Extensions); err != nil {
+		writeError(w, http.StatusBadRequest, "extensions could not be decoded")
+		return
+	}
+	if err := validatePersistedQuery(raw.Extensions); err != "" {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	raw.ReadTime.End = graphql.Now()
+
+	ctx := context.WithValue(r.Context(), cacheableKey{}, true)
+	rc, errs := exec.CreateOperationContext(ctx, raw)
+	if errs != nil {
+		status := http.StatusOK
+		if errcode.GetErrorKind(errs) == errcode.KindProtocol {
+			status = http.StatusUnprocessableEntity
+		}
+		w.WriteHeader(status)
+		writeJSON(w, exec.DispatchError(graphql.WithOperationContext(ctx, rc), errs))
+		return
+	}
+
+	op := rc.Doc.Operations.ForName(rc.OperationName)
+	if op == nil || op.Operation != ast.Query {
+		writeError(w, http.StatusNotAcceptable, "GET requests only allow query operations")
+		return
+	}
+
+	responses, ctx := exec.DispatchOperation(ctx, rc)
+	writeJSON(w, responses(ctx))
+}
+
+// validatePersistedQuery checks the persistedQuery extension, and yields an error message when invalid
+func validatePersistedQuery(extensions map[string]interface{}) string {
+	pq, ok := extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return "GET requests require a persisted query hash"
+	}
+	if version, ok := pq["version"].(json.Number); !ok || version.String() != "1" {
+		return "unsupported persisted query version"
+	}
+	if hash, ok := pq["sha256Hash"].(string); !ok || !sha256Hash.MatchString(hash) {
+		return "invalid persisted query hash"
+	}
+	return ""
+}
+
+func decode(value string, target interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	return dec.Decode(target)
+}
+
+func writeJSON(w http.ResponseWriter, resp *graphql.Response) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		panic(err)
+	}
+	_, _ = w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	writeJSON(w, &graphql.Response{Errors: gqlerror.List{{Message: message}}})
+}
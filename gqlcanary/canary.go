@@ -0,0 +1,239 @@
+// Package gqlcanary periodically executes synthetic canary operations against the local executable schema,
+// bypassing HTTP, to detect regressions before clients do.
+//
+// The latency and the correctness of canaries are recorded as opencensus metrics, and failures are exposed
+// for alerting, e.g. by a health check endpoint (see Runner.Handler).
+//
+// Canaries are executed with the extensions configured with WithExtensions, such as the contrib metrics
+// and tracing extensions, so they are accounted for like any other operation.
+//
+// Example:
+//
+//   runner := gqlcanary.New(generated.NewExecutableSchema(cfg),
+//     gqlcanary.WithCanary(gqlcanary.Canary{
+//       Name:   "viewer",
+//       Query:  "query canary { viewer { id } }",
+//       Expect: json.RawMessage(`{"viewer":{"id":"canary"}}`),
+//     }),
+//     gqlcanary.WithExtensions(metrics.New()),
+//     gqlcanary.WithInterval(time.Minute),
+//   )
+//   go runner.Run(ctx)
+//   http.Handle("/canary", runner.Handler())
+package gqlcanary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+)
+
+type (
+	// Canary is a synthetic operation executed periodically
+	Canary struct {
+		// Name of the canary, used to tag metrics
+		Name string
+
+		// Query, operation name and variables of the operation
+		Query         string
+		OperationName string
+		Variables     map[string]interface{}
+
+		// Expect is the expected data of the response. When set, any difference is a failure.
+		Expect json.RawMessage
+
+		// DiffOptions apply when comparing the response with the expected data, e.g. to ignore volatile fields
+		DiffOptions []gqldiff.Option
+
+		// Check is an optional extra check on the response. A non-nil error is a failure.
+		Check func(*graphql.Response) error
+	}
+
+	// Result of the execution of a canary
+	Result struct {
+		Name     string        `json:"name"`
+		Time     time.Time     `json:"time"`
+		Duration time.Duration `json:"duration"`
+		Error    string        `json:"error,omitempty"`
+	}
+
+	// Runner executes canaries periodically
+	Runner struct {
+		*config
+		exec *executor.Executor
+
+		mx      sync.RWMutex
+		results map[string]Result
+	}
+)
+
+// Failed tells if the execution of the canary failed
+func (r Result) Failed() bool {
+	return r.Error != ""
+}
+
+// New Runner, executing canaries against an executable schema
+func New(schema graphql.ExecutableSchema, opts ...Option) *Runner {
+	r := &Runner{
+		config:  defaultConfig(),
+		exec:    executor.New(schema),
+		results: make(map[string]Result),
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	for _, extension := range r.config.extensions {
+		r.exec.Use(extension)
+	}
+	return r
+}
+
+// Run canaries periodically, until the context is done
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.interval)
+	defer ticker.Stop()
+
+	for {
+		r.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce executes all canaries once, and yields their results
+func (r *Runner) RunOnce(ctx context.Context) []Result {
+	results := make([]Result, 0, len(r.config.canaries))
+	for _, canary := range r.config.canaries {
+		result := r.execute(ctx, canary)
+		results = append(results, result)
+
+		r.mx.Lock()
+		r.results[canary.Name] = result
+		r.mx.Unlock()
+	}
+	return results
+}
+
+// Results yields the latest result of each canary
+func (r *Runner) Results() []Result {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	results := make([]Result, 0, len(r.config.canaries))
+	for _, canary := range r.config.canaries {
+		if result, ok := r.results[canary.Name]; ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Failures yields the latest results of failed canaries
+func (r *Runner) Failures() []Result {
+	var failures []Result
+	for _, result := range r.Results() {
+		if result.Failed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Handler serves the latest results of canaries as JSON, with status 503 Service Unavailable
+// when any canary failed, and 200 OK otherwise.
+func (r *Runner) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		status := http.StatusOK
+		if len(r.Failures()) > 0 {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(r.Results())
+	})
+}
+
+func (r *Runner) execute(ctx context.Context, canary Canary) Result {
+	ctx, cancel := r.config.context(ctx)
+	defer cancel()
+
+	start := r.config.clock()
+	resp := r.dispatch(ctx, canary)
+	duration := r.config.clock().Sub(start)
+
+	result := Result{
+		Name:     canary.Name,
+		Time:     start,
+		Duration: duration,
+	}
+	if err := verify(canary, resp); err != nil {
+		result.Error = err.Error()
+	}
+
+	tags := []tag.Mutator{tag.Upsert(TagCanary, canary.Name)}
+	measurements := []stats.Measurement{
+		CanaryCount.M(1),
+		CanaryLatency.M(float64(duration) / float64(time.Millisecond)),
+	}
+	if result.Failed() {
+		measurements = append(measurements, CanaryFailureCount.M(1))
+	}
+	_ = stats.RecordWithTags(ctx, tags, measurements...)
+
+	return result
+}
+
+func (r *Runner) dispatch(ctx context.Context, canary Canary) *graphql.Response {
+	ctx = graphql.StartOperationTrace(ctx)
+	now := r.config.clock()
+	params := &graphql.RawParams{
+		Query:         canary.Query,
+		OperationName: canary.OperationName,
+		Variables:     canary.Variables,
+		ReadTime:      graphql.TraceTiming{Start: now, End: now},
+	}
+
+	oc, errs := r.exec.CreateOperationContext(ctx, params)
+	if errs != nil {
+		return r.exec.DispatchError(graphql.WithOperationContext(ctx, oc), errs)
+	}
+
+	responses, ctx := r.exec.DispatchOperation(ctx, oc)
+	return responses(ctx)
+}
+
+func verify(canary Canary, resp *graphql.Response) error {
+	if resp == nil {
+		return fmt.Errorf("no response")
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	if canary.Expect != nil {
+		changes, err := gqldiff.Compare(canary.Expect, resp.Data, canary.DiffOptions...)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("unexpected response: %d change(s), first at %q", len(changes), changes[0].Path)
+		}
+	}
+	if canary.Check != nil {
+		return canary.Check(resp)
+	}
+	return nil
+}
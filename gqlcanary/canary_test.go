@@ -0,0 +1,142 @@
+package gqlcanary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+)
+
+const testSchema = `
+type Viewer {
+  id: ID!
+  lastSeen: String!
+}
+
+type Query {
+  viewer: Viewer!
+}
+`
+
+func TestRunner(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	resp := &graphql.Response{Data: json.RawMessage(`{"viewer":{"id":"canary","lastSeen":"now"}}`)}
+	es := &graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return schema },
+		ExecFunc: func(ctx context.Context) graphql.ResponseHandler {
+			return graphql.OneShot(resp)
+		},
+	}
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(10 * time.Millisecond)
+		return now
+	}
+
+	r := New(es,
+		WithCanary(
+			Canary{
+				Name:        "viewer",
+				Query:       `query canary { viewer { id lastSeen } }`,
+				Expect:      json.RawMessage(`{"viewer":{"id":"canary","lastSeen":"yesterday"}}`),
+				DiffOptions: []gqldiff.Option{gqldiff.IgnoreFields("lastSeen")},
+			},
+			Canary{
+				Name:  "check",
+				Query: `{ viewer { id } }`,
+				Check: func(resp *graphql.Response) error {
+					if len(resp.Data) == 0 {
+						return errors.New("no data")
+					}
+					return nil
+				},
+			},
+			Canary{
+				Name:  "invalid",
+				Query: `{ viewer { unknown } }`,
+			},
+		),
+		WithClock(clock),
+	)
+
+	handle := func() (int, []Result) {
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/canary", nil))
+		var results []Result
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		return rec.Code, results
+	}
+
+	t.Run("no results yet", func(t *testing.T) {
+		assert.Empty(t, r.Results())
+		status, results := handle()
+		assert.Equal(t, http.StatusOK, status)
+		assert.Empty(t, results)
+	})
+
+	t.Run("results", func(t *testing.T) {
+		results := r.RunOnce(context.Background())
+		require.Len(t, results, 3)
+
+		assert.Equal(t, "viewer", results[0].Name)
+		assert.False(t, results[0].Failed(), results[0].Error)
+		assert.True(t, results[0].Duration > 0)
+		assert.True(t, results[0].Time.After(time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)))
+		assert.False(t, results[1].Failed(), results[1].Error)
+
+		assert.True(t, results[2].Failed(), "validation errors fail the canary")
+		assert.Equal(t, []Result{results[2]}, r.Failures())
+		assert.Equal(t, results, r.Results())
+
+		status, served := handle()
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Len(t, served, 3)
+	})
+
+	t.Run("unexpected response", func(t *testing.T) {
+		resp = &graphql.Response{Data: json.RawMessage(`{"viewer":{"id":"other","lastSeen":"now"}}`)}
+		results := r.RunOnce(context.Background())
+		require.Len(t, results, 3)
+		assert.Contains(t, results[0].Error, "unexpected response")
+		assert.False(t, results[1].Failed())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		resp = &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("unavailable")}}
+		results := r.RunOnce(context.Background())
+		require.Len(t, results, 3)
+		assert.Contains(t, results[0].Error, "unavailable")
+		assert.Contains(t, results[1].Error, "unavailable")
+		assert.Len(t, r.Failures(), 3)
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		resp = &graphql.Response{Data: json.RawMessage(`{"viewer":{"id":"canary","lastSeen":"now"}}`)}
+		r.RunOnce(context.Background())
+		failures := r.Failures()
+		require.Len(t, failures, 1, "the latest results replace previous failures")
+		assert.Equal(t, "invalid", failures[0].Name)
+	})
+
+	t.Run("run until done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r.Run(ctx)
+		assert.Len(t, r.Results(), 3)
+	})
+}
@@ -0,0 +1,79 @@
+package gqlcanary
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before running canaries.
+func Register() error {
+	return gqlmetrics.Register(CanaryViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(CanaryViews...)
+}
+
+var (
+	// TagCanary is the name of a canary
+	TagCanary = tag.MustNewKey("gql.canary")
+
+	// CanaryViews contains all opencensus stats views declared by the canary runner
+	CanaryViews = []*view.View{
+		CanaryCountView,
+		CanaryFailureCountView,
+		CanaryLatencyView,
+	}
+
+	// CanaryCount tracks a count of canary executions
+	CanaryCount = stats.Int64(
+		"gql/canary/count",
+		"Number of canary executions",
+		stats.UnitDimensionless)
+
+	// CanaryFailureCount tracks a count of failed canary executions
+	CanaryFailureCount = stats.Int64(
+		"gql/canary/failure_count",
+		"Number of failed canary executions",
+		stats.UnitDimensionless)
+
+	// CanaryLatency tracks the execution time of canaries, in milliseconds
+	CanaryLatency = stats.Float64(
+		"gql/canary/latency",
+		"Canary execution latency",
+		stats.UnitMilliseconds)
+
+	// CanaryCountView reports a count of canary executions tagged by canary
+	CanaryCountView = &view.View{
+		Name:        "gql/canary/count",
+		Description: "Count of canary executions by canary",
+		Measure:     CanaryCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagCanary},
+	}
+
+	// CanaryFailureCountView reports a count of failed canary executions tagged by canary
+	CanaryFailureCountView = &view.View{
+		Name:        "gql/canary/failure_count",
+		Description: "Count of failed canary executions by canary",
+		Measure:     CanaryFailureCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagCanary},
+	}
+
+	// CanaryLatencyView reports a distribution of the execution time of canaries, by canary (in milliseconds)
+	CanaryLatencyView = &view.View{
+		Name:        "gql/canary/latency",
+		Description: "Execution time distribution of canaries by canary",
+		Measure:     CanaryLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagCanary},
+	}
+)
@@ -0,0 +1,73 @@
+package gqlcanary
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the canary runner
+	Option func(*config)
+
+	config struct {
+		canaries   []Canary
+		extensions []graphql.HandlerExtension
+		interval   time.Duration
+		timeout    time.Duration
+		clock      func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		interval: time.Minute,
+		timeout:  10 * time.Second,
+		clock:    graphql.Now,
+	}
+}
+
+func (c config) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// WithCanary adds canaries to execute
+func WithCanary(canaries ...Canary) Option {
+	return func(c *config) {
+		c.canaries = append(c.canaries, canaries...)
+	}
+}
+
+// WithExtensions sets the extensions used to execute canaries, e.g. the contrib metrics and tracing extensions.
+func WithExtensions(extensions ...graphql.HandlerExtension) Option {
+	return func(c *config) {
+		c.extensions = append(c.extensions, extensions...)
+	}
+}
+
+// WithInterval sets the interval between executions of canaries. The default is 1 minute.
+func WithInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithTimeout sets the timeout of each canary execution. The default is 10s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithClock sets the clock used to measure latencies. By default, this is graphql.Now
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
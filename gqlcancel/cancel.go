@@ -0,0 +1,65 @@
+// Package gqlcancel tells operations cancelled by the client from operations cancelled by the server.
+//
+// A context is cancelled when the client goes away, but also when the server gives up on an operation,
+// e.g. when gqldrain cancels stragglers. Server-side code cancels operations with a context from WithCancel,
+// so that instrumentation reports them as server errors rather than client cancellations:
+//
+//   ctx, cancel := gqlcancel.WithCancel(ctx)
+//   defer cancel()
+//
+//   ...
+//
+//   if gqlcancel.ByClient(ctx) {
+//     // the client went away before completion
+//   }
+package gqlcancel
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type (
+	causeKey struct{}
+
+	// cause records whether a server-side context was cancelled by the server
+	cause struct {
+		byServer int32
+		parent   *cause
+	}
+)
+
+// WithCancel returns a copy of ctx, with a cancel function for the server.
+//
+// The cancellation of the returned context by this function is not attributed to the client,
+// unless the parent context was cancelled first.
+func WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	c := &cause{parent: fromContext(ctx)}
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, causeKey{}, c))
+
+	return ctx, func() {
+		if ctx.Err() == nil {
+			atomic.StoreInt32(&c.byServer, 1)
+		}
+		cancel()
+	}
+}
+
+// ByClient reports whether ctx was cancelled by the client, i.e. is cancelled,
+// but not by the cancel function of some WithCancel
+func ByClient(ctx context.Context) bool {
+	if ctx.Err() != context.Canceled {
+		return false
+	}
+	for c := fromContext(ctx); c != nil; c = c.parent {
+		if atomic.LoadInt32(&c.byServer) == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func fromContext(ctx context.Context) *cause {
+	c, _ := ctx.Value(causeKey{}).(*cause)
+	return c
+}
@@ -0,0 +1,55 @@
+package gqlcancel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByClient(t *testing.T) {
+	t.Run("cancelled by the client", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		served, stop := WithCancel(ctx)
+		defer stop()
+
+		assert.False(t, ByClient(served))
+		cancel()
+		assert.True(t, ByClient(ctx))
+		assert.True(t, ByClient(served))
+
+		stop()
+		assert.True(t, ByClient(served), "the client cancelled first")
+	})
+
+	t.Run("cancelled by the server", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		served, stop := WithCancel(ctx)
+		inner, cancelInner := context.WithCancel(served)
+		defer cancelInner()
+
+		stop()
+		assert.Equal(t, context.Canceled, served.Err())
+		assert.False(t, ByClient(served))
+		assert.False(t, ByClient(inner))
+		assert.False(t, ByClient(ctx), "the parent is not cancelled")
+	})
+
+	t.Run("nested cancellations by the server", func(t *testing.T) {
+		outer, stopOuter := WithCancel(context.Background())
+		inner, stopInner := WithCancel(outer)
+		defer stopInner()
+
+		stopOuter()
+		assert.False(t, ByClient(inner))
+	})
+
+	t.Run("deadlines are not cancellations", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+		assert.False(t, ByClient(ctx))
+	})
+}
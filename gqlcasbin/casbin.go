@@ -0,0 +1,317 @@
+// Package gqlcasbin provides a gqlgen extension authorizing fields with Casbin.
+//
+// Fields are mapped to Casbin objects and actions: by default, the object is the coordinate of the field
+// ("Object.field") and the action is the type of the operation ("query", "mutation" or "subscription").
+// Roles and their inheritance are defined by the Casbin model and policies, e.g. with Model:
+//
+//   p, reader, User.*, query
+//   p, support, User.email, query
+//   p, admin, Mutation.*, mutation
+//   g, support, reader
+//   g, alice, support
+//
+// When the enforcer supports batches (as the Casbin Enforcer does), all the fields an operation may
+// resolve are enforced at once, before the execution. Other enforcers are called field by field.
+//
+// Example:
+//
+//   m, _ := model.NewModelFromString(gqlcasbin.Model)
+//   enforcer, _ := casbin.NewEnforcer(m, fileadapter.NewAdapter("policy.csv"))
+//   srv.Use(gqlcasbin.New(enforcer,
+//     gqlcasbin.WithSubject(func(ctx context.Context) string { return auth.ForContext(ctx).UserID }),
+//   ))
+//
+// Denied fields fail with the code FORBIDDEN. Introspection fields are not enforced.
+package gqlcasbin
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcode"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "Casbin"
+
+	// CodeForbidden is the code of errors reporting denied fields and operations
+	CodeForbidden = gqlcode.Forbidden
+)
+
+// Model is a Casbin model with role inheritance, matching objects with wildcards, e.g. "User.*",
+// and actions with "*"
+const Model = `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && (r.act == p.act || p.act == "*")
+`
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = &Authorizer{}
+
+type (
+	// Enforcer enforces Casbin policies, as the Casbin Enforcer does
+	Enforcer interface {
+		Enforce(rvals ...interface{}) (bool, error)
+	}
+
+	// BatchEnforcer enforces a batch of Casbin requests at once
+	BatchEnforcer interface {
+		Enforcer
+		BatchEnforce(requests [][]interface{}) ([]bool, error)
+	}
+
+	// Mapping maps a field to a Casbin object and action, given the type of the operation.
+	// Fields mapped to an empty object are not enforced.
+	Mapping func(object, field string, operation ast.Operation) (obj string, act string)
+
+	// Authorizer is a gqlgen extension authorizing fields with Casbin
+	Authorizer struct {
+		*config
+		enforcer Enforcer
+		schema   *ast.Schema
+	}
+
+	// decisions of an operation, by field coordinate
+	decisions struct {
+		subject   string
+		operation ast.Operation
+
+		mx      sync.Mutex
+		allowed map[string]bool
+	}
+
+	decisionsKey struct{}
+)
+
+// New authorizing extension, with an Enforcer
+func New(enforcer Enforcer, opts ...Option) *Authorizer {
+	a := &Authorizer{
+		config:   defaultConfig(),
+		enforcer: enforcer,
+	}
+	for _, apply := range opts {
+		apply(a.config)
+	}
+	return a
+}
+
+// ExtensionName yields the extension name: "Casbin"
+func (*Authorizer) ExtensionName() string {
+	return extensionName
+}
+
+// Validate retains the schema, to resolve the fields selected on interfaces and unions
+func (a *Authorizer) Validate(schema graphql.ExecutableSchema) error {
+	a.schema = schema.Schema()
+	return nil
+}
+
+// CoordinateMapping is the default Mapping: the object is the coordinate of the field, and the action
+// is the type of the operation
+func CoordinateMapping(object, field string, operation ast.Operation) (string, string) {
+	return object + "." + field, string(operation)
+}
+
+// InterceptOperation implements the gqlgen operation interceptor, enforcing all the fields of the operation at once
+// when the enforcer supports batches
+func (a *Authorizer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil {
+		return next(ctx)
+	}
+
+	d := &decisions{
+		subject:   a.config.subject(ctx),
+		operation: oc.Operation.Operation,
+		allowed:   make(map[string]bool),
+	}
+	if batch, ok := a.enforcer.(BatchEnforcer); ok && a.schema != nil {
+		if err := a.enforceBatch(batch, d, a.coordinates(oc)); err != nil {
+			return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("authorization failed: %v", err)}})
+		}
+		if a.config.rejectOperation {
+			if denied := d.denied(); len(denied) > 0 {
+				a.record(ctx, oc, denied...)
+				return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{a.forbidden(nil)}})
+			}
+		}
+	}
+	return next(context.WithValue(ctx, decisionsKey{}, d))
+}
+
+// InterceptField implements the gqlgen field interceptor, failing denied fields
+func (a *Authorizer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	d, ok := ctx.Value(decisionsKey{}).(*decisions)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil || introspection(fc.Object, fc.Field.Name) {
+		return next(ctx)
+	}
+
+	coordinate := fc.Object + "." + fc.Field.Name
+	allowed, known := d.lookup(coordinate)
+	if !known {
+		var err error
+		allowed, err = a.enforce(d, fc.Object, fc.Field.Name)
+		if err != nil {
+			return nil, err
+		}
+		d.store(coordinate, allowed)
+	}
+	if allowed {
+		return next(ctx)
+	}
+
+	a.record(ctx, graphql.GetOperationContext(ctx), coordinate)
+	return nil, a.forbidden(fc.Path())
+}
+
+func (a *Authorizer) enforce(d *decisions, object, field string) (bool, error) {
+	obj, act := a.config.mapping(object, field, d.operation)
+	if obj == "" {
+		return true, nil
+	}
+	return a.enforcer.Enforce(d.subject, obj, act)
+}
+
+func (a *Authorizer) enforceBatch(batch BatchEnforcer, d *decisions, coordinates []string) error {
+	requests := make([][]interface{}, 0, len(coordinates))
+	enforced := make([]string, 0, len(coordinates))
+	for _, coordinate := range coordinates {
+		parts := strings.SplitN(coordinate, ".", 2)
+		obj, act := a.config.mapping(parts[0], parts[1], d.operation)
+		if obj == "" {
+			d.allowed[coordinate] = true
+			continue
+		}
+		requests = append(requests, []interface{}{d.subject, obj, act})
+		enforced = append(enforced, coordinate)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	results, err := batch.BatchEnforce(requests)
+	if err != nil {
+		return err
+	}
+	for i, coordinate := range enforced {
+		d.allowed[coordinate] = i < len(results) && results[i]
+	}
+	return nil
+}
+
+// coordinates of the fields an operation may resolve, sorted. Fields selected on interfaces and unions
+// are expanded to the fields of their possible types.
+func (a *Authorizer) coordinates(oc *graphql.OperationContext) []string {
+	seen := make(map[string]struct{})
+	visited := make(map[string]struct{})
+	var walk func(ast.SelectionSet)
+	walk = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if s.ObjectDefinition != nil && !introspection(s.ObjectDefinition.Name, s.Name) {
+					for _, object := range a.schema.GetPossibleTypes(s.ObjectDefinition) {
+						seen[object.Name+"."+s.Name] = struct{}{}
+					}
+				}
+				walk(s.SelectionSet)
+			case *ast.InlineFragment:
+				walk(s.SelectionSet)
+			case *ast.FragmentSpread:
+				if _, ok := visited[s.Name]; ok {
+					continue
+				}
+				visited[s.Name] = struct{}{}
+				if s.Definition != nil {
+					walk(s.Definition.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(oc.Operation.SelectionSet)
+
+	coordinates := make([]string, 0, len(seen))
+	for coordinate := range seen {
+		coordinates = append(coordinates, coordinate)
+	}
+	sort.Strings(coordinates)
+	return coordinates
+}
+
+func (a *Authorizer) record(ctx context.Context, oc *graphql.OperationContext, coordinates ...string) {
+	label := a.config.opLabel(oc)
+	for _, coordinate := range coordinates {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{
+			tag.Upsert(metrics.TagOperation, label),
+			tag.Upsert(metrics.TagField, coordinate),
+		}, DeniedCount.M(1))
+	}
+}
+
+func (a *Authorizer) forbidden(path ast.Path) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: a.config.message,
+		Path:    path,
+		Extensions: map[string]interface{}{
+			"code": CodeForbidden,
+		},
+	}
+}
+
+func (d *decisions) lookup(coordinate string) (bool, bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	allowed, ok := d.allowed[coordinate]
+	return allowed, ok
+}
+
+func (d *decisions) store(coordinate string, allowed bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.allowed[coordinate] = allowed
+}
+
+// denied coordinates, sorted
+func (d *decisions) denied() []string {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	var denied []string
+	for coordinate, allowed := range d.allowed {
+		if !allowed {
+			denied = append(denied, coordinate)
+		}
+	}
+	sort.Strings(denied)
+	return denied
+}
+
+func introspection(object, field string) bool {
+	return strings.HasPrefix(object, "__") || strings.HasPrefix(field, "__")
+}
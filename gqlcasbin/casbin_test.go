@@ -0,0 +1,182 @@
+package gqlcasbin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const testSchema = `
+interface Node {
+  id: ID!
+}
+
+type User implements Node {
+  id: ID!
+  name: String!
+  email: String
+}
+
+type Query {
+  node(id: ID!): Node
+  viewer: User
+}
+`
+
+// enforcer is a minimal RBAC enforcer: subjects inherit the policies of their roles, and objects
+// match with a trailing wildcard
+type enforcer struct {
+	roles    map[string][]string
+	policies map[string][]string
+	batches  [][][]interface{}
+	single   [][]interface{}
+}
+
+func (e *enforcer) Enforce(rvals ...interface{}) (bool, error) {
+	e.single = append(e.single, rvals)
+	return e.allowed(rvals[0].(string), rvals[1].(string), rvals[2].(string)), nil
+}
+
+func (e *enforcer) BatchEnforce(requests [][]interface{}) ([]bool, error) {
+	e.batches = append(e.batches, requests)
+	results := make([]bool, len(requests))
+	for i, r := range requests {
+		results[i] = e.allowed(r[0].(string), r[1].(string), r[2].(string))
+	}
+	return results, nil
+}
+
+func (e *enforcer) allowed(sub, obj, act string) bool {
+	for _, policy := range e.policies[sub] {
+		parts := strings.SplitN(policy, " ", 2)
+		if parts[1] == act && (parts[0] == obj || strings.HasSuffix(parts[0], "*") && strings.HasPrefix(obj, strings.TrimSuffix(parts[0], "*"))) {
+			return true
+		}
+	}
+	for _, role := range e.roles[sub] {
+		if e.allowed(role, obj, act) {
+			return true
+		}
+	}
+	return false
+}
+
+func operation(t *testing.T, schema *ast.Schema, query string) context.Context {
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	require.Empty(t, errs)
+	return graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Doc:       doc,
+		Operation: doc.Operations[0],
+	})
+}
+
+func resolve(ctx context.Context, a *Authorizer, object, field string) (interface{}, error) {
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Object: object,
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: field, Alias: field}},
+	})
+	return a.InterceptField(ctx, func(context.Context) (interface{}, error) { return "value", nil })
+}
+
+func TestAuthorizer(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	e := &enforcer{
+		roles: map[string][]string{"alice": {"reader"}},
+		policies: map[string][]string{
+			"reader": {"Query.* query", "User.id query", "User.name query"},
+		},
+	}
+	a := New(e, WithSubject(func(context.Context) string { return "alice" }))
+	require.Equal(t, extensionName, a.ExtensionName())
+	require.NoError(t, a.Validate(&graphql.ExecutableSchemaMock{SchemaFunc: func() *ast.Schema { return schema }}))
+
+	ctx := operation(t, schema, `query { viewer { name email } node(id: "1") { id ... on User { name } } __typename }`)
+	var opCtx context.Context
+	a.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		opCtx = ctx
+		return graphql.OneShot(&graphql.Response{})
+	})
+	require.NotNil(t, opCtx)
+
+	require.Len(t, e.batches, 1)
+	var objects []string
+	for _, request := range e.batches[0] {
+		assert.Equal(t, "alice", request[0])
+		assert.Equal(t, "query", request[2])
+		objects = append(objects, request[1].(string))
+	}
+	assert.Equal(t, []string{"Query.node", "Query.viewer", "User.email", "User.id", "User.name"}, objects)
+
+	res, err := resolve(opCtx, a, "User", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "value", res)
+
+	_, err = resolve(opCtx, a, "User", "email")
+	require.Error(t, err)
+	gqlErr2, isGQLError := err.(*gqlerror.Error)
+	require.True(t, isGQLError)
+	assert.Equal(t, CodeForbidden, gqlErr2.Extensions["code"])
+
+	_, err = resolve(opCtx, a, "Query", "__schema")
+	require.NoError(t, err, "introspection is not enforced")
+	assert.Empty(t, e.single, "fields are enforced in batch")
+}
+
+func TestRejectOperation(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	e := &enforcer{policies: map[string][]string{"bob": {"Query.viewer query", "User.name query"}}}
+	a := New(e, WithSubject(func(context.Context) string { return "bob" }), WithRejectOperation(true))
+	require.NoError(t, a.Validate(&graphql.ExecutableSchemaMock{SchemaFunc: func() *ast.Schema { return schema }}))
+
+	next := func(context.Context) graphql.ResponseHandler {
+		return graphql.OneShot(&graphql.Response{Data: []byte(`{}`)})
+	}
+
+	resp := a.InterceptOperation(operation(t, schema, `{ viewer { name } }`), next)(context.Background())
+	assert.Empty(t, resp.Errors)
+
+	resp = a.InterceptOperation(operation(t, schema, `{ viewer { name email } }`), next)(context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "not authorized", resp.Errors[0].Message)
+}
+
+func TestSingleEnforcer(t *testing.T) {
+	e := &enforcer{policies: map[string][]string{"": {"Query.viewer query"}}}
+	a := New(struct{ Enforcer }{e}, WithMapping(func(object, field string, operation ast.Operation) (string, string) {
+		if field == "id" {
+			return "", ""
+		}
+		return CoordinateMapping(object, field, operation)
+	}))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+	var opCtx context.Context
+	a.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		opCtx = ctx
+		return graphql.OneShot(&graphql.Response{})
+	})
+
+	_, err := resolve(opCtx, a, "Query", "viewer")
+	require.NoError(t, err)
+	_, err = resolve(opCtx, a, "Query", "viewer")
+	require.NoError(t, err)
+	_, err = resolve(opCtx, a, "User", "id")
+	require.NoError(t, err, "unmapped fields are not enforced")
+	_, err = resolve(opCtx, a, "User", "name")
+	require.Error(t, err)
+
+	assert.Len(t, e.single, 2, "decisions are retained for the operation")
+}
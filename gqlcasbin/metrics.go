@@ -0,0 +1,39 @@
+package gqlcasbin
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(DeniedCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(DeniedCountView)
+}
+
+var (
+	// DeniedCount tracks a count of fields denied by Casbin policies
+	DeniedCount = stats.Int64(
+		"gql/server/casbin_denied_count",
+		"Number of GraphQL fields denied by Casbin policies",
+		stats.UnitDimensionless)
+
+	// DeniedCountView reports a count of denied fields tagged by operation name and field
+	DeniedCountView = &view.View{
+		Name:        "gql/server/casbin_denied_count",
+		Description: "Count of denied GraphQL fields by operation and field",
+		Measure:     DeniedCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, metrics.TagField},
+	}
+)
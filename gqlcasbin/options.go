@@ -0,0 +1,68 @@
+package gqlcasbin
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the authorizing extension
+	Option func(*config)
+
+	config struct {
+		subject         func(context.Context) string
+		mapping         Mapping
+		rejectOperation bool
+		message         string
+		opLabel         gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		subject: func(context.Context) string { return "" },
+		mapping: CoordinateMapping,
+		message: "not authorized",
+		opLabel: gqllabel.OperationName,
+	}
+}
+
+// WithSubject sets the function retrieving the Casbin subject of the caller from the context, e.g. a user ID
+func WithSubject(subject func(context.Context) string) Option {
+	return func(c *config) {
+		c.subject = subject
+	}
+}
+
+// WithMapping sets the function mapping fields to Casbin objects and actions. The default is CoordinateMapping.
+func WithMapping(mapping Mapping) Option {
+	return func(c *config) {
+		c.mapping = mapping
+	}
+}
+
+// WithRejectOperation rejects whole operations selecting denied fields, rather than failing the denied fields.
+//
+// This requires an enforcer supporting batches. Notice that fields excluded by @skip or @include are enforced too.
+func WithRejectOperation(enabled bool) Option {
+	return func(c *config) {
+		c.rejectOperation = enabled
+	}
+}
+
+// WithMessage sets the message of errors denying fields and operations. The default is "not authorized".
+func WithMessage(message string) Option {
+	return func(c *config) {
+		c.message = message
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
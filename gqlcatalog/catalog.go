@@ -0,0 +1,210 @@
+// Package gqlcatalog builds a machine-readable catalog of the registered operations of a server, for internal
+// developer portals.
+//
+// Operations are retrieved from a persisted query manifest (see gqlmanifest), and documented with metadata
+// maintained alongside: owners, SLO targets and deprecation status. The catalog is served as JSON over HTTP.
+//
+// Example metadata, keyed by operation name:
+//
+//   GetUser:
+//     description: Profile page of a user
+//     owner: team-accounts
+//     slo:
+//       latencyMs: 200
+//       availability: 0.999
+//   ListLegacyOrders:
+//     owner: team-orders
+//     deprecated: true
+//     deprecationReason: use ListOrders
+//
+// Example:
+//
+//   manifest, _ := gqlmanifest.Load("manifest.json")
+//   metadata, _ := gqlcatalog.LoadMetadata("operations.yaml")
+//   catalog, err := gqlcatalog.New(manifest, metadata)
+//   if err != nil {
+//     log.Fatal(err)
+//   }
+//   http.Handle("/catalog", catalog.Handler())
+package gqlcatalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"gopkg.in/yaml.v2"
+
+	"github.com/99designs/gqlgen-contrib/gqlmanifest"
+)
+
+type (
+	// Metadata of an operation, maintained by its owners
+	Metadata struct {
+		Description       string   `json:"description,omitempty" yaml:"description"`
+		Owner             string   `json:"owner,omitempty" yaml:"owner"`
+		SLO               *SLO     `json:"slo,omitempty" yaml:"slo"`
+		Deprecated        bool     `json:"deprecated" yaml:"deprecated"`
+		DeprecationReason string   `json:"deprecationReason,omitempty" yaml:"deprecationReason"`
+		Tags              []string `json:"tags,omitempty" yaml:"tags"`
+	}
+
+	// SLO targets of an operation
+	SLO struct {
+		// LatencyMs is the target latency of the operation, in milliseconds
+		LatencyMs float64 `json:"latencyMs,omitempty" yaml:"latencyMs"`
+
+		// Availability is the target ratio of successful operations, e.g. 0.999
+		Availability float64 `json:"availability,omitempty" yaml:"availability"`
+	}
+
+	// Entry of the catalog: a named operation, with its metadata
+	Entry struct {
+		Name string `json:"name"`
+
+		// Type of the operation: query, mutation or subscription
+		Type string `json:"type"`
+
+		// Hashes of the persisted queries defining the operation, sorted
+		Hashes []string `json:"hashes"`
+
+		Metadata
+	}
+
+	// Catalog of operations
+	Catalog struct {
+		Version string  `json:"version,omitempty"`
+		Entries []Entry `json:"operations"`
+
+		// Undocumented operations, which have no metadata
+		Undocumented []string `json:"undocumented,omitempty"`
+
+		// Unknown operations, which have metadata but are not in the manifest
+		Unknown []string `json:"unknown,omitempty"`
+
+		index map[string]int
+	}
+)
+
+// LoadMetadata loads the metadata of operations from a YAML file, keyed by operation name
+func LoadMetadata(path string) (map[string]Metadata, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]Metadata)
+	if err := yaml.UnmarshalStrict(buf, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// New Catalog of the named operations of a manifest, documented with metadata. Unnamed operations are not cataloged.
+func New(manifest *gqlmanifest.Manifest, metadata map[string]Metadata) (*Catalog, error) {
+	c := &Catalog{
+		Version: manifest.Version,
+		index:   make(map[string]int),
+	}
+
+	hashes := make([]string, 0, len(manifest.Operations))
+	for hash := range manifest.Operations {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	for _, hash := range hashes {
+		doc, err := parser.ParseQuery(&ast.Source{Input: manifest.Operations[hash].Query})
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %v", hash, err)
+		}
+		for _, op := range doc.Operations {
+			if op.Name == "" {
+				continue
+			}
+			i, ok := c.index[op.Name]
+			if !ok {
+				i = len(c.Entries)
+				c.index[op.Name] = i
+				c.Entries = append(c.Entries, Entry{
+					Name:     op.Name,
+					Type:     string(op.Operation),
+					Metadata: metadata[op.Name],
+				})
+			}
+			c.Entries[i].Hashes = append(c.Entries[i].Hashes, hash)
+		}
+	}
+
+	sort.Slice(c.Entries, func(i, j int) bool { return c.Entries[i].Name < c.Entries[j].Name })
+	for i, entry := range c.Entries {
+		c.index[entry.Name] = i
+		if _, ok := metadata[entry.Name]; !ok {
+			c.Undocumented = append(c.Undocumented, entry.Name)
+		}
+	}
+	for name := range metadata {
+		if _, ok := c.index[name]; !ok {
+			c.Unknown = append(c.Unknown, name)
+		}
+	}
+	sort.Strings(c.Unknown)
+
+	return c, nil
+}
+
+// Lookup an operation by name
+func (c *Catalog) Lookup(name string) (Entry, bool) {
+	i, ok := c.index[name]
+	if !ok {
+		return Entry{}, false
+	}
+	return c.Entries[i], true
+}
+
+// Filter entries by owner, and by deprecation status when deprecated is not nil
+func (c *Catalog) Filter(owner string, deprecated *bool) []Entry {
+	entries := make([]Entry, 0, len(c.Entries))
+	for _, entry := range c.Entries {
+		if owner != "" && entry.Owner != owner {
+			continue
+		}
+		if deprecated != nil && entry.Deprecated != *deprecated {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Handler serves the Catalog as JSON. Operations may be filtered with the "owner" and "deprecated" query parameters,
+// e.g. ?owner=team-orders&deprecated=true
+func (c *Catalog) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		owner := query.Get("owner")
+
+		var deprecated *bool
+		if value := query.Get("deprecated"); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, "invalid deprecated parameter", http.StatusBadRequest)
+				return
+			}
+			deprecated = &b
+		}
+
+		filtered := *c
+		if owner != "" || deprecated != nil {
+			filtered.Entries = c.Filter(owner, deprecated)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(filtered)
+	})
+}
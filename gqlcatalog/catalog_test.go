@@ -0,0 +1,92 @@
+package gqlcatalog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqlmanifest"
+)
+
+const testMetadata = `
+GetUser:
+  owner: team-accounts
+  slo:
+    latencyMs: 200
+    availability: 0.999
+DeleteUser:
+  owner: team-accounts
+  deprecated: true
+  deprecationReason: use DeactivateUser
+Removed:
+  owner: team-orders
+`
+
+func TestCatalog(t *testing.T) {
+	manifest := gqlmanifest.New("v42")
+	for _, query := range []string{
+		`query GetUser { user { id } }`,
+		`query GetUser { user { id name } }`,
+		`mutation DeleteUser { deleteUser(id: "1") }`,
+		`query Search { search { id } }`,
+		`{ me { id } }`,
+	} {
+		_, err := manifest.Add(query)
+		require.NoError(t, err)
+	}
+
+	f, err := ioutil.TempFile("", "gqlcatalog")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	_, err = f.WriteString(testMetadata)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	metadata, err := LoadMetadata(f.Name())
+	require.NoError(t, err)
+
+	catalog, err := New(manifest, metadata)
+	require.NoError(t, err)
+
+	require.Len(t, catalog.Entries, 3)
+	assert.Equal(t, []string{"Search"}, catalog.Undocumented)
+	assert.Equal(t, []string{"Removed"}, catalog.Unknown)
+
+	entry, ok := catalog.Lookup("GetUser")
+	require.True(t, ok)
+	assert.Equal(t, "query", entry.Type)
+	assert.Len(t, entry.Hashes, 2)
+	require.NotNil(t, entry.SLO)
+	assert.Equal(t, 0.999, entry.SLO.Availability)
+
+	rec := httptest.NewRecorder()
+	catalog.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/catalog?owner=team-accounts&deprecated=true", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var served struct {
+		Version    string `json:"version"`
+		Operations []struct {
+			Name              string `json:"name"`
+			Type              string `json:"type"`
+			DeprecationReason string `json:"deprecationReason"`
+		} `json:"operations"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, "v42", served.Version)
+	require.Len(t, served.Operations, 1)
+	assert.Equal(t, "DeleteUser", served.Operations[0].Name)
+	assert.Equal(t, "mutation", served.Operations[0].Type)
+	assert.Equal(t, "use DeactivateUser", served.Operations[0].DeprecationReason)
+
+	rec = httptest.NewRecorder()
+	catalog.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/catalog?deprecated=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,47 @@
+package gqlchaos
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler serves the runtime settings of a chaos extension, as JSON.
+//
+// GET yields the current settings. POST and PUT update the settings: fields omitted from the payload
+// are left unchanged.
+//
+// Every request must be accepted by the authorize hook, or the handler responds with 403 Forbidden.
+// A nil authorize hook rejects all requests.
+//
+// Example:
+//
+//   curl -X POST -d '{"enabled": false}' http://localhost:8080/admin/chaos
+func AdminHandler(c *Chaos, authorize func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize == nil || !authorize(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			s := c.Settings()
+			if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := c.UpdateSettings(s); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Settings())
+	})
+}
@@ -0,0 +1,200 @@
+// Package gqlchaos provides a gqlgen extension injecting faults into resolvers: latency, errors or missing data,
+// on chosen fields and with a given probability.
+//
+// This allows teams to test the resilience of clients, and their handling of partial responses.
+//
+// Faults are controlled at runtime, with UpdateSettings or the AdminHandler. The extension is disabled by default:
+// it is advisable to only register it in pre-production environments.
+//
+// Example:
+//
+//   chaos := gqlchaos.New()
+//   srv.Use(chaos)
+//   http.Handle("/admin/chaos", gqlchaos.AdminHandler(chaos, isAdmin))
+//
+//   curl -X POST -d '{"enabled": true, "faults": [{"field": "Query.user", "probability": 0.1, "latencyMs": 500}]}' \
+//     http://localhost:8080/admin/chaos
+package gqlchaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "Chaos"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Chaos{}
+
+// ErrInjected is the default error injected into resolvers
+var ErrInjected = errors.New("chaos: injected fault")
+
+type (
+	// Settings of the faults injected at runtime
+	Settings struct {
+		// Enabled activates fault injection
+		Enabled bool `json:"enabled"`
+
+		// Faults to inject
+		Faults []Fault `json:"faults"`
+	}
+
+	// Fault injected into the resolvers of some fields.
+	//
+	// Fields are selected by Field, e.g. "Query.user", or by Path, e.g. "user.friends.name" (list indices are ignored).
+	// When both are set, both must match.
+	Fault struct {
+		Field string `json:"field,omitempty"`
+		Path  string `json:"path,omitempty"`
+
+		// Probability for the fault to be injected, between 0 and 1
+		Probability float64 `json:"probability"`
+
+		// LatencyMs delays the resolver by this duration, in milliseconds
+		LatencyMs int64 `json:"latencyMs,omitempty"`
+
+		// Error replaces the result of the resolver with an error carrying this message.
+		// The special value "default" yields ErrInjected.
+		Error string `json:"error,omitempty"`
+
+		// Null replaces the result of the resolver with null, without error
+		Null bool `json:"null,omitempty"`
+	}
+
+	// Chaos is a gqlgen extension injecting faults into resolvers
+	Chaos struct {
+		*config
+		settings atomic.Value
+	}
+)
+
+var pathIndices = regexp.MustCompile(`\[\d+\]`)
+
+// Validate settings
+func (s Settings) Validate() error {
+	for i, fault := range s.Faults {
+		if fault.Field == "" && fault.Path == "" {
+			return fmt.Errorf("fault #%d: a field or a path is required", i)
+		}
+		if fault.Probability < 0 || fault.Probability > 1 {
+			return fmt.Errorf("fault #%d: probability must be in [0, 1], got %v", i, fault.Probability)
+		}
+		if fault.LatencyMs < 0 {
+			return fmt.Errorf("fault #%d: latency must be positive, got %v", i, fault.LatencyMs)
+		}
+	}
+	return nil
+}
+
+// New chaos extension
+func New(opts ...Option) *Chaos {
+	c := &Chaos{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	c.settings.Store(c.config.settings)
+	return c
+}
+
+// Settings yields the current settings
+func (c *Chaos) Settings() Settings {
+	s := c.settings.Load().(Settings)
+	s.Faults = append([]Fault(nil), s.Faults...)
+	return s
+}
+
+// UpdateSettings atomically replaces the settings.
+//
+// It is safe to call UpdateSettings while the extension is serving requests.
+func (c *Chaos) UpdateSettings(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	s.Faults = append([]Fault(nil), s.Faults...)
+	c.settings.Store(s)
+	return nil
+}
+
+// ExtensionName yields the extension name: "Chaos"
+func (*Chaos) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Chaos) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (c *Chaos) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	s := c.settings.Load().(Settings)
+	if !s.Enabled || len(s.Faults) == 0 {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil {
+		return next(ctx)
+	}
+
+	field := fc.Object + "." + fc.Field.Name
+	var path string
+	for _, fault := range s.Faults {
+		if fault.Field != "" && fault.Field != field {
+			continue
+		}
+		if fault.Path != "" {
+			if path == "" {
+				path = pathIndices.ReplaceAllString(fc.Path().String(), "")
+			}
+			if fault.Path != path {
+				continue
+			}
+		}
+		if c.config.random() >= fault.Probability {
+			continue
+		}
+
+		return c.inject(ctx, fault, next)
+	}
+
+	return next(ctx)
+}
+
+func (c *Chaos) inject(ctx context.Context, fault Fault, next graphql.Resolver) (interface{}, error) {
+	if fault.LatencyMs > 0 {
+		timer := time.NewTimer(time.Duration(fault.LatencyMs) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	switch {
+	case fault.Error == "default":
+		return nil, ErrInjected
+	case fault.Error != "":
+		return nil, errors.New(fault.Error)
+	case fault.Null:
+		return nil, nil
+	default:
+		return next(ctx)
+	}
+}
+
+func defaultRandom() float64 {
+	return rand.Float64()
+}
@@ -0,0 +1,54 @@
+package gqlchaos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestChaos(t *testing.T) {
+	c := New(WithRandom(func() float64 { return 0.5 }))
+	require.Equal(t, extensionName, c.ExtensionName())
+
+	resolver := func(_ context.Context) (interface{}, error) {
+		return "ok", nil
+	}
+	field := func(object, name string) context.Context {
+		return graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+			Object: object,
+			Field:  graphql.CollectedField{Field: &ast.Field{Name: name, Alias: name}},
+		})
+	}
+
+	// disabled by default
+	res, err := c.InterceptField(field("Query", "user"), resolver)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	require.Error(t, c.UpdateSettings(Settings{Faults: []Fault{{Probability: 1}}}))
+	require.NoError(t, c.UpdateSettings(Settings{
+		Enabled: true,
+		Faults: []Fault{
+			{Field: "Query.user", Probability: 1, Error: "default"},
+			{Field: "Query.posts", Probability: 0.1, Error: "default"},
+			{Path: "friends", Probability: 1, Null: true},
+		},
+	}))
+
+	_, err = c.InterceptField(field("Query", "user"), resolver)
+	require.Equal(t, ErrInjected, err)
+
+	res, err = c.InterceptField(field("Query", "posts"), resolver)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	res, err = c.InterceptField(field("Query", "friends"), resolver)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	assert.Len(t, c.Settings().Faults, 3)
+}
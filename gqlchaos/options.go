@@ -0,0 +1,38 @@
+package gqlchaos
+
+type (
+	// Option for the chaos extension
+	Option func(*config)
+
+	config struct {
+		settings Settings
+		random   func() float64
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		random: defaultRandom,
+	}
+}
+
+// WithSettings sets the initial settings. By default, fault injection is disabled.
+//
+// Invalid settings are ignored.
+func WithSettings(s Settings) Option {
+	return func(c *config) {
+		if s.Validate() != nil {
+			return
+		}
+		c.settings = s
+		c.settings.Faults = append([]Fault(nil), s.Faults...)
+	}
+}
+
+// WithRandom sets the source of random numbers in [0, 1), used to decide whether to inject a fault.
+// By default, this is math/rand.Float64.
+func WithRandom(random func() float64) Option {
+	return func(c *config) {
+		c.random = random
+	}
+}
@@ -0,0 +1,74 @@
+package gqlchrometrace
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the trace recorder
+	Option func(*config)
+
+	config struct {
+		threshold time.Duration
+		rate      float64
+		allFields bool
+		maxEvents int
+		onError   func(context.Context, error)
+		clock     func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		threshold: time.Second,
+		rate:      1,
+		maxEvents: 10000,
+		clock:     graphql.Now,
+	}
+}
+
+// WithThreshold sets the duration above which operations are traced. The default is 1s.
+func WithThreshold(threshold time.Duration) Option {
+	return func(c *config) {
+		c.threshold = threshold
+	}
+}
+
+// WithSampleRate sets the fraction of slow operations which are traced, between 0 and 1. The default is 1.
+func WithSampleRate(rate float64) Option {
+	return func(c *config) {
+		c.rate = rate
+	}
+}
+
+// WithAllFields records all fields. By default, only fields resolved by resolver methods are recorded.
+func WithAllFields(enabled bool) Option {
+	return func(c *config) {
+		c.allFields = enabled
+	}
+}
+
+// WithMaxEvents sets the maximum number of fields recorded per operation. The default is 10000.
+// Fields beyond this limit are counted in the "dropped" metadata of traces.
+func WithMaxEvents(max int) Option {
+	return func(c *config) {
+		c.maxEvents = max
+	}
+}
+
+// WithErrorHandler sets a handler for sink errors. By default, sink errors are ignored.
+func WithErrorHandler(handler func(context.Context, error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithClock sets the clock used to measure intervals. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,157 @@
+// Package gqlchrometrace provides a gqlgen extension recording when each field resolver ran during slow operations,
+// as Chrome trace files.
+//
+// Trace files use the trace event format, and may be opened with chrome://tracing or https://ui.perfetto.dev.
+// They show which resolvers ran concurrently, and which ones were waited on sequentially.
+//
+// Example:
+//
+//   srv.Use(gqlchrometrace.New(
+//     gqlchrometrace.NewDirSink("/tmp/traces"),
+//     gqlchrometrace.WithThreshold(500*time.Millisecond),
+//     gqlchrometrace.WithSampleRate(0.1),
+//   ))
+package gqlchrometrace
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "ChromeTrace"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Recorder{}
+
+type (
+	// Recorder is a gqlgen extension recording the intervals of field resolvers, and emitting a Trace
+	// for sampled slow operations
+	Recorder struct {
+		*config
+		sink Sink
+	}
+
+	// interval occupied by a field resolver
+	interval struct {
+		name  string
+		path  string
+		start time.Time
+		end   time.Time
+		err   bool
+	}
+
+	// collector gathers the intervals of an operation
+	collector struct {
+		mx        sync.Mutex
+		intervals []interval
+		dropped   int
+	}
+
+	collectorKey struct{}
+)
+
+// New Recorder, emitting traces to a Sink
+func New(sink Sink, opts ...Option) *Recorder {
+	r := &Recorder{
+		config: defaultConfig(),
+		sink:   sink,
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// ExtensionName yields the extension name: "ChromeTrace"
+func (*Recorder) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Recorder) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor.
+//
+// Traces are emitted after the response, for operations slower than the threshold. Sink errors are reported
+// by the error handler (see WithErrorHandler) and do not affect the response.
+func (r *Recorder) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	c := &collector{}
+	start := r.config.clock()
+	resp := next(context.WithValue(ctx, collectorKey{}, c))
+	if resp == nil {
+		// the final pull of websocket transports, past the response of the operation
+		return resp
+	}
+	end := r.config.clock()
+
+	if end.Sub(start) < r.config.threshold || !r.sampled() {
+		return resp
+	}
+
+	c.mx.Lock()
+	intervals := c.intervals
+	dropped := c.dropped
+	c.mx.Unlock()
+
+	name := oc.OperationName
+	if name == "" {
+		name = "anonymous"
+	}
+	operation := interval{name: name, start: start, end: end, err: len(resp.Errors) > 0}
+	trace := newTrace(operation, intervals, dropped)
+
+	if err := r.sink.Write(ctx, trace); err != nil && r.config.onError != nil {
+		r.config.onError(ctx, err)
+	}
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor, recording the interval of the resolver
+func (r *Recorder) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || (!fc.IsMethod && !r.config.allFields) {
+		return next(ctx)
+	}
+
+	start := r.config.clock()
+	res, err := next(ctx)
+	end := r.config.clock()
+
+	c.add(interval{
+		name:  fc.Object + "." + fc.Field.Name,
+		path:  fc.Path().String(),
+		start: start,
+		end:   end,
+		err:   err != nil,
+	}, r.config.maxEvents)
+	return res, err
+}
+
+func (r *Recorder) sampled() bool {
+	return r.config.rate >= 1 || (r.config.rate > 0 && rand.Float64() < r.config.rate)
+}
+
+func (c *collector) add(i interval, max int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if max > 0 && len(c.intervals) >= max {
+		c.dropped++
+		return
+	}
+	c.intervals = append(c.intervals, i)
+}
@@ -0,0 +1,100 @@
+package gqlchrometrace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestNewTrace(t *testing.T) {
+	origin := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time {
+		return origin.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	trace := newTrace(interval{name: "GetUser", start: at(0), end: at(100)}, []interval{
+		{name: "Query.user", path: "user", start: at(0), end: at(40)},
+		{name: "User.friends", path: "user.friends", start: at(40), end: at(90)},
+		{name: "User.posts", path: "user.posts", start: at(45), end: at(60)},
+		{name: "User.avatar", path: "user.avatar", start: at(50), end: at(55), err: true},
+	}, 0)
+
+	assert.Equal(t, "GetUser", trace.Operation)
+	require.Len(t, trace.TraceEvents, 9)
+
+	lanes := make(map[string]int)
+	for _, e := range trace.TraceEvents {
+		if e.Phase == "X" {
+			lanes[e.Name] = e.ThreadID
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"GetUser":      0,
+		"Query.user":   1,
+		"User.friends": 1,
+		"User.posts":   2,
+		"User.avatar":  3,
+	}, lanes, "concurrent fields are on distinct lanes")
+
+	friends := trace.TraceEvents[2]
+	assert.Equal(t, "User.friends", friends.Name)
+	assert.Equal(t, int64(40000), friends.Timestamp)
+	assert.Equal(t, int64(50000), friends.Duration)
+	assert.Equal(t, true, trace.TraceEvents[4].Args["error"])
+}
+
+func TestRecorder(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var traces []Trace
+	r := New(SinkFunc(func(_ context.Context, trace Trace) error {
+		traces = append(traces, trace)
+		return nil
+	}), WithThreshold(50*time.Millisecond), WithClock(func() time.Time {
+		return now
+	}))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "GetUser"})
+	run := func(latency time.Duration) {
+		r.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			fctx := graphql.WithFieldContext(ctx, &graphql.FieldContext{
+				Object:   "Query",
+				Field:    graphql.CollectedField{Field: &ast.Field{Name: "user", Alias: "user"}},
+				IsMethod: true,
+			})
+			_, _ = r.InterceptField(fctx, func(context.Context) (interface{}, error) {
+				now = now.Add(latency)
+				return nil, nil
+			})
+			return &graphql.Response{}
+		})
+	}
+
+	run(10 * time.Millisecond)
+	assert.Empty(t, traces, "fast operations are not traced")
+
+	run(100 * time.Millisecond)
+	require.Len(t, traces, 1)
+	assert.Equal(t, "GetUser", traces[0].Operation)
+	assert.Equal(t, "Query.user", traces[0].TraceEvents[1].Name)
+	assert.Equal(t, "user", traces[0].TraceEvents[1].Args["path"])
+}
+
+func TestRecorderPullsUntilNil(t *testing.T) {
+	var traces []Trace
+	r := New(SinkFunc(func(_ context.Context, trace Trace) error {
+		traces = append(traces, trace)
+		return nil
+	}), WithThreshold(0))
+
+	// websocket transports pull responses until nil: the operation is traced once
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "GetUser"})
+	next := graphql.OneShot(&graphql.Response{})
+	for r.InterceptResponse(ctx, next) != nil {
+	}
+	assert.Len(t, traces, 1)
+}
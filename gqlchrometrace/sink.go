@@ -0,0 +1,57 @@
+package gqlchrometrace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+type (
+	// Sink stores traces
+	Sink interface {
+		Write(context.Context, Trace) error
+	}
+
+	// SinkFunc is a function implementing Sink
+	SinkFunc func(context.Context, Trace) error
+
+	// DirSink writes each trace as a JSON file in a directory
+	DirSink struct {
+		dir string
+	}
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Write implements Sink
+func (f SinkFunc) Write(ctx context.Context, trace Trace) error {
+	return f(ctx, trace)
+}
+
+// NewDirSink builds a Sink writing traces to files in a directory, created if needed.
+//
+// Files are named after the start time and the name of the operation, e.g. "20200102T150405.000000-GetUser.json".
+func NewDirSink(dir string) *DirSink {
+	return &DirSink{dir: dir}
+}
+
+// Write implements Sink
+func (s *DirSink) Write(_ context.Context, trace Trace) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(trace)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.json",
+		trace.Start.UTC().Format("20060102T150405.000000"),
+		unsafeFileChars.ReplaceAllString(trace.Operation, "_"),
+	)
+	return ioutil.WriteFile(filepath.Join(s.dir, name), buf, 0644)
+}
@@ -0,0 +1,127 @@
+package gqlchrometrace
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+const processID = 1
+
+type (
+	// Trace of an operation, in the trace event format of Chrome
+	Trace struct {
+		TraceEvents     []Event           `json:"traceEvents"`
+		DisplayTimeUnit string            `json:"displayTimeUnit"`
+		Metadata        map[string]string `json:"metadata,omitempty"`
+
+		// Operation is the name of the traced operation
+		Operation string `json:"-"`
+
+		// Start of the traced operation
+		Start time.Time `json:"-"`
+	}
+
+	// Event of a trace.
+	//
+	// Timestamps and durations are in microseconds, relative to the start of the operation.
+	Event struct {
+		Name      string                 `json:"name"`
+		Category  string                 `json:"cat,omitempty"`
+		Phase     string                 `json:"ph"`
+		Timestamp int64                  `json:"ts"`
+		Duration  int64                  `json:"dur,omitempty"`
+		ProcessID int                    `json:"pid"`
+		ThreadID  int                    `json:"tid"`
+		Args      map[string]interface{} `json:"args,omitempty"`
+	}
+)
+
+// newTrace lays out the intervals of the fields of an operation on lanes, displayed as threads.
+//
+// An interval is placed on the first lane free at its start: fields resolved concurrently appear on distinct lanes.
+// Since field interceptors only wrap resolvers, child fields start after their parent resolver returns, and never nest.
+func newTrace(operation interval, intervals []interval, dropped int) Trace {
+	sort.SliceStable(intervals, func(i, j int) bool {
+		if !intervals[i].start.Equal(intervals[j].start) {
+			return intervals[i].start.Before(intervals[j].start)
+		}
+		return intervals[i].path < intervals[j].path
+	})
+
+	trace := Trace{
+		TraceEvents:     make([]Event, 0, len(intervals)+2),
+		DisplayTimeUnit: "ms",
+		Metadata:        map[string]string{"operation": operation.name},
+		Operation:       operation.name,
+		Start:           operation.start,
+	}
+	if dropped > 0 {
+		trace.Metadata["dropped"] = strconv.Itoa(dropped)
+	}
+
+	op := event(operation, operation.start, "operation", 0)
+	if operation.err {
+		op.Args = map[string]interface{}{"error": true}
+	}
+	trace.TraceEvents = append(trace.TraceEvents, op)
+
+	// lanes hold the end of the last interval placed on each lane
+	var lanes []time.Time
+	for _, i := range intervals {
+		lane := len(lanes)
+		for l, end := range lanes {
+			if !end.After(i.start) {
+				lane = l
+				break
+			}
+		}
+		if lane == len(lanes) {
+			lanes = append(lanes, i.end)
+		} else {
+			lanes[lane] = i.end
+		}
+
+		e := event(i, operation.start, "field", lane+1)
+		e.Args = map[string]interface{}{"path": i.path}
+		if i.err {
+			e.Args["error"] = true
+		}
+		trace.TraceEvents = append(trace.TraceEvents, e)
+	}
+
+	trace.TraceEvents = append(trace.TraceEvents, Event{
+		Name:      "thread_name",
+		Phase:     "M",
+		ProcessID: processID,
+		ThreadID:  0,
+		Args:      map[string]interface{}{"name": "operation"},
+	})
+	for l := range lanes {
+		trace.TraceEvents = append(trace.TraceEvents, Event{
+			Name:      "thread_name",
+			Phase:     "M",
+			ProcessID: processID,
+			ThreadID:  l + 1,
+			Args:      map[string]interface{}{"name": "resolvers #" + strconv.Itoa(l+1)},
+		})
+	}
+	return trace
+}
+
+func event(i interval, origin time.Time, category string, thread int) Event {
+	e := Event{
+		Name:      i.name,
+		Category:  category,
+		Phase:     "X",
+		Timestamp: i.start.Sub(origin).Microseconds(),
+		Duration:  i.end.Sub(i.start).Microseconds(),
+		ProcessID: processID,
+		ThreadID:  thread,
+	}
+	if e.Duration == 0 {
+		// zero durations are omitted, which Chrome reads as an instant event
+		e.Duration = 1
+	}
+	return e
+}
@@ -0,0 +1,176 @@
+// Package gqlcoalesce provides a gqlgen extension to coalesce identical concurrent queries into a single execution.
+//
+// This is useful to absorb bursts of identical queries, such as the ones issued by dashboards.
+package gqlcoalesce
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/internal/detach"
+)
+
+const extensionName = "Coalesce"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Coalescer{}
+
+type (
+	// Coalescer is a gqlgen extension which coalesces identical concurrent queries into a single execution,
+	// and fans the result out to all waiting requests.
+	//
+	// Queries are identical when they share the same query, operation name, variables and scope.
+	// Without a scope (see WithScope), queries are not coalesced. Mutations and subscriptions are never coalesced.
+	//
+	// The shared execution runs with the values of the context of the first request, detached from its cancellation:
+	// a client going away does not fail the other requests. Every request stops waiting when its own context is done.
+	Coalescer struct {
+		*config
+		mx    sync.Mutex
+		calls map[string]*call
+	}
+
+	call struct {
+		done chan struct{}
+		resp *graphql.Response
+	}
+)
+
+// New Coalescer
+func New(opts ...Option) *Coalescer {
+	c := &Coalescer{
+		config: defaultConfig(),
+		calls:  make(map[string]*call),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "Coalesce"
+func (*Coalescer) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Coalescer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor.
+//
+// The response of a query is yielded once: the websocket transport pulls responses until it gets nil,
+// which must neither join another execution nor report the cancellation of the request again.
+func (c *Coalescer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if c.config.scope == nil || oc.Operation == nil || oc.Operation.Operation != ast.Query {
+		return next(ctx)
+	}
+
+	key, err := c.key(ctx, oc)
+	if err != nil {
+		return next(ctx)
+	}
+
+	handler := next(ctx)
+	var done bool
+	return func(ctx context.Context) *graphql.Response {
+		if done {
+			return nil
+		}
+		done = true
+		return c.coalesce(ctx, oc, key, handler)
+	}
+}
+
+// coalesce the execution of a query with the identical queries in flight
+func (c *Coalescer) coalesce(ctx context.Context, oc *graphql.OperationContext, key string, next graphql.ResponseHandler) *graphql.Response {
+	c.mx.Lock()
+	inflight, coalesced := c.calls[key]
+	if !coalesced {
+		inflight = &call{done: make(chan struct{})}
+		c.calls[key] = inflight
+		go c.run(detach.Context(ctx), key, inflight, next)
+	}
+	c.mx.Unlock()
+
+	select {
+	case <-inflight.done:
+	case <-ctx.Done():
+		return graphql.ErrorResponse(ctx, "%v", ctx.Err())
+	}
+
+	if !coalesced {
+		return inflight.resp
+	}
+
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.TagOperation, c.config.opLabel(oc))},
+		CoalescedCount.M(1),
+	)
+
+	return copyResponse(inflight.resp)
+}
+
+// run the shared execution of coalesced queries
+func (c *Coalescer) run(ctx context.Context, key string, inflight *call, next graphql.ResponseHandler) {
+	defer func() {
+		if p := recover(); p != nil {
+			inflight.resp = graphql.ErrorResponse(ctx, "coalesced execution panicked: %v", p)
+		}
+
+		c.mx.Lock()
+		delete(c.calls, key)
+		c.mx.Unlock()
+
+		close(inflight.done)
+	}()
+
+	inflight.resp = next(ctx)
+}
+
+func (c *Coalescer) key(ctx context.Context, oc *graphql.OperationContext) (string, error) {
+	variables, err := json.Marshal(oc.Variables)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(oc.RawQuery))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(oc.OperationName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(variables)
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(c.config.scope(ctx)))
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyResponse makes a shallow copy of a shared response, so waiting requests may add their own extensions.
+func copyResponse(resp *graphql.Response) *graphql.Response {
+	if resp == nil {
+		return nil
+	}
+
+	cp := *resp
+	if resp.Extensions != nil {
+		cp.Extensions = make(map[string]interface{}, len(resp.Extensions))
+		for k, v := range resp.Extensions {
+			cp.Extensions[k] = v
+		}
+	}
+	return &cp
+}
@@ -0,0 +1,239 @@
+package gqlcoalesce
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type userKey struct{}
+
+func userScope(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// waitingContext counts the requests waiting for an execution, i.e. selecting on the done channel of their context
+type waitingContext struct {
+	context.Context
+	waiting *int32
+}
+
+func (w waitingContext) Done() <-chan struct{} {
+	atomic.AddInt32(w.waiting, 1)
+	return w.Context.Done()
+}
+
+func withWaiting(ctx context.Context) (context.Context, func() int) {
+	var waiting int32
+	return waitingContext{Context: ctx, waiting: &waiting}, func() int { return int(atomic.LoadInt32(&waiting)) }
+}
+
+// intercept pulls the first response of a query
+func intercept(c *Coalescer, ctx context.Context, h graphql.ResponseHandler) *graphql.Response {
+	return c.InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler { return h })(ctx)
+}
+
+func TestCoalesce(t *testing.T) {
+	const n = 5
+	c := New(WithScope(userScope))
+	require.Equal(t, extensionName, c.ExtensionName())
+
+	release := make(chan struct{})
+	var executions int32
+	h := func(_ context.Context) *graphql.Response {
+		atomic.AddInt32(&executions, 1)
+		<-release
+		return &graphql.Response{
+			Data: json.RawMessage(`{"a": "abc"}`),
+		}
+	}
+
+	ctx, waiting := withWaiting(graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		RawQuery:      "query test { a }",
+		OperationName: "test",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query, Name: "test"},
+	}))
+
+	var wg sync.WaitGroup
+	responses := make([]*graphql.Response, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = intercept(c, ctx, h)
+		}(i)
+	}
+
+	require.Eventually(t, func() bool { return waiting() == n }, time.Second, time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&executions))
+	for _, resp := range responses {
+		require.NotNil(t, resp)
+		require.JSONEq(t, `{"a": "abc"}`, string(resp.Data))
+	}
+	c.mx.Lock()
+	require.Empty(t, c.calls)
+	c.mx.Unlock()
+}
+
+func TestCoalesceScope(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	var executions int32
+	h := func(_ context.Context) *graphql.Response {
+		atomic.AddInt32(&executions, 1)
+		started <- struct{}{}
+		<-release
+		return &graphql.Response{}
+	}
+
+	oc := &graphql.OperationContext{
+		RawQuery:  "{ a }",
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	}
+	concurrently := func(c *Coalescer, users ...string) {
+		var wg sync.WaitGroup
+		for _, user := range users {
+			wg.Add(1)
+			go func(user string) {
+				defer wg.Done()
+				ctx := context.WithValue(context.Background(), userKey{}, user)
+				_ = intercept(c, graphql.WithOperationContext(ctx, oc), h)
+			}(user)
+		}
+		for range users {
+			<-started
+		}
+		close(release)
+		wg.Wait()
+	}
+
+	// without a scope, queries are not coalesced
+	concurrently(New(), "alice", "alice")
+	require.Equal(t, int32(2), atomic.LoadInt32(&executions))
+
+	// queries from different users are not coalesced
+	atomic.StoreInt32(&executions, 0)
+	release = make(chan struct{})
+	concurrently(New(WithScope(userScope)), "alice", "bob")
+	require.Equal(t, int32(2), atomic.LoadInt32(&executions))
+}
+
+func TestCoalesceCancel(t *testing.T) {
+	c := New(WithScope(userScope))
+
+	release := make(chan struct{})
+	h := func(ctx context.Context) *graphql.Response {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return graphql.ErrorResponse(ctx, "%v", err)
+		}
+		return &graphql.Response{Data: json.RawMessage(`{"a": "abc"}`)}
+	}
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		RawQuery:  "{ a }",
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+
+	firstCtx, cancelFirst := context.WithCancel(ctx)
+	firstCtx, firstWaiting := withWaiting(firstCtx)
+	first := make(chan *graphql.Response)
+	go func() { first <- intercept(c, firstCtx, h) }()
+	require.Eventually(t, func() bool { return firstWaiting() == 1 }, time.Second, time.Millisecond)
+
+	waiterCtx, cancelWaiter := context.WithCancel(ctx)
+	waiterCtx, waiterWaiting := withWaiting(waiterCtx)
+	waiter := make(chan *graphql.Response)
+	go func() { waiter <- intercept(c, waiterCtx, h) }()
+	require.Eventually(t, func() bool { return waiterWaiting() == 1 }, time.Second, time.Millisecond)
+
+	otherCtx, otherWaiting := withWaiting(ctx)
+	other := make(chan *graphql.Response)
+	go func() { other <- intercept(c, otherCtx, h) }()
+	require.Eventually(t, func() bool { return otherWaiting() == 1 }, time.Second, time.Millisecond)
+
+	// requests give up when their own context is done, while the shared execution continues
+	cancelWaiter()
+	resp := <-waiter
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, context.Canceled.Error(), resp.Errors[0].Message)
+
+	// the first request going away does not fail the others
+	cancelFirst()
+	resp = <-first
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, context.Canceled.Error(), resp.Errors[0].Message)
+
+	close(release)
+	resp = <-other
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `{"a": "abc"}`, string(resp.Data))
+
+	require.Eventually(t, func() bool {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		return len(c.calls) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestCoalesceMutation(t *testing.T) {
+	c := New(WithScope(userScope))
+
+	var executions int32
+	h := func(_ context.Context) *graphql.Response {
+		atomic.AddInt32(&executions, 1)
+		return &graphql.Response{}
+	}
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		RawQuery:  "mutation { a }",
+		Operation: &ast.OperationDefinition{Operation: ast.Mutation},
+	})
+	_ = intercept(c, ctx, h)
+	_ = intercept(c, ctx, h)
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&executions))
+}
+
+func TestCoalesceOneShot(t *testing.T) {
+	c := New(WithScope(userScope))
+	h := func(_ context.Context) *graphql.Response {
+		return &graphql.Response{Data: json.RawMessage(`{"a": "abc"}`)}
+	}
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		RawQuery:  "{ a }",
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+
+	// the websocket transport pulls responses until it gets nil
+	handler := c.InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler { return h })
+	require.NotNil(t, handler(ctx))
+	require.Nil(t, handler(ctx))
+
+	// including when the request went away
+	release := make(chan struct{})
+	defer close(release)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	handler = c.InterceptOperation(cancelled, func(context.Context) graphql.ResponseHandler {
+		return func(context.Context) *graphql.Response {
+			<-release
+			return nil
+		}
+	})
+	resp := handler(cancelled)
+	require.Len(t, resp.Errors, 1)
+	require.Nil(t, handler(cancelled))
+}
@@ -0,0 +1,39 @@
+package gqlcoalesce
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(CoalescedCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(CoalescedCountView)
+}
+
+var (
+	// CoalescedCount tracks a count of GraphQL requests served by the execution of an identical concurrent request
+	CoalescedCount = stats.Int64(
+		"gql/server/coalesced_count",
+		"Number of GraphQL requests coalesced with an identical concurrent request",
+		stats.UnitDimensionless)
+
+	// CoalescedCountView reports a count of coalesced requests tagged by operation name
+	CoalescedCountView = &view.View{
+		Name:        "gql/server/coalesced_count",
+		Description: "Count of GraphQL requests coalesced by operation",
+		Measure:     CoalescedCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
@@ -0,0 +1,42 @@
+package gqlcoalesce
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the coalescing extension
+	Option func(*config)
+
+	config struct {
+		scope   func(context.Context) string
+		opLabel gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		opLabel: gqllabel.OperationName,
+	}
+}
+
+// WithScope enables coalescing of requests sharing the same scope, e.g. the same user or tenant.
+//
+// By default, there is no scope and queries are never coalesced. A constant scope coalesces identical
+// queries across all users: this is only safe when the results do not depend on the caller.
+func WithScope(scope func(context.Context) string) Option {
+	return func(c *config) {
+		c.scope = scope
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
@@ -0,0 +1,30 @@
+// Package gqlcode holds the error codes shared by the extensions of this repository, set as the "code"
+// of the extensions of GraphQL errors.
+//
+// Extensions rejecting operations use these codes, and gqlstatus maps them to HTTP status codes,
+// without depending on each other.
+package gqlcode
+
+// Error codes shared by extensions
+const (
+	// Unauthenticated rejects operations from unauthenticated clients
+	Unauthenticated = "UNAUTHENTICATED"
+
+	// Forbidden rejects operations and fields denied to the client
+	Forbidden = "FORBIDDEN"
+
+	// RateLimited rejects operations of clients exceeding their rate limit
+	RateLimited = "RATE_LIMITED"
+
+	// Internal reports internal errors
+	Internal = "INTERNAL"
+
+	// ServiceOverloaded rejects operations shed by a full or timed out queue (gqlqueue)
+	ServiceOverloaded = "SERVICE_OVERLOADED"
+
+	// ServiceUnavailable rejects operations during maintenance (gqlmaintenance)
+	ServiceUnavailable = "SERVICE_UNAVAILABLE"
+
+	// ServerShuttingDown rejects or cancels operations while the server drains (gqldrain)
+	ServerShuttingDown = "SERVER_SHUTTING_DOWN"
+)
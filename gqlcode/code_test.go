@@ -0,0 +1,24 @@
+package gqlcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodes(t *testing.T) {
+	// codes are seen by clients: changing their value breaks them
+	codes := map[string]string{
+		Unauthenticated:    "UNAUTHENTICATED",
+		Forbidden:          "FORBIDDEN",
+		RateLimited:        "RATE_LIMITED",
+		Internal:           "INTERNAL",
+		ServiceOverloaded:  "SERVICE_OVERLOADED",
+		ServiceUnavailable: "SERVICE_UNAVAILABLE",
+		ServerShuttingDown: "SERVER_SHUTTING_DOWN",
+	}
+	assert.Len(t, codes, 7, "codes must be distinct")
+	for code, expected := range codes {
+		assert.Equal(t, expected, code)
+	}
+}
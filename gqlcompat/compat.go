@@ -0,0 +1,159 @@
+// Package gqlcompat adapts the gqlgen extensions of this repository to plain middleware functions,
+// for gqlgen v0.11 servers which do not register extensions with handler.Server.Use.
+//
+// This is the case of servers still built with the deprecated github.com/99designs/gqlgen/handler package
+// of gqlgen v0.11, which only accepts resolver and request middleware. Releases of gqlgen before v0.11 are not
+// supported: their middleware do not share the graphql interfaces of extensions, nor their operation context.
+//
+// Example:
+//
+//   mw, err := gqlcompat.Adapt(exec, tracer, metrics)
+//   if err != nil {
+//     log.Fatal(err)
+//   }
+//   http.Handle("/query", handler.GraphQL(exec,
+//     handler.ResolverMiddleware(mw.Field),
+//     handler.RequestMiddleware(mw.Response),
+//   ))
+//
+// The adapted middleware behave like extensions registered in the same order: the first extension wraps the others.
+//
+// Only operation context mutators and operation, response and field interceptors are adapted. Operation context
+// mutators and operation interceptors only run where the server exposes an operation middleware, which
+// handler.GraphQL does not. Operation parameter mutators (e.g. gqlsign) run before the operation context is built,
+// and cannot be adapted: Adapt rejects them, rather than silently skipping their checks.
+package gqlcompat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type (
+	// Middlewares adapted from gqlgen extensions
+	Middlewares struct {
+		// Operation runs the operation context mutators and the operation interceptors
+		Operation graphql.OperationMiddleware
+
+		// Response runs the response interceptors
+		Response graphql.ResponseMiddleware
+
+		// Field runs the field interceptors
+		Field graphql.FieldMiddleware
+	}
+)
+
+// Adapt extensions to middleware functions. Extensions are validated against the executable schema.
+//
+// Extensions implementing graphql.OperationParameterMutator, or none of the adapted interfaces, are rejected.
+func Adapt(schema graphql.ExecutableSchema, extensions ...graphql.HandlerExtension) (Middlewares, error) {
+	var (
+		mutators    []graphql.OperationContextMutator
+		operations  []graphql.OperationInterceptor
+		responses   []graphql.ResponseInterceptor
+		fields      []graphql.FieldInterceptor
+		unsupported []string
+	)
+
+	for _, extension := range extensions {
+		if err := extension.Validate(schema); err != nil {
+			return Middlewares{}, fmt.Errorf("%s: %w", extension.ExtensionName(), err)
+		}
+
+		if _, ok := extension.(graphql.OperationParameterMutator); ok {
+			return Middlewares{}, fmt.Errorf("gqlcompat: %s mutates operation parameters, which may not be adapted", extension.ExtensionName())
+		}
+
+		supported := false
+		if m, ok := extension.(graphql.OperationContextMutator); ok {
+			mutators = append(mutators, m)
+			supported = true
+		}
+		if i, ok := extension.(graphql.OperationInterceptor); ok {
+			operations = append(operations, i)
+			supported = true
+		}
+		if i, ok := extension.(graphql.ResponseInterceptor); ok {
+			responses = append(responses, i)
+			supported = true
+		}
+		if i, ok := extension.(graphql.FieldInterceptor); ok {
+			fields = append(fields, i)
+			supported = true
+		}
+		if !supported {
+			unsupported = append(unsupported, extension.ExtensionName())
+		}
+	}
+	if len(unsupported) > 0 {
+		return Middlewares{}, fmt.Errorf("gqlcompat: extensions %v implement no interceptor which may be adapted", unsupported)
+	}
+
+	return Middlewares{
+		Operation: operationMiddleware(mutators, operations),
+		Response:  responseMiddleware(responses),
+		Field:     fieldMiddleware(fields),
+	}, nil
+}
+
+func operationMiddleware(mutators []graphql.OperationContextMutator, interceptors []graphql.OperationInterceptor) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		if graphql.HasOperationContext(ctx) {
+			oc := graphql.GetOperationContext(ctx)
+			for _, mutator := range mutators {
+				if err := mutator.MutateOperationContext(ctx, oc); err != nil {
+					return errorResponse(err)
+				}
+			}
+		}
+
+		handler := next
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], handler
+			handler = func(ctx context.Context) graphql.ResponseHandler {
+				return interceptor.InterceptOperation(ctx, inner)
+			}
+		}
+		return handler(ctx)
+	}
+}
+
+func responseMiddleware(interceptors []graphql.ResponseInterceptor) graphql.ResponseMiddleware {
+	return func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		handler := next
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], handler
+			handler = func(ctx context.Context) *graphql.Response {
+				return interceptor.InterceptResponse(ctx, inner)
+			}
+		}
+		return handler(ctx)
+	}
+}
+
+func fieldMiddleware(interceptors []graphql.FieldInterceptor) graphql.FieldMiddleware {
+	return func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		resolver := next
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], resolver
+			resolver = func(ctx context.Context) (interface{}, error) {
+				return interceptor.InterceptField(ctx, inner)
+			}
+		}
+		return resolver(ctx)
+	}
+}
+
+func errorResponse(err *gqlerror.Error) graphql.ResponseHandler {
+	sent := false
+	return func(context.Context) *graphql.Response {
+		if sent {
+			return nil
+		}
+		sent = true
+		return &graphql.Response{Errors: gqlerror.List{err}}
+	}
+}
@@ -0,0 +1,115 @@
+package gqlcompat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type testExtension struct {
+	name  string
+	trace *[]string
+	fail  bool
+}
+
+func (e *testExtension) ExtensionName() string {
+	return e.name
+}
+
+func (e *testExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e *testExtension) MutateOperationContext(context.Context, *graphql.OperationContext) *gqlerror.Error {
+	*e.trace = append(*e.trace, e.name+".mutate")
+	if e.fail {
+		return gqlerror.Errorf("rejected by %s", e.name)
+	}
+	return nil
+}
+
+func (e *testExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	*e.trace = append(*e.trace, e.name+".response")
+	return next(ctx)
+}
+
+func (e *testExtension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	*e.trace = append(*e.trace, e.name+".field")
+	return next(ctx)
+}
+
+type nameOnly struct{}
+
+func (nameOnly) ExtensionName() string                   { return "NameOnly" }
+func (nameOnly) Validate(graphql.ExecutableSchema) error { return nil }
+
+type paramMutator struct {
+	nameOnly
+}
+
+func (paramMutator) MutateOperationParameters(context.Context, *graphql.RawParams) *gqlerror.Error {
+	return nil
+}
+
+func TestAdapt(t *testing.T) {
+	var trace []string
+	first := &testExtension{name: "first", trace: &trace}
+	second := &testExtension{name: "second", trace: &trace}
+
+	mw, err := Adapt(nil, first, second)
+	require.NoError(t, err)
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{})
+	resp := mw.Response(ctx, func(context.Context) *graphql.Response {
+		trace = append(trace, "response")
+		return &graphql.Response{}
+	})
+	require.NotNil(t, resp)
+
+	res, err := mw.Field(ctx, func(context.Context) (interface{}, error) {
+		trace = append(trace, "field")
+		return "value", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", res)
+
+	handler := mw.Operation(ctx, func(context.Context) graphql.ResponseHandler {
+		trace = append(trace, "operation")
+		return nil
+	})
+	assert.Nil(t, handler)
+
+	assert.Equal(t, []string{
+		"first.response", "second.response", "response",
+		"first.field", "second.field", "field",
+		"first.mutate", "second.mutate", "operation",
+	}, trace)
+
+	second.fail = true
+	handler = mw.Operation(ctx, func(context.Context) graphql.ResponseHandler {
+		t.Fatal("the operation must not run when a mutator fails")
+		return nil
+	})
+	resp = handler(ctx)
+	require.NotNil(t, resp)
+	assert.Equal(t, "rejected by second", resp.Errors[0].Message)
+	assert.Nil(t, handler(ctx))
+
+	// without operation context, mutators are skipped
+	handler = mw.Operation(context.Background(), func(context.Context) graphql.ResponseHandler {
+		trace = append(trace, "no operation context")
+		return nil
+	})
+	assert.Nil(t, handler)
+	assert.Equal(t, "no operation context", trace[len(trace)-1])
+
+	_, err = Adapt(nil, first, nameOnly{})
+	assert.Error(t, err)
+
+	_, err = Adapt(nil, first, paramMutator{})
+	assert.Error(t, err, "operation parameter mutators may not be adapted")
+}
@@ -0,0 +1,80 @@
+package gqlcompress
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the middleware.
+func Register() error {
+	return gqlmetrics.Register(CompressionViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(CompressionViews...)
+}
+
+var (
+	// TagEncoding is the content encoding of the response, e.g. "gzip" or "identity"
+	TagEncoding = tag.MustNewKey("gql.encoding")
+
+	// CompressionViews contains all opencensus stats views declared by the compression middleware
+	CompressionViews = []*view.View{
+		ResponseBytesView,
+		ResponseWireBytesView,
+		CompressionRatioView,
+	}
+
+	// ResponseBytes tracks the size of responses before compression, in bytes
+	ResponseBytes = stats.Int64(
+		"gql/server/response_bytes",
+		"Size of GraphQL responses before compression",
+		stats.UnitBytes)
+
+	// ResponseWireBytes tracks the size of responses sent to clients, after compression, in bytes
+	ResponseWireBytes = stats.Int64(
+		"gql/server/response_wire_bytes",
+		"Size of GraphQL responses after compression",
+		stats.UnitBytes)
+
+	// CompressionRatio tracks the compression ratio of compressed responses
+	CompressionRatio = stats.Float64(
+		"gql/server/compression_ratio",
+		"Compression ratio of GraphQL responses",
+		stats.UnitDimensionless)
+
+	sizeDistribution = view.Distribution(256, 1<<10, 4<<10, 16<<10, 64<<10, 256<<10, 1<<20, 4<<20, 16<<20)
+
+	// ResponseBytesView reports a distribution of the size of responses before compression, by encoding
+	ResponseBytesView = &view.View{
+		Name:        "gql/server/response_bytes",
+		Description: "Distribution of the size of GraphQL responses before compression",
+		Measure:     ResponseBytes,
+		Aggregation: sizeDistribution,
+		TagKeys:     []tag.Key{TagEncoding},
+	}
+
+	// ResponseWireBytesView reports a distribution of the size of responses after compression, by encoding
+	ResponseWireBytesView = &view.View{
+		Name:        "gql/server/response_wire_bytes",
+		Description: "Distribution of the size of GraphQL responses after compression",
+		Measure:     ResponseWireBytes,
+		Aggregation: sizeDistribution,
+		TagKeys:     []tag.Key{TagEncoding},
+	}
+
+	// CompressionRatioView reports a distribution of the compression ratio of compressed responses, by encoding
+	CompressionRatioView = &view.View{
+		Name:        "gql/server/compression_ratio",
+		Description: "Distribution of the compression ratio of GraphQL responses",
+		Measure:     CompressionRatio,
+		Aggregation: view.Distribution(1, 1.5, 2, 3, 4, 5, 7.5, 10, 15, 20),
+		TagKeys:     []tag.Key{TagEncoding},
+	}
+)
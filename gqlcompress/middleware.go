@@ -0,0 +1,286 @@
+// Package gqlcompress provides a HTTP middleware instrumenting the compression of GraphQL responses.
+//
+// The middleware records the size of responses before and after compression, and the compression ratio,
+// as opencensus metrics and as attributes of the current span.
+//
+// It may also compress responses with gzip, when they exceed a threshold size and the client accepts it
+// (see WithCompressionThreshold).
+//
+// When responses are compressed by another middleware (e.g. for brotli), wrap the GraphQL handler
+// with Uncompressed to measure the size of responses before compression:
+//
+//   http.Handle("/query", gqlcompress.Middleware(brotliHandler(gqlcompress.Uncompressed(srv))))
+//
+// The Payloads extension reduces the size of large response extensions, by compressing them or
+// delivering them out-of-band.
+package gqlcompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+type sizesKey struct{}
+
+type (
+	// sizes collects the size of a response before compression
+	sizes struct {
+		mx           sync.Mutex
+		uncompressed int64
+		counted      bool
+	}
+
+	// countingWriter counts the bytes written to the underlying writer
+	countingWriter struct {
+		http.ResponseWriter
+		sizes   *sizes
+		written int64
+	}
+)
+
+// Middleware records the size of responses before and after compression.
+//
+// Websocket upgrade requests are passed through untouched.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sz := new(sizes)
+		ctx := context.WithValue(r.Context(), sizesKey{}, sz)
+		r = r.WithContext(ctx)
+		wire := &countingWriter{ResponseWriter: w}
+
+		if cfg.threshold > 0 && acceptsGzip(r) {
+			gz := newGzipWriter(wire, sz, cfg)
+			next.ServeHTTP(gz, r)
+			gz.close()
+		} else {
+			next.ServeHTTP(wire, r)
+		}
+
+		cfg.record(ctx, w.Header().Get("Content-Encoding"), sz, wire.written)
+	})
+}
+
+// Uncompressed measures the size of responses before compression by another middleware.
+//
+// It must wrap the GraphQL handler, within the Middleware and the compression middleware.
+func Uncompressed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sz, ok := r.Context().Value(sizesKey{}).(*sizes)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&countingWriter{ResponseWriter: w, sizes: sz}, r)
+	})
+}
+
+func (c config) record(ctx context.Context, encoding string, sz *sizes, wire int64) {
+	uncompressed, counted := sz.get()
+	if !counted {
+		uncompressed = wire
+	}
+	if encoding == "" {
+		encoding = "identity"
+	}
+
+	measurements := []stats.Measurement{
+		ResponseBytes.M(uncompressed),
+		ResponseWireBytes.M(wire),
+	}
+	ratio := 1.0
+	if wire > 0 && encoding != "identity" {
+		ratio = float64(uncompressed) / float64(wire)
+		measurements = append(measurements, CompressionRatio.M(ratio))
+	}
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagEncoding, encoding)}, measurements...)
+
+	span := trace.FromContext(ctx)
+	if span == nil || !span.IsRecordingEvents() {
+		return
+	}
+	span.AddAttributes(
+		trace.StringAttribute("response.encoding", encoding),
+		trace.Int64Attribute("response.bytes", uncompressed),
+		trace.Int64Attribute("response.wire_bytes", wire),
+		trace.Float64Attribute("response.compression_ratio", ratio),
+	)
+}
+
+func (s *sizes) add(n int) {
+	s.mx.Lock()
+	s.uncompressed += int64(n)
+	s.counted = true
+	s.mx.Unlock()
+}
+
+func (s *sizes) get() (int64, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.uncompressed, s.counted
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	if w.sizes != nil {
+		w.sizes.add(n)
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *countingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *countingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return httpwriter.Hijack(w.ResponseWriter)
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), "gzip") {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter buffers the response until it exceeds the threshold size, then compresses it with gzip.
+// Smaller responses are sent uncompressed.
+type gzipWriter struct {
+	http.ResponseWriter
+	wire   *countingWriter
+	sizes  *sizes
+	config *config
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func newGzipWriter(wire *countingWriter, sz *sizes, cfg *config) *gzipWriter {
+	return &gzipWriter{
+		ResponseWriter: wire,
+		wire:           wire,
+		sizes:          sz,
+		config:         cfg,
+		status:         http.StatusOK,
+	}
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if !w.decided {
+		w.status = status
+		return
+	}
+	w.wire.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	w.sizes.add(len(b))
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.wire.Write(b)
+	}
+
+	n, _ := w.buf.Write(b)
+	if w.buf.Len() >= w.config.threshold {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Flush implements http.Flusher. Responses flushed before reaching the threshold are sent uncompressed.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.wire.Flush()
+}
+
+// Hijack implements http.Hijacker
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return httpwriter.Hijack(w.wire.ResponseWriter)
+}
+
+// decide whether to compress the response, then send the headers and the buffered response
+func (w *gzipWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.wire.Header()
+	if compress && header.Get("Content-Encoding") == "" {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		gz, err := gzip.NewWriterLevel(w.wire, w.config.level)
+		if err != nil {
+			return err
+		}
+		w.gz = gz
+	}
+	w.wire.WriteHeader(w.status)
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.wire.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
@@ -0,0 +1,141 @@
+package gqlcompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	body := `{"data":{"items":["` + strings.Repeat("abc", 1000) + `"]}}`
+	gqlHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+
+	t.Run("compressed over threshold", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+		w := httptest.NewRecorder()
+
+		Middleware(gqlHandler, WithCompressionThreshold(1024)).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.True(t, w.Body.Len() < len(body))
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		uncompressed, err := ioutil.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(uncompressed))
+	})
+
+	t.Run("uncompressed under threshold", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		Middleware(gqlHandler, WithCompressionThreshold(len(body)+1)).ServeHTTP(w, r)
+
+		require.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, w.Body.String())
+	})
+
+	t.Run("gzip not accepted", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.Header.Set("Accept-Encoding", "gzip;q=0")
+		w := httptest.NewRecorder()
+
+		Middleware(gqlHandler, WithCompressionThreshold(1)).ServeHTTP(w, r)
+
+		require.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, w.Body.String())
+	})
+
+	t.Run("uncompressed size measured", func(t *testing.T) {
+		var sz *sizes
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		w := httptest.NewRecorder()
+
+		Middleware(Uncompressed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sz = r.Context().Value(sizesKey{}).(*sizes)
+			gqlHandler.ServeHTTP(w, r)
+		}))).ServeHTTP(w, r)
+
+		uncompressed, counted := sz.get()
+		require.True(t, counted)
+		assert.Equal(t, int64(len(body)), uncompressed)
+	})
+}
+
+func TestPayloads(t *testing.T) {
+	store := NewMemoryPayloadStore(time.Minute, 10)
+	payloads := NewPayloads(
+		WithPayloadThreshold(100),
+		WithPayloadStore(store, "/extensions"),
+		WithPayloadRequestID(func(context.Context) string { return "req-1" }),
+	)
+
+	tracing := map[string]interface{}{"trace": strings.Repeat("x", 200)}
+	respond := func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{Extensions: map[string]interface{}{
+			"tracing": tracing,
+			"small":   "ok",
+		}}
+	}
+	intercept := func(header string) (resp *graphql.Response) {
+		handler := payloads.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			resp = payloads.InterceptResponse(r.Context(), respond)
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.Header.Set(ExtensionsEncodingHeader, header)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return
+	}
+	expected, err := json.Marshal(tracing)
+	require.NoError(t, err)
+
+	t.Run("compressed", func(t *testing.T) {
+		resp := intercept("gzip")
+		assert.Equal(t, "ok", resp.Extensions["small"])
+		compressed, ok := resp.Extensions["tracing"].(CompressedPayload)
+		require.True(t, ok)
+		assert.Equal(t, EncodingGzipBase64, compressed.Encoding)
+
+		data, err := base64.StdEncoding.DecodeString(compressed.Data)
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		payload, err := ioutil.ReadAll(zr)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(expected), string(payload))
+	})
+
+	t.Run("offloaded", func(t *testing.T) {
+		resp := intercept("")
+		assert.Equal(t, "ok", resp.Extensions["small"])
+		assert.Equal(t, OffloadedPayload{URL: "/extensions?id=req-1&key=tracing"}, resp.Extensions["tracing"])
+
+		w := httptest.NewRecorder()
+		PayloadsHandler(store).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/extensions?id=req-1&key=tracing", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, string(expected), w.Body.String())
+
+		w = httptest.NewRecorder()
+		PayloadsHandler(store).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/extensions?id=other", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
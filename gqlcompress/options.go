@@ -0,0 +1,36 @@
+package gqlcompress
+
+import "compress/gzip"
+
+type (
+	// Option for the compression middleware
+	Option func(*config)
+
+	config struct {
+		threshold int
+		level     int
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		level: gzip.DefaultCompression,
+	}
+}
+
+// WithCompressionThreshold compresses responses with gzip when they exceed the threshold size (in bytes),
+// and the client accepts gzip. Responses already compressed by the handler are left untouched.
+//
+// By default, the middleware does not compress responses.
+func WithCompressionThreshold(threshold int) Option {
+	return func(c *config) {
+		c.threshold = threshold
+	}
+}
+
+// WithCompressionLevel sets the gzip compression level. The default is gzip.DefaultCompression.
+func WithCompressionLevel(level int) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
@@ -0,0 +1,323 @@
+package gqlcompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// ExtensionsEncodingHeader is the request header by which clients accept compressed extension payloads,
+// with the value "gzip"
+const ExtensionsEncodingHeader = "X-GraphQL-Extensions-Encoding"
+
+// EncodingGzipBase64 is the encoding of compressed extension payloads
+const EncodingGzipBase64 = "gzip+base64"
+
+const payloadsExtensionName = "ExtensionPayloads"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Payloads{}
+
+type (
+	// Payloads is a gqlgen extension reducing the size of large response extensions, such as apollo tracing
+	// or debug payloads.
+	//
+	// Extension payloads larger than a threshold are either:
+	//
+	//   - compressed with gzip and encoded in base64, when the client accepts it with the header
+	//     "X-GraphQL-Extensions-Encoding: gzip". The payload is replaced by {"encoding": "gzip+base64", "data": "..."}
+	//   - delivered out-of-band, when a store is configured (see WithPayloadStore). The payload is replaced by
+	//     {"url": "/extensions?id=...&key=tracing"}, to be retrieved from the PayloadsHandler
+	//
+	// Other payloads are left untouched. Register this extension first, so that it intercepts the extensions
+	// added by all other extensions:
+	//
+	//   payloads := gqlcompress.NewPayloads(
+	//     gqlcompress.WithPayloadThreshold(8<<10),
+	//     gqlcompress.WithPayloadStore(gqlcompress.NewMemoryPayloadStore(time.Minute, 1000), "/extensions"),
+	//   )
+	//   srv.Use(payloads)
+	//   srv.Use(apollotracing.Tracer{})
+	//   http.Handle("/query", payloads.Middleware(srv))
+	//   http.Handle("/extensions", gqlcompress.PayloadsHandler(store))
+	Payloads struct {
+		*payloadConfig
+	}
+
+	// PayloadOption for the extension payloads extension
+	PayloadOption func(*payloadConfig)
+
+	payloadConfig struct {
+		threshold int
+		keys      map[string]bool
+		store     PayloadStore
+		url       string
+		requestID func(context.Context) string
+	}
+
+	// CompressedPayload replaces a compressed extension payload
+	CompressedPayload struct {
+		Encoding string `json:"encoding"`
+		Data     string `json:"data"`
+	}
+
+	// OffloadedPayload replaces an extension payload delivered out-of-band
+	OffloadedPayload struct {
+		URL string `json:"url"`
+	}
+
+	// PayloadStore holds extension payloads delivered out-of-band, by request ID and extension key
+	PayloadStore interface {
+		Put(id string, payloads map[string]json.RawMessage)
+		Get(id string) (map[string]json.RawMessage, bool)
+	}
+
+	// MemoryPayloadStore is a PayloadStore held in memory, for a limited time. It is safe for concurrent use.
+	MemoryPayloadStore struct {
+		ttl        time.Duration
+		maxEntries int
+
+		mx      sync.Mutex
+		entries map[string]storedPayloads
+		order   []string
+	}
+
+	storedPayloads struct {
+		payloads map[string]json.RawMessage
+		expires  time.Time
+	}
+
+	acceptsKey struct{}
+)
+
+// NewPayloads builds an extension reducing the size of large extension payloads
+func NewPayloads(opts ...PayloadOption) *Payloads {
+	p := &Payloads{
+		payloadConfig: &payloadConfig{
+			threshold: 4 << 10,
+			requestID: newRequestID,
+		},
+	}
+	for _, apply := range opts {
+		apply(p.payloadConfig)
+	}
+	return p
+}
+
+// WithPayloadThreshold sets the size (in bytes) above which extension payloads are compressed or offloaded.
+// The default is 4KiB.
+func WithPayloadThreshold(threshold int) PayloadOption {
+	return func(c *payloadConfig) {
+		c.threshold = threshold
+	}
+}
+
+// WithPayloadKeys restricts the extensions considered, e.g. "tracing". By default, all extensions are considered.
+func WithPayloadKeys(keys ...string) PayloadOption {
+	return func(c *payloadConfig) {
+		if c.keys == nil {
+			c.keys = make(map[string]bool, len(keys))
+		}
+		for _, key := range keys {
+			c.keys[key] = true
+		}
+	}
+}
+
+// WithPayloadStore delivers large extension payloads out-of-band, when the client does not accept compressed payloads.
+// The url is the path where the PayloadsHandler serving this store is mounted.
+func WithPayloadStore(store PayloadStore, url string) PayloadOption {
+	return func(c *payloadConfig) {
+		c.store = store
+		c.url = url
+	}
+}
+
+// WithPayloadRequestID sets the function retrieving the ID of the request from the context, to key offloaded payloads.
+// By default, a random ID is generated.
+func WithPayloadRequestID(requestID func(context.Context) string) PayloadOption {
+	return func(c *payloadConfig) {
+		c.requestID = requestID
+	}
+}
+
+// Middleware captures the negotiation of compressed extension payloads into the request context.
+//
+// The middleware must wrap the gqlgen handler.
+func (p *Payloads) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(ExtensionsEncodingHeader) == "gzip" {
+			r = r.WithContext(context.WithValue(r.Context(), acceptsKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ExtensionName yields the extension name: "ExtensionPayloads"
+func (*Payloads) ExtensionName() string {
+	return payloadsExtensionName
+}
+
+// Validate this extension. This is a noop
+func (*Payloads) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (p *Payloads) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || len(resp.Extensions) == 0 {
+		return resp
+	}
+
+	accepts, _ := ctx.Value(acceptsKey{}).(bool)
+	if !accepts && p.store == nil {
+		return resp
+	}
+
+	var offloaded map[string]json.RawMessage
+	for key, value := range resp.Extensions {
+		if p.keys != nil && !p.keys[key] {
+			continue
+		}
+		payload, err := json.Marshal(value)
+		if err != nil || len(payload) <= p.threshold {
+			continue
+		}
+
+		if accepts {
+			if compressed, err := compressPayload(payload); err == nil {
+				resp.Extensions[key] = compressed
+			}
+			continue
+		}
+
+		if offloaded == nil {
+			offloaded = make(map[string]json.RawMessage)
+		}
+		offloaded[key] = payload
+	}
+
+	if len(offloaded) == 0 {
+		return resp
+	}
+	id := p.requestID(ctx)
+	p.store.Put(id, offloaded)
+	for key := range offloaded {
+		resp.Extensions[key] = OffloadedPayload{
+			URL: p.url + "?" + url.Values{"id": {id}, "key": {key}}.Encode(),
+		}
+	}
+	return resp
+}
+
+func compressPayload(payload []byte) (CompressedPayload, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return CompressedPayload{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return CompressedPayload{}, err
+	}
+	return CompressedPayload{
+		Encoding: EncodingGzipBase64,
+		Data:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// PayloadsHandler serves the extension payloads delivered out-of-band, as JSON.
+//
+// Payloads are identified by the query parameters "id" (the request ID) and "key" (the extension key).
+// Without key, all the offloaded payloads of the request are served.
+func PayloadsHandler(store PayloadStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		payloads, ok := store.Get(r.URL.Query().Get("id"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		var body interface{} = payloads
+		if key := r.URL.Query().Get("key"); key != "" {
+			payload, found := payloads[key]
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			body = payload
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+// NewMemoryPayloadStore builds a PayloadStore in memory, keeping payloads for a limited time,
+// and at most maxEntries requests.
+func NewMemoryPayloadStore(ttl time.Duration, maxEntries int) *MemoryPayloadStore {
+	return &MemoryPayloadStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]storedPayloads),
+	}
+}
+
+// Put implements PayloadStore
+func (s *MemoryPayloadStore) Put(id string, payloads map[string]json.RawMessage) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := time.Now()
+	if _, exists := s.entries[id]; !exists {
+		s.order = append(s.order, id)
+	}
+	s.entries[id] = storedPayloads{payloads: payloads, expires: now.Add(s.ttl)}
+
+	// evict expired entries, then the oldest ones above the capacity
+	for len(s.order) > 0 {
+		oldest := s.order[0]
+		entry, ok := s.entries[oldest]
+		if ok && now.Before(entry.expires) && (s.maxEntries <= 0 || len(s.order) <= s.maxEntries) {
+			break
+		}
+		delete(s.entries, oldest)
+		s.order = s.order[1:]
+	}
+}
+
+// Get implements PayloadStore
+func (s *MemoryPayloadStore) Get(id string) (map[string]json.RawMessage, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.payloads, true
+}
+
+func newRequestID(_ context.Context) string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,114 @@
+// Package gqlconfig builds the options of the contrib extensions from a YAML configuration file,
+// possibly overridden by environment variables.
+//
+// This allows deployments to tune instrumentation without recompiling.
+//
+// Example configuration:
+//
+//   tracing:
+//     enabled: true
+//     samplingRate: 0.1
+//     rawQuery: true
+//     rawQueryLimit: 1024
+//     slowQueryThreshold: 2s
+//   metrics:
+//     enabled: true
+//     fields: false
+//     naming:
+//       namespace: acme
+//       constLabels:
+//         team: payments
+//       dropLabels: [gql.path]
+//   playground:
+//     environments: [dev, staging]
+//
+// The naming of metrics is global: apply it with gqlmetrics.SetConfig(cfg.Metrics.Naming), before registering metrics.
+package gqlconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// DefaultEnvPrefix is the default prefix of environment variables overriding the configuration
+const DefaultEnvPrefix = "GQL_"
+
+type (
+	// Config for the contrib extensions
+	Config struct {
+		Tracing    TracingConfig    `yaml:"tracing"`
+		Metrics    MetricsConfig    `yaml:"metrics"`
+		Playground PlaygroundConfig `yaml:"playground"`
+		Coalesce   CoalesceConfig   `yaml:"coalesce"`
+	}
+
+	// TracingConfig configures the opencensus tracer
+	TracingConfig struct {
+		Enabled       bool     `yaml:"enabled"`
+		SamplingRate  *float64 `yaml:"samplingRate"`
+		RawQuery      bool     `yaml:"rawQuery"`
+		RawQueryLimit int      `yaml:"rawQueryLimit"`
+		Variables     bool     `yaml:"variables"`
+		Args          bool     `yaml:"args"`
+		OnlyMethods   *bool    `yaml:"onlyMethods"`
+		DataDog       bool     `yaml:"datadog"`
+
+		// SlowQueryThreshold is a duration such as "2s"
+		SlowQueryThreshold time.Duration `yaml:"slowQueryThreshold"`
+	}
+
+	// MetricsConfig configures the opencensus metrics collector
+	MetricsConfig struct {
+		Enabled bool   `yaml:"enabled"`
+		Host    string `yaml:"host"`
+		Fields  *bool  `yaml:"fields"`
+
+		// Naming of metrics, shared by all metrics packages
+		Naming gqlmetrics.Config `yaml:"naming"`
+	}
+
+	// PlaygroundConfig configures the playground handler
+	PlaygroundConfig struct {
+		Enabled      bool     `yaml:"enabled"`
+		Environments []string `yaml:"environments"`
+		EnvVar       string   `yaml:"envVar"`
+	}
+
+	// CoalesceConfig configures the query coalescing extension
+	CoalesceConfig struct {
+		Enabled bool `yaml:"enabled"`
+	}
+)
+
+// Load the configuration from a YAML file, then apply overrides from environment variables
+// with the default prefix "GQL_".
+//
+// An empty path skips the file and only loads the environment.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if path != "" {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(buf, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(DefaultEnvPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Metrics.Naming.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
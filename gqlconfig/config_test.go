@@ -0,0 +1,96 @@
+package gqlconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+tracing:
+  enabled: true
+  samplingRate: 0.5
+  rawQuery: true
+  rawQueryLimit: 1024
+  slowQueryThreshold: 2s
+metrics:
+  enabled: true
+  fields: false
+  naming:
+    namespace: acme
+    dropLabels: [gql.path]
+playground:
+  environments: [dev, staging]
+`
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "gqlconfig")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	_, err = f.WriteString(testConfig)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, os.Setenv("GQL_TRACING_RAW_QUERY_LIMIT", "256"))
+	require.NoError(t, os.Setenv("GQL_METRICS_HOST", "mypod"))
+	defer func() {
+		_ = os.Unsetenv("GQL_TRACING_RAW_QUERY_LIMIT")
+		_ = os.Unsetenv("GQL_METRICS_HOST")
+	}()
+
+	cfg, err := Load(f.Name())
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Tracing.Enabled)
+	require.NotNil(t, cfg.Tracing.SamplingRate)
+	assert.Equal(t, 0.5, *cfg.Tracing.SamplingRate)
+	assert.Equal(t, 256, cfg.Tracing.RawQueryLimit)
+	assert.Equal(t, 2*time.Second, cfg.Tracing.SlowQueryThreshold)
+	assert.Len(t, cfg.Tracing.Options(), 4)
+
+	assert.True(t, cfg.Metrics.Enabled)
+	assert.Equal(t, "mypod", cfg.Metrics.Host)
+	assert.Len(t, cfg.Metrics.Options(), 2)
+	assert.Equal(t, "acme", cfg.Metrics.Naming.Namespace)
+	assert.Equal(t, []string{"gql.path"}, cfg.Metrics.Naming.DropLabels)
+
+	assert.Equal(t, []string{"dev", "staging"}, cfg.Playground.Environments)
+	assert.Len(t, cfg.Playground.Options(), 1)
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"TEST_TRACING_ONLY_METHODS":         "false",
+		"TEST_PLAYGROUND_ENVIRONMENTS":      "dev, staging,",
+		"TEST_TRACING_SAMPLING_RATE":        "0.1",
+		"TEST_COALESCE_ENABLED":             "true",
+		"TEST_TRACING_RAW_QUERY_LIMIT_XX":   "ignored",
+		"TEST_METRICS_CONST_LABELS":         "team=payments, region = eu",
+		"TEST_TRACING_SLOW_QUERY_THRESHOLD": "500ms",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cfg := &Config{}
+	require.NoError(t, cfg.ApplyEnv("TEST_", lookup))
+
+	require.NotNil(t, cfg.Tracing.OnlyMethods)
+	assert.False(t, *cfg.Tracing.OnlyMethods)
+	assert.Equal(t, []string{"dev", "staging"}, cfg.Playground.Environments)
+	assert.True(t, cfg.Coalesce.Enabled)
+	assert.Equal(t, map[string]string{"team": "payments", "region": "eu"}, cfg.Metrics.Naming.ConstLabels)
+	assert.Equal(t, 500*time.Millisecond, cfg.Tracing.SlowQueryThreshold)
+
+	env["TEST_METRICS_ENABLED"] = "maybe"
+	err := cfg.ApplyEnv("TEST_", lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TEST_METRICS_ENABLED")
+}
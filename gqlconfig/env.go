@@ -0,0 +1,168 @@
+package gqlconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApplyEnv overrides the configuration with environment variables, retrieved with lookup (e.g. os.LookupEnv).
+//
+// Supported variables, with the default prefix "GQL_", are:
+//
+//   GQL_TRACING_ENABLED, GQL_TRACING_SAMPLING_RATE, GQL_TRACING_RAW_QUERY, GQL_TRACING_RAW_QUERY_LIMIT,
+//   GQL_TRACING_VARIABLES, GQL_TRACING_ARGS, GQL_TRACING_ONLY_METHODS, GQL_TRACING_DATADOG,
+//   GQL_TRACING_SLOW_QUERY_THRESHOLD (a duration such as "2s"),
+//   GQL_METRICS_ENABLED, GQL_METRICS_HOST, GQL_METRICS_FIELDS, GQL_METRICS_NAMESPACE,
+//   GQL_METRICS_DROP_LABELS (comma separated), GQL_METRICS_CONST_LABELS (comma separated key=value pairs),
+//   GQL_PLAYGROUND_ENABLED, GQL_PLAYGROUND_ENVIRONMENTS (comma separated), GQL_PLAYGROUND_ENV_VAR,
+//   GQL_COALESCE_ENABLED
+func (c *Config) ApplyEnv(prefix string, lookup func(string) (string, bool)) error {
+	e := envLoader{prefix: prefix, lookup: lookup}
+
+	e.setBool("TRACING_ENABLED", &c.Tracing.Enabled)
+	e.setFloatPtr("TRACING_SAMPLING_RATE", &c.Tracing.SamplingRate)
+	e.setBool("TRACING_RAW_QUERY", &c.Tracing.RawQuery)
+	e.setInt("TRACING_RAW_QUERY_LIMIT", &c.Tracing.RawQueryLimit)
+	e.setBool("TRACING_VARIABLES", &c.Tracing.Variables)
+	e.setBool("TRACING_ARGS", &c.Tracing.Args)
+	e.setBoolPtr("TRACING_ONLY_METHODS", &c.Tracing.OnlyMethods)
+	e.setBool("TRACING_DATADOG", &c.Tracing.DataDog)
+	e.setDuration("TRACING_SLOW_QUERY_THRESHOLD", &c.Tracing.SlowQueryThreshold)
+
+	e.setBool("METRICS_ENABLED", &c.Metrics.Enabled)
+	e.setString("METRICS_HOST", &c.Metrics.Host)
+	e.setBoolPtr("METRICS_FIELDS", &c.Metrics.Fields)
+	e.setString("METRICS_NAMESPACE", &c.Metrics.Naming.Namespace)
+	e.setStrings("METRICS_DROP_LABELS", &c.Metrics.Naming.DropLabels)
+	e.setMap("METRICS_CONST_LABELS", &c.Metrics.Naming.ConstLabels)
+
+	e.setBool("PLAYGROUND_ENABLED", &c.Playground.Enabled)
+	e.setStrings("PLAYGROUND_ENVIRONMENTS", &c.Playground.Environments)
+	e.setString("PLAYGROUND_ENV_VAR", &c.Playground.EnvVar)
+
+	e.setBool("COALESCE_ENABLED", &c.Coalesce.Enabled)
+
+	return e.err
+}
+
+// envLoader retrieves typed values from the environment and retains the first error
+type envLoader struct {
+	prefix string
+	lookup func(string) (string, bool)
+	err    error
+}
+
+func (e *envLoader) get(key string) (string, bool) {
+	if e.err != nil {
+		return "", false
+	}
+	return e.lookup(e.prefix + key)
+}
+
+func (e *envLoader) fail(key string, err error) {
+	e.err = fmt.Errorf("invalid value for environment variable %s%s: %v", e.prefix, key, err)
+}
+
+func (e *envLoader) setString(key string, target *string) {
+	if value, ok := e.get(key); ok {
+		*target = value
+	}
+}
+
+func (e *envLoader) setStrings(key string, target *[]string) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+	values := strings.Split(value, ",")
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	*target = result
+}
+
+func (e *envLoader) setMap(key string, target *map[string]string) {
+	var pairs []string
+	e.setStrings(key, &pairs)
+	if pairs == nil {
+		return
+	}
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			e.fail(key, fmt.Errorf("expected key=value, got %q", pair))
+			return
+		}
+		result[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+	}
+	*target = result
+}
+
+func (e *envLoader) setBool(key string, target *bool) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*target = b
+}
+
+func (e *envLoader) setBoolPtr(key string, target **bool) {
+	var b bool
+	if _, ok := e.get(key); !ok {
+		return
+	}
+	e.setBool(key, &b)
+	if e.err == nil {
+		*target = &b
+	}
+}
+
+func (e *envLoader) setInt(key string, target *int) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*target = i
+}
+
+func (e *envLoader) setFloatPtr(key string, target **float64) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*target = &f
+}
+
+func (e *envLoader) setDuration(key string, target *time.Duration) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*target = d
+}
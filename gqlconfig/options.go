@@ -0,0 +1,64 @@
+package gqlconfig
+
+import (
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlplayground"
+)
+
+// Options builds the options of the opencensus tracer
+func (c TracingConfig) Options() []gqlopencensus.Option {
+	opts := make([]gqlopencensus.Option, 0, 8)
+	if c.RawQuery {
+		opts = append(opts, gqlopencensus.WithRawQuery())
+	}
+	if c.RawQueryLimit > 0 {
+		opts = append(opts, gqlopencensus.WithRawQueryLimit(c.RawQueryLimit))
+	}
+	if c.Variables {
+		opts = append(opts, gqlopencensus.WithVariables())
+	}
+	if c.Args {
+		opts = append(opts, gqlopencensus.WithArgs())
+	}
+	if c.OnlyMethods != nil {
+		opts = append(opts, gqlopencensus.OnlyMethods(*c.OnlyMethods))
+	}
+	if c.DataDog {
+		opts = append(opts, gqlopencensus.WithDataDog())
+	}
+	if c.SamplingRate != nil {
+		opts = append(opts, gqlopencensus.WithSamplingRate(*c.SamplingRate))
+	}
+	if c.SlowQueryThreshold > 0 {
+		opts = append(opts, gqlopencensus.WithSlowQueryThreshold(c.SlowQueryThreshold))
+	}
+	return opts
+}
+
+// Options builds the options of the opencensus metrics collector
+func (c MetricsConfig) Options() []metrics.Option {
+	opts := make([]metrics.Option, 0, 2)
+	if c.Host != "" {
+		opts = append(opts, metrics.Host(c.Host))
+	}
+	if c.Fields != nil {
+		opts = append(opts, metrics.FieldsEnabled(*c.Fields))
+	}
+	return opts
+}
+
+// Options builds the options of the playground handler
+func (c PlaygroundConfig) Options() []gqlplayground.Option {
+	opts := make([]gqlplayground.Option, 0, 3)
+	if c.Enabled {
+		opts = append(opts, gqlplayground.Enabled(true))
+	}
+	if len(c.Environments) > 0 {
+		opts = append(opts, gqlplayground.WithEnvironments(c.Environments...))
+	}
+	if c.EnvVar != "" {
+		opts = append(opts, gqlplayground.WithEnvVar(c.EnvVar))
+	}
+	return opts
+}
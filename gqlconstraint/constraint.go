@@ -0,0 +1,237 @@
+// Package gqlconstraint provides a gqlgen extension enforcing a @constraint directive on arguments and input fields.
+//
+// Values are validated before resolvers run. Violations are reported as a single error with the code BAD_USER_INPUT,
+// detailing each violation in its extensions:
+//
+//   {
+//     "message": "invalid input",
+//     "path": ["createUser"],
+//     "extensions": {
+//       "code": "BAD_USER_INPUT",
+//       "violations": [{"field": "input.email", "constraint": "format", "message": "must be a valid email"}]
+//     }
+//   }
+//
+// The directive must be declared in the schema:
+//
+//   directive @constraint(min: Float, max: Float, pattern: String, format: String) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+//
+// For numbers, min and max bound the value. For strings, min and max bound the length (in characters).
+// For lists, constraints apply to each element. Supported formats are: email, uuid, uri, date, date-time, ip.
+//
+// Since the extension enforces the directive, the directive generated by gqlgen should be implemented as a noop:
+//
+//   cfg.Directives.Constraint = func(ctx context.Context, obj interface{}, next graphql.Resolver,
+//     min, max *float64, pattern, format *string) (interface{}, error) {
+//     return next(ctx)
+//   }
+package gqlconstraint
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const extensionName = "Constraint"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Constraint{}
+
+type (
+	// Violation of a constraint by an argument or an input field
+	Violation struct {
+		Field      string `json:"field"`
+		Constraint string `json:"constraint"`
+		Message    string `json:"message"`
+	}
+
+	// Constraint is a gqlgen extension enforcing the @constraint directive
+	Constraint struct {
+		*config
+		schema *ast.Schema
+
+		// constrained fields, as "Object.field"
+		fields map[string]struct{}
+
+		// rules compiled from the directives found in the schema
+		rules map[*ast.Directive]*rule
+	}
+
+	rule struct {
+		min     *float64
+		max     *float64
+		pattern *regexp.Regexp
+		format  string
+	}
+)
+
+// New constraint extension
+func New(opts ...Option) *Constraint {
+	c := &Constraint{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "Constraint"
+func (*Constraint) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the directives declared in the schema, and prepare their rules
+func (c *Constraint) Validate(schema graphql.ExecutableSchema) error {
+	return c.prepare(schema.Schema())
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (c *Constraint) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+	if _, ok := c.fields[fc.Object+"."+fc.Field.Name]; !ok {
+		return next(ctx)
+	}
+
+	variables := graphql.GetOperationContext(ctx).Variables
+
+	var violations []Violation
+	for _, argDef := range fc.Field.Definition.Arguments {
+		arg := fc.Field.Arguments.ForName(argDef.Name)
+		if arg == nil || arg.Value == nil {
+			continue
+		}
+		value, err := arg.Value.Value(variables)
+		if err != nil {
+			continue
+		}
+		violations = c.check(violations, argDef.Name, argDef.Directives, argDef.Type, value)
+	}
+
+	if len(violations) == 0 {
+		return next(ctx)
+	}
+
+	return nil, &gqlerror.Error{
+		Message: c.config.message,
+		Path:    fc.Path(),
+		Extensions: map[string]interface{}{
+			"code":       "BAD_USER_INPUT",
+			"violations": violations,
+		},
+	}
+}
+
+// check a value against the constraint of its definition, then recursively against the constraints of input fields
+func (c *Constraint) check(violations []Violation, path string, directives ast.DirectiveList, typ *ast.Type, value interface{}) []Violation {
+	if value == nil {
+		return violations
+	}
+
+	if list, ok := value.([]interface{}); ok && typ.Elem != nil {
+		for i, elem := range list {
+			violations = c.check(violations, fmt.Sprintf("%s[%d]", path, i), directives, typ.Elem, elem)
+		}
+		return violations
+	}
+
+	if d := directives.ForName(c.config.directive); d != nil {
+		if r, ok := c.rules[d]; ok {
+			violations = r.check(violations, path, value)
+		}
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return violations
+	}
+	def := c.schema.Types[typ.Name()]
+	if def == nil || def.Kind != ast.InputObject {
+		return violations
+	}
+	for _, field := range def.Fields {
+		violations = c.check(violations, path+"."+field.Name, field.Directives, field.Type, object[field.Name])
+	}
+	return violations
+}
+
+// prepare compiles the rules found in the schema, and indexes the fields with constrained arguments
+func (c *Constraint) prepare(schema *ast.Schema) error {
+	c.schema = schema
+	c.fields = make(map[string]struct{})
+	c.rules = make(map[*ast.Directive]*rule)
+
+	constrained := make(map[string]bool)
+	for _, def := range schema.Types {
+		if def.Kind != ast.InputObject {
+			continue
+		}
+		for _, field := range def.Fields {
+			if err := c.compile(def.Name+"."+field.Name, field.Directives); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, def := range schema.Types {
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+		for _, field := range def.Fields {
+			for _, arg := range field.Arguments {
+				if err := c.compile(def.Name+"."+field.Name+"("+arg.Name+")", arg.Directives); err != nil {
+					return err
+				}
+				if arg.Directives.ForName(c.config.directive) != nil || c.isConstrained(arg.Type.Name(), constrained, make(map[string]bool)) {
+					c.fields[def.Name+"."+field.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isConstrained tells if an input type has constrained fields, at any depth
+func (c *Constraint) isConstrained(typeName string, constrained, visiting map[string]bool) bool {
+	if result, ok := constrained[typeName]; ok {
+		return result
+	}
+	def := c.schema.Types[typeName]
+	if def == nil || def.Kind != ast.InputObject || visiting[typeName] {
+		return false
+	}
+	visiting[typeName] = true
+
+	result := false
+	for _, field := range def.Fields {
+		if field.Directives.ForName(c.config.directive) != nil || c.isConstrained(field.Type.Name(), constrained, visiting) {
+			result = true
+			break
+		}
+	}
+	constrained[typeName] = result
+	return result
+}
+
+func (c *Constraint) compile(location string, directives ast.DirectiveList) error {
+	d := directives.ForName(c.config.directive)
+	if d == nil {
+		return nil
+	}
+	r, err := newRule(d)
+	if err != nil {
+		return fmt.Errorf("invalid @%s on %s: %v", c.config.directive, location, err)
+	}
+	c.rules[d] = r
+	return nil
+}
@@ -0,0 +1,100 @@
+package gqlconstraint
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const testSchema = `
+directive @constraint(min: Float, max: Float, pattern: String, format: String) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+
+input UserInput {
+  email: String! @constraint(format: "email")
+  name: String @constraint(min: 2, max: 5)
+  tags: [String!] @constraint(pattern: "^[a-z]+$")
+}
+
+type User { id: ID! }
+
+type Query {
+  users(first: Int @constraint(min: 1, max: 100)): [User!]!
+}
+
+type Mutation {
+  createUser(input: UserInput!): User!
+}
+`
+
+func TestConstraint(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	c := New()
+	require.Equal(t, extensionName, c.ExtensionName())
+	require.NoError(t, c.prepare(schema))
+
+	resolve := func(object, field string, arguments ast.ArgumentList, variables map[string]interface{}) (interface{}, error) {
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{Variables: variables})
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object: object,
+			Field: graphql.CollectedField{Field: &ast.Field{
+				Name:       field,
+				Alias:      field,
+				Arguments:  arguments,
+				Definition: schema.Types[object].Fields.ForName(field),
+			}},
+		})
+		return c.InterceptField(ctx, func(_ context.Context) (interface{}, error) {
+			return "ok", nil
+		})
+	}
+	variable := func(name string) *ast.Value {
+		return &ast.Value{Kind: ast.Variable, Raw: name}
+	}
+	violations := func(err error) []Violation {
+		gqlErr, ok := err.(*gqlerror.Error)
+		require.True(t, ok)
+		assert.Equal(t, "BAD_USER_INPUT", gqlErr.Extensions["code"])
+		return gqlErr.Extensions["violations"].([]Violation)
+	}
+
+	res, err := resolve("Query", "users", ast.ArgumentList{{Name: "first", Value: variable("first")}},
+		map[string]interface{}{"first": json.Number("10")})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res)
+
+	_, err = resolve("Query", "users", ast.ArgumentList{{Name: "first", Value: variable("first")}},
+		map[string]interface{}{"first": json.Number("1000")})
+	require.Error(t, err)
+	assert.Equal(t, []Violation{{Field: "first", Constraint: "max", Message: "must be less than or equal to 100"}}, violations(err))
+
+	_, err = resolve("Mutation", "createUser", ast.ArgumentList{{Name: "input", Value: variable("input")}},
+		map[string]interface{}{"input": map[string]interface{}{
+			"email": "not an email",
+			"name":  "abcdef",
+			"tags":  []interface{}{"ok", "NOT"},
+		}})
+	require.Error(t, err)
+	assert.ElementsMatch(t, []string{"input.email", "input.name", "input.tags[1]"}, func() []string {
+		var fields []string
+		for _, v := range violations(err) {
+			fields = append(fields, v.Field)
+		}
+		return fields
+	}())
+
+	invalid, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: `
+directive @constraint(min: Float, max: Float, pattern: String, format: String) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+type Query { users(first: Int @constraint(format: "unknown")): Int }
+`})
+	require.Nil(t, gqlErr)
+	require.Error(t, New().prepare(invalid))
+}
@@ -0,0 +1,32 @@
+package gqlconstraint
+
+type (
+	// Option for the constraint extension
+	Option func(*config)
+
+	config struct {
+		directive string
+		message   string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		directive: "constraint",
+		message:   "invalid input",
+	}
+}
+
+// WithDirective sets the name of the constraint directive. The default is "constraint".
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
+
+// WithMessage sets the message of errors reporting violations. The default is "invalid input".
+func WithMessage(message string) Option {
+	return func(c *config) {
+		c.message = message
+	}
+}
@@ -0,0 +1,160 @@
+package gqlconstraint
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// formats validate strings
+var formats = map[string]func(string) bool{
+	"email": func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	"uuid": uuidPattern.MatchString,
+	"uri": func(s string) bool {
+		u, err := url.ParseRequestURI(s)
+		return err == nil && u.Scheme != ""
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"ip": func(s string) bool {
+		return net.ParseIP(s) != nil
+	},
+}
+
+func newRule(d *ast.Directive) (*rule, error) {
+	r := new(rule)
+	var err error
+
+	if r.min, err = floatArgument(d, "min"); err != nil {
+		return nil, err
+	}
+	if r.max, err = floatArgument(d, "max"); err != nil {
+		return nil, err
+	}
+	if r.min != nil && r.max != nil && *r.min > *r.max {
+		return nil, fmt.Errorf("min %v is greater than max %v", *r.min, *r.max)
+	}
+
+	pattern, err := stringArgument(d, "pattern")
+	if err != nil {
+		return nil, err
+	}
+	if pattern != "" {
+		if r.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.format, err = stringArgument(d, "format"); err != nil {
+		return nil, err
+	}
+	if _, ok := formats[r.format]; r.format != "" && !ok {
+		return nil, fmt.Errorf("unsupported format %q", r.format)
+	}
+
+	return r, nil
+}
+
+func (r *rule) check(violations []Violation, path string, value interface{}) []Violation {
+	violation := func(constraint, message string) {
+		violations = append(violations, Violation{Field: path, Constraint: constraint, Message: message})
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if r.min != nil && length < *r.min {
+			violation("min", fmt.Sprintf("must be at least %v characters long", *r.min))
+		}
+		if r.max != nil && length > *r.max {
+			violation("max", fmt.Sprintf("must be at most %v characters long", *r.max))
+		}
+		if r.pattern != nil && !r.pattern.MatchString(v) {
+			violation("pattern", fmt.Sprintf("must match %q", r.pattern.String()))
+		}
+		if r.format != "" && !formats[r.format](v) {
+			violation("format", "must be a valid "+r.format)
+		}
+	default:
+		number, ok := toFloat(v)
+		if !ok {
+			return violations
+		}
+		if r.min != nil && number < *r.min {
+			violation("min", fmt.Sprintf("must be greater than or equal to %v", *r.min))
+		}
+		if r.max != nil && number > *r.max {
+			violation("max", fmt.Sprintf("must be less than or equal to %v", *r.max))
+		}
+	}
+	return violations
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		// numbers passed as variables are decoded as json.Number
+		if n, ok := v.(interface{ Float64() (float64, error) }); ok {
+			f, err := n.Float64()
+			return f, err == nil
+		}
+		return 0, false
+	}
+}
+
+func floatArgument(d *ast.Directive, name string) (*float64, error) {
+	arg := d.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return nil, nil
+	}
+	value, err := arg.Value.Value(nil)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	f, ok := toFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+	return &f, nil
+}
+
+func stringArgument(d *ast.Directive, name string) (string, error) {
+	arg := d.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return "", nil
+	}
+	value, err := arg.Value.Value(nil)
+	if err != nil || value == nil {
+		return "", err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	return s, nil
+}
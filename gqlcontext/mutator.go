@@ -0,0 +1,58 @@
+// Package gqlcontext provides a gqlgen extension to attach derived values to the context of an operation,
+// before any resolver runs.
+//
+// Values attached this way are visible to all resolvers, and to the response and field interceptors
+// of other extensions, such as the contrib tracing and metrics extensions.
+package gqlcontext
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "OperationContextMutator"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Mutator{}
+
+type (
+	// OperationContextMutator derives a new context from the context of an operation,
+	// e.g. to attach parsed client information or feature flags.
+	OperationContextMutator func(context.Context, *graphql.OperationContext) context.Context
+
+	// Mutator is a gqlgen extension which applies operation context mutators to all operations.
+	Mutator struct {
+		*config
+	}
+)
+
+// New Mutator
+func New(opts ...Option) *Mutator {
+	m := &Mutator{config: &config{}}
+	for _, apply := range opts {
+		apply(m.config)
+	}
+	return m
+}
+
+// ExtensionName yields the extension name: "OperationContextMutator"
+func (Mutator) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (Mutator) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor
+func (m Mutator) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	for _, mutate := range m.config.mutators {
+		ctx = mutate(ctx, oc)
+	}
+	return next(ctx)
+}
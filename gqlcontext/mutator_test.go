@@ -0,0 +1,39 @@
+package gqlcontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey string
+
+func TestMutator(t *testing.T) {
+	m := New(
+		WithOperationContextMutator(func(ctx context.Context, oc *graphql.OperationContext) context.Context {
+			return context.WithValue(ctx, ctxKey("client"), oc.OperationName)
+		}),
+		WithOperationContextMutator(func(ctx context.Context, _ *graphql.OperationContext) context.Context {
+			return context.WithValue(ctx, ctxKey("derived"), ctx.Value(ctxKey("client")).(string)+"-derived")
+		}),
+	)
+	require.Equal(t, extensionName, m.ExtensionName())
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "test",
+	})
+
+	var derived interface{}
+	next := func(ctx context.Context) graphql.ResponseHandler {
+		derived = ctx.Value(ctxKey("derived"))
+		return func(_ context.Context) *graphql.Response {
+			return &graphql.Response{}
+		}
+	}
+
+	handler := m.InterceptOperation(ctx, next)
+	require.NotNil(t, handler)
+	require.Equal(t, "test-derived", derived)
+}
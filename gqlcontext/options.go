@@ -0,0 +1,19 @@
+package gqlcontext
+
+type (
+	// Option for the operation context mutator extension
+	Option func(*config)
+
+	config struct {
+		mutators []OperationContextMutator
+	}
+)
+
+// WithOperationContextMutator adds a mutator to derive the context of operations.
+//
+// Mutators are applied in the order they are added, each one receiving the context produced by the previous one.
+func WithOperationContextMutator(mutators ...OperationContextMutator) Option {
+	return func(c *config) {
+		c.mutators = append(c.mutators, mutators...)
+	}
+}
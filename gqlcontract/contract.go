@@ -0,0 +1,422 @@
+// Package gqlcontract checks the operations of clients against the current schema, so that breaking changes are
+// caught in CI or at server startup rather than by clients.
+//
+// Client operations are loaded from GraphQL documents (.graphql, .gql), e.g. collected from client repositories.
+// Fragments may be shared across documents. Each operation is validated against the schema: unknown fields,
+// arguments and types, and type mismatches are breaking. Usages of deprecated fields and enum values are
+// reported as warnings.
+//
+// Example, failing the startup of the server on breakage:
+//
+//   srv.Use(gqlcontract.New([]string{"contracts/"},
+//     gqlcontract.WithHandler(func(issue gqlcontract.Issue) { log.Println("client contract:", issue) }),
+//   ))
+//
+// gqlgen validates extensions as they are registered: when a contract is broken, srv.Use panics.
+//
+// The gqlcontract command runs the same checks in CI.
+package gqlcontract
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+
+	// register the standard validation rules
+	_ "github.com/vektah/gqlparser/v2/validator/rules"
+)
+
+const extensionName = "ClientContract"
+
+var _ interface {
+	graphql.HandlerExtension
+} = &Contract{}
+
+// Kinds of issues
+const (
+	KindSyntax        = "syntax"
+	KindUnknownField  = "unknown-field"
+	KindUnknownType   = "unknown-type"
+	KindArgument      = "argument"
+	KindTypeMismatch  = "type-mismatch"
+	KindInvalid       = "invalid"
+	KindDeprecatedUse = "deprecated"
+)
+
+// Severity of an issue
+type Severity string
+
+// Severities
+const (
+	// Error is a breaking issue: the operation fails against the schema
+	Error Severity = "error"
+
+	// Warning is a non-breaking issue, e.g. the usage of a deprecated field
+	Warning Severity = "warning"
+)
+
+type (
+	// Issue found in a client operation
+	Issue struct {
+		File      string   `json:"file"`
+		Operation string   `json:"operation,omitempty"`
+		Kind      string   `json:"kind"`
+		Severity  Severity `json:"severity"`
+		Message   string   `json:"message"`
+		Line      int      `json:"line,omitempty"`
+		Column    int      `json:"column,omitempty"`
+	}
+
+	// Report of a check of client operations
+	Report struct {
+		// Operations is the number of checked operations
+		Operations int `json:"operations"`
+
+		Issues []Issue `json:"issues"`
+	}
+
+	// Contract checks client operations against a schema.
+	//
+	// As a gqlgen extension, it checks the executable schema at server startup.
+	Contract struct {
+		*config
+		paths []string
+	}
+
+	// document loaded from a file
+	document struct {
+		file string
+		doc  *ast.QueryDocument
+		err  *gqlerror.Error
+	}
+)
+
+// String representation of an issue, e.g. "app/todos.graphql:3:5: GetTodos: error unknown-field: ..."
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location += fmt.Sprintf(":%d:%d", i.Line, i.Column)
+	}
+	if i.Operation != "" {
+		location += ": " + i.Operation
+	}
+	return fmt.Sprintf("%s: %s %s: %s", location, i.Severity, i.Kind, i.Message)
+}
+
+// Breaking tells if the report has any error
+func (r *Report) Breaking() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Error reports the breaking issues
+func (r *Report) Error() string {
+	var errors []string
+	for _, issue := range r.Issues {
+		if issue.Severity == Error {
+			errors = append(errors, issue.String())
+		}
+	}
+	return fmt.Sprintf("%d breaking issue(s) in client operations:\n%s", len(errors), strings.Join(errors, "\n"))
+}
+
+// New Contract for the client operations found at some paths. Directories are walked recursively.
+func New(paths []string, opts ...Option) *Contract {
+	c := &Contract{
+		config: defaultConfig(),
+		paths:  paths,
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "ClientContract"
+func (*Contract) ExtensionName() string {
+	return extensionName
+}
+
+// Validate checks client operations against the executable schema. Issues are reported to the handlers
+// (see WithHandler), then the report is returned as an error when it is breaking, unless WithWarnings is enabled.
+func (c *Contract) Validate(schema graphql.ExecutableSchema) error {
+	report, err := c.Check(schema.Schema())
+	if err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		for _, handle := range c.config.handlers {
+			handle(issue)
+		}
+	}
+	if !report.Breaking() || c.config.warnings {
+		return nil
+	}
+	return report
+}
+
+// Check client operations against a schema. An error is returned when documents cannot be read.
+func (c *Contract) Check(schema *ast.Schema) (*Report, error) {
+	documents, err := load(c.paths)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, d := range documents {
+		if d.err != nil {
+			continue
+		}
+		for _, fragment := range d.doc.Fragments {
+			fragments[fragment.Name] = fragment
+		}
+	}
+
+	for _, d := range documents {
+		if d.err != nil {
+			continue
+		}
+		for _, op := range d.doc.Operations {
+			report.Operations++
+			report.Issues = append(report.Issues, c.checkOperation(schema, d.file, op, fragments)...)
+		}
+	}
+	for _, d := range documents {
+		if d.err != nil {
+			report.Issues = append(report.Issues, syntaxIssue(d.file, d.err))
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+func (c *Contract) checkOperation(schema *ast.Schema, file string, op *ast.OperationDefinition, fragments map[string]*ast.FragmentDefinition) []Issue {
+	doc := &ast.QueryDocument{
+		Operations: ast.OperationList{op},
+		Fragments:  usedFragments(op.SelectionSet, fragments),
+	}
+
+	var issues []Issue
+	if errs := validator.Validate(schema, doc); len(errs) > 0 {
+		for _, err := range errs {
+			issue := Issue{
+				File:      file,
+				Operation: op.Name,
+				Kind:      kindOf(err.Rule),
+				Severity:  Error,
+				Message:   err.Message,
+			}
+			if len(err.Locations) > 0 {
+				issue.Line, issue.Column = err.Locations[0].Line, err.Locations[0].Column
+			}
+			issues = append(issues, issue)
+		}
+		return issues
+	}
+
+	severity := Warning
+	if c.config.deprecationsAsErrors {
+		severity = Error
+	}
+	for _, usage := range deprecations(op.SelectionSet, make(map[string]bool)) {
+		issues = append(issues, Issue{
+			File:      file,
+			Operation: op.Name,
+			Kind:      KindDeprecatedUse,
+			Severity:  severity,
+			Message:   usage.message,
+			Line:      usage.line,
+			Column:    usage.column,
+		})
+	}
+	return issues
+}
+
+// kindOf maps the validation rules of gqlparser to kinds of issues
+func kindOf(rule string) string {
+	switch rule {
+	case "FieldsOnCorrectType":
+		return KindUnknownField
+	case "KnownTypeNames", "PossibleFragmentSpreads", "FragmentsOnCompositeTypes":
+		return KindUnknownType
+	case "KnownArgumentNames", "ProvidedRequiredArguments", "UniqueArgumentNames":
+		return KindArgument
+	case "ValuesOfCorrectType", "VariablesInAllowedPosition", "VariablesAreInputTypes", "ScalarLeafs":
+		return KindTypeMismatch
+	default:
+		return KindInvalid
+	}
+}
+
+func syntaxIssue(file string, err *gqlerror.Error) Issue {
+	issue := Issue{
+		File:     file,
+		Kind:     KindSyntax,
+		Severity: Error,
+		Message:  err.Message,
+	}
+	if len(err.Locations) > 0 {
+		issue.Line, issue.Column = err.Locations[0].Line, err.Locations[0].Column
+	}
+	return issue
+}
+
+// usedFragments collects the fragments spread by a selection set, transitively
+func usedFragments(selections ast.SelectionSet, fragments map[string]*ast.FragmentDefinition) ast.FragmentDefinitionList {
+	var used ast.FragmentDefinitionList
+	seen := make(map[string]bool)
+
+	var walk func(ast.SelectionSet)
+	walk = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				walk(sel.SelectionSet)
+			case *ast.InlineFragment:
+				walk(sel.SelectionSet)
+			case *ast.FragmentSpread:
+				fragment, ok := fragments[sel.Name]
+				if !ok || seen[sel.Name] {
+					// unknown fragments are reported by validation
+					continue
+				}
+				seen[sel.Name] = true
+				used = append(used, fragment)
+				walk(fragment.SelectionSet)
+			}
+		}
+	}
+	walk(selections)
+	return used
+}
+
+// usage of a deprecated field or enum value
+type usage struct {
+	message      string
+	line, column int
+}
+
+// deprecations finds the usages of deprecated fields and enum values in a validated selection set
+func deprecations(selections ast.SelectionSet, visiting map[string]bool) []usage {
+	var usages []usage
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if sel.Definition != nil {
+				if reason, ok := deprecated(sel.Definition.Directives); ok {
+					usages = append(usages, usageAt(sel.Position,
+						fmt.Sprintf("field %s.%s is deprecated: %s", sel.ObjectDefinition.Name, sel.Name, reason)))
+				}
+			}
+			for _, arg := range sel.Arguments {
+				usages = append(usages, enumDeprecations(arg.Value)...)
+			}
+			usages = append(usages, deprecations(sel.SelectionSet, visiting)...)
+
+		case *ast.InlineFragment:
+			usages = append(usages, deprecations(sel.SelectionSet, visiting)...)
+
+		case *ast.FragmentSpread:
+			if sel.Definition == nil || visiting[sel.Name] {
+				continue
+			}
+			visiting[sel.Name] = true
+			usages = append(usages, deprecations(sel.Definition.SelectionSet, visiting)...)
+			delete(visiting, sel.Name)
+		}
+	}
+	return usages
+}
+
+// enumDeprecations finds the deprecated enum values in a literal value
+func enumDeprecations(value *ast.Value) []usage {
+	if value == nil {
+		return nil
+	}
+	var usages []usage
+	if value.Kind == ast.EnumValue && value.Definition != nil {
+		if enum := value.Definition.EnumValues.ForName(value.Raw); enum != nil {
+			if reason, ok := deprecated(enum.Directives); ok {
+				usages = append(usages, usageAt(value.Position,
+					fmt.Sprintf("enum value %s.%s is deprecated: %s", value.Definition.Name, value.Raw, reason)))
+			}
+		}
+	}
+	for _, child := range value.Children {
+		usages = append(usages, enumDeprecations(child.Value)...)
+	}
+	return usages
+}
+
+func deprecated(directives ast.DirectiveList) (string, bool) {
+	directive := directives.ForName("deprecated")
+	if directive == nil {
+		return "", false
+	}
+	reason := "No longer supported"
+	if arg := directive.Arguments.ForName("reason"); arg != nil && arg.Value != nil {
+		reason = arg.Value.Raw
+	}
+	return reason, true
+}
+
+func usageAt(pos *ast.Position, message string) usage {
+	u := usage{message: message}
+	if pos != nil {
+		u.line, u.column = pos.Line, pos.Column
+	}
+	return u
+}
+
+// load the GraphQL documents found at some paths. Documents with a syntax error are returned with this error.
+func load(paths []string) ([]document, error) {
+	var documents []document
+	for _, path := range paths {
+		err := filepath.Walk(path, func(pth string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(pth)) {
+			case ".graphql", ".gql":
+			default:
+				return nil
+			}
+
+			buf, err := ioutil.ReadFile(pth)
+			if err != nil {
+				return err
+			}
+			doc, gqlErr := parser.ParseQuery(&ast.Source{Name: pth, Input: string(buf)})
+			documents = append(documents, document{file: pth, doc: doc, err: gqlErr})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
@@ -0,0 +1,92 @@
+package gqlcontract
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var schema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	enum Status { OPEN, DONE, ARCHIVED @deprecated(reason: "use DONE") }
+	type Query { todos(status: Status): [Todo!]! }
+	type Todo {
+		id: ID!
+		text: String!
+		title: String! @deprecated(reason: "use text")
+	}
+`})
+
+func writeDocuments(t *testing.T, documents map[string]string) string {
+	dir, err := ioutil.TempDir("", "contracts")
+	require.NoError(t, err)
+	for name, content := range documents {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestCheck(t *testing.T) {
+	dir := writeDocuments(t, map[string]string{
+		"fragments.graphql": `fragment TodoFields on Todo { id text }`,
+		"ok.graphql":        `query GetTodos { todos { ...TodoFields } }`,
+		"deprecated.gql":    `query Archived { todos(status: ARCHIVED) { title } }`,
+		"broken.graphql":    `query Broken { todos { id owner } }`,
+		"mismatch.graphql":  `query Mismatch($status: String) { todos(status: $status) { id } }`,
+		"syntax.graphql":    `query {`,
+		"README.md":         `not a document`,
+	})
+	defer os.RemoveAll(dir)
+
+	report, err := New([]string{dir}).Check(schema)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Operations)
+	assert.True(t, report.Breaking())
+
+	kinds := make(map[string][]Issue)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind] = append(kinds[issue.Kind], issue)
+	}
+	require.Len(t, kinds[KindUnknownField], 1)
+	assert.Equal(t, "Broken", kinds[KindUnknownField][0].Operation)
+	require.Len(t, kinds[KindTypeMismatch], 1)
+	assert.Equal(t, "Mismatch", kinds[KindTypeMismatch][0].Operation)
+	require.Len(t, kinds[KindSyntax], 1)
+	require.Len(t, kinds[KindDeprecatedUse], 2)
+	for _, issue := range kinds[KindDeprecatedUse] {
+		assert.Equal(t, Warning, issue.Severity)
+		assert.Equal(t, "Archived", issue.Operation)
+	}
+
+	report, err = New([]string{filepath.Join(dir, "deprecated.gql")}, WithDeprecationsAsErrors(true)).Check(schema)
+	require.NoError(t, err)
+	assert.True(t, report.Breaking())
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeDocuments(t, map[string]string{
+		"broken.graphql": `query Broken { todos { owner } }`,
+	})
+	defer os.RemoveAll(dir)
+
+	es := &graphql.ExecutableSchemaMock{SchemaFunc: func() *ast.Schema { return schema }}
+
+	var handled []Issue
+	contract := New([]string{dir}, WithHandler(func(issue Issue) { handled = append(handled, issue) }))
+	assert.Equal(t, extensionName, contract.ExtensionName())
+	err := contract.Validate(es)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Broken")
+	assert.Len(t, handled, 1)
+
+	assert.NoError(t, New([]string{dir}, WithWarnings()).Validate(es))
+
+	_, err = New([]string{filepath.Join(dir, "missing")}).Check(schema)
+	assert.Error(t, err)
+}
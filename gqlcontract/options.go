@@ -0,0 +1,38 @@
+package gqlcontract
+
+type (
+	// Option for the contract
+	Option func(*config)
+
+	config struct {
+		deprecationsAsErrors bool
+		warnings             bool
+		handlers             []func(Issue)
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithDeprecationsAsErrors reports the usages of deprecated fields and enum values as breaking issues.
+// By default, these are warnings.
+func WithDeprecationsAsErrors(enabled bool) Option {
+	return func(c *config) {
+		c.deprecationsAsErrors = enabled
+	}
+}
+
+// WithWarnings only reports breaking issues to the handlers, without failing the startup of the server.
+func WithWarnings() Option {
+	return func(c *config) {
+		c.warnings = true
+	}
+}
+
+// WithHandler adds handlers of the issues found at server startup, e.g. to log them
+func WithHandler(handlers ...func(Issue)) Option {
+	return func(c *config) {
+		c.handlers = append(c.handlers, handlers...)
+	}
+}
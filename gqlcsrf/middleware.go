@@ -0,0 +1,175 @@
+// Package gqlcsrf provides a HTTP middleware protecting a GraphQL endpoint against cross-site request forgery (CSRF).
+//
+// Browser-based GraphQL endpoints relying on cookies for authentication are a CSRF target: a malicious page may
+// submit a mutation with a simple (non-preflighted) request, which carries the cookies of the user.
+//
+// The middleware enforces an allowlist of origins, checked against the Origin or Referer headers, and optionally
+// requires the presence of a custom header, which browsers cannot set without a CORS preflight.
+//
+// Checks apply to all requests which may carry a mutation, i.e. any request but GET, HEAD and OPTIONS
+// (the gqlgen GET transport rejects mutations). The origin of websocket upgrades is checked as well, against
+// cross-site websocket hijacking: browsers do not apply the same-origin policy to websockets. Browsers cannot set
+// custom headers on websockets, so that required headers are not checked on upgrades.
+//
+// A request is from the same origin when its origin has the scheme and host of the request. The scheme of the
+// request is "https" for TLS connections, or taken from the X-Forwarded-Proto header set by a TLS-terminating proxy.
+//
+// Example:
+//
+//   http.Handle("/query", gqlcsrf.Middleware(srv,
+//     gqlcsrf.WithAllowedOrigins("https://app.example.com", "https://*.example.com"),
+//     gqlcsrf.WithRequiredHeaders("X-Requested-With", "Apollo-Require-Preflight"),
+//     gqlcsrf.WithEnvironments("staging", "production"),
+//   ))
+package gqlcsrf
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Middleware enforces CSRF protections on a GraphQL handler.
+//
+// Rejected requests are served with 403 Forbidden.
+//
+// When enforcement is restricted to some environments with WithEnvironments and the current environment
+// is not one of them, the handler is returned untouched.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	if !cfg.isEnforced() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case isUpgrade(r) && !cfg.allowsOrigin(r),
+			mayMutate(r) && !cfg.allows(r):
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func mayMutate(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+func isUpgrade(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+func (c config) allows(r *http.Request) bool {
+	return c.allowsOrigin(r) && c.hasRequiredHeader(r)
+}
+
+func (c config) allowsOrigin(r *http.Request) bool {
+	origin, sent := requestOrigin(r)
+	if !sent {
+		// non-browser clients send neither an Origin nor a Referer
+		return !c.strictOrigin
+	}
+	if origin == "null" {
+		// opaque origins (sandboxed iframes, data: documents...) are only allowed explicitly
+		return c.allowsNullOrigin()
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Scheme, requestScheme(r)) && strings.EqualFold(u.Host, r.Host) {
+		// same origin
+		return true
+	}
+
+	for _, allowed := range c.origins {
+		if matchOrigin(allowed, u) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c config) allowsNullOrigin() bool {
+	for _, allowed := range c.origins {
+		if allowed == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c config) hasRequiredHeader(r *http.Request) bool {
+	if len(c.headers) == 0 {
+		return true
+	}
+	for _, header := range c.headers {
+		if r.Header.Get(header) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// requestScheme yields the scheme of the request, as seen by the browser
+func requestScheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestOrigin yields the origin of the request from the Origin header, or the Referer header as a fallback.
+//
+// It tells whether the request carries any of these headers: an origin which cannot be determined from a
+// header which is present yields an empty origin, which is never allowed.
+func requestOrigin(r *http.Request) (string, bool) {
+	if origin, ok := r.Header["Origin"]; ok {
+		if len(origin) == 0 {
+			return "", true
+		}
+		return origin[0], true
+	}
+	referer, ok := r.Header["Referer"]
+	if !ok {
+		return "", false
+	}
+	if len(referer) == 0 {
+		return "", true
+	}
+	u, err := url.Parse(referer[0])
+	if err != nil || u.Host == "" {
+		return "", true
+	}
+	return u.Scheme + "://" + u.Host, true
+}
+
+// matchOrigin matches an origin against an allowed origin such as "https://app.example.com",
+// or "https://*.example.com" to allow all subdomains
+func matchOrigin(allowed string, origin *url.URL) bool {
+	a, err := url.Parse(allowed)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(a.Scheme, origin.Scheme) {
+		return false
+	}
+	if strings.HasPrefix(a.Host, "*.") {
+		return strings.HasSuffix(strings.ToLower(origin.Host), strings.ToLower(a.Host[1:]))
+	}
+	return strings.EqualFold(a.Host, origin.Host)
+}
@@ -0,0 +1,78 @@
+package gqlcsrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gqlHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		headers  map[string]string
+		opts     []Option
+		expected int
+	}{
+		{name: "GET is not checked", method: http.MethodGet, headers: map[string]string{"Origin": "https://evil.com"}, expected: http.StatusOK},
+		{name: "same origin", headers: map[string]string{"Origin": "http://example.com"}, expected: http.StatusOK},
+		{name: "same host, other scheme", headers: map[string]string{"Origin": "https://example.com"}, expected: http.StatusForbidden},
+		{name: "same origin, forwarded scheme", headers: map[string]string{"Origin": "https://example.com", "X-Forwarded-Proto": "https"}, expected: http.StatusOK},
+		{name: "websocket upgrade, same origin", method: http.MethodGet, headers: map[string]string{"Origin": "http://example.com", "Upgrade": "websocket"}, opts: []Option{WithRequiredHeaders("X-Requested-With")}, expected: http.StatusOK},
+		{name: "websocket upgrade, cross origin", method: http.MethodGet, headers: map[string]string{"Origin": "https://evil.com", "Upgrade": "websocket"}, expected: http.StatusForbidden},
+		{name: "websocket upgrade, allowed origin", method: http.MethodGet, headers: map[string]string{"Origin": "https://app.example.org", "Upgrade": "websocket"}, opts: []Option{WithAllowedOrigins("https://app.example.org")}, expected: http.StatusOK},
+		{name: "cross origin", headers: map[string]string{"Origin": "https://evil.com"}, expected: http.StatusForbidden},
+		{name: "allowed origin", headers: map[string]string{"Origin": "https://app.example.org"}, opts: []Option{WithAllowedOrigins("https://app.example.org")}, expected: http.StatusOK},
+		{name: "allowed subdomain", headers: map[string]string{"Origin": "https://a.example.org"}, opts: []Option{WithAllowedOrigins("https://*.example.org")}, expected: http.StatusOK},
+		{name: "not a subdomain", headers: map[string]string{"Origin": "https://evilexample.org"}, opts: []Option{WithAllowedOrigins("https://*.example.org")}, expected: http.StatusForbidden},
+		{name: "allowed scheme", headers: map[string]string{"Origin": "http://app.example.org"}, opts: []Option{WithAllowedOrigins("https://app.example.org")}, expected: http.StatusForbidden},
+		{name: "referer", headers: map[string]string{"Referer": "https://evil.com/page"}, expected: http.StatusForbidden},
+		{name: "null origin", headers: map[string]string{"Origin": "null"}, expected: http.StatusForbidden},
+		{name: "null origin, same-origin referer", headers: map[string]string{"Origin": "null", "Referer": "http://example.com/page"}, expected: http.StatusForbidden},
+		{name: "null origin, allowed", headers: map[string]string{"Origin": "null"}, opts: []Option{WithAllowedOrigins("null")}, expected: http.StatusOK},
+		{name: "websocket upgrade, null origin", method: http.MethodGet, headers: map[string]string{"Origin": "null", "Upgrade": "websocket"}, expected: http.StatusForbidden},
+		{name: "empty origin", headers: map[string]string{"Origin": ""}, expected: http.StatusForbidden},
+		{name: "no origin", expected: http.StatusOK},
+		{name: "no origin, strict", opts: []Option{WithStrictOrigin(true)}, expected: http.StatusForbidden},
+		{name: "missing header", opts: []Option{WithRequiredHeaders("X-Requested-With")}, expected: http.StatusForbidden},
+		{name: "required header", headers: map[string]string{"X-Requested-With": "app"}, opts: []Option{WithRequiredHeaders("X-Requested-With")}, expected: http.StatusOK},
+		{name: "not enforced", headers: map[string]string{"Origin": "https://evil.com"}, opts: []Option{WithEnvVar("GQLCSRF_TEST_ENV"), WithEnvironments("production")}, expected: http.StatusOK},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			r := httptest.NewRequest(method, "http://example.com/query", nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			Middleware(gqlHandler, tc.opts...).ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+
+	t.Run("enforced", func(t *testing.T) {
+		os.Setenv("GQLCSRF_TEST_ENV", "production")
+		defer os.Unsetenv("GQLCSRF_TEST_ENV")
+
+		r := httptest.NewRequest(http.MethodPost, "http://example.com/query", nil)
+		r.Header.Set("Origin", "https://evil.com")
+		w := httptest.NewRecorder()
+
+		Middleware(gqlHandler, WithEnvVar("GQLCSRF_TEST_ENV"), WithEnvironments("production")).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
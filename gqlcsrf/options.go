@@ -0,0 +1,75 @@
+package gqlcsrf
+
+import "github.com/99designs/gqlgen-contrib/internal/env"
+
+// DefaultEnvVar is the environment variable looked up by default to enforce the protection (see WithEnvironments)
+const DefaultEnvVar = env.DefaultVar
+
+type (
+	// Option for the CSRF middleware
+	Option func(*config)
+
+	config struct {
+		origins      []string
+		strictOrigin bool
+		headers      []string
+		envVar       env.Var
+		environments []string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// isEnforced tells if the protection is enforced for the current environment.
+func (c config) isEnforced() bool {
+	return len(c.environments) == 0 || c.envVar.In(c.environments...)
+}
+
+// WithAllowedOrigins allows requests from these origins, in addition to same-origin requests.
+//
+// An origin such as "https://*.example.com" allows all subdomains of example.com.
+//
+// Requests with the opaque origin "null", sent by sandboxed iframes or data: documents, are rejected
+// unless "null" is explicitly allowed.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(c *config) {
+		c.origins = append(c.origins, origins...)
+	}
+}
+
+// WithStrictOrigin rejects requests which carry neither an Origin nor a Referer header.
+//
+// By default, such requests are allowed, since they are issued by non-browser clients.
+func WithStrictOrigin(enabled bool) Option {
+	return func(c *config) {
+		c.strictOrigin = enabled
+	}
+}
+
+// WithRequiredHeaders requires requests to carry at least one of these headers, with a non-empty value.
+//
+// Browsers cannot send custom headers to another origin without a CORS preflight request.
+// By default, no header is required.
+func WithRequiredHeaders(headers ...string) Option {
+	return func(c *config) {
+		c.headers = append(c.headers, headers...)
+	}
+}
+
+// WithEnvironments enforces the protection only when the current environment is one of these environments
+// (e.g. "staging", "production"). By default, the protection is enforced in all environments.
+func WithEnvironments(enforced ...string) Option {
+	return func(c *config) {
+		c.environments = append(c.environments, enforced...)
+	}
+}
+
+// WithEnvVar sets the environment variable holding the name of the environment, when the protection
+// is only enforced in some environments (see WithEnvironments). The default is "GQL_ENV".
+func WithEnvVar(name string) Option {
+	return func(c *config) {
+		c.envVar = env.Var(name)
+	}
+}
@@ -0,0 +1,207 @@
+// Package gqlctx provides a gqlgen extension attaching a bag of values to each operation, so extensions
+// and resolvers share the values they compute instead of each computing them again.
+//
+// Values are identified by typed keys. The contrib packages share the well-known keys declared here,
+// such as the Signature of the operation.
+//
+// Example:
+//
+//   srv.Use(gqlctx.New()) // register first, so the bag is visible to all other extensions
+//   srv.Use(gqlcontext.New(gqlcontext.WithOperationContextMutator(
+//     func(ctx context.Context, _ *graphql.OperationContext) context.Context {
+//       gqlctx.Set(ctx, gqlctx.Client, auth.ForContext(ctx).ClientID)
+//       return ctx
+//     },
+//   )))
+//   srv.Use(gqlshape.New(gqlshape.WithClient(gqlctx.StringFunc(gqlctx.Client, "-"))))
+//
+// Without a bag in the context, values can't be set, and Load computes values every time.
+package gqlctx
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "OperationBag"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Extension{}
+
+var nextKey int32
+
+// Well-known keys shared by the contrib packages
+var (
+	// Signature of the operation, as a string (see gqlapollo.OperationSignature)
+	Signature = NewKey("signature")
+
+	// Client which sent the operation, as a string
+	Client = NewKey("client")
+
+	// ClientVersion of the client which sent the operation, as a string
+	ClientVersion = NewKey("clientVersion")
+
+	// Cost of the operation, as an int
+	Cost = NewKey("cost")
+)
+
+type (
+	// Key identifies a value in bags
+	Key struct {
+		id   int
+		name string
+	}
+
+	// Bag of values attached to an operation. It is safe for concurrent use.
+	Bag struct {
+		mx     sync.RWMutex
+		values []slot
+	}
+
+	slot struct {
+		value interface{}
+		set   bool
+	}
+
+	// Extension is a gqlgen extension attaching a Bag to each operation
+	Extension struct{}
+
+	bagKey struct{}
+)
+
+// NewKey declares a key, usually as a package variable
+func NewKey(name string) Key {
+	return Key{id: int(atomic.AddInt32(&nextKey, 1)) - 1, name: name}
+}
+
+// String yields the name of the key
+func (k Key) String() string {
+	return k.name
+}
+
+// New bag extension
+func New() *Extension {
+	return &Extension{}
+}
+
+// ExtensionName yields the extension name: "OperationBag"
+func (*Extension) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor, attaching a new Bag to the operation
+func (*Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	return next(WithBag(ctx))
+}
+
+// WithBag attaches a new empty Bag to a context, e.g. outside of gqlgen or in tests
+func WithBag(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bagKey{}, &Bag{values: make([]slot, atomic.LoadInt32(&nextKey))})
+}
+
+// GetBag yields the Bag of a context, or nil
+func GetBag(ctx context.Context) *Bag {
+	bag, _ := ctx.Value(bagKey{}).(*Bag)
+	return bag
+}
+
+// Get the value of a key in the bag of a context
+func Get(ctx context.Context, key Key) (interface{}, bool) {
+	bag := GetBag(ctx)
+	if bag == nil {
+		return nil, false
+	}
+	return bag.Get(key)
+}
+
+// Set the value of a key in the bag of a context. Values are set once: this yields false when the value
+// is already set, or when there is no bag.
+func Set(ctx context.Context, key Key, value interface{}) bool {
+	bag := GetBag(ctx)
+	if bag == nil {
+		return false
+	}
+	return bag.Set(key, value)
+}
+
+// Load the value of a key in the bag of a context, computing and setting it when it is not set yet.
+//
+// Concurrent loads may compute a value more than once, but all of them yield the value set first.
+func Load(ctx context.Context, key Key, compute func() interface{}) interface{} {
+	bag := GetBag(ctx)
+	if bag == nil {
+		return compute()
+	}
+	if value, ok := bag.Get(key); ok {
+		return value
+	}
+	value := compute()
+	if !bag.Set(key, value) {
+		value, _ = bag.Get(key)
+	}
+	return value
+}
+
+// String yields the value of a key in the bag of a context as a string, or an empty string
+func String(ctx context.Context, key Key) string {
+	value, _ := Get(ctx, key)
+	str, _ := value.(string)
+	return str
+}
+
+// Int yields the value of a key in the bag of a context as an int, or zero
+func Int(ctx context.Context, key Key) int {
+	value, _ := Get(ctx, key)
+	i, _ := value.(int)
+	return i
+}
+
+// StringFunc builds a function yielding the string value of a key, or a fallback when it is not set,
+// e.g. to identify clients in the options of metrics extensions.
+func StringFunc(key Key, fallback string) func(context.Context) string {
+	return func(ctx context.Context) string {
+		if str := String(ctx, key); str != "" {
+			return str
+		}
+		return fallback
+	}
+}
+
+// Get the value of a key
+func (b *Bag) Get(key Key) (interface{}, bool) {
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+
+	if key.id >= len(b.values) {
+		return nil, false
+	}
+	s := b.values[key.id]
+	return s.value, s.set
+}
+
+// Set the value of a key. Values are set once: this yields false when the value is already set.
+func (b *Bag) Set(key Key, value interface{}) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if key.id >= len(b.values) {
+		values := make([]slot, key.id+1)
+		copy(values, b.values)
+		b.values = values
+	}
+	if b.values[key.id].set {
+		return false
+	}
+	b.values[key.id] = slot{value: value, set: true}
+	return true
+}
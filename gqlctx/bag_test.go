@@ -0,0 +1,45 @@
+package gqlctx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBag(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, Set(ctx, Client, "web"), "values can't be set without a bag")
+	assert.Equal(t, "computed", Load(ctx, Signature, func() interface{} { return "computed" }))
+
+	var bagged context.Context
+	New().InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		bagged = ctx
+		return nil
+	})
+	require.NotNil(t, GetBag(bagged))
+
+	assert.True(t, Set(bagged, Client, "web"))
+	assert.False(t, Set(bagged, Client, "mobile"), "values are set once")
+	assert.Equal(t, "web", String(bagged, Client))
+	assert.Equal(t, "web", StringFunc(Client, "-")(bagged))
+	assert.Equal(t, "-", StringFunc(ClientVersion, "-")(bagged))
+
+	computed := 0
+	compute := func() interface{} {
+		computed++
+		return 42
+	}
+	assert.Equal(t, 42, Load(bagged, Cost, compute))
+	assert.Equal(t, 42, Load(bagged, Cost, compute))
+	assert.Equal(t, 1, computed)
+	assert.Equal(t, 42, Int(bagged, Cost))
+
+	late := NewKey("late")
+	assert.True(t, Set(bagged, late, true), "keys declared after the bag are supported")
+	value, ok := Get(bagged, late)
+	assert.True(t, ok)
+	assert.Equal(t, true, value)
+}
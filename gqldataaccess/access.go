@@ -0,0 +1,310 @@
+// Package gqldataaccess provides a gqlgen extension to log accesses to personally identifiable information (PII),
+// for compliance with regulations such as the GDPR.
+//
+// Whenever fields tagged as PII (see gqlpii) are returned, the extension emits a Record to a compliance Sink,
+// with the data subject, the requesting principal and the declared purpose of the access.
+//
+// Example:
+//
+//   engine, _ := gqlpii.New(generated.NewExecutableSchema(cfg).Schema())
+//   logger := gqldataaccess.New(engine, sink,
+//     gqldataaccess.WithPrincipal(func(ctx context.Context) string { return auth.ForContext(ctx).UserID }),
+//   )
+//   srv.Use(logger)
+//   http.Handle("/query", logger.Middleware(srv))
+package gqldataaccess
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqldelivery"
+	"github.com/99designs/gqlgen-contrib/gqlpii"
+)
+
+const extensionName = "DataAccessLog"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Logger{}
+
+type (
+	// Record of the accesses to the PII of a data subject by an operation
+	Record struct {
+		Time          time.Time `json:"time"`
+		OperationName string    `json:"operationName,omitempty"`
+		Principal     string    `json:"principal,omitempty"`
+		Purpose       string    `json:"purpose,omitempty"`
+		Subject       string    `json:"subject,omitempty"`
+		Accesses      []Access  `json:"accesses"`
+	}
+
+	// Access to a field tagged as PII
+	Access struct {
+		Path  string      `json:"path"`
+		Field string      `json:"field"`
+		Kind  gqlpii.Kind `json:"kind"`
+	}
+
+	// Sink stores data access records, e.g. in an append-only compliance log
+	Sink interface {
+		Record(context.Context, Record) error
+	}
+
+	// SinkFunc is a function implementing Sink
+	SinkFunc func(context.Context, Record) error
+
+	// WriterSink writes data access records as JSON lines. It is safe for concurrent use.
+	WriterSink struct {
+		mx      sync.Mutex
+		encoder *json.Encoder
+	}
+
+	// QueueSink enqueues data access records as JSON to a delivery queue, so an unavailable compliance log
+	// neither blocks nor loses records. The queue must be run, see gqldelivery.Queue.
+	QueueSink struct {
+		queue *gqldelivery.Queue
+	}
+
+	// SubjectFunc identifies the data subject whose field is resolved, e.g. from the arguments of a parent field.
+	SubjectFunc func(context.Context, *graphql.FieldContext) string
+
+	// Logger is a gqlgen extension recording accesses to PII to a Sink
+	Logger struct {
+		*config
+		engine *gqlpii.Engine
+		sink   Sink
+	}
+
+	// collector gathers the accesses of a response, by subject. Each field instance is recorded once.
+	collector struct {
+		mx       sync.Mutex
+		accesses map[string][]Access
+		seen     map[collectorEntry]struct{}
+	}
+
+	collectorEntry struct {
+		subject string
+		path    string
+	}
+
+	headersKey   struct{}
+	collectorKey struct{}
+)
+
+// Record implements Sink
+func (f SinkFunc) Record(ctx context.Context, record Record) error {
+	return f(ctx, record)
+}
+
+// NewWriterSink builds a Sink writing JSON lines to a writer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{encoder: json.NewEncoder(w)}
+}
+
+// Record implements Sink
+func (s *WriterSink) Record(_ context.Context, record Record) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.encoder.Encode(record)
+}
+
+// NewQueueSink builds a Sink enqueuing records to a delivery queue
+func NewQueueSink(queue *gqldelivery.Queue) *QueueSink {
+	return &QueueSink{queue: queue}
+}
+
+// Record implements Sink. Errors are only reported when the record overflows the queue and can't be spilled.
+func (s *QueueSink) Record(_ context.Context, record Record) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.queue.Enqueue(payload)
+}
+
+// SubjectFromArgs identifies the data subject from the first of these arguments found on the resolved field
+// or its ancestors, e.g. SubjectFromArgs("id") for user(id: "123") { email }.
+func SubjectFromArgs(names ...string) SubjectFunc {
+	return func(_ context.Context, fc *graphql.FieldContext) string {
+		for ; fc != nil; fc = fc.Parent {
+			for _, name := range names {
+				if value, ok := fc.Args[name]; ok && value != nil {
+					if str, isString := value.(string); isString {
+						return str
+					}
+					buf, _ := json.Marshal(value)
+					return string(buf)
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// New data access Logger, recording accesses to the PII tagged in the schema of the engine
+func New(engine *gqlpii.Engine, sink Sink, opts ...Option) *Logger {
+	l := &Logger{
+		config: defaultConfig(),
+		engine: engine,
+		sink:   sink,
+	}
+	for _, apply := range opts {
+		apply(l.config)
+	}
+	return l
+}
+
+// Middleware captures the principal and purpose headers (see WithPrincipalHeader and WithPurposeHeader).
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers := make(map[string]string, 2)
+		for _, name := range []string{l.config.principalHeader, l.config.purposeHeader} {
+			if name == "" {
+				continue
+			}
+			if value := r.Header.Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), headersKey{}, headers)))
+	})
+}
+
+// ExtensionName yields the extension name: "DataAccessLog"
+func (*Logger) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Logger) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor.
+//
+// One Record is emitted per data subject after each response. Sink errors are reported by the error handler
+// (see WithErrorHandler) and do not affect the response.
+func (l *Logger) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	c := &collector{accesses: make(map[string][]Access), seen: make(map[collectorEntry]struct{})}
+	resp := next(context.WithValue(ctx, collectorKey{}, c))
+
+	if len(c.accesses) == 0 {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	principal := l.principal(ctx)
+	purpose := l.purpose(ctx)
+	now := l.config.clock()
+
+	subjects := make([]string, 0, len(c.accesses))
+	for subject := range c.accesses {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		record := Record{
+			Time:          now,
+			OperationName: oc.OperationName,
+			Principal:     principal,
+			Purpose:       purpose,
+			Subject:       subject,
+			Accesses:      c.accesses[subject],
+		}
+		if err := l.sink.Record(ctx, record); err != nil && l.config.onError != nil {
+			l.config.onError(ctx, err)
+		}
+	}
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor, collecting the PII fields returned.
+//
+// The data subject is resolved before the field, from the full chain of parent fields.
+func (l *Logger) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil {
+		return next(ctx)
+	}
+	kind, tagged := l.engine.FieldKind(fc.Object, fc.Field.Name)
+	if !tagged {
+		return next(ctx)
+	}
+	subject := l.config.subject(ctx, fc)
+
+	res, err := next(ctx)
+	if err != nil || isNil(res) {
+		return res, err
+	}
+
+	c.add(subject, Access{
+		Path:  fc.Path().String(),
+		Field: fc.Object + "." + fc.Field.Name,
+		Kind:  kind,
+	})
+	return res, err
+}
+
+func (l *Logger) principal(ctx context.Context) string {
+	if l.config.principal != nil {
+		return l.config.principal(ctx)
+	}
+	return header(ctx, l.config.principalHeader)
+}
+
+func (l *Logger) purpose(ctx context.Context) string {
+	if l.config.purpose != nil {
+		return l.config.purpose(ctx)
+	}
+	return header(ctx, l.config.purposeHeader)
+}
+
+func (c *collector) add(subject string, access Access) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	entry := collectorEntry{subject: subject, path: access.Path}
+	if _, seen := c.seen[entry]; seen {
+		return
+	}
+	c.seen[entry] = struct{}{}
+	c.accesses[subject] = append(c.accesses[subject], access)
+}
+
+func header(ctx context.Context, name string) string {
+	if name == "" {
+		return ""
+	}
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers[name]
+}
+
+// isNil tells if a resolved value is null, including typed nil pointers
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,94 @@
+package gqldataaccess
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlpii"
+	"github.com/99designs/gqlgen-contrib/internal/fieldtest"
+)
+
+const testSchema = `
+directive @pii(kind: PIIKind!) on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+
+enum PIIKind { EMAIL PHONE NAME ADDRESS IP CARD SECRET OTHER }
+
+type User {
+  id: ID!
+  email: String! @pii(kind: EMAIL)
+  phone: String @pii(kind: PHONE)
+}
+
+type Query {
+  user(id: ID!): User
+}
+`
+
+func TestLogger(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+	engine, err := gqlpii.New(schema)
+	require.NoError(t, err)
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	var records []Record
+	logger := New(engine, SinkFunc(func(_ context.Context, record Record) error {
+		records = append(records, record)
+		return nil
+	}), WithClock(func() time.Time { return now }))
+
+	resolve := func(ctx context.Context, id, field string, value interface{}) {
+		parent := fieldtest.Field(nil, "Query", "user")
+		parent.Args = map[string]interface{}{"id": id}
+		ctx = fieldtest.Context(ctx, parent, fieldtest.Field(nil, "User", field))
+		_, _ = logger.InterceptField(ctx, func(context.Context) (interface{}, error) {
+			return value, nil
+		})
+	}
+
+	var phone *string
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{OperationName: "users"})
+		logger.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			resolve(ctx, "42", "id", "42")
+			resolve(ctx, "42", "email", "john@example.com")
+			resolve(ctx, "42", "email", "john@example.com")
+			resolve(ctx, "42", "phone", phone)
+			resolve(ctx, "7", "email", "jane@example.com")
+			return &graphql.Response{}
+		})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("X-Principal", "support-agent")
+	req.Header.Set("X-Access-Purpose", "ticket-1234")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []Record{
+		{
+			Time:          now,
+			OperationName: "users",
+			Principal:     "support-agent",
+			Purpose:       "ticket-1234",
+			Subject:       "42",
+			Accesses:      []Access{{Path: "user.email", Field: "User.email", Kind: gqlpii.Email}},
+		},
+		{
+			Time:          now,
+			OperationName: "users",
+			Principal:     "support-agent",
+			Purpose:       "ticket-1234",
+			Subject:       "7",
+			Accesses:      []Access{{Path: "user.email", Field: "User.email", Kind: gqlpii.Email}},
+		},
+	}, records)
+}
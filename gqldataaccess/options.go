@@ -0,0 +1,87 @@
+package gqldataaccess
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Option for the data access logger
+	Option func(*config)
+
+	config struct {
+		subject         SubjectFunc
+		principal       func(context.Context) string
+		purpose         func(context.Context) string
+		principalHeader string
+		purposeHeader   string
+		onError         func(context.Context, error)
+		clock           func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		subject:         SubjectFromArgs("id"),
+		principalHeader: "X-Principal",
+		purposeHeader:   "X-Access-Purpose",
+		clock:           time.Now,
+	}
+}
+
+// WithSubject sets the function identifying the data subject of a PII field. The default is SubjectFromArgs("id").
+//
+// Accesses for which no subject is identified are recorded with an empty subject.
+func WithSubject(subject SubjectFunc) Option {
+	return func(c *config) {
+		c.subject = subject
+	}
+}
+
+// WithPrincipal sets the function identifying the requesting principal from the context, e.g. from the claims
+// of an authentication token. This takes precedence over the principal header.
+func WithPrincipal(principal func(context.Context) string) Option {
+	return func(c *config) {
+		c.principal = principal
+	}
+}
+
+// WithPurpose sets the function retrieving the purpose of the access from the context, e.g. from a token claim.
+// This takes precedence over the purpose header.
+func WithPurpose(purpose func(context.Context) string) Option {
+	return func(c *config) {
+		c.purpose = purpose
+	}
+}
+
+// WithPrincipalHeader sets the request header identifying the principal, captured by the Middleware.
+// The default is "X-Principal". An empty name disables the header.
+//
+// Only trust this header when set by an authenticating proxy.
+func WithPrincipalHeader(name string) Option {
+	return func(c *config) {
+		c.principalHeader = name
+	}
+}
+
+// WithPurposeHeader sets the request header declaring the purpose of the access, captured by the Middleware.
+// The default is "X-Access-Purpose". An empty name disables the header.
+func WithPurposeHeader(name string) Option {
+	return func(c *config) {
+		c.purposeHeader = name
+	}
+}
+
+// WithErrorHandler sets a handler for sink errors, e.g. to log them or to alert. By default, sink errors are ignored.
+func WithErrorHandler(handler func(context.Context, error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithClock sets the clock used to timestamp records. The default is time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
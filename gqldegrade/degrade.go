@@ -0,0 +1,234 @@
+// Package gqldegrade provides a gqlgen extension to downgrade errors on non-nullable fields to nulls,
+// when the schema allows it with a @degradable directive.
+//
+// By default, an error on a non-nullable field nulls its parent, up to the nearest nullable ancestor:
+// a single failing leaf may wipe out an entire response tree. With this extension, the failing field
+// alone is set to null, and the error is reported as a warning in the extensions of the response:
+//
+//   {
+//     "data": {"user": {"id": "42", "rating": null}},
+//     "extensions": {
+//       "warnings": [{"message": "rating service unavailable", "path": ["user", "rating"], "code": "DEGRADED"}]
+//     }
+//   }
+//
+// The directive must be declared in the schema, and implemented by gqlgen as a noop:
+//
+//   directive @degradable on FIELD_DEFINITION
+//
+//   type User {
+//     id: ID!
+//     rating: Float! @degradable
+//   }
+//
+// Notice that clients must be prepared to receive nulls for these fields, despite their non-nullable type.
+//
+// Only fields of scalar, enum or list types are degraded: errors on fields of object types keep
+// their default behavior.
+package gqldegrade
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "Degrade"
+
+	// CodeDegraded is the code of warnings reporting a degraded field
+	CodeDegraded = "DEGRADED"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Degrade{}
+
+type (
+	// Warning reports an error on a degraded field
+	Warning struct {
+		Message string   `json:"message"`
+		Path    ast.Path `json:"path"`
+		Code    string   `json:"code"`
+
+		// degraded field, as "Object.field"
+		field string
+	}
+
+	// Degrade is a gqlgen extension downgrading errors on @degradable non-nullable fields to nulls
+	Degrade struct {
+		*config
+		schema *ast.Schema
+
+		// degradable fields, as "Object.field"
+		fields map[string]struct{}
+	}
+
+	// collector gathers the warnings of a response
+	collector struct {
+		mx       sync.Mutex
+		warnings []Warning
+	}
+
+	collectorKey struct{}
+)
+
+// New degrading extension
+func New(opts ...Option) *Degrade {
+	d := &Degrade{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(d.config)
+	}
+	return d
+}
+
+// ExtensionName yields the extension name: "Degrade"
+func (*Degrade) ExtensionName() string {
+	return extensionName
+}
+
+// Validate indexes the degradable fields declared in the schema
+func (d *Degrade) Validate(schema graphql.ExecutableSchema) error {
+	d.prepare(schema.Schema())
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor, setting degraded fields to null
+// and reporting warnings in the extensions of the response
+func (d *Degrade) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	c := &collector{}
+	resp := next(context.WithValue(ctx, collectorKey{}, c))
+	if resp == nil || len(c.warnings) == 0 {
+		return resp
+	}
+
+	paths := make([]ast.Path, 0, len(c.warnings))
+	for _, warning := range c.warnings {
+		paths = append(paths, warning.Path)
+	}
+	if data, err := nullify(resp.Data, paths); err == nil {
+		resp.Data = data
+	}
+
+	if resp.Extensions == nil {
+		resp.Extensions = make(map[string]interface{})
+	}
+	warnings, _ := resp.Extensions[d.config.extensionKey].([]Warning)
+	resp.Extensions[d.config.extensionKey] = append(warnings, c.warnings...)
+
+	label := d.config.opLabel(graphql.GetOperationContext(ctx))
+	for _, warning := range c.warnings {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{
+			tag.Upsert(metrics.TagOperation, label),
+			tag.Upsert(metrics.TagField, warning.field),
+		}, DegradedFieldCount.M(1))
+	}
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor.
+//
+// Errors on degradable fields are swallowed and replaced by a zero value, so that gqlgen does not null the parent.
+// The field is eventually set to null in the response.
+func (d *Degrade) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	if err == nil {
+		return res, err
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil || fc.Field.Definition == nil {
+		return res, err
+	}
+	if _, ok := d.fields[fc.Object+"."+fc.Field.Name]; !ok {
+		return res, err
+	}
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return res, err
+	}
+	zero, ok := d.zeroValue(fc.Field.Definition.Type, res)
+	if !ok {
+		return res, err
+	}
+
+	message := err.Error()
+	if gqlErr, isGQLError := err.(*gqlerror.Error); isGQLError {
+		message = gqlErr.Message
+	}
+	c.add(Warning{
+		Message: message,
+		Path:    fc.Path(),
+		Code:    CodeDegraded,
+		field:   fc.Object + "." + fc.Field.Name,
+	})
+	return zero, nil
+}
+
+// zeroValue yields a placeholder value of the same Go type as the result, which gqlgen marshals without
+// resolving anything further. Objects are not degraded, since resolving their fields is not safe.
+func (d *Degrade) zeroValue(typ *ast.Type, res interface{}) (interface{}, bool) {
+	if res == nil {
+		return nil, false
+	}
+	rv := reflect.ValueOf(res)
+
+	if typ.Elem != nil {
+		if rv.Kind() != reflect.Slice {
+			return nil, false
+		}
+		return reflect.Zero(rv.Type()).Interface(), true
+	}
+
+	def := d.schema.Types[typ.Name()]
+	if def == nil || (def.Kind != ast.Scalar && def.Kind != ast.Enum) {
+		return nil, false
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Func, reflect.Chan:
+		return nil, false
+	default:
+		return reflect.Zero(rv.Type()).Interface(), true
+	}
+}
+
+// prepare indexes the degradable non-nullable fields. Directives on interface fields apply to their implementations.
+func (d *Degrade) prepare(schema *ast.Schema) {
+	d.schema = schema
+	d.fields = make(map[string]struct{})
+
+	for _, def := range schema.Types {
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+		for _, field := range def.Fields {
+			if !field.Type.NonNull || field.Directives.ForName(d.config.directive) == nil {
+				continue
+			}
+			d.fields[def.Name+"."+field.Name] = struct{}{}
+			if def.Kind == ast.Interface {
+				for _, impl := range schema.GetPossibleTypes(def) {
+					d.fields[impl.Name+"."+field.Name] = struct{}{}
+				}
+			}
+		}
+	}
+}
+
+func (c *collector) add(warning Warning) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.warnings = append(c.warnings, warning)
+}
@@ -0,0 +1,84 @@
+package gqldegrade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/internal/fieldtest"
+)
+
+const testSchema = `
+directive @degradable on FIELD_DEFINITION
+
+type User {
+  id: ID!
+  rating: Float! @degradable
+  tags: [String!]! @degradable
+  friend: User! @degradable
+  name: String!
+}
+
+type Query {
+  user: User
+}
+`
+
+func TestDegrade(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	d := New()
+	d.prepare(schema)
+
+	resolve := func(ctx context.Context, field string, res interface{}) (interface{}, error) {
+		ctx = fieldtest.Context(ctx, fieldtest.Field(nil, "Query", "user"), fieldtest.Field(schema, "User", field))
+		return d.InterceptField(ctx, func(context.Context) (interface{}, error) {
+			return res, errors.New(field + " unavailable")
+		})
+	}
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "user"})
+	resp := d.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		res, err := resolve(ctx, "rating", float64(0))
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), res)
+
+		res, err = resolve(ctx, "tags", []string(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, []string(nil), res)
+
+		_, err = resolve(ctx, "friend", (*struct{})(nil))
+		assert.Error(t, err, "objects are not degraded")
+
+		_, err = resolve(ctx, "name", "")
+		assert.Error(t, err, "fields without directive are not degraded")
+
+		return &graphql.Response{
+			Data: json.RawMessage(`{"user":{"rating":0,"tags":[],"id":"42"}}`),
+		}
+	})
+
+	assert.Equal(t, `{"user":{"rating":null,"tags":null,"id":"42"}}`, string(resp.Data))
+	assert.Equal(t, []Warning{
+		{Message: "rating unavailable", Path: ast.Path{ast.PathName("user"), ast.PathName("rating")}, Code: CodeDegraded, field: "User.rating"},
+		{Message: "tags unavailable", Path: ast.Path{ast.PathName("user"), ast.PathName("tags")}, Code: CodeDegraded, field: "User.tags"},
+	}, resp.Extensions["warnings"])
+}
+
+func TestNullify(t *testing.T) {
+	data, err := nullify([]byte(`{"b":[{"x":1},{"x":2.50}],"a":"z"}`), []ast.Path{
+		{ast.PathName("b"), ast.PathIndex(1), ast.PathName("x")},
+		{ast.PathName("c")},
+		{ast.PathName("b"), ast.PathIndex(5)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":[{"x":1},{"x":null}],"a":"z"}`, string(data))
+}
@@ -0,0 +1,152 @@
+package gqldegrade
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// object is a decoded JSON object, retaining the order of its keys, as mandated for GraphQL responses
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// nullify sets the values found at these paths to null, preserving the order of fields.
+// Paths which are not found are ignored.
+func nullify(data []byte, paths []ast.Path) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		setNull(root, path)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeValue(&buf, root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func setNull(value interface{}, path ast.Path) {
+	if len(path) == 0 {
+		return
+	}
+	for i, elem := range path {
+		last := i == len(path)-1
+		switch e := elem.(type) {
+		case ast.PathName:
+			obj, ok := value.(*object)
+			if !ok {
+				return
+			}
+			if _, found := obj.values[string(e)]; !found {
+				return
+			}
+			if last {
+				obj.values[string(e)] = nil
+				return
+			}
+			value = obj.values[string(e)]
+		case ast.PathIndex:
+			list, ok := value.([]interface{})
+			if !ok || int(e) < 0 || int(e) >= len(list) {
+				return
+			}
+			if last {
+				list[e] = nil
+				return
+			}
+			value = list[e]
+		default:
+			return
+		}
+	}
+}
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &object{values: make(map[string]interface{})}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", keyTok)
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key)
+			obj.values[key] = value
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := make([]interface{}, 0)
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	default:
+		return tok, nil
+	}
+}
+
+func encodeValue(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case *object:
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := encodeValue(buf, v.values[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		scalar, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(scalar)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package gqldegrade
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(DegradedFieldCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(DegradedFieldCountView)
+}
+
+var (
+	// DegradedFieldCount tracks a count of errors downgraded to nulls
+	DegradedFieldCount = stats.Int64(
+		"gql/server/degraded_field_count",
+		"Number of GraphQL field errors downgraded to nulls",
+		stats.UnitDimensionless)
+
+	// DegradedFieldCountView reports a count of degraded fields tagged by operation name and field
+	DegradedFieldCountView = &view.View{
+		Name:        "gql/server/degraded_field_count",
+		Description: "Count of degraded GraphQL fields by operation and field",
+		Measure:     DegradedFieldCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, metrics.TagField},
+	}
+)
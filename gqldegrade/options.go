@@ -0,0 +1,47 @@
+package gqldegrade
+
+import (
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the degrading extension
+	Option func(*config)
+
+	config struct {
+		directive    string
+		extensionKey string
+		opLabel      gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		directive:    "degradable",
+		extensionKey: "warnings",
+		opLabel:      gqllabel.OperationName,
+	}
+}
+
+// WithDirective sets the name of the directive allowing a field to be degraded. The default is "degradable".
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
+
+// WithExtensionKey sets the key of warnings in the extensions of the response. The default is "warnings".
+func WithExtensionKey(key string) Option {
+	return func(c *config) {
+		c.extensionKey = key
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
@@ -0,0 +1,283 @@
+// Package gqldelivery provides a resilient delivery queue for the reports of gqlgen extensions,
+// such as usage statistics or audit logs, so outages of reporting backends neither lose data
+// nor block the handling of requests.
+//
+// Payloads are enqueued without blocking, then delivered in the background by Run, with retries
+// and an exponential backoff. Payloads which still fail to be delivered, or which overflow the queue,
+// may be spilled to disk and delivered again later. Payloads which cannot be delivered nor spilled
+// are handed over to a dead letter handler.
+//
+// Example:
+//
+//   reporter := gqlapollo.New(os.Getenv("APOLLO_KEY"), "my-graph@current",
+//     gqlapollo.WithDelivery(
+//       gqldelivery.WithSpill("/var/spool/apollo", 64<<20),
+//       gqldelivery.WithDeadLetter(func(payload []byte, err error) { log.Printf("report lost: %v", err) }),
+//     ),
+//   )
+//   go reporter.Run(ctx)
+package gqldelivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const spillExt = ".payload"
+
+// ErrQueueFull is passed to the dead letter handler for payloads overflowing the queue, when they can't be spilled
+var ErrQueueFull = errors.New("delivery: queue is full")
+
+type (
+	// Sender delivers a payload, e.g. to a reporting API
+	Sender interface {
+		Send(context.Context, []byte) error
+	}
+
+	// SenderFunc is a function implementing Sender
+	SenderFunc func(context.Context, []byte) error
+
+	// Queue delivers payloads to a Sender in the background
+	Queue struct {
+		*config
+		sender Sender
+		queue  chan []byte
+
+		spillMx sync.Mutex
+		seq     uint64
+	}
+)
+
+// Send implements Sender
+func (f SenderFunc) Send(ctx context.Context, payload []byte) error {
+	return f(ctx, payload)
+}
+
+// New delivery Queue to a Sender
+func New(sender Sender, opts ...Option) *Queue {
+	q := &Queue{
+		config: defaultConfig(),
+		sender: sender,
+	}
+	for _, apply := range opts {
+		apply(q.config)
+	}
+	q.queue = make(chan []byte, q.config.bufferSize)
+	return q
+}
+
+// Enqueue a payload for delivery. This never blocks.
+//
+// When the queue is full, the payload is spilled to disk, or handed over to the dead letter handler with
+// ErrQueueFull, which is then returned.
+func (q *Queue) Enqueue(payload []byte) error {
+	select {
+	case q.queue <- payload:
+		return nil
+	default:
+	}
+
+	if q.spill(payload) {
+		return nil
+	}
+	q.deadLetter(payload, ErrQueueFull)
+	return ErrQueueFull
+}
+
+// Pending yields the number of payloads waiting in memory for delivery
+func (q *Queue) Pending() int {
+	return len(q.queue)
+}
+
+// Run delivers enqueued payloads until the context is done, and periodically delivers again spilled payloads.
+//
+// Payloads remaining in memory when Run returns may be delivered with Drain.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.config.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload := <-q.queue:
+			q.deliver(ctx, payload)
+		case <-ticker.C:
+			q.replay(ctx)
+		}
+	}
+}
+
+// Drain delivers the payloads remaining in memory, with a single attempt each, e.g. upon shutdown.
+//
+// Payloads failing to be delivered, or remaining when the context is done, are spilled to disk
+// or handed over to the dead letter handler.
+func (q *Queue) Drain(ctx context.Context) {
+	for {
+		select {
+		case payload := <-q.queue:
+			err := ctx.Err()
+			if err == nil {
+				err = q.attempt(ctx, payload)
+			}
+			if err != nil {
+				q.fail(payload, err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// deliver a payload with retries
+func (q *Queue) deliver(ctx context.Context, payload []byte) {
+	backoff := q.config.backoff
+	var err error
+	for attempt := 0; attempt <= q.config.retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				q.fail(payload, err)
+				return
+			case <-timer.C:
+			}
+			if backoff *= 2; backoff > q.config.maxBackoff {
+				backoff = q.config.maxBackoff
+			}
+		}
+
+		if err = q.attempt(ctx, payload); err == nil {
+			return
+		}
+	}
+	q.fail(payload, err)
+}
+
+func (q *Queue) attempt(ctx context.Context, payload []byte) error {
+	if q.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.config.timeout)
+		defer cancel()
+	}
+	return q.sender.Send(ctx, payload)
+}
+
+// fail spills a payload which failed to be delivered, or hands it over to the dead letter handler
+func (q *Queue) fail(payload []byte, err error) {
+	if q.spill(payload) {
+		return
+	}
+	q.deadLetter(payload, err)
+}
+
+func (q *Queue) deadLetter(payload []byte, err error) {
+	if q.config.deadLetter != nil {
+		q.config.deadLetter(payload, err)
+	}
+}
+
+func (q *Queue) reportError(err error) {
+	if q.config.onError != nil {
+		q.config.onError(err)
+	}
+}
+
+// spill a payload to disk. It yields false when spilling is disabled, the disk quota is exceeded, or writing fails.
+func (q *Queue) spill(payload []byte) bool {
+	if q.config.spillDir == "" {
+		return false
+	}
+
+	q.spillMx.Lock()
+	defer q.spillMx.Unlock()
+
+	_, size, err := q.spilled()
+	if err != nil {
+		q.reportError(err)
+		return false
+	}
+	if q.config.spillMaxBytes > 0 && size+int64(len(payload)) > q.config.spillMaxBytes {
+		return false
+	}
+
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), atomic.AddUint64(&q.seq, 1))
+	tmp := filepath.Join(q.config.spillDir, name+".tmp")
+	if err := ioutil.WriteFile(tmp, payload, 0600); err != nil {
+		q.reportError(err)
+		return false
+	}
+	if err := os.Rename(tmp, filepath.Join(q.config.spillDir, name+spillExt)); err != nil {
+		_ = os.Remove(tmp)
+		q.reportError(err)
+		return false
+	}
+	return true
+}
+
+// spilled yields the spilled payload files, oldest first, and their total size
+func (q *Queue) spilled() ([]string, int64, error) {
+	if err := os.MkdirAll(q.config.spillDir, 0700); err != nil {
+		return nil, 0, err
+	}
+	infos, err := ioutil.ReadDir(q.config.spillDir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		files []string
+		size  int64
+	)
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), spillExt) {
+			continue
+		}
+		files = append(files, filepath.Join(q.config.spillDir, info.Name()))
+		size += info.Size()
+	}
+	sort.Strings(files)
+	return files, size, nil
+}
+
+// replay delivers spilled payloads, oldest first, until one fails
+func (q *Queue) replay(ctx context.Context) {
+	if q.config.spillDir == "" {
+		return
+	}
+
+	q.spillMx.Lock()
+	files, _, err := q.spilled()
+	q.spillMx.Unlock()
+	if err != nil {
+		q.reportError(err)
+		return
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		payload, err := ioutil.ReadFile(file)
+		if err != nil {
+			q.reportError(err)
+			continue
+		}
+		if err := q.attempt(ctx, payload); err != nil {
+			return
+		}
+		if err := os.Remove(file); err != nil {
+			q.reportError(err)
+		}
+	}
+}
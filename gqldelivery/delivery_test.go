@@ -0,0 +1,111 @@
+package gqldelivery
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSender struct {
+	mx        sync.Mutex
+	fail      bool
+	attempts  int
+	delivered []string
+}
+
+func (s *testSender) Send(_ context.Context, payload []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.attempts++
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	s.delivered = append(s.delivered, string(payload))
+	return nil
+}
+
+func (s *testSender) setFail(fail bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.fail = fail
+}
+
+func (s *testSender) result() (int, []string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.attempts, append([]string(nil), s.delivered...)
+}
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqldelivery")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	sender := &testSender{fail: true}
+	q := New(sender,
+		WithRetries(2, time.Millisecond),
+		WithSpill(dir, 1024),
+		WithReplayInterval(10*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	require.NoError(t, q.Enqueue([]byte("first")))
+	require.NoError(t, q.Enqueue([]byte("second")))
+
+	assert.Eventually(t, func() bool {
+		files, _, err := q.spilled()
+		return err == nil && len(files) == 2
+	}, time.Second, 5*time.Millisecond, "failed payloads are spilled")
+	attempts, _ := sender.result()
+	assert.True(t, attempts >= 6, "payloads are retried")
+
+	sender.setFail(false)
+	assert.Eventually(t, func() bool {
+		_, delivered := sender.result()
+		return len(delivered) == 2
+	}, time.Second, 5*time.Millisecond, "spilled payloads are replayed")
+	_, delivered := sender.result()
+	assert.Equal(t, []string{"first", "second"}, delivered)
+
+	files, _, err := q.spilled()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	cancel()
+	<-done
+}
+
+func TestDeadLetter(t *testing.T) {
+	var dead []string
+	sender := &testSender{}
+	q := New(sender,
+		WithBufferSize(1),
+		WithDeadLetter(func(payload []byte, err error) {
+			dead = append(dead, string(payload)+": "+err.Error())
+		}),
+	)
+
+	require.NoError(t, q.Enqueue([]byte("first")))
+	assert.Equal(t, ErrQueueFull, q.Enqueue([]byte("second")))
+	assert.Equal(t, 1, q.Pending())
+
+	sender.setFail(true)
+	q.Drain(context.Background())
+	assert.Equal(t, 0, q.Pending())
+	assert.Equal(t, []string{"second: " + ErrQueueFull.Error(), "first: unavailable"}, dead)
+}
@@ -0,0 +1,101 @@
+package gqldelivery
+
+import (
+	"time"
+)
+
+type (
+	// Option for the delivery queue
+	Option func(*config)
+
+	config struct {
+		bufferSize     int
+		retries        int
+		backoff        time.Duration
+		maxBackoff     time.Duration
+		timeout        time.Duration
+		spillDir       string
+		spillMaxBytes  int64
+		replayInterval time.Duration
+		deadLetter     func([]byte, error)
+		onError        func(error)
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		bufferSize:     100,
+		retries:        3,
+		backoff:        time.Second,
+		maxBackoff:     30 * time.Second,
+		timeout:        10 * time.Second,
+		replayInterval: 30 * time.Second,
+	}
+}
+
+// WithBufferSize sets the number of payloads buffered in memory. The default is 100.
+func WithBufferSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.bufferSize = size
+		}
+	}
+}
+
+// WithRetries sets the number of retries of failed deliveries, and the backoff before the first retry.
+// The backoff doubles after each retry. The defaults are 3 retries, after 1s.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *config) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithMaxBackoff caps the backoff between retries. The default is 30s.
+func WithMaxBackoff(backoff time.Duration) Option {
+	return func(c *config) {
+		c.maxBackoff = backoff
+	}
+}
+
+// WithTimeout sets the timeout of each delivery attempt. The default is 10s: zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithSpill spills to a directory the payloads which failed to be delivered or overflow the queue, up to
+// a total size in bytes (zero means no limit). Spilled payloads are delivered again by Run, and survive restarts.
+//
+// Spilling is disabled by default.
+func WithSpill(dir string, maxBytes int64) Option {
+	return func(c *config) {
+		c.spillDir = dir
+		c.spillMaxBytes = maxBytes
+	}
+}
+
+// WithReplayInterval sets the interval between attempts to deliver spilled payloads. The default is 30s.
+func WithReplayInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.replayInterval = interval
+		}
+	}
+}
+
+// WithDeadLetter sets a handler for the payloads which can neither be delivered nor spilled, with the last error.
+// By default, such payloads are dropped.
+func WithDeadLetter(handler func(payload []byte, err error)) Option {
+	return func(c *config) {
+		c.deadLetter = handler
+	}
+}
+
+// WithErrorHandler sets a handler for errors spilling payloads to disk. By default, errors are ignored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
@@ -0,0 +1,170 @@
+// Package gqldeps constructs the dependencies of resolvers, such as database pools, HTTP clients and caches,
+// pre-wrapped with the instrumentation of this repository.
+//
+// All dependencies are named, and their telemetry is consistent:
+//   - database handles trace queries with gqlsql, and their pool statistics are recorded as metrics
+//   - HTTP clients trace requests and propagate the trace context with ochttp, and forward the allowed
+//     headers of the incoming request with gqlforward
+//   - caches record hits and misses with gqlcache, and Redis hooks trace commands with gqlredis
+//
+// Example:
+//
+//   deps := gqldeps.New(gqldeps.WithPool(20, 5, time.Hour))
+//   defer deps.Close()
+//   go deps.Run(ctx)
+//
+//   db, err := deps.OpenDB("users", "postgres", dsn)
+//   if err != nil {
+//     log.Fatal(err)
+//   }
+//   resolver := &graph.Resolver{
+//     DB:      db,
+//     Billing: deps.HTTPClient("billing"),
+//     Cache:   deps.Cache("profiles", gqlcache.NewMemcachedStore(mc), gqlcache.WithTTL(time.Minute)),
+//   }
+package gqldeps
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqlforward"
+	"github.com/99designs/gqlgen-contrib/gqlredis"
+	"github.com/99designs/gqlgen-contrib/gqlsql"
+)
+
+// Container of the instrumented dependencies of resolvers
+type Container struct {
+	*config
+
+	mx  sync.Mutex
+	dbs map[string]*sql.DB
+}
+
+// New Container of dependencies
+func New(opts ...Option) *Container {
+	c := &Container{
+		config: defaultConfig(),
+		dbs:    make(map[string]*sql.DB),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// OpenDB opens a database with the configured pool settings (see WithPool), and wraps it with DB
+func (c *Container) OpenDB(name, driver, dsn string, opts ...gqlsql.Option) (*gqlsql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(c.config.maxOpen)
+	db.SetMaxIdleConns(c.config.maxIdle)
+	db.SetConnMaxLifetime(c.config.maxLifetime)
+	return c.DB(name, db, opts...), nil
+}
+
+// DB wraps a database handle with tracing, and records the statistics of its pool under its name.
+//
+// The database is closed by Close.
+func (c *Container) DB(name string, db *sql.DB, opts ...gqlsql.Option) *gqlsql.DB {
+	c.mx.Lock()
+	c.dbs[name] = db
+	c.mx.Unlock()
+
+	return gqlsql.Wrap(db, append([]gqlsql.Option{gqlsql.WithDBName(name)}, opts...)...)
+}
+
+// HTTPClient builds a client for a downstream service, with the configured timeout (see WithHTTPTimeout).
+//
+// Requests produce spans named after the service, propagate the trace context, and forward the allowed headers
+// of the incoming request (see gqlforward).
+func (c *Container) HTTPClient(name string) *http.Client {
+	return &http.Client{
+		Timeout: c.config.httpTimeout,
+		Transport: &ochttp.Transport{
+			Base: gqlforward.Transport(c.config.transport),
+			FormatSpanName: func(req *http.Request) string {
+				return name + " " + req.Method
+			},
+		},
+	}
+}
+
+// Cache builds a cache on top of a store, recording metrics under its name
+func (c *Container) Cache(name string, store gqlcache.Store, opts ...gqlcache.Option) *gqlcache.Cache {
+	return gqlcache.New(store, append([]gqlcache.Option{gqlcache.WithName(name)}, opts...)...)
+}
+
+// RedisHook builds a hook tracing the commands of a Redis client, under the name of its database
+func (c *Container) RedisHook(name string, opts ...gqlredis.Option) *gqlredis.Hook {
+	return gqlredis.NewHook(append([]gqlredis.Option{gqlredis.WithDB(name)}, opts...)...)
+}
+
+// Run records the statistics of the database pools at the configured interval (see WithStatsInterval),
+// until the context is done
+func (c *Container) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.RecordStats(ctx)
+		}
+	}
+}
+
+// RecordStats records the statistics of the database pools
+func (c *Container) RecordStats(ctx context.Context) {
+	c.mx.Lock()
+	dbs := make(map[string]*sql.DB, len(c.dbs))
+	for name, db := range c.dbs {
+		dbs[name] = db
+	}
+	c.mx.Unlock()
+
+	for name, db := range dbs {
+		s := db.Stats()
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagDB, name)},
+			DBOpenConnections.M(int64(s.OpenConnections)),
+			DBInUseConnections.M(int64(s.InUse)),
+			DBIdleConnections.M(int64(s.Idle)),
+			DBWaitCount.M(s.WaitCount),
+			DBWaitDuration.M(float64(s.WaitDuration)/float64(time.Millisecond)),
+		)
+	}
+}
+
+// Close the databases, in name order. The first error is returned.
+func (c *Container) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	names := make([]string, 0, len(c.dbs))
+	for name := range c.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var first error
+	for _, name := range names {
+		if err := c.dbs[name].Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(c.dbs, name)
+	}
+	return first
+}
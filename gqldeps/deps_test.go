@@ -0,0 +1,61 @@
+package gqldeps
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqlforward"
+)
+
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+	sql.Register("gqldeps-test", testDriver{})
+}
+
+func TestHTTPClient(t *testing.T) {
+	var forwarded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Request-Id")
+	}))
+	defer srv.Close()
+
+	deps := New(WithHTTPTimeout(time.Second))
+	client := deps.HTTPClient("billing")
+	assert.Equal(t, time.Second, client.Timeout)
+
+	ctx := gqlforward.WithHeaders(context.Background(), http.Header{"X-Request-Id": []string{"42"}})
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req.WithContext(ctx))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, "42", forwarded)
+}
+
+func TestOpenDB(t *testing.T) {
+	deps := New(WithPool(10, 3, time.Minute))
+
+	db, err := deps.OpenDB("users", "gqldeps-test", "")
+	require.NoError(t, err)
+	assert.Equal(t, 10, db.Stats().MaxOpenConnections)
+
+	deps.RecordStats(context.Background())
+
+	require.NoError(t, deps.Close())
+	assert.Error(t, db.Ping())
+}
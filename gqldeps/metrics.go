@@ -0,0 +1,110 @@
+package gqldeps
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before running the container.
+func Register() error {
+	return gqlmetrics.Register(DBViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(DBViews...)
+}
+
+var (
+	// DBViews contains all opencensus stats views declared for database pools
+	DBViews = []*view.View{
+		DBOpenConnectionsView,
+		DBInUseConnectionsView,
+		DBIdleConnectionsView,
+		DBWaitCountView,
+		DBWaitDurationView,
+	}
+
+	// DBOpenConnections tracks the number of open connections of database pools
+	DBOpenConnections = stats.Int64(
+		"gql/deps/db_open_connections",
+		"Number of open connections of database pools",
+		stats.UnitDimensionless)
+
+	// DBInUseConnections tracks the number of connections in use of database pools
+	DBInUseConnections = stats.Int64(
+		"gql/deps/db_in_use_connections",
+		"Number of connections in use of database pools",
+		stats.UnitDimensionless)
+
+	// DBIdleConnections tracks the number of idle connections of database pools
+	DBIdleConnections = stats.Int64(
+		"gql/deps/db_idle_connections",
+		"Number of idle connections of database pools",
+		stats.UnitDimensionless)
+
+	// DBWaitCount tracks the total number of waits for a connection of database pools
+	DBWaitCount = stats.Int64(
+		"gql/deps/db_wait_count",
+		"Total number of waits for a connection of database pools",
+		stats.UnitDimensionless)
+
+	// DBWaitDuration tracks the total time spent waiting for a connection of database pools
+	DBWaitDuration = stats.Float64(
+		"gql/deps/db_wait_duration",
+		"Total time spent waiting for a connection of database pools",
+		stats.UnitMilliseconds)
+
+	// DBOpenConnectionsView reports the number of open connections, by database
+	DBOpenConnectionsView = &view.View{
+		Name:        "gql/deps/db_open_connections",
+		Description: "Number of open connections of database pools",
+		Measure:     DBOpenConnections,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagDB},
+	}
+
+	// DBInUseConnectionsView reports the number of connections in use, by database
+	DBInUseConnectionsView = &view.View{
+		Name:        "gql/deps/db_in_use_connections",
+		Description: "Number of connections in use of database pools",
+		Measure:     DBInUseConnections,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagDB},
+	}
+
+	// DBIdleConnectionsView reports the number of idle connections, by database
+	DBIdleConnectionsView = &view.View{
+		Name:        "gql/deps/db_idle_connections",
+		Description: "Number of idle connections of database pools",
+		Measure:     DBIdleConnections,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagDB},
+	}
+
+	// DBWaitCountView reports the total number of waits for a connection, by database
+	DBWaitCountView = &view.View{
+		Name:        "gql/deps/db_wait_count",
+		Description: "Total number of waits for a connection of database pools",
+		Measure:     DBWaitCount,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagDB},
+	}
+
+	// DBWaitDurationView reports the total time spent waiting for a connection, by database
+	DBWaitDurationView = &view.View{
+		Name:        "gql/deps/db_wait_duration",
+		Description: "Total time spent waiting for a connection of database pools",
+		Measure:     DBWaitDuration,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagDB},
+	}
+
+	// TagDB is the name of the database
+	TagDB = tag.MustNewKey("gql.db")
+)
@@ -0,0 +1,59 @@
+package gqldeps
+
+import (
+	"net/http"
+	"time"
+)
+
+type (
+	// Option for the container of dependencies
+	Option func(*config)
+
+	config struct {
+		maxOpen       int
+		maxIdle       int
+		maxLifetime   time.Duration
+		httpTimeout   time.Duration
+		transport     http.RoundTripper
+		statsInterval time.Duration
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		maxIdle:       2,
+		httpTimeout:   10 * time.Second,
+		statsInterval: 10 * time.Second,
+	}
+}
+
+// WithPool sets the pool settings of the databases opened with OpenDB. The default is the database/sql default:
+// unlimited open connections, 2 idle connections and no maximum lifetime.
+func WithPool(maxOpen, maxIdle int, maxLifetime time.Duration) Option {
+	return func(c *config) {
+		c.maxOpen = maxOpen
+		c.maxIdle = maxIdle
+		c.maxLifetime = maxLifetime
+	}
+}
+
+// WithHTTPTimeout sets the timeout of HTTP clients. The default is 10s.
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.httpTimeout = timeout
+	}
+}
+
+// WithTransport sets the base transport of HTTP clients. The default is http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithStatsInterval sets the interval at which Run records the statistics of database pools. The default is 10s.
+func WithStatsInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.statsInterval = interval
+	}
+}
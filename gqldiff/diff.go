@@ -0,0 +1,259 @@
+// Package gqldiff produces structural diffs of GraphQL responses.
+//
+// Diffs ignore the ordering of object keys and, by default, of list elements. Volatile fields, such as
+// timestamps or generated IDs, may be ignored as well.
+//
+// This is used by the shadow traffic extension, and may be used in integration tests when migrating schemas.
+package gqldiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Kind of change
+type Kind uint8
+
+const (
+	// Added value, present on the right side only
+	Added Kind = iota + 1
+
+	// Removed value, present on the left side only
+	Removed
+
+	// Changed value
+	Changed
+)
+
+// String representation of a kind of change
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a single difference between two responses.
+//
+// The path locates the change, e.g. "todos[1].user.name". Left and Right are the decoded JSON values.
+type Change struct {
+	Path  string
+	Kind  Kind
+	Left  interface{}
+	Right interface{}
+}
+
+// Compare two JSON documents, such as the data of two GraphQL responses.
+//
+// An empty document is equivalent to null.
+func Compare(left, right json.RawMessage, opts ...Option) ([]Change, error) {
+	l, err := decode(left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := decode(right)
+	if err != nil {
+		return nil, err
+	}
+	return Values(l, r, opts...), nil
+}
+
+// Values compares two decoded JSON values.
+//
+// Values are expected to be made of the types produced by encoding/json: maps, slices, strings, float64, bool and nil.
+func Values(left, right interface{}, opts ...Option) []Change {
+	d := differ{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(d.config)
+	}
+	d.compare("", left, right)
+	return d.changes
+}
+
+// Equal tells if two JSON documents are equivalent
+func Equal(left, right json.RawMessage, opts ...Option) (bool, error) {
+	changes, err := Compare(left, right, opts...)
+	if err != nil {
+		return false, err
+	}
+	return len(changes) == 0, nil
+}
+
+func decode(doc json.RawMessage) (interface{}, error) {
+	if len(bytes.TrimSpace(doc)) == 0 {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+type differ struct {
+	*config
+	changes []Change
+}
+
+func (d *differ) add(path string, kind Kind, left, right interface{}) {
+	d.changes = append(d.changes, Change{Path: path, Kind: kind, Left: left, Right: right})
+}
+
+func (d *differ) compare(path string, left, right interface{}) {
+	switch l := left.(type) {
+	case map[string]interface{}:
+		if r, ok := right.(map[string]interface{}); ok {
+			d.compareObjects(path, l, r)
+			return
+		}
+	case []interface{}:
+		if r, ok := right.([]interface{}); ok {
+			if d.config.ordered {
+				d.compareOrderedLists(path, l, r)
+			} else {
+				d.compareUnorderedLists(path, l, r)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		d.add(path, Changed, left, right)
+	}
+}
+
+func (d *differ) compareObjects(path string, left, right map[string]interface{}) {
+	keys := make([]string, 0, len(left)+len(right))
+	for k := range left {
+		keys = append(keys, k)
+	}
+	for k := range right {
+		if _, ok := left[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if d.config.ignored(k) {
+			continue
+		}
+		pth := joinField(path, k)
+		l, inLeft := left[k]
+		r, inRight := right[k]
+		switch {
+		case !inLeft:
+			d.add(pth, Added, nil, r)
+		case !inRight:
+			d.add(pth, Removed, l, nil)
+		default:
+			d.compare(pth, l, r)
+		}
+	}
+}
+
+func (d *differ) compareOrderedLists(path string, left, right []interface{}) {
+	for i := 0; i < len(left) || i < len(right); i++ {
+		pth := joinIndex(path, i)
+		switch {
+		case i >= len(left):
+			d.add(pth, Added, nil, right[i])
+		case i >= len(right):
+			d.add(pth, Removed, left[i], nil)
+		default:
+			d.compare(pth, left[i], right[i])
+		}
+	}
+}
+
+// compareUnorderedLists matches the elements of both lists regardless of their position.
+// Unmatched elements are reported as removed from the left list or added to the right list.
+func (d *differ) compareUnorderedLists(path string, left, right []interface{}) {
+	unmatched := make(map[string][]int, len(right))
+	for j, r := range right {
+		key := d.canonical(r)
+		unmatched[key] = append(unmatched[key], j)
+	}
+
+	matched := make([]bool, len(right))
+	for i, l := range left {
+		key := d.canonical(l)
+		if candidates := unmatched[key]; len(candidates) > 0 {
+			matched[candidates[0]] = true
+			unmatched[key] = candidates[1:]
+			continue
+		}
+		d.add(joinIndex(path, i), Removed, l, nil)
+	}
+
+	for j, r := range right {
+		if !matched[j] {
+			d.add(joinIndex(path, j), Added, nil, r)
+		}
+	}
+}
+
+// canonical representation of a value, without ignored fields
+func (d *differ) canonical(v interface{}) string {
+	buf, _ := json.Marshal(d.strip(v))
+	return string(buf)
+}
+
+func (d *differ) strip(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			if !d.config.ignored(k) {
+				stripped[k] = d.strip(e)
+			}
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(val))
+		canonicals := make([]string, len(val))
+		for i, e := range val {
+			stripped[i] = d.strip(e)
+			buf, _ := json.Marshal(stripped[i])
+			canonicals[i] = string(buf)
+		}
+		if !d.config.ordered {
+			sort.Sort(byCanonical{values: stripped, keys: canonicals})
+		}
+		return stripped
+	default:
+		return v
+	}
+}
+
+type byCanonical struct {
+	values []interface{}
+	keys   []string
+}
+
+func (b byCanonical) Len() int           { return len(b.values) }
+func (b byCanonical) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byCanonical) Swap(i, j int) {
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+func joinField(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func joinIndex(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
@@ -0,0 +1,69 @@
+package gqldiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	const left = `{"todos": [
+	  {"id": "1", "text": "A", "updatedAt": "2020-01-01", "user": {"name": "Name A"}},
+	  {"id": "2", "text": "B", "updatedAt": "2020-01-01", "user": {"name": "Name B"}}
+	]}`
+
+	t.Run("equal regardless of ordering", func(t *testing.T) {
+		const right = `{"todos": [
+		  {"user": {"name": "Name B"}, "updatedAt": "2020-01-01", "text": "B", "id": "2"},
+		  {"id": "1", "text": "A", "updatedAt": "2020-01-01", "user": {"name": "Name A"}}
+		]}`
+
+		ok, err := Equal(json.RawMessage(left), json.RawMessage(right))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		changes, err := Compare(json.RawMessage(left), json.RawMessage(right), Ordered(true))
+		require.NoError(t, err)
+		assert.NotEmpty(t, changes)
+	})
+
+	t.Run("ignore volatile fields", func(t *testing.T) {
+		const right = `{"todos": [
+		  {"id": "2", "text": "B", "updatedAt": "2020-02-02", "user": {"name": "Name B"}},
+		  {"id": "1", "text": "A", "updatedAt": "2020-02-02", "user": {"name": "Name A"}}
+		]}`
+
+		ok, err := Equal(json.RawMessage(left), json.RawMessage(right), IgnoreFields("updatedAt"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = Equal(json.RawMessage(left), json.RawMessage(right))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("report changes", func(t *testing.T) {
+		const right = `{"todos": [
+		  {"id": "1", "text": "A", "updatedAt": "2020-01-01", "user": {"name": "Name C"}},
+		  {"id": "2", "text": "B", "updatedAt": "2020-01-01", "user": {"name": "Name B"}, "done": true}
+		]}`
+
+		changes, err := Compare(json.RawMessage(left), json.RawMessage(right), Ordered(true))
+		require.NoError(t, err)
+		assert.Equal(t, []Change{
+			{Path: "todos[0].user.name", Kind: Changed, Left: "Name A", Right: "Name C"},
+			{Path: "todos[1].done", Kind: Added, Right: true},
+		}, changes)
+	})
+
+	t.Run("null and empty documents", func(t *testing.T) {
+		ok, err := Equal(nil, json.RawMessage(`null`))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = Compare(json.RawMessage(`{`), nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,40 @@
+package gqldiff
+
+type (
+	// Option for a diff
+	Option func(*config)
+
+	config struct {
+		ordered       bool
+		ignoredFields map[string]struct{}
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		ignoredFields: make(map[string]struct{}),
+	}
+}
+
+func (c config) ignored(field string) bool {
+	_, ok := c.ignoredFields[field]
+	return ok
+}
+
+// IgnoreFields ignores volatile fields, such as timestamps or generated IDs, at any depth in the responses.
+//
+// Fields are matched by their name (or alias) in the response.
+func IgnoreFields(fields ...string) Option {
+	return func(c *config) {
+		for _, field := range fields {
+			c.ignoredFields[field] = struct{}{}
+		}
+	}
+}
+
+// Ordered compares lists element by element, in order. By default, the ordering of list elements is ignored.
+func Ordered(enabled bool) Option {
+	return func(c *config) {
+		c.ordered = enabled
+	}
+}
@@ -0,0 +1,248 @@
+// Package gqldrain provides a gqlgen extension coordinating the graceful drain of in-flight operations,
+// e.g. when a server is shutting down.
+//
+// The Drainer tracks in-flight operations and subscriptions. When draining starts, new operations are rejected
+// with the code SERVER_SHUTTING_DOWN, and in-flight operations are given some time to complete. Stragglers
+// still running after this deadline are cancelled, and their responses get an error with the same code.
+//
+// Example:
+//
+//   drainer := gqldrain.New(gqldrain.WithTimeout(20 * time.Second))
+//   srv.Use(drainer)
+//
+//   go func() {
+//     if err := <-drainer.DrainOnSignal(syscall.SIGTERM); err != nil {
+//       log.Printf("drain: %v", err)
+//     }
+//     _ = httpServer.Shutdown(context.Background())
+//   }()
+//
+// Draining is complementary to http.Server.Shutdown, which does not wait for hijacked connections such as websockets.
+package gqldrain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
+	"github.com/99designs/gqlgen-contrib/gqlcode"
+)
+
+const extensionName = "Drainer"
+
+// CodeShuttingDown is the code of errors for operations rejected or cancelled by the drain
+const CodeShuttingDown = gqlcode.ServerShuttingDown
+
+// ErrForced is returned by Drain when stragglers had to be cancelled
+var ErrForced = errors.New("in-flight operations were cancelled after the drain timeout")
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Drainer{}
+
+type (
+	// Drainer is a gqlgen extension tracking in-flight operations, to drain them gracefully
+	Drainer struct {
+		*config
+
+		draining int32
+
+		mx       sync.Mutex
+		inflight map[*operation]struct{}
+		idle     chan struct{}
+	}
+
+	// operation in flight
+	operation struct {
+		cancel    context.CancelFunc
+		cancelled int32
+		once      sync.Once
+	}
+)
+
+// New Drainer extension
+func New(opts ...Option) *Drainer {
+	d := &Drainer{
+		config:   defaultConfig(),
+		inflight: make(map[*operation]struct{}),
+	}
+	for _, apply := range opts {
+		apply(d.config)
+	}
+	return d
+}
+
+// ExtensionName yields the extension name: "Drainer"
+func (*Drainer) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Drainer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Draining tells if the drain has started, e.g. for a readiness probe
+func (d *Drainer) Draining() bool {
+	return atomic.LoadInt32(&d.draining) == 1
+}
+
+// InFlight yields the number of in-flight operations and subscriptions
+func (d *Drainer) InFlight() int {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return len(d.inflight)
+}
+
+// InterceptOperation implements the gqlgen operation interceptor
+func (d *Drainer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	op, ctx := d.track(ctx)
+	if op == nil {
+		stats.Record(ctx, RejectedCount.M(1))
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{shuttingDown("the server is shutting down: retry later")}})
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	subscription := oc.Operation != nil && oc.Operation.Operation == ast.Subscription
+	if subscription {
+		// subscriptions end when the client goes away, without further calls to the response handler
+		go func() {
+			<-ctx.Done()
+			d.release(op)
+		}()
+	}
+
+	handler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if !subscription || resp == nil {
+			d.release(op)
+		}
+		if resp != nil && atomic.LoadInt32(&op.cancelled) == 1 {
+			resp.Errors = append(resp.Errors, shuttingDown("the operation was cancelled: the server is shutting down"))
+		}
+		return resp
+	}
+}
+
+// Drain stops accepting new operations, and waits for in-flight operations to complete, up to the drain timeout
+// or the deadline of the context. Stragglers are then cancelled, and given a grace period to respond.
+//
+// Drain returns ErrForced when stragglers were cancelled, and the error of the context if it is done
+// before the end of the grace period.
+func (d *Drainer) Drain(ctx context.Context) error {
+	idle := d.startDraining()
+
+	timeout := time.NewTimer(d.config.timeout)
+	defer timeout.Stop()
+	select {
+	case <-idle:
+		return nil
+	case <-timeout.C:
+	case <-ctx.Done():
+	}
+
+	stragglers := d.cancelAll()
+	stats.Record(context.Background(), ForcedCount.M(int64(stragglers)))
+
+	grace := time.NewTimer(d.config.grace)
+	defer grace.Stop()
+	select {
+	case <-idle:
+		return ErrForced
+	case <-grace.C:
+		return ErrForced
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DrainOnSignal starts to drain when one of these signals is received, e.g. syscall.SIGTERM.
+// The result of Drain is sent on the returned channel.
+func (d *Drainer) DrainOnSignal(signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt}
+	}
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+
+	result := make(chan error, 1)
+	go func() {
+		<-received
+		signal.Stop(received)
+		result <- d.Drain(context.Background())
+	}()
+	return result
+}
+
+// track registers a new operation, unless draining
+func (d *Drainer) track(ctx context.Context) (*operation, context.Context) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if d.Draining() {
+		return nil, ctx
+	}
+
+	op := &operation{}
+	ctx, op.cancel = gqlcancel.WithCancel(ctx)
+	d.inflight[op] = struct{}{}
+	stats.Record(ctx, InFlightCount.M(int64(len(d.inflight))))
+	return op, ctx
+}
+
+func (d *Drainer) release(op *operation) {
+	op.once.Do(func() {
+		d.mx.Lock()
+		defer d.mx.Unlock()
+		delete(d.inflight, op)
+		op.cancel()
+		stats.Record(context.Background(), InFlightCount.M(int64(len(d.inflight))))
+		if len(d.inflight) == 0 && d.idle != nil {
+			close(d.idle)
+			d.idle = nil
+		}
+	})
+}
+
+// startDraining rejects new operations, and yields a channel closed when no operation is in flight
+func (d *Drainer) startDraining() <-chan struct{} {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	atomic.StoreInt32(&d.draining, 1)
+
+	idle := make(chan struct{})
+	if len(d.inflight) == 0 {
+		close(idle)
+		return idle
+	}
+	d.idle = idle
+	return idle
+}
+
+func (d *Drainer) cancelAll() int {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for op := range d.inflight {
+		atomic.StoreInt32(&op.cancelled, 1)
+		op.cancel()
+	}
+	return len(d.inflight)
+}
+
+func shuttingDown(message string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": CodeShuttingDown},
+	}
+}
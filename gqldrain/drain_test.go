@@ -0,0 +1,82 @@
+package gqldrain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func query() context.Context {
+	return graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+}
+
+func TestDrain(t *testing.T) {
+	d := New(WithTimeout(200*time.Millisecond), WithGracePeriod(time.Second))
+
+	// an operation completing in time, and a straggler waiting for its context to be cancelled
+	release := make(chan struct{})
+	responses := make(chan *graphql.Response, 2)
+	started := make(chan struct{}, 2)
+	for _, straggler := range []bool{false, true} {
+		straggler := straggler
+		handler := d.InterceptOperation(query(), func(ctx context.Context) graphql.ResponseHandler {
+			return func(_ context.Context) *graphql.Response {
+				started <- struct{}{}
+				if straggler {
+					<-ctx.Done()
+				} else {
+					<-release
+				}
+				return &graphql.Response{}
+			}
+		})
+		go func() {
+			responses <- handler(context.Background())
+		}()
+	}
+	<-started
+	<-started
+	assert.Equal(t, 2, d.InFlight())
+
+	result := make(chan error)
+	go func() {
+		result <- d.Drain(context.Background())
+	}()
+	for !d.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// new operations are rejected, with a single response
+	rejected := d.InterceptOperation(query(), nil)
+	resp := rejected(context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, CodeShuttingDown, resp.Errors[0].Extensions["code"])
+	assert.Nil(t, rejected(context.Background()), "the websocket transport pulls responses until it gets nil")
+
+	close(release)
+	assert.Empty(t, (<-responses).Errors)
+
+	assert.Equal(t, ErrForced, <-result)
+	resp = <-responses
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, CodeShuttingDown, resp.Errors[0].Extensions["code"])
+	assert.Equal(t, 0, d.InFlight())
+}
+
+func TestDrainIdle(t *testing.T) {
+	d := New()
+	handler := d.InterceptOperation(query(), func(ctx context.Context) graphql.ResponseHandler {
+		return func(_ context.Context) *graphql.Response {
+			return &graphql.Response{}
+		}
+	})
+	handler(context.Background())
+	require.NoError(t, d.Drain(context.Background()))
+}
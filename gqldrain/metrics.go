@@ -0,0 +1,71 @@
+package gqldrain
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(DrainViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(DrainViews...)
+}
+
+var (
+	// DrainViews contains all opencensus stats views declared by the drainer
+	DrainViews = []*view.View{
+		InFlightCountView,
+		RejectedCountView,
+		ForcedCountView,
+	}
+
+	// InFlightCount tracks the number of in-flight operations and subscriptions
+	InFlightCount = stats.Int64(
+		"gql/server/inflight_count",
+		"Number of in-flight GraphQL operations",
+		stats.UnitDimensionless)
+
+	// RejectedCount tracks a count of operations rejected while draining
+	RejectedCount = stats.Int64(
+		"gql/server/drain_rejected_count",
+		"Number of GraphQL operations rejected while draining",
+		stats.UnitDimensionless)
+
+	// ForcedCount tracks the number of in-flight operations cancelled after the drain timeout
+	ForcedCount = stats.Int64(
+		"gql/server/drain_forced_count",
+		"Number of GraphQL operations cancelled after the drain timeout",
+		stats.UnitDimensionless)
+
+	// InFlightCountView reports the last number of in-flight operations
+	InFlightCountView = &view.View{
+		Name:        "gql/server/inflight_count",
+		Description: "Number of in-flight GraphQL operations and subscriptions",
+		Measure:     InFlightCount,
+		Aggregation: view.LastValue(),
+	}
+
+	// RejectedCountView reports a count of operations rejected while draining
+	RejectedCountView = &view.View{
+		Name:        "gql/server/drain_rejected_count",
+		Description: "Count of GraphQL operations rejected while draining",
+		Measure:     RejectedCount,
+		Aggregation: view.Count(),
+	}
+
+	// ForcedCountView reports the number of operations cancelled after the drain timeout
+	ForcedCountView = &view.View{
+		Name:        "gql/server/drain_forced_count",
+		Description: "Number of GraphQL operations cancelled after the drain timeout",
+		Measure:     ForcedCount,
+		Aggregation: view.Sum(),
+	}
+)
@@ -0,0 +1,36 @@
+package gqldrain
+
+import (
+	"time"
+)
+
+type (
+	// Option for the drainer
+	Option func(*config)
+
+	config struct {
+		timeout time.Duration
+		grace   time.Duration
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		timeout: 30 * time.Second,
+		grace:   time.Second,
+	}
+}
+
+// WithTimeout sets the time given to in-flight operations to complete once draining starts. The default is 30s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithGracePeriod sets the time given to cancelled stragglers to respond. The default is 1s.
+func WithGracePeriod(grace time.Duration) Option {
+	return func(c *config) {
+		c.grace = grace
+	}
+}
@@ -0,0 +1,209 @@
+// Package gqlexperiment provides a gqlgen extension assigning operations to the buckets of A/B experiments.
+//
+// Each operation is assigned a bucket per experiment, by a hash of its subject (e.g. the user or the client),
+// so that a subject stays in the same bucket across operations. Resolvers retrieve the bucket from the context
+// to pick an implementation, and the bucket is exposed as attributes of the trace span of the operation and as
+// tags of the latency and error metrics, so that implementations can be compared bucket per bucket.
+//
+// Example:
+//
+//   srv.Use(gqlopencensus.New())
+//   srv.Use(gqlexperiment.New(
+//     gqlexperiment.WithExperiment("search-v2", gqlexperiment.Bucket{Name: "control", Weight: 90}, gqlexperiment.Bucket{Name: "v2", Weight: 10}),
+//     gqlexperiment.WithSubject(func(ctx context.Context) string { return auth.ForContext(ctx).UserID }),
+//   ))
+//
+// In resolvers:
+//
+//   if gqlexperiment.BucketFor(ctx, "search-v2") == "v2" {
+//     return r.searchV2(ctx, query)
+//   }
+//
+// Register this extension after the tracer, so that it annotates the span of the operation.
+package gqlexperiment
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "Experiments"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = &Extension{}
+
+type (
+	// Bucket of an experiment. Subjects are spread over buckets in proportion of their weight.
+	Bucket struct {
+		Name   string
+		Weight int
+	}
+
+	// Experiment with its buckets
+	Experiment struct {
+		Name    string
+		Buckets []Bucket
+	}
+
+	// Assignment of an operation to the bucket of an experiment
+	Assignment struct {
+		Experiment string
+		Bucket     string
+	}
+
+	// Extension is a gqlgen extension assigning operations to experiment buckets
+	Extension struct {
+		*config
+	}
+
+	assignmentsKey struct{}
+)
+
+// New experiment extension
+func New(opts ...Option) *Extension {
+	e := &Extension{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(e.config)
+	}
+	return e
+}
+
+// ExtensionName yields the extension name: "Experiments"
+func (*Extension) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the experiments: all experiments must have buckets with a positive total weight
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	for _, experiment := range e.config.experiments {
+		total := 0
+		for _, bucket := range experiment.Buckets {
+			if bucket.Weight < 0 {
+				return fmt.Errorf("experiment %q: negative weight for bucket %q", experiment.Name, bucket.Name)
+			}
+			total += bucket.Weight
+		}
+		if total == 0 {
+			return fmt.Errorf("experiment %q has no weighted bucket", experiment.Name)
+		}
+	}
+	return nil
+}
+
+// InterceptOperation assigns the operation to a bucket of each experiment.
+//
+// Operations without a subject are not enrolled in experiments.
+func (e *Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	subject := e.config.subject(ctx)
+	if subject == "" || len(e.config.experiments) == 0 {
+		return next(ctx)
+	}
+
+	assignments := make([]Assignment, 0, len(e.config.experiments))
+	for _, experiment := range e.config.experiments {
+		assignments = append(assignments, Assignment{
+			Experiment: experiment.Name,
+			Bucket:     experiment.Assign(subject),
+		})
+	}
+	return next(context.WithValue(ctx, assignmentsKey{}, assignments))
+}
+
+// InterceptResponse annotates the trace span of the operation with its buckets, and records the latency
+// and errors of the operation per bucket
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	assignments := Assignments(ctx)
+	if len(assignments) == 0 {
+		return next(ctx)
+	}
+
+	if span := trace.FromContext(ctx); span != nil && span.IsRecordingEvents() {
+		attributes := make([]trace.Attribute, 0, len(assignments))
+		for _, assignment := range assignments {
+			attributes = append(attributes, trace.StringAttribute("experiment."+assignment.Experiment, assignment.Bucket))
+		}
+		span.AddAttributes(attributes...)
+	}
+
+	start := e.config.clock()
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	elapsed := float64(e.config.clock().Sub(start)) / float64(time.Millisecond)
+	operation := e.config.opLabel(graphql.GetOperationContext(ctx))
+	measurements := []stats.Measurement{ExperimentLatency.M(elapsed)}
+	if len(resp.Errors) > 0 {
+		measurements = append(measurements, ExperimentErrors.M(1))
+	}
+	for _, assignment := range assignments {
+		mutators := append(Mutators(assignment), tag.Upsert(metrics.TagOperation, operation))
+		_ = stats.RecordWithTags(ctx, mutators, measurements...)
+	}
+	return resp
+}
+
+// Assign a subject to a bucket of the experiment. Assignments are stable, and independent across experiments.
+func (x Experiment) Assign(subject string) string {
+	total := 0
+	for _, bucket := range x.Buckets {
+		total += bucket.Weight
+	}
+	if total <= 0 {
+		return ""
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(x.Name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(subject))
+	point := int(h.Sum64() % uint64(total))
+
+	for _, bucket := range x.Buckets {
+		if point < bucket.Weight {
+			return bucket.Name
+		}
+		point -= bucket.Weight
+	}
+	return ""
+}
+
+// Assignments yields the buckets of the operation of a context
+func Assignments(ctx context.Context) []Assignment {
+	assignments, _ := ctx.Value(assignmentsKey{}).([]Assignment)
+	return assignments
+}
+
+// BucketFor yields the bucket of the operation of a context in an experiment, or an empty string when the
+// operation is not enrolled in the experiment
+func BucketFor(ctx context.Context, experiment string) string {
+	for _, assignment := range Assignments(ctx) {
+		if assignment.Experiment == experiment {
+			return assignment.Bucket
+		}
+	}
+	return ""
+}
+
+// Mutators yields the tag mutators of an assignment, to tag application metrics by bucket
+func Mutators(assignment Assignment) []tag.Mutator {
+	return []tag.Mutator{
+		tag.Upsert(TagExperiment, assignment.Experiment),
+		tag.Upsert(TagBucket, assignment.Bucket),
+	}
+}
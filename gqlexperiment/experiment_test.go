@@ -0,0 +1,59 @@
+package gqlexperiment
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type subjectKey struct{}
+
+func TestAssign(t *testing.T) {
+	x := Experiment{Name: "search-v2", Buckets: []Bucket{{Name: "control", Weight: 75}, {Name: "v2", Weight: 25}}}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		subject := "user-" + strconv.Itoa(i)
+		bucket := x.Assign(subject)
+		require.Equal(t, bucket, x.Assign(subject), "assignments must be stable")
+		counts[bucket]++
+	}
+	assert.InDelta(t, 7500, counts["control"], 300)
+	assert.InDelta(t, 2500, counts["v2"], 300)
+
+	assert.Empty(t, Experiment{Name: "empty"}.Assign("user-1"))
+}
+
+func TestExtension(t *testing.T) {
+	e := New(
+		WithExperiment("search-v2", Bucket{Name: "control", Weight: 1}, Bucket{Name: "v2", Weight: 1}),
+		WithExperiment("ranking", Bucket{Name: "only", Weight: 1}),
+		WithSubject(func(ctx context.Context) string {
+			subject, _ := ctx.Value(subjectKey{}).(string)
+			return subject
+		}),
+	)
+	require.NoError(t, e.Validate(nil))
+
+	var search, ranking string
+	resolve := func(ctx context.Context) graphql.ResponseHandler {
+		search = BucketFor(ctx, "search-v2")
+		ranking = BucketFor(ctx, "ranking")
+		return nil
+	}
+
+	e.InterceptOperation(context.WithValue(context.Background(), subjectKey{}, "user-1"), resolve)
+	assert.Equal(t, e.config.experiments[0].Assign("user-1"), search)
+	assert.Equal(t, "only", ranking)
+
+	e.InterceptOperation(context.Background(), resolve)
+	assert.Empty(t, search, "operations without a subject are not enrolled")
+	assert.Empty(t, ranking)
+
+	invalid := New(WithExperiment("invalid", Bucket{Name: "none"}))
+	assert.Error(t, invalid.Validate(nil))
+}
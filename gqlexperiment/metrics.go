@@ -0,0 +1,66 @@
+package gqlexperiment
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(ExperimentViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(ExperimentViews...)
+}
+
+var (
+	// ExperimentViews contains all opencensus stats views declared by the experiment extension
+	ExperimentViews = []*view.View{
+		ExperimentLatencyView,
+		ExperimentErrorsView,
+	}
+
+	// ExperimentLatency tracks the latency of operations enrolled in experiments
+	ExperimentLatency = stats.Float64(
+		"gql/server/experiment_latency",
+		"Latency of GraphQL operations enrolled in experiments",
+		stats.UnitMilliseconds)
+
+	// ExperimentErrors counts the operations enrolled in experiments which returned errors
+	ExperimentErrors = stats.Int64(
+		"gql/server/experiment_errors",
+		"Number of GraphQL operations enrolled in experiments which returned errors",
+		stats.UnitDimensionless)
+
+	// ExperimentLatencyView reports a distribution of the latency of operations, by experiment bucket and operation
+	ExperimentLatencyView = &view.View{
+		Name:        "gql/server/experiment_latency",
+		Description: "Distribution of the latency of GraphQL operations by experiment bucket",
+		Measure:     ExperimentLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagExperiment, TagBucket, metrics.TagOperation},
+	}
+
+	// ExperimentErrorsView reports the count of operations which returned errors, by experiment bucket and operation
+	ExperimentErrorsView = &view.View{
+		Name:        "gql/server/experiment_errors",
+		Description: "Count of GraphQL operations which returned errors by experiment bucket",
+		Measure:     ExperimentErrors,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagExperiment, TagBucket, metrics.TagOperation},
+	}
+
+	// TagExperiment is the name of the experiment
+	TagExperiment = tag.MustNewKey("gql.experiment")
+
+	// TagBucket is the bucket of the experiment
+	TagBucket = tag.MustNewKey("gql.experiment_bucket")
+)
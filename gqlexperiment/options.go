@@ -0,0 +1,62 @@
+package gqlexperiment
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlctx"
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the experiment extension
+	Option func(*config)
+
+	config struct {
+		experiments []Experiment
+		subject     func(context.Context) string
+		opLabel     gqllabel.OperationLabeler
+		clock       func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		subject: gqlctx.StringFunc(gqlctx.Client, ""),
+		opLabel: gqllabel.OperationName,
+		clock:   graphql.Now,
+	}
+}
+
+// WithExperiment adds an experiment, with its buckets
+func WithExperiment(name string, buckets ...Bucket) Option {
+	return func(c *config) {
+		c.experiments = append(c.experiments, Experiment{Name: name, Buckets: buckets})
+	}
+}
+
+// WithSubject sets the function identifying the subject of an operation, e.g. the user.
+// By default, the subject is the client stored in the operation bag (see gqlctx.Client).
+func WithSubject(subject func(context.Context) string) Option {
+	return func(c *config) {
+		c.subject = subject
+	}
+}
+
+// WithOperationLabel sets the function identifying operations. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of metrics.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
+
+// WithClock sets the clock, e.g. for tests. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,172 @@
+// Package gqlfederation provides a gqlgen extension instrumenting the resolution of entities by federated subgraphs.
+//
+// The gateway fetches entities from a subgraph with the _entities field, passing a list of representations.
+// This dominates the performance of subgraphs, but is hardly visible in generic field metrics.
+// The extension records, by entity type:
+//
+//   - the number of representations requested
+//   - the number of entities which failed to be resolved
+//
+// as well as the latency of _entities fetches, by operation.
+//
+// Entity resolvers are called directly by the code generated by gqlgen, and cannot be intercepted.
+// Their latency is recorded with TimeEntity:
+//
+//   func (r *entityResolver) FindUserByID(ctx context.Context, id string) (*User, error) {
+//     defer gqlfederation.TimeEntity(ctx, "User")()
+//     return r.users.Get(ctx, id)
+//   }
+//
+// See also EntityAttributer, which adds federation attributes to trace spans.
+package gqlfederation
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "FederationMetrics"
+
+const (
+	entitiesField      = "_entities"
+	representationsArg = "representations"
+	typenameKey        = "__typename"
+	unknownEntityType  = "-"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Collector{}
+
+// Collector is a gqlgen extension collecting metrics on the resolution of federated entities
+type Collector struct {
+	*config
+}
+
+// New federation metrics Collector
+func New(opts ...Option) *Collector {
+	c := &Collector{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "FederationMetrics"
+func (*Collector) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Collector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (c *Collector) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !isEntitiesField(fc) {
+		return next(ctx)
+	}
+
+	types := representationTypes(fc.Args)
+	for typeName, count := range countTypes(types) {
+		_ = stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(TagEntityType, typeName)},
+			EntityRepresentationCount.M(count),
+		)
+	}
+
+	start := c.config.clock()
+	res, err := next(ctx)
+	latency := c.config.clock().Sub(start)
+
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.TagOperation, c.config.opLabel(graphql.GetOperationContext(ctx)))},
+		EntitiesLatency.M(float64(latency)/float64(time.Millisecond)),
+	)
+
+	for typeName, count := range countTypes(failedTypes(types, res, err)) {
+		_ = stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(TagEntityType, typeName)},
+			EntityErrorCount.M(count),
+		)
+	}
+
+	return res, err
+}
+
+// TimeEntity records the latency of the resolution of an entity by an entity resolver.
+// Call the returned function when the entity is resolved.
+func TimeEntity(ctx context.Context, typeName string) func() {
+	start := graphql.Now()
+	return func() {
+		_ = stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(TagEntityType, typeName)},
+			EntityResolutionLatency.M(float64(graphql.Now().Sub(start))/float64(time.Millisecond)),
+		)
+	}
+}
+
+func isEntitiesField(fc *graphql.FieldContext) bool {
+	return fc != nil && fc.Object == "Query" && fc.Field.Field != nil && fc.Field.Name == entitiesField
+}
+
+// representationTypes yields the type of each representation passed to _entities
+func representationTypes(args map[string]interface{}) []string {
+	representations, _ := args[representationsArg].([]map[string]interface{})
+	types := make([]string, len(representations))
+	for i, representation := range representations {
+		typeName, _ := representation[typenameKey].(string)
+		if typeName == "" {
+			typeName = unknownEntityType
+		}
+		types[i] = typeName
+	}
+	return types
+}
+
+// failedTypes yields the types of the representations which failed to be resolved.
+//
+// When _entities fails, no entity is resolved. Otherwise, entities which failed are resolved as nil.
+func failedTypes(types []string, res interface{}, err error) []string {
+	if err != nil {
+		return types
+	}
+
+	rv := reflect.ValueOf(res)
+	if rv.Kind() != reflect.Slice {
+		return types
+	}
+
+	var failed []string
+	for i, typeName := range types {
+		if i >= rv.Len() {
+			failed = append(failed, typeName)
+			continue
+		}
+		elem := rv.Index(i)
+		if (elem.Kind() == reflect.Interface || elem.Kind() == reflect.Ptr) && elem.IsNil() {
+			failed = append(failed, typeName)
+		}
+	}
+	return failed
+}
+
+func countTypes(types []string) map[string]int64 {
+	counts := make(map[string]int64, len(types))
+	for _, typeName := range types {
+		counts[typeName]++
+	}
+	return counts
+}
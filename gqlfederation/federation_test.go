@@ -0,0 +1,95 @@
+package gqlfederation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+func TestEntityTypes(t *testing.T) {
+	types := representationTypes(map[string]interface{}{
+		representationsArg: []map[string]interface{}{
+			{typenameKey: "User", "id": "1"},
+			{typenameKey: "Product", "upc": "2"},
+			{typenameKey: "User", "id": "3"},
+			{"id": "4"},
+		},
+	})
+	assert.Equal(t, []string{"User", "Product", "User", unknownEntityType}, types)
+	assert.Equal(t, map[string]int64{"User": 2, "Product": 1, unknownEntityType: 1}, countTypes(types))
+
+	type user struct{}
+	entities := []interface{}{&user{}, nil, &user{}}
+	assert.Equal(t, []string{"Product", unknownEntityType}, failedTypes(types, entities, nil))
+	assert.Equal(t, types, failedTypes(types, nil, errors.New("failed")))
+	assert.Empty(t, failedTypes(types, []interface{}{&user{}, &user{}, &user{}, &user{}}, nil))
+}
+
+func TestEntityAttributer(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: `
+directive @key(fields: String!) on OBJECT | INTERFACE
+directive @requires(fields: String!) on FIELD_DEFINITION
+directive @provides(fields: String!) on FIELD_DEFINITION
+directive @external on FIELD_DEFINITION
+
+type Query {
+  me: User @provides(fields: "name")
+}
+
+type User @key(fields: "id") @key(fields: "org { id } login") {
+  id: ID!
+  login: String! @external
+  org: Org! @external
+  name: String @external
+  weight: Int @external
+  shipping: Int @requires(fields: "weight")
+}
+
+type Org {
+  id: ID!
+}
+`})
+	require.Nil(t, gqlErr)
+
+	a := NewEntityAttributer(schema)
+	assert.Equal(t, map[string]bool{"id": true, "org": true, "login": true, "weight": true}, a.expected["User"])
+
+	entities := &graphql.FieldContext{
+		Object: "Query",
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: entitiesField}},
+		Args: map[string]interface{}{
+			representationsArg: []map[string]interface{}{
+				{typenameKey: "User", "id": "1", "weight": 10},
+				{typenameKey: "User", "id": "2", "name": "bob"},
+			},
+		},
+	}
+	attrs := attributeMap(a.Attributes(entities))
+	assert.Equal(t, int64(2), attrs["federation.representations"])
+	assert.Equal(t, "User", attrs["federation.entity_types"])
+	assert.Equal(t, int64(2), attrs["federation.User.count"])
+	assert.Equal(t, "id,name,weight", attrs["federation.User.fields"])
+	assert.Equal(t, "name", attrs["federation.User.unexpected"])
+
+	me := &graphql.FieldContext{
+		Object: "Query",
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: "me", Definition: schema.Query.Fields.ForName("me")}},
+	}
+	assert.Equal(t, map[string]interface{}{"federation.provides": "name"}, attributeMap(a.Attributes(me)))
+}
+
+// attributeMap yields attributes by key
+func attributeMap(attrs []gqlattr.KeyValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key] = attr.Value
+	}
+	return m
+}
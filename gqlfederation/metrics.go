@@ -0,0 +1,95 @@
+package gqlfederation
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(FederationViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(FederationViews...)
+}
+
+var (
+	// FederationViews contains all opencensus stats views declared by the federation extension
+	FederationViews = []*view.View{
+		EntityRepresentationCountView,
+		EntityErrorCountView,
+		EntityResolutionLatencyView,
+		EntitiesLatencyView,
+	}
+
+	// EntityRepresentationCount tracks a count of entity representations requested to the subgraph
+	EntityRepresentationCount = stats.Int64(
+		"gql/federation/representation_count",
+		"Number of entity representations requested",
+		stats.UnitDimensionless)
+
+	// EntityErrorCount tracks a count of entities which failed to be resolved
+	EntityErrorCount = stats.Int64(
+		"gql/federation/entity_error_count",
+		"Number of entities which failed to be resolved",
+		stats.UnitDimensionless)
+
+	// EntityResolutionLatency tracks the resolution time of single entities, in milliseconds
+	EntityResolutionLatency = stats.Float64(
+		"gql/federation/entity_latency",
+		"Entity resolution latency",
+		stats.UnitMilliseconds)
+
+	// EntitiesLatency tracks the execution time of _entities fetches, in milliseconds
+	EntitiesLatency = stats.Float64(
+		"gql/federation/entities_latency",
+		"Entities fetch latency",
+		stats.UnitMilliseconds)
+
+	// EntityRepresentationCountView reports the number of representations requested, by entity type
+	EntityRepresentationCountView = &view.View{
+		Name:        "gql/federation/representation_count",
+		Description: "Number of entity representations requested by entity type",
+		Measure:     EntityRepresentationCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagEntityType},
+	}
+
+	// EntityErrorCountView reports the number of entities which failed to be resolved, by entity type
+	EntityErrorCountView = &view.View{
+		Name:        "gql/federation/entity_error_count",
+		Description: "Number of entities which failed to be resolved by entity type",
+		Measure:     EntityErrorCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagEntityType},
+	}
+
+	// EntityResolutionLatencyView reports a distribution of the resolution time of entities, by entity type (in milliseconds)
+	EntityResolutionLatencyView = &view.View{
+		Name:        "gql/federation/entity_latency",
+		Description: "Resolution time distribution of entities by entity type",
+		Measure:     EntityResolutionLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagEntityType},
+	}
+
+	// EntitiesLatencyView reports a distribution of the execution time of _entities fetches, by operation (in milliseconds)
+	EntitiesLatencyView = &view.View{
+		Name:        "gql/federation/entities_latency",
+		Description: "Execution time distribution of _entities fetches by operation",
+		Measure:     EntitiesLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// TagEntityType is the type of a federated entity
+	TagEntityType = tag.MustNewKey("gql.entity_type")
+)
@@ -0,0 +1,33 @@
+package gqlfederation
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the federation extension
+	Option func(*config)
+
+	config struct {
+		opLabel gqllabel.OperationLabeler
+		clock   func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		opLabel: gqllabel.OperationName,
+		clock:   graphql.Now,
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
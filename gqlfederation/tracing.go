@@ -0,0 +1,172 @@
+package gqlfederation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+// EntityAttributer produces span attributes describing the representations provided by the gateway to
+// the _entities field, and the @requires and @provides directives of other fields.
+//
+// This helps debugging over-fetching and mismatched federation directives. Use it with the opencensus tracer:
+//
+//   tracer := gqlopencensus.New(
+//     gqlopencensus.WithFieldKeyValues(gqlfederation.NewEntityAttributer(es.Schema()).Attributes),
+//   )
+//
+// The span of _entities gets, for each entity type:
+//
+//   - "federation.<Type>.count": the number of representations of this type
+//   - "federation.<Type>.fields": the fields provided in representations, e.g. "id,upc,weight"
+//   - "federation.<Type>.unexpected": provided fields which are neither a @key nor @requires by the subgraph
+//
+// Spans of fields with @requires or @provides get the attributes "federation.requires" and "federation.provides".
+type EntityAttributer struct {
+	// expected fields in representations, by entity type
+	expected map[string]map[string]bool
+}
+
+// NewEntityAttributer builds an EntityAttributer for a subgraph schema
+func NewEntityAttributer(schema *ast.Schema) *EntityAttributer {
+	a := &EntityAttributer{
+		expected: make(map[string]map[string]bool),
+	}
+	for _, def := range schema.Types {
+		if def.Kind != ast.Object || def.Directives.ForName("key") == nil {
+			continue
+		}
+		expected := make(map[string]bool)
+		for _, d := range def.Directives {
+			if d.Name == "key" {
+				addFieldSet(expected, fieldSet(d))
+			}
+		}
+		for _, field := range def.Fields {
+			if d := field.Directives.ForName("requires"); d != nil {
+				addFieldSet(expected, fieldSet(d))
+			}
+		}
+		a.expected[def.Name] = expected
+	}
+	return a
+}
+
+// Attributes of the span of a field. This function has the signature of a gqlopencensus.FieldKeyValuer.
+func (a *EntityAttributer) Attributes(fc *graphql.FieldContext) []gqlattr.KeyValue {
+	if fc == nil || fc.Field.Field == nil {
+		return nil
+	}
+	if isEntitiesField(fc) {
+		return a.entitiesAttributes(fc)
+	}
+	if fc.Field.Definition == nil {
+		return nil
+	}
+
+	var attrs []gqlattr.KeyValue
+	if d := fc.Field.Definition.Directives.ForName("requires"); d != nil {
+		attrs = append(attrs, gqlattr.KeyValue{Key: "federation.requires", Value: fieldSet(d)})
+	}
+	if d := fc.Field.Definition.Directives.ForName("provides"); d != nil {
+		attrs = append(attrs, gqlattr.KeyValue{Key: "federation.provides", Value: fieldSet(d)})
+	}
+	return attrs
+}
+
+func (a *EntityAttributer) entitiesAttributes(fc *graphql.FieldContext) []gqlattr.KeyValue {
+	representations, _ := fc.Args[representationsArg].([]map[string]interface{})
+
+	counts := make(map[string]int64)
+	provided := make(map[string]map[string]bool)
+	for _, representation := range representations {
+		typeName, _ := representation[typenameKey].(string)
+		if typeName == "" {
+			typeName = unknownEntityType
+		}
+		counts[typeName]++
+		if provided[typeName] == nil {
+			provided[typeName] = make(map[string]bool)
+		}
+		for key := range representation {
+			if key != typenameKey {
+				provided[typeName][key] = true
+			}
+		}
+	}
+
+	types := make([]string, 0, len(counts))
+	for typeName := range counts {
+		types = append(types, typeName)
+	}
+	sort.Strings(types)
+
+	attrs := make([]gqlattr.KeyValue, 0, 2+3*len(types))
+	attrs = append(attrs,
+		gqlattr.KeyValue{Key: "federation.representations", Value: int64(len(representations))},
+		gqlattr.KeyValue{Key: "federation.entity_types", Value: strings.Join(types, ",")},
+	)
+	for _, typeName := range types {
+		prefix := "federation." + typeName + "."
+		attrs = append(attrs,
+			gqlattr.KeyValue{Key: prefix + "count", Value: counts[typeName]},
+			gqlattr.KeyValue{Key: prefix + "fields", Value: strings.Join(sortedKeys(provided[typeName]), ",")},
+		)
+
+		expected, known := a.expected[typeName]
+		if !known {
+			continue
+		}
+		var unexpected []string
+		for _, key := range sortedKeys(provided[typeName]) {
+			if !expected[key] {
+				unexpected = append(unexpected, key)
+			}
+		}
+		if len(unexpected) > 0 {
+			attrs = append(attrs, gqlattr.KeyValue{Key: prefix + "unexpected", Value: strings.Join(unexpected, ",")})
+		}
+	}
+	return attrs
+}
+
+// fieldSet yields the "fields" argument of a federation directive, e.g. "id sku"
+func fieldSet(d *ast.Directive) string {
+	arg := d.Arguments.ForName("fields")
+	if arg == nil || arg.Value == nil {
+		return ""
+	}
+	return arg.Value.Raw
+}
+
+// addFieldSet adds the top-level fields of a field set, e.g. "id organization { id }" yields id and organization
+func addFieldSet(fields map[string]bool, set string) {
+	var depth int
+	for _, token := range strings.FieldsFunc(strings.NewReplacer("{", " { ", "}", " } ").Replace(set), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\n' || r == '\t'
+	}) {
+		switch token {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		default:
+			if depth == 0 {
+				fields[token] = true
+			}
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,171 @@
+package gqlfingerprint
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type (
+	// Occurrence of errors with the same fingerprint during a window
+	Occurrence struct {
+		Fingerprint string
+
+		// Error is the first error with this fingerprint during the window
+		Error error
+
+		// Occurrences counts the errors with this fingerprint during the window
+		Occurrences int64
+
+		First time.Time
+		Last  time.Time
+
+		// Summary is true for the reports at the end of a window, of occurrences which were suppressed
+		Summary bool
+	}
+
+	// Deduplicator groups errors by fingerprint, and reports them to handlers without flooding them.
+	//
+	// The first occurrence of each fingerprint during a window is reported immediately, up to a maximum
+	// number of reports per window. Other occurrences are suppressed, and summarized at the end of the window.
+	Deduplicator struct {
+		*config
+
+		mx          sync.Mutex
+		occurrences map[string]*occurrence
+		reports     int
+	}
+
+	occurrence struct {
+		Occurrence
+		reported int64
+	}
+)
+
+// New Deduplicator of errors
+func New(opts ...Option) *Deduplicator {
+	d := &Deduplicator{
+		config:      defaultConfig(),
+		occurrences: make(map[string]*occurrence),
+	}
+	for _, apply := range opts {
+		apply(d.config)
+	}
+	return d
+}
+
+// Observe an error, and report it to the handlers unless it is suppressed.
+//
+// It yields the fingerprint of the error, and tells if this occurrence was reported.
+func (d *Deduplicator) Observe(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	fingerprint := Fingerprint(err)
+	now := d.config.clock()
+
+	d.mx.Lock()
+	o, ok := d.occurrences[fingerprint]
+	if !ok {
+		o = &occurrence{Occurrence: Occurrence{Fingerprint: fingerprint, Error: err, First: now}}
+		d.occurrences[fingerprint] = o
+	}
+	o.Occurrences++
+	o.Last = now
+
+	report := !ok && (d.config.maxReports <= 0 || d.reports < d.config.maxReports)
+	var reported Occurrence
+	if report {
+		d.reports++
+		o.reported = o.Occurrences
+		reported = o.Occurrence
+	}
+	d.mx.Unlock()
+
+	if report {
+		d.report(reported)
+	}
+	return fingerprint, report
+}
+
+// Run ends windows on an interval, until the context is done. Suppressed occurrences are summarized before returning.
+func (d *Deduplicator) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.Flush()
+			return
+		case <-ticker.C:
+			d.Flush()
+		}
+	}
+}
+
+// Flush ends the current window: the occurrences suppressed during the window are reported as summaries,
+// by order of first occurrence.
+//
+// Flush is called by Run at the end of each window: call it directly when driving windows otherwise.
+func (d *Deduplicator) Flush() {
+	d.mx.Lock()
+	occurrences := d.occurrences
+	d.occurrences = make(map[string]*occurrence, len(occurrences))
+	d.reports = 0
+	d.mx.Unlock()
+
+	summaries := make([]Occurrence, 0, len(occurrences))
+	for _, o := range occurrences {
+		if o.Occurrences == o.reported {
+			continue
+		}
+		summary := o.Occurrence
+		summary.Summary = true
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].First.Equal(summaries[j].First) {
+			return summaries[i].First.Before(summaries[j].First)
+		}
+		return summaries[i].Fingerprint < summaries[j].Fingerprint
+	})
+
+	for _, summary := range summaries {
+		d.report(summary)
+	}
+}
+
+func (d *Deduplicator) report(o Occurrence) {
+	for _, handle := range d.config.handlers {
+		handle(o)
+	}
+}
+
+// ErrorPresenter wraps an error presenter, observing the errors of responses with a Deduplicator.
+//
+// The fingerprint of errors is added to their extensions, under the key set by WithExtensionKey.
+//
+// Example:
+//
+//   srv.SetErrorPresenter(gqlfingerprint.ErrorPresenter(dedup, graphql.DefaultErrorPresenter))
+func ErrorPresenter(d *Deduplicator, next graphql.ErrorPresenterFunc) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		fingerprint, _ := d.Observe(err)
+		presented := next(ctx, err)
+		if presented == nil || d.config.extensionKey == "" {
+			return presented
+		}
+
+		if presented.Extensions == nil {
+			presented.Extensions = make(map[string]interface{}, 1)
+		}
+		presented.Extensions[d.config.extensionKey] = fingerprint
+		return presented
+	}
+}
@@ -0,0 +1,140 @@
+// Package gqlfingerprint groups duplicate errors by fingerprint, to count their occurrences
+// and suppress floods of logs or error reports during incidents.
+//
+// The fingerprint of an error is a stable hash over the type of its root cause, its message normalized from
+// variable parts such as numbers, identifiers or quoted values, and the top frame of its stack trace, if any.
+//
+// A Deduplicator reports the first occurrence of each fingerprint during a window, and a summary of
+// the suppressed occurrences at the end of the window, to handlers such as loggers or error trackers.
+//
+// Example:
+//
+//   dedup := gqlfingerprint.New(
+//     gqlfingerprint.WithHandler(func(o gqlfingerprint.Occurrence) {
+//       log.Printf("[%s] %v (occurrences: %d)", o.Fingerprint, o.Error, o.Occurrences)
+//     }),
+//   )
+//   srv.SetErrorPresenter(gqlfingerprint.ErrorPresenter(dedup, graphql.DefaultErrorPresenter))
+//   go dedup.Run(ctx)
+package gqlfingerprint
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+type (
+	// stackError is an error carrying the stack trace where it was captured
+	stackError struct {
+		err     error
+		callers []uintptr
+	}
+)
+
+var normalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+}
+
+// WithStack wraps an error with the stack trace of the caller, so its top frame is part of its fingerprint
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	callers := make([]uintptr, 32)
+	n := runtime.Callers(2, callers)
+	return &stackError{err: err, callers: callers[:n]}
+}
+
+func (e *stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace yields the program counters of the stack trace of the error
+func (e *stackError) StackTrace() []uintptr {
+	return e.callers
+}
+
+// Fingerprint yields the fingerprint of an error, as an hexadecimal string.
+//
+// Errors with the same type of root cause, the same normalized message and the same top stack frame
+// have the same fingerprint.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "%T\n%s\n%s", rootCause(err), Normalize(err.Error()), topFrame(err))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Normalize a message, replacing its variable parts such as numbers, UUIDs, hexadecimal identifiers
+// and quoted values with placeholders
+func Normalize(message string) string {
+	for _, normalizer := range normalizers {
+		message = normalizer.pattern.ReplaceAllString(message, normalizer.replacement)
+	}
+	return strings.TrimSpace(message)
+}
+
+func rootCause(err error) error {
+	for {
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			return err
+		}
+		err = cause
+	}
+}
+
+// topFrame yields the function of the top frame of the first stack trace found in the chain of errors.
+//
+// Stack traces are exposed by errors with a StackTrace method yielding a slice of program counters,
+// such as those of WithStack, or github.com/pkg/errors.
+func topFrame(err error) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		pcs := stackTrace(err)
+		if len(pcs) == 0 {
+			continue
+		}
+		frame, _ := runtime.CallersFrames(pcs).Next()
+		return frame.Function
+	}
+	return ""
+}
+
+func stackTrace(err error) []uintptr {
+	if e, ok := err.(interface{ StackTrace() []uintptr }); ok {
+		return e.StackTrace()
+	}
+
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	out := method.Call(nil)[0]
+	if out.Kind() != reflect.Slice || out.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	pcs := make([]uintptr, out.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(out.Index(i).Uint())
+	}
+	return pcs
+}
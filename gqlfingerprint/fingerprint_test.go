@@ -0,0 +1,89 @@
+package gqlfingerprint
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notFoundError struct {
+	id int
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("user %d not found", e.id)
+}
+
+func failAt(id int) error {
+	return WithStack(&notFoundError{id: id})
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t,
+		`user <n> not found in <str> (request <uuid>, trace <hex>, after <n>ms)`,
+		Normalize(`user 42 not found in "users" (request 123e4567-e89b-12d3-a456-426614174000, trace 4bf92f3577b34da6, after 1.5ms) `))
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Empty(t, Fingerprint(nil))
+
+	fp := Fingerprint(&notFoundError{id: 1})
+	assert.Len(t, fp, 16)
+	assert.Equal(t, fp, Fingerprint(&notFoundError{id: 2}))
+	assert.NotEqual(t, fp, Fingerprint(errors.New("user 1 not found")), "the type of the root cause is part of the fingerprint")
+
+	withStack := Fingerprint(failAt(1))
+	assert.NotEqual(t, fp, withStack, "the top frame is part of the fingerprint")
+	assert.Equal(t, withStack, Fingerprint(failAt(2)))
+	assert.Equal(t, "github.com/99designs/gqlgen-contrib/gqlfingerprint.failAt", topFrame(fmt.Errorf("wrapped: %w", failAt(3))))
+}
+
+func TestDeduplicator(t *testing.T) {
+	var (
+		reported []Occurrence
+		now      = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	d := New(
+		WithMaxReports(2),
+		WithClock(func() time.Time {
+			now = now.Add(time.Millisecond)
+			return now
+		}),
+		WithHandler(func(o Occurrence) {
+			reported = append(reported, o)
+		}),
+	)
+
+	for i := 0; i < 5; i++ {
+		fp, ok := d.Observe(&notFoundError{id: i})
+		assert.Equal(t, i == 0, ok)
+		assert.NotEmpty(t, fp)
+	}
+	_, ok := d.Observe(errors.New("timeout after 30s"))
+	assert.True(t, ok)
+	_, ok = d.Observe(errors.New("connection refused"))
+	assert.False(t, ok, "the maximum number of reports is reached")
+
+	require.Len(t, reported, 2)
+	assert.Equal(t, int64(1), reported[0].Occurrences)
+	assert.False(t, reported[0].Summary)
+
+	reported = nil
+	d.Flush()
+	require.Len(t, reported, 2)
+	assert.Equal(t, "user 0 not found", reported[0].Error.Error())
+	assert.Equal(t, int64(5), reported[0].Occurrences)
+	assert.True(t, reported[0].Summary)
+	assert.Equal(t, "connection refused", reported[1].Error.Error())
+
+	reported = nil
+	d.Flush()
+	assert.Empty(t, reported)
+
+	_, ok = d.Observe(&notFoundError{id: 6})
+	assert.True(t, ok, "a new window reports errors again")
+}
@@ -0,0 +1,68 @@
+package gqlfingerprint
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the deduplicator of errors
+	Option func(*config)
+
+	config struct {
+		window       time.Duration
+		maxReports   int
+		handlers     []func(Occurrence)
+		extensionKey string
+		clock        func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		window:       time.Minute,
+		maxReports:   100,
+		extensionKey: "fingerprint",
+		clock:        graphql.Now,
+	}
+}
+
+// WithWindow sets the duration of the windows during which duplicate errors are suppressed. The default is 1m.
+func WithWindow(window time.Duration) Option {
+	return func(c *config) {
+		c.window = window
+	}
+}
+
+// WithMaxReports sets the maximum number of errors reported immediately during a window. Further errors
+// are only summarized at the end of the window. The default is 100: zero or less means no limit.
+func WithMaxReports(reports int) Option {
+	return func(c *config) {
+		c.maxReports = reports
+	}
+}
+
+// WithHandler adds a handler of error occurrences, e.g. to log them or to send them to an error tracker.
+//
+// Handlers are called synchronously, and should not block.
+func WithHandler(handlers ...func(Occurrence)) Option {
+	return func(c *config) {
+		c.handlers = append(c.handlers, handlers...)
+	}
+}
+
+// WithExtensionKey sets the key of the fingerprint in the extensions of errors presented by ErrorPresenter.
+// The default is "fingerprint": an empty key disables this.
+func WithExtensionKey(key string) Option {
+	return func(c *config) {
+		c.extensionKey = key
+	}
+}
+
+// WithClock sets the clock, e.g. for tests. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,115 @@
+// Package gqlflags provides a gqlgen extension to gate fields behind feature flags.
+//
+// Flags are evaluated per request by a Provider, which typically retrieves the user or tenant from the context.
+// Feature flag services such as LaunchDarkly or OpenFeature are plugged in with a ProviderFunc wrapping their client.
+//
+// Example:
+//
+//   gate := gqlflags.New(provider,
+//     gqlflags.WithFlag("Query", "newFeature", "new-feature-enabled"),
+//   )
+package gqlflags
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+)
+
+const extensionName = "FeatureFlags"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Gate{}
+
+type (
+	// Provider evaluates feature flags for the user or tenant in the context
+	Provider interface {
+		Enabled(ctx context.Context, flag string) (bool, error)
+	}
+
+	// ProviderFunc is a function implementing Provider.
+	//
+	// Example with the LaunchDarkly client:
+	//
+	//   gqlflags.ProviderFunc(func(ctx context.Context, flag string) (bool, error) {
+	//     return client.BoolVariation(flag, userFromContext(ctx), false)
+	//   })
+	ProviderFunc func(ctx context.Context, flag string) (bool, error)
+
+	// Gate is a gqlgen extension which hides or rejects the fields behind disabled feature flags.
+	//
+	// Flag evaluations are added as attributes to the current opencensus span, if any.
+	Gate struct {
+		*config
+		provider Provider
+	}
+)
+
+// Enabled implements Provider
+func (f ProviderFunc) Enabled(ctx context.Context, flag string) (bool, error) {
+	return f(ctx, flag)
+}
+
+// New Gate, with flags evaluated by the provider
+func New(provider Provider, opts ...Option) *Gate {
+	g := &Gate{
+		config:   defaultConfig(),
+		provider: provider,
+	}
+	for _, apply := range opts {
+		apply(g.config)
+	}
+	return g
+}
+
+// ExtensionName yields the extension name: "FeatureFlags"
+func (Gate) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (Gate) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (g Gate) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+	fc := graphql.GetFieldContext(ctx)
+	flag, ok := g.config.flags[fieldKey(fc.Object, fc.Field.Name)]
+	if !ok {
+		return next(ctx)
+	}
+
+	enabled, err := g.provider.Enabled(ctx, flag)
+	if err != nil {
+		enabled = g.config.fallback
+	}
+
+	if span := trace.FromContext(ctx); span != nil {
+		span.AddAttributes(trace.BoolAttribute("flag."+flag, enabled))
+	}
+
+	if enabled {
+		return next(ctx)
+	}
+
+	if g.config.mode == ModeError || isNonNull(fc) {
+		return nil, fmt.Errorf("field %s is not available", fc.Field.Name)
+	}
+
+	return nil, nil
+}
+
+// isNonNull tells if a field is declared as non-nullable, and can't be hidden
+func isNonNull(fc *graphql.FieldContext) bool {
+	return fc.Field.Field != nil && fc.Field.Definition != nil && fc.Field.Definition.Type != nil &&
+		fc.Field.Definition.Type.NonNull
+}
+
+func fieldKey(object, field string) string {
+	return object + "." + field
+}
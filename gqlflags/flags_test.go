@@ -0,0 +1,100 @@
+package gqlflags
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+type Query {
+  newFeature: String
+  newRequiredFeature: String!
+  stable: String
+}
+`
+
+func TestGate(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	flags := map[string]bool{"new-feature": false}
+	provider := ProviderFunc(func(_ context.Context, flag string) (bool, error) {
+		enabled, ok := flags[flag]
+		if !ok {
+			return false, errors.New("unknown flag " + flag)
+		}
+		return enabled, nil
+	})
+
+	resolve := func(g *Gate, field string) (interface{}, error) {
+		fc := &graphql.FieldContext{
+			Object: "Query",
+			Field: graphql.CollectedField{Field: &ast.Field{
+				Name:       field,
+				Alias:      field,
+				Definition: schema.Query.Fields.ForName(field),
+			}},
+		}
+		return g.InterceptField(graphql.WithFieldContext(context.Background(), fc), func(context.Context) (interface{}, error) {
+			return field, nil
+		})
+	}
+
+	opts := []Option{
+		WithFlag("Query", "newFeature", "new-feature"),
+		WithFlag("Query", "newRequiredFeature", "new-feature"),
+		WithFlag("Query", "stable", "unknown"),
+	}
+
+	t.Run("hide", func(t *testing.T) {
+		g := New(provider, opts...)
+
+		res, err := resolve(g, "newFeature")
+		assert.NoError(t, err)
+		assert.Nil(t, res)
+
+		_, err = resolve(g, "newRequiredFeature")
+		assert.EqualError(t, err, "field newRequiredFeature is not available", "non-nullable fields can't be hidden")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		g := New(provider, append(opts, WithMode(ModeError))...)
+
+		_, err := resolve(g, "newFeature")
+		assert.EqualError(t, err, "field newFeature is not available")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		flags["new-feature"] = true
+		defer func() { flags["new-feature"] = false }()
+
+		for _, mode := range []Mode{ModeHide, ModeError} {
+			g := New(provider, append(opts, WithMode(mode))...)
+
+			res, err := resolve(g, "newFeature")
+			assert.NoError(t, err)
+			assert.Equal(t, "newFeature", res)
+
+			res, err = resolve(g, "newRequiredFeature")
+			assert.NoError(t, err)
+			assert.Equal(t, "newRequiredFeature", res)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		res, err := resolve(New(provider, opts...), "stable")
+		assert.NoError(t, err)
+		assert.Nil(t, res)
+
+		res, err = resolve(New(provider, append(opts, WithFallback(true))...), "stable")
+		assert.NoError(t, err)
+		assert.Equal(t, "stable", res)
+	})
+}
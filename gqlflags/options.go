@@ -0,0 +1,52 @@
+package gqlflags
+
+// Mode determines how disabled fields are handled
+type Mode uint8
+
+const (
+	// ModeHide resolves disabled fields as null, without error. This is the default.
+	//
+	// Non-nullable fields can't be hidden: they are resolved with an error, as with ModeError.
+	ModeHide Mode = iota
+
+	// ModeError resolves disabled fields with an error
+	ModeError
+)
+
+type (
+	// Option for the feature flags extension
+	Option func(*config)
+
+	config struct {
+		flags    map[string]string
+		mode     Mode
+		fallback bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		flags: make(map[string]string),
+	}
+}
+
+// WithFlag puts a field of a GraphQL object behind a feature flag.
+func WithFlag(object, field, flag string) Option {
+	return func(c *config) {
+		c.flags[fieldKey(object, field)] = flag
+	}
+}
+
+// WithMode determines how disabled fields are handled. The default is ModeHide.
+func WithMode(mode Mode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+// WithFallback sets the value of flags which fail to be evaluated by the provider. The default is false (disabled).
+func WithFallback(enabled bool) Option {
+	return func(c *config) {
+		c.fallback = enabled
+	}
+}
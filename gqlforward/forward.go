@@ -0,0 +1,137 @@
+// Package gqlforward forwards selected inbound HTTP headers to the context of resolvers, and onward
+// to downstream services.
+//
+// A Policy defines which headers are forwarded, with an allowlist and a denylist. Hop-by-hop headers,
+// such as Connection or Transfer-Encoding, are never forwarded.
+//
+// Example:
+//
+//   fwd := gqlforward.New(
+//     gqlforward.WithAllow("Accept-Language", "X-Request-Id", "X-B3-*", "Traceparent"),
+//     gqlforward.WithDeny("X-B3-Flags"),
+//   )
+//   http.Handle("/query", fwd.Middleware(srv))
+//
+//   // downstream HTTP services
+//   client := &http.Client{Transport: gqlforward.Transport(http.DefaultTransport)}
+//
+//   // downstream gRPC services
+//   ctx = metadata.NewOutgoingContext(ctx, metadata.MD(gqlforward.Metadata(ctx)))
+package gqlforward
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Forwarder captures the inbound headers allowed by its policy into the request context
+	Forwarder struct {
+		*config
+	}
+
+	headersKey struct{}
+)
+
+// New header Forwarder
+func New(opts ...Option) *Forwarder {
+	f := &Forwarder{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(f.config)
+	}
+	return f
+}
+
+// Middleware captures the inbound headers allowed by the policy into the request context.
+//
+// The middleware must wrap the gqlgen handler:
+//
+//   http.Handle("/query", fwd.Middleware(srv))
+func (f *Forwarder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers := f.config.policy.Filter(r.Header)
+		if len(headers) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithHeaders(r.Context(), headers)))
+	})
+}
+
+// WithHeaders sets the headers to forward in the context, e.g. for transports other than HTTP such as the init
+// payload of websockets. These headers are not filtered by any policy.
+func WithHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
+// Headers yields a copy of the headers to forward, captured in the context
+func Headers(ctx context.Context) http.Header {
+	headers, ok := ctx.Value(headersKey{}).(http.Header)
+	if !ok {
+		return http.Header{}
+	}
+	return cloneHeader(headers)
+}
+
+// Inject the headers to forward into an outgoing request. Forwarded headers replace the ones set on the request.
+func Inject(ctx context.Context, req *http.Request) {
+	headers, ok := ctx.Value(headersKey{}).(http.Header)
+	if !ok {
+		return
+	}
+	for key, values := range headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+}
+
+// Metadata yields the headers to forward as gRPC metadata, with lower-cased keys.
+// The result may be converted to a metadata.MD.
+func Metadata(ctx context.Context) map[string][]string {
+	headers, ok := ctx.Value(headersKey{}).(http.Header)
+	if !ok {
+		return map[string][]string{}
+	}
+	md := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		md[strings.ToLower(key)] = append([]string(nil), values...)
+	}
+	return md
+}
+
+// Transport wraps a http.RoundTripper, injecting the headers to forward found in the context of requests.
+// The default base transport is http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{base: base}
+}
+
+type roundTripper struct {
+	base http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Context().Value(headersKey{}).(http.Header); !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = cloneHeader(req.Header)
+	Inject(req.Context(), clone)
+
+	return t.base.RoundTrip(clone)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for key, values := range h {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
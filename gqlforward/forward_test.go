@@ -0,0 +1,55 @@
+package gqlforward
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwarder(t *testing.T) {
+	fwd := New(
+		WithAllow("accept-language", "X-B3-*", "Connection"),
+		WithDeny("X-B3-Flags"),
+	)
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "fr", r.Header.Get("Accept-Language"))
+		assert.Equal(t, "abc", r.Header.Get("X-B3-Traceid"))
+		assert.Empty(t, r.Header.Get("X-B3-Flags"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	handler := fwd.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		assert.Equal(t, http.Header{
+			"Accept-Language": {"fr"},
+			"X-B3-Traceid":    {"abc"},
+		}, Headers(ctx))
+		assert.Equal(t, map[string][]string{
+			"accept-language": {"fr"},
+			"x-b3-traceid":    {"abc"},
+		}, Metadata(ctx))
+
+		req, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req.WithContext(ctx))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Empty(t, req.Header, "the outgoing request is not modified")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("Accept-Language", "fr")
+	req.Header.Set("X-B3-TraceId", "abc")
+	req.Header.Set("X-B3-Flags", "1")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Connection", "close")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
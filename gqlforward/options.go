@@ -0,0 +1,36 @@
+package gqlforward
+
+type (
+	// Option for the header forwarder
+	Option func(*config)
+
+	config struct {
+		policy Policy
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithAllow adds headers to the allowlist of the policy. By default, no header is forwarded.
+func WithAllow(names ...string) Option {
+	return func(c *config) {
+		c.policy.Allow = append(c.policy.Allow, names...)
+	}
+}
+
+// WithDeny adds headers to the denylist of the policy, e.g. to exclude some headers matched by a prefix
+// in the allowlist.
+func WithDeny(names ...string) Option {
+	return func(c *config) {
+		c.policy.Deny = append(c.policy.Deny, names...)
+	}
+}
+
+// WithPolicy sets the policy, replacing any allowlist or denylist set before.
+func WithPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
@@ -0,0 +1,65 @@
+package gqlforward
+
+import (
+	"net/http"
+	"strings"
+)
+
+// hopByHop headers are never forwarded
+var hopByHop = map[string]struct{}{
+	"Connection":          {},
+	"Content-Length":      {},
+	"Host":                {},
+	"Keep-Alive":          {},
+	"Proxy-Authenticate":  {},
+	"Proxy-Authorization": {},
+	"Proxy-Connection":    {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+}
+
+// Policy tells which headers are forwarded.
+//
+// Header names are case-insensitive. A name ending with "*" matches all headers with this prefix, e.g. "X-B3-*".
+// The denylist takes precedence over the allowlist.
+type Policy struct {
+	Allow []string
+	Deny  []string
+}
+
+// Allowed tells if a header is forwarded by this policy
+func (p Policy) Allowed(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if _, ok := hopByHop[name]; ok {
+		return false
+	}
+	return matchAny(p.Allow, name) && !matchAny(p.Deny, name)
+}
+
+// Filter yields the headers forwarded by this policy
+func (p Policy) Filter(headers http.Header) http.Header {
+	filtered := make(http.Header)
+	for key, values := range headers {
+		if p.Allowed(key) {
+			filtered[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	return filtered
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(strings.ToLower(name), strings.ToLower(strings.TrimSuffix(pattern, "*"))) {
+				return true
+			}
+			continue
+		}
+		if http.CanonicalHeaderKey(pattern) == name {
+			return true
+		}
+	}
+	return false
+}
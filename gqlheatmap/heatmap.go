@@ -0,0 +1,251 @@
+// Package gqlheatmap provides a gqlgen extension collecting per-field latency histograms over time,
+// for teams who want latency distributions without running Prometheus.
+//
+// Latencies are counted in exponential buckets, over a rolling series of time windows. A snapshot of
+// these histograms may be dumped on demand as JSON, ready to be plotted as a heatmap: time windows on
+// one axis, latency buckets on the other.
+//
+// Example:
+//
+//   heatmap := gqlheatmap.New(gqlheatmap.WithWindow(time.Minute), gqlheatmap.WithRetention(60))
+//   srv.Use(heatmap)
+//   http.Handle("/debug/heatmap", heatmap.Handler())
+package gqlheatmap
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "LatencyHeatmap"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Collector{}
+
+type (
+	// Collector is a gqlgen extension collecting per-field latency histograms
+	Collector struct {
+		*config
+
+		// upper bounds of buckets. The last bucket counts all latencies above the last bound.
+		bounds []time.Duration
+
+		current atomic.Value // *window
+		mx      sync.Mutex
+		windows []*window // retained windows, oldest first
+	}
+
+	// window of time, with the histograms of fields resolved during this window
+	window struct {
+		start  time.Time
+		end    time.Time
+		fields sync.Map // field -> *histogram
+	}
+
+	histogram struct {
+		counts []int64
+	}
+
+	// Snapshot of the latency histograms, ready to be plotted as a heatmap
+	Snapshot struct {
+		// Upper bounds of latency buckets, in milliseconds. The last bucket has no upper bound.
+		Bounds []float64 `json:"bounds"`
+
+		// Histograms of fields, as "Object.field", by time window, oldest first
+		Fields map[string][]Slice `json:"fields"`
+	}
+
+	// Slice is the latency histogram of a field during a time window
+	Slice struct {
+		Start time.Time `json:"start"`
+		Count int64     `json:"count"`
+
+		// Counts by bucket index. Empty buckets are omitted.
+		Counts map[string]int64 `json:"counts"`
+	}
+)
+
+// New latency heatmap Collector
+func New(opts ...Option) *Collector {
+	c := &Collector{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+
+	for bound := c.config.minLatency; bound < c.config.maxLatency; bound = time.Duration(float64(bound) * c.config.growth) {
+		c.bounds = append(c.bounds, bound)
+	}
+	c.bounds = append(c.bounds, c.config.maxLatency)
+	return c
+}
+
+// ExtensionName yields the extension name: "LatencyHeatmap"
+func (*Collector) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Collector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (c *Collector) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || (!fc.IsMethod && !c.config.allFields) {
+		return next(ctx)
+	}
+
+	start := c.config.clock()
+	res, err := next(ctx)
+	end := c.config.clock()
+
+	c.window(end).histogram(fc.Object+"."+fc.Field.Name, len(c.bounds)+1).add(c.bucket(end.Sub(start)))
+	return res, err
+}
+
+// Snapshot yields the latency histograms of the retained windows. Only fields with these names are included,
+// if any are specified.
+func (c *Collector) Snapshot(fields ...string) Snapshot {
+	snapshot := Snapshot{
+		Bounds: make([]float64, len(c.bounds)),
+		Fields: make(map[string][]Slice),
+	}
+	for i, bound := range c.bounds {
+		snapshot.Bounds[i] = float64(bound) / float64(time.Millisecond)
+	}
+
+	var filter map[string]bool
+	if len(fields) > 0 {
+		filter = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			filter[field] = true
+		}
+	}
+
+	oldest := c.oldest(c.config.clock())
+	c.mx.Lock()
+	windows := make([]*window, len(c.windows))
+	copy(windows, c.windows)
+	c.mx.Unlock()
+
+	for _, w := range windows {
+		if w.start.Before(oldest) {
+			// expired, but not rotated yet for lack of traffic
+			continue
+		}
+		w.fields.Range(func(key, value interface{}) bool {
+			field := key.(string)
+			if filter != nil && !filter[field] {
+				return true
+			}
+			snapshot.Fields[field] = append(snapshot.Fields[field], value.(*histogram).slice(w.start))
+			return true
+		})
+	}
+	return snapshot
+}
+
+// Handler serves a JSON Snapshot. Fields may be selected with the "field" query parameter, e.g. ?field=Query.todos
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Snapshot(r.URL.Query()["field"]...))
+	})
+}
+
+// window yields the window for this time, rotating windows as time goes
+func (c *Collector) window(now time.Time) *window {
+	if w, ok := c.current.Load().(*window); ok && now.Before(w.end) && !now.Before(w.start) {
+		return w
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	start := now.Truncate(c.config.window)
+	for _, w := range c.windows {
+		if w.start.Equal(start) {
+			return w
+		}
+	}
+
+	w := &window{start: start, end: start.Add(c.config.window)}
+	c.windows = append(c.windows, w)
+	sort.Slice(c.windows, func(i, j int) bool { return c.windows[i].start.Before(c.windows[j].start) })
+
+	// retain the windows over the retention period ending with the latest window, even when some are missing
+	oldest := c.oldest(c.windows[len(c.windows)-1].start)
+	expired := 0
+	for expired < len(c.windows) && c.windows[expired].start.Before(oldest) {
+		expired++
+	}
+	c.windows = c.windows[expired:]
+	if latest := c.windows[len(c.windows)-1]; latest == w {
+		c.current.Store(w)
+	}
+	return w
+}
+
+// oldest yields the start of the oldest window retained at this time
+func (c *Collector) oldest(now time.Time) time.Time {
+	return now.Truncate(c.config.window).Add(-time.Duration(c.config.retention-1) * c.config.window)
+}
+
+// bucket yields the index of the bucket of a latency
+func (c *Collector) bucket(d time.Duration) int {
+	if d <= c.bounds[0] {
+		return 0
+	}
+	if d > c.bounds[len(c.bounds)-1] {
+		return len(c.bounds)
+	}
+	i := int(math.Ceil(math.Log(float64(d)/float64(c.bounds[0])) / math.Log(c.config.growth)))
+	// adjust for rounding of bounds
+	for i > 0 && d <= c.bounds[i-1] {
+		i--
+	}
+	for i < len(c.bounds) && d > c.bounds[i] {
+		i++
+	}
+	return i
+}
+
+func (w *window) histogram(field string, buckets int) *histogram {
+	if h, ok := w.fields.Load(field); ok {
+		return h.(*histogram)
+	}
+	h, _ := w.fields.LoadOrStore(field, &histogram{counts: make([]int64, buckets)})
+	return h.(*histogram)
+}
+
+func (h *histogram) add(bucket int) {
+	atomic.AddInt64(&h.counts[bucket], 1)
+}
+
+func (h *histogram) slice(start time.Time) Slice {
+	s := Slice{
+		Start:  start,
+		Counts: make(map[string]int64),
+	}
+	for i := range h.counts {
+		if count := atomic.LoadInt64(&h.counts[i]); count > 0 {
+			s.Counts[strconv.Itoa(i)] = count
+			s.Count += count
+		}
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package gqlheatmap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestCollector(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	c := New(
+		WithClock(clock),
+		WithWindow(time.Minute),
+		WithRetention(2),
+		WithBuckets(time.Millisecond, time.Second, 10),
+	)
+	require.Len(t, c.bounds, 4)
+
+	resolve := func(field string, latency time.Duration) {
+		fc := &graphql.FieldContext{
+			Object:   "Query",
+			Field:    graphql.CollectedField{Field: &ast.Field{Name: field}},
+			IsMethod: true,
+		}
+		_, _ = c.InterceptField(graphql.WithFieldContext(context.Background(), fc), func(context.Context) (interface{}, error) {
+			now = now.Add(latency)
+			return nil, nil
+		})
+	}
+
+	resolve("todos", 500*time.Microsecond)
+	resolve("todos", 5*time.Millisecond)
+	resolve("todos", 10*time.Millisecond)
+	resolve("users", time.Hour)
+
+	now = now.Add(time.Minute)
+	resolve("todos", 50*time.Millisecond)
+
+	now = now.Add(time.Minute)
+	resolve("todos", 500*time.Millisecond)
+
+	snapshot := c.Snapshot("Query.todos")
+	assert.Equal(t, []float64{1, 10, 100, 1000}, snapshot.Bounds)
+	require.Len(t, snapshot.Fields["Query.todos"], 2, "only 2 windows are retained")
+	assert.Empty(t, snapshot.Fields["Query.users"])
+
+	assert.Equal(t, Slice{
+		Start:  time.Date(2020, 5, 1, 13, 1, 0, 0, time.UTC),
+		Count:  1,
+		Counts: map[string]int64{"2": 1},
+	}, snapshot.Fields["Query.todos"][0])
+	assert.Equal(t, map[string]int64{"3": 1}, snapshot.Fields["Query.todos"][1].Counts)
+
+	now = now.Add(time.Minute)
+	require.Len(t, c.Snapshot().Fields["Query.todos"], 1, "expired windows are not reported without traffic")
+
+	assert.Equal(t, 0, c.bucket(500*time.Microsecond))
+	assert.Equal(t, 1, c.bucket(5*time.Millisecond))
+	assert.Equal(t, 1, c.bucket(10*time.Millisecond))
+	assert.Equal(t, 4, c.bucket(time.Hour))
+}
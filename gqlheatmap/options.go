@@ -0,0 +1,78 @@
+package gqlheatmap
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the heatmap collector
+	Option func(*config)
+
+	config struct {
+		window     time.Duration
+		retention  int
+		minLatency time.Duration
+		maxLatency time.Duration
+		growth     float64
+		allFields  bool
+		clock      func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		window:     time.Minute,
+		retention:  60,
+		minLatency: 100 * time.Microsecond,
+		maxLatency: time.Minute,
+		growth:     1.25,
+		clock:      graphql.Now,
+	}
+}
+
+// WithWindow sets the duration of time windows. The default is 1m.
+func WithWindow(window time.Duration) Option {
+	return func(c *config) {
+		if window > 0 {
+			c.window = window
+		}
+	}
+}
+
+// WithRetention sets the number of time windows retained. The default is 60.
+func WithRetention(windows int) Option {
+	return func(c *config) {
+		if windows > 0 {
+			c.retention = windows
+		}
+	}
+}
+
+// WithBuckets sets the latency buckets: the upper bound of the first bucket, the upper bound of the last bounded
+// bucket, and the growth factor between bounds. The defaults are 100µs, 1m and 1.25, i.e. 61 bounded buckets.
+func WithBuckets(min, max time.Duration, growth float64) Option {
+	return func(c *config) {
+		if min <= 0 || max < min || growth <= 1 {
+			return
+		}
+		c.minLatency = min
+		c.maxLatency = max
+		c.growth = growth
+	}
+}
+
+// WithAllFields collects the latency of all fields. By default, only fields resolved by resolver methods are collected.
+func WithAllFields(enabled bool) Option {
+	return func(c *config) {
+		c.allFields = enabled
+	}
+}
+
+// WithClock sets the clock used to measure latencies. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
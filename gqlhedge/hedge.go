@@ -0,0 +1,219 @@
+// Package gqlhedge reduces the tail latency of idempotent downstream fetches with hedged requests: when a call
+// takes longer than a percentile of the recent latencies, a second attempt is sent, and the first response wins.
+//
+// Hedges are limited by a budget, as a fraction of calls, so that a slow downstream service is not overloaded
+// by hedges. The span of calls is annotated with "hedged=true" and the winning attempt.
+//
+// Example, with HTTP services:
+//
+//   hedger := gqlhedge.New(gqlhedge.WithName("users-api"), gqlhedge.WithPercentile(0.95), gqlhedge.WithBudget(0.1))
+//   client := &http.Client{Transport: &ochttp.Transport{Base: hedger.Transport(http.DefaultTransport)}}
+//
+// Example, with gRPC services:
+//
+//   resp, err := hedger.Do(ctx, func(ctx context.Context) (interface{}, error) {
+//     return usersClient.GetUser(ctx, req)
+//   })
+//
+// Only idempotent calls may be hedged: both attempts may reach the downstream service.
+package gqlhedge
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+)
+
+type (
+	// Hedger sends hedged attempts of calls
+	Hedger struct {
+		*config
+
+		mx        sync.Mutex
+		latencies []time.Duration // ring buffer of the latest latencies
+		next      int
+		observed  int
+		delay     time.Duration
+		tokens    float64
+	}
+
+	// outcome of an attempt
+	outcome struct {
+		value interface{}
+		err   error
+		hedge bool
+	}
+)
+
+// New Hedger
+func New(opts ...Option) *Hedger {
+	h := &Hedger{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(h.config)
+	}
+	h.latencies = make([]time.Duration, 0, h.config.window)
+	h.delay = h.config.initialDelay
+	h.tokens = h.config.burst
+	return h
+}
+
+// Do a call, with a hedged attempt when the first one is slow. The first successful attempt wins, and the other one
+// is cancelled. Errors are not retried: when the first attempt fails before the hedge delay, its error is returned.
+func (h *Hedger) Do(ctx context.Context, call func(context.Context) (interface{}, error)) (interface{}, error) {
+	return h.do(ctx, call, nil)
+}
+
+// do a call, with a function releasing the values of the attempts which lost the race
+func (h *Hedger) do(ctx context.Context, call func(context.Context) (interface{}, error), release func(interface{})) (interface{}, error) {
+	h.deposit()
+	start := h.config.clock()
+
+	results := make(chan outcome, 2)
+	cancels := make([]context.CancelFunc, 0, 2)
+	launch := func(hedge bool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			value, err := call(attemptCtx)
+			results <- outcome{value: value, err: err, hedge: hedge}
+		}()
+	}
+
+	launch(false)
+	timer := time.NewTimer(h.currentDelay())
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	for {
+		select {
+		case res := <-results:
+			pending--
+			if res.err != nil && pending > 0 {
+				// the other attempt may still succeed
+				continue
+			}
+
+			h.observe(h.config.clock().Sub(start))
+			h.finish(ctx, hedged, res, pending, results, cancels, release)
+			return res.value, res.err
+
+		case <-timer.C:
+			if hedged {
+				continue
+			}
+			if !h.withdraw() {
+				h.record(ctx, "budget_exhausted")
+				continue
+			}
+			hedged = true
+			pending++
+			launch(true)
+		}
+	}
+}
+
+// finish a call: annotate its span, record metrics, and release the attempt which lost the race.
+//
+// The context of the winning attempt is not cancelled, since its value may still be in use, e.g. to read the body
+// of a response: it ends with the context of the call.
+func (h *Hedger) finish(ctx context.Context, hedged bool, winner outcome, pending int, results <-chan outcome, cancels []context.CancelFunc, release func(interface{})) {
+	if !hedged {
+		return
+	}
+
+	winnerName := "primary"
+	if winner.hedge {
+		winnerName = "hedge"
+	}
+	h.record(ctx, winnerName)
+	if span := trace.FromContext(ctx); span != nil && span.IsRecordingEvents() {
+		span.AddAttributes(ocattr.Attributes(gqlattr.Default(), []gqlattr.KeyValue{
+			{Key: "hedged", Value: true},
+			{Key: "hedge.winner", Value: winnerName},
+		})...)
+	}
+
+	loser := cancels[0]
+	if !winner.hedge {
+		loser = cancels[1]
+	}
+	loser()
+	if pending == 0 {
+		return
+	}
+	go func() {
+		if res := <-results; release != nil && res.err == nil {
+			release(res.value)
+		}
+	}()
+}
+
+// currentDelay yields the delay before hedging
+func (h *Hedger) currentDelay() time.Duration {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return h.delay
+}
+
+// observe the latency of a call, and periodically update the hedge delay from the percentile of the latest latencies
+func (h *Hedger) observe(latency time.Duration) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if len(h.latencies) < h.config.window {
+		h.latencies = append(h.latencies, latency)
+	} else {
+		h.latencies[h.next] = latency
+		h.next = (h.next + 1) % h.config.window
+	}
+	h.observed++
+	if len(h.latencies) < h.config.minSamples || h.observed%h.config.refresh != 0 {
+		return
+	}
+
+	sorted := append(make([]time.Duration, 0, len(h.latencies)), h.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	delay := sorted[int(h.config.percentile*float64(len(sorted)-1))]
+	if delay < h.config.minDelay {
+		delay = h.config.minDelay
+	}
+	h.delay = delay
+}
+
+// deposit a fraction of token for a call, up to the burst of the budget
+func (h *Hedger) deposit() {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.tokens += h.config.budget
+	if h.tokens > h.config.burst {
+		h.tokens = h.config.burst
+	}
+}
+
+// withdraw a token to hedge a call, when the budget allows it
+func (h *Hedger) withdraw() bool {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+func (h *Hedger) record(ctx context.Context, outcome string) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(TagTarget, h.config.name),
+		tag.Upsert(TagOutcome, outcome),
+	}, HedgeCount.M(1))
+}
@@ -0,0 +1,110 @@
+package gqlhedge
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowFirst is a call whose first attempt hangs until cancelled
+func slowFirst(attempts *int32, cancelled chan<- struct{}) func(context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(attempts, 1) == 1 {
+			select {
+			case <-ctx.Done():
+				close(cancelled)
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return "primary", nil
+			}
+		}
+		return "hedge", nil
+	}
+}
+
+func TestHedge(t *testing.T) {
+	h := New(WithDelay(10*time.Millisecond, time.Millisecond), WithBudget(1, 1))
+
+	var attempts int32
+	cancelled := make(chan struct{})
+	value, err := h.Do(context.Background(), slowFirst(&attempts, cancelled))
+	require.NoError(t, err)
+	assert.Equal(t, "hedge", value)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the primary attempt to be cancelled")
+	}
+}
+
+func TestBudgetExhausted(t *testing.T) {
+	h := New(WithDelay(time.Millisecond, time.Millisecond), WithBudget(0, 0))
+
+	var attempts int32
+	value, err := h.Do(context.Background(), func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&attempts, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "primary", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "primary", value)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestDelay(t *testing.T) {
+	h := New(WithDelay(time.Second, 5*time.Millisecond), WithWindow(100))
+	assert.Equal(t, time.Second, h.currentDelay())
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 95*time.Millisecond, h.currentDelay())
+
+	for i := 0; i < 100; i++ {
+		h.observe(time.Millisecond)
+	}
+	assert.Equal(t, 5*time.Millisecond, h.currentDelay())
+}
+
+func TestTransport(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("hedged response"))
+	}))
+	defer srv.Close()
+
+	h := New(WithDelay(10*time.Millisecond, time.Millisecond), WithBudget(1, 2))
+	client := &http.Client{Transport: h.Transport(nil)}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "hedged response", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// non-idempotent requests are not hedged
+	atomic.StoreInt32(&requests, 10)
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("x"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(11), atomic.LoadInt32(&requests))
+}
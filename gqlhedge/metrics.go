@@ -0,0 +1,50 @@
+package gqlhedge
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the hedger.
+func Register() error {
+	return gqlmetrics.Register(HedgeViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(HedgeViews...)
+}
+
+var (
+	// HedgeViews contains all opencensus stats views declared by the hedger
+	HedgeViews = []*view.View{
+		HedgeCountView,
+	}
+
+	// HedgeCount tracks a count of hedged calls, by outcome
+	HedgeCount = stats.Int64(
+		"gql/server/hedge_count",
+		"Number of hedged calls to downstream services",
+		stats.UnitDimensionless)
+
+	// HedgeCountView reports the number of hedged calls, by target and outcome
+	HedgeCountView = &view.View{
+		Name:        "gql/server/hedge_count",
+		Description: "Number of hedged calls to downstream services by target and outcome",
+		Measure:     HedgeCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagTarget, TagOutcome},
+	}
+
+	// TagTarget is the name of the downstream target
+	TagTarget = tag.MustNewKey("gql.hedge_target")
+
+	// TagOutcome is the outcome of a hedged call: the winning attempt, "primary" or "hedge", or "budget_exhausted"
+	// when a call could not be hedged
+	TagOutcome = tag.MustNewKey("gql.hedge_outcome")
+)
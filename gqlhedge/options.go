@@ -0,0 +1,104 @@
+package gqlhedge
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the hedger
+	Option func(*config)
+
+	config struct {
+		name         string
+		percentile   float64
+		initialDelay time.Duration
+		minDelay     time.Duration
+		window       int
+		minSamples   int
+		refresh      int
+		budget       float64
+		burst        float64
+		idempotent   func(*http.Request) bool
+		clock        func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		name:         "default",
+		percentile:   0.95,
+		initialDelay: 100 * time.Millisecond,
+		minDelay:     time.Millisecond,
+		window:       1000,
+		minSamples:   20,
+		refresh:      10,
+		budget:       0.05,
+		burst:        10,
+		idempotent:   idempotentMethod,
+		clock:        graphql.Now,
+	}
+}
+
+// WithName sets the name of the downstream target, as the tag of metrics. The default is "default".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithPercentile sets the percentile of the latest latencies after which a call is hedged, between 0 and 1.
+// The default is 0.95.
+func WithPercentile(percentile float64) Option {
+	return func(c *config) {
+		c.percentile = percentile
+	}
+}
+
+// WithDelay sets the hedge delay used until enough latencies are observed, and the minimum delay.
+// The defaults are 100ms and 1ms.
+func WithDelay(initial, min time.Duration) Option {
+	return func(c *config) {
+		c.initialDelay = initial
+		c.minDelay = min
+	}
+}
+
+// WithWindow sets the number of latest latencies from which the percentile is computed. The default is 1000.
+func WithWindow(size int) Option {
+	return func(c *config) {
+		if size < 1 {
+			size = 1
+		}
+		c.window = size
+		if c.minSamples > size {
+			c.minSamples = size
+		}
+	}
+}
+
+// WithBudget sets the maximum fraction of calls which may be hedged, and the burst of hedges allowed after
+// a quiet period. The defaults are 0.05 (5% of calls) and 10 hedges.
+func WithBudget(ratio float64, burst int) Option {
+	return func(c *config) {
+		c.budget = ratio
+		c.burst = float64(burst)
+	}
+}
+
+// WithIdempotent sets the function telling which HTTP requests may be hedged. By default, these are requests with
+// the GET, HEAD and OPTIONS methods.
+func WithIdempotent(idempotent func(*http.Request) bool) Option {
+	return func(c *config) {
+		c.idempotent = idempotent
+	}
+}
+
+// WithClock sets the clock measuring latencies, e.g. for tests. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
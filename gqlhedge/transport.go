@@ -0,0 +1,61 @@
+package gqlhedge
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+type transport struct {
+	*Hedger
+	base http.RoundTripper
+}
+
+// Transport hedges the idempotent requests sent with a base transport (http.DefaultTransport when nil).
+//
+// Requests are idempotent when their method is GET, HEAD or OPTIONS, unless set otherwise with WithIdempotent.
+// Requests with a body which cannot be replayed (without GetBody) are never hedged.
+func (h *Hedger) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{Hedger: h, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.config.idempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.base.RoundTrip(req)
+	}
+
+	value, err := t.do(req.Context(), func(ctx context.Context) (interface{}, error) {
+		attempt := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		return t.base.RoundTrip(attempt)
+	}, func(value interface{}) {
+		if resp, ok := value.(*http.Response); ok && resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*http.Response), nil
+}
+
+func idempotentMethod(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
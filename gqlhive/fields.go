@@ -0,0 +1,100 @@
+package gqlhive
+
+import (
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// schemaCoordinates lists the types, fields, arguments and input fields used by an operation,
+// as schema coordinates such as "Query", "Query.user", "Query.user.id" or "UserInput.email".
+func schemaCoordinates(schema *ast.Schema, doc *ast.QueryDocument, op *ast.OperationDefinition) []string {
+	c := &coordinates{
+		schema:   schema,
+		doc:      doc,
+		seen:     make(map[string]struct{}),
+		visiting: make(map[string]bool),
+	}
+	if op == nil {
+		return nil
+	}
+	for _, v := range op.VariableDefinitions {
+		c.inputType(v.Type.Name())
+	}
+	c.selectionSet(op.SelectionSet)
+
+	sorted := make([]string, 0, len(c.seen))
+	for coordinate := range c.seen {
+		sorted = append(sorted, coordinate)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+type coordinates struct {
+	schema   *ast.Schema
+	doc      *ast.QueryDocument
+	seen     map[string]struct{}
+	visiting map[string]bool
+}
+
+func (c *coordinates) add(coordinate string) bool {
+	if _, ok := c.seen[coordinate]; ok {
+		return false
+	}
+	c.seen[coordinate] = struct{}{}
+	return true
+}
+
+func (c *coordinates) selectionSet(selections ast.SelectionSet) {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.ObjectDefinition == nil || s.Definition == nil || len(s.Name) > 1 && s.Name[:2] == "__" {
+				continue
+			}
+			parent := s.ObjectDefinition.Name
+			c.add(parent)
+			c.add(parent + "." + s.Name)
+			c.add(s.Definition.Type.Name())
+			for _, arg := range s.Arguments {
+				c.add(parent + "." + s.Name + "." + arg.Name)
+				if argDef := s.Definition.Arguments.ForName(arg.Name); argDef != nil {
+					c.inputType(argDef.Type.Name())
+				}
+			}
+			c.selectionSet(s.SelectionSet)
+		case *ast.InlineFragment:
+			if s.TypeCondition != "" {
+				c.add(s.TypeCondition)
+			}
+			c.selectionSet(s.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment := s.Definition
+			if fragment == nil && c.doc != nil {
+				fragment = c.doc.Fragments.ForName(s.Name)
+			}
+			if fragment == nil || c.visiting[fragment.Name] {
+				continue
+			}
+			c.visiting[fragment.Name] = true
+			c.add(fragment.TypeCondition)
+			c.selectionSet(fragment.SelectionSet)
+		}
+	}
+}
+
+// inputType adds an input type, and the fields of input objects at any depth
+func (c *coordinates) inputType(name string) {
+	if !c.add(name) || c.schema == nil {
+		return
+	}
+	def := c.schema.Types[name]
+	if def == nil || def.Kind != ast.InputObject {
+		return
+	}
+	for _, field := range def.Fields {
+		c.add(name + "." + field.Name)
+		c.inputType(field.Type.Name())
+	}
+}
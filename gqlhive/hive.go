@@ -0,0 +1,326 @@
+// Package gqlhive provides a gqlgen extension reporting operation and field usage to GraphQL Hive.
+//
+// Executed operations are buffered, then sent in batches to Hive's usage API, with their normalized document,
+// the schema coordinates they use, their duration and errors, and the client which sent them.
+//
+// Clients are identified by the "graphql-client-name" and "graphql-client-version" request headers,
+// captured by the Middleware.
+//
+// Example:
+//
+//   reporter := gqlhive.New(os.Getenv("HIVE_TOKEN"), gqlhive.WithSampleRate(0.5))
+//   srv.Use(reporter)
+//   http.Handle("/query", reporter.Middleware(srv))
+//
+//   go reporter.Run(ctx)
+//
+// Operations are normalized with gqlapollo.Signature: literals are hidden, aliases removed and selections sorted.
+package gqlhive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlapollo"
+	"github.com/99designs/gqlgen-contrib/gqldelivery"
+)
+
+const (
+	extensionName = "HiveUsageReporting"
+
+	// DefaultEndpoint is the usage API of GraphQL Hive
+	DefaultEndpoint = "https://app.graphql-hive.com/usage"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Reporter{}
+
+type (
+	// Reporter is a gqlgen extension reporting usage to GraphQL Hive
+	Reporter struct {
+		*config
+		token  string
+		schema *ast.Schema
+		queue  *gqldelivery.Queue
+
+		mx         sync.Mutex
+		operations map[string]Operation
+		records    []Record
+		full       chan struct{}
+	}
+
+	// Report is the payload sent to Hive's usage API
+	Report struct {
+		Size       int                  `json:"size"`
+		Map        map[string]Operation `json:"map"`
+		Operations []Record             `json:"operations"`
+	}
+
+	// Operation describes an operation, referenced by records
+	Operation struct {
+		Operation     string   `json:"operation"`
+		OperationName string   `json:"operationName,omitempty"`
+		Fields        []string `json:"fields"`
+	}
+
+	// Record is the execution of an operation
+	Record struct {
+		OperationMapKey string    `json:"operationMapKey"`
+		Timestamp       int64     `json:"timestamp"`
+		Execution       Execution `json:"execution"`
+		Metadata        *Metadata `json:"metadata,omitempty"`
+	}
+
+	// Execution reports the outcome of an operation. The duration is in nanoseconds.
+	Execution struct {
+		OK          bool  `json:"ok"`
+		Duration    int64 `json:"duration"`
+		ErrorsTotal int   `json:"errorsTotal"`
+	}
+
+	// Metadata about the execution of an operation
+	Metadata struct {
+		Client *Client `json:"client,omitempty"`
+	}
+
+	// Client which sent an operation
+	Client struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	clientKey struct{}
+)
+
+// New Hive usage Reporter, authenticated with a registry access token
+func New(token string, opts ...Option) *Reporter {
+	r := &Reporter{
+		config:     defaultConfig(),
+		token:      token,
+		operations: make(map[string]Operation),
+		full:       make(chan struct{}, 1),
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	if r.config.delivery {
+		r.queue = gqldelivery.New(gqldelivery.SenderFunc(r.send), r.config.deliveryOptions...)
+	}
+	return r
+}
+
+// Middleware captures the client name and version headers (see WithClientHeaders).
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (r *Reporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		client := Client{
+			Name:    req.Header.Get(r.config.clientNameHeader),
+			Version: req.Header.Get(r.config.clientVersionHeader),
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), clientKey{}, client)))
+	})
+}
+
+// ExtensionName yields the extension name: "HiveUsageReporting"
+func (*Reporter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate retains the schema, to resolve the fields of input types
+func (r *Reporter) Validate(schema graphql.ExecutableSchema) error {
+	r.schema = schema.Schema()
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor, buffering a record of sampled operations
+func (r *Reporter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		// the final pull of websocket transports, past the response of the operation
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || !r.sampled(oc) {
+		return resp
+	}
+
+	start := oc.Stats.OperationStart
+	now := r.config.clock()
+	if start.IsZero() {
+		start = now
+	}
+
+	operation := Operation{
+		Operation:     gqlapollo.OperationSignature(ctx),
+		OperationName: oc.Operation.Name,
+		Fields:        schemaCoordinates(r.schema, oc.Doc, oc.Operation),
+	}
+	key := operationKey(operation)
+
+	record := Record{
+		OperationMapKey: key,
+		Timestamp:       start.UnixNano() / int64(time.Millisecond),
+		Execution: Execution{
+			Duration:    now.Sub(start).Nanoseconds(),
+			ErrorsTotal: len(resp.Errors),
+		},
+	}
+	record.Execution.OK = record.Execution.ErrorsTotal == 0
+
+	client, _ := ctx.Value(clientKey{}).(Client)
+	if r.config.clientInfo != nil {
+		client.Name, client.Version = r.config.clientInfo(ctx)
+	}
+	if client.Name != "" {
+		record.Metadata = &Metadata{Client: &client}
+	}
+
+	r.mx.Lock()
+	r.operations[key] = operation
+	r.records = append(r.records, record)
+	full := len(r.records) >= r.config.maxBatchSize
+	r.mx.Unlock()
+
+	if full {
+		select {
+		case r.full <- struct{}{}:
+		default:
+		}
+	}
+	return resp
+}
+
+// Run sends reports on an interval, or as soon as a batch is full, until the context is done.
+// Pending records are sent before returning.
+//
+// Errors are reported by the error handler (see WithErrorHandler). With WithDelivery, reports are delivered
+// by the queue, run alongside, and drained before returning.
+func (r *Reporter) Run(ctx context.Context) {
+	if r.queue != nil {
+		done := make(chan struct{})
+		go func() {
+			r.queue.Run(ctx)
+			close(done)
+		}()
+		defer func() {
+			<-done
+			drainCtx, cancel := context.WithTimeout(context.Background(), r.config.timeout)
+			r.queue.Drain(drainCtx)
+			cancel()
+		}()
+	}
+
+	ticker := time.NewTicker(r.config.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), r.config.timeout)
+			r.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			r.flush(ctx)
+		case <-r.full:
+			r.flush(ctx)
+		}
+	}
+}
+
+// Flush sends the records buffered since the last report, or enqueues them with WithDelivery.
+// This is a noop when no operation was recorded.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mx.Lock()
+	report := Report{
+		Size:       len(r.records),
+		Map:        r.operations,
+		Operations: r.records,
+	}
+	r.operations = make(map[string]Operation)
+	r.records = nil
+	r.mx.Unlock()
+
+	if report.Size == 0 {
+		return nil
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if r.queue != nil {
+		return r.queue.Enqueue(body)
+	}
+	return r.send(ctx, body)
+}
+
+func (r *Reporter) flush(ctx context.Context) {
+	if err := r.Flush(ctx); err != nil && r.config.onError != nil {
+		r.config.onError(err)
+	}
+}
+
+func (r *Reporter) sampled(oc *graphql.OperationContext) bool {
+	if r.config.sampler != nil {
+		return r.config.sampler(oc)
+	}
+	return r.config.rate > 0 && rand.Float64() < r.config.rate
+}
+
+func (r *Reporter) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, r.config.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	token := r.token
+	if r.config.token != nil {
+		token = r.config.token()
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Usage-API-Version", "2")
+	req.Header.Set("User-Agent", "gqlgen-contrib-gqlhive")
+
+	resp, err := r.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		text, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("hive usage reporting failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(text))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// operationKey identifies an operation by a hash of its document, name and fields
+func operationKey(operation Operation) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, operation.Operation)
+	_, _ = io.WriteString(h, "\n"+operation.OperationName+"\n")
+	_, _ = io.WriteString(h, strings.Join(operation.Fields, ","))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,192 @@
+package gqlhive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const testSchema = `
+input UserFilter {
+  name: String
+}
+
+type User {
+  id: ID!
+  name: String!
+}
+
+type Query {
+  users(filter: UserFilter): [User!]!
+}
+`
+
+// hive is a fake usage API, yielding the reports it receives
+type hive struct {
+	*httptest.Server
+	reports  chan Report
+	requests chan *http.Request
+	status   int32
+}
+
+func newHive(t *testing.T) *hive {
+	h := &hive{
+		reports:  make(chan Report, 10),
+		requests: make(chan *http.Request, 10),
+		status:   http.StatusOK,
+	}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		h.requests <- r
+		h.reports <- report
+		w.WriteHeader(int(atomic.LoadInt32(&h.status)))
+	}))
+	return h
+}
+
+func newReporter(t *testing.T, opts ...Option) (*Reporter, *graphql.ExecutableSchemaMock) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+	es := &graphql.ExecutableSchemaMock{SchemaFunc: func() *ast.Schema { return schema }}
+
+	r := New("token", opts...)
+	require.Equal(t, extensionName, r.ExtensionName())
+	require.NoError(t, r.Validate(es))
+	return r, es
+}
+
+// execute an operation through the extension, as a client
+func execute(t *testing.T, r *Reporter, es *graphql.ExecutableSchemaMock, client string, resp *graphql.Response) {
+	doc, gqlErrs := gqlparser.LoadQuery(es.Schema(), `query users($filter: UserFilter) { users(filter: $filter) { id name } }`)
+	require.Empty(t, gqlErrs)
+
+	handler := r.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		ctx := graphql.WithOperationContext(req.Context(), &graphql.OperationContext{
+			Doc:           doc,
+			Operation:     doc.Operations.ForName("users"),
+			OperationName: "users",
+			Stats:         graphql.Stats{OperationStart: time.Now().Add(-time.Millisecond)},
+		})
+		// pull responses until nil, as websocket transports do
+		next := graphql.OneShot(resp)
+		for r.InterceptResponse(ctx, next) != nil {
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	if client != "" {
+		req.Header.Set("graphql-client-name", client)
+		req.Header.Set("graphql-client-version", "1.0")
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestFlush(t *testing.T) {
+	h := newHive(t)
+	defer h.Close()
+	r, es := newReporter(t, WithEndpoint(h.URL))
+
+	require.NoError(t, r.Flush(context.Background()), "nothing is sent without records")
+	assert.Empty(t, h.reports)
+
+	execute(t, r, es, "web", &graphql.Response{Data: json.RawMessage(`{"users":[]}`)})
+	execute(t, r, es, "", &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("failed")}})
+	require.NoError(t, r.Flush(context.Background()))
+
+	req := <-h.requests
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	assert.Equal(t, "2", req.Header.Get("X-Usage-API-Version"))
+
+	report := <-h.reports
+	require.Equal(t, 2, report.Size)
+	require.Len(t, report.Map, 1, "executions of the same operation share an entry of the map")
+	for key, operation := range report.Map {
+		assert.Equal(t, "users", operation.OperationName)
+		assert.Equal(t, []string{"ID", "Query", "Query.users", "Query.users.filter", "String", "User", "User.id", "User.name", "UserFilter", "UserFilter.name"}, operation.Fields)
+		assert.Equal(t, key, report.Operations[0].OperationMapKey)
+	}
+
+	assert.True(t, report.Operations[0].Execution.OK)
+	assert.True(t, report.Operations[0].Execution.Duration > 0)
+	require.NotNil(t, report.Operations[0].Metadata)
+	assert.Equal(t, &Client{Name: "web", Version: "1.0"}, report.Operations[0].Metadata.Client)
+
+	assert.False(t, report.Operations[1].Execution.OK)
+	assert.Equal(t, 1, report.Operations[1].Execution.ErrorsTotal)
+	assert.Nil(t, report.Operations[1].Metadata)
+
+	require.NoError(t, r.Flush(context.Background()))
+	assert.Empty(t, h.reports, "records are only sent once")
+}
+
+func TestFlushError(t *testing.T) {
+	h := newHive(t)
+	defer h.Close()
+	atomic.StoreInt32(&h.status, http.StatusUnauthorized)
+	r, es := newReporter(t, WithEndpoint(h.URL))
+
+	execute(t, r, es, "", &graphql.Response{})
+	assert.Error(t, r.Flush(context.Background()))
+}
+
+func TestSampling(t *testing.T) {
+	h := newHive(t)
+	defer h.Close()
+	r, es := newReporter(t, WithEndpoint(h.URL), WithSampleRate(0))
+
+	execute(t, r, es, "", &graphql.Response{})
+	require.NoError(t, r.Flush(context.Background()))
+	assert.Empty(t, h.reports, "unsampled operations are not recorded")
+}
+
+func TestRun(t *testing.T) {
+	h := newHive(t)
+	defer h.Close()
+	errs := make(chan error, 10)
+	r, es := newReporter(t,
+		WithEndpoint(h.URL),
+		WithInterval(time.Hour),
+		WithMaxBatchSize(2),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	// a full batch is sent right away
+	execute(t, r, es, "", &graphql.Response{})
+	execute(t, r, es, "", &graphql.Response{})
+	report := <-h.reports
+	assert.Equal(t, 2, report.Size)
+
+	// failures are reported to the error handler
+	atomic.StoreInt32(&h.status, http.StatusInternalServerError)
+	execute(t, r, es, "", &graphql.Response{})
+	execute(t, r, es, "", &graphql.Response{})
+	<-h.reports
+	assert.Error(t, <-errs)
+	atomic.StoreInt32(&h.status, http.StatusOK)
+
+	// pending records are sent when Run returns
+	execute(t, r, es, "", &graphql.Response{})
+	cancel()
+	<-done
+	report = <-h.reports
+	assert.Equal(t, 1, report.Size)
+}
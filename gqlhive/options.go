@@ -0,0 +1,153 @@
+package gqlhive
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqldelivery"
+)
+
+type (
+	// Option for the usage reporter
+	Option func(*config)
+
+	config struct {
+		endpoint            string
+		client              *http.Client
+		interval            time.Duration
+		timeout             time.Duration
+		maxBatchSize        int
+		rate                float64
+		sampler             func(*graphql.OperationContext) bool
+		clientNameHeader    string
+		clientVersionHeader string
+		clientInfo          func(context.Context) (string, string)
+		token               func() string
+		onError             func(error)
+		delivery            bool
+		deliveryOptions     []gqldelivery.Option
+		clock               func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		endpoint:            DefaultEndpoint,
+		client:              http.DefaultClient,
+		interval:            5 * time.Second,
+		timeout:             10 * time.Second,
+		maxBatchSize:        1000,
+		rate:                1,
+		clientNameHeader:    "graphql-client-name",
+		clientVersionHeader: "graphql-client-version",
+		clock:               graphql.Now,
+	}
+}
+
+// WithEndpoint sets the usage API endpoint, e.g. for a self-hosted Hive. The default is DefaultEndpoint.
+func WithEndpoint(endpoint string) Option {
+	return func(c *config) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send reports. The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithInterval sets the interval between reports sent by Run. The default is 5s.
+func WithInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithTimeout sets the timeout of the last report, sent when Run returns. The default is 10s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxBatchSize sets the number of buffered records which triggers a report before the interval elapses.
+// The default is 1000.
+func WithMaxBatchSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.maxBatchSize = size
+		}
+	}
+}
+
+// WithSampleRate sets the fraction of operations reported, between 0 and 1. The default is 1.
+func WithSampleRate(rate float64) Option {
+	return func(c *config) {
+		c.rate = rate
+	}
+}
+
+// WithSampler sets the function deciding whether an operation is reported, e.g. to always report mutations.
+// This takes precedence over the sample rate.
+func WithSampler(sampler func(*graphql.OperationContext) bool) Option {
+	return func(c *config) {
+		c.sampler = sampler
+	}
+}
+
+// WithClientHeaders sets the request headers identifying the client name and version, captured by the Middleware.
+// The defaults are "graphql-client-name" and "graphql-client-version".
+func WithClientHeaders(name, version string) Option {
+	return func(c *config) {
+		c.clientNameHeader = name
+		c.clientVersionHeader = version
+	}
+}
+
+// WithClientInfo sets the function identifying the client name and version from the context, e.g. from an API key.
+// This takes precedence over the client headers.
+func WithClientInfo(info func(context.Context) (name, version string)) Option {
+	return func(c *config) {
+		c.clientInfo = info
+	}
+}
+
+// WithTokenFunc sets the function yielding the access token for each report, e.g. the value of a rotated secret.
+// This takes precedence over the token of the Reporter.
+func WithTokenFunc(token func() string) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithErrorHandler sets a handler for errors sending reports in Run. By default, errors are ignored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithDelivery sends reports through a gqldelivery.Queue, with these options: reports failing to be sent
+// are retried in the background, and may be spilled to disk or handed over to a dead letter handler.
+//
+// By default, reports failing to be sent are dropped.
+func WithDelivery(opts ...gqldelivery.Option) Option {
+	return func(c *config) {
+		c.delivery = true
+		c.deliveryOptions = append(c.deliveryOptions, opts...)
+	}
+}
+
+// WithClock sets the clock used to timestamp records. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
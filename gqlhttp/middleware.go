@@ -0,0 +1,140 @@
+// Package gqlhttp provides a HTTP middleware enforcing the GraphQL over HTTP specification
+// on top of the gqlgen transports, where gqlgen defaults diverge from the specification:
+//
+//   - Accept negotiation, with support for the application/graphql-response+json media type
+//   - status codes distinguishing request errors from execution errors
+//
+// See https://graphql.github.io/graphql-over-http/
+//
+// Example:
+//
+//   http.Handle("/query", gqlhttp.Middleware(srv))
+package gqlhttp
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+const (
+	// MediaTypeGraphQLResponse is the media type of GraphQL responses defined by the GraphQL over HTTP specification
+	MediaTypeGraphQLResponse = "application/graphql-response+json"
+
+	// MediaTypeJSON is the legacy media type of GraphQL responses
+	MediaTypeJSON = "application/json"
+)
+
+// Middleware enforces the GraphQL over HTTP specification on a GraphQL handler.
+//
+// Responses are served with the media type negotiated with the Accept header of the request:
+// application/graphql-response+json when accepted, application/json otherwise.
+// Requests accepting none of these media types are rejected with 406 Not Acceptable.
+//
+// With application/graphql-response+json, request errors (such as parsing or validation errors) are served with
+// 400 Bad Request, while responses which reached execution are served with 200 OK, even when they contain
+// execution errors. With application/json, all GraphQL responses are served with 200 OK.
+//
+// Responses are not buffered: streaming responses are flushed as they are written.
+// Websocket upgrade requests are passed through untouched.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mediaType, ok := negotiate(r.Header.Get("Accept"), cfg.legacyFirst)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			return
+		}
+
+		// responses are not buffered, so that streaming transports may flush them
+		next.ServeHTTP(httpwriter.New(w, func(status int) int {
+			return setMediaType(w.Header(), mediaType, status)
+		}), r)
+	})
+}
+
+// negotiate the media type of the response from the Accept header
+func negotiate(accept string, legacyFirst bool) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		// no Accept header: the client accepts anything
+		if legacyFirst {
+			return MediaTypeJSON, true
+		}
+		return MediaTypeGraphQLResponse, true
+	}
+
+	var acceptsGraphQL, acceptsJSON, acceptsAny bool
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight <= 0 {
+				continue
+			}
+		}
+		switch mediaType {
+		case MediaTypeGraphQLResponse:
+			acceptsGraphQL = true
+		case MediaTypeJSON:
+			acceptsJSON = true
+		case "*/*", "application/*":
+			acceptsAny = true
+		}
+	}
+
+	switch {
+	case acceptsGraphQL && !(legacyFirst && acceptsJSON):
+		return MediaTypeGraphQLResponse, true
+	case acceptsJSON:
+		return MediaTypeJSON, true
+	case acceptsAny && legacyFirst:
+		return MediaTypeJSON, true
+	case acceptsAny:
+		return MediaTypeGraphQLResponse, true
+	default:
+		return "", false
+	}
+}
+
+// responseStatus determines the status code of a GraphQL response.
+//
+// gqlgen reports request errors (parsing and validation) with 422 Unprocessable Entity, and responses which reached
+// the execution stage with 200 OK.
+func responseStatus(mediaType string, status int) int {
+	if status != http.StatusUnprocessableEntity {
+		// execution results, as well as transport errors such as a malformed request body, are left untouched
+		return status
+	}
+	if mediaType == MediaTypeGraphQLResponse {
+		return http.StatusBadRequest
+	}
+	return http.StatusOK
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == MediaTypeJSON || mediaType == MediaTypeGraphQLResponse)
+}
+
+// setMediaType sets the negotiated media type and the status code of GraphQL responses
+func setMediaType(header http.Header, mediaType string, status int) int {
+	if isJSON(header.Get("Content-Type")) {
+		status = responseStatus(mediaType, status)
+		header.Set("Content-Type", mediaType+"; charset=utf-8")
+	}
+	return status
+}
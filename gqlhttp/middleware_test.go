@@ -0,0 +1,73 @@
+package gqlhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	gqlHandler := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"boom"}],"data":null}`))
+		})
+	}
+
+	for _, tc := range []struct {
+		name        string
+		accept      string
+		status      int
+		legacy      bool
+		expected    int
+		contentType string
+	}{
+		{name: "validation error", accept: MediaTypeGraphQLResponse, status: http.StatusUnprocessableEntity, expected: http.StatusBadRequest, contentType: MediaTypeGraphQLResponse},
+		{name: "validation error, legacy", accept: MediaTypeJSON, status: http.StatusUnprocessableEntity, expected: http.StatusOK, contentType: MediaTypeJSON},
+		{name: "execution error", accept: MediaTypeGraphQLResponse, status: http.StatusOK, expected: http.StatusOK, contentType: MediaTypeGraphQLResponse},
+		{name: "malformed request", accept: MediaTypeGraphQLResponse, status: http.StatusBadRequest, expected: http.StatusBadRequest, contentType: MediaTypeGraphQLResponse},
+		{name: "no preference", accept: "", status: http.StatusOK, expected: http.StatusOK, contentType: MediaTypeGraphQLResponse},
+		{name: "no preference, legacy", accept: "*/*", legacy: true, status: http.StatusOK, expected: http.StatusOK, contentType: MediaTypeJSON},
+		{name: "both accepted", accept: "application/json;q=0.9, application/graphql-response+json", status: http.StatusOK, expected: http.StatusOK, contentType: MediaTypeGraphQLResponse},
+		{name: "not acceptable", accept: "text/html", status: http.StatusOK, expected: http.StatusNotAcceptable},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+
+			Middleware(gqlHandler(tc.status), PreferLegacyJSON(tc.legacy)).ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.contentType != "" {
+				assert.Equal(t, tc.contentType+"; charset=utf-8", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestMiddlewareStreaming(t *testing.T) {
+	w := httptest.NewRecorder()
+	Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=-")
+		_, _ = w.Write([]byte("---\r\n"))
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok)
+		flusher.Flush()
+
+		_, isHijacker := w.(http.Hijacker)
+		assert.True(t, isHijacker)
+	})).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/query", nil))
+
+	assert.True(t, w.Flushed)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "multipart/mixed; boundary=-", w.Header().Get("Content-Type"))
+	assert.Equal(t, "---\r\n", w.Body.String())
+}
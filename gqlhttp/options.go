@@ -0,0 +1,22 @@
+package gqlhttp
+
+type (
+	// Option for the GraphQL over HTTP middleware
+	Option func(*config)
+
+	config struct {
+		legacyFirst bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// PreferLegacyJSON serves application/json rather than application/graphql-response+json when the client accepts both,
+// or does not express any preference. This eases the transition of legacy clients.
+func PreferLegacyJSON(enabled bool) Option {
+	return func(c *config) {
+		c.legacyFirst = enabled
+	}
+}
@@ -0,0 +1,257 @@
+// Package gqlidempotency provides a gqlgen extension honoring idempotency keys for mutations.
+//
+// The response to a mutation carrying an idempotency key is stored, keyed by client and idempotency key,
+// and replayed when the client retries the same mutation with the same key. This prevents duplicate
+// side effects from network retries.
+//
+// The idempotency key is retrieved from the "Idempotency-Key" HTTP header, captured by the Middleware,
+// or from a variable of the operation (see WithVariable).
+//
+// Responses are stored in a gqlcache.Store. Idempotency keys are scoped to the client of the request, which must be
+// identified with WithClient: requests without a client are executed as if they carried no idempotency key.
+//
+// Example:
+//
+//   idem := gqlidempotency.New(store, gqlidempotency.WithClient(apiKeyFromContext))
+//   srv.Use(idem)
+//   http.Handle("/query", idem.Middleware(srv))
+package gqlidempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "Idempotency"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Idempotency{}
+
+type (
+	// Idempotency is a gqlgen extension which stores the responses to mutations carrying an idempotency key,
+	// and replays them on retries.
+	//
+	// Every completed response is stored, including responses with errors: some fields of a mutation may have
+	// been committed before another one failed, so that executing it again would repeat their side effects.
+	// Clients retry a failed mutation with a new key.
+	//
+	// Reusing a key for a different request (i.e. a different query, operation name or variables) yields an error.
+	// So does a retry while the original request is still in progress on this server.
+	Idempotency struct {
+		*config
+		store gqlcache.Store
+
+		mx       sync.Mutex
+		inflight map[string]struct{}
+	}
+
+	record struct {
+		Fingerprint string            `json:"fingerprint"`
+		Response    *graphql.Response `json:"response"`
+	}
+
+	keyKey struct{}
+)
+
+// New Idempotency extension, storing responses in the store
+func New(store gqlcache.Store, opts ...Option) *Idempotency {
+	i := &Idempotency{
+		config:   defaultConfig(),
+		store:    store,
+		inflight: make(map[string]struct{}),
+	}
+	for _, apply := range opts {
+		apply(i.config)
+	}
+	return i
+}
+
+// WithKey returns a context carrying an idempotency key
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// KeyFromContext retrieves the idempotency key from the context, if any
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyKey{}).(string)
+	return key
+}
+
+// Middleware captures the idempotency key from the request header. The default header is "Idempotency-Key".
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(i.config.header); key != "" {
+			r = r.WithContext(WithKey(r.Context(), key))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ExtensionName yields the extension name: "Idempotency"
+func (*Idempotency) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the configuration: the client of requests must be identified
+func (i *Idempotency) Validate(schema graphql.ExecutableSchema) error {
+	if i.config.client == nil {
+		return fmt.Errorf("no client configured: idempotency keys must be scoped to a client with WithClient")
+	}
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor.
+//
+// Replayed responses and rejections are yielded once, so that websocket operations terminate.
+func (i *Idempotency) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	idempotencyKey := i.idempotencyKey(ctx, oc)
+	if idempotencyKey == "" {
+		return next(ctx)
+	}
+
+	client := i.clientOf(ctx)
+	if client == "" {
+		return next(ctx)
+	}
+
+	fingerprint, err := fingerprint(oc)
+	if err != nil {
+		return next(ctx)
+	}
+
+	key := i.storeKey(client, idempotencyKey)
+	if !i.acquire(key) {
+		return graphql.OneShot(graphql.ErrorResponse(ctx, "a request with this idempotency key is in progress"))
+	}
+
+	rec, found := i.lookup(ctx, key)
+	if found {
+		i.release(key)
+		if rec.Fingerprint != fingerprint {
+			return graphql.OneShot(graphql.ErrorResponse(ctx, "this idempotency key has already been used for a different request"))
+		}
+
+		_ = stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(metrics.TagOperation, i.config.opLabel(oc))},
+			ReplayedCount.M(1),
+		)
+		return graphql.OneShot(rec.Response)
+	}
+
+	handler := next(ctx)
+	var done bool
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if done {
+			return resp
+		}
+		done = true
+		defer i.release(key)
+
+		if resp != nil {
+			i.save(ctx, key, record{Fingerprint: fingerprint, Response: resp})
+		}
+		return resp
+	}
+}
+
+func (i *Idempotency) idempotencyKey(ctx context.Context, oc *graphql.OperationContext) string {
+	if key := KeyFromContext(ctx); key != "" {
+		return key
+	}
+	if i.config.variable == "" {
+		return ""
+	}
+	key, _ := oc.Variables[i.config.variable].(string)
+	return key
+}
+
+func (i *Idempotency) clientOf(ctx context.Context) string {
+	if i.config.client == nil {
+		return ""
+	}
+	return i.config.client(ctx)
+}
+
+// storeKey scopes the idempotency key to the client
+func (i *Idempotency) storeKey(client, idempotencyKey string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(client))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(idempotencyKey))
+	return i.config.prefix + hex.EncodeToString(h.Sum(nil))
+}
+
+func (i *Idempotency) acquire(key string) bool {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	if _, busy := i.inflight[key]; busy {
+		return false
+	}
+	i.inflight[key] = struct{}{}
+	return true
+}
+
+func (i *Idempotency) release(key string) {
+	i.mx.Lock()
+	delete(i.inflight, key)
+	i.mx.Unlock()
+}
+
+// lookup a stored response. Store errors are reported as misses.
+func (i *Idempotency) lookup(ctx context.Context, key string) (record, bool) {
+	var rec record
+	buf, found, err := i.store.Get(ctx, key)
+	if err != nil || !found {
+		return rec, false
+	}
+	if err := json.Unmarshal(buf, &rec); err != nil || rec.Response == nil {
+		return rec, false
+	}
+	return rec, true
+}
+
+func (i *Idempotency) save(ctx context.Context, key string, rec record) {
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	_ = i.store.Set(ctx, key, buf, i.config.ttl)
+}
+
+// fingerprint identifies a request, to detect idempotency keys reused for a different request
+func fingerprint(oc *graphql.OperationContext) (string, error) {
+	variables, err := json.Marshal(oc.Variables)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(oc.RawQuery))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(oc.OperationName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(variables)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
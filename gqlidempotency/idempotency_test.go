@@ -0,0 +1,171 @@
+package gqlidempotency
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+)
+
+type clientKey struct{}
+
+func clientScope(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
+
+func withClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+func TestIdempotency(t *testing.T) {
+	idem := New(gqlcache.NewMemoryStore(), WithVariable("idempotencyKey"), WithClient(clientScope))
+	require.Equal(t, extensionName, idem.ExtensionName())
+	require.NoError(t, idem.Validate(nil))
+
+	var executions int
+	h := func(_ context.Context) *graphql.Response {
+		executions++
+		return &graphql.Response{Data: json.RawMessage(`{"pay":{"id":"1"}}`)}
+	}
+
+	// intercept pulls the first response of an operation executing the response handler once, like mutations
+	intercept := func(ctx context.Context, h graphql.ResponseHandler) *graphql.Response {
+		return idem.InterceptOperation(ctx, once(h))(ctx)
+	}
+
+	mutation := func(ctx context.Context, amount int) context.Context {
+		return graphql.WithOperationContext(ctx, &graphql.OperationContext{
+			RawQuery:      "mutation pay($amount: Int!) { pay(amount: $amount) { id } }",
+			OperationName: "pay",
+			Operation:     &ast.OperationDefinition{Operation: ast.Mutation, Name: "pay"},
+			Variables:     map[string]interface{}{"amount": amount},
+		})
+	}
+
+	t.Run("without key, mutations are executed", func(t *testing.T) {
+		ctx := mutation(context.Background(), 10)
+		intercept(ctx, h)
+		intercept(ctx, h)
+		require.Equal(t, 2, executions)
+	})
+
+	t.Run("with key, retries are replayed", func(t *testing.T) {
+		executions = 0
+		var ctx context.Context
+		handler := idem.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req = req.WithContext(withClient(req.Context(), "billing"))
+		req.Header.Set("Idempotency-Key", "abc")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, "abc", KeyFromContext(ctx))
+
+		ctx = mutation(ctx, 10)
+		first := intercept(ctx, h)
+		second := intercept(ctx, h)
+		require.Equal(t, 1, executions)
+		assert.JSONEq(t, string(first.Data), string(second.Data))
+
+		resp := intercept(mutation(WithKey(withClient(context.Background(), "billing"), "abc"), 20), h)
+		require.Equal(t, 1, executions)
+		require.Len(t, resp.Errors, 1)
+
+		// keys are scoped to the client
+		intercept(mutation(WithKey(withClient(context.Background(), "search"), "abc"), 20), h)
+		require.Equal(t, 2, executions)
+	})
+
+	t.Run("with key from variable, retries are replayed", func(t *testing.T) {
+		executions = 0
+		ctx := mutation(withClient(context.Background(), "billing"), 10)
+		graphql.GetOperationContext(ctx).Variables["idempotencyKey"] = "def"
+		intercept(ctx, h)
+		intercept(ctx, h)
+		require.Equal(t, 1, executions)
+	})
+
+	t.Run("without client, keys are ignored", func(t *testing.T) {
+		executions = 0
+		ctx := mutation(WithKey(context.Background(), "jkl"), 10)
+		intercept(ctx, h)
+		intercept(ctx, h)
+		require.Equal(t, 2, executions)
+	})
+
+	t.Run("responses with errors are replayed", func(t *testing.T) {
+		executions = 0
+		partial := func(_ context.Context) *graphql.Response {
+			executions++
+			return &graphql.Response{
+				Data:   json.RawMessage(`{"pay":{"id":"2"},"notify":null}`),
+				Errors: gqlerror.List{gqlerror.Errorf("notification failed")},
+			}
+		}
+		ctx := mutation(WithKey(withClient(context.Background(), "billing"), "mno"), 30)
+		first := intercept(ctx, partial)
+		second := intercept(ctx, partial)
+		require.Equal(t, 1, executions, "committed fields are not executed again")
+		assert.JSONEq(t, string(first.Data), string(second.Data))
+		require.Len(t, second.Errors, 1)
+		assert.Equal(t, "notification failed", second.Errors[0].Message)
+	})
+
+	t.Run("responses are yielded once", func(t *testing.T) {
+		executions = 0
+		ctx := mutation(WithKey(withClient(context.Background(), "billing"), "pqr"), 40)
+
+		// the websocket transport pulls responses until it gets nil
+		for _, replay := range []bool{false, true} {
+			handler := idem.InterceptOperation(ctx, once(h))
+			require.NotNil(t, handler(ctx), "replay: %t", replay)
+			assert.Nil(t, handler(ctx), "replay: %t", replay)
+		}
+		require.Equal(t, 1, executions)
+
+		ctx = mutation(WithKey(withClient(context.Background(), "billing"), "pqr"), 50)
+		handler := idem.InterceptOperation(ctx, once(h))
+		resp := handler(ctx)
+		require.Len(t, resp.Errors, 1)
+		assert.Nil(t, handler(ctx))
+	})
+
+	t.Run("queries are ignored", func(t *testing.T) {
+		executions = 0
+		ctx := graphql.WithOperationContext(WithKey(withClient(context.Background(), "billing"), "ghi"), &graphql.OperationContext{
+			RawQuery:  "query { a }",
+			Operation: &ast.OperationDefinition{Operation: ast.Query},
+		})
+		intercept(ctx, h)
+		intercept(ctx, h)
+		require.Equal(t, 2, executions)
+	})
+}
+
+// once yields an operation handler executing a response handler once
+func once(h graphql.ResponseHandler) graphql.OperationHandler {
+	return func(context.Context) graphql.ResponseHandler {
+		var done bool
+		return func(ctx context.Context) *graphql.Response {
+			if done {
+				return nil
+			}
+			done = true
+			return h(ctx)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.Error(t, New(gqlcache.NewMemoryStore()).Validate(nil), "idempotency keys must be scoped to a client")
+}
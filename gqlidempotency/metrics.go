@@ -0,0 +1,39 @@
+package gqlidempotency
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(ReplayedCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(ReplayedCountView)
+}
+
+var (
+	// ReplayedCount tracks a count of mutations replayed from a stored response
+	ReplayedCount = stats.Int64(
+		"gql/server/idempotent_replayed_count",
+		"Number of GraphQL mutations replayed from a stored response",
+		stats.UnitDimensionless)
+
+	// ReplayedCountView reports a count of replayed mutations tagged by operation name
+	ReplayedCountView = &view.View{
+		Name:        "gql/server/idempotent_replayed_count",
+		Description: "Count of GraphQL mutations replayed by operation",
+		Measure:     ReplayedCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
@@ -0,0 +1,84 @@
+package gqlidempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the idempotency extension
+	Option func(*config)
+
+	config struct {
+		header   string
+		variable string
+		client   func(context.Context) string
+		prefix   string
+		ttl      time.Duration
+		opLabel  gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		opLabel: gqllabel.OperationName,
+		header:  "Idempotency-Key",
+		prefix:  "idempotency:",
+		ttl:     24 * time.Hour,
+	}
+}
+
+// WithHeader sets the request header carrying the idempotency key, captured by the Middleware.
+// The default is "Idempotency-Key".
+func WithHeader(header string) Option {
+	return func(c *config) {
+		c.header = header
+	}
+}
+
+// WithVariable sets the name of an operation variable carrying the idempotency key, for clients which
+// cannot set headers. The header, when present, takes precedence. By default, no variable is used.
+//
+// Example, with WithVariable("idempotencyKey"):
+//
+//   mutation pay($idempotencyKey: String, $amount: Int!) { pay(amount: $amount) { id } }
+func WithVariable(name string) Option {
+	return func(c *config) {
+		c.variable = name
+	}
+}
+
+// WithClient sets the function to identify the client from the context, e.g. from an API key.
+// Idempotency keys are scoped to the client. This option is required.
+//
+// Requests for which the client yields "" are executed without honoring their idempotency key.
+func WithClient(client func(context.Context) string) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithPrefix sets a prefix for all store keys. The default is "idempotency:".
+func WithPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// WithTTL sets how long responses are retained for replay. The default is 24 hours.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
@@ -0,0 +1,118 @@
+// Package gqllabel produces metric labels for GraphQL operations, with a bounded cardinality.
+//
+// Metrics keyed by operation names may explode in cardinality when clients send unnamed operations,
+// or arbitrary operation names. The Sanitizer maps such operations to a bounded set of labels.
+//
+// All metrics packages in this repository accept an OperationLabeler, for instance:
+//
+//   sanitizer := gqllabel.NewSanitizer(
+//     gqllabel.WithKnownOperations(names...),
+//     gqllabel.WithSignatureBuckets(16),
+//   )
+//   srv.Use(metrics.New(metrics.WithOperationLabel(sanitizer.Label)))
+package gqllabel
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const (
+	// Invalid is the label of operations which failed to parse or validate
+	Invalid = "invalid"
+
+	// AnonymousPrefix prefixes the label of unnamed operations, e.g. "anonymous/query"
+	AnonymousPrefix = "anonymous/"
+
+	// UnknownPrefix prefixes the label of operations with an unknown name, e.g. "unknown/mutation"
+	UnknownPrefix = "unknown/"
+)
+
+type (
+	// OperationLabeler yields the metric label of an operation
+	OperationLabeler func(*graphql.OperationContext) string
+
+	// Sanitizer maps operations to a bounded set of metric labels.
+	//
+	// Operations are labeled as follows:
+	//   - known operations are labeled by name
+	//   - unnamed operations are labeled by type, e.g. "anonymous/query"
+	//   - operations with an unknown name are labeled by type, e.g. "unknown/query"
+	//   - operations which failed to parse are labeled "invalid"
+	//
+	// With signature buckets, unnamed and unknown operations are further spread over a fixed number of buckets,
+	// by a hash of the query, e.g. "anonymous/query/3".
+	Sanitizer struct {
+		*config
+		known map[string]struct{}
+	}
+)
+
+var _ OperationLabeler = OperationName
+
+// OperationName yields the name of an operation, or its type when it is unnamed. This is the default OperationLabeler.
+//
+// Notice that this labeler does not bound the cardinality of operation names.
+func OperationName(oc *graphql.OperationContext) (opName string) {
+	if oc == nil {
+		return ""
+	}
+	if oc.Operation != nil {
+		opName = oc.Operation.Name
+	}
+	if opName == "" && oc.Operation != nil {
+		//parent response case
+		opName = string(oc.Operation.Operation)
+	}
+	if opName == "" {
+		opName = oc.OperationName
+	}
+	return
+}
+
+// NewSanitizer builds a Sanitizer for operation labels
+func NewSanitizer(opts ...Option) *Sanitizer {
+	s := &Sanitizer{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(s.config)
+	}
+	if s.config.known != nil {
+		s.known = make(map[string]struct{}, len(s.config.known))
+		for _, name := range s.config.known {
+			s.known[name] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Label yields the sanitized label of an operation. Its signature is an OperationLabeler.
+func (s *Sanitizer) Label(oc *graphql.OperationContext) string {
+	if oc == nil || oc.Operation == nil {
+		return Invalid
+	}
+
+	opType := string(oc.Operation.Operation)
+	name := oc.Operation.Name
+	switch {
+	case name == "":
+		return s.bucket(AnonymousPrefix+opType, oc.RawQuery)
+	case s.known != nil:
+		if _, ok := s.known[name]; !ok {
+			return s.bucket(UnknownPrefix+opType, oc.RawQuery)
+		}
+	}
+	return name
+}
+
+func (s *Sanitizer) bucket(label, query string) string {
+	if s.config.buckets <= 0 {
+		return label
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(query))
+	return label + "/" + strconv.FormatUint(uint64(h.Sum32()%uint32(s.config.buckets)), 10)
+}
@@ -0,0 +1,46 @@
+package gqllabel
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestSanitizer(t *testing.T) {
+	operation := func(name string, opType ast.Operation, query string) *graphql.OperationContext {
+		return &graphql.OperationContext{
+			RawQuery:  query,
+			Operation: &ast.OperationDefinition{Operation: opType, Name: name},
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		s := NewSanitizer()
+		assert.Equal(t, "getUser", s.Label(operation("getUser", ast.Query, "query getUser { a }")))
+		assert.Equal(t, "anonymous/mutation", s.Label(operation("", ast.Mutation, "mutation { a }")))
+		assert.Equal(t, Invalid, s.Label(&graphql.OperationContext{RawQuery: "{"}))
+		assert.Equal(t, Invalid, s.Label(nil))
+	})
+
+	t.Run("known operations", func(t *testing.T) {
+		s := NewSanitizer(WithKnownOperations("getUser"))
+		assert.Equal(t, "getUser", s.Label(operation("getUser", ast.Query, "query getUser { a }")))
+		assert.Equal(t, "unknown/query", s.Label(operation("random123", ast.Query, "query random123 { a }")))
+	})
+
+	t.Run("signature buckets", func(t *testing.T) {
+		s := NewSanitizer(WithKnownOperations(), WithSignatureBuckets(4))
+		label := s.Label(operation("", ast.Query, "{ a }"))
+		assert.Regexp(t, `^anonymous/query/[0-3]$`, label)
+		assert.Equal(t, label, s.Label(operation("", ast.Query, "{ a }")))
+		assert.Regexp(t, `^unknown/query/[0-3]$`, s.Label(operation("random", ast.Query, "query random { a }")))
+	})
+
+	t.Run("operation name", func(t *testing.T) {
+		assert.Equal(t, "getUser", OperationName(operation("getUser", ast.Query, "")))
+		assert.Equal(t, "subscription", OperationName(operation("", ast.Subscription, "")))
+		assert.Equal(t, "", OperationName(nil))
+	})
+}
@@ -0,0 +1,36 @@
+package gqllabel
+
+type (
+	// Option for the label Sanitizer
+	Option func(*config)
+
+	config struct {
+		known   []string
+		buckets int
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithKnownOperations declares the names of known operations, e.g. from a persisted query manifest.
+// Operations with other names are labeled as unknown.
+//
+// By default, all operation names are considered known.
+func WithKnownOperations(names ...string) Option {
+	return func(c *config) {
+		if c.known == nil {
+			c.known = make([]string, 0, len(names))
+		}
+		c.known = append(c.known, names...)
+	}
+}
+
+// WithSignatureBuckets spreads unnamed and unknown operations over n buckets, by a hash of their query.
+// By default, there are no buckets.
+func WithSignatureBuckets(n int) Option {
+	return func(c *config) {
+		c.buckets = n
+	}
+}
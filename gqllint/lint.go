@@ -0,0 +1,289 @@
+// Package gqllint provides a gqlgen extension linting the executable schema at server startup.
+//
+// Rules check the schema for common design issues: missing descriptions, unbounded lists without pagination
+// arguments, nullable IDs and deprecations without a reason. Issues fail the startup of the server,
+// or are only reported as warnings.
+//
+// Example:
+//
+//   srv.Use(gqllint.New(
+//     gqllint.WithWarnings(),
+//     gqllint.WithHandler(func(issue gqllint.Issue) { log.Println("schema lint:", issue) }),
+//     gqllint.WithIgnore("Query.legacyUsers"),
+//   ))
+//
+// gqlgen validates extensions as they are registered: when issues fail the startup, srv.Use panics.
+package gqllint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const extensionName = "SchemaLint"
+
+var _ interface {
+	graphql.HandlerExtension
+} = &Linter{}
+
+type (
+	// Issue found by a rule
+	Issue struct {
+		// Rule which found the issue
+		Rule string
+
+		// Location of the issue, e.g. "User" or "User.email"
+		Location string
+
+		Message string
+	}
+
+	// Issues found in a schema
+	Issues []Issue
+
+	// Rule checks a schema, yielding the issues it finds
+	Rule func(schema *ast.Schema) Issues
+
+	// Linter is a gqlgen extension linting the executable schema
+	Linter struct {
+		*config
+	}
+)
+
+// Rule names
+const (
+	RuleMissingDescription       = "missing-description"
+	RuleUnboundedList            = "unbounded-list"
+	RuleNullableID               = "nullable-id"
+	RuleDeprecationWithoutReason = "deprecation-without-reason"
+)
+
+// DefaultPaginationArgs are the arguments bounding lists
+var DefaultPaginationArgs = []string{"first", "last", "limit"}
+
+// New schema Linter
+func New(opts ...Option) *Linter {
+	l := &Linter{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(l.config)
+	}
+	return l
+}
+
+// ExtensionName yields the extension name: "SchemaLint"
+func (*Linter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate lints the schema. Issues are reported to the handlers (see WithHandler), then returned as an error
+// unless they are only warnings (see WithWarnings).
+func (l *Linter) Validate(schema graphql.ExecutableSchema) error {
+	issues := l.Lint(schema.Schema())
+	for _, issue := range issues {
+		for _, handle := range l.config.handlers {
+			handle(issue)
+		}
+	}
+	if len(issues) == 0 || l.config.warnings {
+		return nil
+	}
+	return issues
+}
+
+// Lint a schema with the configured rules, skipping ignored locations
+func (l *Linter) Lint(schema *ast.Schema) Issues {
+	var issues Issues
+	for _, issue := range Lint(schema, l.config.rules...) {
+		if !l.config.ignored[issue.Location] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// Lint a schema with rules
+func Lint(schema *ast.Schema, rules ...Rule) Issues {
+	var issues Issues
+	for _, rule := range rules {
+		issues = append(issues, rule(schema)...)
+	}
+	return issues
+}
+
+// DefaultRules yields all the rules of this package, with default settings
+func DefaultRules() []Rule {
+	return []Rule{
+		MissingDescriptions(),
+		UnboundedLists(DefaultPaginationArgs...),
+		NullableIDs(),
+		DeprecationsWithoutReason(),
+	}
+}
+
+// MissingDescriptions reports types and fields without a description
+func MissingDescriptions() Rule {
+	return func(schema *ast.Schema) Issues {
+		var issues Issues
+		for _, def := range definitions(schema) {
+			if strings.TrimSpace(def.Description) == "" {
+				issues = append(issues, Issue{
+					Rule:     RuleMissingDescription,
+					Location: def.Name,
+					Message:  fmt.Sprintf("%s %s has no description", strings.ToLower(string(def.Kind)), def.Name),
+				})
+			}
+			for _, field := range def.Fields {
+				if isIntrospection(field.Name) || strings.TrimSpace(field.Description) != "" {
+					continue
+				}
+				issues = append(issues, Issue{
+					Rule:     RuleMissingDescription,
+					Location: def.Name + "." + field.Name,
+					Message:  "field has no description",
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// UnboundedLists reports fields of objects and interfaces returning lists of objects, without any of
+// the pagination arguments. These replace DefaultPaginationArgs, e.g. UnboundedLists("first", "order").
+func UnboundedLists(paginationArgs ...string) Rule {
+	return func(schema *ast.Schema) Issues {
+		var issues Issues
+		for _, def := range definitions(schema) {
+			if def.Kind != ast.Object && def.Kind != ast.Interface {
+				continue
+			}
+			for _, field := range def.Fields {
+				if isIntrospection(field.Name) || field.Type.Elem == nil || !isComposite(schema, field.Type) {
+					continue
+				}
+				if hasArgument(field, paginationArgs) {
+					continue
+				}
+				issues = append(issues, Issue{
+					Rule:     RuleUnboundedList,
+					Location: def.Name + "." + field.Name,
+					Message: fmt.Sprintf("list %s has no pagination argument (one of %s)",
+						field.Type.String(), strings.Join(paginationArgs, ", ")),
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// NullableIDs reports "id" fields of objects and interfaces which are nullable
+func NullableIDs() Rule {
+	return func(schema *ast.Schema) Issues {
+		var issues Issues
+		for _, def := range definitions(schema) {
+			if def.Kind != ast.Object && def.Kind != ast.Interface {
+				continue
+			}
+			for _, field := range def.Fields {
+				if field.Name != "id" || field.Type.NonNull {
+					continue
+				}
+				issues = append(issues, Issue{
+					Rule:     RuleNullableID,
+					Location: def.Name + "." + field.Name,
+					Message:  fmt.Sprintf("id is nullable: use %s!", field.Type.String()),
+				})
+			}
+		}
+		return issues
+	}
+}
+
+// DeprecationsWithoutReason reports fields and enum values deprecated without a reason
+func DeprecationsWithoutReason() Rule {
+	return func(schema *ast.Schema) Issues {
+		var issues Issues
+		for _, def := range definitions(schema) {
+			for _, field := range def.Fields {
+				if isDeprecatedWithoutReason(field.Directives) {
+					issues = append(issues, Issue{
+						Rule:     RuleDeprecationWithoutReason,
+						Location: def.Name + "." + field.Name,
+						Message:  "field is deprecated without a reason",
+					})
+				}
+			}
+			for _, value := range def.EnumValues {
+				if isDeprecatedWithoutReason(value.Directives) {
+					issues = append(issues, Issue{
+						Rule:     RuleDeprecationWithoutReason,
+						Location: def.Name + "." + value.Name,
+						Message:  "enum value is deprecated without a reason",
+					})
+				}
+			}
+		}
+		return issues
+	}
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s [%s]", i.Location, i.Message, i.Rule)
+}
+
+// Error implements error
+func (i Issues) Error() string {
+	messages := make([]string, 0, len(i))
+	for _, issue := range i {
+		messages = append(messages, issue.String())
+	}
+	return fmt.Sprintf("schema lint: %d issue(s): %s", len(i), strings.Join(messages, "; "))
+}
+
+// definitions yields the types defined by the schema, sorted by name, without built-in types
+func definitions(schema *ast.Schema) []*ast.Definition {
+	if schema == nil {
+		return nil
+	}
+	defs := make([]*ast.Definition, 0, len(schema.Types))
+	for _, def := range schema.Types {
+		if def.BuiltIn || isIntrospection(def.Name) {
+			continue
+		}
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func isIntrospection(name string) bool {
+	return strings.HasPrefix(name, "__")
+}
+
+func isComposite(schema *ast.Schema, typ *ast.Type) bool {
+	def := schema.Types[typ.Name()]
+	return def != nil && (def.Kind == ast.Object || def.Kind == ast.Interface || def.Kind == ast.Union)
+}
+
+func hasArgument(field *ast.FieldDefinition, names []string) bool {
+	for _, name := range names {
+		if field.Arguments.ForName(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeprecatedWithoutReason(directives ast.DirectiveList) bool {
+	deprecated := directives.ForName("deprecated")
+	if deprecated == nil {
+		return false
+	}
+	reason := deprecated.Arguments.ForName("reason")
+	return reason == nil || reason.Value == nil || strings.TrimSpace(reason.Value.Raw) == ""
+}
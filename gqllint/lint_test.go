@@ -0,0 +1,69 @@
+package gqllint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+"A user"
+type User {
+  "The identifier"
+  id: ID
+  "The friends"
+  friends: [User!]!
+  "The nicknames"
+  nicknames: [String!]!
+  "The login"
+  login: String! @deprecated
+}
+
+"Queries"
+type Query {
+  "Users"
+  users(first: Int): [User!]!
+  "Sorted users"
+  sortedUsers(order: String): [User!]!
+  me: User
+}
+
+"Roles"
+enum Role {
+  "Administrator"
+  ADMIN
+  "Guest"
+  GUEST @deprecated(reason: "")
+  "Member"
+  MEMBER @deprecated(reason: "use ADMIN or GUEST")
+}
+`
+
+func TestLint(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	issues := Lint(schema, DefaultRules()...)
+	locations := make(map[string][]string)
+	for _, issue := range issues {
+		locations[issue.Rule] = append(locations[issue.Rule], issue.Location)
+	}
+	assert.Equal(t, map[string][]string{
+		RuleMissingDescription:       {"Query.me"},
+		RuleUnboundedList:            {"Query.sortedUsers", "User.friends"},
+		RuleNullableID:               {"User.id"},
+		RuleDeprecationWithoutReason: {"Role.GUEST", "User.login"},
+	}, locations)
+
+	l := New(
+		WithRules(UnboundedLists("first", "order"), NullableIDs()),
+		WithIgnore("User.id"),
+	)
+	issues = l.Lint(schema)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "User.friends: list [User!]! has no pagination argument (one of first, order) [unbounded-list]", issues[0].String())
+	assert.Contains(t, issues.Error(), "1 issue(s)")
+}
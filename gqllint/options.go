@@ -0,0 +1,50 @@
+package gqllint
+
+type (
+	// Option for the schema linter
+	Option func(*config)
+
+	config struct {
+		rules    []Rule
+		ignored  map[string]bool
+		warnings bool
+		handlers []func(Issue)
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		rules:   DefaultRules(),
+		ignored: make(map[string]bool),
+	}
+}
+
+// WithRules sets the rules checked by the linter. The default is DefaultRules().
+func WithRules(rules ...Rule) Option {
+	return func(c *config) {
+		c.rules = rules
+	}
+}
+
+// WithIgnore ignores the issues found at some locations, e.g. "User" or "Query.legacyUsers"
+func WithIgnore(locations ...string) Option {
+	return func(c *config) {
+		for _, location := range locations {
+			c.ignored[location] = true
+		}
+	}
+}
+
+// WithWarnings only reports issues to the handlers, without failing the startup of the server
+func WithWarnings() Option {
+	return func(c *config) {
+		c.warnings = true
+	}
+}
+
+// WithHandler adds a handler of issues, e.g. to log them
+func WithHandler(handlers ...func(Issue)) Option {
+	return func(c *config) {
+		c.handlers = append(c.handlers, handlers...)
+	}
+}
@@ -0,0 +1,284 @@
+// Package gqllive provides a gqlgen extension implementing live queries.
+//
+// A query marked with the @live directive is re-executed whenever it is invalidated, and its updated result
+// is pushed to the client. This requires a transport able to carry several responses for a single operation,
+// such as the websocket transport. Over other transports, live queries are served as regular queries.
+//
+// Invalidation signals are received from a pluggable PubSub, on topics collected while resolving the query:
+// by default, each root field of the query is a topic (e.g. "Query.todos"), and resolvers may add their own
+// topics with AddTopic (e.g. "Todo:42"). As a fallback, live queries may be polled at a fixed interval.
+//
+// The directive must be declared in the schema:
+//
+//   directive @live on QUERY
+//
+// Example:
+//
+//   srv.AddTransport(transport.Websocket{})
+//   srv.Use(gqllive.New(gqllive.WithPubSub(pubsub), gqllive.WithPollInterval(time.Minute)))
+package gqllive
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "LiveQuery"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Live{}
+
+type (
+	// PubSub delivers invalidation signals for topics.
+	//
+	// Subscribe returns a channel receiving a value whenever any of the topics is invalidated.
+	// The subscription ends, and the channel may be closed, when the context is done.
+	PubSub interface {
+		Subscribe(ctx context.Context, topics []string) (<-chan struct{}, error)
+	}
+
+	// PubSubFunc is a function implementing PubSub
+	PubSubFunc func(context.Context, []string) (<-chan struct{}, error)
+
+	// Live is a gqlgen extension re-executing live queries on invalidation
+	Live struct {
+		*config
+	}
+
+	// topics collected while resolving a query
+	topics struct {
+		mx    sync.Mutex
+		names map[string]struct{}
+	}
+
+	topicsKey struct{}
+)
+
+// Subscribe implements PubSub
+func (f PubSubFunc) Subscribe(ctx context.Context, topics []string) (<-chan struct{}, error) {
+	return f(ctx, topics)
+}
+
+// New live query extension
+func New(opts ...Option) *Live {
+	l := &Live{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(l.config)
+	}
+	return l
+}
+
+// AddTopic declares topics invalidating the live query being resolved, e.g. the ID of an entity.
+//
+// This is a noop when the query is not live.
+func AddTopic(ctx context.Context, names ...string) {
+	t, ok := ctx.Value(topicsKey{}).(*topics)
+	if !ok {
+		return
+	}
+	t.add(names...)
+}
+
+// ExtensionName yields the extension name: "LiveQuery"
+func (*Live) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Live) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor
+func (l *Live) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if !l.isLive(oc) {
+		return next(ctx)
+	}
+
+	opCtx := ctx
+	responses := next(ctx)
+	tags := []tag.Mutator{tag.Upsert(metrics.TagOperation, l.config.opLabel(oc))}
+
+	var (
+		executed  bool
+		revision  int
+		last      json.RawMessage
+		signals   <-chan struct{}
+		ticker    *time.Ticker
+		collected *topics
+		cancel    context.CancelFunc = func() {}
+	)
+
+	return func(ctx context.Context) *graphql.Response {
+		if !executed {
+			executed = true
+			collected = l.rootTopics(oc)
+			resp := responses(context.WithValue(ctx, topicsKey{}, collected))
+			if resp != nil {
+				last = resp.Data
+			}
+			return resp
+		}
+
+		if signals == nil && ticker == nil {
+			// the transport expects more responses: start listening to invalidations
+			var subCtx context.Context
+			subCtx, cancel = context.WithCancel(ctx)
+			signals = l.subscribe(subCtx, collected)
+			if l.config.pollInterval > 0 {
+				ticker = time.NewTicker(l.config.pollInterval)
+			}
+			if signals == nil && ticker == nil {
+				cancel()
+				return nil
+			}
+		}
+
+		var tick <-chan time.Time
+		if ticker != nil {
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				l.stop(cancel, ticker)
+				return nil
+			case _, ok := <-signals:
+				if !ok {
+					signals = nil
+					if ticker == nil {
+						l.stop(cancel, ticker)
+						return nil
+					}
+					continue
+				}
+			case <-tick:
+			}
+
+			_ = stats.RecordWithTags(ctx, tags, ReexecutionCount.M(1))
+			resp := next(opCtx)(context.WithValue(ctx, topicsKey{}, collected))
+			if resp == nil {
+				l.stop(cancel, ticker)
+				return nil
+			}
+
+			push, changed := l.update(resp, last, revision+1)
+			if !changed {
+				continue
+			}
+			revision++
+			last = resp.Data
+			_ = stats.RecordWithTags(ctx, tags, PushCount.M(1))
+
+			return push
+		}
+	}
+}
+
+func (l *Live) isLive(oc *graphql.OperationContext) bool {
+	if oc.Operation == nil || oc.Operation.Operation != ast.Query {
+		return false
+	}
+	return oc.Operation.Directives.ForName(l.config.directive) != nil
+}
+
+func (l *Live) subscribe(ctx context.Context, collected *topics) <-chan struct{} {
+	if l.config.pubsub == nil {
+		return nil
+	}
+	signals, err := l.config.pubsub.Subscribe(ctx, collected.list())
+	if err != nil {
+		return nil
+	}
+	return signals
+}
+
+func (l *Live) stop(cancel context.CancelFunc, ticker *time.Ticker) {
+	cancel()
+	if ticker != nil {
+		ticker.Stop()
+	}
+}
+
+// update yields the response to push after a re-execution, if the result changed.
+//
+// With diffs enabled, the data is replaced by a patch against the previous result, in the "live" extension.
+func (l *Live) update(resp *graphql.Response, last json.RawMessage, revision int) (*graphql.Response, bool) {
+	if len(resp.Errors) > 0 {
+		return resp, true
+	}
+
+	changes, err := gqldiff.Compare(last, resp.Data, gqldiff.Ordered(true))
+	if err != nil {
+		return resp, true
+	}
+	if len(changes) == 0 {
+		return nil, false
+	}
+
+	live := map[string]interface{}{"revision": revision}
+	if l.config.diffs {
+		patch := make([]map[string]interface{}, 0, len(changes))
+		for _, change := range changes {
+			patch = append(patch, map[string]interface{}{
+				"op":    change.Kind.String(),
+				"path":  change.Path,
+				"value": change.Right,
+			})
+		}
+		live["patch"] = patch
+		resp = &graphql.Response{Extensions: resp.Extensions}
+	}
+
+	if resp.Extensions == nil {
+		resp.Extensions = make(map[string]interface{})
+	}
+	resp.Extensions["live"] = live
+
+	return resp, true
+}
+
+// rootTopics yields the default topics of a query: its root fields, e.g. "Query.todos"
+func (l *Live) rootTopics(oc *graphql.OperationContext) *topics {
+	t := &topics{names: make(map[string]struct{})}
+	for _, selection := range oc.Operation.SelectionSet {
+		if field, ok := selection.(*ast.Field); ok {
+			t.add("Query." + field.Name)
+		}
+	}
+	return t
+}
+
+func (t *topics) add(names ...string) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	for _, name := range names {
+		t.names[name] = struct{}{}
+	}
+}
+
+func (t *topics) list() []string {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	names := make([]string, 0, len(t.names))
+	for name := range t.names {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+package gqllive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestLive(t *testing.T) {
+	signals := make(chan struct{}, 1)
+	var subscribed []string
+	l := New(
+		WithPubSub(PubSubFunc(func(_ context.Context, topics []string) (<-chan struct{}, error) {
+			subscribed = topics
+			return signals, nil
+		})),
+		WithDiffs(true),
+	)
+	require.Equal(t, extensionName, l.ExtensionName())
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{
+			Operation:    ast.Query,
+			Directives:   ast.DirectiveList{{Name: "live"}},
+			SelectionSet: ast.SelectionSet{&ast.Field{Name: "counter"}},
+		},
+	})
+
+	counter := 0
+	next := func(_ context.Context) graphql.ResponseHandler {
+		executed := false
+		return func(ctx context.Context) *graphql.Response {
+			if executed {
+				return nil
+			}
+			executed = true
+			AddTopic(ctx, "Counter:1")
+			return &graphql.Response{Data: json.RawMessage(fmt.Sprintf(`{"counter":%d}`, counter))}
+		}
+	}
+
+	responses := l.InterceptOperation(ctx, next)
+
+	resp := responses(ctx)
+	require.NotNil(t, resp)
+	assert.JSONEq(t, `{"counter":0}`, string(resp.Data))
+
+	counter++
+	signals <- struct{}{}
+	resp = responses(ctx)
+	require.NotNil(t, resp)
+	assert.ElementsMatch(t, []string{"Query.counter", "Counter:1"}, subscribed)
+
+	live, ok := resp.Extensions["live"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, 1, live["revision"])
+	assert.Len(t, live["patch"], 1)
+
+	close(signals)
+	require.Nil(t, responses(ctx))
+}
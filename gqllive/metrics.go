@@ -0,0 +1,54 @@
+package gqllive
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(ReexecutionCountView, PushCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(ReexecutionCountView, PushCountView)
+}
+
+var (
+	// ReexecutionCount tracks a count of re-executions of live queries
+	ReexecutionCount = stats.Int64(
+		"gql/server/live_reexecution_count",
+		"Number of re-executions of GraphQL live queries",
+		stats.UnitDimensionless)
+
+	// PushCount tracks a count of updated results pushed to clients
+	PushCount = stats.Int64(
+		"gql/server/live_push_count",
+		"Number of updated results of GraphQL live queries pushed to clients",
+		stats.UnitDimensionless)
+
+	// ReexecutionCountView reports a count of re-executions of live queries tagged by operation name
+	ReexecutionCountView = &view.View{
+		Name:        "gql/server/live_reexecution_count",
+		Description: "Count of re-executions of live queries by operation",
+		Measure:     ReexecutionCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// PushCountView reports a count of pushed results tagged by operation name
+	PushCountView = &view.View{
+		Name:        "gql/server/live_push_count",
+		Description: "Count of updated results of live queries pushed by operation",
+		Measure:     PushCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
@@ -0,0 +1,69 @@
+package gqllive
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the live query extension
+	Option func(*config)
+
+	config struct {
+		directive    string
+		pubsub       PubSub
+		pollInterval time.Duration
+		diffs        bool
+		opLabel      gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		directive: "live",
+		opLabel:   gqllabel.OperationName,
+	}
+}
+
+// WithDirective sets the name of the directive marking live queries. The default is "live".
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
+
+// WithPubSub sets the source of invalidation signals. By default, there is none.
+func WithPubSub(pubsub PubSub) Option {
+	return func(c *config) {
+		c.pubsub = pubsub
+	}
+}
+
+// WithPollInterval re-executes live queries at a fixed interval, as a fallback to invalidation signals.
+// By default, live queries are not polled.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.pollInterval = interval
+	}
+}
+
+// WithDiffs pushes updates as a patch against the previous result, rather than the full result.
+//
+// The patch is a list of changes in the "live" extension of the response, e.g.
+//
+//   {"data": null, "extensions": {"live": {"revision": 2, "patch": [{"op": "changed", "path": "todos[1].done", "value": true}]}}}
+func WithDiffs(enabled bool) Option {
+	return func(c *config) {
+		c.diffs = enabled
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
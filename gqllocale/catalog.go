@@ -0,0 +1,58 @@
+package gqllocale
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type (
+	// Catalog of localized messages
+	Catalog interface {
+		// Message yields the message localized for a locale, identified by a key
+		Message(locale, key string) (string, bool)
+	}
+
+	// Messages is a Catalog held in memory, as messages by key, by locale
+	Messages map[string]map[string]string
+)
+
+// Message implements Catalog. When the locale is not found, messages of its language are looked up,
+// e.g. "fr" for "fr-CA".
+func (m Messages) Message(locale, key string) (string, bool) {
+	if msg, ok := m[locale][key]; ok {
+		return msg, true
+	}
+	msg, ok := m[baseLanguage(locale)][key]
+	return msg, ok
+}
+
+// ErrorPresenter wraps an error presenter, localizing the messages of errors with a Catalog.
+//
+// Messages are looked up by the "code" in the extensions of errors, then by the message itself.
+// Errors without a localized message are left unchanged.
+//
+// Example:
+//
+//   srv.SetErrorPresenter(gqllocale.ErrorPresenter(catalog, graphql.DefaultErrorPresenter))
+func ErrorPresenter(catalog Catalog, next graphql.ErrorPresenterFunc) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		presented := next(ctx, err)
+		locale := Locale(ctx)
+		if presented == nil || locale == "" {
+			return presented
+		}
+
+		if code, ok := presented.Extensions["code"].(string); ok {
+			if msg, found := catalog.Message(locale, code); found {
+				presented.Message = msg
+				return presented
+			}
+		}
+		if msg, found := catalog.Message(locale, presented.Message); found {
+			presented.Message = msg
+		}
+		return presented
+	}
+}
@@ -0,0 +1,244 @@
+// Package gqllocale provides a gqlgen extension resolving the locale of GraphQL operations.
+//
+// The locale is picked from, in this order: a variable of the operation, a custom header, then the
+// Accept-Language header. It is normalized (e.g. "en-us" becomes "en-US"), and matched against the supported locales.
+// Resolvers retrieve it from the context with Locale.
+//
+// Example:
+//
+//   loc := gqllocale.New(
+//     gqllocale.WithSupported("en-US", "fr-FR", "de"),
+//     gqllocale.WithDefault("en-US"),
+//     gqllocale.WithVariable("locale"),
+//   )
+//   srv.Use(loc)
+//   srv.SetErrorPresenter(gqllocale.ErrorPresenter(catalog, graphql.DefaultErrorPresenter))
+//   http.Handle("/query", loc.Middleware(srv))
+//
+// The locale is added as the attribute "locale" to the current trace span: register this extension after
+// the tracer, so that it annotates the span of the operation.
+package gqllocale
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+)
+
+const extensionName = "Locale"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = &Extension{}
+
+type (
+	// Extension is a gqlgen extension resolving the locale of operations
+	Extension struct {
+		*config
+	}
+
+	// requestHeaders captured from the HTTP request
+	requestHeaders struct {
+		acceptLanguage string
+		locale         string
+	}
+
+	headersKey struct{}
+	localeKey  struct{}
+)
+
+// New locale Extension
+func New(opts ...Option) *Extension {
+	e := &Extension{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(e.config)
+	}
+	return e
+}
+
+// Locale yields the locale of the operation, or an empty string if none is resolved
+func Locale(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// WithLocale sets the locale in the context, e.g. in tests
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Middleware captures the Accept-Language header and the custom locale header, if any, into the request context.
+//
+// The middleware must wrap the gqlgen handler:
+//
+//   http.Handle("/query", loc.Middleware(srv))
+func (e *Extension) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers := &requestHeaders{
+			acceptLanguage: r.Header.Get("Accept-Language"),
+		}
+		if e.config.header != "" {
+			headers.locale = r.Header.Get(e.config.header)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), headersKey{}, headers)))
+	})
+}
+
+// ExtensionName yields the extension name: "Locale"
+func (*Extension) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor
+func (e *Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	return next(WithLocale(ctx, e.resolve(ctx, graphql.GetOperationContext(ctx))))
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if span := trace.FromContext(ctx); span != nil && span.IsRecordingEvents() {
+		if locale := Locale(ctx); locale != "" {
+			span.AddAttributes(trace.StringAttribute("locale", locale))
+		}
+	}
+	return next(ctx)
+}
+
+// resolve the locale from the candidates found in the variables and headers
+func (e *Extension) resolve(ctx context.Context, oc *graphql.OperationContext) string {
+	var candidates []string
+	if e.config.variable != "" {
+		if value, ok := oc.Variables[e.config.variable].(string); ok && value != "" {
+			candidates = append(candidates, value)
+		}
+	}
+	if headers, ok := ctx.Value(headersKey{}).(*requestHeaders); ok {
+		if headers.locale != "" {
+			candidates = append(candidates, headers.locale)
+		}
+		candidates = append(candidates, ParseAcceptLanguage(headers.acceptLanguage)...)
+	}
+
+	for _, candidate := range candidates {
+		if locale, ok := e.match(Normalize(candidate)); ok {
+			return locale
+		}
+	}
+	return e.config.defaultLocale
+}
+
+// match a locale against the supported ones: an exact match is preferred, then a match of the language
+func (e *Extension) match(locale string) (string, bool) {
+	if locale == "" {
+		return "", false
+	}
+	if len(e.config.supported) == 0 {
+		return locale, true
+	}
+	for _, supported := range e.config.supported {
+		if supported == locale {
+			return supported, true
+		}
+	}
+	language := baseLanguage(locale)
+	for _, supported := range e.config.supported {
+		if baseLanguage(supported) == language {
+			return supported, true
+		}
+	}
+	return "", false
+}
+
+// ParseAcceptLanguage yields the language tags of an Accept-Language header, by decreasing preference.
+// The wildcard "*" and tags with a zero quality are ignored.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+	result := make([]string, len(tags))
+	for i, tag := range tags {
+		result[i] = tag.tag
+	}
+	return result
+}
+
+// Normalize a language tag: the language is lower-cased, the script title-cased and the region upper-cased,
+// e.g. "zh_hant_tw" becomes "zh-Hant-TW". Invalid tags yield an empty string.
+func Normalize(tag string) string {
+	parts := strings.FieldsFunc(strings.TrimSpace(tag), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	if len(parts) == 0 || len(parts[0]) < 2 || len(parts[0]) > 3 || !isAlpha(parts[0]) {
+		return ""
+	}
+
+	normalized := []string{strings.ToLower(parts[0])}
+	for _, part := range parts[1:] {
+		switch {
+		case len(part) == 4 && isAlpha(part):
+			normalized = append(normalized, strings.ToUpper(part[:1])+strings.ToLower(part[1:]))
+		case len(part) == 2 && isAlpha(part), len(part) == 3 && !isAlpha(part):
+			normalized = append(normalized, strings.ToUpper(part))
+		default:
+			normalized = append(normalized, strings.ToLower(part))
+		}
+	}
+	return strings.Join(normalized, "-")
+}
+
+func baseLanguage(locale string) string {
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
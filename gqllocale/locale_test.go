@@ -0,0 +1,77 @@
+package gqllocale
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestNormalize(t *testing.T) {
+	for input, expected := range map[string]string{
+		"en":          "en",
+		"EN-us":       "en-US",
+		"zh_hant_tw":  "zh-Hant-TW",
+		"es-419":      "es-419",
+		"":            "",
+		"1234":        "",
+		"de-DE-1996":  "de-DE-1996",
+		" fr-ca ":     "fr-CA",
+		"toolonglang": "",
+	} {
+		assert.Equal(t, expected, Normalize(input), input)
+	}
+
+	assert.Equal(t, []string{"fr-CH", "fr", "de"}, ParseAcceptLanguage("de;q=0.7, fr-CH, *;q=0.5, fr;q=0.9, en;q=0"))
+}
+
+func TestLocale(t *testing.T) {
+	loc := New(
+		WithSupported("en-US", "fr-FR"),
+		WithDefault("en-US"),
+		WithVariable("locale"),
+		WithHeader("X-Locale"),
+	)
+
+	resolve := func(acceptLanguage, header string, variables map[string]interface{}) (locale string) {
+		handler := loc.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{Variables: variables})
+			loc.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+				locale = Locale(ctx)
+				return nil
+			})
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		req.Header.Set("X-Locale", header)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return
+	}
+
+	assert.Equal(t, "fr-FR", resolve("de, fr-CA;q=0.8", "", nil))
+	assert.Equal(t, "en-US", resolve("de", "", nil))
+	assert.Equal(t, "en-US", resolve("fr", "en", nil))
+	assert.Equal(t, "fr-FR", resolve("en", "de", map[string]interface{}{"locale": "fr_fr"}))
+}
+
+func TestErrorPresenter(t *testing.T) {
+	presenter := ErrorPresenter(Messages{
+		"fr": {
+			"NOT_FOUND":      "introuvable",
+			"internal error": "erreur interne",
+		},
+	}, graphql.DefaultErrorPresenter)
+
+	ctx := WithLocale(context.Background(), "fr-FR")
+	assert.Equal(t, "introuvable", presenter(ctx, &gqlerror.Error{
+		Message:    "not found",
+		Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+	}).Message)
+	assert.Equal(t, "erreur interne", presenter(ctx, gqlerror.Errorf("internal error")).Message)
+	assert.Equal(t, "other", presenter(ctx, gqlerror.Errorf("other")).Message)
+	assert.Equal(t, "internal error", presenter(context.Background(), gqlerror.Errorf("internal error")).Message)
+}
@@ -0,0 +1,53 @@
+package gqllocale
+
+type (
+	// Option for the locale extension
+	Option func(*config)
+
+	config struct {
+		supported     []string
+		defaultLocale string
+		variable      string
+		header        string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithSupported sets the supported locales. By default, any valid locale is accepted.
+//
+// Requested locales which are not supported fall back to a supported locale of the same language, if any.
+func WithSupported(locales ...string) Option {
+	return func(c *config) {
+		for _, locale := range locales {
+			if normalized := Normalize(locale); normalized != "" {
+				c.supported = append(c.supported, normalized)
+			}
+		}
+	}
+}
+
+// WithDefault sets the locale of operations when no requested locale is supported. By default, this is empty.
+func WithDefault(locale string) Option {
+	return func(c *config) {
+		c.defaultLocale = Normalize(locale)
+	}
+}
+
+// WithVariable sets the name of a variable of operations carrying the requested locale.
+// This takes precedence over headers. By default, variables are not considered.
+func WithVariable(name string) Option {
+	return func(c *config) {
+		c.variable = name
+	}
+}
+
+// WithHeader sets the name of a custom header carrying the requested locale, e.g. "X-Locale".
+// This takes precedence over Accept-Language. By default, only Accept-Language is considered.
+func WithHeader(name string) Option {
+	return func(c *config) {
+		c.header = name
+	}
+}
@@ -0,0 +1,221 @@
+// Package gqlmaintenance provides a gqlgen extension to switch a GraphQL server to read-only or maintenance mode
+// at runtime, e.g. during a database migration.
+//
+// In read-only mode, mutations are rejected. In maintenance mode, all operations are rejected, except those
+// allowed by name. Rejected operations fail with the code SERVICE_UNAVAILABLE, a descriptive message,
+// and the number of seconds after which to retry, in the error extensions and in the Retry-After header.
+//
+// The mode is switched with UpdateSettings or the AdminHandler. Rejections are counted by the RejectedCount metric.
+//
+// Example:
+//
+//   m := gqlmaintenance.New()
+//   srv.Use(m)
+//   http.Handle("/query", m.Middleware(srv))
+//   http.Handle("/admin/maintenance", gqlmaintenance.AdminHandler(m, isAdmin))
+//
+//   curl -X POST -d '{"mode": "read-only", "message": "database migration in progress", "retryAfter": 600}' \
+//     http://localhost:8080/admin/maintenance
+package gqlmaintenance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcode"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+const extensionName = "Maintenance"
+
+// CodeUnavailable is the code of errors rejecting operations
+const CodeUnavailable = gqlcode.ServiceUnavailable
+
+// Modes of the server
+const (
+	ModeOff         Mode = "off"
+	ModeReadOnly    Mode = "read-only"
+	ModeMaintenance Mode = "maintenance"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Maintenance{}
+
+type (
+	// Mode of the server
+	Mode string
+
+	// Settings of the maintenance switch
+	Settings struct {
+		Mode Mode `json:"mode"`
+
+		// Message of the errors rejecting operations
+		Message string `json:"message,omitempty"`
+
+		// RetryAfter is the number of seconds after which clients may retry. Zero omits this hint.
+		RetryAfter int `json:"retryAfter,omitempty"`
+
+		// Allow lists the names of the operations still served in maintenance mode, e.g. health checks
+		Allow []string `json:"allow,omitempty"`
+	}
+
+	// Maintenance is a gqlgen extension rejecting operations in read-only or maintenance mode
+	Maintenance struct {
+		*config
+		settings atomic.Value
+	}
+
+	// holder tells the middleware to set the Retry-After header
+	holder struct {
+		retryAfter int
+	}
+
+	holderKey struct{}
+)
+
+// Validate settings
+func (s Settings) Validate() error {
+	switch s.Mode {
+	case ModeOff, ModeReadOnly, ModeMaintenance:
+	default:
+		return fmt.Errorf("invalid mode %q: expected one of %q, %q, %q", s.Mode, ModeOff, ModeReadOnly, ModeMaintenance)
+	}
+	if s.RetryAfter < 0 {
+		return fmt.Errorf("retryAfter must be positive, got %d", s.RetryAfter)
+	}
+	return nil
+}
+
+// New maintenance extension. The mode is off by default.
+func New(opts ...Option) *Maintenance {
+	m := &Maintenance{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(m.config)
+	}
+	m.settings.Store(m.config.settings)
+	return m
+}
+
+// Settings yields the current settings
+func (m *Maintenance) Settings() Settings {
+	s := m.settings.Load().(Settings)
+	s.Allow = append([]string(nil), s.Allow...)
+	return s
+}
+
+// UpdateSettings atomically replaces the settings.
+//
+// It is safe to call UpdateSettings while the extension is serving requests.
+func (m *Maintenance) UpdateSettings(s Settings) error {
+	if s.Mode == "" {
+		s.Mode = ModeOff
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	s.Allow = append([]string(nil), s.Allow...)
+	m.settings.Store(s)
+	return nil
+}
+
+// Middleware sets the Retry-After header on responses rejected by the extension.
+//
+// The middleware must wrap the gqlgen handler:
+//
+//   http.Handle("/query", m.Middleware(srv))
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := &holder{}
+		next.ServeHTTP(httpwriter.New(w, func(status int) int { return h.writeHeaders(w.Header(), status) }),
+			r.WithContext(context.WithValue(r.Context(), holderKey{}, h)))
+	})
+}
+
+// ExtensionName yields the extension name: "Maintenance"
+func (*Maintenance) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Maintenance) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor.
+//
+// Rejected operations yield a single response, so that subscriptions and websocket operations terminate.
+func (m *Maintenance) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	s := m.settings.Load().(Settings)
+	if s.Mode == ModeOff || s.Mode == "" {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if !rejected(s, oc) {
+		return next(ctx)
+	}
+
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.TagOperation, m.config.opLabel(oc)), tag.Upsert(TagMode, string(s.Mode))},
+		RejectedCount.M(1),
+	)
+
+	if h, ok := ctx.Value(holderKey{}).(*holder); ok {
+		h.retryAfter = s.RetryAfter
+	}
+
+	message := s.Message
+	if message == "" {
+		message = m.config.messages[s.Mode]
+	}
+	err := &gqlerror.Error{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code": CodeUnavailable,
+			"mode": s.Mode,
+		},
+	}
+	if s.RetryAfter > 0 {
+		err.Extensions["retryAfter"] = s.RetryAfter
+	}
+	return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{err}})
+}
+
+func rejected(s Settings, oc *graphql.OperationContext) bool {
+	if s.Mode == ModeReadOnly {
+		return oc.Operation != nil && oc.Operation.Operation == ast.Mutation
+	}
+
+	name := oc.OperationName
+	if oc.Operation != nil && oc.Operation.Name != "" {
+		name = oc.Operation.Name
+	}
+	for _, allowed := range s.Allow {
+		if name != "" && name == allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// writeHeaders sets the Retry-After header before the response is written
+func (h *holder) writeHeaders(header http.Header, status int) int {
+	if h.retryAfter > 0 {
+		header.Set("Retry-After", strconv.Itoa(h.retryAfter))
+	}
+	return status
+}
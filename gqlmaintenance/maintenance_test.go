@@ -0,0 +1,95 @@
+package gqlmaintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func operation(op ast.Operation, name string) context.Context {
+	return graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: name,
+		Operation:     &ast.OperationDefinition{Operation: op, Name: name},
+	})
+}
+
+func TestMaintenance(t *testing.T) {
+	m := New()
+	ok := func(ctx context.Context) graphql.ResponseHandler {
+		return graphql.OneShot(&graphql.Response{})
+	}
+
+	resp := m.InterceptOperation(operation(ast.Mutation, "update"), ok)(context.Background())
+	assert.Empty(t, resp.Errors)
+
+	require.NoError(t, m.UpdateSettings(Settings{Mode: ModeReadOnly, RetryAfter: 60}))
+	resp = m.InterceptOperation(operation(ast.Query, "list"), ok)(context.Background())
+	assert.Empty(t, resp.Errors)
+	resp = m.InterceptOperation(operation(ast.Mutation, "update"), ok)(context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, CodeUnavailable, resp.Errors[0].Extensions["code"])
+	assert.Equal(t, 60, resp.Errors[0].Extensions["retryAfter"])
+	assert.Contains(t, resp.Errors[0].Message, "read-only")
+
+	require.NoError(t, m.UpdateSettings(Settings{Mode: ModeMaintenance, Message: "back soon", Allow: []string{"health"}}))
+	resp = m.InterceptOperation(operation(ast.Query, "health"), ok)(context.Background())
+	assert.Empty(t, resp.Errors)
+	resp = m.InterceptOperation(operation(ast.Query, "list"), ok)(context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "back soon", resp.Errors[0].Message)
+
+	assert.Error(t, m.UpdateSettings(Settings{Mode: "closed"}))
+}
+
+func TestRejectionsAreOneShot(t *testing.T) {
+	m := New(WithSettings(Settings{Mode: ModeMaintenance}))
+	next := func(ctx context.Context) graphql.ResponseHandler {
+		t.Fatal("rejected operations are not executed")
+		return nil
+	}
+
+	// the websocket transport pulls responses until it gets nil
+	handler := m.InterceptOperation(operation(ast.Query, "list"), next)
+	resp := handler(context.Background())
+	require.NotNil(t, resp)
+	require.Len(t, resp.Errors, 1)
+	assert.Nil(t, handler(context.Background()))
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New(WithSettings(Settings{Mode: ModeMaintenance, RetryAfter: 120}))
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{OperationName: "list"})
+		m.InterceptOperation(ctx, nil)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", nil))
+	assert.Equal(t, "120", rec.Header().Get("Retry-After"))
+}
+
+func TestAdminHandler(t *testing.T) {
+	m := New()
+	handler := AdminHandler(m, func(*http.Request) bool { return true })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"mode": "read-only"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ModeReadOnly, m.Settings().Mode)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"mode": "closed"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	AdminHandler(m, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
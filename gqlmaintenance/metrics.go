@@ -0,0 +1,42 @@
+package gqlmaintenance
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(RejectedCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(RejectedCountView)
+}
+
+var (
+	// RejectedCount tracks a count of operations rejected in read-only or maintenance mode
+	RejectedCount = stats.Int64(
+		"gql/server/maintenance_rejected_count",
+		"Number of GraphQL operations rejected in read-only or maintenance mode",
+		stats.UnitDimensionless)
+
+	// RejectedCountView reports a count of rejected operations tagged by operation name and mode
+	RejectedCountView = &view.View{
+		Name:        "gql/server/maintenance_rejected_count",
+		Description: "Count of GraphQL operations rejected in read-only or maintenance mode by operation and mode",
+		Measure:     RejectedCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, TagMode},
+	}
+
+	// TagMode is the mode of the server
+	TagMode = tag.MustNewKey("gql.maintenance_mode")
+)
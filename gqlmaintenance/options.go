@@ -0,0 +1,53 @@
+package gqlmaintenance
+
+import (
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the maintenance extension
+	Option func(*config)
+
+	config struct {
+		settings Settings
+		messages map[Mode]string
+		opLabel  gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		settings: Settings{Mode: ModeOff},
+		messages: map[Mode]string{
+			ModeReadOnly:    "the service is in read-only mode: mutations are temporarily disabled",
+			ModeMaintenance: "the service is under maintenance",
+		},
+		opLabel: gqllabel.OperationName,
+	}
+}
+
+// WithSettings sets the initial settings, e.g. to start in read-only mode. Invalid settings are ignored.
+func WithSettings(s Settings) Option {
+	return func(c *config) {
+		if s.Mode == "" {
+			s.Mode = ModeOff
+		}
+		if s.Validate() == nil {
+			c.settings = s
+		}
+	}
+}
+
+// WithDefaultMessage sets the message of the errors rejecting operations in a mode, when the settings have no message.
+func WithDefaultMessage(mode Mode, message string) Option {
+	return func(c *config) {
+		c.messages[mode] = message
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
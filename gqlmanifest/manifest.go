@@ -0,0 +1,130 @@
+// Package gqlmanifest builds and loads manifests of persisted queries: the allowlist of the queries
+// known to clients, indexed by their sha256 hash, as used by automatic persisted queries, or by the IDs
+// assigned by relay or apollo manifests.
+//
+// Manifests are built from client GraphQL documents, or from relay and apollo persisted query manifests.
+// The cmd/gqlmanifest command line tool builds manifests at client build time.
+package gqlmanifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+type (
+	// Manifest of persisted queries
+	Manifest struct {
+		Version    string               `json:"version,omitempty"`
+		Operations map[string]Operation `json:"operations"`
+	}
+
+	// Operation in a manifest
+	Operation struct {
+		Names []string `json:"names,omitempty"`
+		Query string   `json:"query"`
+	}
+
+	// Diff between two manifests, as lists of hashes
+	Diff struct {
+		Added   []string `json:"added"`
+		Removed []string `json:"removed"`
+	}
+)
+
+// New empty manifest
+func New(version string) *Manifest {
+	return &Manifest{
+		Version:    version,
+		Operations: make(map[string]Operation),
+	}
+}
+
+// Hash computes the hash of a query, as expected by automatic persisted queries (hex-encoded sha256)
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Add a query to the manifest. The query is parsed to retrieve the names of its operations.
+//
+// The hash of the query is returned.
+func (m *Manifest) Add(query string) (string, error) {
+	hash := Hash(query)
+	if err := m.AddWithID(hash, query); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// AddWithID adds a query to the manifest with the ID assigned by a client, e.g. by the relay compiler.
+func (m *Manifest) AddWithID(id, query string) error {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(doc.Operations))
+	for _, op := range doc.Operations {
+		if op.Name != "" {
+			names = append(names, op.Name)
+		}
+	}
+
+	m.Operations[id] = Operation{Names: names, Query: query}
+
+	return nil
+}
+
+// Has tells if the manifest contains a query hash
+func (m *Manifest) Has(hash string) bool {
+	_, ok := m.Operations[hash]
+	return ok
+}
+
+// Query retrieves a query from its hash
+func (m *Manifest) Query(hash string) (string, bool) {
+	op, ok := m.Operations[hash]
+	return op.Query, ok
+}
+
+// Diff reports the hashes added to this manifest, and removed from it, compared to a previous manifest
+func (m *Manifest) Diff(previous *Manifest) Diff {
+	diff := Diff{
+		Added:   []string{},
+		Removed: []string{},
+	}
+	for hash := range m.Operations {
+		if !previous.Has(hash) {
+			diff.Added = append(diff.Added, hash)
+		}
+	}
+	for hash := range previous.Operations {
+		if !m.Has(hash) {
+			diff.Removed = append(diff.Removed, hash)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+
+	return diff
+}
+
+// Load a manifest from a JSON file
+func Load(path string) (*Manifest, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := New("")
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,96 @@
+package gqlmanifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	todosQuery = `query todos { todos { id text } }`
+	usersQuery = `query users { todos { user { name } } }`
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqlmanifest")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "todos.graphql"), []byte(todosQuery), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "persisted_queries.json"), []byte(`{"abc": "query users { todos { user { name } } }"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "client"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte(`ignored`), 0600))
+
+	m := New("v2")
+	require.NoError(t, m.AddPath(dir))
+	require.Len(t, m.Operations, 2, "only manifests are loaded")
+
+	query, ok := m.Query(Hash(todosQuery))
+	require.True(t, ok)
+	assert.Equal(t, todosQuery, query)
+	assert.Equal(t, Operation{Names: []string{"users"}, Query: usersQuery}, m.Operations["abc"], "manifest IDs are retained")
+
+	previous := New("v1")
+	_, err = previous.Add(todosQuery)
+	require.NoError(t, err)
+	_, err = previous.Add(`{ todos { done } }`)
+	require.NoError(t, err)
+
+	diff := m.Diff(previous)
+	assert.Equal(t, []string{"abc"}, diff.Added)
+	assert.Equal(t, []string{Hash(`{ todos { done } }`)}, diff.Removed)
+
+	_, err = m.Add(`query {`)
+	assert.Error(t, err)
+}
+
+func TestManifestFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqlmanifest")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	apollo := filepath.Join(dir, "persisted-query-manifest.json")
+	require.NoError(t, ioutil.WriteFile(apollo, []byte(`{
+  "format": "apollo-persisted-query-manifest",
+  "version": 1,
+  "operations": [{"id": "def", "name": "todos", "type": "query", "body": "query todos { todos { id text } }"}]
+}`), 0600))
+	relay := filepath.Join(dir, "queries.json")
+	require.NoError(t, ioutil.WriteFile(relay, []byte(`{"abc": "query users { todos { user { name } } }"}`), 0600))
+
+	t.Run("apollo", func(t *testing.T) {
+		m := New("")
+		require.NoError(t, m.AddPath(dir))
+		require.Len(t, m.Operations, 1)
+		query, ok := m.Query("def")
+		require.True(t, ok)
+		assert.Equal(t, todosQuery, query)
+	})
+
+	t.Run("manifest names", func(t *testing.T) {
+		m := New("")
+		require.NoError(t, m.AddPath(dir, WithManifestNames("queries.json")))
+		require.Len(t, m.Operations, 1)
+		assert.True(t, m.Has("abc"))
+	})
+
+	t.Run("explicit paths", func(t *testing.T) {
+		m := New("")
+		require.NoError(t, m.AddPath(relay))
+		assert.True(t, m.Has("abc"))
+	})
+
+	t.Run("format", func(t *testing.T) {
+		m := New("")
+		assert.Error(t, m.AddPath(apollo, WithFormat(FormatRelay)))
+		assert.Error(t, m.AddPath(relay, WithFormat(FormatApollo)))
+	})
+}
@@ -0,0 +1,58 @@
+package gqlmanifest
+
+// Format of the JSON manifests of persisted queries
+type Format string
+
+const (
+	// FormatAuto detects the format of manifests: apollo manifests declare their format, others are relay manifests
+	FormatAuto Format = ""
+
+	// FormatRelay is the format of relay manifests: a map of IDs to queries
+	FormatRelay Format = "relay"
+
+	// FormatApollo is the format of apollo persisted query manifests, which must declare the
+	// "apollo-persisted-query-manifest" format
+	FormatApollo Format = "apollo"
+)
+
+// DefaultManifestNames are the names of the JSON manifests loaded by AddPath: the default outputs of the relay
+// compiler and of the apollo persisted query manifest generator.
+var DefaultManifestNames = []string{
+	"persisted_queries.json",
+	"persisted-queries.json",
+	"persisted-query-manifest.json",
+}
+
+type (
+	// Option to add paths to a manifest
+	Option func(*config)
+
+	config struct {
+		names  []string
+		format Format
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		names:  DefaultManifestNames,
+		format: FormatAuto,
+	}
+}
+
+// WithManifestNames sets the names of the JSON manifests loaded when walking directories, as patterns
+// matched against file names (see filepath.Match). The default is DefaultManifestNames.
+//
+// JSON files given explicitly as paths are always loaded.
+func WithManifestNames(patterns ...string) Option {
+	return func(c *config) {
+		c.names = patterns
+	}
+}
+
+// WithFormat sets the format of JSON manifests. By default, the format is detected.
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
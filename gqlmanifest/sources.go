@@ -0,0 +1,111 @@
+package gqlmanifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// apolloFormat is the format declared by apollo persisted query manifests
+const apolloFormat = "apollo-persisted-query-manifest"
+
+// apolloManifest is the format of apollo persisted query manifests
+type apolloManifest struct {
+	Format     string `json:"format"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// AddPath adds the queries found at some path to the manifest.
+//
+// Directories are walked recursively. Supported files are GraphQL documents (.graphql, .gql), each file being
+// a query, and JSON manifests produced by relay (a map of IDs to queries) or apollo. When walking directories,
+// only JSON files with a manifest name are loaded (see WithManifestNames).
+//
+// Queries from JSON manifests are indexed by their ID in the manifest.
+func (m *Manifest) AddPath(path string, opts ...Option) error {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	return filepath.Walk(path, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(pth)) {
+		case ".graphql", ".gql":
+			return m.addDocument(pth)
+		case ".json":
+			if pth != path && !cfg.manifest(info.Name()) {
+				return nil
+			}
+			return m.addJSONManifest(pth, cfg.format)
+		default:
+			return nil
+		}
+	})
+}
+
+// manifest tells if a file name is the name of a JSON manifest
+func (c config) manifest(name string) bool {
+	for _, pattern := range c.names {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manifest) addDocument(path string) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Add(string(buf)); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+func (m *Manifest) addJSONManifest(path string, format Format) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if format != FormatRelay {
+		var apollo apolloManifest
+		if err := json.Unmarshal(buf, &apollo); err == nil && apollo.Format == apolloFormat {
+			for _, op := range apollo.Operations {
+				if err := m.AddWithID(op.ID, op.Body); err != nil {
+					return fmt.Errorf("%s: operation %s: %v", path, op.Name, err)
+				}
+			}
+			return nil
+		}
+		if format == FormatApollo {
+			return fmt.Errorf("%s: not an apollo manifest", path)
+		}
+	}
+
+	var relay map[string]string
+	if err := json.Unmarshal(buf, &relay); err != nil {
+		return fmt.Errorf("%s: unsupported manifest: %v", path, err)
+	}
+	for id, query := range relay {
+		if err := m.AddWithID(id, query); err != nil {
+			return fmt.Errorf("%s: query %s: %v", path, id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Package gqlmemo provides a gqlgen extension memoizing the results of resolvers within a single operation.
+//
+// When fragments or aliases select the same field of the same object several times, its resolver runs
+// once per selection. For opted-in fields, this extension runs the resolver once per operation, parent object
+// and arguments, and shares its result (or error) with all other selections, including concurrent ones.
+//
+// Fields opt in by name, as "Object.field", or with a directive on their definition in the schema:
+//
+//   directive @memo on FIELD_DEFINITION
+//
+//   type User {
+//     id: ID!
+//     friends(first: Int): [User!]! @memo
+//   }
+//
+// Example:
+//
+//   srv.Use(gqlmemo.New(
+//     gqlmemo.WithFields("Query.viewer", "User.recommendations"),
+//   ))
+//
+// Parent objects are told apart by their identifier, which is by default the value of their "ID" struct field
+// (see WithParentID). Fields of parents without an identifier are not memoized.
+//
+// The cache lives as long as the operation: subscriptions and the root fields of mutations are never memoized.
+package gqlmemo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "Memoize"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = &Memo{}
+
+type (
+	// ParentID yields the identifier of the parent object of a field, and false when the object has no identifier
+	ParentID func(parent interface{}) (string, bool)
+
+	// Memo is a gqlgen extension memoizing the results of resolvers within an operation
+	Memo struct {
+		*config
+	}
+
+	// cache of the results of an operation
+	cache struct {
+		mx      sync.Mutex
+		entries map[string]*entry
+	}
+
+	// entry is the result of a resolver, available once done is closed
+	entry struct {
+		done   chan struct{}
+		result interface{}
+		err    error
+	}
+
+	cacheKey struct{}
+)
+
+// New memoization extension
+func New(opts ...Option) *Memo {
+	m := &Memo{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(m.config)
+	}
+	return m
+}
+
+// ExtensionName yields the extension name: "Memoize"
+func (*Memo) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the memoized fields: fields opted in by name must exist in the schema
+func (m *Memo) Validate(schema graphql.ExecutableSchema) error {
+	s := schema.Schema()
+	for field := range m.config.fields {
+		object, name := splitField(field)
+		def := s.Types[object]
+		if def == nil || def.Fields.ForName(name) == nil {
+			return fmt.Errorf("memoized field %q is not defined in the schema", field)
+		}
+	}
+	return nil
+}
+
+// InterceptOperation attaches a new cache to queries and mutations
+func (m *Memo) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+	return next(context.WithValue(ctx, cacheKey{}, &cache{entries: make(map[string]*entry)}))
+}
+
+// InterceptField resolves an opted-in field once per operation, parent object and arguments
+func (m *Memo) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	c, ok := ctx.Value(cacheKey{}).(*cache)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil || !m.memoized(fc) {
+		return next(ctx)
+	}
+
+	key, ok := m.key(ctx, fc)
+	if !ok {
+		return next(ctx)
+	}
+
+	e, found := c.lookup(key)
+	if found {
+		_ = stats.RecordWithTags(ctx,
+			[]tag.Mutator{tag.Upsert(metrics.TagField, fc.Object+"."+fc.Field.Name)},
+			MemoHitCount.M(1),
+		)
+		select {
+		case <-e.done:
+			return e.result, e.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			e.err = fmt.Errorf("memoized resolver panicked: %v", r)
+			close(e.done)
+			panic(r)
+		}
+		close(e.done)
+	}()
+	e.result, e.err = next(ctx)
+	return e.result, e.err
+}
+
+func (m *Memo) memoized(fc *graphql.FieldContext) bool {
+	if _, ok := m.config.fields[fc.Object+"."+fc.Field.Name]; ok {
+		return true
+	}
+	return m.config.directive != "" && fc.Field.Definition != nil &&
+		fc.Field.Definition.Directives.ForName(m.config.directive) != nil
+}
+
+// key of a field: its signature, the identifier of its parent object and its arguments
+func (m *Memo) key(ctx context.Context, fc *graphql.FieldContext) (string, bool) {
+	var parentID string
+	if fc.Parent != nil && fc.Parent.Result != nil {
+		id, ok := m.config.parentID(fc.Parent.Result)
+		if !ok {
+			return "", false
+		}
+		parentID = id
+	} else if oc := graphql.GetOperationContext(ctx); oc.Operation.Operation == ast.Mutation {
+		// root fields of mutations have side effects
+		return "", false
+	}
+
+	args, err := json.Marshal(fc.Args)
+	if err != nil {
+		return "", false
+	}
+	return fc.Object + "." + fc.Field.Name + "\x00" + parentID + "\x00" + string(args), true
+}
+
+// lookup the entry of a key, or register a new entry to be resolved by the caller
+func (c *cache) lookup(key string) (*entry, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if e, ok := c.entries[key]; ok {
+		return e, true
+	}
+	e := &entry{done: make(chan struct{})}
+	c.entries[key] = e
+	return e, false
+}
+
+// StructID identifies parent objects by the value of their "ID" struct field, through pointers and interfaces
+func StructID(parent interface{}) (string, bool) {
+	v := reflect.ValueOf(parent)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	id := v.FieldByName("ID")
+	if !id.IsValid() || !id.CanInterface() {
+		return "", false
+	}
+	return v.Type().String() + ":" + fmt.Sprint(id.Interface()), true
+}
+
+func splitField(field string) (string, string) {
+	parts := strings.SplitN(field, ".", 2)
+	if len(parts) < 2 {
+		return field, ""
+	}
+	return parts[0], parts[1]
+}
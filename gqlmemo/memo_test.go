@@ -0,0 +1,176 @@
+package gqlmemo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type user struct {
+	ID   string
+	Name string
+}
+
+func operation(t *testing.T, m *Memo, op ast.Operation) context.Context {
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: op},
+	})
+	var opCtx context.Context
+	m.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		opCtx = ctx
+		return graphql.OneShot(&graphql.Response{})
+	})
+	require.NotNil(t, opCtx)
+	return opCtx
+}
+
+func field(ctx context.Context, parent interface{}, object, name string, args map[string]interface{}) context.Context {
+	if parent != nil {
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{Result: parent})
+	}
+	return graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Object: object,
+		Args:   args,
+		Field: graphql.CollectedField{
+			Field: &ast.Field{Name: name, Definition: &ast.FieldDefinition{Name: name}},
+		},
+	})
+}
+
+func TestMemo(t *testing.T) {
+	m := New(WithFields("User.friends", "Query.viewer"))
+	require.Equal(t, extensionName, m.ExtensionName())
+
+	var calls int32
+	resolver := func(_ context.Context) (interface{}, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	ctx := operation(t, m, ast.Query)
+	alice := &user{ID: "1"}
+
+	t.Run("same parent and arguments are resolved once", func(t *testing.T) {
+		first, err := m.InterceptField(field(ctx, alice, "User", "friends", map[string]interface{}{"first": 10}), resolver)
+		require.NoError(t, err)
+		second, err := m.InterceptField(field(ctx, &user{ID: "1", Name: "copy"}, "User", "friends", map[string]interface{}{"first": 10}), resolver)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("other parents or arguments are resolved again", func(t *testing.T) {
+		_, _ = m.InterceptField(field(ctx, alice, "User", "friends", map[string]interface{}{"first": 5}), resolver)
+		_, _ = m.InterceptField(field(ctx, &user{ID: "2"}, "User", "friends", map[string]interface{}{"first": 10}), resolver)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("fields not opted in are not memoized", func(t *testing.T) {
+		_, _ = m.InterceptField(field(ctx, alice, "User", "name", nil), resolver)
+		_, _ = m.InterceptField(field(ctx, alice, "User", "name", nil), resolver)
+		assert.EqualValues(t, 5, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("parents without identifier are not memoized", func(t *testing.T) {
+		_, _ = m.InterceptField(field(ctx, "anonymous", "User", "friends", nil), resolver)
+		_, _ = m.InterceptField(field(ctx, "anonymous", "User", "friends", nil), resolver)
+		assert.EqualValues(t, 7, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("the cache is scoped to the operation", func(t *testing.T) {
+		other := operation(t, m, ast.Query)
+		_, _ = m.InterceptField(field(other, alice, "User", "friends", map[string]interface{}{"first": 10}), resolver)
+		assert.EqualValues(t, 8, atomic.LoadInt32(&calls))
+	})
+}
+
+func TestMemoConcurrent(t *testing.T) {
+	m := New(WithFields("Query.viewer"))
+	ctx := operation(t, m, ast.Query)
+
+	var calls int32
+	release := make(chan struct{})
+	boom := errors.New("boom")
+	resolver := func(_ context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil, boom
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = m.InterceptField(field(ctx, nil, "Query", "viewer", nil), resolver)
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	for _, err := range errs {
+		assert.Equal(t, boom, err)
+	}
+}
+
+func TestMemoSkipped(t *testing.T) {
+	m := New(WithFields("Mutation.createUser", "Subscription.events"))
+
+	var calls int32
+	resolver := func(_ context.Context) (interface{}, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	mutation := operation(t, m, ast.Mutation)
+	_, _ = m.InterceptField(field(mutation, nil, "Mutation", "createUser", nil), resolver)
+	_, _ = m.InterceptField(field(mutation, nil, "Mutation", "createUser", nil), resolver)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	subscription := operation(t, m, ast.Subscription)
+	_, _ = m.InterceptField(field(subscription, nil, "Subscription", "events", nil), resolver)
+	_, _ = m.InterceptField(field(subscription, nil, "Subscription", "events", nil), resolver)
+	assert.EqualValues(t, 4, atomic.LoadInt32(&calls))
+}
+
+func TestMemoDirective(t *testing.T) {
+	m := New()
+	ctx := operation(t, m, ast.Query)
+
+	var calls int32
+	resolver := func(_ context.Context) (interface{}, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	memoized := func() context.Context {
+		ctx := field(ctx, &user{ID: "1"}, "User", "friends", nil)
+		graphql.GetFieldContext(ctx).Field.Definition.Directives = ast.DirectiveList{{Name: "memo"}}
+		return ctx
+	}
+	_, _ = m.InterceptField(memoized(), resolver)
+	_, _ = m.InterceptField(memoized(), resolver)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestStructID(t *testing.T) {
+	id, ok := StructID(&user{ID: "1"})
+	assert.True(t, ok)
+	assert.Equal(t, "gqlmemo.user:1", id)
+
+	u := &user{ID: "2"}
+	id, ok = StructID(&u)
+	assert.True(t, ok)
+	assert.Equal(t, "gqlmemo.user:2", id)
+
+	_, ok = StructID((*user)(nil))
+	assert.False(t, ok)
+	_, ok = StructID(struct{ Name string }{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,39 @@
+package gqlmemo
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(MemoHitCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(MemoHitCountView)
+}
+
+var (
+	// MemoHitCount counts the resolutions of fields served from the cache of the operation
+	MemoHitCount = stats.Int64(
+		"gql/server/memo_hit_count",
+		"Number of field resolutions served from the memoization cache",
+		stats.UnitDimensionless)
+
+	// MemoHitCountView reports the number of field resolutions served from the cache, by field
+	MemoHitCountView = &view.View{
+		Name:        "gql/server/memo_hit_count",
+		Description: "Count of GraphQL field resolutions served from the memoization cache by field",
+		Measure:     MemoHitCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagField},
+	}
+)
@@ -0,0 +1,45 @@
+package gqlmemo
+
+type (
+	// Option for the memoization extension
+	Option func(*config)
+
+	config struct {
+		fields    map[string]struct{}
+		directive string
+		parentID  ParentID
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		fields:    make(map[string]struct{}),
+		directive: "memo",
+		parentID:  StructID,
+	}
+}
+
+// WithFields opts fields in memoization, identified as "Object.field", e.g. "User.friends"
+func WithFields(fields ...string) Option {
+	return func(c *config) {
+		for _, field := range fields {
+			c.fields[field] = struct{}{}
+		}
+	}
+}
+
+// WithDirective sets the name of the directive opting field definitions in memoization. The default is "memo".
+//
+// An empty name disables opting in with a directive.
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
+
+// WithParentID sets the function identifying parent objects. The default is StructID.
+func WithParentID(parentID ParentID) Option {
+	return func(c *config) {
+		c.parentID = parentID
+	}
+}
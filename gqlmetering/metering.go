@@ -0,0 +1,180 @@
+// Package gqlmetering provides a gqlgen extension to meter the cost of the operations consumed by each client,
+// e.g. for API monetization or quota reporting per API key.
+//
+// Costs are accumulated in memory and periodically flushed to a pluggable UsageStore.
+//
+// Example:
+//
+//   meter := gqlmetering.New(store, gqlmetering.WithClient(apiKeyFromContext))
+//   srv.Use(extension.FixedComplexityLimit(1000))
+//   srv.Use(meter)
+//   go meter.Run(ctx)
+package gqlmetering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "Metering"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Meter{}
+
+type (
+	// Usage consumed by a client over a period
+	Usage struct {
+		Client     string
+		Operations int64
+		Errors     int64
+		Cost       int64
+		Start      time.Time
+		End        time.Time
+	}
+
+	// UsageStore persists usage records
+	UsageStore interface {
+		Store(context.Context, []Usage) error
+	}
+
+	// UsageStoreFunc is a function implementing UsageStore
+	UsageStoreFunc func(context.Context, []Usage) error
+
+	// Meter is a gqlgen extension accumulating the cost of operations per client.
+	Meter struct {
+		*config
+		store UsageStore
+
+		mx    sync.Mutex
+		start time.Time
+		usage map[string]*Usage
+	}
+)
+
+// Store implements UsageStore
+func (f UsageStoreFunc) Store(ctx context.Context, usage []Usage) error {
+	return f(ctx, usage)
+}
+
+// New Meter, flushing usage to the store
+func New(store UsageStore, opts ...Option) *Meter {
+	m := &Meter{
+		config: defaultConfig(),
+		store:  store,
+		usage:  make(map[string]*Usage),
+	}
+	for _, apply := range opts {
+		apply(m.config)
+	}
+	m.start = m.config.clock()
+	return m
+}
+
+// ExtensionName yields the extension name: "Metering"
+func (*Meter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Meter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (m *Meter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		// the final pull of websocket transports, past the response of the operation
+		return resp
+	}
+
+	client := m.config.client(ctx)
+	cost := m.config.cost(ctx)
+	failed := len(resp.Errors) > 0
+
+	m.mx.Lock()
+	u, ok := m.usage[client]
+	if !ok {
+		u = &Usage{Client: client}
+		m.usage[client] = u
+	}
+	u.Operations++
+	u.Cost += int64(cost)
+	if failed {
+		u.Errors++
+	}
+	m.mx.Unlock()
+
+	return resp
+}
+
+// Run flushes usage periodically, until the context is done. Remaining usage is flushed before returning.
+func (m *Meter) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = m.Flush(ctx)
+		case <-ctx.Done():
+			_ = m.Flush(context.Background())
+			return
+		}
+	}
+}
+
+// Flush the usage accumulated since the previous flush to the store.
+//
+// Upon failure, the usage is retained and merged into the next flush.
+func (m *Meter) Flush(ctx context.Context) error {
+	end := m.config.clock()
+
+	m.mx.Lock()
+	if len(m.usage) == 0 {
+		m.start = end
+		m.mx.Unlock()
+		return nil
+	}
+	pending := m.usage
+	start := m.start
+	m.usage = make(map[string]*Usage, len(pending))
+	m.start = end
+	m.mx.Unlock()
+
+	records := make([]Usage, 0, len(pending))
+	for _, u := range pending {
+		u.Start = start
+		u.End = end
+		records = append(records, *u)
+	}
+
+	err := m.store.Store(ctx, records)
+	if err != nil {
+		m.restore(pending, start)
+	}
+	return err
+}
+
+// restore usage which failed to be flushed
+func (m *Meter) restore(pending map[string]*Usage, start time.Time) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.start = start
+	for client, p := range pending {
+		u, ok := m.usage[client]
+		if !ok {
+			m.usage[client] = p
+			continue
+		}
+		u.Operations += p.Operations
+		u.Errors += p.Errors
+		u.Cost += p.Cost
+	}
+}
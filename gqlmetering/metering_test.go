@@ -0,0 +1,149 @@
+package gqlmetering
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const testSchema = `
+type Todo {
+  id: ID!
+  text: String!
+}
+
+type Query {
+  todos(first: Int!): [Todo!]!
+  version: String!
+}
+`
+
+type clientKey struct{}
+
+func TestMeter(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	// lists cost their length times the cost of their elements, other fields cost 1
+	es := &graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return schema },
+		ComplexityFunc: func(typeName, field string, childComplexity int, args map[string]interface{}) (int, bool) {
+			if typeName == "Query" && field == "todos" {
+				// literal arguments are int64, variables are passed as is
+				switch first := args["first"].(type) {
+				case int64:
+					return int(first) * childComplexity, true
+				case int:
+					return first * childComplexity, true
+				}
+			}
+			return 0, false
+		},
+	}
+	complexity := extension.FixedComplexityLimit(1000)
+	require.NoError(t, complexity.Validate(es))
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	var stored [][]Usage
+	var storeErr error
+	m := New(UsageStoreFunc(func(_ context.Context, usage []Usage) error {
+		if storeErr != nil {
+			return storeErr
+		}
+		stored = append(stored, usage)
+		return nil
+	}),
+		WithClient(func(ctx context.Context) string {
+			client, _ := ctx.Value(clientKey{}).(string)
+			return client
+		}),
+		WithClock(func() time.Time { return now }),
+	)
+
+	execute := func(client, query string, variables map[string]interface{}, errs gqlerror.List) {
+		doc, gqlErrs := gqlparser.LoadQuery(schema, query)
+		require.Empty(t, gqlErrs)
+		oc := &graphql.OperationContext{
+			RawQuery:  query,
+			Doc:       doc,
+			Operation: doc.Operations[0],
+			Variables: variables,
+		}
+		ctx := context.WithValue(context.Background(), clientKey{}, client)
+		require.Nil(t, complexity.MutateOperationContext(ctx, oc))
+
+		m.InterceptResponse(graphql.WithOperationContext(ctx, oc), func(context.Context) *graphql.Response {
+			return &graphql.Response{Errors: errs}
+		})
+	}
+
+	execute("acme", `{ version }`, nil, nil)
+	execute("acme", `query($first: Int!) { todos(first: $first) { id text } }`, map[string]interface{}{"first": 10}, nil)
+	execute("globex", `{ todos(first: 3) { id } version }`, nil, gqlerror.List{gqlerror.Errorf("boom")})
+
+	now = now.Add(time.Minute)
+	require.NoError(t, m.Flush(context.Background()))
+	require.Len(t, stored, 1)
+	start := now.Add(-time.Minute)
+	assert.ElementsMatch(t, []Usage{
+		{Client: "acme", Operations: 2, Cost: 1 + 10*2, Start: start, End: now},
+		{Client: "globex", Operations: 1, Errors: 1, Cost: 3*1 + 1, Start: start, End: now},
+	}, stored[0])
+
+	// usage which fails to be stored is merged into the next flush
+	execute("acme", `{ version }`, nil, nil)
+	storeErr = errors.New("unavailable")
+	now = now.Add(time.Minute)
+	require.Error(t, m.Flush(context.Background()))
+
+	execute("acme", `{ todos(first: 2) { id } }`, nil, nil)
+	storeErr = nil
+	now = now.Add(time.Minute)
+	require.NoError(t, m.Flush(context.Background()))
+	require.Len(t, stored, 2)
+	assert.Equal(t, []Usage{
+		{Client: "acme", Operations: 2, Cost: 1 + 2, Start: now.Add(-2 * time.Minute), End: now},
+	}, stored[1])
+
+	// nothing to flush
+	require.NoError(t, m.Flush(context.Background()))
+	assert.Len(t, stored, 2)
+}
+
+func TestMeterPullsUntilNil(t *testing.T) {
+	var stored []Usage
+	m := New(UsageStoreFunc(func(_ context.Context, usage []Usage) error {
+		stored = append(stored, usage...)
+		return nil
+	}))
+
+	// websocket transports pull responses until nil: the operation is metered once
+	responses := []*graphql.Response{{Data: []byte(`{"version":"1"}`)}}
+	next := func(context.Context) *graphql.Response {
+		if len(responses) == 0 {
+			return nil
+		}
+		resp := responses[0]
+		responses = responses[1:]
+		return resp
+	}
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{})
+	pulls := 0
+	for m.InterceptResponse(ctx, next) != nil {
+		pulls++
+	}
+	require.Equal(t, 1, pulls)
+
+	require.NoError(t, m.Flush(context.Background()))
+	require.Len(t, stored, 1)
+	assert.Equal(t, int64(1), stored[0].Operations)
+}
@@ -0,0 +1,72 @@
+package gqlmetering
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+)
+
+type (
+	// Option for the metering extension
+	Option func(*config)
+
+	config struct {
+		client   func(context.Context) string
+		cost     func(context.Context) int
+		interval time.Duration
+		clock    func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		client:   func(_ context.Context) string { return "" },
+		cost:     complexityCost,
+		interval: time.Minute,
+		clock:    time.Now,
+	}
+}
+
+// complexityCost retrieves the complexity computed by the extension.ComplexityLimit extension
+func complexityCost(ctx context.Context) int {
+	stats := extension.GetComplexityStats(ctx)
+	if stats == nil {
+		return 0
+	}
+	return stats.Complexity
+}
+
+// WithClient sets the function to identify the client from the context, e.g. from an API key.
+// By default, all usage is accounted to the same anonymous client "".
+func WithClient(client func(context.Context) string) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithCost sets the function to retrieve the cost of an operation.
+//
+// By default, this is the complexity computed by the extension.ComplexityLimit extension,
+// which must be used by the server.
+func WithCost(cost func(context.Context) int) Option {
+	return func(c *config) {
+		c.cost = cost
+	}
+}
+
+// WithInterval sets the interval between periodic flushes. The default is 1 minute.
+func WithInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithClock sets the clock used to timestamp usage periods. By default, this is time.Now
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,167 @@
+// Package gqlmetrics holds the naming conventions applied to the metrics of all the contrib packages:
+// a namespace prefixing metric names, renamed metrics, constant labels, and labels dropped to bound cardinality.
+//
+// The configuration is global, and must be set before metrics are registered. It may be loaded from YAML,
+// e.g. with gqlconfig, so platform teams enforce conventions from Helm values or Terraform variables.
+//
+// Example:
+//
+//   err := gqlmetrics.SetConfig(gqlmetrics.Config{
+//     Namespace:   "acme",
+//     Names:       map[string]string{"gql/server/latency": "graphql/latency"},
+//     ConstLabels: map[string]string{"team": "payments"},
+//     DropLabels:  []string{"gql.path"},
+//   })
+//   _ = metrics.Register() // registers the view "acme/graphql/latency"
+//
+// OpenCensus views are renamed and their tags dropped. Constant labels only apply to Prometheus metrics:
+// with OpenCensus, pass them to the exporter, e.g. the ConstLabels of the Prometheus exporter.
+package gqlmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+type (
+	// Config of the naming of metrics
+	Config struct {
+		// Namespace prefixes the names of all metrics, e.g. "acme" yields "acme_graphql_request_duration_ms"
+		// for Prometheus metrics, and "acme/gql/server/latency" for OpenCensus views.
+		Namespace string `yaml:"namespace"`
+
+		// Names of renamed metrics, by default name
+		Names map[string]string `yaml:"names"`
+
+		// ConstLabels are added to all Prometheus metrics
+		ConstLabels map[string]string `yaml:"constLabels"`
+
+		// DropLabels are removed from all metrics, by label or tag name
+		DropLabels []string `yaml:"dropLabels"`
+	}
+)
+
+var (
+	mx     sync.RWMutex
+	global Config
+
+	validName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	validLabel = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// SetConfig sets the global configuration, used by metrics registered afterwards.
+//
+// An invalid configuration is rejected, leaving the global configuration unchanged.
+func SetConfig(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+	global = c
+	return nil
+}
+
+// GetConfig yields the global configuration. By default, metrics keep their names.
+func GetConfig() Config {
+	mx.RLock()
+	defer mx.RUnlock()
+	return global
+}
+
+// Validate the configuration: the namespace and constant labels must be valid Prometheus names,
+// and names of renamed metrics and dropped labels must not be empty
+func (c Config) Validate() error {
+	if c.Namespace != "" && !validName.MatchString(c.Namespace) {
+		return fmt.Errorf("invalid metrics namespace %q", c.Namespace)
+	}
+	for name := range c.ConstLabels {
+		if !validLabel.MatchString(name) {
+			return fmt.Errorf("invalid constant label name %q", name)
+		}
+	}
+	for from, to := range c.Names {
+		if to == "" {
+			return fmt.Errorf("empty name for metric %q", from)
+		}
+	}
+	for _, name := range c.DropLabels {
+		if name == "" {
+			return fmt.Errorf("empty dropped label name")
+		}
+	}
+	return nil
+}
+
+// PrometheusName yields the name of a Prometheus metric, given its default name
+func (c Config) PrometheusName(name string) string {
+	return c.qualify(name, "_")
+}
+
+// ViewName yields the name of an OpenCensus view, given its default name
+func (c Config) ViewName(name string) string {
+	return c.qualify(name, "/")
+}
+
+// KeepLabel tells if a label is kept on metrics
+func (c Config) KeepLabel(name string) bool {
+	for _, dropped := range c.DropLabels {
+		if dropped == name {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels yields the labels kept among these
+func (c Config) Labels(names []string) []string {
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		if c.KeepLabel(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// Views yields copies of views, renamed and without the dropped tags. Views are left unchanged.
+func (c Config) Views(views ...*view.View) []*view.View {
+	configured := make([]*view.View, 0, len(views))
+	for _, v := range views {
+		cv := *v
+		cv.Name = c.ViewName(v.Name)
+		cv.TagKeys = make([]tag.Key, 0, len(v.TagKeys))
+		for _, key := range v.TagKeys {
+			if c.KeepLabel(key.Name()) {
+				cv.TagKeys = append(cv.TagKeys, key)
+			}
+		}
+		configured = append(configured, &cv)
+	}
+	return configured
+}
+
+func (c Config) qualify(name, separator string) string {
+	if renamed, ok := c.Names[name]; ok {
+		name = renamed
+	}
+	if c.Namespace == "" {
+		return name
+	}
+	return c.Namespace + separator + name
+}
+
+// Register views, as configured by the global configuration
+func Register(views ...*view.View) error {
+	return view.Register(GetConfig().Views(views...)...)
+}
+
+// Unregister views registered with Register. The global configuration must not have changed in between.
+func Unregister(views ...*view.View) {
+	view.Unregister(GetConfig().Views(views...)...)
+}
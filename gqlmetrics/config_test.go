@@ -0,0 +1,54 @@
+package gqlmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+func TestConfig(t *testing.T) {
+	c := Config{
+		Namespace:  "acme",
+		Names:      map[string]string{"gql/server/latency": "graphql/latency", "graphql_request_duration_ms": "graphql_latency_ms"},
+		DropLabels: []string{"gql.path", "field"},
+	}
+	require.NoError(t, c.Validate())
+
+	assert.Equal(t, "acme/graphql/latency", c.ViewName("gql/server/latency"))
+	assert.Equal(t, "acme/gql/server/field_latency", c.ViewName("gql/server/field_latency"))
+	assert.Equal(t, "acme_graphql_latency_ms", c.PrometheusName("graphql_request_duration_ms"))
+	assert.Equal(t, "graphql_request_duration_ms", Config{}.PrometheusName("graphql_request_duration_ms"))
+	assert.Equal(t, []string{"exit_status", "object"}, c.Labels([]string{"exit_status", "object", "field"}))
+
+	path := tag.MustNewKey("gql.path")
+	field := tag.MustNewKey("gql.field")
+	v := &view.View{
+		Name:        "gql/server/latency",
+		Measure:     stats.Float64("gql/server/latency", "latency", stats.UnitMilliseconds),
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{field, path},
+	}
+	views := c.Views(v)
+	require.Len(t, views, 1)
+	assert.Equal(t, "acme/graphql/latency", views[0].Name)
+	assert.Equal(t, []tag.Key{field}, views[0].TagKeys)
+	assert.Equal(t, "gql/server/latency", v.Name, "views are copied")
+
+	require.NoError(t, SetConfig(c))
+	defer func() { _ = SetConfig(Config{}) }()
+	require.NoError(t, Register(v))
+	assert.NotNil(t, view.Find("acme/graphql/latency"))
+	Unregister(v)
+	assert.Nil(t, view.Find("acme/graphql/latency"))
+
+	assert.Error(t, Config{Namespace: "acme-corp"}.Validate())
+	assert.Error(t, Config{ConstLabels: map[string]string{"a.b": "c"}}.Validate())
+	assert.Error(t, Config{DropLabels: []string{""}}.Validate())
+
+	assert.Error(t, SetConfig(Config{Namespace: "acme-corp"}))
+	assert.Equal(t, c, GetConfig(), "invalid configurations are rejected")
+}
@@ -0,0 +1,139 @@
+package gqlmock
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type (
+	// Generator produces the value of a scalar or enum field
+	Generator func(GenContext) interface{}
+
+	// GenContext is the context of a generated value
+	GenContext struct {
+		// Rand is a deterministic source of randomness for this value
+		Rand *rand.Rand
+
+		// Object type of the field
+		Object *ast.Definition
+
+		// Field definition
+		Field *ast.FieldDefinition
+
+		// Args are the arguments of the field
+		Args map[string]interface{}
+
+		// Path of the value in the response, e.g. "todos.1.user.name"
+		Path string
+	}
+)
+
+var (
+	firstNames = []string{"Ada", "Alan", "Grace", "Edsger", "Barbara", "Donald", "Margaret", "Ken", "Frances", "Dennis"}
+	lastNames  = []string{"Lovelace", "Turing", "Hopper", "Dijkstra", "Liskov", "Knuth", "Hamilton", "Thompson", "Allen", "Ritchie"}
+	words      = []string{
+		"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do",
+		"eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim",
+	}
+	epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// OneOf is a Generator picking one of some values
+func OneOf(values ...interface{}) Generator {
+	return func(g GenContext) interface{} {
+		return values[g.Rand.Intn(len(values))]
+	}
+}
+
+// Words is a Generator of a sentence with a number of words between min and max
+func Words(min, max int) Generator {
+	return func(g GenContext) interface{} {
+		n := min
+		if max > min {
+			n += g.Rand.Intn(max - min + 1)
+		}
+		sentence := make([]string, n)
+		for i := range sentence {
+			sentence[i] = words[g.Rand.Intn(len(words))]
+		}
+		return strings.Join(sentence, " ")
+	}
+}
+
+// generate the value of a scalar or enum, with the generator of its field or type, or a default one
+func (c config) generate(g GenContext, def *ast.Definition) interface{} {
+	if g.Object != nil && g.Field != nil {
+		if generator, ok := c.fieldGenerators[g.Object.Name+"."+g.Field.Name]; ok {
+			return generator(g)
+		}
+	}
+	if generator, ok := c.generators[def.Name]; ok {
+		return generator(g)
+	}
+
+	if def.Kind == ast.Enum {
+		if len(def.EnumValues) == 0 {
+			return nil
+		}
+		return def.EnumValues[g.Rand.Intn(len(def.EnumValues))].Name
+	}
+
+	switch def.Name {
+	case "Int":
+		return g.Rand.Intn(100)
+	case "Float":
+		return float64(g.Rand.Intn(10000)) / 100
+	case "Boolean":
+		return g.Rand.Intn(2) == 0
+	case "ID":
+		return fmt.Sprintf("%d", 1+g.Rand.Intn(100000))
+	}
+
+	name := strings.ToLower(def.Name)
+	switch {
+	case strings.Contains(name, "datetime"), strings.Contains(name, "time"):
+		return randomTime(g).Format(time.RFC3339)
+	case strings.Contains(name, "date"):
+		return randomTime(g).Format("2006-01-02")
+	}
+	return stringFor(g)
+}
+
+// stringFor generates a string after the name of its field
+func stringFor(g GenContext) interface{} {
+	field := ""
+	if g.Field != nil {
+		field = strings.ToLower(g.Field.Name)
+	}
+	first := firstNames[g.Rand.Intn(len(firstNames))]
+	last := lastNames[g.Rand.Intn(len(lastNames))]
+
+	switch {
+	case strings.Contains(field, "email"):
+		return strings.ToLower(first+"."+last) + "@example.com"
+	case strings.Contains(field, "url"), strings.Contains(field, "uri"), strings.Contains(field, "link"):
+		return "https://example.com/" + words[g.Rand.Intn(len(words))]
+	case strings.Contains(field, "phone"):
+		return fmt.Sprintf("+1 555 %04d", g.Rand.Intn(10000))
+	case strings.Contains(field, "firstname"):
+		return first
+	case strings.Contains(field, "lastname"):
+		return last
+	case strings.Contains(field, "name"):
+		return first + " " + last
+	case strings.HasSuffix(field, "at"), strings.Contains(field, "date"), strings.Contains(field, "time"):
+		return randomTime(g).Format(time.RFC3339)
+	case strings.Contains(field, "description"), strings.Contains(field, "text"), strings.Contains(field, "body"):
+		return Words(8, 20)(g)
+	default:
+		return Words(1, 3)(g)
+	}
+}
+
+func randomTime(g GenContext) time.Time {
+	return epoch.Add(time.Duration(g.Rand.Int63n(int64(365 * 24 * time.Hour))))
+}
@@ -0,0 +1,78 @@
+package gqlmock
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// introspect resolves a selection on the values of the gqlgen introspection package (e.g. *introspection.Schema),
+// by calling their methods or reading their fields: the GraphQL field "possibleTypes" maps to the method
+// PossibleTypes, "name" to the method Name or the field Name.
+func (r *resolver) introspect(value interface{}, selections ast.SelectionSet) interface{} {
+	return r.introspectValue(reflect.ValueOf(value), selections)
+}
+
+func (r *resolver) introspectValue(v reflect.Value, selections ast.SelectionSet) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() != reflect.Struct {
+			return r.introspectValue(v.Elem(), selections)
+		}
+	case reflect.Slice:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = r.introspectValue(v.Index(i), selections)
+		}
+		return list
+	case reflect.Struct:
+		if v.CanAddr() {
+			v = v.Addr()
+		} else {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			v = ptr
+		}
+	default:
+		return v.Interface()
+	}
+
+	typeName := "__" + v.Elem().Type().Name()
+	fields := graphql.CollectFields(r.oc, selections, []string{typeName})
+	obj := make(object, 0, len(fields))
+	for _, field := range fields {
+		var value interface{}
+		if field.Name == "__typename" {
+			value = typeName
+		} else {
+			value = r.introspectValue(introspectField(v, field.Name, field.ArgumentMap(r.oc.Variables)), field.Selections)
+		}
+		obj = append(obj, member{key: field.Alias, value: value})
+	}
+	return obj
+}
+
+// introspectField yields the value of a GraphQL field on a pointer to an introspection struct
+func introspectField(v reflect.Value, name string, args map[string]interface{}) reflect.Value {
+	goName := strings.ToUpper(name[:1]) + name[1:]
+	if method := v.MethodByName(goName); method.IsValid() {
+		in := make([]reflect.Value, method.Type().NumIn())
+		for i := range in {
+			// the only argument of introspection fields is includeDeprecated
+			includeDeprecated, _ := args["includeDeprecated"].(bool)
+			in[i] = reflect.ValueOf(includeDeprecated)
+		}
+		return method.Call(in)[0]
+	}
+	if field := v.Elem().FieldByName(goName); field.IsValid() {
+		return field
+	}
+	return reflect.ValueOf(nil)
+}
@@ -0,0 +1,107 @@
+package gqlmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var schema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	scalar Time
+	enum Role { ADMIN MEMBER }
+	interface Node { id: ID! }
+	type User implements Node { id: ID! name: String! email: String! role: Role! }
+	type Todo implements Node { id: ID! text: String! done: Boolean! createdAt: Time! owner: User! }
+	union Result = User | Todo
+	type Query {
+		todos(first: Int): [Todo!]!
+		search(text: String!): [Result!]!
+		node(id: ID!): Node
+	}
+`})
+
+func query(t *testing.T, mock *Mock, q string) map[string]interface{} {
+	srv := handler.NewDefaultServer(mock)
+	body, _ := json.Marshal(map[string]interface{}{"query": q})
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Nil(t, resp["errors"], rec.Body.String())
+	return resp["data"].(map[string]interface{})
+}
+
+func TestMock(t *testing.T) {
+	mock := New(schema,
+		WithListLength(2, 2),
+		WithFieldGenerator("User.name", OneOf("Ada")),
+	)
+	const q = `{
+		todos(first: 2) { id text done createdAt owner { name email role } }
+		other: todos(first: 3) { id }
+		search(text: "x") { __typename ... on User { name } ... on Todo { text } }
+	}`
+
+	data := query(t, mock, q)
+	todos := data["todos"].([]interface{})
+	require.Len(t, todos, 2)
+	todo := todos[0].(map[string]interface{})
+	assert.IsType(t, "", todo["id"])
+	assert.IsType(t, true, todo["done"])
+	assert.Regexp(t, `^2020-\d\d-\d\dT`, todo["createdAt"])
+	owner := todo["owner"].(map[string]interface{})
+	assert.Equal(t, "Ada", owner["name"])
+	assert.Regexp(t, `@example\.com$`, owner["email"])
+	assert.Contains(t, []interface{}{"ADMIN", "MEMBER"}, owner["role"])
+	assert.Len(t, data["other"], 2)
+
+	for _, item := range data["search"].([]interface{}) {
+		result := item.(map[string]interface{})
+		switch result["__typename"] {
+		case "User":
+			assert.Equal(t, "Ada", result["name"])
+		case "Todo":
+			assert.NotEmpty(t, result["text"])
+		default:
+			t.Errorf("unexpected type %v", result["__typename"])
+		}
+	}
+
+	// deterministic data
+	assert.Equal(t, data, query(t, mock, q))
+	assert.NotEqual(t, data, query(t, New(schema, WithSeed(2), WithListLength(2, 2)), q))
+}
+
+func TestFieldOrder(t *testing.T) {
+	resp, err := json.Marshal(object{{key: "b", value: 1}, {key: "a", value: "x"}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":1,"a":"x"}`, string(resp))
+}
+
+func TestIntrospection(t *testing.T) {
+	data := query(t, New(schema), `{
+		__schema { queryType { name } }
+		__type(name: "Todo") { kind fields { name type { kind ofType { name } } } }
+	}`)
+
+	assert.Equal(t, "Query", data["__schema"].(map[string]interface{})["queryType"].(map[string]interface{})["name"])
+	todo := data["__type"].(map[string]interface{})
+	assert.Equal(t, "OBJECT", todo["kind"])
+	fields := todo["fields"].([]interface{})
+	require.Len(t, fields, 5)
+	id := fields[0].(map[string]interface{})
+	assert.Equal(t, "id", id["name"])
+	assert.Equal(t, "NON_NULL", id["type"].(map[string]interface{})["kind"])
+	assert.Equal(t, "ID", id["type"].(map[string]interface{})["ofType"].(map[string]interface{})["name"])
+}
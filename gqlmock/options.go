@@ -0,0 +1,75 @@
+package gqlmock
+
+import (
+	"time"
+)
+
+type (
+	// Option for the mock
+	Option func(*config)
+
+	config struct {
+		seed            int64
+		minLength       int
+		maxLength       int
+		nullRate        float64
+		interval        time.Duration
+		generators      map[string]Generator
+		fieldGenerators map[string]Generator
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		seed:            1,
+		minLength:       1,
+		maxLength:       5,
+		interval:        time.Second,
+		generators:      make(map[string]Generator),
+		fieldGenerators: make(map[string]Generator),
+	}
+}
+
+// WithSeed sets the seed of generated data. The default is 1.
+func WithSeed(seed int64) Option {
+	return func(c *config) {
+		c.seed = seed
+	}
+}
+
+// WithListLength sets the bounds of the length of generated lists. The default is between 1 and 5 items.
+func WithListLength(min, max int) Option {
+	return func(c *config) {
+		c.minLength = min
+		c.maxLength = max
+	}
+}
+
+// WithNullRate sets the probability for nullable fields to be null, between 0 and 1. The default is 0.
+func WithNullRate(rate float64) Option {
+	return func(c *config) {
+		c.nullRate = rate
+	}
+}
+
+// WithSubscriptionInterval sets the interval between the events of subscriptions. The default is 1s.
+func WithSubscriptionInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+// WithGenerator sets the generator of the values of a scalar or enum type, e.g. "DateTime"
+func WithGenerator(typeName string, generator Generator) Option {
+	return func(c *config) {
+		c.generators[typeName] = generator
+	}
+}
+
+// WithFieldGenerator sets the generator of the values of a field of scalar or enum type, e.g. "User.role".
+// This takes precedence over the generator of the type.
+func WithFieldGenerator(field string, generator Generator) Option {
+	return func(c *config) {
+		c.fieldGenerators[field] = generator
+	}
+}
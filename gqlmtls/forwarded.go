@@ -0,0 +1,121 @@
+package gqlmtls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/url"
+	"strings"
+)
+
+// ParseForwarded parses the identity of the client from a X-Forwarded-Client-Cert header, as set by Envoy:
+//
+//   By=spiffe://cluster.local/ns/default/sa/api;Hash=4f0c...;Subject="CN=billing";URI=spiffe://cluster.local/ns/default/sa/billing
+//
+// When the header lists several elements, e.g. after several proxies, the last one is used: this is the client
+// of the closest proxy. The identity is taken from the certificate, when forwarded, and from the Subject, URI and DNS
+// keys otherwise.
+func ParseForwarded(header string) (Identity, bool) {
+	elements := split(header, ',')
+	if len(elements) == 0 {
+		return Identity{}, false
+	}
+
+	var (
+		identity Identity
+		found    bool
+	)
+	for _, pair := range split(elements[len(elements)-1], ';') {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), unquote(strings.TrimSpace(parts[1]))
+		switch strings.ToLower(key) {
+		case "cert":
+			if cert, ok := parseCertificate(value); ok {
+				return FromCertificate(cert), true
+			}
+		case "hash":
+			identity.Fingerprint = value
+		case "subject":
+			identity.CommonName = commonName(value)
+			found = found || identity.CommonName != ""
+		case "uri":
+			identity.URIs = append(identity.URIs, value)
+			found = true
+		case "dns":
+			identity.DNSNames = append(identity.DNSNames, value)
+			found = true
+		}
+	}
+	return identity, found
+}
+
+func parseCertificate(value string) (*x509.Certificate, bool) {
+	decoded, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, false
+	}
+	block, _ := pem.Decode([]byte(decoded))
+	if block == nil {
+		return nil, false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	return cert, err == nil
+}
+
+// commonName extracts the common name of a distinguished name, e.g. "CN=billing,OU=payments,O=Example"
+func commonName(dn string) string {
+	for _, attribute := range split(dn, ',') {
+		parts := strings.SplitN(attribute, "=", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "CN") {
+			return strings.TrimSpace(parts[1])
+		}
+	}
+	return ""
+}
+
+// split a header value on a separator, outside of double quotes and escaped characters
+func split(value string, sep byte) []string {
+	var (
+		parts   []string
+		start   int
+		quoted  bool
+		escaped bool
+	)
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(value) {
+		parts = append(parts, value[start:])
+	}
+	return parts
+}
+
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	value = value[1 : len(value)-1]
+	var b strings.Builder
+	escaped := false
+	for i := 0; i < len(value); i++ {
+		if !escaped && value[i] == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package gqlmtls
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extractor.
+func Register() error {
+	return gqlmetrics.Register(RequestCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(RequestCountView)
+}
+
+var (
+	// TagSource is the source of the identity of clients (tls, forwarded or none)
+	TagSource = tag.MustNewKey("gql.identity_source")
+
+	// RequestCount tracks a count of requests by source of the client identity
+	RequestCount = stats.Int64(
+		"gql/server/client_identity_count",
+		"Number of requests by source of the client identity",
+		stats.UnitDimensionless)
+
+	// RequestCountView reports a count of requests by source of the client identity
+	RequestCountView = &view.View{
+		Name:        "gql/server/client_identity_count",
+		Description: "Count of GraphQL requests by source of the client identity",
+		Measure:     RequestCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagSource},
+	}
+)
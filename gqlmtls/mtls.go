@@ -0,0 +1,239 @@
+// Package gqlmtls extracts the identity of clients from their TLS certificate, for zero-trust meshes.
+//
+// The identity of the client, i.e. its SPIFFE ID, other URI and DNS SANs or common name, is exposed to resolvers
+// and other extensions through the context, e.g. as the key of rate limits or in audit logs, and is set as
+// attributes of the trace span of the operation.
+//
+// Example, with TLS terminated by the server:
+//
+//   ext := gqlmtls.New()
+//   srv.Use(gqlopencensus.New())
+//   srv.Use(ext)
+//   http.Handle("/query", ext.Middleware(srv))
+//
+//   server := &http.Server{TLSConfig: &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}}
+//
+// When TLS is terminated by a sidecar proxy such as Envoy, the certificate of the client is forwarded
+// in the X-Forwarded-Client-Cert header, which must be explicitly trusted:
+//
+//   ext := gqlmtls.New(gqlmtls.WithForwardedHeader("X-Forwarded-Client-Cert"))
+//
+// In resolvers and extensions:
+//
+//   clientID := gqlmtls.ID(ctx)
+package gqlmtls
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+)
+
+const extensionName = "ClientIdentity"
+
+// Sources of identities
+const (
+	SourceTLS       = "tls"
+	SourceForwarded = "forwarded"
+	SourceNone      = "none"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Extractor{}
+
+type (
+	// Identity of a client, from its certificate
+	Identity struct {
+		CommonName string
+		DNSNames   []string
+		URIs       []string
+		Emails     []string
+		Issuer     string
+		Serial     string
+
+		// Fingerprint is the hex-encoded SHA-256 hash of the certificate
+		Fingerprint string
+		NotAfter    time.Time
+
+		// Source of the identity: SourceTLS or SourceForwarded
+		Source string
+	}
+
+	// Extractor extracts the identity of clients from their certificate, as a middleware,
+	// and sets it as attributes of the trace span of operations, as a gqlgen extension
+	Extractor struct {
+		*config
+	}
+
+	identityKey struct{}
+)
+
+// New identity extractor
+func New(opts ...Option) *Extractor {
+	e := &Extractor{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(e.config)
+	}
+	return e
+}
+
+// FromCertificate builds the identity of the subject of a certificate
+func FromCertificate(cert *x509.Certificate) Identity {
+	fingerprint := sha256.Sum256(cert.Raw)
+	identity := Identity{
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		Emails:      cert.EmailAddresses,
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotAfter:    cert.NotAfter,
+	}
+	if cert.SerialNumber != nil {
+		identity.Serial = cert.SerialNumber.String()
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	return identity
+}
+
+// ID yields a stable identifier of the client: its SPIFFE ID, or else its first URI SAN, DNS SAN, email SAN
+// or common name
+func (i Identity) ID() string {
+	for _, uri := range i.URIs {
+		if strings.HasPrefix(uri, "spiffe://") {
+			return uri
+		}
+	}
+	switch {
+	case len(i.URIs) > 0:
+		return i.URIs[0]
+	case len(i.DNSNames) > 0:
+		return i.DNSNames[0]
+	case len(i.Emails) > 0:
+		return i.Emails[0]
+	default:
+		return i.CommonName
+	}
+}
+
+// FromContext yields the identity of the client of a request, if any
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// ID yields the identifier of the client of a request, or an empty string
+func ID(ctx context.Context) string {
+	identity, ok := FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return identity.ID()
+}
+
+// WithIdentity sets the identity of the client in a context, e.g. in tests
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Middleware extracts the identity of the client from its certificate, or from the forwarded certificate
+// when a forwarded header is trusted.
+//
+// Requests without identity are rejected with a 403 status in strict mode.
+func (e *Extractor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := e.extract(r)
+		source := SourceNone
+		if ok {
+			source = identity.Source
+		}
+		_ = stats.RecordWithTags(r.Context(), []tag.Mutator{tag.Upsert(TagSource, source)}, RequestCount.M(1))
+
+		if !ok {
+			if e.config.strict {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
+
+// ExtensionName yields the extension name: "ClientIdentity"
+func (*Extractor) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Extractor) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse sets the identity of the client as attributes of the trace span of the operation
+func (e *Extractor) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	identity, ok := FromContext(ctx)
+	if !ok {
+		return next(ctx)
+	}
+	if span := trace.FromContext(ctx); span != nil && span.IsRecordingEvents() {
+		attributes := []gqlattr.KeyValue{
+			{Key: "client.id", Value: identity.ID()},
+			{Key: "client.identity_source", Value: identity.Source},
+		}
+		if identity.Fingerprint != "" {
+			attributes = append(attributes, gqlattr.KeyValue{Key: "client.cert_fingerprint", Value: identity.Fingerprint})
+		}
+		if identity.Issuer != "" {
+			attributes = append(attributes, gqlattr.KeyValue{Key: "client.cert_issuer", Value: identity.Issuer})
+		}
+		span.AddAttributes(ocattr.Attributes(gqlattr.Default(), attributes)...)
+	}
+	return next(ctx)
+}
+
+func (e *Extractor) extract(r *http.Request) (Identity, bool) {
+	if r.TLS != nil {
+		var cert *x509.Certificate
+		switch {
+		case len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0:
+			cert = r.TLS.VerifiedChains[0][0]
+		case e.config.unverified && len(r.TLS.PeerCertificates) > 0:
+			cert = r.TLS.PeerCertificates[0]
+		}
+		if cert != nil {
+			identity := FromCertificate(cert)
+			identity.Source = SourceTLS
+			return identity, true
+		}
+	}
+
+	if e.config.forwardedHeader == "" {
+		return Identity{}, false
+	}
+	value := r.Header.Get(e.config.forwardedHeader)
+	if value == "" {
+		return Identity{}, false
+	}
+	identity, ok := ParseForwarded(value)
+	identity.Source = SourceForwarded
+	return identity, ok
+}
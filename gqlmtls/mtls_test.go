@@ -0,0 +1,129 @@
+package gqlmtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func certificate(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	spiffe, err := url.Parse("spiffe://cluster.local/ns/default/sa/billing")
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "billing"},
+		Issuer:       pkix.Name{CommonName: "mesh-ca"},
+		DNSNames:     []string{"billing.default.svc"},
+		URIs:         []*url.URL{spiffe},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestMiddleware(t *testing.T) {
+	cert := certificate(t)
+
+	var (
+		identity Identity
+		found    bool
+	)
+	handler := func(e *Extractor) http.Handler {
+		return e.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			identity, found = FromContext(r.Context())
+		}))
+	}
+	serve := func(h http.Handler, r *http.Request) int {
+		identity, found = Identity{}, false
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("verified certificates", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		serve(handler(New()), r)
+
+		require.True(t, found)
+		assert.Equal(t, "spiffe://cluster.local/ns/default/sa/billing", identity.ID())
+		assert.Equal(t, "billing", identity.CommonName)
+		assert.Equal(t, "42", identity.Serial)
+		assert.Equal(t, SourceTLS, identity.Source)
+		assert.Len(t, identity.Fingerprint, 64)
+	})
+
+	t.Run("unverified certificates", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		serve(handler(New()), r)
+		assert.False(t, found)
+
+		serve(handler(New(WithUnverified(true))), r)
+		assert.True(t, found)
+	})
+
+	t.Run("forwarded certificates", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.Header.Set("X-Forwarded-Client-Cert", `Hash=abc;Subject="CN=search,O=Example";DNS=search.default.svc`)
+		serve(handler(New()), r)
+		assert.False(t, found, "forwarded headers are not trusted by default")
+
+		serve(handler(New(WithForwardedHeader("X-Forwarded-Client-Cert"))), r)
+		require.True(t, found)
+		assert.Equal(t, "search.default.svc", identity.ID())
+		assert.Equal(t, "search", identity.CommonName)
+		assert.Equal(t, SourceForwarded, identity.Source)
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		assert.Equal(t, http.StatusForbidden, serve(handler(New(WithStrict(true))), r))
+		assert.Equal(t, http.StatusOK, serve(handler(New()), r))
+	})
+}
+
+func TestParseForwarded(t *testing.T) {
+	cert := certificate(t)
+	encoded := url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+
+	identity, ok := ParseForwarded(`By=spiffe://cluster.local/ns/default/sa/gateway;URI=spiffe://cluster.local/ns/default/sa/web,` +
+		`By=spiffe://cluster.local/ns/default/sa/api;Cert="` + encoded + `";Subject="CN=ignored"`)
+	require.True(t, ok)
+	assert.Equal(t, "spiffe://cluster.local/ns/default/sa/billing", identity.ID(), "the last element is used")
+
+	identity, ok = ParseForwarded(`Subject="CN=a \"quoted\" name,OU=x";Hash=abc`)
+	require.True(t, ok)
+	assert.Equal(t, `a "quoted" name`, identity.ID())
+	assert.Equal(t, "abc", identity.Fingerprint)
+
+	_, ok = ParseForwarded(`Hash=abc`)
+	assert.False(t, ok)
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, "", ID(context.Background()))
+	ctx := WithIdentity(context.Background(), Identity{Emails: []string{"ops@example.com"}, CommonName: "ops"})
+	assert.Equal(t, "ops@example.com", ID(ctx))
+}
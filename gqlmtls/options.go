@@ -0,0 +1,41 @@
+package gqlmtls
+
+type (
+	// Option for the identity extractor
+	Option func(*config)
+
+	config struct {
+		forwardedHeader string
+		unverified      bool
+		strict          bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithForwardedHeader trusts the certificate forwarded by a proxy in a header, e.g. "X-Forwarded-Client-Cert",
+// for requests without a client certificate.
+//
+// The header must be set by a trusted proxy terminating TLS, and stripped from requests of other origins.
+func WithForwardedHeader(name string) Option {
+	return func(c *config) {
+		c.forwardedHeader = name
+	}
+}
+
+// WithUnverified accepts client certificates which were not verified, e.g. with tls.RequestClientCert.
+// By default, only certificates of verified chains are accepted.
+func WithUnverified(enabled bool) Option {
+	return func(c *config) {
+		c.unverified = enabled
+	}
+}
+
+// WithStrict rejects requests without client identity, with a 403 status
+func WithStrict(enabled bool) Option {
+	return func(c *config) {
+		c.strict = enabled
+	}
+}
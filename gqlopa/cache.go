@@ -0,0 +1,80 @@
+package gqlopa
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// decisionCache retains decisions for a while, up to a maximum number of entries
+	decisionCache struct {
+		ttl   time.Duration
+		size  int
+		clock func() time.Time
+
+		mx      sync.Mutex
+		entries map[string]cachedDecision
+	}
+
+	cachedDecision struct {
+		decision Decision
+		expires  time.Time
+	}
+)
+
+func newDecisionCache(ttl time.Duration, size int, clock func() time.Time) *decisionCache {
+	return &decisionCache{
+		ttl:     ttl,
+		size:    size,
+		clock:   clock,
+		entries: make(map[string]cachedDecision),
+	}
+}
+
+func (c *decisionCache) get(key string) (Decision, bool) {
+	if c.ttl <= 0 {
+		return Decision{}, false
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return Decision{}, false
+	}
+	if !c.clock().Before(entry.expires) {
+		delete(c.entries, key)
+		return Decision{}, false
+	}
+	return entry.decision, true
+}
+
+func (c *decisionCache) set(key string, decision Decision) {
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+	now := c.clock()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if len(c.entries) >= c.size {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= c.size {
+		// evict an arbitrary entry
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = cachedDecision{decision: decision, expires: now.Add(c.ttl)}
+}
+
+func (c *decisionCache) flush() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.entries = make(map[string]cachedDecision)
+}
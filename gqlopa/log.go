@@ -0,0 +1,53 @@
+package gqlopa
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type (
+	// DecisionLog reports a decision
+	DecisionLog struct {
+		Time       time.Time     `json:"time"`
+		Input      Input         `json:"input"`
+		Decision   Decision      `json:"decision"`
+		DecisionID string        `json:"decisionId,omitempty"`
+		Cached     bool          `json:"cached"`
+		Error      string        `json:"error,omitempty"`
+		Duration   time.Duration `json:"duration"`
+	}
+
+	// DecisionLogger records decisions, e.g. for audits
+	DecisionLogger interface {
+		LogDecision(context.Context, DecisionLog)
+	}
+
+	// DecisionLoggerFunc is a function implementing DecisionLogger
+	DecisionLoggerFunc func(context.Context, DecisionLog)
+
+	// WriterLogger writes decisions as JSON lines. It is safe for concurrent use.
+	WriterLogger struct {
+		mx      sync.Mutex
+		encoder *json.Encoder
+	}
+)
+
+// LogDecision implements DecisionLogger
+func (f DecisionLoggerFunc) LogDecision(ctx context.Context, entry DecisionLog) {
+	f(ctx, entry)
+}
+
+// NewWriterLogger builds a DecisionLogger writing JSON lines to a writer
+func NewWriterLogger(w io.Writer) *WriterLogger {
+	return &WriterLogger{encoder: json.NewEncoder(w)}
+}
+
+// LogDecision implements DecisionLogger. Write errors are ignored.
+func (l *WriterLogger) LogDecision(_ context.Context, entry DecisionLog) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	_ = l.encoder.Encode(entry)
+}
@@ -0,0 +1,72 @@
+package gqlopa
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(DecisionCountView, DecisionLatencyView, ReloadCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(DecisionCountView, DecisionLatencyView, ReloadCountView)
+}
+
+var (
+	// TagResult is the result of a decision (allow, deny or error), or of a reload (ok or error)
+	TagResult = tag.MustNewKey("gql.opa_result")
+
+	// DecisionCount tracks a count of policy decisions
+	DecisionCount = stats.Int64(
+		"gql/server/opa_decision_count",
+		"Number of policy decisions",
+		stats.UnitDimensionless)
+
+	// DecisionLatency tracks the time spent evaluating policies, excluding cached decisions, in milliseconds
+	DecisionLatency = stats.Float64(
+		"gql/server/opa_decision_latency",
+		"Time spent evaluating policies",
+		stats.UnitMilliseconds)
+
+	// ReloadCount tracks a count of policy reloads
+	ReloadCount = stats.Int64(
+		"gql/server/opa_reload_count",
+		"Number of policy reloads",
+		stats.UnitDimensionless)
+
+	// DecisionCountView reports a count of policy decisions by operation, field and result
+	DecisionCountView = &view.View{
+		Name:        "gql/server/opa_decision_count",
+		Description: "Count of policy decisions by operation, field and result",
+		Measure:     DecisionCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, metrics.TagField, TagResult},
+	}
+
+	// DecisionLatencyView reports a distribution of the time spent evaluating policies by result (in milliseconds)
+	DecisionLatencyView = &view.View{
+		Name:        "gql/server/opa_decision_latency",
+		Description: "Distribution of the time spent evaluating policies by result",
+		Measure:     DecisionLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagResult},
+	}
+
+	// ReloadCountView reports a count of policy reloads by result
+	ReloadCountView = &view.View{
+		Name:        "gql/server/opa_reload_count",
+		Description: "Count of policy reloads by result",
+		Measure:     ReloadCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagResult},
+	}
+)
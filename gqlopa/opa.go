@@ -0,0 +1,302 @@
+// Package gqlopa provides a gqlgen extension authorizing operations, and optionally fields, with Open Policy Agent.
+//
+// Policies are evaluated with an Input describing the operation (or field) and the claims of the caller:
+//
+//   {
+//     "operation": "userProfile",
+//     "operationType": "query",
+//     "field": "User.email",
+//     "path": "user.email",
+//     "args": {},
+//     "claims": {"sub": "42", "roles": ["support"]}
+//   }
+//
+// The result of the policy is either a boolean, or an object {"allow": true, "reason": "..."}.
+//
+// Decisions are cached for a short while, so that repeated operations and fields do not hit the policy engine
+// every time. Every decision is reported to an optional decision logger.
+//
+// Example, with an OPA server or sidecar:
+//
+//   srv.Use(gqlopa.New(
+//     gqlopa.NewRemote("http://localhost:8181/v1/data/graphql/authz", nil),
+//     gqlopa.WithClaims(func(ctx context.Context) map[string]interface{} { return auth.ForContext(ctx).Claims }),
+//     gqlopa.WithFields("User.email", "Query.auditLog"),
+//     gqlopa.WithDecisionLogger(gqlopa.NewWriterLogger(os.Stdout)),
+//   ))
+//
+// Policies may also be embedded with the rego package of OPA, and reloaded when their bundle changes,
+// with a Reloader:
+//
+//   reloader, err := gqlopa.NewReloader(ctx, func(ctx context.Context) (gqlopa.Evaluator, error) {
+//     query, err := rego.New(rego.Query("data.graphql.authz"), rego.LoadBundle("policies")).PrepareForEval(ctx)
+//     if err != nil {
+//       return nil, err
+//     }
+//     return gqlopa.EvaluatorFunc(func(ctx context.Context, input gqlopa.Input) (gqlopa.Decision, error) {
+//       rs, err := query.Eval(ctx, rego.EvalInput(input))
+//       if err != nil || len(rs) == 0 || len(rs[0].Expressions) == 0 {
+//         return gqlopa.Decision{}, err
+//       }
+//       return gqlopa.ParseDecision(rs[0].Expressions[0].Value)
+//     }), nil
+//   })
+//   go reloader.Watch(ctx, time.Minute)
+//   srv.Use(gqlopa.New(reloader))
+//
+// The decision cache is flushed whenever the Reloader reloads its policies.
+package gqlopa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcode"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "OPA"
+
+	// CodeForbidden is the code of errors reporting denied operations and fields
+	CodeForbidden = gqlcode.Forbidden
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = &Authorizer{}
+
+var pathIndices = regexp.MustCompile(`\[\d+\]`)
+
+type (
+	// Input of policies
+	Input struct {
+		Operation     string `json:"operation"`
+		OperationType string `json:"operationType"`
+
+		// Field, as "Object.field", when a field is authorized
+		Field string `json:"field,omitempty"`
+
+		// Path of the field in the response, without list indices, e.g. "users.email"
+		Path string `json:"path,omitempty"`
+
+		Args   map[string]interface{} `json:"args,omitempty"`
+		Claims map[string]interface{} `json:"claims,omitempty"`
+	}
+
+	// Decision of a policy
+	Decision struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason,omitempty"`
+
+		// ID of the decision, when provided by the policy engine
+		ID string `json:"-"`
+	}
+
+	// Evaluator evaluates policies
+	Evaluator interface {
+		Evaluate(context.Context, Input) (Decision, error)
+	}
+
+	// EvaluatorFunc is a function implementing Evaluator
+	EvaluatorFunc func(context.Context, Input) (Decision, error)
+
+	// Authorizer is a gqlgen extension authorizing operations and fields with policies
+	Authorizer struct {
+		*config
+		evaluator Evaluator
+		cache     *decisionCache
+	}
+)
+
+// Evaluate implements Evaluator
+func (f EvaluatorFunc) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	return f(ctx, input)
+}
+
+// ParseDecision converts the result of a policy, either a boolean or an object with "allow" and "reason" keys,
+// into a Decision. Undefined results deny.
+func ParseDecision(result interface{}) (Decision, error) {
+	switch r := result.(type) {
+	case nil:
+		return Decision{}, nil
+	case bool:
+		return Decision{Allow: r}, nil
+	case map[string]interface{}:
+		allow, ok := r["allow"].(bool)
+		if !ok && r["allow"] != nil {
+			return Decision{}, fmt.Errorf("unexpected policy result: allow is a %T", r["allow"])
+		}
+		reason, _ := r["reason"].(string)
+		return Decision{Allow: allow, Reason: reason}, nil
+	default:
+		return Decision{}, fmt.Errorf("unexpected policy result: %T", result)
+	}
+}
+
+// New authorizing extension, evaluating policies with an Evaluator
+func New(evaluator Evaluator, opts ...Option) *Authorizer {
+	a := &Authorizer{
+		config:    defaultConfig(),
+		evaluator: evaluator,
+	}
+	for _, apply := range opts {
+		apply(a.config)
+	}
+	a.cache = newDecisionCache(a.config.cacheTTL, a.config.cacheSize, a.config.clock)
+	if reloadable, ok := evaluator.(interface{ OnReload(func()) }); ok {
+		reloadable.OnReload(a.cache.flush)
+	}
+	return a
+}
+
+// ExtensionName yields the extension name: "OPA"
+func (*Authorizer) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Authorizer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor, authorizing the operation once.
+//
+// Denied operations yield a single response, so that subscriptions and websocket operations terminate.
+func (a *Authorizer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if !a.config.operations {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	input := a.input(ctx, oc)
+	decision := a.decide(ctx, input, oc)
+	if decision.Allow {
+		return next(ctx)
+	}
+	return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{a.forbidden(decision)}})
+}
+
+// InterceptField implements the gqlgen field interceptor, authorizing the fields selected with WithFields
+func (a *Authorizer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if len(a.config.fields) == 0 {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil {
+		return next(ctx)
+	}
+	field := fc.Object + "." + fc.Field.Name
+	if _, ok := a.config.fields[field]; !ok {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	input := a.input(ctx, oc)
+	input.Field = field
+	input.Path = pathIndices.ReplaceAllString(fc.Path().String(), "")
+	input.Args = fc.Args
+
+	decision := a.decide(ctx, input, oc)
+	if decision.Allow {
+		return next(ctx)
+	}
+	err := a.forbidden(decision)
+	err.Path = fc.Path()
+	return nil, err
+}
+
+func (a *Authorizer) input(ctx context.Context, oc *graphql.OperationContext) Input {
+	input := Input{
+		Operation: oc.OperationName,
+	}
+	if oc.Operation != nil {
+		input.OperationType = string(oc.Operation.Operation)
+	}
+	if a.config.claims != nil {
+		input.Claims = a.config.claims(ctx)
+	}
+	return input
+}
+
+// decide on an input, from the cache or the evaluator
+func (a *Authorizer) decide(ctx context.Context, input Input, oc *graphql.OperationContext) Decision {
+	start := a.config.clock()
+	key, keyErr := json.Marshal(input)
+	if keyErr == nil {
+		if decision, ok := a.cache.get(string(key)); ok {
+			a.report(ctx, input, oc, decision, nil, true, start)
+			return decision
+		}
+	}
+
+	decision, err := a.evaluator.Evaluate(ctx, input)
+	if err != nil {
+		decision = Decision{Allow: a.config.failOpen}
+	} else if keyErr == nil {
+		a.cache.set(string(key), decision)
+	}
+	a.report(ctx, input, oc, decision, err, false, start)
+	return decision
+}
+
+func (a *Authorizer) report(ctx context.Context, input Input, oc *graphql.OperationContext, decision Decision, err error, cached bool, start time.Time) {
+	elapsed := a.config.clock().Sub(start)
+	result := "deny"
+	switch {
+	case err != nil:
+		result = "error"
+	case decision.Allow:
+		result = "allow"
+	}
+
+	tags := []tag.Mutator{
+		tag.Upsert(metrics.TagOperation, a.config.opLabel(oc)),
+		tag.Upsert(metrics.TagField, input.Field),
+		tag.Upsert(TagResult, result),
+	}
+	measurements := []stats.Measurement{DecisionCount.M(1)}
+	if !cached {
+		measurements = append(measurements, DecisionLatency.M(float64(elapsed)/float64(time.Millisecond)))
+	}
+	_ = stats.RecordWithTags(ctx, tags, measurements...)
+
+	if a.config.logger == nil {
+		return
+	}
+	entry := DecisionLog{
+		Time:       start,
+		Input:      input,
+		Decision:   decision,
+		DecisionID: decision.ID,
+		Cached:     cached,
+		Duration:   elapsed,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.config.logger.LogDecision(ctx, entry)
+}
+
+func (a *Authorizer) forbidden(decision Decision) *gqlerror.Error {
+	message := decision.Reason
+	if message == "" {
+		message = a.config.message
+	}
+	return &gqlerror.Error{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code": CodeForbidden,
+		},
+	}
+}
@@ -0,0 +1,192 @@
+package gqlopa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func operationContext(name string) context.Context {
+	return graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: name,
+		Operation:     &ast.OperationDefinition{Operation: ast.Query},
+	})
+}
+
+func TestRemote(t *testing.T) {
+	var received []Input
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req remoteRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		received = append(received, req.Input)
+
+		switch req.Input.Operation {
+		case "allowed":
+			_, _ = w.Write([]byte(`{"result":true,"decision_id":"d1"}`))
+		case "denied":
+			_, _ = w.Write([]byte(`{"result":{"allow":false,"reason":"admins only"},"decision_id":"d2"}`))
+		case "undefined":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	remote := NewRemote(server.URL, nil)
+	ctx := context.Background()
+
+	decision, err := remote.Evaluate(ctx, Input{Operation: "allowed", Claims: map[string]interface{}{"sub": "42"}})
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Allow: true, ID: "d1"}, decision)
+	assert.Equal(t, map[string]interface{}{"sub": "42"}, received[0].Claims)
+
+	decision, err = remote.Evaluate(ctx, Input{Operation: "denied"})
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Reason: "admins only", ID: "d2"}, decision)
+
+	decision, err = remote.Evaluate(ctx, Input{Operation: "undefined"})
+	require.NoError(t, err)
+	assert.False(t, decision.Allow)
+
+	_, err = remote.Evaluate(ctx, Input{Operation: "broken"})
+	assert.Error(t, err)
+}
+
+func TestAuthorizer(t *testing.T) {
+	var evaluations int32
+	evaluator := EvaluatorFunc(func(_ context.Context, input Input) (Decision, error) {
+		atomic.AddInt32(&evaluations, 1)
+		switch {
+		case input.Operation == "broken":
+			return Decision{}, errors.New("boom")
+		case input.Field == "User.email":
+			return Decision{Allow: input.Claims["sub"] == "admin", Reason: "admins only"}, nil
+		default:
+			return Decision{Allow: input.Operation != "forbidden"}, nil
+		}
+	})
+
+	var logged bytes.Buffer
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := New(evaluator,
+		WithClaims(func(ctx context.Context) map[string]interface{} { return map[string]interface{}{"sub": "42"} }),
+		WithFields("User.email"),
+		WithDecisionLogger(NewWriterLogger(&logged)),
+		WithClock(func() time.Time { return now }),
+	)
+	require.Equal(t, extensionName, a.ExtensionName())
+
+	ok := func(context.Context) graphql.ResponseHandler {
+		return graphql.OneShot(&graphql.Response{Data: []byte(`{}`)})
+	}
+
+	t.Run("operations", func(t *testing.T) {
+		resp := a.InterceptOperation(operationContext("allowed"), ok)(context.Background())
+		assert.Empty(t, resp.Errors)
+
+		resp = a.InterceptOperation(operationContext("forbidden"), ok)(context.Background())
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "not authorized", resp.Errors[0].Message)
+		assert.Equal(t, CodeForbidden, resp.Errors[0].Extensions["code"])
+
+		resp = a.InterceptOperation(operationContext("broken"), ok)(context.Background())
+		require.Len(t, resp.Errors, 1, "errors deny by default")
+	})
+
+	t.Run("denials are one shot", func(t *testing.T) {
+		// the websocket transport pulls responses until it gets nil
+		handler := a.InterceptOperation(operationContext("forbidden"), ok)
+		resp := handler(context.Background())
+		require.NotNil(t, resp)
+		require.Len(t, resp.Errors, 1)
+		assert.Nil(t, handler(context.Background()))
+	})
+
+	t.Run("decisions are cached", func(t *testing.T) {
+		before := atomic.LoadInt32(&evaluations)
+		a.InterceptOperation(operationContext("allowed"), ok)(context.Background())
+		assert.Equal(t, before, atomic.LoadInt32(&evaluations))
+
+		now = now.Add(time.Minute)
+		a.InterceptOperation(operationContext("allowed"), ok)(context.Background())
+		assert.Equal(t, before+1, atomic.LoadInt32(&evaluations))
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		resolve := func(field string) (interface{}, error) {
+			ctx := graphql.WithFieldContext(operationContext("allowed"), &graphql.FieldContext{
+				Object: "User",
+				Field:  graphql.CollectedField{Field: &ast.Field{Name: field, Alias: field}},
+			})
+			return a.InterceptField(ctx, func(context.Context) (interface{}, error) { return "value", nil })
+		}
+
+		res, err := resolve("name")
+		require.NoError(t, err)
+		assert.Equal(t, "value", res)
+
+		_, err = resolve("email")
+		require.Error(t, err)
+		gqlErr, isGQLError := err.(*gqlerror.Error)
+		require.True(t, isGQLError)
+		assert.Equal(t, "admins only", gqlErr.Message)
+		assert.Equal(t, ast.Path{ast.PathName("email")}, gqlErr.Path)
+	})
+
+	var entry DecisionLog
+	require.NoError(t, json.NewDecoder(&logged).Decode(&entry))
+	assert.Equal(t, "allowed", entry.Input.Operation)
+	assert.Equal(t, "query", entry.Input.OperationType)
+	assert.True(t, entry.Decision.Allow)
+	assert.False(t, entry.Cached)
+}
+
+func TestReloader(t *testing.T) {
+	var version int32
+	reloader, err := NewReloader(context.Background(), func(context.Context) (Evaluator, error) {
+		v := atomic.AddInt32(&version, 1)
+		if v == 3 {
+			return nil, errors.New("invalid bundle")
+		}
+		return EvaluatorFunc(func(context.Context, Input) (Decision, error) {
+			return Decision{Allow: v > 1}, nil
+		}), nil
+	})
+	require.NoError(t, err)
+
+	a := New(reloader)
+	ok := func(context.Context) graphql.ResponseHandler { return graphql.OneShot(&graphql.Response{}) }
+
+	resp := a.InterceptOperation(operationContext("op"), ok)(context.Background())
+	assert.Len(t, resp.Errors, 1)
+
+	require.NoError(t, reloader.Reload(context.Background()))
+	resp = a.InterceptOperation(operationContext("op"), ok)(context.Background())
+	assert.Empty(t, resp.Errors, "the cache is flushed on reload")
+
+	require.Error(t, reloader.Reload(context.Background()))
+	resp = a.InterceptOperation(operationContext("op"), ok)(context.Background())
+	assert.Empty(t, resp.Errors, "failed reloads retain the previous policies")
+}
+
+func TestParseDecision(t *testing.T) {
+	decision, err := ParseDecision(map[string]interface{}{"allow": true, "reason": "owner"})
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Allow: true, Reason: "owner"}, decision)
+
+	_, err = ParseDecision("yes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package gqlopa
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the authorizing extension
+	Option func(*config)
+
+	config struct {
+		claims     func(context.Context) map[string]interface{}
+		fields     map[string]struct{}
+		operations bool
+		cacheTTL   time.Duration
+		cacheSize  int
+		failOpen   bool
+		message    string
+		logger     DecisionLogger
+		opLabel    gqllabel.OperationLabeler
+		clock      func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		operations: true,
+		cacheTTL:   30 * time.Second,
+		cacheSize:  10000,
+		message:    "not authorized",
+		opLabel:    gqllabel.OperationName,
+		clock:      graphql.Now,
+	}
+}
+
+// WithClaims sets the function retrieving the claims of the caller from the context, submitted to policies
+func WithClaims(claims func(context.Context) map[string]interface{}) Option {
+	return func(c *config) {
+		c.claims = claims
+	}
+}
+
+// WithFields authorizes these fields, identified as "Object.field", e.g. "User.email", before their resolution.
+//
+// By default, only operations are authorized.
+func WithFields(fields ...string) Option {
+	return func(c *config) {
+		if c.fields == nil {
+			c.fields = make(map[string]struct{}, len(fields))
+		}
+		for _, field := range fields {
+			c.fields[field] = struct{}{}
+		}
+	}
+}
+
+// WithOperations enables or disables the authorization of operations. The default is enabled.
+func WithOperations(enabled bool) Option {
+	return func(c *config) {
+		c.operations = enabled
+	}
+}
+
+// WithCache sets the time decisions are cached, and the maximum number of cached decisions.
+// The default is 30s and 10000 decisions. A zero TTL disables the cache.
+func WithCache(ttl time.Duration, size int) Option {
+	return func(c *config) {
+		c.cacheTTL = ttl
+		c.cacheSize = size
+	}
+}
+
+// WithFailOpen allows operations and fields when policies fail to be evaluated. By default, they are denied.
+func WithFailOpen(enabled bool) Option {
+	return func(c *config) {
+		c.failOpen = enabled
+	}
+}
+
+// WithMessage sets the message of errors denying operations and fields, when the decision carries no reason.
+// The default is "not authorized".
+func WithMessage(message string) Option {
+	return func(c *config) {
+		c.message = message
+	}
+}
+
+// WithDecisionLogger sets a logger of all decisions, including cached ones
+func WithDecisionLogger(logger DecisionLogger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
+
+// WithClock sets the clock of the extension. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,153 @@
+package gqlopa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+var (
+	_ Evaluator = &Remote{}
+	_ Evaluator = &Reloader{}
+)
+
+type (
+	// Remote evaluates policies with the data API of an OPA server, e.g. "http://localhost:8181/v1/data/graphql/authz"
+	Remote struct {
+		url    string
+		client *http.Client
+	}
+
+	// Loader loads the policies of an Evaluator, e.g. from a bundle
+	Loader func(context.Context) (Evaluator, error)
+
+	// Reloader is an Evaluator reloading its policies on demand or periodically.
+	//
+	// Policies are replaced atomically: evaluations in progress complete with the previous policies.
+	Reloader struct {
+		load Loader
+
+		mx        sync.RWMutex
+		current   Evaluator
+		callbacks []func()
+	}
+
+	remoteRequest struct {
+		Input Input `json:"input"`
+	}
+
+	remoteResponse struct {
+		Result     interface{} `json:"result"`
+		DecisionID string      `json:"decision_id"`
+	}
+)
+
+// NewRemote builds an Evaluator querying the data API of an OPA server.
+//
+// A nil client defaults to http.DefaultClient.
+func NewRemote(url string, client *http.Client) *Remote {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Remote{url: url, client: client}
+}
+
+// Evaluate implements Evaluator
+func (r *Remote) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	body, err := json.Marshal(remoteRequest{Input: input})
+	if err != nil {
+		return Decision{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return Decision{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("policy evaluation failed with status %d", resp.StatusCode)
+	}
+
+	var result remoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Decision{}, err
+	}
+	decision, err := ParseDecision(result.Result)
+	decision.ID = result.DecisionID
+	return decision, err
+}
+
+// NewReloader builds an Evaluator with the policies of a Loader, failing when the initial load fails
+func NewReloader(ctx context.Context, load Loader) (*Reloader, error) {
+	r := &Reloader{load: load}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Evaluate implements Evaluator, with the current policies
+func (r *Reloader) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	r.mx.RLock()
+	current := r.current
+	r.mx.RUnlock()
+	return current.Evaluate(ctx, input)
+}
+
+// Reload the policies. On failure, the previous policies are retained.
+func (r *Reloader) Reload(ctx context.Context) error {
+	evaluator, err := r.load(ctx)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagResult, result)}, ReloadCount.M(1))
+	if err != nil {
+		return err
+	}
+
+	r.mx.Lock()
+	r.current = evaluator
+	callbacks := r.callbacks
+	r.mx.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+	return nil
+}
+
+// Watch reloads the policies periodically, until the context is done. Reload errors are ignored:
+// the previous policies are retained until the next successful reload.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.Reload(ctx)
+		}
+	}
+}
+
+// OnReload registers a callback, called after each successful reload
+func (r *Reloader) OnReload(callback func()) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.callbacks = append(r.callbacks, callback)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/99designs/gqlgen/graphql"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
 )
 
 const extensionName = "OpencensusMetrics"
@@ -71,10 +73,10 @@ func (m Collector) InterceptField(ctx context.Context, next graphql.Resolver) (r
 		return next(ctx)
 	}
 
-	start := graphql.Now()
+	start := m.config.clock()
 
 	defer func() {
-		end := graphql.Now()
+		end := m.config.clock()
 		_ = stats.RecordWithTags(ctx,
 			m.fieldTagger(fieldTags(fc)),
 			ServerFieldCount.M(1),
@@ -88,18 +90,25 @@ func (m Collector) InterceptField(ctx context.Context, next graphql.Resolver) (r
 // InterceptResponse implements the gqlgen response interceptor
 func (m Collector) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
 	rc := graphql.GetOperationContext(ctx)
-	opName := operationName(rc)
+	opName := m.config.opLabel(rc)
 
+	// parsing and validation are timed by gqlgen, whereas the execution is timed with the clock of the collector
+	start := m.config.clock()
 	resp := next(ctx)
-	end := graphql.Now()
+	end := m.config.clock()
 
 	_ = stats.RecordWithTags(ctx,
 		m.opTagger(opName),
 		ServerRequestCount.M(1),
 		ServerParsing.M(float64(rc.Stats.Validation.End.Sub(rc.Stats.Parsing.Start))/float64(time.Millisecond)),
-		ServerLatency.M(float64(end.Sub(rc.Stats.Validation.End))/float64(time.Millisecond)),
+		ServerLatency.M(float64(end.Sub(start))/float64(time.Millisecond)),
 	)
 
+	if gqlcancel.ByClient(ctx) {
+		// requests cancelled by the client are counted apart from errors
+		_ = stats.RecordWithTags(ctx, m.opTagger(opName), ServerCancelledCount.M(1))
+		return resp
+	}
 	if resp == nil {
 		return nil
 	}
@@ -109,20 +118,6 @@ func (m Collector) InterceptResponse(ctx context.Context, next graphql.ResponseH
 	return resp
 }
 
-func operationName(ctx *graphql.OperationContext) (opName string) {
-	if ctx.Operation != nil {
-		opName = ctx.Operation.Name
-	}
-	if opName == "" && ctx.Operation != nil {
-		//parent response case
-		opName = string(ctx.Operation.Operation)
-	}
-	if opName == "" {
-		opName = ctx.OperationName
-	}
-	return
-}
-
 func fieldTags(ctx *graphql.FieldContext) (string, string) {
 	pth := ctx.Path().String()
 	if strings.HasPrefix(pth, "__schema") {
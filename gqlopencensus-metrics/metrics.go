@@ -6,18 +6,20 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
 )
 
 // Register views.
 //
 // Views must be registered before using the extension.
 func Register() error {
-	return view.Register(GQLViews...)
+	return gqlmetrics.Register(GQLViews...)
 }
 
 // Unregister views
 func Unregister() {
-	view.Unregister(GQLViews...)
+	gqlmetrics.Unregister(GQLViews...)
 }
 
 var (
@@ -26,6 +28,7 @@ var (
 		OperationCountView,
 		FieldCountView,
 		OperationErrorsView,
+		OperationCancelledView,
 		OperationLatencyView,
 		FieldLatencyView,
 		OperationParsingView,
@@ -47,8 +50,14 @@ var (
 
 	// ServerErrorCount tracks a count of request errors
 	ServerErrorCount = stats.Int64(
-		"gql/server/request_count",
-		"Number of GraphQL requests started",
+		"gql/server/error_count",
+		"Number of GraphQL requests returning an error",
+		stats.UnitDimensionless)
+
+	// ServerCancelledCount tracks a count of requests cancelled by the client before completion
+	ServerCancelledCount = stats.Int64(
+		"gql/server/cancelled_count",
+		"Number of GraphQL requests cancelled by the client",
 		stats.UnitDimensionless)
 
 	// ServerLatency tracks the execution time of requests (excluding parsing and validation time), in milliseconds
@@ -98,6 +107,17 @@ var (
 		TagKeys:     []tag.Key{TagHost, TagOperation},
 	}
 
+	// OperationCancelledView reports a count of requests cancelled by the client, tagged by host and operation name.
+	//
+	// These requests are not counted as errors.
+	OperationCancelledView = &view.View{
+		Name:        "gql/server/cancelled_count",
+		Description: "Count of GraphQL requests cancelled by the client by operation",
+		Measure:     ServerCancelledCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagHost, TagOperation},
+	}
+
 	// OperationLatencyView reports a distribution of execution time of GraphQL operations, by host and operation (in milliseconds)
 	OperationLatencyView = &view.View{
 		Name:        "gql/server/latency",
@@ -7,8 +7,13 @@ import (
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
 )
 
 func TestMetrics(t *testing.T) {
@@ -46,6 +51,89 @@ func TestMetrics(t *testing.T) {
 	time.Sleep(11 * time.Second)
 }
 
+func TestWithClock(t *testing.T) {
+	require.NoError(t, Register())
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ext := New(Host("clocked"), WithClock(func() time.Time {
+		now = now.Add(7 * time.Millisecond)
+		return now
+	}))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "clocked"})
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fctx := graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Field:    graphql.CollectedField{Field: &ast.Field{Name: "users", Alias: "users"}},
+			IsMethod: true,
+		})
+		_, _ = ext.InterceptField(fctx, func(context.Context) (interface{}, error) { return nil, nil })
+		return &graphql.Response{}
+	})
+
+	// the operation reads the clock before and after the field
+	assertDistribution(t, OperationLatencyView.Name, 21)
+	assertDistribution(t, FieldLatencyView.Name, 7)
+}
+
+func TestCancelled(t *testing.T) {
+	require.NoError(t, Register())
+
+	for _, host := range []string{"client", "server"} {
+		ext := New(Host(host))
+
+		var cancel context.CancelFunc
+		ctx := context.Background()
+		if host == "client" {
+			ctx, cancel = context.WithCancel(ctx)
+		} else {
+			ctx, cancel = gqlcancel.WithCancel(ctx)
+		}
+		ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{OperationName: "cancelled"})
+		ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			cancel()
+			return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("context canceled")}}
+		})
+	}
+
+	assert.Equal(t, int64(1), countFor(t, OperationCancelledView.Name, "client"))
+	assert.Equal(t, int64(0), countFor(t, OperationErrorsView.Name, "client"))
+	assert.Equal(t, int64(0), countFor(t, OperationCancelledView.Name, "server"), "operations cancelled by the server are errors")
+	assert.Equal(t, int64(1), countFor(t, OperationErrorsView.Name, "server"))
+}
+
+func countFor(t *testing.T, name, host string) int64 {
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+	for _, row := range rows {
+		for _, tg := range row.Tags {
+			if tg.Key == TagHost && tg.Value == host {
+				data, ok := row.Data.(*view.CountData)
+				require.True(t, ok)
+				return data.Value
+			}
+		}
+	}
+	return 0
+}
+
+func assertDistribution(t *testing.T, name string, expected float64) {
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+	for _, row := range rows {
+		for _, tg := range row.Tags {
+			if tg.Key == TagHost && tg.Value == "clocked" {
+				data, ok := row.Data.(*view.DistributionData)
+				require.True(t, ok)
+				assert.Equal(t, int64(1), data.Count)
+				assert.Equal(t, expected, data.Min)
+				assert.Equal(t, expected, data.Max)
+				return
+			}
+		}
+	}
+	t.Fatalf("no measurement for %s", name)
+}
+
 type testExporter struct{ t testing.TB }
 
 func (x testExporter) ExportView(viewData *view.Data) {
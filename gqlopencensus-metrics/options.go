@@ -2,6 +2,11 @@ package metrics
 
 import (
 	"os"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
 )
 
 type (
@@ -11,6 +16,8 @@ type (
 	config struct {
 		host          string
 		fieldsEnabled bool
+		clock         func() time.Time
+		opLabel       gqllabel.OperationLabeler
 	}
 )
 
@@ -20,6 +27,8 @@ func defaultCollector() *Collector {
 		config: &config{
 			host:          host,
 			fieldsEnabled: true,
+			clock:         graphql.Now,
+			opLabel:       gqllabel.OperationName,
 		},
 	}
 }
@@ -37,3 +46,21 @@ func FieldsEnabled(enabled bool) Option {
 		c.fieldsEnabled = enabled
 	}
 }
+
+// WithClock sets the clock used to measure latencies. By default, this is graphql.Now
+//
+// This is useful to produce deterministic measurements in tests or replay tooling.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
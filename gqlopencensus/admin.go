@@ -0,0 +1,52 @@
+package gqlopencensus
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// AdminHandler serves the runtime settings of a tracer, as JSON.
+//
+// GET yields the current settings. POST and PUT update the settings: fields omitted from the payload
+// are left unchanged.
+//
+// Every request must be accepted by the authorize hook, or the handler responds with 403 Forbidden.
+// A nil authorize hook rejects all requests.
+//
+// Example:
+//
+//   curl -X POST -d '{"rawQuery": true, "samplingRate": 0.5, "slowQueryThreshold": "2s"}' http://localhost:8080/admin/tracing
+func AdminHandler(tr *Tracer, authorize func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize == nil || !authorize(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			payload, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// the payload is merged into the current settings, with no other update in between
+			err = tr.UpdateSettingsFunc(func(s *Settings) error {
+				return json.Unmarshal(payload, s)
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(tr.Settings())
+	})
+}
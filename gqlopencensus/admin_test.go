@@ -0,0 +1,75 @@
+package gqlopencensus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler(t *testing.T) {
+	tr := New(WithArgs())
+	allow := func(_ *http.Request) bool { return true }
+
+	t.Run("forbidden", func(t *testing.T) {
+		resp := doAdminRequest(AdminHandler(tr, nil), http.MethodGet, "")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("get settings", func(t *testing.T) {
+		resp := doAdminRequest(AdminHandler(tr, allow), http.MethodGet, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var s Settings
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &s))
+		assert.Equal(t, Settings{Args: true}, s)
+	})
+
+	t.Run("update settings", func(t *testing.T) {
+		resp := doAdminRequest(AdminHandler(tr, allow), http.MethodPost, `{"rawQuery": true, "samplingRate": 0.5}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		s := tr.Settings()
+		assert.True(t, s.RawQuery)
+		assert.True(t, s.Args)
+		require.NotNil(t, s.SamplingRate)
+		assert.Equal(t, 0.5, *s.SamplingRate)
+	})
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for _, payload := range []string{`{"variables": true}`, `{"slowQueryThreshold": "2s"}`} {
+			wg.Add(1)
+			go func(payload string) {
+				defer wg.Done()
+				resp := doAdminRequest(AdminHandler(tr, allow), http.MethodPost, payload)
+				assert.Equal(t, http.StatusOK, resp.Code)
+			}(payload)
+		}
+		wg.Wait()
+
+		s := tr.Settings()
+		assert.True(t, s.Variables)
+		assert.Equal(t, Duration(2*time.Second), s.SlowQueryThreshold)
+		assert.True(t, s.RawQuery)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		resp := doAdminRequest(AdminHandler(tr, allow), http.MethodPut, `{"samplingRate": 2}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, 0.5, *tr.Settings().SamplingRate)
+	})
+}
+
+func doAdminRequest(handler http.Handler, method string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,89 @@
+package gqlopencensus
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+type aggregatorKey struct{}
+
+type (
+	// fieldAggregator collects latency summaries of the fields resolved by an operation, in place of field spans
+	fieldAggregator struct {
+		clock     func() time.Time
+		mx        sync.Mutex
+		summaries map[string]*fieldSummary
+	}
+
+	fieldSummary struct {
+		count int64
+		total time.Duration
+		max   time.Duration
+	}
+)
+
+func withFieldAggregator(ctx context.Context, clock func() time.Time) (context.Context, *fieldAggregator) {
+	agg := &fieldAggregator{clock: clock, summaries: make(map[string]*fieldSummary)}
+	return context.WithValue(ctx, aggregatorKey{}, agg), agg
+}
+
+func fieldAggregatorFromContext(ctx context.Context) *fieldAggregator {
+	agg, _ := ctx.Value(aggregatorKey{}).(*fieldAggregator)
+	return agg
+}
+
+// aggregateField resolves a field and accounts for its latency, without producing a span
+func (a *fieldAggregator) aggregateField(ctx context.Context, fc *graphql.FieldContext, next graphql.Resolver) (interface{}, error) {
+	start := a.clock()
+	defer func() {
+		a.add(fc.Object+"."+fc.Field.Name, a.clock().Sub(start))
+	}()
+
+	return next(ctx)
+}
+
+func (a *fieldAggregator) add(field string, elapsed time.Duration) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	summary, ok := a.summaries[field]
+	if !ok {
+		summary = new(fieldSummary)
+		a.summaries[field] = summary
+	}
+	summary.count++
+	summary.total += elapsed
+	if elapsed > summary.max {
+		summary.max = elapsed
+	}
+}
+
+// attributes yields the summaries as span attributes, e.g. "field.Query.users.count",
+// "field.Query.users.total_ms" and "field.Query.users.max_ms"
+func (a *fieldAggregator) attributes() []gqlattr.KeyValue {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	fields := make([]string, 0, len(a.summaries))
+	for field := range a.summaries {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	attrs := make([]gqlattr.KeyValue, 0, 3*len(fields))
+	for _, field := range fields {
+		summary := a.summaries[field]
+		attrs = append(attrs,
+			gqlattr.KeyValue{Key: "field." + field + ".count", Value: summary.count},
+			gqlattr.KeyValue{Key: "field." + field + ".total_ms", Value: float64(summary.total) / float64(time.Millisecond)},
+			gqlattr.KeyValue{Key: "field." + field + ".max_ms", Value: float64(summary.max) / float64(time.Millisecond)},
+		)
+	}
+	return attrs
+}
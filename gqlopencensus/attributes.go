@@ -0,0 +1,47 @@
+package gqlopencensus
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+)
+
+// ContextAttributes yields span attributes describing the GraphQL operation and field being resolved in the context:
+// "graphql.operation", "graphql.field" and "graphql.path".
+//
+// This is useful to correlate the spans of downstream calls (e.g. database or cache) made by resolvers,
+// with the GraphQL query. Attributes are omitted when the context does not carry a GraphQL operation or field.
+//
+// Attributes are sanitized with the default pipeline of gqlattr.
+func ContextAttributes(ctx context.Context) []trace.Attribute {
+	return ocattr.Attributes(gqlattr.Default(), ContextKeyValues(ctx))
+}
+
+// ContextKeyValues yields the same attributes as ContextAttributes, before they are sanitized.
+//
+// This is useful to tracers appending their own attributes before sanitizing them all at once.
+func ContextKeyValues(ctx context.Context) []gqlattr.KeyValue {
+	kvs := make([]gqlattr.KeyValue, 0, 3)
+	if oc := operationContext(ctx); oc != nil {
+		kvs = append(kvs, gqlattr.KeyValue{Key: "graphql.operation", Value: operationName(oc)})
+	}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		kvs = append(kvs,
+			gqlattr.KeyValue{Key: "graphql.field", Value: fc.Field.Name},
+			gqlattr.KeyValue{Key: "graphql.path", Value: fc.Path().String()},
+		)
+	}
+	return kvs
+}
+
+// operationContext yields the operation context, or nil when the context does not carry any
+func operationContext(ctx context.Context) *graphql.OperationContext {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	return graphql.GetOperationContext(ctx)
+}
@@ -0,0 +1,66 @@
+package gqlopencensus
+
+import (
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+// Builder accumulates options to build a Tracer.
+//
+// A Builder is immutable: With returns a new Builder, so a common base of options may safely be shared
+// and extended from several goroutines.
+//
+// The Tracer produced by Build is immutable as well, except for its runtime Settings,
+// which may be updated atomically with UpdateSettings.
+type Builder struct {
+	opts []Option
+}
+
+// NewBuilder prepares a Tracer with some options
+func NewBuilder(opts ...Option) Builder {
+	return Builder{}.With(opts...)
+}
+
+// With yields a new Builder with some extra options
+func (b Builder) With(opts ...Option) Builder {
+	all := make([]Option, 0, len(b.opts)+len(opts))
+	all = append(all, b.opts...)
+	all = append(all, opts...)
+
+	return Builder{opts: all}
+}
+
+// Build a Tracer from the accumulated options
+func (b Builder) Build() *Tracer {
+	tr := defaultTracer()
+	for _, apply := range b.opts {
+		apply(&tr.config)
+	}
+	tr.config = tr.config.freeze()
+	if tr.config.idGenerator != nil {
+		trace.ApplyConfig(trace.Config{IDGenerator: tr.config.idGenerator})
+	}
+
+	tr.dynamic = new(dynamicSettings)
+	tr.dynamic.store(tr.config.settings)
+
+	return tr
+}
+
+// freeze yields a copy of the configuration which does not share any backing array with the options:
+// appending to it afterwards always reallocates.
+func (c config) freeze() config {
+	c.fieldAttributers = append(make([]FieldAttributer, 0, len(c.fieldAttributers)), c.fieldAttributers...)
+	c.fieldKeyValuers = append(make([]FieldKeyValuer, 0, len(c.fieldKeyValuers)), c.fieldKeyValuers...)
+	c.operationAttributers = append(make([]OperationAttributer, 0, len(c.operationAttributers)), c.operationAttributers...)
+	c.operationKeyValuers = append(make([]OperationKeyValuer, 0, len(c.operationKeyValuers)), c.operationKeyValuers...)
+	if c.sanitizers != nil {
+		c.sanitizers = append(make(gqlattr.Pipeline, 0, len(c.sanitizers)), c.sanitizers...)
+	}
+	if c.settings.SamplingRate != nil {
+		rate := *c.settings.SamplingRate
+		c.settings.SamplingRate = &rate
+	}
+	return c
+}
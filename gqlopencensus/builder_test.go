@@ -0,0 +1,41 @@
+package gqlopencensus
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	base := NewBuilder(WithFieldAttributes(FieldAttribute("host", "mypod")))
+
+	const n = 10
+	tracers := make([]*Tracer, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := base
+			if i%2 == 0 {
+				b = b.With(WithFieldAttributes(FieldAttribute("extra", "value")), WithRawQuery())
+			}
+			tracers[i] = b.Build()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, tr := range tracers {
+		require.NotNil(t, tr)
+		if i%2 == 0 {
+			assert.Len(t, tr.fieldAttributers, 2)
+			assert.True(t, tr.Settings().RawQuery)
+			continue
+		}
+		assert.Len(t, tr.fieldAttributers, 1)
+		assert.False(t, tr.Settings().RawQuery)
+	}
+	assert.Len(t, base.opts, 1)
+}
@@ -0,0 +1,73 @@
+package gqlopencensus
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
+)
+
+type spanRecorder struct {
+	mx    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mx.Lock()
+	r.spans = append(r.spans, s)
+	r.mx.Unlock()
+}
+
+func (r *spanRecorder) recorded() []*trace.SpanData {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return append([]*trace.SpanData(nil), r.spans...)
+}
+
+func TestCancelledByClient(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	tracer := New(WithSamplingRate(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{OperationName: "todos"})
+	tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		cancel()
+		return nil
+	})
+
+	require.Len(t, recorder.spans, 1)
+	span := recorder.spans[0]
+	assert.Equal(t, int32(trace.StatusCodeCancelled), span.Status.Code)
+	assert.Equal(t, true, span.Attributes[AttributeCancelledByClient])
+}
+
+func TestCancelledByServer(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	tracer := New(WithSamplingRate(1))
+
+	// e.g. a straggler cancelled by gqldrain
+	ctx, cancel := gqlcancel.WithCancel(context.Background())
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{OperationName: "todos"})
+	tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		cancel()
+		return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("the server is shutting down")}}
+	})
+
+	require.Len(t, recorder.spans, 1)
+	span := recorder.spans[0]
+	assert.Equal(t, int32(trace.StatusCodeUnknown), span.Status.Code)
+	assert.NotContains(t, span.Attributes, AttributeCancelledByClient)
+}
@@ -0,0 +1,116 @@
+package gqlopencensus
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+)
+
+// tickingClock advances by a fixed step on every reading
+func tickingClock(step time.Duration) func() time.Time {
+	var mx sync.Mutex
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		mx.Lock()
+		defer mx.Unlock()
+		now = now.Add(step)
+		return now
+	}
+}
+
+type sequentialIDs struct {
+	mx   sync.Mutex
+	next uint64
+}
+
+func (g *sequentialIDs) id() uint64 {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.next++
+	return g.next
+}
+
+func (g *sequentialIDs) NewTraceID() (id [16]byte) {
+	binary.BigEndian.PutUint64(id[8:], g.id())
+	return id
+}
+
+func (g *sequentialIDs) NewSpanID() (id [8]byte) {
+	binary.BigEndian.PutUint64(id[:], g.id())
+	return id
+}
+
+func TestWithClock(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	tracer := New(WithSamplingRate(1), WithFieldAggregation(true), WithClock(tickingClock(5*time.Millisecond)))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "users"})
+	tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fctx := graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object:   "Query",
+			Field:    graphql.CollectedField{Field: &ast.Field{Name: "users", Alias: "users"}},
+			IsMethod: true,
+		})
+		_, _ = tracer.InterceptField(fctx, func(context.Context) (interface{}, error) { return nil, nil })
+		return &graphql.Response{}
+	})
+
+	spans := recorder.recorded()
+	require.Len(t, spans, 1)
+	assert.Equal(t, int64(1), spans[0].Attributes["field.Query.users.count"])
+	assert.Equal(t, float64(5), spans[0].Attributes["field.Query.users.total_ms"])
+	assert.Equal(t, float64(5), spans[0].Attributes["field.Query.users.max_ms"])
+}
+
+func TestLoaderWithClock(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	ctx, root := trace.StartSpan(context.Background(), "field", trace.WithSampler(trace.AlwaysSample()))
+	defer root.End()
+
+	// readings: wait start, fetch start, fetch end, wait end
+	loader := NewLoaderWithClock("clocked", tickingClock(10*time.Millisecond))
+	require.NoError(t, loader.Wait(ctx, func(ctx context.Context) error {
+		return loader.Fetch(ctx, func(context.Context) error { return nil })
+	}))
+
+	var found bool
+	for _, span := range recorder.recorded() {
+		if span.Name == "clocked.wait" {
+			found = true
+			assert.Equal(t, int64(10), span.Attributes[AttributeLoaderFetchMs])
+			assert.Equal(t, int64(20), span.Attributes[AttributeLoaderWaitMs])
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	ids := &sequentialIDs{next: 1 << 32}
+	tracer := New(WithSamplingRate(1), WithIDGenerator(ids))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "users"})
+	tracer.InterceptResponse(ctx, func(context.Context) *graphql.Response { return &graphql.Response{} })
+
+	spans := recorder.recorded()
+	require.Len(t, spans, 1)
+	assert.Equal(t, uint64(1<<32+1), binary.BigEndian.Uint64(spans[0].TraceID[8:]))
+	assert.Equal(t, uint64(1<<32+2), binary.BigEndian.Uint64(spans[0].SpanID[:]))
+}
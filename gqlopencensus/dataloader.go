@@ -0,0 +1,251 @@
+package gqlopencensus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+)
+
+// StartBatchSpan starts a span for a batched fetch, such as the one performed by a dataloader,
+// with a link to the span found in each of the contributing contexts.
+//
+// This makes the fan-in from many field spans to a single fetch visible in traces.
+//
+// The batch span is a child of the span in ctx, if any.
+//
+// Example:
+//
+//   ctx, span := StartBatchSpan(ctx, "users.batch", fieldContexts...)
+//   defer span.End()
+func StartBatchSpan(ctx context.Context, name string, contributors ...context.Context) (context.Context, *trace.Span) {
+	links := make([]trace.Link, 0, len(contributors))
+	for _, contributor := range contributors {
+		if link, ok := contributorLink(contributor); ok {
+			links = append(links, link)
+		}
+	}
+	return startBatchSpan(ctx, name, links)
+}
+
+// BatchLinks collects links to the field spans contributing keys to a batched fetch.
+//
+// This is useful with dataloaders whose fetch function does not receive the contexts
+// of the loading fields: call Add when a key is loaded, then StartSpan from the fetch function.
+//
+// A BatchLinks is safe for concurrent use.
+type BatchLinks struct {
+	mx    sync.Mutex
+	links []trace.Link
+}
+
+// Add a link to the span in the context of a field contributing to the batch.
+// Contexts without a span are ignored.
+func (b *BatchLinks) Add(ctx context.Context) {
+	link, ok := contributorLink(ctx)
+	if !ok {
+		return
+	}
+	b.mx.Lock()
+	b.links = append(b.links, link)
+	b.mx.Unlock()
+}
+
+// StartSpan starts a span for the batched fetch, with links to all the spans collected so far.
+//
+// Collected links are reset, so the same BatchLinks may be reused for the next batch.
+func (b *BatchLinks) StartSpan(ctx context.Context, name string) (context.Context, *trace.Span) {
+	b.mx.Lock()
+	links := b.links
+	b.links = nil
+	b.mx.Unlock()
+
+	return startBatchSpan(ctx, name, links)
+}
+
+func contributorLink(ctx context.Context) (trace.Link, bool) {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return trace.Link{}, false
+	}
+	sc := span.SpanContext()
+	return trace.Link{
+		TraceID: sc.TraceID,
+		SpanID:  sc.SpanID,
+		Type:    trace.LinkTypeParent,
+	}, true
+}
+
+func startBatchSpan(ctx context.Context, name string, links []trace.Link) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	if !span.IsRecordingEvents() {
+		return ctx, span
+	}
+
+	span.AddAttributes(ocattr.Attributes(gqlattr.Default(), []gqlattr.KeyValue{
+		serverAttribute,
+		{Key: "batch.links", Value: int64(len(links))},
+	})...)
+	for _, link := range links {
+		span.AddLink(link)
+	}
+	return ctx, span
+}
+
+// Attributes set on the span of each dataloader wait.
+const (
+	AttributeLoaderWaitMs    = "dataloader.wait_ms"
+	AttributeLoaderFetchMs   = "dataloader.fetch_ms"
+	AttributeLoaderBatchSize = "dataloader.batch_size"
+)
+
+// Loader measures the time fields spend blocked on a dataloader, separating the time spent
+// waiting for the batch to be dispatched (wait_ms) from the time spent by the batched fetch (fetch_ms).
+//
+// Each call to Wait produces a "<name>.wait" span, and each call to Fetch a "<name>.fetch" span linked
+// to the waits it serves. A high wait_ms relative to fetch_ms hints at a batching window that is too long,
+// whereas many small batches hint at one that is too short.
+//
+// Waits are attributed to the batch pending when they start, which matches how dataloaders
+// such as dataloaden accumulate keys. Waits served from the loader cache report a zero fetch_ms.
+//
+// A Loader is safe for concurrent use.
+//
+// Example:
+//
+//   usersLoader := gqlopencensus.NewLoader("users")
+//
+//   // in the fetch function of the dataloader
+//   err := usersLoader.Fetch(ctx, func(ctx context.Context) error {
+//     users, errs = fetchUsers(ctx, keys)
+//     return nil
+//   })
+//
+//   // in the resolver
+//   err := usersLoader.Wait(ctx, func(ctx context.Context) (err error) {
+//     user, err = loaders.Users.Load(id)
+//     return err
+//   })
+type Loader struct {
+	name  string
+	clock func() time.Time
+
+	mx      sync.Mutex
+	pending *loaderBatch
+}
+
+type loaderBatch struct {
+	mx         sync.Mutex
+	links      []trace.Link
+	size       int
+	fetchStart time.Time
+	fetchEnd   time.Time
+}
+
+// NewLoader builds a Loader for the dataloader with this name.
+func NewLoader(name string) *Loader {
+	return NewLoaderWithClock(name, time.Now)
+}
+
+// NewLoaderWithClock builds a Loader like NewLoader, measuring durations with a specific clock,
+// e.g. to produce deterministic attributes in tests.
+func NewLoaderWithClock(name string, clock func() time.Time) *Loader {
+	return &Loader{name: name, clock: clock}
+}
+
+// Wait runs load, which is expected to block on the dataloader, in a wait span.
+func (l *Loader) Wait(ctx context.Context, load func(context.Context) error) error {
+	ctx, span := trace.StartSpan(ctx, l.name+".wait")
+	defer span.End()
+
+	batch := l.join(ctx)
+	start := l.clock()
+	err := load(ctx)
+	total := l.clock().Sub(start)
+
+	fetch := batch.fetchDuration()
+	if fetch > total {
+		fetch = total
+	}
+
+	if span.IsRecordingEvents() {
+		span.AddAttributes(ocattr.Attributes(gqlattr.Default(), []gqlattr.KeyValue{
+			{Key: AttributeLoaderWaitMs, Value: int64((total - fetch) / time.Millisecond)},
+			{Key: AttributeLoaderFetchMs, Value: int64(fetch / time.Millisecond)},
+			{Key: AttributeLoaderBatchSize, Value: int64(batch.batchSize())},
+		})...)
+	}
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	return err
+}
+
+// Fetch runs the batched fetch in a fetch span, linked to the waits of the pending batch.
+//
+// Waits starting after Fetch is called are attributed to the next batch.
+func (l *Loader) Fetch(ctx context.Context, fetch func(context.Context) error) error {
+	l.mx.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.mx.Unlock()
+
+	if batch == nil {
+		batch = &loaderBatch{}
+	}
+
+	batch.mx.Lock()
+	links := batch.links
+	batch.fetchStart = l.clock()
+	batch.mx.Unlock()
+
+	ctx, span := startBatchSpan(ctx, l.name+".fetch", links)
+	defer span.End()
+
+	err := fetch(ctx)
+
+	batch.mx.Lock()
+	batch.fetchEnd = l.clock()
+	batch.mx.Unlock()
+
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	return err
+}
+
+func (l *Loader) join(ctx context.Context) *loaderBatch {
+	l.mx.Lock()
+	if l.pending == nil {
+		l.pending = &loaderBatch{}
+	}
+	batch := l.pending
+	l.mx.Unlock()
+
+	batch.mx.Lock()
+	batch.size++
+	if link, ok := contributorLink(ctx); ok {
+		batch.links = append(batch.links, link)
+	}
+	batch.mx.Unlock()
+	return batch
+}
+
+func (b *loaderBatch) fetchDuration() time.Duration {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.fetchStart.IsZero() || b.fetchEnd.IsZero() {
+		return 0
+	}
+	return b.fetchEnd.Sub(b.fetchStart)
+}
+
+func (b *loaderBatch) batchSize() int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.size
+}
@@ -0,0 +1,183 @@
+package gqlopencensus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+// parentSpans starts n sampled spans, standing for the fields contributing to a batch
+func parentSpans(n int) ([]context.Context, []trace.SpanContext, func()) {
+	ctxs := make([]context.Context, 0, n)
+	scs := make([]trace.SpanContext, 0, n)
+	spans := make([]*trace.Span, 0, n)
+	for i := 0; i < n; i++ {
+		ctx, span := trace.StartSpan(context.Background(), "field", trace.WithSampler(trace.AlwaysSample()))
+		ctxs = append(ctxs, ctx)
+		scs = append(scs, span.SpanContext())
+		spans = append(spans, span)
+	}
+	return ctxs, scs, func() {
+		for _, span := range spans {
+			span.End()
+		}
+	}
+}
+
+// assertBatchLinks checks that a batch span links to each parent span, in order
+func assertBatchLinks(t *testing.T, batch *trace.SpanData, parents []trace.SpanContext) {
+	require.Len(t, batch.Links, len(parents))
+	assert.Equal(t, int64(len(parents)), batch.Attributes["batch.links"])
+	for i, link := range batch.Links {
+		assert.Equal(t, parents[i].TraceID, link.TraceID)
+		assert.Equal(t, parents[i].SpanID, link.SpanID)
+		assert.Equal(t, trace.LinkTypeParent, link.Type)
+	}
+}
+
+// recordedSpan yields the last recorded span with this name
+func recordedSpan(t *testing.T, recorder *spanRecorder, name string) *trace.SpanData {
+	spans := recorder.recorded()
+	for i := len(spans) - 1; i >= 0; i-- {
+		if spans[i].Name == name {
+			return spans[i]
+		}
+	}
+	t.Fatalf("no span %q", name)
+	return nil
+}
+
+func TestStartBatchSpan(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	for _, n := range []int{0, 1, 5} {
+		contributors, parents, end := parentSpans(n)
+
+		ctx, root := trace.StartSpan(context.Background(), "fetch", trace.WithSampler(trace.AlwaysSample()))
+		// a context without span does not contribute any link
+		_, span := StartBatchSpan(ctx, "users.batch", append(contributors, context.Background())...)
+		span.End()
+		root.End()
+		end()
+
+		batch := recordedSpan(t, recorder, "users.batch")
+		assertBatchLinks(t, batch, parents)
+		assert.Equal(t, root.SpanContext().SpanID, batch.ParentSpanID, "the batch span is a child of the span in ctx")
+		assert.Equal(t, trace.SpanKindClient, batch.SpanKind)
+	}
+}
+
+func TestBatchLinks(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	contributors, parents, end := parentSpans(5)
+	defer end()
+
+	var links BatchLinks
+	var wg sync.WaitGroup
+	for _, ctx := range contributors {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			links.Add(ctx)
+		}(ctx)
+	}
+	wg.Wait()
+	links.Add(context.Background())
+
+	ctx, root := trace.StartSpan(context.Background(), "fetch", trace.WithSampler(trace.AlwaysSample()))
+	defer root.End()
+	_, span := links.StartSpan(ctx, "users.batch")
+	span.End()
+
+	// links are added concurrently, in any order
+	batch := recordedSpan(t, recorder, "users.batch")
+	require.Len(t, batch.Links, len(parents))
+	assert.Equal(t, int64(5), batch.Attributes["batch.links"])
+	for _, parent := range parents {
+		assert.Contains(t, batch.Links, trace.Link{TraceID: parent.TraceID, SpanID: parent.SpanID, Type: trace.LinkTypeParent})
+	}
+
+	t.Run("links are reset for the next batch", func(t *testing.T) {
+		links.Add(contributors[0])
+		_, span := links.StartSpan(ctx, "users.next")
+		span.End()
+
+		assertBatchLinks(t, recordedSpan(t, recorder, "users.next"), parents[:1])
+	})
+}
+
+func TestLoader(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	ctx, root := trace.StartSpan(context.Background(), "field", trace.WithSampler(trace.AlwaysSample()))
+	defer root.End()
+
+	loader := NewLoader("users")
+	released := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = loader.Wait(ctx, func(context.Context) error {
+				<-released
+				return nil
+			})
+		}()
+	}
+
+	// let both waits join the pending batch before it is dispatched
+	require.Eventually(t, func() bool {
+		loader.mx.Lock()
+		defer loader.mx.Unlock()
+		return loader.pending != nil && loader.pending.batchSize() == 2
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, loader.Fetch(ctx, func(context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}))
+	close(released)
+	wg.Wait()
+
+	var waits int
+	for _, span := range recorder.recorded() {
+		switch span.Name {
+		case "users.fetch":
+			assert.Len(t, span.Links, 2)
+			assert.Equal(t, int64(2), span.Attributes["batch.links"])
+		case "users.wait":
+			waits++
+			assert.Equal(t, int64(2), span.Attributes[AttributeLoaderBatchSize])
+			assert.GreaterOrEqual(t, span.Attributes[AttributeLoaderFetchMs], int64(20))
+			assert.GreaterOrEqual(t, span.Attributes[AttributeLoaderWaitMs], int64(20))
+		}
+	}
+	assert.Equal(t, 2, waits)
+
+	t.Run("cached load", func(t *testing.T) {
+		cached := NewLoader("cached")
+		require.NoError(t, cached.Wait(ctx, func(context.Context) error { return nil }))
+
+		for _, span := range recorder.recorded() {
+			if span.Name == "cached.wait" {
+				assert.Equal(t, int64(0), span.Attributes[AttributeLoaderFetchMs])
+				assert.Equal(t, int64(1), span.Attributes[AttributeLoaderBatchSize])
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package gqlopencensus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+// DefaultTraceHeader is the default response header carrying the trace ID
+const DefaultTraceHeader = "X-Trace-Id"
+
+type traceHolderKey struct{}
+
+// traceHolder captures the span context of the operation, for the middleware to write response headers
+type traceHolder struct {
+	mx  sync.Mutex
+	sc  trace.SpanContext
+	set bool
+}
+
+func (h *traceHolder) capture(sc trace.SpanContext) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if !h.set {
+		h.sc = sc
+		h.set = true
+	}
+}
+
+func (h *traceHolder) spanContext() (trace.SpanContext, bool) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return h.sc, h.set
+}
+
+func captureSpan(ctx context.Context, span *trace.Span) {
+	if h, ok := ctx.Value(traceHolderKey{}).(*traceHolder); ok {
+		h.capture(span.SpanContext())
+	}
+}
+
+// Middleware writes the ID of the trace of the GraphQL operation into the response headers,
+// so that frontend teams may look up the trace of a slow request.
+//
+// The trace ID is written in the "X-Trace-Id" header by default (see WithTraceHeader),
+// and optionally as a traceparent in the Server-Timing header (see WithServerTimingTraceparent).
+// Headers are only written for sampled traces.
+//
+// The middleware must wrap the gqlgen handler using this tracer:
+//
+//   tracer := gqlopencensus.New()
+//   srv.Use(tracer)
+//   http.Handle("/query", tracer.Middleware(srv))
+func (tr *Tracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := new(traceHolder)
+		ctx := context.WithValue(r.Context(), traceHolderKey{}, h)
+
+		next.ServeHTTP(httpwriter.New(w, func(status int) int {
+			writeTraceHeaders(w.Header(), h, &tr.config)
+			return status
+		}), r.WithContext(ctx))
+	})
+}
+
+// writeTraceHeaders writes the trace headers of the operation, if sampled
+func writeTraceHeaders(header http.Header, h *traceHolder, cfg *config) {
+	sc, ok := h.spanContext()
+	if !ok || !sc.IsSampled() {
+		return
+	}
+
+	if cfg.traceHeader != "" {
+		header.Set(cfg.traceHeader, sc.TraceID.String())
+	}
+	if cfg.serverTimingTraceparent {
+		header.Add("Server-Timing", fmt.Sprintf(`traceparent;desc="%s"`, traceparent(sc)))
+	}
+}
+
+// traceparent formats a span context as a W3C traceparent
+func traceparent(sc trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID.String(), sc.SpanID.String(), uint32(sc.TraceOptions))
+}
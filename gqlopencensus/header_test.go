@@ -0,0 +1,61 @@
+package gqlopencensus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	serve := func(tracer *Tracer) *httptest.ResponseRecorder {
+		handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{OperationName: "todos"})
+			tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+				return &graphql.Response{}
+			})
+			_, _ = w.Write([]byte(`{}`))
+			w.(http.Flusher).Flush()
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", nil))
+		return rec
+	}
+
+	t.Run("sampled", func(t *testing.T) {
+		recorder.spans = nil
+		rec := serve(New(WithSamplingRate(1), WithServerTimingTraceparent(true)))
+
+		spans := recorder.recorded()
+		require.Len(t, spans, 1)
+		sc := spans[0].SpanContext
+		assert.Equal(t, sc.TraceID.String(), rec.Header().Get(DefaultTraceHeader))
+		assert.Equal(t, `traceparent;desc="`+traceparent(sc)+`"`, rec.Header().Get("Server-Timing"))
+		assert.True(t, rec.Flushed)
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		rec := serve(New(WithSamplingRate(1), WithTraceHeader("X-Request-Trace")))
+
+		assert.NotEmpty(t, rec.Header().Get("X-Request-Trace"))
+		assert.Empty(t, rec.Header().Get(DefaultTraceHeader))
+		assert.Empty(t, rec.Header().Get("Server-Timing"))
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		rec := serve(New(WithSamplingRate(0), WithServerTimingTraceparent(true)))
+
+		assert.Empty(t, rec.Header().Get(DefaultTraceHeader))
+		assert.Empty(t, rec.Header().Get("Server-Timing"))
+	})
+}
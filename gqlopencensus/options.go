@@ -2,15 +2,24 @@ package gqlopencensus
 
 import (
 	"encoding/json"
+	"time"
+	"unicode/utf8"
 
 	"github.com/99designs/gqlgen/graphql"
 	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+	"github.com/99designs/gqlgen-contrib/gqlpii"
 )
 
 // Option for an opencensus tracer. At this moment, it is possible to configure span attributes retrieved from the GraphQL contexts.
 type Option func(*config)
 
-// FieldAttributer is a functor producing trace attributes from the GraphL field context
+// FieldAttributer is a functor producing trace attributes from the GraphL field context.
+//
+// Attributes produced by a FieldAttributer are added as is, after the sanitized attributes of the span
+// (see FieldKeyValuer to sanitize custom attributes).
 type FieldAttributer func(*graphql.FieldContext) []trace.Attribute
 
 // FieldAttribute is a simple FieldAttributer that just adds a constant key/value attribute to the span.
@@ -26,7 +35,14 @@ func FieldAttribute(key, value string) FieldAttributer {
 	}
 }
 
+// FieldKeyValuer is a functor producing trace attributes from the GraphL field context as key/values,
+// sanitized together with the default attributes of the span.
+type FieldKeyValuer func(*graphql.FieldContext) []gqlattr.KeyValue
+
 // OperationAttributer is a functor producing trace attributes from the GraphL operation context.
+//
+// Attributes produced by an OperationAttributer are added as is, after the sanitized attributes of the span
+// (see OperationKeyValuer to sanitize custom attributes).
 type OperationAttributer func(*graphql.OperationContext) []trace.Attribute
 
 // OperationAttribute is a simple OperationAttributer that just adds a constant key/value attribute to the span.
@@ -42,46 +58,131 @@ func OperationAttribute(key, value string) OperationAttributer {
 	}
 }
 
+// OperationKeyValuer is a functor producing trace attributes from the GraphL operation context as key/values,
+// sanitized together with the default attributes of the span.
+type OperationKeyValuer func(*graphql.OperationContext) []gqlattr.KeyValue
+
 type config struct {
 	fieldAttributers     []FieldAttributer
+	fieldKeyValuers      []FieldKeyValuer
 	operationAttributers []OperationAttributer
+	operationKeyValuers  []OperationKeyValuer
 	onlyMethods          bool
+	rawQueryLimit        int
+	settings             Settings
+	aggregateFields      bool
+	profilerLabels       bool
+	runtimeDeltas        bool
+	memStatsRate         float64
+	pii                  *gqlpii.Engine
+	sanitizers           gqlattr.Pipeline
+
+	clock       func() time.Time
+	idGenerator IDGenerator
+
+	traceHeader             string
+	serverTimingTraceparent bool
 }
 
-func (c config) fieldAttributes(ctx *graphql.FieldContext) []trace.Attribute {
-	attrs := make([]trace.Attribute, 0, 10)
+// IDGenerator generates the IDs of traces and spans, e.g. to produce deterministic spans in tests
+// or replay tooling. It has the methods of the ID generators of OpenCensus.
+type IDGenerator interface {
+	NewTraceID() [16]byte
+	NewSpanID() [8]byte
+}
+
+// serverAttribute is the constant attribute set on all spans
+var serverAttribute = gqlattr.KeyValue{Key: "server", Value: "gqlgen"}
+
+// sanitize attributes with the configured sanitizers, or with the default pipeline of gqlattr
+func (c config) sanitize(kvs []gqlattr.KeyValue) []trace.Attribute {
+	if c.sanitizers != nil {
+		return ocattr.Attributes(c.sanitizers, kvs)
+	}
+	return ocattr.Attributes(gqlattr.Default(), kvs)
+}
+
+// fieldAttributes yields the default attributes of a field and the ones of custom key/valuers, all sanitized,
+// followed by the ones of custom attributers
+func (c config) fieldAttributes(ctx *graphql.FieldContext, s *settings) []trace.Attribute {
+	// default attributes are set inline rather than with a FieldAttributer: this spares
+	// a closure call and an extra allocation on every resolved field
+	kvs := make([]gqlattr.KeyValue, 2, 3)
+	kvs[0] = serverAttribute
+	kvs[1] = gqlattr.KeyValue{Key: "field", Value: ctx.Field.Name}
+	if s.Args {
+		var args []byte
+		if c.pii != nil {
+			args, _ = json.Marshal(c.pii.MaskArgs(ctx))
+		} else {
+			args, _ = json.Marshal(ctx.Args)
+		}
+		kvs = append(kvs, gqlattr.KeyValue{Key: "args", Value: string(args)})
+	}
+
+	for _, apply := range c.fieldKeyValuers {
+		kvs = append(kvs, apply(ctx)...)
+	}
+	attrs := c.sanitize(kvs)
 	for _, apply := range c.fieldAttributers {
 		attrs = append(attrs, apply(ctx)...)
 	}
 	return attrs
 }
 
-func (c config) operationAttributes(ctx *graphql.OperationContext) []trace.Attribute {
-	attrs := make([]trace.Attribute, 0, 10)
+// operationAttributes yields the default attributes of an operation and the ones of custom key/valuers,
+// all sanitized, followed by the ones of custom attributers
+func (c config) operationAttributes(ctx *graphql.OperationContext, s *settings) []trace.Attribute {
+	kvs := make([]gqlattr.KeyValue, 2, 5)
+	kvs[0] = serverAttribute
+	kvs[1] = gqlattr.KeyValue{Key: "operation", Value: operationName(ctx)}
+	if s.RawQuery {
+		kvs = append(kvs, c.rawQueryAttributes(ctx.RawQuery)...)
+	}
+	if s.Variables {
+		var variables []byte
+		if c.pii != nil {
+			variables, _ = json.Marshal(c.pii.MaskVariables(ctx))
+		} else {
+			variables, _ = json.Marshal(ctx.Variables)
+		}
+		kvs = append(kvs, gqlattr.KeyValue{Key: "variables", Value: string(variables)})
+	}
+
+	for _, apply := range c.operationKeyValuers {
+		kvs = append(kvs, apply(ctx)...)
+	}
+	attrs := c.sanitize(kvs)
 	for _, apply := range c.operationAttributers {
 		attrs = append(attrs, apply(ctx)...)
 	}
 	return attrs
 }
 
+func (c config) rawQueryAttributes(query string) []gqlattr.KeyValue {
+	if c.rawQueryLimit <= 0 || len(query) <= c.rawQueryLimit {
+		return []gqlattr.KeyValue{
+			{Key: "query", Value: query},
+		}
+	}
+
+	limit := c.rawQueryLimit
+	for limit > 0 && !utf8.RuneStart(query[limit]) {
+		// do not split a multi-byte character
+		limit--
+	}
+	return []gqlattr.KeyValue{
+		{Key: "query", Value: query[:limit]},
+		{Key: "query.truncated", Value: true},
+	}
+}
+
 func defaultTracer() *Tracer {
 	return &Tracer{
 		config: config{
-			fieldAttributers: []FieldAttributer{func(fc *graphql.FieldContext) []trace.Attribute {
-				return []trace.Attribute{
-					trace.StringAttribute("server", "gqlgen"),
-					trace.StringAttribute("field", fc.Field.Name),
-				}
-			},
-			},
-			operationAttributers: []OperationAttributer{func(oc *graphql.OperationContext) []trace.Attribute {
-				return []trace.Attribute{
-					trace.StringAttribute("server", "gqlgen"),
-					trace.StringAttribute("operation", operationName(oc)),
-				}
-			},
-			},
 			onlyMethods: true,
+			traceHeader: DefaultTraceHeader,
+			clock:       time.Now,
 		},
 	}
 }
@@ -93,6 +194,14 @@ func WithFieldAttributes(attributers ...FieldAttributer) Option {
 	}
 }
 
+// WithFieldKeyValues adds some extra attributes from the graphQL field context to the span,
+// sanitized like the default attributes (see WithSanitizers)
+func WithFieldKeyValues(keyValuers ...FieldKeyValuer) Option {
+	return func(c *config) {
+		c.fieldKeyValuers = append(c.fieldKeyValuers, keyValuers...)
+	}
+}
+
 // WithOperationAttributes adds some extra attributes from the graphQL operation context to the span
 func WithOperationAttributes(attributers ...OperationAttributer) Option {
 	return func(c *config) {
@@ -100,50 +209,80 @@ func WithOperationAttributes(attributers ...OperationAttributer) Option {
 	}
 }
 
+// WithOperationKeyValues adds some extra attributes from the graphQL operation context to the span,
+// sanitized like the default attributes (see WithSanitizers)
+func WithOperationKeyValues(keyValuers ...OperationKeyValuer) Option {
+	return func(c *config) {
+		c.operationKeyValuers = append(c.operationKeyValuers, keyValuers...)
+	}
+}
+
 // WithDataDog provides DataDog specific span attrs.
 // see github.com/DataDog/opencensus-go-exporter-datadog
 func WithDataDog() Option {
 	return func(c *config) {
-		c.operationAttributers = append(c.operationAttributers, func(oc *graphql.OperationContext) []trace.Attribute {
-			return []trace.Attribute{
-				trace.StringAttribute("resource.name", operationName(oc)),
+		c.operationKeyValuers = append(c.operationKeyValuers, func(oc *graphql.OperationContext) []gqlattr.KeyValue {
+			return []gqlattr.KeyValue{
+				{Key: "resource.name", Value: operationName(oc)},
 			}
 		})
 	}
 }
 
 // WithRawQuery adds the GraphL query to the trace span of an operation. This is disabled by default.
+//
+// The query is only added to sampled spans. Its length may be capped with WithRawQueryLimit.
+// This setting may be changed at runtime with UpdateSettings.
 func WithRawQuery() Option {
 	return func(c *config) {
-		c.operationAttributers = append(c.operationAttributers, func(oc *graphql.OperationContext) []trace.Attribute {
-			return []trace.Attribute{
-				trace.StringAttribute("query", oc.RawQuery),
-			}
-		})
+		c.settings.RawQuery = true
+	}
+}
+
+// WithRawQueryLimit caps the length (in bytes) of the GraphQL query added to the trace span of an operation by WithRawQuery.
+//
+// Truncated queries are flagged with the extra attribute "query.truncated=true". By default, there is no limit.
+func WithRawQueryLimit(limit int) Option {
+	return func(c *config) {
+		c.rawQueryLimit = limit
 	}
 }
 
 // WithVariables adds the values of all variables attached to the GraphL query to the trace span of an operation. This is disabled by default.
+//
+// This setting may be changed at runtime with UpdateSettings.
 func WithVariables() Option {
 	return func(c *config) {
-		c.operationAttributers = append(c.operationAttributers, func(oc *graphql.OperationContext) []trace.Attribute {
-			variables, _ := json.Marshal(oc.Variables)
-			return []trace.Attribute{
-				trace.StringAttribute("variables", string(variables)),
-			}
-		})
+		c.settings.Variables = true
 	}
 }
 
 // WithArgs adds the GraphL args of a field to the trace span of an field. This is disabled by default.
+//
+// This setting may be changed at runtime with UpdateSettings.
 func WithArgs() Option {
 	return func(c *config) {
-		c.fieldAttributers = append(c.fieldAttributers, func(fc *graphql.FieldContext) []trace.Attribute {
-			args, _ := json.Marshal(fc.Args)
-			return []trace.Attribute{
-				trace.StringAttribute("args", string(args)),
-			}
-		})
+		c.settings.Args = true
+	}
+}
+
+// WithSamplingRate sets the probability for the spans of this tracer to be sampled.
+// By default, the global opencensus sampler applies.
+//
+// This setting may be changed at runtime with UpdateSettings.
+func WithSamplingRate(rate float64) Option {
+	return func(c *config) {
+		c.settings.SamplingRate = &rate
+	}
+}
+
+// WithSlowQueryThreshold flags the sampled spans of operations lasting at least the threshold, with the attribute
+// "slow_query=true". This is disabled by default.
+//
+// This setting may be changed at runtime with UpdateSettings.
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(c *config) {
+		c.settings.SlowQueryThreshold = Duration(threshold)
 	}
 }
 
@@ -155,6 +294,109 @@ func OnlyMethods(enabled bool) Option {
 	}
 }
 
+// WithFieldAggregation replaces field spans by latency summaries per field, attached to the span of the operation.
+// This is disabled by default.
+//
+// Large queries may produce thousands of tiny field spans: aggregating them locally drastically reduces the volume
+// sent to exporters. Each resolved field, identified by its object and name, yields the attributes
+// "field.<Object>.<field>.count", "field.<Object>.<field>.total_ms" and "field.<Object>.<field>.max_ms".
+//
+// Summaries are only collected for sampled operations. Field attributes set with WithFieldAttributes or WithArgs
+// do not apply to aggregated fields.
+func WithFieldAggregation(enabled bool) Option {
+	return func(c *config) {
+		c.aggregateFields = enabled
+	}
+}
+
+// WithProfilerLabels sets runtime/pprof labels around the execution of operations and resolvers,
+// so that CPU profiles may be sliced by GraphQL operation and field path, with pprof or continuous profilers.
+//
+// Labels are "graphql.operation" and "graphql.path", e.g. "todos.user.name": list indices are omitted.
+// Labels are set regardless of sampling: this adds an allocation per resolved field.
+func WithProfilerLabels(enabled bool) Option {
+	return func(c *config) {
+		c.profilerLabels = enabled
+	}
+}
+
+// WithRuntimeDeltas adds to the span of an operation the change in the number of goroutines during its execution,
+// as the attribute "runtime.goroutines.delta". This is disabled by default.
+//
+// A fraction memStatsRate of operations also gets the allocations made during its execution, as the attributes
+// "runtime.alloc_bytes.delta" and "runtime.mallocs.delta". Reading memory statistics briefly stops the world:
+// keep this rate low on busy servers.
+//
+// Runtime counters are process-wide: deltas include the activity of concurrent operations, and are only approximate.
+// They help spot operations leaking goroutines or allocating excessively. Deltas are only added to sampled spans.
+func WithRuntimeDeltas(enabled bool, memStatsRate float64) Option {
+	return func(c *config) {
+		c.runtimeDeltas = enabled
+		c.memStatsRate = memStatsRate
+	}
+}
+
+// WithPII masks the personally identifiable information tagged in the schema in the args and variables
+// added to spans (see WithArgs and WithVariables).
+//
+// Notice that literal values inlined in the raw query (see WithRawQuery) are not masked.
+func WithPII(engine *gqlpii.Engine) Option {
+	return func(c *config) {
+		c.pii = engine
+	}
+}
+
+// WithSanitizers sets the sanitizers applied to the attributes of spans, e.g. to mask PII or cap
+// the length of values. This takes precedence over the default pipeline of gqlattr.
+//
+// Attributes produced by custom field and operation key/valuers, e.g. WithDataDog, are sanitized as well.
+// Attributes produced by OpenCensus attributers (see WithFieldAttributes) are not.
+func WithSanitizers(sanitizers ...gqlattr.Sanitizer) Option {
+	return func(c *config) {
+		c.sanitizers = append(gqlattr.Pipeline{}, sanitizers...)
+	}
+}
+
+// WithClock sets the clock used to measure the latencies of aggregated fields (see WithFieldAggregation).
+// By default, this is time.Now.
+//
+// The timestamps of spans are set by OpenCensus, which does not support another clock.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithIDGenerator sets the generator of the IDs of traces and spans.
+//
+// OpenCensus only supports a global ID generator: building the tracer sets it for the whole process,
+// with trace.ApplyConfig.
+func WithIDGenerator(generator IDGenerator) Option {
+	return func(c *config) {
+		c.idGenerator = generator
+	}
+}
+
+// WithTraceHeader sets the name of the response header carrying the trace ID, written by the Middleware of the tracer.
+// The default is "X-Trace-Id". An empty name disables this header.
+func WithTraceHeader(name string) Option {
+	return func(c *config) {
+		c.traceHeader = name
+	}
+}
+
+// WithServerTimingTraceparent adds the trace context to the Server-Timing response header, as a W3C traceparent,
+// written by the Middleware of the tracer. This is disabled by default.
+//
+// Example:
+//
+//   Server-Timing: traceparent;desc="00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func WithServerTimingTraceparent(enabled bool) Option {
+	return func(c *config) {
+		c.serverTimingTraceparent = enabled
+	}
+}
+
 func operationName(ctx *graphql.OperationContext) (opName string) {
 	if ctx.Operation != nil {
 		opName = ctx.Operation.Name
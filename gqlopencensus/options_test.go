@@ -0,0 +1,98 @@
+package gqlopencensus
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+func TestAttributersSanitized(t *testing.T) {
+	tr := New(
+		WithDataDog(),
+		WithOperationAttributes(OperationAttribute("host", "mypod")),
+		WithFieldAttributes(FieldAttribute("host", "mypod")),
+		WithOperationKeyValues(func(*graphql.OperationContext) []gqlattr.KeyValue {
+			return []gqlattr.KeyValue{{Key: "host", Value: "mypod"}, {Key: "zone", Value: "eu"}}
+		}),
+		WithFieldKeyValues(func(*graphql.FieldContext) []gqlattr.KeyValue {
+			return []gqlattr.KeyValue{{Key: "host", Value: "mypod"}}
+		}),
+		WithSanitizers(
+			gqlattr.Rename(map[string]string{"resource.name": "dd.resource"}),
+			gqlattr.Drop("host"),
+		),
+	)
+
+	oc := &graphql.OperationContext{OperationName: "todos"}
+	assert.Equal(t, []trace.Attribute{
+		trace.StringAttribute("server", "gqlgen"),
+		trace.StringAttribute("operation", "todos"),
+		trace.StringAttribute("dd.resource", "todos"),
+		trace.StringAttribute("zone", "eu"),
+		trace.StringAttribute("host", "mypod"),
+	}, tr.config.operationAttributes(oc, newSettings(Settings{})), "custom operation key/values are sanitized, opencensus attributes are added as is")
+
+	fc := &graphql.FieldContext{Field: graphql.CollectedField{Field: &ast.Field{Name: "todos"}}}
+	assert.Equal(t, []trace.Attribute{
+		trace.StringAttribute("server", "gqlgen"),
+		trace.StringAttribute("field", "todos"),
+		trace.StringAttribute("host", "mypod"),
+	}, tr.config.fieldAttributes(fc, newSettings(Settings{})), "custom field key/values are sanitized, opencensus attributes are added as is")
+}
+
+func TestRawQueryAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		query     string
+		limit     int
+		expected  string
+		truncated bool
+	}{
+		{name: "no limit", query: "{ users }", expected: "{ users }"},
+		{name: "under the limit", query: "{ users }", limit: 16, expected: "{ users }"},
+		{name: "at the limit", query: "{ users }", limit: 9, expected: "{ users }"},
+		{name: "over the limit", query: "{ users }", limit: 4, expected: "{ us", truncated: true},
+		{name: "2-byte rune straddling the limit", query: "{ café }", limit: 6, expected: "{ caf", truncated: true},
+		{name: "2-byte rune before the limit", query: "{ café }", limit: 7, expected: "{ café", truncated: true},
+		{name: "3-byte rune straddling the limit", query: "ab日本cd", limit: 4, expected: "ab", truncated: true},
+		{name: "4-byte rune straddling the limit", query: "ab😀cd", limit: 5, expected: "ab", truncated: true},
+		{name: "4-byte rune before the limit", query: "ab😀cd", limit: 6, expected: "ab😀", truncated: true},
+		{name: "rune straddling a limit of one byte", query: "日本", limit: 1, expected: "", truncated: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kvs := config{rawQueryLimit: tc.limit}.rawQueryAttributes(tc.query)
+
+			expected := []gqlattr.KeyValue{{Key: "query", Value: tc.expected}}
+			if tc.truncated {
+				expected = append(expected, gqlattr.KeyValue{Key: "query.truncated", Value: true})
+			}
+			assert.Equal(t, expected, kvs)
+			assert.True(t, utf8.ValidString(kvs[0].Value.(string)), "the query is cut at a rune boundary")
+		})
+	}
+}
+
+func TestRawQueryLimit(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	tr := New(WithSamplingRate(1), WithRawQuery(), WithRawQueryLimit(6))
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{RawQuery: "{ café }"})
+	tr.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+
+	spans := recorder.recorded()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "{ caf", spans[0].Attributes["query"])
+	assert.Equal(t, true, spans[0].Attributes["query.truncated"])
+}
@@ -0,0 +1,37 @@
+package gqlopencensus
+
+import (
+	"runtime/pprof"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Profiler labels set with WithProfilerLabels
+const (
+	LabelOperation = "graphql.operation"
+	LabelPath      = "graphql.path"
+)
+
+func operationLabels(oc *graphql.OperationContext) pprof.LabelSet {
+	return pprof.Labels(LabelOperation, operationName(oc))
+}
+
+func fieldLabels(fc *graphql.FieldContext) pprof.LabelSet {
+	return pprof.Labels(LabelPath, fieldPath(fc))
+}
+
+// fieldPath yields the path of a field without list indices, e.g. "todos.user.name",
+// so that profiles aggregate all the elements of a list.
+func fieldPath(fc *graphql.FieldContext) string {
+	var names []string
+	for it := fc; it != nil; it = it.Parent {
+		if it.Index == nil && it.Field.Field != nil {
+			names = append(names, it.Field.Name)
+		}
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, ".")
+}
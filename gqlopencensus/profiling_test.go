@@ -0,0 +1,35 @@
+package gqlopencensus
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/internal/fieldtest"
+)
+
+func TestProfilerLabels(t *testing.T) {
+	tracer := New(WithProfilerLabels(true))
+
+	todos := fieldtest.Field(nil, "Query", "todos")
+	todos.Field.Alias = "list"
+	user := fieldtest.Field(nil, "Todo", "user")
+	user.IsMethod = true
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "todos"})
+	tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		operation, _ := pprof.Label(ctx, LabelOperation)
+		assert.Equal(t, "todos", operation)
+
+		fctx := fieldtest.Context(ctx, todos, fieldtest.Element(1), user)
+		_, _ = tracer.InterceptField(fctx, func(ctx context.Context) (interface{}, error) {
+			path, _ := pprof.Label(ctx, LabelPath)
+			assert.Equal(t, "todos.user", path)
+			return nil, nil
+		})
+		return &graphql.Response{}
+	})
+}
@@ -0,0 +1,56 @@
+package gqlopencensus
+
+import (
+	"math/rand"
+	"runtime"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+// Attributes set by WithRuntimeDeltas
+const (
+	AttributeGoroutinesDelta = "runtime.goroutines.delta"
+	AttributeAllocBytesDelta = "runtime.alloc_bytes.delta"
+	AttributeMallocsDelta    = "runtime.mallocs.delta"
+)
+
+// runtimeSnapshot captures process-wide runtime counters at the start of an operation
+type runtimeSnapshot struct {
+	goroutines int
+	memStats   bool
+	allocBytes uint64
+	mallocs    uint64
+}
+
+// takeRuntimeSnapshot captures runtime counters. Memory statistics are only read for a fraction of operations,
+// since runtime.ReadMemStats briefly stops the world.
+func takeRuntimeSnapshot(memStatsRate float64) runtimeSnapshot {
+	snap := runtimeSnapshot{
+		goroutines: runtime.NumGoroutine(),
+	}
+	if memStatsRate > 0 && rand.Float64() < memStatsRate {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		snap.memStats = true
+		snap.allocBytes = m.TotalAlloc
+		snap.mallocs = m.Mallocs
+	}
+	return snap
+}
+
+// attributes yields the deltas of runtime counters since the snapshot was taken
+func (snap runtimeSnapshot) attributes() []gqlattr.KeyValue {
+	attrs := []gqlattr.KeyValue{
+		{Key: AttributeGoroutinesDelta, Value: int64(runtime.NumGoroutine() - snap.goroutines)},
+	}
+	if !snap.memStats {
+		return attrs
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return append(attrs,
+		gqlattr.KeyValue{Key: AttributeAllocBytesDelta, Value: int64(m.TotalAlloc - snap.allocBytes)},
+		gqlattr.KeyValue{Key: AttributeMallocsDelta, Value: int64(m.Mallocs - snap.mallocs)},
+	)
+}
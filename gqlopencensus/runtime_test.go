@@ -0,0 +1,33 @@
+package gqlopencensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeDeltas(t *testing.T) {
+	snap := takeRuntimeSnapshot(1)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		<-done
+	}()
+	buf := make([][]byte, 0, 16)
+	for i := 0; i < 16; i++ {
+		buf = append(buf, make([]byte, 1024))
+	}
+
+	attrs := snap.attributes()
+	require.Len(t, attrs, 3)
+	assert.Equal(t, AttributeGoroutinesDelta, attrs[0].Key)
+	assert.True(t, attrs[0].Value.(int64) >= 1)
+	assert.Equal(t, AttributeAllocBytesDelta, attrs[1].Key)
+	assert.True(t, attrs[1].Value.(int64) >= 16*1024)
+	assert.Equal(t, AttributeMallocsDelta, attrs[2].Key)
+	assert.Len(t, buf, 16)
+
+	assert.Len(t, takeRuntimeSnapshot(0).attributes(), 1)
+}
@@ -0,0 +1,162 @@
+package gqlopencensus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// Settings are the options of a Tracer which may be changed at runtime, with UpdateSettings.
+type Settings struct {
+	// RawQuery adds the GraphQL query to operation spans (see WithRawQuery)
+	RawQuery bool `json:"rawQuery"`
+
+	// Variables adds the GraphQL variables to operation spans (see WithVariables)
+	Variables bool `json:"variables"`
+
+	// Args adds the GraphQL args to field spans (see WithArgs)
+	Args bool `json:"args"`
+
+	// SamplingRate is the probability for spans to be sampled (see WithSamplingRate).
+	// When nil, the global opencensus sampler applies.
+	SamplingRate *float64 `json:"samplingRate"`
+
+	// SlowQueryThreshold flags the sampled spans of operations lasting at least this long (see WithSlowQueryThreshold).
+	// When zero, operations are not flagged.
+	SlowQueryThreshold Duration `json:"slowQueryThreshold"`
+}
+
+// Duration is a time.Duration, represented in JSON as a string such as "500ms"
+type Duration time.Duration
+
+// MarshalJSON represents the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON parses a duration from a string such as "500ms"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("duration must be a string such as \"500ms\": %v", err)
+	}
+	parsed, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Validate settings
+func (s Settings) Validate() error {
+	if s.SamplingRate != nil && (*s.SamplingRate < 0 || *s.SamplingRate > 1) {
+		return fmt.Errorf("sampling rate must be in [0, 1], got %v", *s.SamplingRate)
+	}
+	if s.SlowQueryThreshold < 0 {
+		return fmt.Errorf("slow query threshold must not be negative, got %v", time.Duration(s.SlowQueryThreshold))
+	}
+	return nil
+}
+
+// settings are the runtime settings, with a precomputed sampler
+type settings struct {
+	Settings
+	startOptions []trace.StartOption
+}
+
+func newSettings(s Settings) *settings {
+	startOptions := []trace.StartOption{trace.WithSpanKind(trace.SpanKindServer)}
+	if s.SamplingRate != nil {
+		rate := *s.SamplingRate
+		s.SamplingRate = &rate
+		startOptions = append(startOptions, trace.WithSampler(trace.ProbabilitySampler(rate)))
+	}
+	return &settings{
+		Settings:     s,
+		startOptions: startOptions,
+	}
+}
+
+// dynamicSettings hold the runtime settings of a tracer, which may be hot-swapped atomically.
+//
+// Settings are loaded without locking. Updates are serialized, so that concurrent updates of some settings
+// do not revert each other.
+type dynamicSettings struct {
+	v  atomic.Value
+	mx sync.Mutex
+}
+
+// zeroSettings apply to a Tracer not built with New
+var zeroSettings = newSettings(Settings{})
+
+func (d *dynamicSettings) load() *settings {
+	if d == nil {
+		return zeroSettings
+	}
+	return d.v.Load().(*settings)
+}
+
+func (d *dynamicSettings) store(s Settings) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.v.Store(newSettings(s))
+}
+
+// update loads, modifies and stores the settings, as one atomic step
+func (d *dynamicSettings) update(modify func(*Settings) error) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	s := copySettings(d.load().Settings)
+	if err := modify(&s); err != nil {
+		return err
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	d.v.Store(newSettings(s))
+	return nil
+}
+
+// copySettings yields settings which do not share the sampling rate
+func copySettings(s Settings) Settings {
+	if s.SamplingRate != nil {
+		rate := *s.SamplingRate
+		s.SamplingRate = &rate
+	}
+	return s
+}
+
+// Settings yields the current runtime settings of the tracer
+func (tr Tracer) Settings() Settings {
+	return copySettings(tr.dynamic.load().Settings)
+}
+
+// UpdateSettings atomically replaces the runtime settings of the tracer.
+//
+// It is safe to call UpdateSettings while the tracer is serving requests.
+func (tr Tracer) UpdateSettings(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if tr.dynamic == nil {
+		return errors.New("runtime settings require a tracer built with New")
+	}
+	tr.dynamic.store(s)
+	return nil
+}
+
+// UpdateSettingsFunc atomically modifies the runtime settings of the tracer: concurrent updates are serialized,
+// so that modify applies to the latest settings. The settings are left unchanged when modify fails.
+func (tr Tracer) UpdateSettingsFunc(modify func(*Settings) error) error {
+	if tr.dynamic == nil {
+		return errors.New("runtime settings require a tracer built with New")
+	}
+	return tr.dynamic.update(modify)
+}
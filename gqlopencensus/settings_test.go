@@ -0,0 +1,95 @@
+package gqlopencensus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+func TestSlowQueryThreshold(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	// the operation lasts one tick of the clock
+	tracer := New(WithSamplingRate(1), WithSlowQueryThreshold(5*time.Millisecond), WithClock(tickingClock(5*time.Millisecond)))
+	operate := func() {
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "users"})
+		tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			return &graphql.Response{}
+		})
+	}
+
+	operate()
+	require.NoError(t, tracer.UpdateSettingsFunc(func(s *Settings) error {
+		s.SlowQueryThreshold = Duration(10 * time.Millisecond)
+		return nil
+	}))
+	operate()
+
+	spans := recorder.recorded()
+	require.Len(t, spans, 2)
+	assert.Equal(t, true, spans[0].Attributes[AttributeSlowQuery])
+	assert.NotContains(t, spans[1].Attributes, AttributeSlowQuery)
+}
+
+func TestSettingsJSON(t *testing.T) {
+	var s Settings
+	require.NoError(t, json.Unmarshal([]byte(`{"slowQueryThreshold": "1.5s"}`), &s))
+	assert.Equal(t, Duration(1500*time.Millisecond), s.SlowQueryThreshold)
+
+	encoded, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"slowQueryThreshold":"1.5s"`)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"slowQueryThreshold": 1500}`), &s))
+	assert.Error(t, json.Unmarshal([]byte(`{"slowQueryThreshold": "soon"}`), &s))
+	assert.Error(t, Settings{SlowQueryThreshold: Duration(-time.Second)}.Validate())
+}
+
+func TestUpdateSettingsFunc(t *testing.T) {
+	tracer := New()
+
+	// concurrent updates of different settings are all kept
+	var wg sync.WaitGroup
+	updates := []func(*Settings){
+		func(s *Settings) { s.RawQuery = true },
+		func(s *Settings) { s.Variables = true },
+		func(s *Settings) { s.Args = true },
+		func(s *Settings) { s.SlowQueryThreshold = Duration(time.Second) },
+	}
+	for _, update := range updates {
+		wg.Add(1)
+		go func(update func(*Settings)) {
+			defer wg.Done()
+			_ = tracer.UpdateSettingsFunc(func(s *Settings) error {
+				update(s)
+				return nil
+			})
+		}(update)
+	}
+	wg.Wait()
+	assert.Equal(t, Settings{RawQuery: true, Variables: true, Args: true, SlowQueryThreshold: Duration(time.Second)}, tracer.Settings())
+
+	t.Run("invalid update", func(t *testing.T) {
+		err := tracer.UpdateSettingsFunc(func(s *Settings) error {
+			rate := 2.0
+			s.SamplingRate = &rate
+			s.Args = false
+			return nil
+		})
+		assert.Error(t, err)
+		assert.True(t, tracer.Settings().Args, "settings are left unchanged")
+	})
+
+	t.Run("tracer not built with New", func(t *testing.T) {
+		assert.Error(t, Tracer{}.UpdateSettingsFunc(func(*Settings) error { return nil }))
+	})
+}
@@ -2,14 +2,28 @@ package gqlopencensus
 
 import (
 	"context"
+	"runtime/pprof"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
+)
+
+const (
+	// AttributeCancelledByClient flags the span of an operation cancelled by the client before completion
+	AttributeCancelledByClient = "cancelled_by_client"
+
+	// AttributeSlowQuery flags the span of an operation lasting at least the slow query threshold
+	AttributeSlowQuery = "slow_query"
 )
 
 // Tracer enables opencensus tracing on gqlgen
 type Tracer struct {
 	config
+	dynamic *dynamicSettings
 }
 
 var _ interface {
@@ -19,13 +33,11 @@ var _ interface {
 	graphql.FieldInterceptor
 } = Tracer{}
 
-// New opencensus tracer for gqlgen
+// New opencensus tracer for gqlgen.
+//
+// This is a shorthand for NewBuilder(opts...).Build().
 func New(opts ...Option) *Tracer {
-	tr := defaultTracer()
-	for _, apply := range opts {
-		apply(&tr.config)
-	}
-	return tr
+	return NewBuilder(opts...).Build()
 }
 
 // ExtensionName implements the graphql.HandlerExtension
@@ -45,28 +57,95 @@ func (tr Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (res
 		// only capture fields which correspond to a resolver method
 		return next(ctx)
 	}
-	ctx, span := trace.StartSpan(ctx,
-		fc.Path().String(),
-		trace.WithSpanKind(trace.SpanKindServer),
-	)
-	span.AddAttributes(tr.config.fieldAttributes(fc)...)
+	if tr.profilerLabels {
+		pprof.Do(ctx, fieldLabels(fc), func(ctx context.Context) {
+			res, err = tr.traceField(ctx, fc, next)
+		})
+		return res, err
+	}
+	return tr.traceField(ctx, fc, next)
+}
+
+func (tr Tracer) traceField(ctx context.Context, fc *graphql.FieldContext, next graphql.Resolver) (interface{}, error) {
+	if tr.aggregateFields {
+		if agg := fieldAggregatorFromContext(ctx); agg != nil {
+			return agg.aggregateField(ctx, fc, next)
+		}
+		// the operation is not sampled: fields are not instrumented
+		return next(ctx)
+	}
+	s := tr.dynamic.load()
+	ctx, span := trace.StartSpan(ctx, fc.Field.Name, s.startOptions...)
 	defer span.End()
 
+	if span.IsRecordingEvents() {
+		// the path of the field and attributes are only computed for sampled spans
+		span.SetName(fc.Path().String())
+		span.AddAttributes(tr.config.fieldAttributes(fc, s)...)
+	}
+
 	return next(ctx)
 }
 
 // InterceptResponse implements graphql.OperationInterceptor
-func (tr Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+func (tr Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) (resp *graphql.Response) {
 	oc := graphql.GetOperationContext(ctx)
-	ctx, span := trace.StartSpan(ctx,
-		operationName(oc),
-		trace.WithSpanKind(trace.SpanKindServer),
-	)
+	if tr.profilerLabels {
+		pprof.Do(ctx, operationLabels(oc), func(ctx context.Context) {
+			resp = tr.traceResponse(ctx, oc, next)
+		})
+		return resp
+	}
+	return tr.traceResponse(ctx, oc, next)
+}
+
+func (tr Tracer) traceResponse(ctx context.Context, oc *graphql.OperationContext, next graphql.ResponseHandler) *graphql.Response {
+	s := tr.dynamic.load()
+	ctx, span := trace.StartSpan(ctx, operationName(oc), s.startOptions...)
 	defer span.End()
+	captureSpan(ctx, span)
 
-	span.AddAttributes(tr.config.operationAttributes(oc)...)
+	if span.IsRecordingEvents() {
+		// attributes, including the possibly large raw query, are only computed for sampled spans
+		span.AddAttributes(tr.config.operationAttributes(oc, s)...)
+	}
+
+	if tr.runtimeDeltas && span.IsRecordingEvents() {
+		snap := takeRuntimeSnapshot(tr.memStatsRate)
+		defer func() {
+			span.AddAttributes(tr.config.sanitize(snap.attributes())...)
+		}()
+	}
+
+	if tr.aggregateFields && span.IsRecordingEvents() {
+		var agg *fieldAggregator
+		ctx, agg = withFieldAggregator(ctx, tr.config.clock)
+		defer func() {
+			span.AddAttributes(tr.config.sanitize(agg.attributes())...)
+		}()
+	}
+
+	if threshold := time.Duration(s.SlowQueryThreshold); threshold > 0 && span.IsRecordingEvents() {
+		start := tr.config.clock()
+		defer func() {
+			if tr.config.clock().Sub(start) >= threshold {
+				slow := []gqlattr.KeyValue{{Key: AttributeSlowQuery, Value: true}}
+				span.AddAttributes(tr.config.sanitize(slow)...)
+			}
+		}()
+	}
 
 	resp := next(ctx)
+	if gqlcancel.ByClient(ctx) {
+		// the client went away before completion: this is not a server error
+		cancelled := []gqlattr.KeyValue{{Key: AttributeCancelledByClient, Value: true}}
+		span.AddAttributes(tr.config.sanitize(cancelled)...)
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeCancelled,
+			Message: "cancelled by client",
+		})
+		return resp
+	}
 	if resp == nil {
 		return nil
 	}
@@ -0,0 +1,30 @@
+package gqlopencensus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/internal/fieldtest"
+)
+
+func TestFieldSpanName(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	// the path of fields is only computed for sampled spans
+	tr := New(WithSamplingRate(1))
+	fc := benchField(benchField(nil, "users"), "name")
+	_, err := tr.InterceptField(fieldtest.Nest(context.Background(), fc), func(context.Context) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+
+	spans := recorder.recorded()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "users.name", spans[0].Name)
+}
@@ -0,0 +1,14 @@
+module github.com/99designs/gqlgen-contrib/gqlopentracing
+
+go 1.13
+
+require (
+	github.com/99designs/gqlgen v0.11.3
+	github.com/99designs/gqlgen-contrib v0.1.0
+	github.com/opentracing/opentracing-go v1.1.0
+	github.com/stretchr/testify v1.4.0
+	github.com/vektah/gqlparser/v2 v2.0.1
+)
+
+// for local development, against the sibling root module: releases require the tagged root module
+replace github.com/99designs/gqlgen-contrib => ../
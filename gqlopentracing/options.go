@@ -0,0 +1,50 @@
+package gqlopentracing
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+type (
+	// Option for an opentracing tracer.
+	Option func(*config)
+
+	config struct {
+		clock      func() time.Time
+		sanitizers gqlattr.Pipeline
+	}
+)
+
+// pipeline yields the configured sanitizers, or the default pipeline of gqlattr
+func (c config) pipeline() gqlattr.Pipeline {
+	if c.sanitizers != nil {
+		return c.sanitizers
+	}
+	return gqlattr.Default()
+}
+
+func (c config) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+// WithClock sets the clock used to timestamp the start and finish of spans. By default, this is time.Now.
+//
+// This is useful to produce deterministic spans in tests or replay tooling.
+// Span IDs are assigned by the opentracing tracer implementation and cannot be configured here.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithSanitizers sets the sanitizers applied to the tags and log fields of spans, e.g. to mask PII in error
+// messages. This takes precedence over the default pipeline of gqlattr.
+func WithSanitizers(sanitizers ...gqlattr.Sanitizer) Option {
+	return func(c *config) {
+		c.sanitizers = append(gqlattr.Pipeline{}, sanitizers...)
+	}
+}
@@ -7,9 +7,19 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
 )
 
-type OpenTracingTracer struct{}
+// TagCancelledByClient flags the span of an operation cancelled by the client before completion
+const TagCancelledByClient = "cancelled_by_client"
+
+// OpenTracingTracer enables opentracing on gqlgen.
+//
+// The zero value is ready to use, with default options.
+type OpenTracingTracer struct {
+	config
+}
 
 var _ interface {
 	graphql.HandlerExtension
@@ -17,6 +27,15 @@ var _ interface {
 	graphql.FieldInterceptor
 } = OpenTracingTracer{}
 
+// New opentracing tracer for gqlgen
+func New(opts ...Option) *OpenTracingTracer {
+	tr := &OpenTracingTracer{}
+	for _, apply := range opts {
+		apply(&tr.config)
+	}
+	return tr
+}
+
 func (OpenTracingTracer) ExtensionName() string {
 	return "Opentracing"
 }
@@ -25,17 +44,17 @@ func (OpenTracingTracer) Validate(schema graphql.ExecutableSchema) error {
 	return nil
 }
 
-func (OpenTracingTracer) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+func (tr OpenTracingTracer) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
 	fieldCtx := graphql.GetFieldContext(ctx)
-	span, ctx := opentracing.StartSpanFromContext(ctx, fieldCtx.Path().String())
-	defer span.Finish()
-	ext.SpanKind.Set(span, "server")
-	ext.Component.Set(span, "gqlgen")
+	span, ctx := opentracing.StartSpanFromContext(ctx, fieldCtx.Path().String(), opentracing.StartTime(tr.now()))
+	defer tr.finish(span)
+	tr.setTag(span, string(ext.SpanKind), string(ext.SpanKindRPCServerEnum))
+	tr.setTag(span, string(ext.Component), "gqlgen")
 
 	return next(ctx)
 }
 
-func (OpenTracingTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+func (tr OpenTracingTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
 	opCtx := graphql.GetOperationContext(ctx)
 	opName := ""
 	if opCtx.Operation != nil {
@@ -48,20 +67,42 @@ func (OpenTracingTracer) InterceptResponse(ctx context.Context, next graphql.Res
 	if opName == "" {
 		opName = opCtx.OperationName
 	}
-	span, ctx := opentracing.StartSpanFromContext(ctx, opName)
-	defer span.Finish()
-	ext.SpanKind.Set(span, "server")
-	ext.Component.Set(span, "gqlgen")
+	span, ctx := opentracing.StartSpanFromContext(ctx, opName, opentracing.StartTime(tr.now()))
+	defer tr.finish(span)
+	tr.setTag(span, string(ext.SpanKind), string(ext.SpanKindRPCServerEnum))
+	tr.setTag(span, string(ext.Component), "gqlgen")
 
 	resp := next(ctx)
+	if gqlcancel.ByClient(ctx) {
+		// the client went away before completion: this is not a server error
+		tr.setTag(span, TagCancelledByClient, true)
+		return resp
+	}
 	if resp == nil {
 		return nil
 	}
 
 	if err := resp.Errors.Error(); err != "" {
-		ext.Error.Set(span, true)
-		span.LogFields(log.String("error", err))
+		tr.setTag(span, string(ext.Error), true)
+		if key, value, ok := tr.pipeline().Sanitize("error", err); ok {
+			if str, isString := value.(string); isString {
+				span.LogFields(log.String(key, str))
+			} else {
+				span.LogFields(log.Object(key, value))
+			}
+		}
 	}
 
 	return resp
 }
+
+// setTag sets a tag on a span, once sanitized
+func (tr OpenTracingTracer) setTag(span opentracing.Span, key string, value interface{}) {
+	if key, value, ok := tr.pipeline().Sanitize(key, value); ok {
+		span.SetTag(key, value)
+	}
+}
+
+func (tr OpenTracingTracer) finish(span opentracing.Span) {
+	span.FinishWithOptions(opentracing.FinishOptions{FinishTime: tr.now()})
+}
@@ -0,0 +1,167 @@
+package gqlopentracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
+)
+
+// mockTracer sets a mock tracer as the global tracer, until reset
+func mockTracer() (tracer *mocktracer.MockTracer, reset func()) {
+	tracer = mocktracer.New()
+	previous := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	return tracer, func() { opentracing.SetGlobalTracer(previous) }
+}
+
+func TestInterceptors(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+
+	tr := New()
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Query, Name: "GetTodos"},
+	})
+	resp := tr.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Field: graphql.CollectedField{Field: &ast.Field{Name: "todos", Alias: "todos"}},
+		})
+		_, _ = tr.InterceptField(ctx, func(ctx context.Context) (interface{}, error) {
+			ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+				Field: graphql.CollectedField{Field: &ast.Field{Name: "text", Alias: "text"}},
+			})
+			return tr.InterceptField(ctx, func(context.Context) (interface{}, error) { return "todo", nil })
+		})
+		return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("todo not found")}}
+	})
+	require.NotNil(t, resp)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+
+	text, todos, operation := spans[0], spans[1], spans[2]
+	assert.Equal(t, "todos.text", text.OperationName)
+	assert.Equal(t, "todos", todos.OperationName)
+	assert.Equal(t, "GetTodos", operation.OperationName)
+
+	// field spans are children of the enclosing field, then of the operation
+	assert.Equal(t, todos.SpanContext.SpanID, text.ParentID)
+	assert.Equal(t, operation.SpanContext.SpanID, todos.ParentID)
+	assert.Zero(t, operation.ParentID)
+
+	for _, span := range spans {
+		assert.Equal(t, string(ext.SpanKindRPCServerEnum), span.Tag(string(ext.SpanKind)))
+		assert.Equal(t, "gqlgen", span.Tag(string(ext.Component)))
+	}
+
+	assert.NotContains(t, todos.Tags(), string(ext.Error))
+	assert.Equal(t, true, operation.Tag(string(ext.Error)))
+	logs := operation.Logs()
+	require.Len(t, logs, 1)
+	require.Len(t, logs[0].Fields, 1)
+	assert.Equal(t, "error", logs[0].Fields[0].Key)
+	assert.Equal(t, "input: todo not found\n", logs[0].Fields[0].ValueString)
+
+	t.Run("anonymous operation", func(t *testing.T) {
+		tracer.Reset()
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+			Operation: &ast.OperationDefinition{Operation: ast.Mutation},
+		})
+		assert.Nil(t, tr.InterceptResponse(ctx, func(context.Context) *graphql.Response { return nil }))
+
+		spans := tracer.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "mutation", spans[0].OperationName)
+		assert.NotContains(t, spans[0].Tags(), string(ext.Error))
+	})
+
+	t.Run("sanitizers", func(t *testing.T) {
+		tracer.Reset()
+		tr := New(WithSanitizers(gqlattr.Drop(string(ext.Component)), gqlattr.MaxLength(5)))
+		tr.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+			return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("todo not found")}}
+		})
+
+		spans := tracer.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.NotContains(t, spans[0].Tags(), string(ext.Component))
+		logs := spans[0].Logs()
+		require.Len(t, logs, 1)
+		assert.Equal(t, "input", logs[0].Fields[0].ValueString)
+	})
+}
+
+func TestWithClock(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	tr := New(WithClock(func() time.Time {
+		now = now.Add(5 * time.Millisecond)
+		return now
+	}))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "clocked"})
+	tr.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fctx := graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Field: graphql.CollectedField{Field: &ast.Field{Name: "users", Alias: "users"}},
+		})
+		_, _ = tr.InterceptField(fctx, func(context.Context) (interface{}, error) { return nil, nil })
+		return &graphql.Response{}
+	})
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+
+	field, operation := spans[0], spans[1]
+	assert.Equal(t, "users", field.OperationName)
+	assert.Equal(t, start.Add(10*time.Millisecond), field.StartTime)
+	assert.Equal(t, start.Add(15*time.Millisecond), field.FinishTime)
+
+	assert.Equal(t, "clocked", operation.OperationName)
+	assert.Equal(t, start.Add(5*time.Millisecond), operation.StartTime)
+	assert.Equal(t, start.Add(20*time.Millisecond), operation.FinishTime)
+}
+
+func TestCancelled(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+
+	tr := New()
+	for _, opName := range []string{"client", "server"} {
+		var cancel context.CancelFunc
+		ctx := context.Background()
+		if opName == "client" {
+			ctx, cancel = context.WithCancel(ctx)
+		} else {
+			ctx, cancel = gqlcancel.WithCancel(ctx)
+		}
+		ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{OperationName: opName})
+		tr.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			cancel()
+			return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("context canceled")}}
+		})
+	}
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+
+	client, server := spans[0].Tags(), spans[1].Tags()
+	assert.Equal(t, true, client[TagCancelledByClient])
+	assert.NotContains(t, client, string(ext.Error))
+	assert.NotContains(t, server, TagCancelledByClient)
+	assert.Equal(t, true, server[string(ext.Error)])
+}
@@ -0,0 +1,56 @@
+package gqlorder
+
+type (
+	// Option for the chain
+	Option func(*config)
+
+	config struct {
+		rules    []Rule
+		handlers []func(Violation)
+	}
+)
+
+func defaultConfig() *config {
+	rules := make([]Rule, len(DefaultRules))
+	copy(rules, DefaultRules)
+	return &config{
+		rules: rules,
+	}
+}
+
+// WithRule adds a rule requiring the extension named before to be registered before the extension named after,
+// e.g. for extensions defined by the application
+func WithRule(before, after, reason string) Option {
+	return func(c *config) {
+		c.rules = append(c.rules, Rule{Before: before, After: after, Reason: reason})
+	}
+}
+
+// WithoutDefaultRules removes the DefaultRules. Rules added with WithRule are kept.
+func WithoutDefaultRules() Option {
+	return func(c *config) {
+		kept := c.rules[:0]
+		for _, rule := range c.rules {
+			if !isDefault(rule) {
+				kept = append(kept, rule)
+			}
+		}
+		c.rules = kept
+	}
+}
+
+// WithHandler adds a handler of violations, e.g. to log them
+func WithHandler(handlers ...func(Violation)) Option {
+	return func(c *config) {
+		c.handlers = append(c.handlers, handlers...)
+	}
+}
+
+func isDefault(rule Rule) bool {
+	for _, r := range DefaultRules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+// Package gqlorder diagnoses the order in which gqlgen extensions are registered on a server.
+//
+// Extensions registered first wrap the ones registered after them, and some extensions of this repository
+// depend on others wrapping them: e.g. the bulkhead annotates the trace span of fields, so the tracer must be
+// registered before it. Such mistakes go unnoticed until someone looks for the missing data.
+//
+// A Chain records the extensions registered on a server, and checks them against known ordering rules
+// at startup.
+//
+// Example:
+//
+//   chain := gqlorder.Wrap(srv,
+//     gqlorder.WithRule("Authentication", "Bulkhead", "unauthenticated requests must not consume slots"),
+//     gqlorder.WithHandler(func(v gqlorder.Violation) { log.Println(v) }),
+//   )
+//   chain.Use(gqlopencensus.New())
+//   chain.Use(gqlbulkhead.New(gqlbulkhead.WithLimit("Query.search", 10)))
+//   chain.MustCheck() // panics on violations
+package gqlorder
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Any matches all extensions in rules: e.g. a rule with Before: X, After: Any requires X to be registered first
+const Any = "*"
+
+type (
+	// Server registers extensions, e.g. *handler.Server
+	Server interface {
+		Use(graphql.HandlerExtension)
+	}
+
+	// Rule requires the extension named Before to be registered before the extension named After,
+	// whenever both are registered
+	Rule struct {
+		Before string
+		After  string
+		Reason string
+	}
+
+	// Violation of an ordering rule
+	Violation struct {
+		Rule
+
+		// Extension registered too early
+		Extension string
+
+		// Position of the extension, and of the one it should follow, in registration order
+		Position       int
+		BeforePosition int
+	}
+
+	// Violations found by a check
+	Violations []Violation
+
+	// Chain records the extensions registered on a server, in order
+	Chain struct {
+		*config
+		srv Server
+
+		mx    sync.Mutex
+		names []string
+	}
+)
+
+// DefaultRules between the extensions of this repository
+var DefaultRules = []Rule{
+	{Before: "ExtensionPayloads", After: Any, Reason: "it must intercept the extensions added by all other extensions"},
+	{Before: "OperationBag", After: "ClientIdentification", Reason: "the client is stored in the bag"},
+	{Before: "OperationBag", After: "ApolloUsageReporting", Reason: "the signature of operations is shared in the bag"},
+	{Before: "OperationBag", After: "HiveUsageReporting", Reason: "the signature of operations is shared in the bag"},
+	{Before: "Opencensustracing", After: "Bulkhead", Reason: "the bulkhead annotates the trace span of fields"},
+	{Before: "Opencensustracing", After: "Locale", Reason: "the locale annotates the trace span of the operation"},
+	{Before: "Opencensustracing", After: "UploadInstrumentation", Reason: "uploads annotate the trace span of the operation"},
+	{Before: "Opencensustracing", After: "FeatureFlags", Reason: "feature flags annotate the trace span of fields"},
+	{Before: "Opencensustracing", After: "Saga", Reason: "sagas annotate the trace span of the mutation"},
+	{Before: "Opencensustracing", After: "Pyroscope", Reason: "profiles are linked to the trace span of the operation"},
+	{Before: "Opencensustracing", After: "Experiments", Reason: "experiment buckets annotate the trace span of the operation"},
+	{Before: "TimeBudget", After: "Bulkhead", Reason: "waiting for a bulkhead slot must be bounded by the time budget"},
+}
+
+// Wrap a server, recording the extensions registered with Use
+func Wrap(srv Server, opts ...Option) *Chain {
+	c := &Chain{
+		config: defaultConfig(),
+		srv:    srv,
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// Use registers an extension on the wrapped server, and records it
+func (c *Chain) Use(extension graphql.HandlerExtension) {
+	c.mx.Lock()
+	c.names = append(c.names, extension.ExtensionName())
+	c.mx.Unlock()
+
+	if c.srv != nil {
+		c.srv.Use(extension)
+	}
+}
+
+// Extensions yields the names of the registered extensions, in registration order
+func (c *Chain) Extensions() []string {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	names := make([]string, len(c.names))
+	copy(names, c.names)
+	return names
+}
+
+// Check the registered extensions against the ordering rules. Violations are reported to the handler
+// (see WithHandler), and returned as an error.
+func (c *Chain) Check() error {
+	violations := Check(c.Extensions(), c.config.rules...)
+	for _, v := range violations {
+		for _, handle := range c.config.handlers {
+			handle(v)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// MustCheck checks the registered extensions, and panics on violations
+func (c *Chain) MustCheck() {
+	if err := c.Check(); err != nil {
+		panic(err)
+	}
+}
+
+// Check extension names, in registration order, against ordering rules.
+//
+// Registering the same extension twice is reported as a violation too.
+func Check(names []string, rules ...Rule) Violations {
+	var violations Violations
+
+	positions := make(map[string]int, len(names))
+	for i, name := range names {
+		if first, ok := positions[name]; ok {
+			violations = append(violations, Violation{
+				Rule:           Rule{Before: name, After: name, Reason: "it is registered twice"},
+				Extension:      name,
+				Position:       i,
+				BeforePosition: first,
+			})
+			continue
+		}
+		positions[name] = i
+	}
+
+	for _, rule := range rules {
+		before, ok := positions[rule.Before]
+		if !ok {
+			continue
+		}
+		if rule.After == Any {
+			for i := 0; i < before; i++ {
+				violations = append(violations, violation(rule, names[i], i, before))
+			}
+			continue
+		}
+		if after, ok := positions[rule.After]; ok && after < before {
+			violations = append(violations, violation(rule, rule.After, after, before))
+		}
+	}
+	return violations
+}
+
+func violation(rule Rule, extension string, position, beforePosition int) Violation {
+	return Violation{
+		Rule:           rule,
+		Extension:      extension,
+		Position:       position,
+		BeforePosition: beforePosition,
+	}
+}
+
+func (v Violation) String() string {
+	if v.Before == v.After {
+		return fmt.Sprintf("extension %s (#%d) is registered twice, first as #%d", v.Extension, v.Position+1, v.BeforePosition+1)
+	}
+	return fmt.Sprintf("extension %s (#%d) must be registered after %s (#%d): %s",
+		v.Extension, v.Position+1, v.Before, v.BeforePosition+1, v.Reason)
+}
+
+// Error implements error
+func (v Violations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.String())
+	}
+	return "misordered extensions: " + strings.Join(messages, "; ")
+}
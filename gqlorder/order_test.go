@@ -0,0 +1,67 @@
+package gqlorder
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedExtension string
+
+func (n namedExtension) ExtensionName() string {
+	return string(n)
+}
+
+func (namedExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+type testServer struct {
+	extensions []graphql.HandlerExtension
+}
+
+func (s *testServer) Use(extension graphql.HandlerExtension) {
+	s.extensions = append(s.extensions, extension)
+}
+
+func TestCheck(t *testing.T) {
+	assert.Empty(t, Check([]string{"ExtensionPayloads", "Opencensustracing", "TimeBudget", "Bulkhead"}, DefaultRules...))
+
+	violations := Check([]string{"Bulkhead", "Opencensustracing", "ExtensionPayloads", "Bulkhead"}, DefaultRules...)
+	require.Len(t, violations, 4)
+	assert.Equal(t, "extension Bulkhead (#4) is registered twice, first as #1", violations[0].String())
+	assert.Equal(t, "Bulkhead", violations[1].Extension)
+	assert.Equal(t, "Opencensustracing", violations[2].Extension)
+	assert.Equal(t,
+		"extension Bulkhead (#1) must be registered after Opencensustracing (#2): the bulkhead annotates the trace span of fields",
+		violations[3].String(),
+	)
+}
+
+func TestChain(t *testing.T) {
+	srv := &testServer{}
+	var reported []Violation
+	chain := Wrap(srv,
+		WithoutDefaultRules(),
+		WithRule("Authentication", "Bulkhead", "unauthenticated requests must not consume slots"),
+		WithHandler(func(v Violation) { reported = append(reported, v) }),
+	)
+
+	chain.Use(namedExtension("Bulkhead"))
+	chain.Use(namedExtension("Opencensustracing"))
+	require.NoError(t, chain.Check())
+
+	chain.Use(namedExtension("Authentication"))
+	assert.Len(t, srv.extensions, 3)
+	assert.Equal(t, []string{"Bulkhead", "Opencensustracing", "Authentication"}, chain.Extensions())
+
+	err := chain.Check()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthenticated requests must not consume slots")
+	require.Len(t, reported, 1)
+	assert.Equal(t, "Bulkhead", reported[0].Extension)
+
+	assert.Panics(t, chain.MustCheck)
+}
@@ -0,0 +1,76 @@
+package gqloutbox
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(OutboxViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(OutboxViews...)
+}
+
+var (
+	// OutboxViews contains all opencensus stats views declared by the outbox extension
+	OutboxViews = []*view.View{
+		OutboxPublishedCountView,
+		OutboxRetryCountView,
+		OutboxFailureCountView,
+	}
+
+	// OutboxPublishedCount tracks a count of published events
+	OutboxPublishedCount = stats.Int64(
+		"gql/server/outbox_published_count",
+		"Number of events published after mutations",
+		stats.UnitDimensionless)
+
+	// OutboxRetryCount tracks a count of retried publications
+	OutboxRetryCount = stats.Int64(
+		"gql/server/outbox_retry_count",
+		"Number of retried event publications",
+		stats.UnitDimensionless)
+
+	// OutboxFailureCount tracks a count of events which failed to be published after all attempts
+	OutboxFailureCount = stats.Int64(
+		"gql/server/outbox_failure_count",
+		"Number of events which failed to be published",
+		stats.UnitDimensionless)
+
+	// OutboxPublishedCountView reports the number of published events by operation
+	OutboxPublishedCountView = &view.View{
+		Name:        "gql/server/outbox_published_count",
+		Description: "Number of events published after GraphQL mutations by operation",
+		Measure:     OutboxPublishedCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// OutboxRetryCountView reports a count of retried publications by operation
+	OutboxRetryCountView = &view.View{
+		Name:        "gql/server/outbox_retry_count",
+		Description: "Count of retried event publications by operation",
+		Measure:     OutboxRetryCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// OutboxFailureCountView reports the number of events which failed to be published, by operation
+	OutboxFailureCountView = &view.View{
+		Name:        "gql/server/outbox_failure_count",
+		Description: "Number of events which failed to be published after GraphQL mutations by operation",
+		Measure:     OutboxFailureCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
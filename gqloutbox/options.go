@@ -0,0 +1,74 @@
+package gqloutbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the outbox extension
+	Option func(*config)
+
+	config struct {
+		async      bool
+		attempts   int
+		backoff    time.Duration
+		timeout    time.Duration
+		deadLetter func(context.Context, []Event, error)
+		opLabel    gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		attempts: 3,
+		backoff:  100 * time.Millisecond,
+		timeout:  5 * time.Second,
+		opLabel:  gqllabel.OperationName,
+	}
+}
+
+// WithAsync publishes events in the background, without delaying the response. This is disabled by default.
+//
+// Notice that events published in the background are lost if the process stops before they are published.
+func WithAsync(enabled bool) Option {
+	return func(c *config) {
+		c.async = enabled
+	}
+}
+
+// WithRetries sets the number of attempts to publish events, and the backoff between attempts, doubled after each one.
+// The default is 3 attempts, with a backoff of 100ms.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(c *config) {
+		if attempts < 1 {
+			attempts = 1
+		}
+		c.attempts = attempts
+		c.backoff = backoff
+	}
+}
+
+// WithTimeout sets the timeout of each attempt to publish events. The default is 5s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithDeadLetter sets the handler of events which failed to be published after all attempts, e.g. to store them
+// for a later replay. By default, these events are only counted as failures.
+func WithDeadLetter(handler func(context.Context, []Event, error)) Option {
+	return func(c *config) {
+		c.deadLetter = handler
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
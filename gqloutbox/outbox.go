@@ -0,0 +1,285 @@
+// Package gqloutbox provides a gqlgen extension to publish the domain events emitted by mutations,
+// only once their resolvers succeeded.
+//
+// Resolvers emit events with Emit. Events are buffered until the end of the operation, then published to a pluggable
+// Bus, in the order they were emitted. Events emitted while resolving a mutation field which failed are dropped:
+// the fields of a mutation are executed one after the other, so that the events of the fields which succeeded
+// are still published when another one fails.
+//
+// Example:
+//
+//   func (r *mutationResolver) PlaceOrder(ctx context.Context, input OrderInput) (*Order, error) {
+//     order, err := r.orders.Create(ctx, input)
+//     if err != nil {
+//       return nil, err
+//     }
+//     gqloutbox.Emit(ctx, gqloutbox.Event{Type: "OrderPlaced", Key: order.ID, Payload: order})
+//     return order, nil
+//   }
+//
+// Publication is best effort: events are only buffered in memory, so that they are lost if the process stops before
+// they are published. Failed publications are retried, and events may be published more than once, e.g. when
+// an attempt times out after the bus received the events. Each event carries a unique ID, so consumers may
+// deduplicate them. Events which still fail to be published after all attempts are handed over to a dead letter
+// handler (see WithDeadLetter), which may persist them for a later replay.
+//
+// Publication is not part of the transaction of the mutation: when events must not be lost, store them together
+// with the changes of the mutation (a transactional outbox table), and publish them from this table instead.
+package gqloutbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/internal/detach"
+)
+
+const extensionName = "Outbox"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Outbox{}
+
+type (
+	// Event emitted by a mutation
+	Event struct {
+		// ID uniquely identifies the event. It is generated when not set.
+		ID string `json:"id"`
+
+		// Type of the event, e.g. "OrderPlaced"
+		Type string `json:"type"`
+
+		// Key of the entity concerned by the event, e.g. to partition events
+		Key string `json:"key,omitempty"`
+
+		Payload interface{} `json:"payload,omitempty"`
+
+		// Time of the event. It is set when emitted, if not set.
+		Time time.Time `json:"time"`
+
+		// OperationName of the mutation emitting the event
+		OperationName string `json:"operationName,omitempty"`
+	}
+
+	// Bus publishes events, e.g. to a message broker
+	Bus interface {
+		Publish(context.Context, []Event) error
+	}
+
+	// BusFunc is a function implementing Bus
+	BusFunc func(context.Context, []Event) error
+
+	// Outbox is a gqlgen extension publishing the events emitted by the mutation fields which succeeded
+	Outbox struct {
+		*config
+		bus Bus
+	}
+
+	// buffer of the events emitted during an operation
+	buffer struct {
+		mx     sync.Mutex
+		events []emitted
+		failed map[string]struct{}
+	}
+
+	// emitted event, with the mutation field emitting it
+	emitted struct {
+		event Event
+		field string
+	}
+
+	bufferKey struct{}
+)
+
+// Publish implements Bus
+func (f BusFunc) Publish(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// Emit an event from a mutation. The event is published once the response is produced, unless the mutation field
+// emitting it fails.
+//
+// It returns false when the Outbox extension is not in use, or the operation is not a mutation.
+func Emit(ctx context.Context, event Event) bool {
+	b, ok := ctx.Value(bufferKey{}).(*buffer)
+	if !ok {
+		return false
+	}
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mx.Lock()
+	b.events = append(b.events, emitted{event: event, field: rootField(ctx)})
+	b.mx.Unlock()
+	return true
+}
+
+// New Outbox extension, publishing events to a Bus
+func New(bus Bus, opts ...Option) *Outbox {
+	o := &Outbox{
+		config: defaultConfig(),
+		bus:    bus,
+	}
+	for _, apply := range opts {
+		apply(o.config)
+	}
+	return o
+}
+
+// ExtensionName yields the extension name: "Outbox"
+func (*Outbox) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Outbox) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (o *Outbox) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	b := &buffer{}
+	resp := next(context.WithValue(ctx, bufferKey{}, b))
+	if resp == nil {
+		return resp
+	}
+
+	events := b.drain()
+	if len(events) == 0 {
+		return resp
+	}
+	for i := range events {
+		events[i].OperationName = oc.OperationName
+	}
+
+	// events are published even when the client went away, since the mutation is done
+	label := o.config.opLabel(oc)
+	if o.config.async {
+		go o.publish(detach.Context(ctx), events, label)
+		return resp
+	}
+	o.publish(detach.Context(ctx), events, label)
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor, dropping the events of the mutation fields which failed
+func (o *Outbox) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+	b, ok := ctx.Value(bufferKey{}).(*buffer)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc == nil || fc.Parent != nil {
+		return next(ctx)
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			// the resolver failed or panicked
+			b.fail(fc.Field.Alias)
+		}
+	}()
+
+	res, err = next(ctx)
+	succeeded = err == nil
+	return res, err
+}
+
+// publish events, with retries
+func (o *Outbox) publish(ctx context.Context, events []Event, label string) {
+	tags := []tag.Mutator{tag.Upsert(metrics.TagOperation, label)}
+
+	var err error
+	for attempt := 0; attempt < o.config.attempts; attempt++ {
+		if attempt > 0 {
+			if !o.wait(ctx, o.config.backoff<<uint(attempt-1)) {
+				break
+			}
+			_ = stats.RecordWithTags(ctx, tags, OutboxRetryCount.M(1))
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, o.config.timeout)
+		err = o.bus.Publish(publishCtx, events)
+		cancel()
+		if err == nil {
+			_ = stats.RecordWithTags(ctx, tags, OutboxPublishedCount.M(int64(len(events))))
+			return
+		}
+	}
+
+	_ = stats.RecordWithTags(ctx, tags, OutboxFailureCount.M(int64(len(events))))
+	if o.config.deadLetter != nil {
+		o.config.deadLetter(ctx, events, err)
+	}
+}
+
+// wait for a backoff, unless the context is done first
+func (o *Outbox) wait(ctx context.Context, backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (b *buffer) fail(field string) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.failed == nil {
+		b.failed = make(map[string]struct{})
+	}
+	b.failed[field] = struct{}{}
+}
+
+// drain the events of the mutation fields which succeeded
+func (b *buffer) drain() []Event {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	events := make([]Event, 0, len(b.events))
+	for _, e := range b.events {
+		if _, failed := b.failed[e.field]; !failed {
+			events = append(events, e.event)
+		}
+	}
+	b.events = nil
+	return events
+}
+
+// rootField yields the alias of the mutation field being resolved
+func rootField(ctx context.Context) string {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil {
+		return ""
+	}
+	for fc.Parent != nil {
+		fc = fc.Parent
+	}
+	return fc.Field.Alias
+}
+
+func newID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
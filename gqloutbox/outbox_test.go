@@ -0,0 +1,176 @@
+package gqloutbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type recordingBus struct {
+	mx        sync.Mutex
+	published []Event
+	failures  int
+	ctxErr    error
+}
+
+func (b *recordingBus) Publish(ctx context.Context, events []Event) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.ctxErr = ctx.Err()
+	if b.failures > 0 {
+		b.failures--
+		return errors.New("unavailable")
+	}
+	b.published = append(b.published, events...)
+	return nil
+}
+
+func (b *recordingBus) types() []string {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	types := make([]string, 0, len(b.published))
+	for _, event := range b.published {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func mutation(ctx context.Context) context.Context {
+	return graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		RawQuery:      "mutation place { placeOrder { id } notify }",
+		OperationName: "place",
+		Operation:     &ast.OperationDefinition{Operation: ast.Mutation, Name: "place"},
+	})
+}
+
+// resolve a mutation field emitting events through the extension
+func resolve(ctx context.Context, o *Outbox, field string, err error, types ...string) {
+	fc := &graphql.FieldContext{
+		Object: "Mutation",
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: field, Alias: field}},
+	}
+	_, _ = o.InterceptField(graphql.WithFieldContext(ctx, fc), func(ctx context.Context) (interface{}, error) {
+		for _, typ := range types {
+			child := &graphql.FieldContext{
+				Object: "Order",
+				Field:  graphql.CollectedField{Field: &ast.Field{Name: "id", Alias: "id"}},
+			}
+			Emit(graphql.WithFieldContext(ctx, child), Event{Type: typ})
+		}
+		return nil, err
+	})
+}
+
+func TestOutbox(t *testing.T) {
+	bus := &recordingBus{}
+	o := New(bus, WithRetries(1, 0))
+	require.Equal(t, extensionName, o.ExtensionName())
+
+	resp := o.InterceptResponse(mutation(context.Background()), func(ctx context.Context) *graphql.Response {
+		resolve(ctx, o, "placeOrder", nil, "OrderPlaced", "StockReserved")
+		return &graphql.Response{}
+	})
+	require.NotNil(t, resp)
+
+	assert.Equal(t, []string{"OrderPlaced", "StockReserved"}, bus.types())
+	for _, event := range bus.published {
+		assert.NotEmpty(t, event.ID)
+		assert.False(t, event.Time.IsZero())
+		assert.Equal(t, "place", event.OperationName)
+	}
+}
+
+func TestOutboxFailedField(t *testing.T) {
+	bus := &recordingBus{}
+	o := New(bus, WithRetries(1, 0))
+
+	resp := o.InterceptResponse(mutation(context.Background()), func(ctx context.Context) *graphql.Response {
+		resolve(ctx, o, "placeOrder", nil, "OrderPlaced")
+		resolve(ctx, o, "notify", errors.New("failed"), "CustomerNotified")
+		return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("failed")}}
+	})
+	require.Len(t, resp.Errors, 1)
+
+	assert.Equal(t, []string{"OrderPlaced"}, bus.types(), "the events of the committed fields are published")
+}
+
+func TestOutboxQuery(t *testing.T) {
+	o := New(&recordingBus{})
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+	_ = o.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		assert.False(t, Emit(ctx, Event{Type: "Ignored"}), "queries do not emit events")
+		return &graphql.Response{}
+	})
+}
+
+func TestOutboxDetached(t *testing.T) {
+	bus := &recordingBus{}
+	o := New(bus, WithRetries(1, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_ = o.InterceptResponse(mutation(ctx), func(ctx context.Context) *graphql.Response {
+		resolve(ctx, o, "placeOrder", nil, "OrderPlaced")
+		// the client goes away once the mutation is done
+		cancel()
+		return &graphql.Response{}
+	})
+
+	assert.Equal(t, []string{"OrderPlaced"}, bus.types())
+	assert.NoError(t, bus.ctxErr, "events are published with a context detached from the request")
+}
+
+func TestOutboxRetries(t *testing.T) {
+	bus := &recordingBus{failures: 2}
+	var deadLetters []Event
+	o := New(bus,
+		WithRetries(2, time.Millisecond),
+		WithDeadLetter(func(_ context.Context, events []Event, err error) {
+			assert.Error(t, err)
+			deadLetters = append(deadLetters, events...)
+		}),
+	)
+
+	handler := func(ctx context.Context) *graphql.Response {
+		resolve(ctx, o, "placeOrder", nil, "OrderPlaced")
+		return &graphql.Response{}
+	}
+
+	_ = o.InterceptResponse(mutation(context.Background()), handler)
+	assert.Empty(t, bus.types())
+	require.Len(t, deadLetters, 1, "events failing all attempts are handed over to the dead letter handler")
+
+	_ = o.InterceptResponse(mutation(context.Background()), handler)
+	assert.Equal(t, []string{"OrderPlaced"}, bus.types(), "failed publications are retried")
+	assert.Len(t, deadLetters, 1)
+}
+
+func TestOutboxWait(t *testing.T) {
+	o := New(&recordingBus{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, o.wait(ctx, time.Hour), "retries stop waiting when the context is done")
+	assert.True(t, o.wait(context.Background(), time.Millisecond))
+}
+
+func TestOutboxAsync(t *testing.T) {
+	bus := &recordingBus{}
+	o := New(bus, WithAsync(true), WithRetries(1, 0))
+
+	_ = o.InterceptResponse(mutation(context.Background()), func(ctx context.Context) *graphql.Response {
+		resolve(ctx, o, "placeOrder", nil, "OrderPlaced")
+		return &graphql.Response{}
+	})
+
+	require.Eventually(t, func() bool { return len(bus.types()) == 1 }, time.Second, time.Millisecond)
+}
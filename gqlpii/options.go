@@ -0,0 +1,82 @@
+package gqlpii
+
+import "github.com/99designs/gqlgen-contrib/internal/env"
+
+// DefaultEnvVar is the environment variable looked up by default to select the strategies set with WithEnvStrategy
+const DefaultEnvVar = env.DefaultVar
+
+type (
+	// Option for the masking engine
+	Option func(*config)
+
+	config struct {
+		directive     string
+		envVar        env.Var
+		strategies    map[Kind]Strategy
+		envStrategies map[string]map[Kind]Strategy
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		directive: "pii",
+		strategies: map[Kind]Strategy{
+			Email:   Redact,
+			Phone:   Redact,
+			Name:    Redact,
+			Address: Redact,
+			IP:      Redact,
+			Card:    Redact,
+			Secret:  Redact,
+			Other:   Redact,
+		},
+		envStrategies: make(map[string]map[Kind]Strategy),
+	}
+}
+
+// currentStrategies yields the strategies applicable in an environment
+func (c config) currentStrategies(env string) map[Kind]Strategy {
+	strategies := make(map[Kind]Strategy, len(c.strategies))
+	for kind, strategy := range c.strategies {
+		strategies[kind] = strategy
+	}
+	for kind, strategy := range c.envStrategies[env] {
+		strategies[kind] = strategy
+	}
+	return strategies
+}
+
+// WithStrategy sets the masking strategy for a kind of PII. By default, all kinds are redacted.
+//
+// Custom kinds may be declared this way.
+func WithStrategy(kind Kind, strategy Strategy) Option {
+	return func(c *config) {
+		c.strategies[kind] = strategy
+	}
+}
+
+// WithEnvStrategy sets the masking strategy for a kind of PII in a given environment (e.g. "dev"),
+// overriding the strategy set with WithStrategy.
+func WithEnvStrategy(env string, kind Kind, strategy Strategy) Option {
+	return func(c *config) {
+		if c.envStrategies[env] == nil {
+			c.envStrategies[env] = make(map[Kind]Strategy)
+		}
+		c.envStrategies[env][kind] = strategy
+	}
+}
+
+// WithEnvVar sets the environment variable holding the name of the environment, selecting the strategies
+// set with WithEnvStrategy. The default is "GQL_ENV".
+func WithEnvVar(name string) Option {
+	return func(c *config) {
+		c.envVar = env.Var(name)
+	}
+}
+
+// WithDirective sets the name of the directive tagging PII. The default is "pii".
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
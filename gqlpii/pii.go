@@ -0,0 +1,372 @@
+// Package gqlpii tags personally identifiable information (PII) in a GraphQL schema, and masks it
+// in the logs, traces and reports produced by other contrib packages.
+//
+// Fields, arguments and input fields are tagged in the schema with a directive:
+//
+//   directive @pii(kind: PIIKind!) on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+//
+//   enum PIIKind { EMAIL PHONE NAME ADDRESS IP CARD SECRET OTHER }
+//
+//   type User {
+//     email: String! @pii(kind: EMAIL)
+//   }
+//
+// The masking Engine is shared by the contrib packages which accept it, e.g. gqlopencensus.WithPII.
+// Masking strategies are configured per kind of PII, and may differ per environment.
+//
+// Example:
+//
+//   engine, err := gqlpii.New(generated.NewExecutableSchema(cfg).Schema(),
+//     gqlpii.WithStrategy(gqlpii.Email, gqlpii.Partial),
+//     gqlpii.WithEnvStrategy("dev", gqlpii.Email, gqlpii.Keep),
+//   )
+//   srv.Use(gqlopencensus.New(gqlopencensus.WithArgs(), gqlopencensus.WithPII(engine)))
+package gqlpii
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+)
+
+// Kind of personally identifiable information
+type Kind string
+
+// Kinds of PII
+const (
+	Email   Kind = "EMAIL"
+	Phone   Kind = "PHONE"
+	Name    Kind = "NAME"
+	Address Kind = "ADDRESS"
+	IP      Kind = "IP"
+	Card    Kind = "CARD"
+	Secret  Kind = "SECRET"
+	Other   Kind = "OTHER"
+)
+
+// Engine masks the PII tagged in a schema
+type Engine struct {
+	*config
+	schema *ast.Schema
+
+	// tags by "Type.field" for fields and input fields, and by "Type.field(arg)" for arguments
+	tags map[string]Kind
+
+	// strategies applicable in the current environment
+	strategies map[Kind]Strategy
+}
+
+// New masking Engine, for the PII tagged in a schema
+func New(schema *ast.Schema, opts ...Option) (*Engine, error) {
+	e := &Engine{
+		config: defaultConfig(),
+		schema: schema,
+		tags:   make(map[string]Kind),
+	}
+	for _, apply := range opts {
+		apply(e.config)
+	}
+	e.strategies = e.config.currentStrategies(e.config.envVar.Current())
+
+	for _, def := range schema.Types {
+		for _, field := range def.Fields {
+			if err := e.tag(def.Name+"."+field.Name, field.Directives); err != nil {
+				return nil, err
+			}
+			for _, arg := range field.Arguments {
+				if err := e.tag(def.Name+"."+field.Name+"("+arg.Name+")", arg.Directives); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return e, nil
+}
+
+func (e *Engine) tag(location string, directives ast.DirectiveList) error {
+	d := directives.ForName(e.config.directive)
+	if d == nil {
+		return nil
+	}
+	kind := Other
+	if arg := d.Arguments.ForName("kind"); arg != nil && arg.Value != nil {
+		kind = Kind(arg.Value.Raw)
+	}
+	if _, ok := e.config.strategies[kind]; !ok {
+		return fmt.Errorf("invalid @%s on %s: unknown kind %q", e.config.directive, location, kind)
+	}
+	e.tags[location] = kind
+	return nil
+}
+
+// FieldKind yields the kind of PII of a field of an object or input object, if tagged
+func (e *Engine) FieldKind(typeName, field string) (Kind, bool) {
+	kind, ok := e.tags[typeName+"."+field]
+	return kind, ok
+}
+
+// ArgumentKind yields the kind of PII of an argument, if tagged
+func (e *Engine) ArgumentKind(typeName, field, arg string) (Kind, bool) {
+	kind, ok := e.tags[typeName+"."+field+"("+arg+")"]
+	return kind, ok
+}
+
+// Mask a value of some kind of PII, with the strategy applicable in the current environment.
+//
+// Lists and objects are masked as a whole.
+func (e *Engine) Mask(kind Kind, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	strategy := e.strategies[kind]
+	if strategy == nil {
+		strategy = Redact
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strategy(kind, v)
+	case []interface{}, map[string]interface{}:
+		buf, _ := json.Marshal(v)
+		return strategy(kind, string(buf))
+	default:
+		return strategy(kind, fmt.Sprint(v))
+	}
+}
+
+// Sanitizer yields a gqlattr sanitizer, masking the values of span attributes holding PII, by key
+func (e *Engine) Sanitizer(kinds map[string]Kind) gqlattr.Sanitizer {
+	return func(key string, value interface{}) (string, interface{}, bool) {
+		if kind, ok := kinds[key]; ok {
+			return key, e.Mask(kind, value), true
+		}
+		return key, value, true
+	}
+}
+
+// MaskArgs yields a masked copy of the arguments of a field, as generic JSON values.
+func (e *Engine) MaskArgs(fc *graphql.FieldContext) map[string]interface{} {
+	if fc == nil || fc.Args == nil {
+		return nil
+	}
+	var def *ast.FieldDefinition
+	if fc.Field.Field != nil {
+		def = fc.Field.Definition
+	}
+
+	masked := make(map[string]interface{}, len(fc.Args))
+	for name, value := range fc.Args {
+		generic := toGeneric(value)
+		if kind, ok := e.ArgumentKind(fc.Object, fc.Field.Name, name); ok {
+			masked[name] = e.Mask(kind, generic)
+			continue
+		}
+		if def != nil {
+			if argDef := def.Arguments.ForName(name); argDef != nil {
+				generic = e.maskInput(argDef.Type, generic)
+			}
+		}
+		masked[name] = generic
+	}
+	return masked
+}
+
+// MaskVariables yields a masked copy of the variables of an operation.
+//
+// Variables are masked when their type is an input object with tagged fields, or when they are used
+// as a tagged argument.
+func (e *Engine) MaskVariables(oc *graphql.OperationContext) map[string]interface{} {
+	if oc == nil || oc.Variables == nil {
+		return nil
+	}
+	if oc.Operation == nil {
+		return e.redactAll(oc.Variables)
+	}
+
+	kinds := make(map[string]Kind)
+	e.variableKinds(oc.Operation.SelectionSet, oc.Doc, kinds, make(map[string]bool))
+
+	masked := make(map[string]interface{}, len(oc.Variables))
+	for name, value := range oc.Variables {
+		generic := toGeneric(value)
+		if kind, ok := kinds[name]; ok {
+			masked[name] = e.Mask(kind, generic)
+			continue
+		}
+		if varDef := oc.Operation.VariableDefinitions.ForName(name); varDef != nil {
+			generic = e.maskInput(varDef.Type, generic)
+		}
+		masked[name] = generic
+	}
+	return masked
+}
+
+// MaskData yields a masked copy of the data of a response to an operation
+func (e *Engine) MaskData(oc *graphql.OperationContext, data json.RawMessage) json.RawMessage {
+	if oc == nil || oc.Operation == nil || len(data) == 0 {
+		return data
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return data
+	}
+	masked, err := json.Marshal(e.maskSelection(oc.Operation.SelectionSet, oc.Doc, generic, make(map[string]bool)))
+	if err != nil {
+		return data
+	}
+	return masked
+}
+
+// maskInput masks the tagged fields of an input object, at any depth
+func (e *Engine) maskInput(typ *ast.Type, value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		if typ.Elem == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = e.maskInput(typ.Elem, elem)
+		}
+		return out
+	case map[string]interface{}:
+		def := e.schema.Types[typ.Name()]
+		if def == nil || def.Kind != ast.InputObject {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for name, elem := range v {
+			if kind, ok := e.FieldKind(def.Name, name); ok {
+				out[name] = e.Mask(kind, elem)
+				continue
+			}
+			if field := def.Fields.ForName(name); field != nil {
+				elem = e.maskInput(field.Type, elem)
+			}
+			out[name] = elem
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// maskSelection masks the tagged fields of response data, following the selection set of the operation
+func (e *Engine) maskSelection(selections ast.SelectionSet, doc *ast.QueryDocument, value interface{}, visiting map[string]bool) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = e.maskSelection(selections, doc, elem, visiting)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			out[k] = elem
+		}
+		e.maskFields(selections, doc, v, out, visiting)
+		return out
+	default:
+		return v
+	}
+}
+
+func (e *Engine) maskFields(selections ast.SelectionSet, doc *ast.QueryDocument, in, out map[string]interface{}, visiting map[string]bool) {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			key := s.Alias
+			if key == "" {
+				key = s.Name
+			}
+			value, ok := in[key]
+			if !ok {
+				continue
+			}
+			if s.ObjectDefinition != nil {
+				if kind, tagged := e.FieldKind(s.ObjectDefinition.Name, s.Name); tagged {
+					out[key] = e.Mask(kind, value)
+					continue
+				}
+			}
+			if len(s.SelectionSet) > 0 {
+				out[key] = e.maskSelection(s.SelectionSet, doc, value, visiting)
+			}
+		case *ast.InlineFragment:
+			e.maskFields(s.SelectionSet, doc, in, out, visiting)
+		case *ast.FragmentSpread:
+			if fragment := fragmentDefinition(s, doc); fragment != nil && !visiting[fragment.Name] {
+				visiting[fragment.Name] = true
+				e.maskFields(fragment.SelectionSet, doc, in, out, visiting)
+				delete(visiting, fragment.Name)
+			}
+		}
+	}
+}
+
+// variableKinds collects the variables used as tagged arguments
+func (e *Engine) variableKinds(selections ast.SelectionSet, doc *ast.QueryDocument, kinds map[string]Kind, visiting map[string]bool) {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.ObjectDefinition != nil {
+				for _, arg := range s.Arguments {
+					if arg.Value == nil || arg.Value.Kind != ast.Variable {
+						continue
+					}
+					if kind, ok := e.ArgumentKind(s.ObjectDefinition.Name, s.Name, arg.Name); ok {
+						kinds[arg.Value.Raw] = kind
+					}
+				}
+			}
+			e.variableKinds(s.SelectionSet, doc, kinds, visiting)
+		case *ast.InlineFragment:
+			e.variableKinds(s.SelectionSet, doc, kinds, visiting)
+		case *ast.FragmentSpread:
+			if fragment := fragmentDefinition(s, doc); fragment != nil && !visiting[fragment.Name] {
+				visiting[fragment.Name] = true
+				e.variableKinds(fragment.SelectionSet, doc, kinds, visiting)
+			}
+		}
+	}
+}
+
+func (e *Engine) redactAll(variables map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		masked[name] = e.Mask(Other, toGeneric(value))
+	}
+	return masked
+}
+
+func fragmentDefinition(spread *ast.FragmentSpread, doc *ast.QueryDocument) *ast.FragmentDefinition {
+	if spread.Definition != nil {
+		return spread.Definition
+	}
+	if doc == nil {
+		return nil
+	}
+	return doc.Fragments.ForName(spread.Name)
+}
+
+// toGeneric converts a value, such as an input struct generated by gqlgen, to generic JSON values
+func toGeneric(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool, float64, []interface{}, map[string]interface{}:
+		return value
+	}
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(buf, &generic); err != nil {
+		return fmt.Sprint(value)
+	}
+	return generic
+}
@@ -0,0 +1,107 @@
+package gqlpii
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+directive @pii(kind: PIIKind!) on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+
+enum PIIKind { EMAIL PHONE NAME ADDRESS IP CARD SECRET OTHER }
+
+input UserInput {
+  email: String! @pii(kind: EMAIL)
+  nickname: String
+}
+
+type User {
+  id: ID!
+  email: String! @pii(kind: EMAIL)
+  phone: String @pii(kind: PHONE)
+}
+
+type Query {
+  user(phone: String @pii(kind: PHONE)): User
+}
+
+type Mutation {
+  createUser(input: UserInput!): User!
+}
+`
+
+func TestEngine(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	e, err := New(schema, WithStrategy(Email, Partial), WithEnvVar("GQLPII_TEST_ENV"))
+	require.NoError(t, err)
+
+	kind, ok := e.FieldKind("User", "email")
+	require.True(t, ok)
+	assert.Equal(t, Email, kind)
+
+	t.Run("variables", func(t *testing.T) {
+		doc, gqlErr := gqlparser.LoadQuery(schema, `
+mutation create($input: UserInput!) { createUser(input: $input) { id } }
+query get($phone: String) { user(phone: $phone) { ...details } }
+fragment details on User { email phone }
+`)
+		require.Nil(t, gqlErr)
+
+		masked := e.MaskVariables(&graphql.OperationContext{
+			Doc:       doc,
+			Operation: doc.Operations.ForName("create"),
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"email": "john@example.com", "nickname": "johnny"},
+			},
+		})
+		assert.Equal(t, map[string]interface{}{
+			"input": map[string]interface{}{"email": "***@example.com", "nickname": "johnny"},
+		}, masked)
+
+		oc := &graphql.OperationContext{
+			Doc:       doc,
+			Operation: doc.Operations.ForName("get"),
+			Variables: map[string]interface{}{"phone": "+33123456789"},
+		}
+		assert.Equal(t, map[string]interface{}{"phone": "[PHONE]"}, e.MaskVariables(oc))
+
+		data := e.MaskData(oc, json.RawMessage(`{"user":{"email":"john@example.com","phone":"+33123456789"}}`))
+		assert.JSONEq(t, `{"user":{"email":"***@example.com","phone":"[PHONE]"}}`, string(data))
+	})
+
+	t.Run("span attributes", func(t *testing.T) {
+		sanitize := e.Sanitizer(map[string]Kind{"user.email": Email})
+		key, value, ok := sanitize("user.email", "john@example.com")
+		assert.True(t, ok)
+		assert.Equal(t, "user.email", key)
+		assert.Equal(t, "***@example.com", value)
+
+		_, value, _ = sanitize("user.id", "42")
+		assert.Equal(t, "42", value)
+	})
+
+	t.Run("strategies", func(t *testing.T) {
+		assert.Equal(t, "[CARD]", Redact(Card, "4111111111111111"))
+		assert.Equal(t, "************1111", Partial(Card, "4111111111111111"))
+		assert.Equal(t, Hash(Name, "john"), Hash(Name, "john"))
+		assert.NotEqual(t, Hash(Name, "john"), Hash(Name, "jane"))
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		invalid, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: `
+directive @pii(kind: String!) on FIELD_DEFINITION
+type Query { secret: String @pii(kind: "UNKNOWN") }
+`})
+		require.Nil(t, gqlErr)
+		_, err := New(invalid)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,43 @@
+package gqlpii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strategy masks a value of some kind of PII
+type Strategy func(kind Kind, value string) string
+
+// Redact replaces the value with its kind, e.g. "[EMAIL]"
+func Redact(kind Kind, _ string) string {
+	return "[" + string(kind) + "]"
+}
+
+// Keep leaves the value untouched, e.g. in development environments
+func Keep(_ Kind, value string) string {
+	return value
+}
+
+// Hash replaces the value with a short hash, so that values may be correlated without being disclosed
+func Hash(kind Kind, value string) string {
+	h := sha256.Sum256([]byte(value))
+	return "[" + string(kind) + ":" + hex.EncodeToString(h[:6]) + "]"
+}
+
+// Partial discloses part of the value: the domain of emails, and the last 4 characters of other values
+// long enough to leave at least 4 characters masked
+func Partial(kind Kind, value string) string {
+	if kind == Email {
+		if at := strings.LastIndexByte(value, '@'); at >= 0 {
+			return "***" + value[at:]
+		}
+	}
+	n := utf8.RuneCountInString(value)
+	if n < 8 {
+		return Redact(kind, value)
+	}
+	runes := []rune(value)
+	return strings.Repeat("*", n-4) + string(runes[n-4:])
+}
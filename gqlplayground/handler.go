@@ -0,0 +1,37 @@
+// Package gqlplayground serves a GraphQL playground, gated by environment and role,
+// so it may ship disabled by default in production binaries.
+//
+// The companion Info extension adds contrib-aware details to responses (request ID, trace ID, complexity),
+// which are displayed by the playground alongside query results.
+package gqlplayground
+
+import (
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql/playground"
+)
+
+// Handler serves the GraphQL playground for the query endpoint.
+//
+// When the playground is disabled for the current environment, the handler responds with 404 Not Found.
+// When the request is not authorized, the handler responds with 403 Forbidden.
+func Handler(title, endpoint string, opts ...Option) http.Handler {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	if !cfg.isEnabled() {
+		return http.NotFoundHandler()
+	}
+
+	pg := playground.Handler(title, endpoint)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.authorizer != nil && !cfg.authorizer(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		pg.ServeHTTP(w, r)
+	})
+}
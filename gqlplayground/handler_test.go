@@ -0,0 +1,59 @@
+package gqlplayground
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	const env = "GQL_TEST_ENV"
+	require.NoError(t, os.Setenv(env, "production"))
+	defer func() {
+		_ = os.Unsetenv(env)
+	}()
+
+	t.Run("disabled by default", func(t *testing.T) {
+		resp := doRequest(Handler("test", "/query", WithEnvVar(env)))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("disabled in environment", func(t *testing.T) {
+		resp := doRequest(Handler("test", "/query", WithEnvVar(env), WithEnvironments("dev", "staging")))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("enabled in environment", func(t *testing.T) {
+		resp := doRequest(Handler("test", "/query", WithEnvVar(env), WithEnvironments("dev", "production")))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "test")
+	})
+
+	t.Run("disabled explicitly", func(t *testing.T) {
+		resp := doRequest(Handler("test", "/query", WithEnvVar(env), WithEnvironments("production"), Enabled(false)))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("enabled explicitly", func(t *testing.T) {
+		resp := doRequest(Handler("test", "/query", WithEnvVar(env), Enabled(true)))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		resp := doRequest(Handler("test", "/query", Enabled(true), WithAuthorizer(func(_ *http.Request) bool {
+			return false
+		})))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
+
+func doRequest(handler http.Handler) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
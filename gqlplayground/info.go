@@ -0,0 +1,111 @@
+package gqlplayground
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"go.opencensus.io/trace"
+)
+
+const extensionName = "PlaygroundInfo"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Info{}
+
+// Info is a gqlgen extension which adds details about the execution to the "playground" key of the response extensions:
+// request ID, trace ID and query complexity.
+//
+// Like the playground handler, it is disabled unless enabled for the current environment.
+// With an authorizer, details are only added for requests accepted by the authorizer, as checked by Middleware.
+type Info struct {
+	*config
+	enabled bool
+}
+
+type authorizedKey struct{}
+
+// NewInfo builds an Info extension.
+//
+// The complexity is only reported when the extension.ComplexityLimit extension is used.
+func NewInfo(opts ...Option) *Info {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	// the environment is not expected to change at runtime
+	return &Info{config: cfg, enabled: cfg.isEnabled()}
+}
+
+// Middleware applies the authorizer to requests, so that details are only added to the responses of
+// authorized requests. Without this middleware, responses get no details when an authorizer is configured.
+//
+// The middleware must wrap the gqlgen handler:
+//
+//   http.Handle("/query", info.Middleware(srv))
+func (i *Info) Middleware(next http.Handler) http.Handler {
+	if !i.enabled || i.config.authorizer == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized := i.config.authorizer(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authorizedKey{}, authorized)))
+	})
+}
+
+// ExtensionName yields the extension name: "PlaygroundInfo"
+func (Info) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (Info) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (i Info) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !i.enabled || !i.authorized(ctx) {
+		return next(ctx)
+	}
+
+	info := make(map[string]interface{}, 3)
+	if i.config.requestID != nil {
+		if id := i.config.requestID(ctx); id != "" {
+			info["requestID"] = id
+		}
+	}
+	if span := trace.FromContext(ctx); span != nil {
+		info["traceID"] = span.SpanContext().TraceID.String()
+	}
+
+	resp := next(ctx)
+	if resp == nil {
+		return nil
+	}
+
+	if stats := extension.GetComplexityStats(ctx); stats != nil {
+		info["complexity"] = stats.Complexity
+		info["complexityLimit"] = stats.ComplexityLimit
+	}
+
+	if resp.Extensions == nil {
+		resp.Extensions = make(map[string]interface{}, 1)
+	}
+	resp.Extensions["playground"] = info
+
+	return resp
+}
+
+// authorized tells if the request was accepted by the authorizer, if any
+func (i Info) authorized(ctx context.Context) bool {
+	if i.config.authorizer == nil {
+		return true
+	}
+	authorized, _ := ctx.Value(authorizedKey{}).(bool)
+	return authorized
+}
@@ -0,0 +1,60 @@
+package gqlplayground
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfo(t *testing.T) {
+	requestID := WithRequestID(func(context.Context) string { return "req-1" })
+	withRole := WithAuthorizer(func(r *http.Request) bool { return r.Header.Get("X-Role") == "developer" })
+
+	// serve runs the Info extension behind its middleware, and yields the playground details of the response
+	serve := func(info *Info, role string) interface{} {
+		var extensions map[string]interface{}
+		handler := info.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			ctx := graphql.WithOperationContext(r.Context(), &graphql.OperationContext{})
+			resp := info.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+				return &graphql.Response{}
+			})
+			extensions = resp.Extensions
+		}))
+
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		if role != "" {
+			r.Header.Set("X-Role", role)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return extensions["playground"]
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{"requestID": "req-1"}, serve(NewInfo(Enabled(true), requestID), ""))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, serve(NewInfo(requestID), ""))
+		assert.Nil(t, serve(NewInfo(Enabled(false), requestID), ""))
+	})
+
+	t.Run("authorized", func(t *testing.T) {
+		info := NewInfo(Enabled(true), requestID, withRole)
+		assert.Equal(t, map[string]interface{}{"requestID": "req-1"}, serve(info, "developer"))
+		assert.Nil(t, serve(info, "customer"))
+		assert.Nil(t, serve(info, ""))
+	})
+
+	t.Run("authorizer without middleware", func(t *testing.T) {
+		info := NewInfo(Enabled(true), requestID, withRole)
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{})
+		resp := info.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+			return &graphql.Response{}
+		})
+		assert.Nil(t, resp.Extensions)
+	})
+}
@@ -0,0 +1,78 @@
+package gqlplayground
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen-contrib/internal/env"
+)
+
+// DefaultEnvVar is the environment variable looked up by default to enable the playground (see WithEnvironments)
+const DefaultEnvVar = env.DefaultVar
+
+type (
+	// Option for the playground handler and the companion Info extension
+	Option func(*config)
+
+	config struct {
+		enabled      *bool
+		envVar       env.Var
+		environments []string
+		authorizer   func(*http.Request) bool
+		requestID    func(context.Context) string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// isEnabled tells if the playground is enabled for the current environment.
+func (c config) isEnabled() bool {
+	if c.enabled != nil {
+		return *c.enabled
+	}
+	return c.envVar.In(c.environments...)
+}
+
+// Enabled forces the playground on or off, regardless of the current environment.
+//
+// By default, the playground is disabled unless the current environment is allowed with WithEnvironments.
+func Enabled(enabled bool) Option {
+	return func(c *config) {
+		c.enabled = &enabled
+	}
+}
+
+// WithEnvironments enables the playground only when the current environment is one of the allowed environments
+// (e.g. "dev", "staging").
+func WithEnvironments(allowed ...string) Option {
+	return func(c *config) {
+		c.environments = append(c.environments, allowed...)
+	}
+}
+
+// WithEnvVar sets the environment variable holding the name of the environment, matched against WithEnvironments.
+// The default is "GQL_ENV".
+func WithEnvVar(name string) Option {
+	return func(c *config) {
+		c.envVar = env.Var(name)
+	}
+}
+
+// WithAuthorizer restricts access to the playground to requests accepted by the authorizer (e.g. checking a role).
+//
+// The Info extension only adds details to the responses of accepted requests, when its Middleware wraps the gqlgen handler.
+func WithAuthorizer(authorizer func(*http.Request) bool) Option {
+	return func(c *config) {
+		c.authorizer = authorizer
+	}
+}
+
+// WithRequestID sets a function to retrieve the request ID from the context, to be shown in the playground
+// by the Info extension.
+func WithRequestID(requestID func(context.Context) string) Option {
+	return func(c *config) {
+		c.requestID = requestID
+	}
+}
@@ -0,0 +1,37 @@
+package gqlpresenter
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the registry of error presenters
+	Option func(*config)
+
+	config struct {
+		fallback  graphql.ErrorPresenterFunc
+		domainKey string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		fallback: graphql.DefaultErrorPresenter,
+	}
+}
+
+// WithFallback sets the presenter of the errors handled by no registered presenter, or for which
+// the registered presenter yields nil. The default is graphql.DefaultErrorPresenter.
+func WithFallback(fallback graphql.ErrorPresenterFunc) Option {
+	return func(c *config) {
+		c.fallback = fallback
+	}
+}
+
+// WithDomainExtension adds the domain of the presenter to the extensions of presented errors, under this key.
+// This is disabled by default.
+func WithDomainExtension(key string) Option {
+	return func(c *config) {
+		c.domainKey = key
+	}
+}
@@ -0,0 +1,190 @@
+// Package gqlpresenter provides a composable registry of error presenters for gqlgen.
+//
+// Instead of a single error presenter switching over all the errors of a service, each error domain
+// (authentication, rate limiting, validation, ...) registers a Presenter for its own errors.
+// Presenters are tried in a deterministic order, by priority then by domain name, regardless of the order
+// of registration. Errors matched by no presenter are handled by a fallback presenter.
+//
+// Example:
+//
+//   registry := gqlpresenter.New()
+//   registry.MustRegister("auth", 10, gqlpresenter.As(&auth.Error{}, presentAuthError))
+//   registry.MustRegister("ratelimit", 20, gqlpresenter.Is(ratelimit.ErrLimited, presentRateLimited))
+//   srv.SetErrorPresenter(registry.Present)
+package gqlpresenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type (
+	// Presenter presents the errors of a domain.
+	//
+	// Presenters yield false for the errors they do not handle, so the next presenter is tried.
+	Presenter interface {
+		Present(context.Context, error) (*gqlerror.Error, bool)
+	}
+
+	// PresenterFunc is a function implementing Presenter
+	PresenterFunc func(context.Context, error) (*gqlerror.Error, bool)
+
+	// Registry of error presenters, by domain
+	Registry struct {
+		*config
+
+		mx      sync.RWMutex
+		entries []entry
+	}
+
+	entry struct {
+		domain    string
+		priority  int
+		presenter Presenter
+	}
+)
+
+// Present implements Presenter
+func (f PresenterFunc) Present(ctx context.Context, err error) (*gqlerror.Error, bool) {
+	return f(ctx, err)
+}
+
+// Match builds a Presenter for the errors accepted by a predicate
+func Match(match func(error) bool, present graphql.ErrorPresenterFunc) Presenter {
+	return PresenterFunc(func(ctx context.Context, err error) (*gqlerror.Error, bool) {
+		if !match(err) {
+			return nil, false
+		}
+		return present(ctx, err), true
+	})
+}
+
+// Is builds a Presenter for the errors matching a target error, as reported by errors.Is
+func Is(target error, present graphql.ErrorPresenterFunc) Presenter {
+	return Match(func(err error) bool {
+		return errors.Is(err, target)
+	}, present)
+}
+
+// As builds a Presenter for the errors of the type of a prototype, as reported by errors.As,
+// e.g. As(&AuthError{}, presentAuthError) or As((*AuthError)(nil), presentAuthError).
+//
+// The error passed to the presentation function is the one of this type found in the chain of wrapped errors.
+func As(prototype error, present graphql.ErrorPresenterFunc) Presenter {
+	typ := reflect.TypeOf(prototype)
+	if typ == nil {
+		panic("gqlpresenter: As requires a typed prototype")
+	}
+
+	return PresenterFunc(func(ctx context.Context, err error) (*gqlerror.Error, bool) {
+		target := reflect.New(typ)
+		if !errors.As(err, target.Interface()) {
+			return nil, false
+		}
+		return present(ctx, target.Elem().Interface().(error)), true
+	})
+}
+
+// New empty registry of error presenters
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// Register the presenter of an error domain. Presenters with a lower priority are tried first.
+//
+// It is an error to register a domain twice.
+func (r *Registry) Register(domain string, priority int, presenter Presenter) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	for _, e := range r.entries {
+		if e.domain == domain {
+			return fmt.Errorf("gqlpresenter: domain %q is already registered", domain)
+		}
+	}
+
+	entries := make([]entry, len(r.entries), len(r.entries)+1)
+	copy(entries, r.entries)
+	entries = append(entries, entry{domain: domain, priority: priority, presenter: presenter})
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].priority != entries[j].priority {
+			return entries[i].priority < entries[j].priority
+		}
+		return entries[i].domain < entries[j].domain
+	})
+	r.entries = entries
+	return nil
+}
+
+// MustRegister registers the presenter of an error domain, and panics if the domain is already registered
+func (r *Registry) MustRegister(domain string, priority int, presenter Presenter) {
+	if err := r.Register(domain, priority, presenter); err != nil {
+		panic(err)
+	}
+}
+
+// Domains yields the registered domains, in the order their presenters are tried
+func (r *Registry) Domains() []string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	domains := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		domains = append(domains, e.domain)
+	}
+	return domains
+}
+
+// Present an error with the first registered presenter handling it, or with the fallback presenter.
+//
+// Its signature is a graphql.ErrorPresenterFunc, to be used with SetErrorPresenter, or composed
+// with other error presenters.
+func (r *Registry) Present(ctx context.Context, err error) *gqlerror.Error {
+	r.mx.RLock()
+	entries := r.entries
+	r.mx.RUnlock()
+
+	for _, e := range entries {
+		presented, ok := e.presenter.Present(ctx, err)
+		if !ok {
+			continue
+		}
+		if presented == nil {
+			break
+		}
+		return r.complete(ctx, e.domain, presented)
+	}
+
+	return r.config.fallback(ctx, err)
+}
+
+// complete a presented error with the path of the field, and its domain
+func (r *Registry) complete(ctx context.Context, domain string, presented *gqlerror.Error) *gqlerror.Error {
+	if presented.Path == nil {
+		if fc := graphql.GetFieldContext(ctx); fc != nil {
+			presented.Path = fc.Path()
+		}
+	}
+	if r.config.domainKey != "" {
+		if presented.Extensions == nil {
+			presented.Extensions = make(map[string]interface{}, 1)
+		}
+		if _, found := presented.Extensions[r.config.domainKey]; !found {
+			presented.Extensions[r.config.domainKey] = domain
+		}
+	}
+	return presented
+}
@@ -0,0 +1,59 @@
+package gqlpresenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type authError struct {
+	reason string
+}
+
+func (e *authError) Error() string {
+	return "unauthorized: " + e.reason
+}
+
+var errLimited = errors.New("rate limited")
+
+func TestRegistry(t *testing.T) {
+	registry := New(WithDomainExtension("domain"))
+
+	require.NoError(t, registry.Register("ratelimit", 20, Is(errLimited, func(context.Context, error) *gqlerror.Error {
+		return &gqlerror.Error{Message: "too many requests"}
+	})))
+	require.NoError(t, registry.Register("auth", 10, As(&authError{}, func(_ context.Context, err error) *gqlerror.Error {
+		return &gqlerror.Error{Message: "access denied", Extensions: map[string]interface{}{"reason": err.(*authError).reason}}
+	})))
+	require.NoError(t, registry.Register("internal", 20, Match(func(err error) bool {
+		return err.Error() == "ignored"
+	}, func(context.Context, error) *gqlerror.Error {
+		return nil
+	})))
+	require.Error(t, registry.Register("auth", 0, PresenterFunc(nil)))
+
+	assert.Equal(t, []string{"auth", "internal", "ratelimit"}, registry.Domains())
+
+	ctx := context.Background()
+
+	presented := registry.Present(ctx, fmt.Errorf("wrapped: %w", &authError{reason: "expired"}))
+	assert.Equal(t, "access denied", presented.Message)
+	assert.Equal(t, "expired", presented.Extensions["reason"])
+	assert.Equal(t, "auth", presented.Extensions["domain"])
+
+	presented = registry.Present(ctx, fmt.Errorf("wrapped: %w", errLimited))
+	assert.Equal(t, "too many requests", presented.Message)
+	assert.Equal(t, "ratelimit", presented.Extensions["domain"])
+
+	presented = registry.Present(ctx, errors.New("ignored"))
+	assert.Equal(t, "ignored", presented.Message)
+	assert.Nil(t, presented.Extensions)
+
+	presented = registry.Present(ctx, errors.New("boom"))
+	assert.Equal(t, "boom", presented.Message)
+}
@@ -0,0 +1,92 @@
+package gqlpubsub
+
+import (
+	"context"
+)
+
+type (
+	// KafkaWriter is the subset of a Kafka producer used by the Kafka broker.
+	//
+	// It plugs into segmentio/kafka-go with a thin adapter:
+	//
+	//   type kafkaWriter struct{ *kafka.Writer }
+	//
+	//   func (w kafkaWriter) Write(ctx context.Context, topic string, key, value []byte) error {
+	//     return w.WriteMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: value})
+	//   }
+	KafkaWriter interface {
+		Write(ctx context.Context, topic string, key, value []byte) error
+	}
+
+	// KafkaReader is the subset of a Kafka consumer used by the Kafka broker
+	KafkaReader interface {
+		// Read the value of the next message, blocking until a message is available or the context is done
+		Read(ctx context.Context) ([]byte, error)
+		Close() error
+	}
+
+	// Kafka is a Broker publishing messages to Kafka, one Kafka topic per topic.
+	//
+	// Every instance must receive all messages: readers are expected to consume from the latest offset, without
+	// a consumer group shared by instances.
+	Kafka struct {
+		*kafkaConfig
+		writer    KafkaWriter
+		newReader func(topic string) (KafkaReader, error)
+	}
+)
+
+// NewKafka builds a Broker over Kafka, with a function creating a reader of a Kafka topic for each subscription
+func NewKafka(writer KafkaWriter, newReader func(topic string) (KafkaReader, error), opts ...KafkaOption) *Kafka {
+	k := &Kafka{
+		kafkaConfig: defaultKafkaConfig(),
+		writer:      writer,
+		newReader:   newReader,
+	}
+	for _, apply := range opts {
+		apply(k.kafkaConfig)
+	}
+	return k
+}
+
+// Publish implements Broker. Messages are keyed by their ID.
+func (k *Kafka) Publish(ctx context.Context, msg Message) error {
+	data, err := encode(msg)
+	if err != nil {
+		return err
+	}
+	return k.writer.Write(ctx, k.kafkaConfig.prefix+msg.Topic, []byte(msg.ID), data)
+}
+
+// Subscribe implements Broker
+func (k *Kafka) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	reader, err := k.newReader(k.kafkaConfig.prefix + topic)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Message)
+	go func() {
+		defer close(ch)
+		defer func() { _ = reader.Close() }()
+
+		for {
+			data, err := reader.Read(ctx)
+			if err != nil {
+				return
+			}
+			msg, err := decode(data)
+			if err != nil {
+				continue
+			}
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+var _ Broker = &Kafka{}
@@ -0,0 +1,67 @@
+package gqlpubsub
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// Memory is a Broker delivering messages to the subscribers of the same process.
+	//
+	// Slow subscribers do not block publishers: messages are dropped when their buffer is full.
+	Memory struct {
+		bufferSize int
+
+		mx          sync.Mutex
+		subscribers map[string]map[chan Message]struct{}
+	}
+)
+
+// NewMemory builds an in-process Broker, with a buffer of some messages per subscriber
+func NewMemory(bufferSize int) *Memory {
+	return &Memory{
+		bufferSize:  bufferSize,
+		subscribers: make(map[string]map[chan Message]struct{}),
+	}
+}
+
+// Publish implements Broker
+func (m *Memory) Publish(_ context.Context, msg Message) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for ch := range m.subscribers[msg.Topic] {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe implements Broker
+func (m *Memory) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	ch := make(chan Message, m.bufferSize)
+
+	m.mx.Lock()
+	if m.subscribers[topic] == nil {
+		m.subscribers[topic] = make(map[chan Message]struct{})
+	}
+	m.subscribers[topic][ch] = struct{}{}
+	m.mx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.mx.Lock()
+		defer m.mx.Unlock()
+		delete(m.subscribers[topic], ch)
+		if len(m.subscribers[topic]) == 0 {
+			delete(m.subscribers, topic)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+var _ Broker = &Memory{}
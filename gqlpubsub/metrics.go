@@ -0,0 +1,98 @@
+package gqlpubsub
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the pubsub.
+func Register() error {
+	return gqlmetrics.Register(PubSubViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(PubSubViews...)
+}
+
+var (
+	// PubSubViews contains all opencensus stats views declared by the pubsub
+	PubSubViews = []*view.View{
+		PubSubPublishedCountView,
+		PubSubDeliveredCountView,
+		PubSubDeliveryLatencyView,
+		PubSubErrorCountView,
+	}
+
+	// PubSubPublishedCount tracks a count of published messages
+	PubSubPublishedCount = stats.Int64(
+		"gql/server/pubsub_published_count",
+		"Number of messages published for GraphQL subscriptions",
+		stats.UnitDimensionless)
+
+	// PubSubDeliveredCount tracks a count of messages delivered to subscribers
+	PubSubDeliveredCount = stats.Int64(
+		"gql/server/pubsub_delivered_count",
+		"Number of messages delivered to GraphQL subscriptions",
+		stats.UnitDimensionless)
+
+	// PubSubDeliveryLatency tracks the time between the publication and the delivery of messages
+	PubSubDeliveryLatency = stats.Float64(
+		"gql/server/pubsub_delivery_latency",
+		"Time between the publication and the delivery of messages",
+		stats.UnitMilliseconds)
+
+	// PubSubErrorCount tracks a count of failed publications and subscriptions
+	PubSubErrorCount = stats.Int64(
+		"gql/server/pubsub_error_count",
+		"Number of failed publications and subscriptions",
+		stats.UnitDimensionless)
+
+	// PubSubPublishedCountView reports the number of published messages by topic
+	PubSubPublishedCountView = &view.View{
+		Name:        "gql/server/pubsub_published_count",
+		Description: "Number of messages published for GraphQL subscriptions by topic",
+		Measure:     PubSubPublishedCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagTopic},
+	}
+
+	// PubSubDeliveredCountView reports the number of delivered messages by topic
+	PubSubDeliveredCountView = &view.View{
+		Name:        "gql/server/pubsub_delivered_count",
+		Description: "Number of messages delivered to GraphQL subscriptions by topic",
+		Measure:     PubSubDeliveredCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagTopic},
+	}
+
+	// PubSubDeliveryLatencyView reports a distribution of the delivery latency by topic
+	PubSubDeliveryLatencyView = &view.View{
+		Name:        "gql/server/pubsub_delivery_latency",
+		Description: "Distribution of the delivery latency of messages by topic",
+		Measure:     PubSubDeliveryLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagTopic},
+	}
+
+	// PubSubErrorCountView reports a count of errors by topic and step
+	PubSubErrorCountView = &view.View{
+		Name:        "gql/server/pubsub_error_count",
+		Description: "Count of failed publications and subscriptions by topic and step",
+		Measure:     PubSubErrorCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagTopic, TagStep},
+	}
+
+	// TagTopic is the topic of messages
+	TagTopic = tag.MustNewKey("gql.pubsub_topic")
+
+	// TagStep is the step of the delivery of messages: "publish", "subscribe" or "deliver"
+	TagStep = tag.MustNewKey("gql.pubsub_step")
+)
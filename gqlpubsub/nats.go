@@ -0,0 +1,100 @@
+package gqlpubsub
+
+import (
+	"context"
+)
+
+type (
+	// JetStreamClient is the subset of a NATS JetStream client used by the NATS broker.
+	//
+	// It plugs into nats.go with a thin adapter:
+	//
+	//   type jetStreamClient struct{ nats.JetStreamContext }
+	//
+	//   func (c jetStreamClient) Publish(ctx context.Context, subject string, data []byte) error {
+	//     _, err := c.JetStreamContext.Publish(subject, data, nats.Context(ctx))
+	//     return err
+	//   }
+	//
+	//   func (c jetStreamClient) Subscribe(subject string, handler func([]byte)) (func() error, error) {
+	//     sub, err := c.JetStreamContext.Subscribe(subject, func(m *nats.Msg) { handler(m.Data) }, nats.DeliverNew())
+	//     if err != nil {
+	//       return nil, err
+	//     }
+	//     return sub.Unsubscribe, nil
+	//   }
+	JetStreamClient interface {
+		// Publish data on a subject, waiting for the acknowledgment of the stream
+		Publish(ctx context.Context, subject string, data []byte) error
+
+		// Subscribe to the new messages of a subject, with an ephemeral consumer. The returned function unsubscribes.
+		Subscribe(subject string, handler func(data []byte)) (func() error, error)
+	}
+
+	// NATS is a Broker publishing messages to NATS JetStream, one subject per topic
+	NATS struct {
+		*natsConfig
+		client JetStreamClient
+	}
+)
+
+// NewNATS builds a Broker over NATS JetStream
+func NewNATS(client JetStreamClient, opts ...NATSOption) *NATS {
+	n := &NATS{
+		natsConfig: defaultNATSConfig(),
+		client:     client,
+	}
+	for _, apply := range opts {
+		apply(n.natsConfig)
+	}
+	return n
+}
+
+// Publish implements Broker
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	data, err := encode(msg)
+	if err != nil {
+		return err
+	}
+	return n.client.Publish(ctx, n.natsConfig.prefix+msg.Topic, data)
+}
+
+// Subscribe implements Broker. Messages are dropped when the subscriber does not keep up with its buffer.
+func (n *NATS) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	buffer := make(chan Message, n.natsConfig.bufferSize)
+	unsubscribe, err := n.client.Subscribe(n.natsConfig.prefix+topic, func(data []byte) {
+		msg, err := decode(data)
+		if err != nil {
+			return
+		}
+		select {
+		case buffer <- msg:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Message)
+	go func() {
+		defer close(ch)
+		defer func() { _ = unsubscribe() }()
+
+		for {
+			select {
+			case msg := <-buffer:
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+var _ Broker = &NATS{}
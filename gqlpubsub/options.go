@@ -0,0 +1,128 @@
+package gqlpubsub
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the PubSub
+	Option func(*config)
+
+	config struct {
+		topicLabel func(string) string
+		clock      func() time.Time
+	}
+
+	// RedisOption for the Redis streams broker
+	RedisOption func(*redisConfig)
+
+	redisConfig struct {
+		prefix string
+		maxLen int64
+		block  time.Duration
+	}
+
+	// NATSOption for the NATS broker
+	NATSOption func(*natsConfig)
+
+	natsConfig struct {
+		prefix     string
+		bufferSize int
+	}
+
+	// KafkaOption for the Kafka broker
+	KafkaOption func(*kafkaConfig)
+
+	kafkaConfig struct {
+		prefix string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		topicLabel: func(topic string) string { return topic },
+		clock:      graphql.Now,
+	}
+}
+
+func defaultRedisConfig() *redisConfig {
+	return &redisConfig{
+		prefix: "gql:",
+		maxLen: 1000,
+		block:  5 * time.Second,
+	}
+}
+
+func defaultNATSConfig() *natsConfig {
+	return &natsConfig{
+		prefix:     "gql.",
+		bufferSize: 100,
+	}
+}
+
+func defaultKafkaConfig() *kafkaConfig {
+	return &kafkaConfig{
+		prefix: "gql.",
+	}
+}
+
+// WithTopicLabel sets the function producing the topic tag of metrics, e.g. to strip identifiers from topics
+// such as "todos:123". By default, this is the topic.
+func WithTopicLabel(labeler func(string) string) Option {
+	return func(c *config) {
+		c.topicLabel = labeler
+	}
+}
+
+// WithClock sets the clock timestamping messages and measuring delivery latency. The default is graphql.Now.
+//
+// Delivery latency across instances relies on synchronized clocks.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithRedisPrefix sets the prefix of the keys of streams. The default is "gql:".
+func WithRedisPrefix(prefix string) RedisOption {
+	return func(c *redisConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithRedisMaxLen caps the length of streams. The default is about 1000 entries.
+func WithRedisMaxLen(maxLen int64) RedisOption {
+	return func(c *redisConfig) {
+		c.maxLen = maxLen
+	}
+}
+
+// WithRedisBlock sets how long a blocking read waits for new entries. The default is 5s.
+func WithRedisBlock(block time.Duration) RedisOption {
+	return func(c *redisConfig) {
+		c.block = block
+	}
+}
+
+// WithNATSPrefix sets the prefix of subjects. The default is "gql.".
+func WithNATSPrefix(prefix string) NATSOption {
+	return func(c *natsConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithNATSBufferSize sets the number of messages buffered per subscriber. The default is 100.
+func WithNATSBufferSize(size int) NATSOption {
+	return func(c *natsConfig) {
+		c.bufferSize = size
+	}
+}
+
+// WithKafkaPrefix sets the prefix of Kafka topics. The default is "gql.".
+func WithKafkaPrefix(prefix string) KafkaOption {
+	return func(c *kafkaConfig) {
+		c.prefix = prefix
+	}
+}
@@ -0,0 +1,163 @@
+// Package gqlpubsub provides a publish/subscribe abstraction for subscription resolvers, so that subscriptions
+// work across horizontally scaled gqlgen instances: events published by one instance are delivered to the
+// subscribers of all instances.
+//
+// Messages are carried by a pluggable Broker. Adapters are provided for Redis streams, NATS JetStream and Kafka:
+// they are not tied to a particular client library, and plug into clients with thin adapters. The Memory broker
+// delivers messages within a single process, e.g. for tests.
+//
+// Deliveries are instrumented with opencensus metrics, per topic.
+//
+// Example:
+//
+//   ps := gqlpubsub.New(gqlpubsub.NewRedisStreams(redisStreamsClient{client}))
+//
+//   // in mutation resolvers
+//   err := ps.Publish(ctx, "todos", todo)
+//
+//   // in subscription resolvers
+//   func (r *subscriptionResolver) TodoAdded(ctx context.Context) (<-chan *model.Todo, error) {
+//     messages, err := ps.Subscribe(ctx, "todos")
+//     if err != nil {
+//       return nil, err
+//     }
+//     out := make(chan *model.Todo)
+//     go func() {
+//       defer close(out)
+//       for msg := range messages {
+//         var todo model.Todo
+//         if err := msg.Decode(&todo); err == nil {
+//           out <- &todo
+//         }
+//       }
+//     }()
+//     return out, nil
+//   }
+package gqlpubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+type (
+	// Message published on a topic
+	Message struct {
+		ID      string          `json:"id"`
+		Topic   string          `json:"topic"`
+		Time    time.Time       `json:"time"`
+		Payload json.RawMessage `json:"payload"`
+	}
+
+	// Broker carries messages between instances.
+	//
+	// Subscribe returns a channel receiving the messages published on a topic after the subscription.
+	// The channel is closed when the context is done, or when the subscription fails.
+	Broker interface {
+		Publish(context.Context, Message) error
+		Subscribe(ctx context.Context, topic string) (<-chan Message, error)
+	}
+
+	// PubSub publishes values to a Broker, and delivers them to subscribers, recording metrics
+	PubSub struct {
+		*config
+		broker Broker
+	}
+)
+
+// New PubSub over a broker
+func New(broker Broker, opts ...Option) *PubSub {
+	p := &PubSub{
+		config: defaultConfig(),
+		broker: broker,
+	}
+	for _, apply := range opts {
+		apply(p.config)
+	}
+	return p
+}
+
+// Decode the JSON payload of a message
+func (m Message) Decode(value interface{}) error {
+	return json.Unmarshal(m.Payload, value)
+}
+
+// Publish a value on a topic, as JSON
+func (p *PubSub) Publish(ctx context.Context, topic string, value interface{}) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = p.broker.Publish(ctx, Message{
+		ID:      newID(),
+		Topic:   topic,
+		Time:    p.config.clock(),
+		Payload: payload,
+	})
+
+	measurements := []stats.Measurement{PubSubPublishedCount.M(1)}
+	if err != nil {
+		measurements = []stats.Measurement{PubSubErrorCount.M(1)}
+	}
+	_ = stats.RecordWithTags(ctx, p.mutators(topic, "publish"), measurements...)
+
+	return err
+}
+
+// Subscribe to the messages published on a topic. The channel is closed when the context is done.
+func (p *PubSub) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	messages, err := p.broker.Subscribe(ctx, topic)
+	if err != nil {
+		_ = stats.RecordWithTags(ctx, p.mutators(topic, "subscribe"), PubSubErrorCount.M(1))
+		return nil, err
+	}
+
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		mutators := p.mutators(topic, "deliver")
+		for msg := range messages {
+			latency := float64(p.config.clock().Sub(msg.Time)) / float64(time.Millisecond)
+			_ = stats.RecordWithTags(ctx, mutators, PubSubDeliveredCount.M(1), PubSubDeliveryLatency.M(latency))
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (p *PubSub) mutators(topic, step string) []tag.Mutator {
+	return []tag.Mutator{
+		tag.Upsert(TagTopic, p.config.topicLabel(topic)),
+		tag.Upsert(TagStep, step),
+	}
+}
+
+func newID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// encode a message for brokers carrying raw bytes
+func encode(msg Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// decode a message carried as raw bytes
+func decode(data []byte) (Message, error) {
+	var msg Message
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
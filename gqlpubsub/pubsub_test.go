@@ -0,0 +1,143 @@
+package gqlpubsub
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, messages <-chan Message) int {
+	select {
+	case msg, ok := <-messages:
+		require.True(t, ok)
+		var value int
+		require.NoError(t, msg.Decode(&value))
+		return value
+	case <-time.After(time.Second):
+		t.Fatal("expected a message")
+		return 0
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ps := New(NewMemory(10))
+
+	first, err := ps.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+	second, err := ps.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+	other, err := ps.Subscribe(ctx, "users")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Publish(ctx, "todos", 1))
+	assert.Equal(t, 1, receive(t, first))
+	assert.Equal(t, 1, receive(t, second))
+
+	select {
+	case <-other:
+		t.Fatal("unexpected message on another topic")
+	default:
+	}
+
+	cancel()
+	for range first {
+	}
+}
+
+// fakeStreams is an in-memory RedisStreamsClient
+type fakeStreams struct {
+	mx      sync.Mutex
+	entries map[string][]RedisStreamEntry
+}
+
+func (f *fakeStreams) XAdd(_ context.Context, stream string, _ int64, data []byte) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	id := strconv.Itoa(len(f.entries[stream]) + 1)
+	f.entries[stream] = append(f.entries[stream], RedisStreamEntry{ID: id, Data: data})
+	return nil
+}
+
+func (f *fakeStreams) XRead(ctx context.Context, stream, lastID string, _ time.Duration) ([]RedisStreamEntry, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	entries := f.entries[stream]
+	if lastID == "$" {
+		return nil, nil
+	}
+	last, _ := strconv.Atoi(lastID)
+	return entries[last:], ctx.Err()
+}
+
+func TestRedisStreams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &fakeStreams{entries: make(map[string][]RedisStreamEntry)}
+	broker := NewRedisStreams(client, WithRedisPrefix("test:"))
+	require.NoError(t, broker.Publish(ctx, Message{Topic: "todos", Payload: []byte("1")}))
+	assert.Len(t, client.entries["test:todos"], 1)
+
+	msg, err := decode(client.entries["test:todos"][0].Data)
+	require.NoError(t, err)
+	assert.Equal(t, "todos", msg.Topic)
+}
+
+// fakeReader is a KafkaReader over a channel
+type fakeReader chan []byte
+
+func (f fakeReader) Read(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-f:
+		return data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f fakeReader) Close() error {
+	return nil
+}
+
+type kafkaWriterFunc func(context.Context, string, []byte, []byte) error
+
+func (f kafkaWriterFunc) Write(ctx context.Context, topic string, key, value []byte) error {
+	return f(ctx, topic, key, value)
+}
+
+func TestKafka(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := make(fakeReader, 1)
+	writer := kafkaWriterFunc(func(_ context.Context, topic string, key, value []byte) error {
+		assert.Equal(t, "gql.todos", topic)
+		assert.NotEmpty(t, key)
+		reader <- value
+		return nil
+	})
+	ps := New(NewKafka(writer, func(topic string) (KafkaReader, error) {
+		assert.Equal(t, "gql.todos", topic)
+		return reader, nil
+	}))
+
+	messages, err := ps.Subscribe(ctx, "todos")
+	require.NoError(t, err)
+	require.NoError(t, ps.Publish(ctx, "todos", 2))
+	assert.Equal(t, 2, receive(t, messages))
+}
+
+func TestSubscribeError(t *testing.T) {
+	boom := errors.New("boom")
+	ps := New(NewKafka(nil, func(string) (KafkaReader, error) { return nil, boom }))
+
+	_, err := ps.Subscribe(context.Background(), "todos")
+	assert.Equal(t, boom, err)
+}
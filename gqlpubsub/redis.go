@@ -0,0 +1,101 @@
+package gqlpubsub
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// RedisStreamsClient is the subset of a Redis client used by the Redis streams broker.
+	//
+	// It plugs into go-redis with a thin adapter:
+	//
+	//   type redisStreamsClient struct{ *redis.Client }
+	//
+	//   func (c redisStreamsClient) XAdd(ctx context.Context, stream string, maxLen int64, data []byte) error {
+	//     return c.Client.XAdd(ctx, &redis.XAddArgs{
+	//       Stream: stream, MaxLenApprox: maxLen, Values: map[string]interface{}{"message": data},
+	//     }).Err()
+	//   }
+	//
+	//   func (c redisStreamsClient) XRead(ctx context.Context, stream, lastID string, block time.Duration) ([]gqlpubsub.RedisStreamEntry, error) {
+	//     streams, err := c.Client.XRead(ctx, &redis.XReadArgs{Streams: []string{stream, lastID}, Block: block}).Result()
+	//     if err == redis.Nil {
+	//       return nil, nil
+	//     }
+	//     ...
+	//   }
+	RedisStreamsClient interface {
+		// XAdd appends an entry with the encoded message to a stream, capped to about maxLen entries
+		XAdd(ctx context.Context, stream string, maxLen int64, data []byte) error
+
+		// XRead reads the entries of a stream after lastID, waiting at most block for new entries.
+		// No entry is returned after a timeout.
+		XRead(ctx context.Context, stream, lastID string, block time.Duration) ([]RedisStreamEntry, error)
+	}
+
+	// RedisStreamEntry is an entry read from a Redis stream
+	RedisStreamEntry struct {
+		ID   string
+		Data []byte
+	}
+
+	// RedisStreams is a Broker publishing messages to Redis streams, one stream per topic
+	RedisStreams struct {
+		*redisConfig
+		client RedisStreamsClient
+	}
+)
+
+// NewRedisStreams builds a Broker over Redis streams
+func NewRedisStreams(client RedisStreamsClient, opts ...RedisOption) *RedisStreams {
+	r := &RedisStreams{
+		redisConfig: defaultRedisConfig(),
+		client:      client,
+	}
+	for _, apply := range opts {
+		apply(r.redisConfig)
+	}
+	return r
+}
+
+// Publish implements Broker
+func (r *RedisStreams) Publish(ctx context.Context, msg Message) error {
+	data, err := encode(msg)
+	if err != nil {
+		return err
+	}
+	return r.client.XAdd(ctx, r.redisConfig.prefix+msg.Topic, r.redisConfig.maxLen, data)
+}
+
+// Subscribe implements Broker. Entries are read from the end of the stream at the time of the subscription.
+func (r *RedisStreams) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	ch := make(chan Message)
+	go func() {
+		defer close(ch)
+
+		stream := r.redisConfig.prefix + topic
+		lastID := "$"
+		for ctx.Err() == nil {
+			entries, err := r.client.XRead(ctx, stream, lastID, r.redisConfig.block)
+			if err != nil {
+				return
+			}
+			for _, entry := range entries {
+				lastID = entry.ID
+				msg, err := decode(entry.Data)
+				if err != nil {
+					continue
+				}
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+var _ Broker = &RedisStreams{}
@@ -0,0 +1,87 @@
+package gqlpyroscope
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the profiler extension
+	Option func(*config)
+
+	config struct {
+		rules         []Rule
+		uploader      Uploader
+		minInterval   time.Duration
+		uploadTimeout time.Duration
+		onError       func(error)
+		opLabel       gqllabel.OperationLabeler
+		fieldLabels   bool
+		clock         func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		minInterval:   time.Minute,
+		uploadTimeout: 30 * time.Second,
+		opLabel:       gqllabel.OperationName,
+		clock:         graphql.Now,
+	}
+}
+
+// WithRules selects the operations profiled with a dedicated session. By default, no session is started.
+func WithRules(rules ...Rule) Option {
+	return func(c *config) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// WithUploader sets the Uploader of the profiles captured during sessions. Sessions are only started with an uploader.
+func WithUploader(uploader Uploader) Option {
+	return func(c *config) {
+		c.uploader = uploader
+	}
+}
+
+// WithMinInterval sets the minimum interval between the start of two sessions, to bound the overhead of profiling.
+// The default is 1m.
+func WithMinInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.minInterval = interval
+	}
+}
+
+// WithUploadTimeout sets the timeout of uploads. The default is 30s.
+func WithUploadTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.uploadTimeout = timeout
+	}
+}
+
+// WithErrorHandler sets a handler for upload errors. By default, errors are ignored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithOperationLabel sets the function producing the operation label of profiles. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this label.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
+
+// WithFieldLabels tags resolvers with their field, e.g. "Query.user", so that profiles may be sliced by field.
+// This is disabled by default, since labeling adds some overhead to every resolver.
+func WithFieldLabels(enabled bool) Option {
+	return func(c *config) {
+		c.fieldLabels = enabled
+	}
+}
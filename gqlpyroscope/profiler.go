@@ -0,0 +1,220 @@
+// Package gqlpyroscope provides a gqlgen extension integrating GraphQL operations with the Pyroscope
+// continuous profiler.
+//
+// Operations are tagged with their name, as a profiler label understood by Pyroscope, so that continuous
+// profiles may be sliced by operation. When the operation is traced, the span ID is set as the "span_id"
+// label, which links profiles to spans. With WithFieldLabels, resolvers are tagged with their field as well.
+//
+// In addition, profiling sessions may be started around expensive operations selected by rules: a CPU profile
+// is captured for the duration of the operation, then uploaded to Pyroscope. The ID of the profile is added
+// to the attributes of the span of the operation.
+//
+// Example:
+//
+//   profiler := gqlpyroscope.New(
+//     gqlpyroscope.WithRules(gqlpyroscope.OperationNames("search", "report")),
+//     gqlpyroscope.WithUploader(gqlpyroscope.NewHTTPUploader("http://pyroscope:4040", "my-service")),
+//   )
+//   srv.Use(profiler)
+//
+// Notice that the Go runtime supports only one CPU profile at a time: sessions are skipped while another CPU
+// profile is running, e.g. when the Pyroscope agent collects continuous profiles.
+package gqlpyroscope
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+)
+
+const extensionName = "Pyroscope"
+
+// Labels set on profiles
+const (
+	LabelOperation = "graphql_operation"
+	LabelField     = "graphql_field"
+	LabelSpanID    = "span_id"
+	LabelProfileID = "profile_id"
+)
+
+// AttributeProfileID is the span attribute linking a span to the profile captured during the operation
+const AttributeProfileID = "pyroscope.profile.id"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Profiler{}
+
+type (
+	// Rule selects the operations to profile
+	Rule func(*graphql.OperationContext) bool
+
+	// Profiler is a gqlgen extension tagging profiles with GraphQL operations, and profiling selected operations
+	Profiler struct {
+		*config
+
+		mx          sync.Mutex
+		running     bool
+		lastSession time.Time
+	}
+
+	// session is a CPU profiling session
+	session struct {
+		id    string
+		start time.Time
+		buf   bytes.Buffer
+	}
+)
+
+// OperationNames selects operations by name
+func OperationNames(names ...string) Rule {
+	selected := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		selected[name] = struct{}{}
+	}
+	return func(oc *graphql.OperationContext) bool {
+		if oc.Operation == nil {
+			return false
+		}
+		_, ok := selected[oc.Operation.Name]
+		return ok
+	}
+}
+
+// New Pyroscope Profiler extension
+func New(opts ...Option) *Profiler {
+	p := &Profiler{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(p.config)
+	}
+	return p
+}
+
+// ExtensionName yields the extension name: "Pyroscope"
+func (*Profiler) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Profiler) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (p *Profiler) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) (resp *graphql.Response) {
+	oc := graphql.GetOperationContext(ctx)
+	operation := p.config.opLabel(oc)
+
+	labels := []string{LabelOperation, operation}
+	span := trace.FromContext(ctx)
+	if span != nil {
+		labels = append(labels, LabelSpanID, span.SpanContext().SpanID.String())
+	}
+
+	s := p.startSession(oc)
+	if s != nil {
+		labels = append(labels, LabelProfileID, s.id)
+		if span != nil {
+			span.AddAttributes(trace.StringAttribute(AttributeProfileID, s.id))
+		}
+	}
+
+	pprof.Do(ctx, pprof.Labels(labels...), func(ctx context.Context) {
+		resp = next(ctx)
+	})
+
+	if s != nil {
+		p.stopSession(s, operation)
+	}
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor. Resolvers are tagged with their field, e.g. "Query.user",
+// when field labels are enabled.
+func (p *Profiler) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+	if !p.config.fieldLabels {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsMethod {
+		return next(ctx)
+	}
+
+	pprof.Do(ctx, pprof.Labels(LabelField, fc.Object+"."+fc.Field.Name), func(ctx context.Context) {
+		res, err = next(ctx)
+	})
+	return res, err
+}
+
+// startSession starts a CPU profile for a selected operation, unless another one is running
+func (p *Profiler) startSession(oc *graphql.OperationContext) *session {
+	if p.config.uploader == nil || !p.selected(oc) {
+		return nil
+	}
+
+	now := p.config.clock()
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.running || now.Sub(p.lastSession) < p.config.minInterval {
+		return nil
+	}
+
+	s := &session{id: newID(), start: now}
+	if err := pprof.StartCPUProfile(&s.buf); err != nil {
+		// another CPU profile is running
+		return nil
+	}
+	p.running = true
+	p.lastSession = now
+	return s
+}
+
+// stopSession stops the CPU profile, and uploads it in the background
+func (p *Profiler) stopSession(s *session, operation string) {
+	pprof.StopCPUProfile()
+	end := p.config.clock()
+
+	p.mx.Lock()
+	p.running = false
+	p.mx.Unlock()
+
+	profile := Profile{
+		ID:        s.id,
+		Operation: operation,
+		Start:     s.start,
+		End:       end,
+		Data:      s.buf.Bytes(),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), p.config.uploadTimeout)
+		defer cancel()
+		if err := p.config.uploader.Upload(ctx, profile); err != nil && p.config.onError != nil {
+			p.config.onError(err)
+		}
+	}()
+}
+
+func (p *Profiler) selected(oc *graphql.OperationContext) bool {
+	for _, rule := range p.config.rules {
+		if rule(oc) {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,155 @@
+package gqlpyroscope
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+)
+
+func operationContext(name string) context.Context {
+	return graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: name,
+		Operation:     &ast.OperationDefinition{Name: name, Operation: ast.Query},
+	})
+}
+
+// labels yields the profiler labels set on the context
+func labels(ctx context.Context) map[string]string {
+	set := make(map[string]string)
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		set[key] = value
+		return true
+	})
+	return set
+}
+
+func TestOperationLabels(t *testing.T) {
+	p := New()
+	require.Equal(t, extensionName, p.ExtensionName())
+	require.NoError(t, p.Validate(nil))
+
+	for _, name := range []string{"search", "report"} {
+		var got map[string]string
+		p.InterceptResponse(operationContext(name), func(ctx context.Context) *graphql.Response {
+			got = labels(ctx)
+			return &graphql.Response{}
+		})
+		assert.Equal(t, map[string]string{LabelOperation: name}, got)
+	}
+
+	t.Run("span", func(t *testing.T) {
+		ctx, span := trace.StartSpan(operationContext("search"), "search", trace.WithSampler(trace.AlwaysSample()))
+		defer span.End()
+
+		var got map[string]string
+		p.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			got = labels(ctx)
+			return &graphql.Response{}
+		})
+		assert.Equal(t, map[string]string{
+			LabelOperation: "search",
+			LabelSpanID:    span.SpanContext().SpanID.String(),
+		}, got)
+	})
+}
+
+func TestFieldLabels(t *testing.T) {
+	fieldContext := func(ctx context.Context, object, field string, isMethod bool) context.Context {
+		return graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object:   object,
+			Field:    graphql.CollectedField{Field: &ast.Field{Name: field}},
+			IsMethod: isMethod,
+		})
+	}
+	resolve := func(p *Profiler, ctx context.Context) map[string]string {
+		var got map[string]string
+		res, err := p.InterceptField(ctx, func(ctx context.Context) (interface{}, error) {
+			got = labels(ctx)
+			return "resolved", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "resolved", res)
+		return got
+	}
+
+	p := New(WithFieldLabels(true))
+	p.InterceptResponse(operationContext("search"), func(ctx context.Context) *graphql.Response {
+		assert.Equal(t, map[string]string{
+			LabelOperation: "search",
+			LabelField:     "Query.search",
+		}, resolve(p, fieldContext(ctx, "Query", "search", true)))
+
+		assert.Equal(t, map[string]string{
+			LabelOperation: "search",
+			LabelField:     "Result.author",
+		}, resolve(p, fieldContext(ctx, "Result", "author", true)), "nested resolvers are tagged with their own field")
+
+		assert.Equal(t, map[string]string{
+			LabelOperation: "search",
+		}, resolve(p, fieldContext(ctx, "Result", "title", false)), "trivial fields are not tagged")
+		return &graphql.Response{}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		p := New()
+		p.InterceptResponse(operationContext("search"), func(ctx context.Context) *graphql.Response {
+			assert.Equal(t, map[string]string{
+				LabelOperation: "search",
+			}, resolve(p, fieldContext(ctx, "Query", "search", true)))
+			return &graphql.Response{}
+		})
+	})
+}
+
+func TestSession(t *testing.T) {
+	profiles := make(chan Profile, 10)
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := New(
+		WithRules(OperationNames("report")),
+		WithUploader(UploaderFunc(func(_ context.Context, profile Profile) error {
+			profiles <- profile
+			return nil
+		})),
+		WithMinInterval(time.Minute),
+	)
+	p.config.clock = func() time.Time { return now }
+
+	execute := func(name string) map[string]string {
+		var got map[string]string
+		p.InterceptResponse(operationContext(name), func(ctx context.Context) *graphql.Response {
+			got = labels(ctx)
+			return &graphql.Response{}
+		})
+		return got
+	}
+
+	t.Run("unselected operation", func(t *testing.T) {
+		assert.NotContains(t, execute("search"), LabelProfileID)
+	})
+
+	t.Run("selected operation", func(t *testing.T) {
+		got := execute("report")
+		require.Contains(t, got, LabelProfileID)
+
+		profile := <-profiles
+		assert.Equal(t, got[LabelProfileID], profile.ID, "the profile is tagged with the ID of the session")
+		assert.Equal(t, "report", profile.Operation)
+		assert.NotEmpty(t, profile.Data)
+	})
+
+	t.Run("min interval", func(t *testing.T) {
+		now = now.Add(time.Second)
+		assert.NotContains(t, execute("report"), LabelProfileID)
+
+		now = now.Add(time.Minute)
+		assert.Contains(t, execute("report"), LabelProfileID)
+		<-profiles
+	})
+}
@@ -0,0 +1,151 @@
+package gqlpyroscope
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Profile captured during a profiling session
+	Profile struct {
+		ID        string
+		Operation string
+		Start     time.Time
+		End       time.Time
+
+		// CPU profile, in the pprof format
+		Data []byte
+	}
+
+	// Uploader ships captured profiles, e.g. to a Pyroscope server
+	Uploader interface {
+		Upload(context.Context, Profile) error
+	}
+
+	// UploaderFunc is a function implementing Uploader
+	UploaderFunc func(context.Context, Profile) error
+
+	// HTTPUploader uploads profiles to the ingestion API of a Pyroscope server
+	HTTPUploader struct {
+		// Endpoint of the Pyroscope server, e.g. "http://pyroscope:4040"
+		Endpoint string
+
+		// ApplicationName under which profiles are ingested, e.g. "my-service"
+		ApplicationName string
+
+		// Tags added to all profiles, e.g. the region or the version of the service
+		Tags map[string]string
+
+		// AuthToken, if any, is sent as a bearer token
+		AuthToken string
+
+		// AuthTokenFunc, if any, yields the bearer token for each upload, e.g. the value of a rotated secret.
+		// This takes precedence over AuthToken.
+		AuthTokenFunc func() string
+
+		// Client is the HTTP client used to upload profiles. The default is http.DefaultClient.
+		Client *http.Client
+	}
+)
+
+// Upload implements Uploader
+func (f UploaderFunc) Upload(ctx context.Context, profile Profile) error {
+	return f(ctx, profile)
+}
+
+// NewHTTPUploader builds an Uploader to a Pyroscope server
+func NewHTTPUploader(endpoint, applicationName string) *HTTPUploader {
+	return &HTTPUploader{
+		Endpoint:        endpoint,
+		ApplicationName: applicationName,
+	}
+}
+
+// Upload implements Uploader. Profiles are tagged with the operation and the profile ID.
+func (u *HTTPUploader) Upload(ctx context.Context, profile Profile) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(profile.Data); err != nil {
+		return err
+	}
+	if err = mw.Close(); err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		LabelOperation: profile.Operation,
+		LabelProfileID: profile.ID,
+	}
+	for k, v := range u.Tags {
+		tags[k] = v
+	}
+
+	query := url.Values{}
+	query.Set("name", u.ApplicationName+".cpu"+formatTags(tags))
+	query.Set("from", strconv.FormatInt(profile.Start.Unix(), 10))
+	query.Set("until", strconv.FormatInt(profile.End.Unix(), 10))
+	query.Set("format", "pprof")
+	query.Set("spyName", "gospy")
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(u.Endpoint, "/")+"/ingest?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	token := u.AuthToken
+	if u.AuthTokenFunc != nil {
+		token = u.AuthTokenFunc()
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		text, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("pyroscope upload failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(text))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// formatTags formats tags as expected by Pyroscope, e.g. "{env=prod,region=eu}"
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+tags[k])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,48 @@
+package gqlqueue
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// ByOperationName classifies operations by name
+func ByOperationName(tiers map[string]string) Classifier {
+	return func(_ context.Context, oc *graphql.OperationContext) string {
+		return tiers[oc.OperationName]
+	}
+}
+
+// ByClient classifies operations by client, as identified from the context (e.g. from an API key or a header)
+func ByClient(client func(context.Context) string, tiers map[string]string) Classifier {
+	return func(ctx context.Context, _ *graphql.OperationContext) string {
+		return tiers[client(ctx)]
+	}
+}
+
+// ByDirective classifies operations by a directive set on the operation, with the tier as a string argument.
+//
+// Example, with ByDirective("priority", "tier"):
+//
+//   query monthlyReport @priority(tier: "analytics") { ... }
+func ByDirective(directive, argument string) Classifier {
+	return func(_ context.Context, oc *graphql.OperationContext) string {
+		if oc.Operation == nil {
+			return ""
+		}
+		d := oc.Operation.Directives.ForName(directive)
+		if d == nil {
+			return ""
+		}
+		arg := d.Arguments.ForName(argument)
+		if arg == nil || arg.Value == nil {
+			return ""
+		}
+		value, err := arg.Value.Value(oc.Variables)
+		if err != nil {
+			return ""
+		}
+		name, _ := value.(string)
+		return name
+	}
+}
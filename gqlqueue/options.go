@@ -0,0 +1,79 @@
+package gqlqueue
+
+import (
+	"time"
+)
+
+type (
+	// Option for the priority queue extension
+	Option func(*config)
+
+	config struct {
+		tiers       []tierConfig
+		classifiers []Classifier
+		defaultTier string
+		retryAfter  time.Duration
+	}
+
+	tierConfig struct {
+		name    string
+		workers int
+		queue   int
+		maxWait time.Duration
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		retryAfter: time.Second,
+	}
+}
+
+// WithTier declares a priority tier, with a number of workers executing operations concurrently
+// and the capacity of its queue of pending operations.
+func WithTier(name string, workers, queue int) Option {
+	return func(c *config) {
+		if workers < 1 {
+			workers = 1
+		}
+		c.tiers = append(c.tiers, tierConfig{name: name, workers: workers, queue: queue})
+	}
+}
+
+// WithMaxWait sets the maximum time an operation may wait for a worker of a declared tier. By default, operations
+// wait until their context is done.
+func WithMaxWait(name string, maxWait time.Duration) Option {
+	return func(c *config) {
+		for i := range c.tiers {
+			if c.tiers[i].name == name {
+				c.tiers[i].maxWait = maxWait
+			}
+		}
+	}
+}
+
+// WithClassifier adds classifiers to assign operations to tiers. Classifiers are evaluated in order,
+// and the first non-empty tier wins.
+func WithClassifier(classifiers ...Classifier) Option {
+	return func(c *config) {
+		c.classifiers = append(c.classifiers, classifiers...)
+	}
+}
+
+// WithDefaultTier sets the tier of operations not classified otherwise.
+// By default, such operations are executed immediately, without any limit.
+func WithDefaultTier(name string) Option {
+	return func(c *config) {
+		c.defaultTier = name
+	}
+}
+
+// WithRetryAfter sets the delay after which clients may retry operations rejected by a full or timed out queue.
+// It is reported, rounded up to seconds, as the "retryAfter" extension of the error. The default is one second.
+func WithRetryAfter(retryAfter time.Duration) Option {
+	return func(c *config) {
+		if retryAfter >= time.Second {
+			c.retryAfter = retryAfter
+		}
+	}
+}
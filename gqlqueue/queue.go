@@ -0,0 +1,193 @@
+// Package gqlqueue provides a gqlgen extension to classify operations into priority tiers,
+// and execute them through bounded worker pools per tier.
+//
+// This prevents expensive queries, such as analytics, from starving interactive traffic.
+//
+// Example:
+//
+//   q := gqlqueue.New(
+//     gqlqueue.WithTier("interactive", 100, 1000),
+//     gqlqueue.WithTier("analytics", 4, 20),
+//     gqlqueue.WithClassifier(gqlqueue.ByOperationName(map[string]string{"monthlyReport": "analytics"})),
+//     gqlqueue.WithDefaultTier("interactive"),
+//   )
+package gqlqueue
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlcode"
+)
+
+const extensionName = "PriorityQueue"
+
+// CodeOverloaded is the code of errors for operations rejected by a full or timed out queue
+const CodeOverloaded = gqlcode.ServiceOverloaded
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Queue{}
+
+var (
+	// ErrQueueFull is returned when the queue of a tier is full
+	ErrQueueFull = errors.New("too many pending operations")
+
+	// ErrQueueTimeout is returned when an operation waited too long for a worker
+	ErrQueueTimeout = errors.New("timed out waiting for execution")
+)
+
+type (
+	// Classifier assigns an operation to a priority tier. An empty tier means that the operation is not classified.
+	Classifier func(context.Context, *graphql.OperationContext) string
+
+	// Queue is a gqlgen extension scheduling operations through bounded worker pools per priority tier.
+	//
+	// Operations of a tier beyond its number of workers wait in the queue of that tier.
+	// Operations exceeding the queue capacity or waiting longer than the maximum wait of the tier are rejected,
+	// with the code SERVICE_OVERLOADED and a "retryAfter" hint (see gqlstatus to set the matching HTTP headers).
+	// Subscriptions are never queued.
+	Queue struct {
+		*config
+		tiers map[string]*tier
+	}
+
+	tier struct {
+		slots    chan struct{}
+		waiting  int32
+		maxQueue int32
+		maxWait  time.Duration
+	}
+)
+
+// New Queue
+func New(opts ...Option) *Queue {
+	q := &Queue{
+		config: defaultConfig(),
+		tiers:  make(map[string]*tier),
+	}
+	for _, apply := range opts {
+		apply(q.config)
+	}
+	for _, t := range q.config.tiers {
+		q.tiers[t.name] = &tier{
+			slots:    make(chan struct{}, t.workers),
+			maxQueue: int32(t.queue),
+			maxWait:  t.maxWait,
+		}
+	}
+	return q
+}
+
+// ExtensionName yields the extension name: "PriorityQueue"
+func (Queue) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the tiers referred to by the configuration
+func (q Queue) Validate(schema graphql.ExecutableSchema) error {
+	if q.config.defaultTier != "" {
+		if _, ok := q.tiers[q.config.defaultTier]; !ok {
+			return errors.New("gqlqueue: the default tier is not declared: " + q.config.defaultTier)
+		}
+	}
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor.
+//
+// An operation is admitted once, when its first response is pulled, and releases its worker when this response
+// is yielded: the websocket transport pulls responses until it gets nil, which must not queue the operation again.
+func (q Queue) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	t, ok := q.tiers[q.classify(ctx, oc)]
+	if !ok {
+		return next(ctx)
+	}
+
+	handler := next(ctx)
+	var admitted bool
+	return func(ctx context.Context) *graphql.Response {
+		if admitted {
+			return handler(ctx)
+		}
+		admitted = true
+
+		if err := t.acquire(ctx); err != nil {
+			handler = func(context.Context) *graphql.Response { return nil }
+			if err != ErrQueueFull && err != ErrQueueTimeout {
+				return graphql.ErrorResponse(ctx, "%v", err)
+			}
+			return q.reject(err)
+		}
+		defer t.release()
+
+		return handler(ctx)
+	}
+}
+
+// reject an operation shed by a tier, with a hint of the number of seconds after which clients may retry
+func (q Queue) reject(err error) *graphql.Response {
+	return &graphql.Response{Errors: gqlerror.List{{
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":       CodeOverloaded,
+			"retryAfter": int(math.Ceil(q.config.retryAfter.Seconds())),
+		},
+	}}}
+}
+
+func (q Queue) classify(ctx context.Context, oc *graphql.OperationContext) string {
+	for _, classify := range q.config.classifiers {
+		if name := classify(ctx, oc); name != "" {
+			return name
+		}
+	}
+	return q.config.defaultTier
+}
+
+func (t *tier) acquire(ctx context.Context) error {
+	select {
+	case t.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if atomic.AddInt32(&t.waiting, 1) > t.maxQueue {
+		atomic.AddInt32(&t.waiting, -1)
+		return ErrQueueFull
+	}
+	defer atomic.AddInt32(&t.waiting, -1)
+
+	var timeout <-chan time.Time
+	if t.maxWait > 0 {
+		timer := time.NewTimer(t.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return ErrQueueTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *tier) release() {
+	<-t.slots
+}
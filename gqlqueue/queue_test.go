@@ -0,0 +1,89 @@
+package gqlqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestQueue(t *testing.T) {
+	q := New(
+		WithTier("analytics", 1, 1),
+		WithMaxWait("analytics", 10*time.Millisecond),
+		WithClassifier(ByOperationName(map[string]string{"report": "analytics"})),
+	)
+	require.NoError(t, q.Validate(&graphql.ExecutableSchemaMock{}))
+
+	report := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "report",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query, Name: "report"},
+	})
+	other := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "other",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query, Name: "other"},
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan *graphql.Response)
+	go func() {
+		done <- q.InterceptOperation(report, once(func(_ context.Context) *graphql.Response {
+			close(started)
+			<-release
+			return &graphql.Response{}
+		}))(report)
+	}()
+	<-started
+
+	ok := once(func(_ context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+
+	// unclassified operations are not limited
+	resp := q.InterceptOperation(other, ok)(other)
+	assert.Empty(t, resp.Errors)
+
+	// the only worker is busy: the pending operation times out, with a single response
+	rejected := q.InterceptOperation(report, ok)
+	resp = rejected(report)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, ErrQueueTimeout.Error())
+	assert.Equal(t, CodeOverloaded, resp.Errors[0].Extensions["code"])
+	assert.Equal(t, 1, resp.Errors[0].Extensions["retryAfter"])
+	assert.Nil(t, rejected(report))
+
+	close(release)
+	resp = <-done
+	assert.Empty(t, resp.Errors)
+
+	// the websocket transport pulls responses until it gets nil: operations are admitted once
+	handler := q.InterceptOperation(report, ok)
+	resp = handler(report)
+	assert.Empty(t, resp.Errors)
+	assert.Nil(t, handler(report))
+	assert.Empty(t, q.tiers["analytics"].slots, "the worker is released")
+}
+
+// once yields an operation handler executing a response handler once, like queries and mutations
+func once(h graphql.ResponseHandler) graphql.OperationHandler {
+	return func(context.Context) graphql.ResponseHandler {
+		var done bool
+		return func(ctx context.Context) *graphql.Response {
+			if done {
+				return nil
+			}
+			done = true
+			return h(ctx)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	q := New(WithDefaultTier("missing"))
+	assert.Error(t, q.Validate(&graphql.ExecutableSchemaMock{}))
+}
@@ -0,0 +1,39 @@
+package gqlredact
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(RedactedFieldCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(RedactedFieldCountView)
+}
+
+var (
+	// RedactedFieldCount tracks a count of fields redacted from responses
+	RedactedFieldCount = stats.Int64(
+		"gql/server/redacted_field_count",
+		"Number of GraphQL fields redacted from responses",
+		stats.UnitDimensionless)
+
+	// RedactedFieldCountView reports a count of redacted fields tagged by operation name and field
+	RedactedFieldCountView = &view.View{
+		Name:        "gql/server/redacted_field_count",
+		Description: "Count of redacted GraphQL fields by operation and field",
+		Measure:     RedactedFieldCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, metrics.TagField},
+	}
+)
@@ -0,0 +1,44 @@
+package gqlredact
+
+import (
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the redacting extension
+	Option func(*config)
+
+	config struct {
+		fields  map[string]struct{}
+		opLabel gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		opLabel: gqllabel.OperationName,
+	}
+}
+
+// WithFields restricts the policy to these fields, identified as "Object.field", e.g. "User.email".
+//
+// By default, the policy is evaluated for all fields.
+func WithFields(fields ...string) Option {
+	return func(c *config) {
+		if c.fields == nil {
+			c.fields = make(map[string]struct{}, len(fields))
+		}
+		for _, field := range fields {
+			c.fields[field] = struct{}{}
+		}
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
@@ -0,0 +1,226 @@
+// Package gqlredact provides a gqlgen extension removing from responses the fields a caller is not authorized to see.
+//
+// A policy is evaluated after the resolution of each field, against the path of the field in the response,
+// its arguments, its resolved value and the context of the request (e.g. the claims of the caller). Denied fields
+// are either set to null or removed from the response.
+//
+// This complements authorization directives checked before execution: a policy sees the resolved values,
+// e.g. to hide the email of users other than the caller.
+//
+// Example:
+//
+//   srv.Use(gqlredact.New(func(ctx context.Context, field gqlredact.Field) gqlredact.Decision {
+//     if field.Object == "User" && field.Name == "email" {
+//       if user, ok := field.Parent.(*model.User); ok && user.ID != auth.ForContext(ctx).UserID {
+//         return gqlredact.Nullify
+//       }
+//     }
+//     return gqlredact.Allow
+//   }, gqlredact.WithFields("User.email")))
+//
+// Notice that clients must be prepared to receive nulls for denied non-nullable fields, or to miss removed fields.
+//
+// Denied nullable fields resolve to null right away, so that their selections are not resolved at all.
+// When the denied fields cannot be removed from the response, the data of the response is withheld altogether.
+package gqlredact
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "Redact"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Redact{}
+
+// Decisions of a policy
+const (
+	// Allow the field in the response
+	Allow Decision = iota
+
+	// Nullify the field in the response
+	Nullify
+
+	// Remove the field from the response
+	Remove
+)
+
+type (
+	// Decision of a policy about a field
+	Decision uint8
+
+	// Field submitted to a policy
+	Field struct {
+		// Object type and name of the field, e.g. "User" and "email"
+		Object string
+		Name   string
+
+		// Path of the field in the response
+		Path ast.Path
+
+		Args map[string]interface{}
+
+		// Parent object and resolved value of the field
+		Parent interface{}
+		Result interface{}
+	}
+
+	// Policy decides whether a field is kept in the response
+	Policy func(context.Context, Field) Decision
+
+	// Redaction of a field from the response
+	Redaction struct {
+		Path     ast.Path
+		Decision Decision
+
+		// redacted field, as "Object.field"
+		field string
+	}
+
+	// Redact is a gqlgen extension redacting the fields of responses denied by a policy
+	Redact struct {
+		*config
+		policy Policy
+	}
+
+	// collector gathers the redactions of a response
+	collector struct {
+		mx         sync.Mutex
+		redactions []Redaction
+	}
+
+	collectorKey struct{}
+)
+
+// New redacting extension, with a policy
+func New(policy Policy, opts ...Option) *Redact {
+	r := &Redact{
+		config: defaultConfig(),
+		policy: policy,
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// ExtensionName yields the extension name: "Redact"
+func (*Redact) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Redact) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor, removing or setting to null the denied fields.
+//
+// When the denied fields cannot be redacted, no data is returned.
+func (r *Redact) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	c := &collector{}
+	resp := next(context.WithValue(ctx, collectorKey{}, c))
+	if resp == nil || len(c.redactions) == 0 {
+		return resp
+	}
+
+	data, err := redact(resp.Data, c.redactions)
+	if err != nil {
+		resp.Data = json.RawMessage(`null`)
+		resp.Errors = append(resp.Errors, gqlerror.Errorf("response withheld: denied fields could not be redacted"))
+	} else {
+		resp.Data = data
+	}
+
+	label := r.config.opLabel(graphql.GetOperationContext(ctx))
+	for _, redaction := range c.redactions {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{
+			tag.Upsert(metrics.TagOperation, label),
+			tag.Upsert(metrics.TagField, redaction.field),
+		}, RedactedFieldCount.M(1))
+	}
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor, submitting resolved fields to the policy
+func (r *Redact) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return res, err
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil {
+		return res, err
+	}
+	coordinate := fc.Object + "." + fc.Field.Name
+	if len(r.config.fields) > 0 {
+		if _, ok := r.config.fields[coordinate]; !ok {
+			return res, err
+		}
+	}
+
+	field := Field{
+		Object: fc.Object,
+		Name:   fc.Field.Name,
+		Path:   fc.Path(),
+		Args:   fc.Args,
+		Result: res,
+	}
+	if fc.Parent != nil {
+		field.Parent = fc.Parent.Result
+	}
+	decision := r.policy(ctx, field)
+	if decision == Allow {
+		return res, err
+	}
+
+	c.add(Redaction{
+		Path:     field.Path,
+		Decision: decision,
+		field:    coordinate,
+	})
+	if fc.Field.Definition != nil && fc.Field.Definition.Type != nil && !fc.Field.Definition.Type.NonNull {
+		return nil, nil
+	}
+	return res, err
+}
+
+// Redactions yields the fields redacted so far from the response of a context
+func Redactions(ctx context.Context) []Redaction {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return nil
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return append([]Redaction(nil), c.redactions...)
+}
+
+// Field yields the redacted field, as "Object.field"
+func (r Redaction) Field() string {
+	return r.field
+}
+
+func (c *collector) add(redaction Redaction) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.redactions = append(c.redactions, redaction)
+}
@@ -0,0 +1,134 @@
+package gqlredact
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/internal/fieldtest"
+)
+
+const testSchema = `
+type User {
+  id: ID!
+  email: String
+  phone: String!
+  name: String!
+}
+
+type Query {
+  user: User
+}
+`
+
+type user struct {
+	ID string
+}
+
+func TestRedact(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	var submitted []Field
+	r := New(func(ctx context.Context, field Field) Decision {
+		submitted = append(submitted, field)
+		switch field.Name {
+		case "email":
+			return Nullify
+		case "phone":
+			return Remove
+		default:
+			return Allow
+		}
+	}, WithFields("User.email", "User.phone", "User.name"))
+	require.Equal(t, extensionName, r.ExtensionName())
+
+	parent := &user{ID: "42"}
+	resolve := func(ctx context.Context, field string, res interface{}) (interface{}, error) {
+		userField := fieldtest.Field(nil, "Query", "user")
+		userField.Result = parent
+		ctx = fieldtest.Context(ctx, userField, fieldtest.Field(schema, "User", field))
+		return r.InterceptField(ctx, func(context.Context) (interface{}, error) {
+			return res, nil
+		})
+	}
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "user"})
+	resp := r.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		res, err := resolve(ctx, "email", "a@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, res, "denied nullable fields resolve to null")
+
+		res, err = resolve(ctx, "phone", "555")
+		assert.NoError(t, err)
+		assert.Equal(t, "555", res, "denied non-nullable fields are redacted from the response")
+
+		res, err = resolve(ctx, "name", "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", res)
+
+		res, err = resolve(ctx, "id", "42")
+		assert.NoError(t, err)
+		assert.Equal(t, "42", res)
+
+		assert.Len(t, Redactions(ctx), 2)
+		return &graphql.Response{
+			Data: json.RawMessage(`{"user":{"id":"42","email":null,"phone":"555","name":"alice"}}`),
+		}
+	})
+
+	assert.Equal(t, `{"user":{"id":"42","email":null,"name":"alice"}}`, string(resp.Data))
+
+	require.Len(t, submitted, 3, "the policy is restricted to some fields")
+	assert.Equal(t, Field{
+		Object: "User",
+		Name:   "email",
+		Path:   ast.Path{ast.PathName("user"), ast.PathName("email")},
+		Parent: parent,
+		Result: "a@example.com",
+	}, submitted[0])
+}
+
+func TestRedactMalformed(t *testing.T) {
+	r := New(func(context.Context, Field) Decision { return Remove })
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "user"})
+	resp := r.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fc := &graphql.FieldContext{
+			Object: "User",
+			Field:  graphql.CollectedField{Field: &ast.Field{Name: "email", Alias: "email"}},
+		}
+		_, err := r.InterceptField(graphql.WithFieldContext(ctx, fc), func(context.Context) (interface{}, error) {
+			return "a@example.com", nil
+		})
+		require.NoError(t, err)
+
+		return &graphql.Response{
+			Data:   json.RawMessage(`{"email":"a@example.com"`),
+			Errors: gqlerror.List{gqlerror.Errorf("partial")},
+		}
+	})
+
+	assert.JSONEq(t, `null`, string(resp.Data), "responses which cannot be redacted are withheld")
+	require.Len(t, resp.Errors, 2)
+	assert.Equal(t, "partial", resp.Errors[0].Message)
+	assert.Contains(t, resp.Errors[1].Message, "response withheld")
+}
+
+func TestRedactJSON(t *testing.T) {
+	data, err := redact([]byte(`{"b":[{"x":1,"y":2},{"x":2.50}],"a":"z"}`), []Redaction{
+		{Path: ast.Path{ast.PathName("b"), ast.PathIndex(0), ast.PathName("x")}, Decision: Remove},
+		{Path: ast.Path{ast.PathName("b"), ast.PathIndex(1), ast.PathName("x")}, Decision: Nullify},
+		{Path: ast.Path{ast.PathName("c")}, Decision: Remove},
+		{Path: ast.Path{ast.PathName("b"), ast.PathIndex(5)}, Decision: Nullify},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":[{"y":2},{"x":null}],"a":"z"}`, string(data))
+}
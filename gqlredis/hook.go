@@ -0,0 +1,132 @@
+// Package gqlredis bridges the instrumentation of Redis commands with gqlgen: commands issued by resolvers
+// produce opencensus spans tagged with the GraphQL operation and field path, following the same naming
+// conventions as gqlopencensus.
+//
+// The Hook is not tied to a particular Redis client. It plugs into go-redis with a thin adapter:
+//
+//   type redisHook struct{ *gqlredis.Hook }
+//
+//   func (h redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+//     return h.Before(ctx, cmd), nil
+//   }
+//
+//   func (h redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+//     h.After(ctx, cmd)
+//     return nil
+//   }
+//
+//   func (h redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+//     return h.BeforePipeline(ctx, gqlredis.Cmds(len(cmds), func(i int) gqlredis.Cmd { return cmds[i] })...), nil
+//   }
+//
+//   func (h redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+//     h.AfterPipeline(ctx, gqlredis.Cmds(len(cmds), func(i int) gqlredis.Cmd { return cmds[i] })...)
+//     return nil
+//   }
+//
+//   client.AddHook(redisHook{gqlredis.NewHook()})
+package gqlredis
+
+import (
+	"context"
+	"strings"
+
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+)
+
+type (
+	// Cmd is a Redis command, as exposed by Redis clients (e.g. redis.Cmder from go-redis)
+	Cmd interface {
+		Name() string
+		Err() error
+	}
+
+	// Hook produces spans for Redis commands
+	Hook struct {
+		*config
+	}
+
+	spanKey struct{}
+)
+
+// Cmds converts a slice of client-specific commands into a slice of Cmd, using the accessor at(i)
+func Cmds(n int, at func(int) Cmd) []Cmd {
+	cmds := make([]Cmd, n)
+	for i := range cmds {
+		cmds[i] = at(i)
+	}
+	return cmds
+}
+
+// NewHook builds a Redis hook
+func NewHook(opts ...Option) *Hook {
+	h := &Hook{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(h.config)
+	}
+	return h
+}
+
+// Before starts a span for a command. The returned context must be passed to After.
+func (h Hook) Before(ctx context.Context, cmd Cmd) context.Context {
+	name := strings.ToLower(cmd.Name())
+	return h.start(ctx, "redis:"+name, gqlattr.KeyValue{Key: "redis.cmd", Value: name})
+}
+
+// After ends the span started by Before
+func (h Hook) After(ctx context.Context, cmd Cmd) {
+	h.end(ctx, cmd.Err())
+}
+
+// BeforePipeline starts a span for a pipeline of commands. The returned context must be passed to AfterPipeline.
+func (h Hook) BeforePipeline(ctx context.Context, cmds ...Cmd) context.Context {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, strings.ToLower(cmd.Name()))
+	}
+	return h.start(ctx, "redis:pipeline",
+		gqlattr.KeyValue{Key: "redis.cmd", Value: strings.Join(names, " ")},
+		gqlattr.KeyValue{Key: "redis.pipeline_length", Value: int64(len(cmds))},
+	)
+}
+
+// AfterPipeline ends the span started by BeforePipeline
+func (h Hook) AfterPipeline(ctx context.Context, cmds ...Cmd) {
+	var err error
+	for _, cmd := range cmds {
+		if err = cmd.Err(); err != nil {
+			break
+		}
+	}
+	h.end(ctx, err)
+}
+
+func (h Hook) start(ctx context.Context, name string, attrs ...gqlattr.KeyValue) context.Context {
+	ctx, span := trace.StartSpan(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	if span.IsRecordingEvents() {
+		attrs = append(gqlopencensus.ContextKeyValues(ctx), attrs...)
+		if h.config.db != "" {
+			attrs = append(attrs, gqlattr.KeyValue{Key: "redis.db", Value: h.config.db})
+		}
+		span.AddAttributes(ocattr.Attributes(gqlattr.Default(), attrs)...)
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func (h Hook) end(ctx context.Context, err error) {
+	span, ok := ctx.Value(spanKey{}).(*trace.Span)
+	if !ok {
+		return
+	}
+	if err != nil && !h.config.isNil(err) {
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeUnknown,
+			Message: err.Error(),
+		})
+	}
+	span.End()
+}
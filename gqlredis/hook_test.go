@@ -0,0 +1,138 @@
+package gqlredis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+)
+
+var errNil = errors.New("redis: nil")
+
+type (
+	spanRecorder struct {
+		mx    sync.Mutex
+		spans []*trace.SpanData
+	}
+
+	fakeCmd struct {
+		name string
+		err  error
+	}
+
+	// fakeClient processes commands with a hook, like go-redis does
+	fakeClient struct {
+		hook *Hook
+	}
+)
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mx.Lock()
+	r.spans = append(r.spans, s)
+	r.mx.Unlock()
+}
+
+// recorded yields the spans recorded so far, and resets the recorder
+func (r *spanRecorder) recorded() []*trace.SpanData {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	spans := r.spans
+	r.spans = nil
+	return spans
+}
+
+func (c fakeCmd) Name() string { return c.name }
+func (c fakeCmd) Err() error   { return c.err }
+
+func (c fakeClient) process(ctx context.Context, cmd fakeCmd) {
+	ctx = c.hook.Before(ctx, cmd)
+	c.hook.After(ctx, cmd)
+}
+
+func (c fakeClient) pipeline(ctx context.Context, cmds ...fakeCmd) {
+	converted := Cmds(len(cmds), func(i int) Cmd { return cmds[i] })
+	ctx = c.hook.BeforePipeline(ctx, converted...)
+	c.hook.AfterPipeline(ctx, converted...)
+}
+
+func TestHook(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	// resolver context, within a sampled trace
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "todos"})
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Object: "Query",
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: "todos", Alias: "todos"}},
+	})
+	ctx, parent := trace.StartSpan(ctx, "resolver", trace.WithSampler(trace.AlwaysSample()))
+	defer parent.End()
+
+	client := fakeClient{hook: NewHook(WithDB("0"))}
+
+	t.Run("command", func(t *testing.T) {
+		client.process(ctx, fakeCmd{name: "GET"})
+		client.process(ctx, fakeCmd{name: "GET", err: errNil})
+		client.process(ctx, fakeCmd{name: "SET", err: errors.New("READONLY")})
+
+		spans := recorder.recorded()
+		require.Len(t, spans, 3)
+
+		span := spans[0]
+		assert.Equal(t, "redis:get", span.Name)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Equal(t, parent.SpanContext().TraceID, span.TraceID)
+		assert.Equal(t, int32(trace.StatusCodeOK), span.Status.Code)
+		assert.Equal(t, map[string]interface{}{
+			"graphql.operation": "todos",
+			"graphql.field":     "todos",
+			"graphql.path":      "todos",
+			"redis.cmd":         "get",
+			"redis.db":          "0",
+		}, span.Attributes)
+
+		assert.Equal(t, int32(trace.StatusCodeOK), spans[1].Status.Code, "missing keys are not failures")
+
+		assert.Equal(t, "redis:set", spans[2].Name)
+		assert.Equal(t, int32(trace.StatusCodeUnknown), spans[2].Status.Code)
+		assert.Equal(t, "READONLY", spans[2].Status.Message)
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		client.pipeline(ctx, fakeCmd{name: "GET"}, fakeCmd{name: "INCR", err: errors.New("WRONGTYPE")}, fakeCmd{name: "EXPIRE"})
+
+		spans := recorder.recorded()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "redis:pipeline", span.Name)
+		assert.Equal(t, "get incr expire", span.Attributes["redis.cmd"])
+		assert.Equal(t, int64(3), span.Attributes["redis.pipeline_length"])
+		assert.Equal(t, int32(trace.StatusCodeUnknown), span.Status.Code)
+		assert.Equal(t, "WRONGTYPE", span.Status.Message)
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		hook := NewHook(WithNilError(func(err error) bool { return err == errNil }))
+		fakeClient{hook: hook}.process(ctx, fakeCmd{name: "GET", err: errors.New("redis: nil")})
+
+		spans := recorder.recorded()
+		require.Len(t, spans, 1)
+		assert.Equal(t, int32(trace.StatusCodeUnknown), spans[0].Status.Code)
+		assert.NotContains(t, spans[0].Attributes, "redis.db")
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		unsampled, span := trace.StartSpan(context.Background(), "resolver", trace.WithSampler(trace.NeverSample()))
+		defer span.End()
+
+		client.process(unsampled, fakeCmd{name: "GET"})
+		assert.Empty(t, recorder.recorded())
+	})
+}
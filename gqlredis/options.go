@@ -0,0 +1,35 @@
+package gqlredis
+
+type (
+	// Option for the Redis hook
+	Option func(*config)
+
+	config struct {
+		db    string
+		isNil func(error) bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		isNil: func(err error) bool {
+			// go-redis reports missing keys with the redis.Nil error, which is not a failure
+			return err.Error() == "redis: nil"
+		},
+	}
+}
+
+// WithDB adds the Redis database to spans, as the "redis.db" attribute.
+func WithDB(db string) Option {
+	return func(c *config) {
+		c.db = db
+	}
+}
+
+// WithNilError sets a function to recognize the error returned for missing keys, which is not reported as a failure.
+// By default, this recognizes redis.Nil from go-redis.
+func WithNilError(isNil func(error) bool) Option {
+	return func(c *config) {
+		c.isNil = isNil
+	}
+}
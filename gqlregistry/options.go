@@ -0,0 +1,69 @@
+package gqlregistry
+
+import (
+	"github.com/99designs/gqlgen-contrib/internal/env"
+)
+
+// DefaultEnvVar is the environment variable looked up by default to run in development mode (see WithDevEnvironments)
+const DefaultEnvVar = env.DefaultVar
+
+type (
+	// Option for the operation registry
+	Option func(*config)
+
+	config struct {
+		dev          bool
+		envVar       env.Var
+		environments []string
+		dumpFile     string
+		onError      func(error)
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// isDevMode tells if the registry runs in development mode for the current environment
+func (c config) isDevMode() bool {
+	return c.dev || c.envVar.In(c.environments...)
+}
+
+// DevMode forces the development mode, regardless of the current environment.
+//
+// By default, the registry is enforced unless the current environment is declared with WithDevEnvironments.
+func DevMode(enabled bool) Option {
+	return func(c *config) {
+		c.dev = enabled
+	}
+}
+
+// WithDevEnvironments runs the registry in development mode when the current environment is one of these
+// environments (e.g. "dev").
+func WithDevEnvironments(environments ...string) Option {
+	return func(c *config) {
+		c.environments = append(c.environments, environments...)
+	}
+}
+
+// WithEnvVar sets the environment variable holding the name of the environment, matched against
+// WithDevEnvironments. The default is "GQL_ENV".
+func WithEnvVar(name string) Option {
+	return func(c *config) {
+		c.envVar = env.Var(name)
+	}
+}
+
+// WithDumpFile rewrites this file whenever an operation is registered in development mode.
+func WithDumpFile(path string) Option {
+	return func(c *config) {
+		c.dumpFile = path
+	}
+}
+
+// WithErrorHandler sets a handler for errors writing the dump file. By default, errors are ignored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
@@ -0,0 +1,219 @@
+// Package gqlregistry provides a gqlgen extension enforcing a registry of named operations.
+//
+// Every operation must be named, and registered with its signature: anonymous operations, unregistered
+// operations and operations which signature differs from the registered one are rejected.
+// Signatures are computed with gqlapollo.Signature, so that formatting, aliases and literals do not matter.
+//
+// In development mode, nothing is rejected: named operations are registered as they are executed, and the
+// registry may be dumped for review, then loaded in production.
+//
+// Example:
+//
+//   registry := gqlregistry.New(
+//     gqlregistry.WithDevEnvironments("dev"),
+//     gqlregistry.WithDumpFile("operations.json"),
+//   )
+//   if err := registry.LoadFile("operations.json"); err != nil && !os.IsNotExist(err) {
+//     log.Fatal(err)
+//   }
+//   srv.Use(registry)
+package gqlregistry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlapollo"
+)
+
+const extensionName = "OperationRegistry"
+
+// Error codes of rejected operations
+const (
+	CodeAnonymousOperation    = "ANONYMOUS_OPERATION"
+	CodeUnregisteredOperation = "UNREGISTERED_OPERATION"
+	CodeSignatureMismatch     = "OPERATION_SIGNATURE_MISMATCH"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Registry{}
+
+type (
+	// Registry is a gqlgen extension enforcing a registry of named operations, with their expected signature
+	Registry struct {
+		*config
+		dev bool
+
+		mx         sync.RWMutex
+		operations map[string]string
+		dumpMx     sync.Mutex
+	}
+
+	// document is the serialized form of a registry
+	document struct {
+		Operations map[string]string `json:"operations"`
+	}
+)
+
+// New empty Registry
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		config:     defaultConfig(),
+		operations: make(map[string]string),
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	r.dev = r.config.isDevMode()
+	return r
+}
+
+// ExtensionName yields the extension name: "OperationRegistry"
+func (*Registry) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Registry) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// IsDevMode tells if the registry runs in development mode
+func (r *Registry) IsDevMode() bool {
+	return r.dev
+}
+
+// Register an operation with its expected signature
+func (r *Registry) Register(name, signature string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.operations[name] = signature
+}
+
+// Signature yields the registered signature of an operation
+func (r *Registry) Signature(name string) (string, bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	signature, ok := r.operations[name]
+	return signature, ok
+}
+
+// Load registered operations, as dumped by Dump. Loaded operations are added to the registry.
+func (r *Registry) Load(reader io.Reader) error {
+	var doc document
+	if err := json.NewDecoder(reader).Decode(&doc); err != nil {
+		return err
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for name, signature := range doc.Operations {
+		r.operations[name] = signature
+	}
+	return nil
+}
+
+// LoadFile loads registered operations from a file
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return r.Load(f)
+}
+
+// Dump the registered operations as JSON, sorted by name
+func (r *Registry) Dump(w io.Writer) error {
+	buf, err := r.marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Handler serves a dump of the registry, for review. It is only enabled in development mode.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.dev {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = r.Dump(w)
+	})
+}
+
+// MutateOperationContext implements graphql.OperationContextMutator, rejecting operations which are not registered.
+// In development mode, operations are registered instead.
+func (r *Registry) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	name := rc.Operation.Name
+	if name == "" {
+		if r.dev {
+			return nil
+		}
+		return rejection(CodeAnonymousOperation, "operations must be named")
+	}
+
+	signature := gqlapollo.Signature(rc.Doc, rc.Operation)
+	registered, ok := r.Signature(name)
+	switch {
+	case ok && registered == signature:
+		return nil
+	case r.dev:
+		r.Register(name, signature)
+		r.dump()
+		return nil
+	case !ok:
+		return rejection(CodeUnregisteredOperation, "operation %q is not registered", name)
+	default:
+		return rejection(CodeSignatureMismatch, "operation %q does not match its registered signature", name)
+	}
+}
+
+// dump rewrites the dump file, if any. Errors are reported by the error handler.
+func (r *Registry) dump() {
+	if r.config.dumpFile == "" {
+		return
+	}
+	r.dumpMx.Lock()
+	defer r.dumpMx.Unlock()
+
+	buf, err := r.marshal()
+	if err == nil {
+		err = ioutil.WriteFile(r.config.dumpFile, buf, 0600)
+	}
+	if err != nil && r.config.onError != nil {
+		r.config.onError(err)
+	}
+}
+
+func (r *Registry) marshal() ([]byte, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	// maps are marshaled with sorted keys, and indented for review
+	return json.MarshalIndent(document{Operations: r.operations}, "", "  ")
+}
+
+func rejection(code, format string, args ...interface{}) *gqlerror.Error {
+	err := gqlerror.Errorf(format, args...)
+	err.Extensions = map[string]interface{}{"code": code}
+	return err
+}
@@ -0,0 +1,63 @@
+package gqlregistry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+type Todo {
+  id: ID!
+  text: String!
+}
+
+type Query {
+  todos(first: Int): [Todo!]!
+}
+`
+
+func TestRegistry(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	operation := func(query string) *graphql.OperationContext {
+		doc, gqlErr := gqlparser.LoadQuery(schema, query)
+		require.Nil(t, gqlErr)
+		return &graphql.OperationContext{Doc: doc, Operation: doc.Operations[0]}
+	}
+	code := func(r *Registry, query string) interface{} {
+		err := r.MutateOperationContext(context.Background(), operation(query))
+		if err == nil {
+			return nil
+		}
+		return err.Extensions["code"]
+	}
+
+	dev := New(DevMode(true))
+	assert.True(t, dev.IsDevMode())
+	assert.Nil(t, code(dev, `{ todos { id } }`))
+	assert.Nil(t, code(dev, `query todos { todos(first: 10) { id text } }`))
+
+	var buf bytes.Buffer
+	require.NoError(t, dev.Dump(&buf))
+
+	prod := New(WithEnvVar("GQLREGISTRY_TEST_ENV"), WithDevEnvironments("dev"))
+	assert.False(t, prod.IsDevMode())
+	require.NoError(t, prod.Load(&buf))
+
+	signature, ok := prod.Signature("todos")
+	require.True(t, ok)
+	assert.Equal(t, `query todos{todos(first:0){id text}}`, signature)
+
+	assert.Nil(t, code(prod, `query todos { list: todos(first: 20) { text, id } }`))
+	assert.Equal(t, CodeAnonymousOperation, code(prod, `{ todos { id } }`))
+	assert.Equal(t, CodeUnregisteredOperation, code(prod, `query other { todos { id } }`))
+	assert.Equal(t, CodeSignatureMismatch, code(prod, `query todos { todos { id } }`))
+}
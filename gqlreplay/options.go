@@ -0,0 +1,109 @@
+package gqlreplay
+
+import (
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlpii"
+)
+
+type (
+	// Option for the recorder
+	Option func(*config)
+
+	config struct {
+		rate    float64
+		headers []string
+		filter  func(*graphql.OperationContext) bool
+		pii     *gqlpii.Engine
+	}
+
+	// ReplayOption for the replayer
+	ReplayOption func(*replayConfig)
+
+	replayConfig struct {
+		client      *http.Client
+		concurrency int
+		paced       bool
+		headers     http.Header
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		rate: 0.01,
+	}
+}
+
+func defaultReplayConfig() *replayConfig {
+	return &replayConfig{
+		client:      http.DefaultClient,
+		concurrency: 1,
+	}
+}
+
+// WithRate sets the fraction of requests recorded, between 0 and 1. The default is 0.01.
+func WithRate(rate float64) Option {
+	return func(c *config) {
+		c.rate = rate
+	}
+}
+
+// WithHeaders records these request headers, captured by the Middleware. By default, no header is recorded.
+//
+// Beware not to record credentials, such as the Authorization header.
+func WithHeaders(names ...string) Option {
+	return func(c *config) {
+		c.headers = append(c.headers, names...)
+	}
+}
+
+// WithFilter records only the operations accepted by the filter, e.g. to exclude mutations or introspection.
+func WithFilter(filter func(*graphql.OperationContext) bool) Option {
+	return func(c *config) {
+		c.filter = filter
+	}
+}
+
+// WithPII masks the personally identifiable information tagged in the schema in recorded variables.
+//
+// Notice that literal values inlined in recorded queries are not masked.
+func WithPII(engine *gqlpii.Engine) Option {
+	return func(c *config) {
+		c.pii = engine
+	}
+}
+
+// WithClient sets the HTTP client used to replay requests. The default is http.DefaultClient.
+func WithClient(client *http.Client) ReplayOption {
+	return func(c *replayConfig) {
+		c.client = client
+	}
+}
+
+// WithConcurrency sets the number of requests replayed concurrently. The default is 1.
+func WithConcurrency(n int) ReplayOption {
+	return func(c *replayConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithPacing replays requests at the pace they were recorded, rather than as fast as possible.
+func WithPacing(enabled bool) ReplayOption {
+	return func(c *replayConfig) {
+		c.paced = enabled
+	}
+}
+
+// WithReplayHeader adds a header to all replayed requests, e.g. credentials for the target endpoint.
+func WithReplayHeader(name, value string) ReplayOption {
+	return func(c *replayConfig) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Add(name, value)
+	}
+}
@@ -0,0 +1,165 @@
+// Package gqlreplay records sampled GraphQL requests in a replayable format, and replays them against a target
+// endpoint. This is useful for load testing and regression testing with production-shaped traffic.
+//
+// Requests are recorded as JSON lines, with their query, operation name, variables and a subset of their headers.
+//
+// Example:
+//
+//   f, _ := os.Create("requests.jsonl")
+//   recorder := gqlreplay.New(gqlreplay.NewWriterSink(f),
+//     gqlreplay.WithRate(0.01),
+//     gqlreplay.WithHeaders("User-Agent", "X-Client-Name"),
+//   )
+//   srv.Use(recorder)
+//   http.Handle("/query", recorder.Middleware(srv))
+//
+// Recorded requests are replayed with a Replayer:
+//
+//   report, err := gqlreplay.NewReplayer("https://staging.example.com/query").Replay(ctx, f)
+package gqlreplay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "ReplayRecorder"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Recorder{}
+
+type (
+	// Entry is a recorded request
+	Entry struct {
+		Time          time.Time              `json:"time"`
+		OperationName string                 `json:"operationName,omitempty"`
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+		Headers       map[string]string      `json:"headers,omitempty"`
+	}
+
+	// Sink stores recorded requests, e.g. in a file or an object store
+	Sink interface {
+		Record(context.Context, Entry) error
+	}
+
+	// SinkFunc is a function implementing Sink
+	SinkFunc func(context.Context, Entry) error
+
+	// WriterSink writes recorded requests as JSON lines. It is safe for concurrent use.
+	WriterSink struct {
+		mx      sync.Mutex
+		encoder *json.Encoder
+	}
+
+	// Recorder is a gqlgen extension recording sampled requests to a Sink
+	Recorder struct {
+		*config
+		sink Sink
+	}
+
+	headersKey struct{}
+)
+
+// Record implements Sink
+func (f SinkFunc) Record(ctx context.Context, entry Entry) error {
+	return f(ctx, entry)
+}
+
+// NewWriterSink builds a Sink writing JSON lines to a writer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{encoder: json.NewEncoder(w)}
+}
+
+// Record implements Sink
+func (s *WriterSink) Record(_ context.Context, entry Entry) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.encoder.Encode(entry)
+}
+
+// New Recorder, storing sampled requests in the sink
+func New(sink Sink, opts ...Option) *Recorder {
+	r := &Recorder{
+		config: defaultConfig(),
+		sink:   sink,
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// Middleware captures the subset of request headers to be recorded (see WithHeaders).
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(r.config.headers) == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		headers := make(map[string]string, len(r.config.headers))
+		for _, name := range r.config.headers {
+			if value := req.Header.Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), headersKey{}, headers)))
+	})
+}
+
+// ExtensionName yields the extension name: "ReplayRecorder"
+func (*Recorder) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Recorder) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor.
+//
+// Requests are recorded after their execution. Sink errors are ignored.
+func (r *Recorder) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		// the final pull of websocket transports, past the response of the operation
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if r.config.rate <= 0 || rand.Float64() >= r.config.rate {
+		return resp
+	}
+	if r.config.filter != nil && !r.config.filter(oc) {
+		return resp
+	}
+
+	variables := oc.Variables
+	if r.config.pii != nil {
+		variables = r.config.pii.MaskVariables(oc)
+	}
+
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	_ = r.sink.Record(ctx, Entry{
+		Time:          oc.Stats.OperationStart,
+		OperationName: oc.OperationName,
+		Query:         oc.RawQuery,
+		Variables:     variables,
+		Headers:       headers,
+	})
+
+	return resp
+}
@@ -0,0 +1,167 @@
+package gqlreplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Replayer executes recorded requests against a target endpoint
+	Replayer struct {
+		*replayConfig
+		target string
+	}
+
+	// Report summarizes a replay
+	Report struct {
+		// Replayed is the number of requests sent
+		Replayed int
+
+		// Failed is the number of requests which failed to be sent, or were not served with a 2xx status code
+		Failed int
+
+		// Errors is the number of responses with GraphQL errors
+		Errors int
+
+		// Duration of the replay
+		Duration time.Duration
+	}
+)
+
+// NewReplayer builds a Replayer for a target GraphQL endpoint
+func NewReplayer(target string, opts ...ReplayOption) *Replayer {
+	r := &Replayer{
+		replayConfig: defaultReplayConfig(),
+		target:       target,
+	}
+	for _, apply := range opts {
+		apply(r.replayConfig)
+	}
+	return r
+}
+
+// Replay the requests recorded as JSON lines.
+//
+// An error is returned when the recorded requests cannot be decoded, or when the context is cancelled.
+// Failed requests are accounted for in the report.
+func (r *Replayer) Replay(ctx context.Context, in io.Reader) (Report, error) {
+	var (
+		report Report
+		mx     sync.Mutex
+		wg     sync.WaitGroup
+	)
+	start := time.Now()
+	entries := make(chan Entry)
+
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				failed, hasErrors := r.replay(ctx, entry)
+				mx.Lock()
+				report.Replayed++
+				if failed {
+					report.Failed++
+				}
+				if hasErrors {
+					report.Errors++
+				}
+				mx.Unlock()
+			}
+		}()
+	}
+
+	err := r.dispatch(ctx, in, entries, start)
+	close(entries)
+	wg.Wait()
+	report.Duration = time.Since(start)
+
+	return report, err
+}
+
+func (r *Replayer) dispatch(ctx context.Context, in io.Reader, entries chan<- Entry, start time.Time) error {
+	decoder := json.NewDecoder(in)
+	var first time.Time
+
+	for {
+		var entry Entry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if r.paced && !entry.Time.IsZero() {
+			if first.IsZero() {
+				first = entry.Time
+			}
+			wait := time.Until(start.Add(entry.Time.Sub(first)))
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entries <- entry:
+		}
+	}
+}
+
+// replay a single request, reporting whether it failed, and whether the response carried GraphQL errors
+func (r *Replayer) replay(ctx context.Context, entry Entry) (failed bool, hasErrors bool) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":         entry.Query,
+		"operationName": entry.OperationName,
+		"variables":     entry.Variables,
+	})
+	if err != nil {
+		return true, false
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.target, bytes.NewReader(body))
+	if err != nil {
+		return true, false
+	}
+	req = req.WithContext(ctx)
+	for name, value := range entry.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, values := range r.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return true, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return true, false
+	}
+
+	var result struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return true, false
+	}
+	return false, len(result.Errors) > 0
+}
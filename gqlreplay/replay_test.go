@@ -0,0 +1,67 @@
+package gqlreplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	var recorded bytes.Buffer
+	recorder := New(NewWriterSink(&recorded), WithRate(1), WithHeaders("X-Client-Name"))
+	require.Equal(t, extensionName, recorder.ExtensionName())
+
+	var ctx context.Context
+	handler := recorder.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("X-Client-Name", "web")
+	req.Header.Set("Authorization", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, name := range []string{"a", "b"} {
+		opCtx := graphql.WithOperationContext(ctx, &graphql.OperationContext{
+			RawQuery:      "query " + name + " { a }",
+			OperationName: name,
+			Variables:     map[string]interface{}{"x": 1.0},
+		})
+		// pull responses until nil, as websocket transports do
+		next := graphql.OneShot(&graphql.Response{})
+		for recorder.InterceptResponse(opCtx, next) != nil {
+		}
+	}
+
+	var received []map[string]interface{}
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		assert.Equal(t, "web", r.Header.Get("X-Client-Name"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		received = append(received, params)
+
+		if params["operationName"] == "b" {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"boom"}],"data":null}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"a":1}}`))
+	}))
+	defer target.Close()
+
+	report, err := NewReplayer(target.URL).Replay(context.Background(), &recorded)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Replayed)
+	assert.Equal(t, 0, report.Failed)
+	assert.Equal(t, 1, report.Errors)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "query a { a }", received[0]["query"])
+	assert.Equal(t, map[string]interface{}{"x": 1.0}, received[0]["variables"])
+}
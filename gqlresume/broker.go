@@ -0,0 +1,202 @@
+// Package gqlresume provides resumable subscriptions: published events carry monotonically increasing tokens,
+// and are persisted in a pluggable Store, so that clients reconnecting with the token of the last event they
+// received get the events they missed before going live.
+//
+// Tokens are exposed to clients by the subscription payload, and sent back as an argument of the subscription:
+//
+//   type Subscription {
+//     todoAdded(after: String): TodoEvent!
+//   }
+//
+//   type TodoEvent {
+//     todo: Todo!
+//     resumeToken: String!
+//   }
+//
+// Example:
+//
+//   broker := gqlresume.New(gqlresume.NewMemoryStore(gqlresume.WithRetention(time.Hour)))
+//
+//   // in mutation resolvers
+//   _, err := broker.Publish(ctx, "todos", todo)
+//
+//   // in subscription resolvers
+//   func (r *subscriptionResolver) TodoAdded(ctx context.Context, after *string) (<-chan *model.TodoEvent, error) {
+//     events, err := broker.Subscribe(ctx, "todos", gqlresume.TokenFrom(after))
+//     if err != nil {
+//       return nil, err // e.g. ErrTokenExpired: the client must refetch its state
+//     }
+//     out := make(chan *model.TodoEvent)
+//     go func() {
+//       defer close(out)
+//       for event := range events {
+//         var todo model.Todo
+//         if err := event.Decode(&todo); err == nil {
+//           out <- &model.TodoEvent{Todo: &todo, ResumeToken: event.Token}
+//         }
+//       }
+//     }()
+//     return out, nil
+//   }
+//
+// Live events are delivered to the subscribers of the process publishing them. A subscriber too slow to keep up
+// is unsubscribed: its channel is closed, and its client resumes from its last token.
+package gqlresume
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenExpired is returned when the events following a token are no longer retained
+	ErrTokenExpired = errors.New("resume token expired")
+
+	// ErrInvalidToken is returned when a token is not recognized by the store
+	ErrInvalidToken = errors.New("invalid resume token")
+)
+
+type (
+	// Event published on a topic
+	Event struct {
+		// Token of the event, to resume the subscription after this event
+		Token string `json:"token"`
+
+		Time    time.Time       `json:"time"`
+		Payload json.RawMessage `json:"payload"`
+	}
+
+	// Store persists the events of topics, with monotonically increasing tokens
+	Store interface {
+		// Append an event to a topic, assigning its token
+		Append(ctx context.Context, topic string, payload json.RawMessage) (Event, error)
+
+		// Since yields the events of a topic published after the event with a token, in order.
+		// ErrTokenExpired is returned when some of these events are no longer retained.
+		Since(ctx context.Context, topic, token string) ([]Event, error)
+	}
+
+	// Broker publishes events, and delivers them to subscribers, replaying the missed ones first
+	Broker struct {
+		*config
+		store Store
+
+		mx          sync.Mutex
+		subscribers map[string]map[*subscriber]struct{}
+	}
+
+	subscriber struct {
+		events chan Event
+		done   chan struct{}
+	}
+)
+
+// New Broker of events persisted in a store
+func New(store Store, opts ...Option) *Broker {
+	b := &Broker{
+		config:      defaultConfig(),
+		store:       store,
+		subscribers: make(map[string]map[*subscriber]struct{}),
+	}
+	for _, apply := range opts {
+		apply(b.config)
+	}
+	return b
+}
+
+// TokenFrom yields the token of an optional argument
+func TokenFrom(token *string) string {
+	if token == nil {
+		return ""
+	}
+	return *token
+}
+
+// Decode the JSON payload of an event
+func (e Event) Decode(value interface{}) error {
+	return json.Unmarshal(e.Payload, value)
+}
+
+// Publish a value on a topic, as JSON. The event is persisted, then delivered to live subscribers.
+func (b *Broker) Publish(ctx context.Context, topic string, value interface{}) (Event, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return Event{}, err
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	event, err := b.store.Append(ctx, topic, payload)
+	if err != nil {
+		return Event{}, err
+	}
+	for s := range b.subscribers[topic] {
+		select {
+		case s.events <- event:
+		default:
+			// the subscriber does not keep up: it resumes from its last token after reconnecting
+			b.unsubscribe(topic, s)
+		}
+	}
+	return event, nil
+}
+
+// Subscribe to the events of a topic, published after the event with a token. An empty token only subscribes
+// to live events.
+//
+// The channel is closed when the context is done, or when the subscriber is too slow to keep up.
+func (b *Broker) Subscribe(ctx context.Context, topic, token string) (<-chan Event, error) {
+	b.mx.Lock()
+	var missed []Event
+	if token != "" {
+		var err error
+		if missed, err = b.store.Since(ctx, topic, token); err != nil {
+			b.mx.Unlock()
+			return nil, err
+		}
+	}
+	// live events are buffered while the missed ones are replayed
+	s := &subscriber{
+		events: make(chan Event, b.config.bufferSize+len(missed)),
+		done:   make(chan struct{}),
+	}
+	for _, event := range missed {
+		s.events <- event
+	}
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[*subscriber]struct{})
+	}
+	b.subscribers[topic][s] = struct{}{}
+	b.mx.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.done:
+			return
+		}
+		b.mx.Lock()
+		defer b.mx.Unlock()
+		b.unsubscribe(topic, s)
+	}()
+
+	return s.events, nil
+}
+
+// unsubscribe closes the channel of a subscriber, once. The lock must be held.
+func (b *Broker) unsubscribe(topic string, s *subscriber) {
+	subscribers := b.subscribers[topic]
+	if _, ok := subscribers[s]; !ok {
+		return
+	}
+	delete(subscribers, s)
+	if len(subscribers) == 0 {
+		delete(b.subscribers, topic)
+	}
+	close(s.events)
+	close(s.done)
+}
@@ -0,0 +1,122 @@
+package gqlresume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, events <-chan Event, n int) []string {
+	var payloads []string
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-events:
+			var value int
+			require.NoError(t, event.Decode(&value))
+			payloads = append(payloads, event.Token)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d events, got %d", n, len(payloads))
+		}
+	}
+	return payloads
+}
+
+func TestResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := New(NewMemoryStore())
+	for i := 1; i <= 3; i++ {
+		_, err := broker.Publish(ctx, "todos", i)
+		require.NoError(t, err)
+	}
+
+	events, err := broker.Subscribe(ctx, "todos", "1")
+	require.NoError(t, err)
+	_, err = broker.Publish(ctx, "todos", 4)
+	require.NoError(t, err)
+
+	// missed events are replayed before live ones, without duplicates
+	assert.Equal(t, []string{"2", "3", "4"}, receive(t, events, 3))
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestLiveOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := New(NewMemoryStore())
+	_, err := broker.Publish(ctx, "todos", 1)
+	require.NoError(t, err)
+
+	events, err := broker.Subscribe(ctx, "todos", TokenFrom(nil))
+	require.NoError(t, err)
+	_, err = broker.Publish(ctx, "todos", 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2"}, receive(t, events, 1))
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	broker := New(NewMemoryStore(), WithBufferSize(1))
+
+	events, err := broker.Subscribe(ctx, "todos", "")
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		_, err = broker.Publish(ctx, "todos", i)
+		require.NoError(t, err)
+	}
+
+	// the subscriber is cut off, then resumes from its last token
+	last := receive(t, events, 1)[0]
+	_, open := <-events
+	assert.False(t, open)
+
+	events, err = broker.Subscribe(ctx, "todos", last)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, receive(t, events, 2))
+}
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(
+		WithRetention(time.Minute),
+		WithMaxEvents(3),
+		WithClock(func() time.Time { return now }),
+	)
+
+	for i := 0; i < 5; i++ {
+		_, err := store.Append(ctx, "todos", []byte("{}"))
+		require.NoError(t, err)
+	}
+
+	_, err := store.Since(ctx, "todos", "1")
+	assert.Equal(t, ErrTokenExpired, err)
+
+	events, err := store.Since(ctx, "todos", "2")
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.Since(ctx, "todos", "4")
+	assert.Equal(t, ErrTokenExpired, err)
+
+	events, err = store.Since(ctx, "todos", "5")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = store.Since(ctx, "todos", "6")
+	assert.Equal(t, ErrInvalidToken, err)
+	_, err = store.Since(ctx, "todos", "abc")
+	assert.Equal(t, ErrInvalidToken, err)
+	_, err = store.Since(ctx, "other", "1")
+	assert.Equal(t, ErrTokenExpired, err)
+}
@@ -0,0 +1,58 @@
+package gqlresume
+
+import (
+	"time"
+)
+
+type (
+	// Option for the broker
+	Option func(*config)
+
+	config struct {
+		bufferSize int
+	}
+
+	// StoreOption for the in-memory store
+	StoreOption func(*storeConfig)
+
+	storeConfig struct {
+		retention time.Duration
+		maxEvents int
+		clock     func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		bufferSize: 100,
+	}
+}
+
+// WithBufferSize sets the number of live events buffered per subscriber. Subscribers lagging further behind
+// are unsubscribed. The default is 100.
+func WithBufferSize(size int) Option {
+	return func(c *config) {
+		c.bufferSize = size
+	}
+}
+
+// WithRetention sets how long events are retained. The default is 1h: zero means no time limit.
+func WithRetention(retention time.Duration) StoreOption {
+	return func(c *storeConfig) {
+		c.retention = retention
+	}
+}
+
+// WithMaxEvents sets the maximum number of events retained per topic. The default is 1000: zero means no limit.
+func WithMaxEvents(events int) StoreOption {
+	return func(c *storeConfig) {
+		c.maxEvents = events
+	}
+}
+
+// WithClock sets the clock, e.g. for tests. The default is graphql.Now.
+func WithClock(clock func() time.Time) StoreOption {
+	return func(c *storeConfig) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,129 @@
+package gqlresume
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// MemoryStore retains the events of topics in memory, for a limited time and up to a maximum number of events
+	// per topic. Tokens are sequence numbers per topic.
+	//
+	// Events are lost when the process restarts: use a persistent store to resume subscriptions across restarts.
+	MemoryStore struct {
+		*storeConfig
+
+		mx     sync.Mutex
+		topics map[string]*topicLog
+	}
+
+	// topicLog holds the retained events of a topic, and the sequence number of the last event
+	topicLog struct {
+		events []Event
+		last   uint64
+	}
+)
+
+// NewMemoryStore builds an in-memory Store
+func NewMemoryStore(opts ...StoreOption) *MemoryStore {
+	s := &MemoryStore{
+		storeConfig: defaultStoreConfig(),
+		topics:      make(map[string]*topicLog),
+	}
+	for _, apply := range opts {
+		apply(s.storeConfig)
+	}
+	return s
+}
+
+// Append implements Store
+func (s *MemoryStore) Append(_ context.Context, topic string, payload json.RawMessage) (Event, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	log := s.topics[topic]
+	if log == nil {
+		log = &topicLog{}
+		s.topics[topic] = log
+	}
+	log.last++
+	event := Event{
+		Token:   strconv.FormatUint(log.last, 10),
+		Time:    s.storeConfig.clock(),
+		Payload: payload,
+	}
+	log.events = append(log.events, event)
+	s.trim(log)
+
+	return event, nil
+}
+
+// Since implements Store
+func (s *MemoryStore) Since(_ context.Context, topic, token string) ([]Event, error) {
+	seq, err := strconv.ParseUint(token, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	log := s.topics[topic]
+	if log == nil {
+		if seq == 0 {
+			return nil, nil
+		}
+		// the topic is gone: its events expired, or the store was restarted
+		return nil, ErrTokenExpired
+	}
+	if seq > log.last {
+		return nil, ErrInvalidToken
+	}
+	s.trim(log)
+
+	// sequence number of the first retained event, or the next one when none is retained
+	first := log.last + 1 - uint64(len(log.events))
+	if seq+1 < first {
+		return nil, ErrTokenExpired
+	}
+	retained := log.events[seq+1-first:]
+	events := make([]Event, len(retained))
+	copy(events, retained)
+	return events, nil
+}
+
+// trim the events of a topic beyond the retention. The lock must be held.
+func (s *MemoryStore) trim(log *topicLog) {
+	drop := 0
+	if s.storeConfig.maxEvents > 0 && len(log.events) > s.storeConfig.maxEvents {
+		drop = len(log.events) - s.storeConfig.maxEvents
+	}
+	if s.storeConfig.retention > 0 {
+		horizon := s.storeConfig.clock().Add(-s.storeConfig.retention)
+		for drop < len(log.events) && log.events[drop].Time.Before(horizon) {
+			drop++
+		}
+	}
+	if drop == 0 {
+		return
+	}
+	for i := 0; i < drop; i++ {
+		log.events[i] = Event{}
+	}
+	log.events = log.events[drop:]
+}
+
+var _ Store = &MemoryStore{}
+
+func defaultStoreConfig() *storeConfig {
+	return &storeConfig{
+		retention: time.Hour,
+		maxEvents: 1000,
+		clock:     graphql.Now,
+	}
+}
@@ -0,0 +1,55 @@
+package gqlsanitize
+
+type (
+	// Option for the sanitization extension
+	Option func(*config)
+
+	config struct {
+		defaults  []Sanitizer
+		arguments map[string][]Sanitizer
+		named     map[string]Sanitizer
+		directive string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		arguments: make(map[string][]Sanitizer),
+		named: map[string]Sanitizer{
+			"trim":         Trim,
+			"stripControl": StripControl,
+		},
+		directive: "sanitize",
+	}
+}
+
+// WithDefaults applies sanitizers to all string arguments and input fields, unless they are configured
+// otherwise by name or by directive. By default, there is none.
+func WithDefaults(sanitizers ...Sanitizer) Option {
+	return func(c *config) {
+		c.defaults = append(c.defaults, sanitizers...)
+	}
+}
+
+// WithArgument applies sanitizers to all string arguments and input fields with this name, e.g. "email".
+func WithArgument(name string, sanitizers ...Sanitizer) Option {
+	return func(c *config) {
+		c.arguments[name] = append(c.arguments[name], sanitizers...)
+	}
+}
+
+// WithSanitizer registers a named sanitizer, to be used with the directive, e.g. "nfc".
+//
+// The sanitizers "trim" and "stripControl" are registered by default.
+func WithSanitizer(name string, sanitizer Sanitizer) Option {
+	return func(c *config) {
+		c.named[name] = sanitizer
+	}
+}
+
+// WithDirective sets the name of the sanitization directive. The default is "sanitize".
+func WithDirective(name string) Option {
+	return func(c *config) {
+		c.directive = name
+	}
+}
@@ -0,0 +1,302 @@
+// Package gqlsanitize provides a gqlgen extension to sanitize and normalize string arguments centrally,
+// so resolvers do not need to repeat defensive code.
+//
+// Sanitizers apply to arguments of type String, and to the String fields of input objects, at any depth.
+// They are configured by argument name (WithArgument), for all arguments (WithDefaults), or in the schema
+// with a directive:
+//
+//   directive @sanitize(with: [String!], maxLength: Int) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+//
+//   type Mutation {
+//     createUser(name: String! @sanitize(with: ["trim", "nfc"], maxLength: 64)): User!
+//   }
+//
+// The directive takes precedence over the configuration by name, which takes precedence over the defaults.
+//
+// Since the extension enforces the directive, the directive generated by gqlgen should be implemented as a noop.
+//
+// Example:
+//
+//   srv.Use(gqlsanitize.New(
+//     gqlsanitize.WithDefaults(gqlsanitize.Trim, gqlsanitize.StripControl),
+//     gqlsanitize.WithArgument("email", gqlsanitize.Trim, strings.ToLower),
+//     gqlsanitize.WithSanitizer("nfc", norm.NFC.String),
+//   ))
+package gqlsanitize
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const extensionName = "Sanitize"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Sanitize{}
+
+type (
+	// Sanitize is a gqlgen extension sanitizing string arguments before resolvers run
+	Sanitize struct {
+		*config
+		schema *ast.Schema
+
+		// sanitized fields, as "Object.field"
+		fields map[string]struct{}
+
+		// sanitizers compiled from the directives found in the schema
+		directives map[*ast.Directive][]Sanitizer
+	}
+)
+
+// New sanitization extension
+func New(opts ...Option) *Sanitize {
+	z := &Sanitize{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(z.config)
+	}
+	return z
+}
+
+// ExtensionName yields the extension name: "Sanitize"
+func (*Sanitize) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the directives declared in the schema, and prepare their sanitizers
+func (z *Sanitize) Validate(schema graphql.ExecutableSchema) error {
+	return z.prepare(schema.Schema())
+}
+
+// InterceptField implements the gqlgen field interceptor.
+//
+// Sanitized values replace the arguments of the field, before the resolver runs.
+func (z *Sanitize) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Args == nil || fc.Field.Field == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+	if _, ok := z.fields[fc.Object+"."+fc.Field.Name]; !ok {
+		return next(ctx)
+	}
+
+	for _, argDef := range fc.Field.Definition.Arguments {
+		value, ok := fc.Args[argDef.Name]
+		if !ok || value == nil {
+			continue
+		}
+		sanitized := z.sanitize(reflect.ValueOf(value), argDef.Type, z.sanitizers(argDef.Name, argDef.Directives))
+		fc.Args[argDef.Name] = sanitized.Interface()
+	}
+
+	return next(ctx)
+}
+
+// sanitizers yields the sanitizers applicable to an argument or an input field
+func (z *Sanitize) sanitizers(name string, directives ast.DirectiveList) []Sanitizer {
+	if d := directives.ForName(z.config.directive); d != nil {
+		if sanitizers, ok := z.directives[d]; ok {
+			return sanitizers
+		}
+	}
+	if sanitizers, ok := z.config.arguments[name]; ok {
+		return sanitizers
+	}
+	return z.config.defaults
+}
+
+// sanitize a value of a given GraphQL type, yielding a sanitized copy: values are never modified in place.
+func (z *Sanitize) sanitize(value reflect.Value, typ *ast.Type, sanitizers []Sanitizer) reflect.Value {
+	if typ == nil || !value.IsValid() {
+		return value
+	}
+
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		return z.sanitize(value.Elem(), typ, sanitizers)
+
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		out := reflect.New(value.Type().Elem())
+		out.Elem().Set(z.sanitize(value.Elem(), typ, sanitizers))
+		return out
+
+	case reflect.Slice:
+		if value.IsNil() || typ.Elem == nil {
+			return value
+		}
+		out := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			out.Index(i).Set(z.sanitize(value.Index(i), typ.Elem, sanitizers))
+		}
+		return out
+
+	case reflect.String:
+		if typ.Name() != "String" || len(sanitizers) == 0 {
+			return value
+		}
+		out := reflect.New(value.Type()).Elem()
+		out.SetString(apply(sanitizers, value.String()))
+		return out
+
+	case reflect.Map:
+		def := z.inputObject(typ)
+		if def == nil || value.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		out := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			elem := iter.Value()
+			if field := def.Fields.ForName(iter.Key().String()); field != nil {
+				elem = z.sanitize(elem, field.Type, z.sanitizers(field.Name, field.Directives))
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		return out
+
+	case reflect.Struct:
+		def := z.inputObject(typ)
+		if def == nil {
+			return value
+		}
+		out := reflect.New(value.Type()).Elem()
+		out.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			structField := value.Type().Field(i)
+			if structField.PkgPath != "" {
+				// unexported
+				continue
+			}
+			if field := def.Fields.ForName(fieldName(structField)); field != nil {
+				out.Field(i).Set(z.sanitize(value.Field(i), field.Type, z.sanitizers(field.Name, field.Directives)))
+			}
+		}
+		return out
+
+	default:
+		return value
+	}
+}
+
+func (z *Sanitize) inputObject(typ *ast.Type) *ast.Definition {
+	if z.schema == nil {
+		return nil
+	}
+	def := z.schema.Types[typ.Name()]
+	if def == nil || def.Kind != ast.InputObject {
+		return nil
+	}
+	return def
+}
+
+// fieldName yields the GraphQL name of a field of a struct generated by gqlgen, from its json tag
+func fieldName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "" || tag == "-" {
+		return field.Name
+	}
+	return tag
+}
+
+// prepare compiles the sanitizers declared with directives, and indexes the fields with sanitized arguments
+func (z *Sanitize) prepare(schema *ast.Schema) error {
+	z.schema = schema
+	z.fields = make(map[string]struct{})
+	z.directives = make(map[*ast.Directive][]Sanitizer)
+
+	for _, def := range schema.Types {
+		for _, field := range def.Fields {
+			if err := z.compile(def.Name+"."+field.Name, field.Directives); err != nil {
+				return err
+			}
+			for _, arg := range field.Arguments {
+				if err := z.compile(def.Name+"."+field.Name+"("+arg.Name+")", arg.Directives); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, def := range schema.Types {
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+		for _, field := range def.Fields {
+			for _, arg := range field.Arguments {
+				if z.isSanitized(arg.Name, arg.Directives, arg.Type, make(map[string]bool)) {
+					z.fields[def.Name+"."+field.Name] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isSanitized tells if some sanitizers apply to a value of this type, at any depth
+func (z *Sanitize) isSanitized(name string, directives ast.DirectiveList, typ *ast.Type, visiting map[string]bool) bool {
+	if typ.Name() == "String" {
+		return len(z.sanitizers(name, directives)) > 0
+	}
+	def := z.inputObject(typ)
+	if def == nil || visiting[def.Name] {
+		return false
+	}
+	visiting[def.Name] = true
+	for _, field := range def.Fields {
+		if z.isSanitized(field.Name, field.Directives, field.Type, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+func (z *Sanitize) compile(location string, directives ast.DirectiveList) error {
+	d := directives.ForName(z.config.directive)
+	if d == nil {
+		return nil
+	}
+
+	var sanitizers []Sanitizer
+	if arg := d.Arguments.ForName("with"); arg != nil && arg.Value != nil {
+		value, err := arg.Value.Value(nil)
+		if err != nil {
+			return fmt.Errorf("invalid @%s on %s: %v", z.config.directive, location, err)
+		}
+		names, _ := value.([]interface{})
+		for _, name := range names {
+			s, _ := name.(string)
+			sanitizer, ok := z.config.named[s]
+			if !ok {
+				return fmt.Errorf("invalid @%s on %s: unknown sanitizer %q", z.config.directive, location, s)
+			}
+			sanitizers = append(sanitizers, sanitizer)
+		}
+	}
+	if arg := d.Arguments.ForName("maxLength"); arg != nil && arg.Value != nil {
+		value, err := arg.Value.Value(nil)
+		if err != nil {
+			return fmt.Errorf("invalid @%s on %s: %v", z.config.directive, location, err)
+		}
+		if n, ok := value.(int64); ok && n > 0 {
+			sanitizers = append(sanitizers, MaxLength(int(n)))
+		}
+	}
+
+	z.directives[d] = sanitizers
+	return nil
+}
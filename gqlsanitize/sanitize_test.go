@@ -0,0 +1,101 @@
+package gqlsanitize
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+directive @sanitize(with: [String!], maxLength: Int) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+
+enum Role { ADMIN }
+
+input UserInput {
+  name: String! @sanitize(with: ["trim"], maxLength: 3)
+  email: String
+  aliases: [String!]
+  role: Role
+}
+
+type User { id: ID! }
+
+type Mutation {
+  createUser(input: UserInput!, comment: String, raw: String @sanitize): User!
+}
+`
+
+type userInput struct {
+	Name    string   `json:"name"`
+	Email   *string  `json:"email"`
+	Aliases []string `json:"aliases"`
+	Role    *string  `json:"role"`
+}
+
+func TestSanitize(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	z := New(
+		WithDefaults(Trim, StripControl),
+		WithArgument("email", Trim, strings.ToLower),
+	)
+	require.Equal(t, extensionName, z.ExtensionName())
+	require.NoError(t, z.prepare(schema))
+
+	email := " John@Example.COM "
+	role := " ADMIN "
+	input := userInput{
+		Name:    "  abcdef ",
+		Email:   &email,
+		Aliases: []string{" a\x00b "},
+		Role:    &role,
+	}
+	args := map[string]interface{}{
+		"input":   input,
+		"comment": " hello\x07 ",
+		"raw":     " raw ",
+	}
+
+	ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+		Object: "Mutation",
+		Args:   args,
+		Field: graphql.CollectedField{Field: &ast.Field{
+			Name:       "createUser",
+			Alias:      "createUser",
+			Definition: schema.Types["Mutation"].Fields.ForName("createUser"),
+		}},
+	})
+
+	_, err := z.InterceptField(ctx, func(_ context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	sanitized := args["input"].(userInput)
+	assert.Equal(t, "abc", sanitized.Name)
+	assert.Equal(t, "john@example.com", *sanitized.Email)
+	assert.Equal(t, []string{"ab"}, sanitized.Aliases)
+	assert.Equal(t, " ADMIN ", *sanitized.Role, "enums are not sanitized")
+	assert.Equal(t, "hello", args["comment"])
+	assert.Equal(t, " raw ", args["raw"], "an empty directive disables sanitization")
+
+	// the original values are left untouched
+	assert.Equal(t, " John@Example.COM ", email)
+	assert.Equal(t, "  abcdef ", input.Name)
+
+	require.Error(t, New().prepare(func() *ast.Schema {
+		invalid, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: `
+directive @sanitize(with: [String!], maxLength: Int) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+type Query { users(name: String @sanitize(with: ["unknown"])): Int }
+`})
+		require.Nil(t, gqlErr)
+		return invalid
+	}()))
+}
@@ -0,0 +1,57 @@
+package gqlsanitize
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sanitizer normalizes a string argument
+type Sanitizer func(string) string
+
+// Trim removes leading and trailing white space
+func Trim(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// StripControl removes control characters, except new lines and tabs
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// MaxLength truncates strings to a maximum number of characters
+func MaxLength(n int) Sanitizer {
+	return func(s string) string {
+		if len(s) <= n {
+			return s
+		}
+		i := 0
+		for pos := range s {
+			if i == n {
+				return s[:pos]
+			}
+			i++
+		}
+		return s
+	}
+}
+
+// Normalize applies a unicode normalization form, as provided by golang.org/x/text/unicode/norm.
+//
+// Example:
+//
+//   gqlsanitize.Normalize(norm.NFC.String)
+func Normalize(form func(string) string) Sanitizer {
+	return Sanitizer(form)
+}
+
+func apply(sanitizers []Sanitizer, s string) string {
+	for _, sanitize := range sanitizers {
+		s = sanitize(s)
+	}
+	return s
+}
@@ -0,0 +1,479 @@
+// Package gqlschemadiff compares two versions of a schema, and classifies changes as breaking, dangerous or safe,
+// so that deploy pipelines may gate on breaking changes.
+//
+// Breaking changes fail existing operations, e.g. removing a field or adding a required argument. Dangerous
+// changes may break clients at runtime without failing validation, e.g. adding an enum value or changing
+// the default value of an argument. Other changes are safe.
+//
+// Example:
+//
+//   changes, err := gqlschemadiff.CompareSDL(previousSDL, currentSDL)
+//   if err != nil {
+//     return err
+//   }
+//   for _, change := range changes.Breaking() {
+//     log.Println(change)
+//   }
+//
+// The gqlschemadiff command runs the same comparison in CI.
+package gqlschemadiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Criticality of a change
+type Criticality uint8
+
+const (
+	// Safe change
+	Safe Criticality = iota
+
+	// Dangerous change, which may break clients at runtime
+	Dangerous
+
+	// Breaking change, which fails existing operations
+	Breaking
+)
+
+// String representation of a criticality
+func (c Criticality) String() string {
+	switch c {
+	case Safe:
+		return "safe"
+	case Dangerous:
+		return "dangerous"
+	case Breaking:
+		return "breaking"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText represents a criticality as text
+func (c Criticality) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ParseCriticality parses the text representation of a criticality
+func ParseCriticality(text string) (Criticality, error) {
+	for _, c := range []Criticality{Safe, Dangerous, Breaking} {
+		if c.String() == text {
+			return c, nil
+		}
+	}
+	return Safe, fmt.Errorf("unknown criticality %q", text)
+}
+
+// Kinds of changes
+const (
+	KindTypeAdded                = "type-added"
+	KindTypeRemoved              = "type-removed"
+	KindTypeKindChanged          = "type-kind-changed"
+	KindFieldAdded               = "field-added"
+	KindFieldRemoved             = "field-removed"
+	KindFieldTypeChanged         = "field-type-changed"
+	KindFieldDeprecated          = "field-deprecated"
+	KindArgAdded                 = "arg-added"
+	KindArgRemoved               = "arg-removed"
+	KindArgTypeChanged           = "arg-type-changed"
+	KindArgDefaultChanged        = "arg-default-changed"
+	KindEnumValueAdded           = "enum-value-added"
+	KindEnumValueRemoved         = "enum-value-removed"
+	KindUnionMemberAdded         = "union-member-added"
+	KindUnionMemberRemoved       = "union-member-removed"
+	KindInterfaceAdded           = "interface-added"
+	KindInterfaceRemoved         = "interface-removed"
+	KindDirectiveAdded           = "directive-added"
+	KindDirectiveRemoved         = "directive-removed"
+	KindDirectiveLocationAdded   = "directive-location-added"
+	KindDirectiveLocationRemoved = "directive-location-removed"
+	KindRootTypeChanged          = "root-type-changed"
+)
+
+type (
+	// Change between two versions of a schema
+	Change struct {
+		Criticality Criticality `json:"criticality"`
+		Kind        string      `json:"kind"`
+
+		// Path of the changed element, e.g. "User", "User.email" or "Query.todos(first)"
+		Path string `json:"path"`
+
+		Message string `json:"message"`
+	}
+
+	// Changes between two versions of a schema
+	Changes []Change
+
+	differ struct {
+		changes Changes
+	}
+)
+
+// String representation of a change, e.g. "breaking field-removed User.email: field removed"
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s %s: %s", c.Criticality, c.Kind, c.Path, c.Message)
+}
+
+// Breaking changes
+func (c Changes) Breaking() Changes {
+	return c.AtLeast(Breaking)
+}
+
+// AtLeast yields the changes with a criticality at least equal to some level
+func (c Changes) AtLeast(level Criticality) Changes {
+	var changes Changes
+	for _, change := range c {
+		if change.Criticality >= level {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// CompareSDL compares two versions of a schema, as SDL documents
+func CompareSDL(previous, current string) (Changes, error) {
+	old, err := gqlparser.LoadSchema(&ast.Source{Name: "previous", Input: previous})
+	if err != nil {
+		return nil, err
+	}
+	updated, err := gqlparser.LoadSchema(&ast.Source{Name: "current", Input: current})
+	if err != nil {
+		return nil, err
+	}
+	return Compare(old, updated), nil
+}
+
+// Compare two versions of a schema. Changes are sorted by path.
+func Compare(previous, current *ast.Schema) Changes {
+	d := &differ{}
+	d.compareRoots(previous, current)
+
+	for _, name := range typeNames(previous, current) {
+		old, updated := previous.Types[name], current.Types[name]
+		switch {
+		case old == nil:
+			d.add(Safe, KindTypeAdded, name, "type %s added", strings.ToLower(string(updated.Kind)))
+		case updated == nil:
+			d.add(Breaking, KindTypeRemoved, name, "type %s removed", strings.ToLower(string(old.Kind)))
+		case old.Kind != updated.Kind:
+			d.add(Breaking, KindTypeKindChanged, name, "type changed from %s to %s",
+				strings.ToLower(string(old.Kind)), strings.ToLower(string(updated.Kind)))
+		default:
+			d.compareTypes(old, updated)
+		}
+	}
+
+	d.compareDirectives(previous.Directives, current.Directives)
+
+	sort.SliceStable(d.changes, func(i, j int) bool { return d.changes[i].Path < d.changes[j].Path })
+	return d.changes
+}
+
+func (d *differ) add(criticality Criticality, kind, path, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Criticality: criticality,
+		Kind:        kind,
+		Path:        path,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) compareRoots(previous, current *ast.Schema) {
+	for _, root := range []struct {
+		name         string
+		old, updated *ast.Definition
+	}{
+		{name: "query", old: previous.Query, updated: current.Query},
+		{name: "mutation", old: previous.Mutation, updated: current.Mutation},
+		{name: "subscription", old: previous.Subscription, updated: current.Subscription},
+	} {
+		if root.old != nil && (root.updated == nil || root.updated.Name != root.old.Name) {
+			d.add(Breaking, KindRootTypeChanged, "schema."+root.name, "%s root type %s changed", root.name, root.old.Name)
+		}
+	}
+}
+
+func (d *differ) compareTypes(old, updated *ast.Definition) {
+	switch old.Kind {
+	case ast.Object, ast.Interface:
+		d.compareFields(old, updated)
+		d.compareInterfaces(old, updated)
+	case ast.InputObject:
+		d.compareInputFields(old, updated)
+	case ast.Enum:
+		d.compareEnumValues(old, updated)
+	case ast.Union:
+		d.compareUnionMembers(old, updated)
+	}
+}
+
+func (d *differ) compareFields(old, updated *ast.Definition) {
+	for _, field := range old.Fields {
+		if isIntrospection(field.Name) {
+			continue
+		}
+		path := old.Name + "." + field.Name
+		newField := updated.Fields.ForName(field.Name)
+		if newField == nil {
+			d.add(Breaking, KindFieldRemoved, path, "field removed")
+			continue
+		}
+		if !safeOutputChange(field.Type, newField.Type) {
+			d.add(Breaking, KindFieldTypeChanged, path, "type changed from %s to %s", field.Type, newField.Type)
+		} else if field.Type.String() != newField.Type.String() {
+			d.add(Safe, KindFieldTypeChanged, path, "type changed from %s to %s", field.Type, newField.Type)
+		}
+		if field.Directives.ForName("deprecated") == nil && newField.Directives.ForName("deprecated") != nil {
+			d.add(Safe, KindFieldDeprecated, path, "field deprecated")
+		}
+		d.compareArgs(path, field.Arguments, newField.Arguments)
+	}
+	for _, field := range updated.Fields {
+		if !isIntrospection(field.Name) && old.Fields.ForName(field.Name) == nil {
+			d.add(Safe, KindFieldAdded, updated.Name+"."+field.Name, "field added")
+		}
+	}
+}
+
+func (d *differ) compareArgs(path string, old, updated ast.ArgumentDefinitionList) {
+	for _, arg := range old {
+		argPath := path + "(" + arg.Name + ")"
+		newArg := updated.ForName(arg.Name)
+		if newArg == nil {
+			d.add(Breaking, KindArgRemoved, argPath, "argument removed")
+			continue
+		}
+		d.compareInputValue(argPath, KindArgTypeChanged, arg.Type, newArg.Type)
+		d.compareDefaults(argPath, arg.DefaultValue, newArg.DefaultValue)
+	}
+	for _, arg := range updated {
+		if old.ForName(arg.Name) != nil {
+			continue
+		}
+		argPath := path + "(" + arg.Name + ")"
+		if isRequired(arg.Type, arg.DefaultValue) {
+			d.add(Breaking, KindArgAdded, argPath, "required argument added")
+		} else {
+			d.add(Safe, KindArgAdded, argPath, "optional argument added")
+		}
+	}
+}
+
+func (d *differ) compareInputFields(old, updated *ast.Definition) {
+	for _, field := range old.Fields {
+		path := old.Name + "." + field.Name
+		newField := updated.Fields.ForName(field.Name)
+		if newField == nil {
+			d.add(Breaking, KindFieldRemoved, path, "input field removed")
+			continue
+		}
+		d.compareInputValue(path, KindFieldTypeChanged, field.Type, newField.Type)
+		d.compareDefaults(path, field.DefaultValue, newField.DefaultValue)
+	}
+	for _, field := range updated.Fields {
+		if old.Fields.ForName(field.Name) != nil {
+			continue
+		}
+		path := updated.Name + "." + field.Name
+		if isRequired(field.Type, field.DefaultValue) {
+			d.add(Breaking, KindFieldAdded, path, "required input field added")
+		} else {
+			d.add(Safe, KindFieldAdded, path, "optional input field added")
+		}
+	}
+}
+
+func (d *differ) compareInputValue(path, kind string, old, updated *ast.Type) {
+	if !safeInputChange(old, updated) {
+		d.add(Breaking, kind, path, "type changed from %s to %s", old, updated)
+	} else if old.String() != updated.String() {
+		d.add(Safe, kind, path, "type changed from %s to %s", old, updated)
+	}
+}
+
+func (d *differ) compareDefaults(path string, old, updated *ast.Value) {
+	if valueString(old) != valueString(updated) {
+		d.add(Dangerous, KindArgDefaultChanged, path, "default value changed from %s to %s",
+			valueString(old), valueString(updated))
+	}
+}
+
+func (d *differ) compareEnumValues(old, updated *ast.Definition) {
+	for _, value := range old.EnumValues {
+		if updated.EnumValues.ForName(value.Name) == nil {
+			d.add(Breaking, KindEnumValueRemoved, old.Name+"."+value.Name, "enum value removed")
+		}
+	}
+	for _, value := range updated.EnumValues {
+		if old.EnumValues.ForName(value.Name) == nil {
+			d.add(Dangerous, KindEnumValueAdded, updated.Name+"."+value.Name,
+				"enum value added: clients may not handle it")
+		}
+	}
+}
+
+func (d *differ) compareUnionMembers(old, updated *ast.Definition) {
+	for _, member := range old.Types {
+		if !contains(updated.Types, member) {
+			d.add(Breaking, KindUnionMemberRemoved, old.Name, "member %s removed", member)
+		}
+	}
+	for _, member := range updated.Types {
+		if !contains(old.Types, member) {
+			d.add(Dangerous, KindUnionMemberAdded, updated.Name, "member %s added: clients may not handle it", member)
+		}
+	}
+}
+
+func (d *differ) compareInterfaces(old, updated *ast.Definition) {
+	for _, iface := range old.Interfaces {
+		if !contains(updated.Interfaces, iface) {
+			d.add(Breaking, KindInterfaceRemoved, old.Name, "interface %s no longer implemented", iface)
+		}
+	}
+	for _, iface := range updated.Interfaces {
+		if !contains(old.Interfaces, iface) {
+			d.add(Dangerous, KindInterfaceAdded, updated.Name,
+				"interface %s implemented: clients may not handle it as a possible type", iface)
+		}
+	}
+}
+
+func (d *differ) compareDirectives(old, updated map[string]*ast.DirectiveDefinition) {
+	for _, name := range directiveNames(old, updated) {
+		path := "@" + name
+		oldDirective, newDirective := old[name], updated[name]
+		switch {
+		case oldDirective == nil:
+			d.add(Safe, KindDirectiveAdded, path, "directive added")
+		case newDirective == nil:
+			d.add(Breaking, KindDirectiveRemoved, path, "directive removed")
+		default:
+			d.compareArgs(path, oldDirective.Arguments, newDirective.Arguments)
+			for _, location := range oldDirective.Locations {
+				if !containsLocation(newDirective.Locations, location) {
+					d.add(Breaking, KindDirectiveLocationRemoved, path, "location %s removed", location)
+				}
+			}
+			for _, location := range newDirective.Locations {
+				if !containsLocation(oldDirective.Locations, location) {
+					d.add(Safe, KindDirectiveLocationAdded, path, "location %s added", location)
+				}
+			}
+		}
+	}
+}
+
+// safeOutputChange tells if the type of a field may change without breaking operations: a nullable type may
+// become non-null, but not the other way around
+func safeOutputChange(old, updated *ast.Type) bool {
+	switch {
+	case old.NonNull:
+		return updated.NonNull && safeOutputChange(nullable(old), nullable(updated))
+	case old.Elem != nil:
+		return (!updated.NonNull && updated.Elem != nil && safeOutputChange(old.Elem, updated.Elem)) ||
+			(updated.NonNull && safeOutputChange(old, nullable(updated)))
+	default:
+		return (!updated.NonNull && updated.Elem == nil && updated.NamedType == old.NamedType) ||
+			(updated.NonNull && safeOutputChange(old, nullable(updated)))
+	}
+}
+
+// safeInputChange tells if the type of an argument or input field may change without breaking operations:
+// a non-null type may become nullable, but not the other way around
+func safeInputChange(old, updated *ast.Type) bool {
+	switch {
+	case old.NonNull:
+		if updated.NonNull {
+			return safeInputChange(nullable(old), nullable(updated))
+		}
+		return safeInputChange(nullable(old), updated)
+	case old.Elem != nil:
+		return !updated.NonNull && updated.Elem != nil && safeInputChange(old.Elem, updated.Elem)
+	default:
+		return !updated.NonNull && updated.Elem == nil && updated.NamedType == old.NamedType
+	}
+}
+
+func nullable(t *ast.Type) *ast.Type {
+	c := *t
+	c.NonNull = false
+	return &c
+}
+
+func isRequired(t *ast.Type, defaultValue *ast.Value) bool {
+	return t.NonNull && defaultValue == nil
+}
+
+func valueString(v *ast.Value) string {
+	if v == nil {
+		return "none"
+	}
+	return v.String()
+}
+
+func isIntrospection(name string) bool {
+	return strings.HasPrefix(name, "__")
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLocation(locations []ast.DirectiveLocation, location ast.DirectiveLocation) bool {
+	for _, l := range locations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
+
+// typeNames yields the sorted names of the types of both schemas, except built-in and introspection types
+func typeNames(previous, current *ast.Schema) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, schema := range []*ast.Schema{previous, current} {
+		for name, def := range schema.Types {
+			if def.BuiltIn || isIntrospection(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// directiveNames yields the sorted names of the directives of both schemas, except built-in directives
+func directiveNames(old, updated map[string]*ast.DirectiveDefinition) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, directives := range []map[string]*ast.DirectiveDefinition{old, updated} {
+		for name, directive := range directives {
+			if directive.Position != nil && directive.Position.Src != nil && directive.Position.Src.BuiltIn {
+				continue
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,108 @@
+package gqlschemadiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const previous = `
+	directive @auth(role: String) on FIELD_DEFINITION | OBJECT
+	type Query {
+		todos(first: Int = 10, status: Status): [Todo!]!
+		user(id: ID!): User
+	}
+	type Todo { id: ID! text: String done: Boolean! }
+	type User { id: ID! name: String! email: String! }
+	enum Status { OPEN DONE }
+	union Result = Todo | User
+	input TodoInput { text: String! tags: [String!] }
+	type Legacy { id: ID! }
+`
+
+const current = `
+	directive @auth(role: String) on FIELD_DEFINITION
+	type Query {
+		todos(first: Int = 20, status: Status, owner: ID!): [Todo!]!
+		user(id: ID): User
+	}
+	type Todo { id: ID! text: String! done: String! }
+	type User { id: ID! name: String! @deprecated(reason: "use fullName") fullName: String! }
+	enum Status { OPEN DONE ARCHIVED }
+	union Result = Todo
+	input TodoInput { text: String! tags: [String!] due: String! }
+	type Team { id: ID! }
+`
+
+func TestCompare(t *testing.T) {
+	changes, err := CompareSDL(previous, current)
+	require.NoError(t, err)
+
+	byPath := make(map[string][]Change)
+	for _, change := range changes {
+		byPath[change.Path+" "+change.Kind] = append(byPath[change.Path+" "+change.Kind], change)
+	}
+	expect := func(path, kind string, criticality Criticality) {
+		t.Helper()
+		found, ok := byPath[path+" "+kind]
+		if assert.True(t, ok, "expected %s %s", kind, path) {
+			assert.Equal(t, criticality, found[0].Criticality, "%s %s", kind, path)
+		}
+	}
+
+	expect("@auth", KindDirectiveLocationRemoved, Breaking)
+	expect("Query.todos(first)", KindArgDefaultChanged, Dangerous)
+	expect("Query.todos(owner)", KindArgAdded, Breaking)
+	expect("Query.user(id)", KindArgTypeChanged, Safe)
+	expect("Todo.text", KindFieldTypeChanged, Safe)
+	expect("Todo.done", KindFieldTypeChanged, Breaking)
+	expect("User.name", KindFieldDeprecated, Safe)
+	expect("User.fullName", KindFieldAdded, Safe)
+	expect("User.email", KindFieldRemoved, Breaking)
+	expect("Status.ARCHIVED", KindEnumValueAdded, Dangerous)
+	expect("Result", KindUnionMemberRemoved, Breaking)
+	expect("TodoInput.due", KindFieldAdded, Breaking)
+	expect("Legacy", KindTypeRemoved, Breaking)
+	expect("Team", KindTypeAdded, Safe)
+
+	assert.Len(t, changes, 14)
+	assert.Len(t, changes.Breaking(), 7)
+	assert.Len(t, changes.AtLeast(Dangerous), 9)
+}
+
+func TestTypeChanges(t *testing.T) {
+	for _, tc := range []struct {
+		old, new      string
+		output, input bool
+	}{
+		{old: "String", new: "String!", output: true, input: false},
+		{old: "String!", new: "String", output: false, input: true},
+		{old: "[String]", new: "[String!]!", output: true, input: false},
+		{old: "[String!]!", new: "[String]", output: false, input: true},
+		{old: "String", new: "[String]", output: false, input: false},
+		{old: "String", new: "ID", output: false, input: false},
+	} {
+		oldSchema := "type Query { f(a: " + tc.old + "): " + tc.old + " }"
+		newSchema := "type Query { f(a: " + tc.new + "): " + tc.new + " }"
+		changes, err := CompareSDL(oldSchema, newSchema)
+		require.NoError(t, err)
+		require.Len(t, changes, 2, "%s => %s", tc.old, tc.new)
+
+		for _, change := range changes {
+			safe := change.Criticality == Safe
+			switch change.Kind {
+			case KindFieldTypeChanged:
+				assert.Equal(t, tc.output, safe, "output %s => %s", tc.old, tc.new)
+			case KindArgTypeChanged:
+				assert.Equal(t, tc.input, safe, "input %s => %s", tc.old, tc.new)
+			}
+		}
+	}
+}
+
+func TestNoChange(t *testing.T) {
+	changes, err := CompareSDL(previous, previous)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
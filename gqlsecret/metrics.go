@@ -0,0 +1,44 @@
+package gqlsecret
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before refreshing secrets.
+func Register() error {
+	return gqlmetrics.Register(RefreshCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(RefreshCountView)
+}
+
+var (
+	// TagSecret is the name of a secret
+	TagSecret = tag.MustNewKey("gql.secret")
+
+	// TagResult is the result of the refresh of a secret (rotated, unchanged or error)
+	TagResult = tag.MustNewKey("gql.secret_result")
+
+	// RefreshCount tracks a count of refreshes of secrets
+	RefreshCount = stats.Int64(
+		"gql/server/secret_refresh_count",
+		"Number of refreshes of secrets",
+		stats.UnitDimensionless)
+
+	// RefreshCountView reports a count of refreshes of secrets by secret and result
+	RefreshCountView = &view.View{
+		Name:        "gql/server/secret_refresh_count",
+		Description: "Count of refreshes of secrets by secret and result",
+		Measure:     RefreshCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagSecret, TagResult},
+	}
+)
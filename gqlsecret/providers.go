@@ -0,0 +1,156 @@
+package gqlsecret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	_ Provider = &Env{}
+	_ Provider = &File{}
+	_ Provider = &Vault{}
+)
+
+type (
+	// Env provides secrets from environment variables: the name of secrets is upper-cased and prefixed,
+	// and its characters other than letters and digits are replaced by underscores,
+	// e.g. "hive-token" is read from GQL_SECRET_HIVE_TOKEN with the prefix "GQL_SECRET_"
+	Env struct {
+		prefix string
+	}
+
+	// File provides secrets from the files of a directory, e.g. the mount point of Kubernetes secrets.
+	// Trailing newlines are trimmed.
+	File struct {
+		dir string
+	}
+
+	// Vault provides secrets from the KV version 2 secrets engine of HashiCorp Vault.
+	//
+	// The name of secrets is the path of the secret, and the key of the value, separated by "#",
+	// e.g. "gql/hive#token". The key defaults to "value".
+	Vault struct {
+		addr   string
+		mount  string
+		token  func() string
+		client *http.Client
+	}
+
+	vaultResponse struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+)
+
+// NewEnv builds a provider of secrets from environment variables
+func NewEnv(prefix string) *Env {
+	return &Env{prefix: prefix}
+}
+
+// Fetch implements Provider
+func (e *Env) Fetch(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(e.prefix + envName(name))
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// NewFile builds a provider of secrets from the files of a directory
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+// Fetch implements Provider
+func (f *File) Fetch(_ context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(f.dir, name))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf), "\r\n"), nil
+}
+
+// NewVault builds a provider of secrets from Vault, at an address such as "https://vault:8200",
+// with the KV secrets engine mounted at mount (e.g. "secret").
+//
+// The token is read for every request, so that it may be renewed, e.g. by a Vault agent writing it to a file.
+// A nil client defaults to http.DefaultClient.
+func NewVault(addr, mount string, token func() string, client *http.Client) *Vault {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Vault{
+		addr:   strings.TrimSuffix(addr, "/"),
+		mount:  strings.Trim(mount, "/"),
+		token:  token,
+		client: client,
+	}
+}
+
+// Fetch implements Provider
+func (v *Vault) Fetch(ctx context.Context, name string) (string, error) {
+	path, key := name, "value"
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		path, key = name[:i], name[i+1:]
+	}
+
+	req, err := http.NewRequest(http.MethodGet, v.addr+"/v1/"+v.mount+"/data/"+strings.Trim(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", v.token())
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ErrNotFound
+	default:
+		return "", fmt.Errorf("vault responded with status %d", resp.StatusCode)
+	}
+
+	var decoded vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return "", err
+	}
+	value, ok := decoded.Data.Data[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("secret %q is not a string", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,172 @@
+// Package gqlsecret provides secrets to the extensions requiring credentials, e.g. the tokens of usage reporters
+// or the passwords of Redis and Kafka clients, from pluggable providers: environment variables, files
+// (e.g. Kubernetes secrets) or HashiCorp Vault.
+//
+// Secrets are refreshed periodically, so that long-running servers pick up rotated credentials without restart.
+// Consumers either read the current value of a secret when they need it, or register a rotation callback.
+//
+// Example:
+//
+//   provider := gqlsecret.Chain(gqlsecret.NewEnv("GQL_SECRET_"), gqlsecret.NewFile("/var/run/secrets/gql"))
+//   token, err := gqlsecret.Load(ctx, provider, "hive-token")
+//   if err != nil {
+//     log.Fatal(err)
+//   }
+//   go token.Watch(ctx, time.Minute)
+//
+//   srv.Use(gqlhive.New("", gqlhive.WithTokenFunc(token.Value)))
+//
+// Clients which hold their credentials register a rotation callback:
+//
+//   password.OnRotate(func(value string) { redisClient.Options().Password = value })
+package gqlsecret
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// ErrNotFound is returned by providers when a secret is not found
+var ErrNotFound = errors.New("secret not found")
+
+// Results of refreshes, reported as a tag of metrics
+const (
+	ResultRotated   = "rotated"
+	ResultUnchanged = "unchanged"
+	ResultError     = "error"
+)
+
+type (
+	// Provider fetches the value of secrets by name
+	Provider interface {
+		Fetch(ctx context.Context, name string) (string, error)
+	}
+
+	// ProviderFunc is a function implementing Provider
+	ProviderFunc func(ctx context.Context, name string) (string, error)
+
+	// Secret is the current value of a secret, refreshed from its provider.
+	//
+	// It is safe for concurrent use.
+	Secret struct {
+		provider Provider
+		name     string
+
+		mx        sync.RWMutex
+		value     string
+		callbacks []func(string)
+	}
+
+	chain []Provider
+)
+
+// Fetch implements Provider
+func (f ProviderFunc) Fetch(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// Chain providers: secrets are fetched from the first provider knowing them
+func Chain(providers ...Provider) Provider {
+	return chain(providers)
+}
+
+// Fetch implements Provider
+func (c chain) Fetch(ctx context.Context, name string) (string, error) {
+	for _, provider := range c {
+		value, err := provider.Fetch(ctx, name)
+		if err != ErrNotFound {
+			return value, err
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Load a secret from a provider, failing when its initial value cannot be fetched
+func Load(ctx context.Context, provider Provider, name string) (*Secret, error) {
+	value, err := provider.Fetch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{provider: provider, name: name, value: value}, nil
+}
+
+// Static secret, which never rotates, e.g. in tests
+func Static(value string) *Secret {
+	return &Secret{value: value}
+}
+
+// Name of the secret
+func (s *Secret) Name() string {
+	return s.name
+}
+
+// Value yields the current value of the secret
+func (s *Secret) Value() string {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.value
+}
+
+// OnRotate registers a callback, called with the new value of the secret whenever it is rotated
+func (s *Secret) OnRotate(callback func(string)) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.callbacks = append(s.callbacks, callback)
+}
+
+// Refresh the secret from its provider. Rotation callbacks are called when its value changed.
+//
+// On failure, the previous value is retained.
+func (s *Secret) Refresh(ctx context.Context) error {
+	if s.provider == nil {
+		return nil
+	}
+	value, err := s.provider.Fetch(ctx, s.name)
+	if err != nil {
+		s.record(ctx, ResultError)
+		return err
+	}
+
+	s.mx.Lock()
+	if value == s.value {
+		s.mx.Unlock()
+		s.record(ctx, ResultUnchanged)
+		return nil
+	}
+	s.value = value
+	callbacks := s.callbacks
+	s.mx.Unlock()
+
+	s.record(ctx, ResultRotated)
+	for _, callback := range callbacks {
+		callback(value)
+	}
+	return nil
+}
+
+// Watch refreshes the secret periodically, until the context is done. Refresh errors are ignored:
+// the previous value is retained until the next successful refresh.
+func (s *Secret) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.Refresh(ctx)
+		}
+	}
+}
+
+func (s *Secret) record(ctx context.Context, result string) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(TagSecret, s.name),
+		tag.Upsert(TagResult, result),
+	}, RefreshCount.M(1))
+}
@@ -0,0 +1,139 @@
+package gqlsecret
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("env", func(t *testing.T) {
+		require.NoError(t, os.Setenv("GQLSECRET_TEST_HIVE_TOKEN", "t0k3n"))
+		defer func() { _ = os.Unsetenv("GQLSECRET_TEST_HIVE_TOKEN") }()
+
+		env := NewEnv("GQLSECRET_TEST_")
+		value, err := env.Fetch(ctx, "hive-token")
+		require.NoError(t, err)
+		assert.Equal(t, "t0k3n", value)
+
+		_, err = env.Fetch(ctx, "missing")
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "gqlsecret")
+		require.NoError(t, err)
+		defer func() { _ = os.RemoveAll(dir) }()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "redis-password"), []byte("p4ss\n"), 0600))
+
+		file := NewFile(dir)
+		value, err := file.Fetch(ctx, "redis-password")
+		require.NoError(t, err)
+		assert.Equal(t, "p4ss", value)
+
+		_, err = file.Fetch(ctx, "missing")
+		assert.Equal(t, ErrNotFound, err)
+		_, err = file.Fetch(ctx, "../etc/passwd")
+		assert.Error(t, err)
+	})
+
+	t.Run("vault", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "root" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if r.URL.Path != "/v1/secret/data/gql/hive" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"data":{"token":"v4ult","value":"default"},"metadata":{"version":3}}}`))
+		}))
+		defer server.Close()
+
+		vault := NewVault(server.URL, "secret", func() string { return "root" }, nil)
+		value, err := vault.Fetch(ctx, "gql/hive#token")
+		require.NoError(t, err)
+		assert.Equal(t, "v4ult", value)
+
+		value, err = vault.Fetch(ctx, "gql/hive")
+		require.NoError(t, err)
+		assert.Equal(t, "default", value)
+
+		_, err = vault.Fetch(ctx, "gql/hive#missing")
+		assert.Equal(t, ErrNotFound, err)
+		_, err = vault.Fetch(ctx, "gql/other")
+		assert.Equal(t, ErrNotFound, err)
+
+		_, err = NewVault(server.URL, "secret", func() string { return "expired" }, nil).Fetch(ctx, "gql/hive")
+		assert.Error(t, err)
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		first := ProviderFunc(func(_ context.Context, name string) (string, error) {
+			if name == "a" {
+				return "first", nil
+			}
+			return "", ErrNotFound
+		})
+		second := ProviderFunc(func(context.Context, string) (string, error) { return "second", nil })
+
+		value, err := Chain(first, second).Fetch(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "first", value)
+
+		value, err = Chain(first, second).Fetch(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, "second", value)
+
+		_, err = Chain(first).Fetch(ctx, "b")
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestRotation(t *testing.T) {
+	ctx := context.Background()
+	current, failing := "v1", false
+	provider := ProviderFunc(func(context.Context, string) (string, error) {
+		if failing {
+			return "", errors.New("unavailable")
+		}
+		return current, nil
+	})
+
+	secret, err := Load(ctx, provider, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "token", secret.Name())
+	assert.Equal(t, "v1", secret.Value())
+
+	var rotated []string
+	secret.OnRotate(func(value string) { rotated = append(rotated, value) })
+
+	require.NoError(t, secret.Refresh(ctx))
+	assert.Empty(t, rotated, "unchanged values do not trigger callbacks")
+
+	current = "v2"
+	require.NoError(t, secret.Refresh(ctx))
+	assert.Equal(t, "v2", secret.Value())
+	assert.Equal(t, []string{"v2"}, rotated)
+
+	failing = true
+	assert.Error(t, secret.Refresh(ctx))
+	assert.Equal(t, "v2", secret.Value(), "failed refreshes retain the previous value")
+
+	_, err = Load(ctx, provider, "token")
+	assert.Error(t, err)
+
+	assert.Equal(t, "fixed", Static("fixed").Value())
+	assert.NoError(t, Static("fixed").Refresh(ctx))
+}
@@ -0,0 +1,60 @@
+package gqlshadow
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(ShadowViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(ShadowViews...)
+}
+
+var (
+	// ShadowViews contains all opencensus stats views declared by the shadow extension
+	ShadowViews = []*view.View{
+		ShadowCountView,
+		ShadowDiffCountView,
+	}
+
+	// ShadowCount tracks a count of queries mirrored to the secondary schema
+	ShadowCount = stats.Int64(
+		"gql/server/shadow_count",
+		"Number of GraphQL queries mirrored to the secondary schema",
+		stats.UnitDimensionless)
+
+	// ShadowDiffCount tracks a count of mirrored queries with different results
+	ShadowDiffCount = stats.Int64(
+		"gql/server/shadow_diff_count",
+		"Number of mirrored GraphQL queries with results differing from the primary schema",
+		stats.UnitDimensionless)
+
+	// ShadowCountView reports a count of mirrored queries tagged by operation name
+	ShadowCountView = &view.View{
+		Name:        "gql/server/shadow_count",
+		Description: "Count of GraphQL queries mirrored to the secondary schema by operation",
+		Measure:     ShadowCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// ShadowDiffCountView reports a count of mirrored queries with different results tagged by operation name
+	ShadowDiffCountView = &view.View{
+		Name:        "gql/server/shadow_diff_count",
+		Description: "Count of mirrored GraphQL queries with different results by operation",
+		Measure:     ShadowDiffCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
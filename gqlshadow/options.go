@@ -0,0 +1,100 @@
+package gqlshadow
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the shadow extension
+	Option func(*config)
+
+	config struct {
+		rate        float64
+		maxInFlight int
+		timeout     time.Duration
+		sink        Sink
+		deriveCtx   func(context.Context) context.Context
+		diffOptions []gqldiff.Option
+		opLabel     gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		rate:        0.01,
+		maxInFlight: 10,
+		timeout:     10 * time.Second,
+		opLabel:     gqllabel.OperationName,
+	}
+}
+
+// context yields the context of a shadow execution, derived from the detached context of the primary one
+func (c config) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.deriveCtx != nil {
+		ctx = c.deriveCtx(ctx)
+	}
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// WithRate sets the fraction of queries mirrored to the secondary schema, between 0 and 1. The default is 0.01.
+func WithRate(rate float64) Option {
+	return func(c *config) {
+		c.rate = rate
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent shadow executions. Queries exceeding this limit are not mirrored.
+// The default is 10.
+func WithMaxInFlight(max int) Option {
+	return func(c *config) {
+		if max > 0 {
+			c.maxInFlight = max
+		}
+	}
+}
+
+// WithTimeout sets the timeout of shadow executions. The default is 10s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithSink sets the Sink receiving the differences between primary and secondary results
+func WithSink(sink Sink) Option {
+	return func(c *config) {
+		c.sink = sink
+	}
+}
+
+// WithContext derives the context of shadow executions. By default, this context carries all the values
+// of the primary context, without its deadline or cancellation.
+func WithContext(derive func(context.Context) context.Context) Option {
+	return func(c *config) {
+		c.deriveCtx = derive
+	}
+}
+
+// WithDiffOptions sets the options used to compare primary and secondary results,
+// e.g. to ignore volatile fields with gqldiff.IgnoreFields.
+func WithDiffOptions(opts ...gqldiff.Option) Option {
+	return func(c *config) {
+		c.diffOptions = append(c.diffOptions, opts...)
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
@@ -0,0 +1,206 @@
+// Package gqlshadow provides a gqlgen extension to mirror a sample of queries to a secondary executable schema,
+// and compare its results with the primary ones.
+//
+// This enables safe resolver rewrites: the client only ever gets the primary response, while differences
+// with the new implementation are reported via metrics and a Sink.
+package gqlshadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/internal/detach"
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+const extensionName = "Shadow"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Shadow{}
+
+type (
+	// Shadow is a gqlgen extension which mirrors a sample of queries to a secondary executable schema.
+	//
+	// Mutations and subscriptions are never mirrored.
+	Shadow struct {
+		*config
+		secondary http.Handler
+		inflight  chan struct{}
+	}
+
+	// Diff reports a difference between the primary and secondary results of a query
+	Diff struct {
+		OperationName   string
+		Query           string
+		Variables       map[string]interface{}
+		Primary         json.RawMessage
+		Secondary       json.RawMessage
+		PrimaryErrors   gqlerror.List
+		SecondaryErrors gqlerror.List
+
+		// Changes in the data of the secondary response, compared to the primary one
+		Changes []gqldiff.Change
+	}
+
+	// Sink receives the differences found by the Shadow extension
+	Sink interface {
+		Report(context.Context, Diff)
+	}
+
+	// SinkFunc is a function implementing Sink
+	SinkFunc func(context.Context, Diff)
+)
+
+// Report implements Sink
+func (f SinkFunc) Report(ctx context.Context, diff Diff) {
+	f(ctx, diff)
+}
+
+// New Shadow extension, mirroring queries to the secondary executable schema.
+func New(secondary graphql.ExecutableSchema, opts ...Option) *Shadow {
+	s := &Shadow{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(s.config)
+	}
+
+	srv := handler.New(secondary)
+	srv.AddTransport(transport.POST{})
+	s.secondary = srv
+	s.inflight = make(chan struct{}, s.config.maxInFlight)
+
+	return s
+}
+
+// ExtensionName yields the extension name: "Shadow"
+func (Shadow) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (Shadow) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (s *Shadow) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	oc := graphql.GetOperationContext(ctx)
+	if resp == nil || oc.Operation == nil || oc.Operation.Operation != ast.Query {
+		return resp
+	}
+	if s.config.rate <= 0 || rand.Float64() >= s.config.rate {
+		return resp
+	}
+
+	select {
+	case s.inflight <- struct{}{}:
+	default:
+		// too many shadow executions in flight: skip this one
+		return resp
+	}
+
+	diff := Diff{
+		OperationName: oc.OperationName,
+		Query:         oc.RawQuery,
+		Variables:     oc.Variables,
+		Primary:       append(json.RawMessage(nil), resp.Data...),
+		PrimaryErrors: resp.Errors,
+	}
+
+	label := s.config.opLabel(oc)
+	shadowCtx, cancel := s.config.context(detach.Context(ctx))
+	go func() {
+		defer func() {
+			// a failing shadow execution must never affect the service, e.g. a panicking sink
+			_ = recover()
+			cancel()
+			<-s.inflight
+		}()
+		s.compare(shadowCtx, diff, label)
+	}()
+
+	return resp
+}
+
+func (s *Shadow) compare(ctx context.Context, diff Diff, label string) {
+	tags := []tag.Mutator{tag.Upsert(metrics.TagOperation, label)}
+	_ = stats.RecordWithTags(ctx, tags, ShadowCount.M(1))
+
+	secondary, err := s.execute(ctx, diff)
+	if err != nil {
+		diff.SecondaryErrors = gqlerror.List{gqlerror.Errorf("shadow execution failed: %v", err)}
+	} else {
+		diff.Secondary = secondary.Data
+		diff.SecondaryErrors = secondary.Errors
+		if s.sameResults(&diff) {
+			return
+		}
+	}
+
+	_ = stats.RecordWithTags(ctx, tags, ShadowDiffCount.M(1))
+	if s.config.sink != nil {
+		s.config.sink.Report(ctx, diff)
+	}
+}
+
+func (s *Shadow) execute(ctx context.Context, diff Diff) (resp *graphql.Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	body, err := json.Marshal(graphql.RawParams{
+		Query:         diff.Query,
+		OperationName: diff.OperationName,
+		Variables:     diff.Variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httpwriter.NewBuffer()
+	s.secondary.ServeHTTP(w, req)
+
+	resp = new(graphql.Response)
+	if err := json.Unmarshal(w.Bytes(), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Shadow) sameResults(diff *Diff) bool {
+	changes, err := gqldiff.Compare(diff.Primary, diff.Secondary, s.config.diffOptions...)
+	if err != nil {
+		return false
+	}
+	diff.Changes = changes
+
+	return len(changes) == 0 && len(diff.PrimaryErrors) == len(diff.SecondaryErrors)
+}
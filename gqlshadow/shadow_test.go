@@ -0,0 +1,148 @@
+package gqlshadow
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+type Query {
+  todos: [String!]!
+}
+
+type Mutation {
+  done(todo: String!): Boolean!
+}
+`
+
+type recordingSink struct {
+	mx    sync.Mutex
+	diffs []Diff
+}
+
+func (r *recordingSink) Report(_ context.Context, diff Diff) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.diffs = append(r.diffs, diff)
+}
+
+func (r *recordingSink) reported() []Diff {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return append([]Diff(nil), r.diffs...)
+}
+
+func TestShadow(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	const primaryData = `{"todos":["a","b"]}`
+
+	// secondary executable schema, resolving with exec
+	secondary := func(executions *int32, exec func() *graphql.Response) graphql.ExecutableSchema {
+		return &graphql.ExecutableSchemaMock{
+			SchemaFunc: func() *ast.Schema { return schema },
+			ExecFunc: func(ctx context.Context) graphql.ResponseHandler {
+				atomic.AddInt32(executions, 1)
+				return graphql.OneShot(exec())
+			},
+		}
+	}
+
+	// serve a primary response, then wait for the shadow execution to complete
+	serve := func(s *Shadow, operation ast.Operation, query string) *graphql.Response {
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+			RawQuery:  query,
+			Operation: &ast.OperationDefinition{Operation: operation},
+		})
+		resp := s.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+			return &graphql.Response{Data: json.RawMessage(primaryData)}
+		})
+
+		// in-flight shadow executions release their slot when done
+		for i := 0; i < cap(s.inflight); i++ {
+			s.inflight <- struct{}{}
+		}
+		for i := 0; i < cap(s.inflight); i++ {
+			<-s.inflight
+		}
+		return resp
+	}
+
+	t.Run("sampling", func(t *testing.T) {
+		var executions int32
+		es := secondary(&executions, func() *graphql.Response {
+			return &graphql.Response{Data: json.RawMessage(primaryData)}
+		})
+
+		for i := 0; i < 10; i++ {
+			serve(New(es, WithRate(0)), ast.Query, `{ todos }`)
+		}
+		assert.Equal(t, int32(0), atomic.LoadInt32(&executions))
+
+		s := New(es, WithRate(1))
+		for i := 0; i < 10; i++ {
+			serve(s, ast.Query, `{ todos }`)
+		}
+		assert.Equal(t, int32(10), atomic.LoadInt32(&executions))
+
+		serve(s, ast.Mutation, `mutation { done(todo: "a") }`)
+		assert.Equal(t, int32(10), atomic.LoadInt32(&executions), "mutations are never mirrored")
+	})
+
+	t.Run("comparison", func(t *testing.T) {
+		var executions int32
+		sink := &recordingSink{}
+		data := primaryData
+		s := New(secondary(&executions, func() *graphql.Response {
+			return &graphql.Response{Data: json.RawMessage(data)}
+		}), WithRate(1), WithSink(sink))
+
+		serve(s, ast.Query, `{ todos }`)
+		assert.Empty(t, sink.reported(), "same results are not reported")
+
+		data = `{"todos":["a","c"]}`
+		resp := serve(s, ast.Query, `{ todos }`)
+		assert.JSONEq(t, primaryData, string(resp.Data))
+
+		diffs := sink.reported()
+		require.Len(t, diffs, 1)
+		assert.JSONEq(t, primaryData, string(diffs[0].Primary))
+		assert.JSONEq(t, data, string(diffs[0].Secondary))
+		assert.NotEmpty(t, diffs[0].Changes)
+		assert.Empty(t, diffs[0].SecondaryErrors)
+	})
+
+	t.Run("failures do not affect the primary", func(t *testing.T) {
+		var executions int32
+		sink := &recordingSink{}
+		s := New(secondary(&executions, func() *graphql.Response {
+			panic("boom")
+		}), WithRate(1), WithSink(sink))
+
+		resp := serve(s, ast.Query, `{ todos }`)
+		assert.JSONEq(t, primaryData, string(resp.Data))
+		assert.Empty(t, resp.Errors)
+
+		diffs := sink.reported()
+		require.Len(t, diffs, 1)
+		assert.NotEmpty(t, diffs[0].SecondaryErrors)
+
+		s = New(secondary(&executions, func() *graphql.Response {
+			return &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}
+		}), WithRate(1), WithSink(SinkFunc(func(context.Context, Diff) {
+			panic("sink failure")
+		})))
+		resp = serve(s, ast.Query, `{ todos }`)
+		assert.JSONEq(t, primaryData, string(resp.Data))
+	})
+}
@@ -0,0 +1,116 @@
+package gqlshape
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(ShapeViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(ShapeViews...)
+}
+
+var (
+	// ShapeViews contains all opencensus stats views declared by the query shape collector
+	ShapeViews = []*view.View{
+		QueryDepthView,
+		QueryFieldCountView,
+		QueryAliasCountView,
+		QueryFragmentCountView,
+		QueryComplexityView,
+	}
+
+	// QueryDepth tracks the depth of queries
+	QueryDepth = stats.Int64(
+		"gql/server/query_depth",
+		"Depth of GraphQL queries",
+		stats.UnitDimensionless)
+
+	// QueryFieldCount tracks the number of fields of queries
+	QueryFieldCount = stats.Int64(
+		"gql/server/query_field_count",
+		"Number of fields of GraphQL queries",
+		stats.UnitDimensionless)
+
+	// QueryAliasCount tracks the number of aliases of queries
+	QueryAliasCount = stats.Int64(
+		"gql/server/query_alias_count",
+		"Number of aliased fields of GraphQL queries",
+		stats.UnitDimensionless)
+
+	// QueryFragmentCount tracks the number of fragments of queries
+	QueryFragmentCount = stats.Int64(
+		"gql/server/query_fragment_count",
+		"Number of fragments of GraphQL queries",
+		stats.UnitDimensionless)
+
+	// QueryComplexity tracks the complexity of queries, as computed by the extension.ComplexityLimit extension
+	QueryComplexity = stats.Int64(
+		"gql/server/query_complexity",
+		"Complexity of GraphQL queries",
+		stats.UnitDimensionless)
+
+	// DepthDistribution constructs buckets for depth distributions in views
+	DepthDistribution = view.Distribution(1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 15, 20, 30)
+
+	// CountDistribution constructs buckets for count and complexity distributions in views
+	CountDistribution = view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000)
+
+	// QueryDepthView reports a distribution of the depth of queries, by client
+	QueryDepthView = &view.View{
+		Name:        "gql/server/query_depth",
+		Description: "Distribution of the depth of GraphQL queries by client",
+		Measure:     QueryDepth,
+		Aggregation: DepthDistribution,
+		TagKeys:     []tag.Key{TagClient},
+	}
+
+	// QueryFieldCountView reports a distribution of the number of fields of queries, by client
+	QueryFieldCountView = &view.View{
+		Name:        "gql/server/query_field_count",
+		Description: "Distribution of the number of fields of GraphQL queries by client",
+		Measure:     QueryFieldCount,
+		Aggregation: CountDistribution,
+		TagKeys:     []tag.Key{TagClient},
+	}
+
+	// QueryAliasCountView reports a distribution of the number of aliases of queries, by client
+	QueryAliasCountView = &view.View{
+		Name:        "gql/server/query_alias_count",
+		Description: "Distribution of the number of aliased fields of GraphQL queries by client",
+		Measure:     QueryAliasCount,
+		Aggregation: CountDistribution,
+		TagKeys:     []tag.Key{TagClient},
+	}
+
+	// QueryFragmentCountView reports a distribution of the number of fragments of queries, by client
+	QueryFragmentCountView = &view.View{
+		Name:        "gql/server/query_fragment_count",
+		Description: "Distribution of the number of fragments of GraphQL queries by client",
+		Measure:     QueryFragmentCount,
+		Aggregation: CountDistribution,
+		TagKeys:     []tag.Key{TagClient},
+	}
+
+	// QueryComplexityView reports a distribution of the complexity of queries, by client
+	QueryComplexityView = &view.View{
+		Name:        "gql/server/query_complexity",
+		Description: "Distribution of the complexity of GraphQL queries by client",
+		Measure:     QueryComplexity,
+		Aggregation: CountDistribution,
+		TagKeys:     []tag.Key{TagClient},
+	}
+
+	// TagClient is the client issuing the query
+	TagClient = tag.MustNewKey("gql.client")
+)
@@ -0,0 +1,28 @@
+package gqlshape
+
+import (
+	"context"
+)
+
+type (
+	// Option for the query shape collector
+	Option func(*config)
+
+	config struct {
+		client func(context.Context) string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		client: func(_ context.Context) string { return "-" },
+	}
+}
+
+// WithClient sets the function to identify the client from the context, e.g. from an API key.
+// By default, all queries are tagged with the anonymous client "-".
+func WithClient(client func(context.Context) string) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
@@ -0,0 +1,129 @@
+// Package gqlshape provides a gqlgen extension recording statistics on the shape of queries: depth, number of
+// fields, aliases and fragments, and complexity.
+//
+// Distributions are recorded as opencensus metrics, per client. This helps capacity planning, and tells which
+// limits may be enforced (e.g. with extension.FixedComplexityLimit) without breaking legitimate clients.
+//
+// Example:
+//
+//   srv.Use(gqlshape.New(gqlshape.WithClient(clientFromAPIKey)))
+package gqlshape
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const extensionName = "QueryShape"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Collector{}
+
+type (
+	// Shape of an operation
+	Shape struct {
+		// Depth is the maximum nesting of fields. Fragments do not add depth.
+		Depth int
+
+		// Fields is the number of fields, with fragments expanded
+		Fields int
+
+		// Aliases is the number of aliased fields, with fragments expanded
+		Aliases int
+
+		// Fragments is the number of fragment spreads and inline fragments, with fragments expanded
+		Fragments int
+	}
+
+	// Collector is a gqlgen extension recording statistics on the shape of queries
+	Collector struct {
+		*config
+	}
+)
+
+// New Collector of query shapes
+func New(opts ...Option) *Collector {
+	c := &Collector{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "QueryShape"
+func (*Collector) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Collector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements the gqlgen operation interceptor
+func (c *Collector) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil {
+		return next(ctx)
+	}
+
+	shape := Measure(oc.Operation)
+	measurements := []stats.Measurement{
+		QueryDepth.M(int64(shape.Depth)),
+		QueryFieldCount.M(int64(shape.Fields)),
+		QueryAliasCount.M(int64(shape.Aliases)),
+		QueryFragmentCount.M(int64(shape.Fragments)),
+	}
+	if complexity := extension.GetComplexityStats(ctx); complexity != nil {
+		measurements = append(measurements, QueryComplexity.M(int64(complexity.Complexity)))
+	}
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagClient, c.config.client(ctx))}, measurements...)
+
+	return next(ctx)
+}
+
+// Measure the shape of an operation
+func Measure(op *ast.OperationDefinition) Shape {
+	var shape Shape
+	measure(&shape, op.SelectionSet, 1, make(map[string]bool))
+	return shape
+}
+
+func measure(shape *Shape, selections ast.SelectionSet, depth int, visiting map[string]bool) {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			shape.Fields++
+			if sel.Alias != "" && sel.Alias != sel.Name {
+				shape.Aliases++
+			}
+			if depth > shape.Depth {
+				shape.Depth = depth
+			}
+			measure(shape, sel.SelectionSet, depth+1, visiting)
+
+		case *ast.InlineFragment:
+			shape.Fragments++
+			measure(shape, sel.SelectionSet, depth, visiting)
+
+		case *ast.FragmentSpread:
+			shape.Fragments++
+			if sel.Definition == nil || visiting[sel.Name] {
+				// cycles are rejected by validation: this is just a safeguard
+				continue
+			}
+			visiting[sel.Name] = true
+			measure(shape, sel.Definition.SelectionSet, depth, visiting)
+			delete(visiting, sel.Name)
+		}
+	}
+}
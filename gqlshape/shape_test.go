@@ -0,0 +1,54 @@
+package gqlshape
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+type Query {
+  user(id: ID!): User
+}
+
+type User {
+  id: ID!
+  name: String
+  friends: [User!]!
+}
+`
+
+func TestMeasure(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	require.Nil(t, gqlErr)
+
+	doc, gqlErrs := gqlparser.LoadQuery(schema, `
+query {
+  me: user(id: "1") {
+    ...userFields
+    friends {
+      ... on User {
+        friend: name
+        friends { id }
+      }
+    }
+  }
+}
+
+fragment userFields on User {
+  id
+  name
+}
+`)
+	require.Empty(t, gqlErrs)
+
+	assert.Equal(t, Shape{
+		Depth:     4,
+		Fields:    7,
+		Aliases:   2,
+		Fragments: 2,
+	}, Measure(doc.Operations[0]))
+}
@@ -0,0 +1,41 @@
+package gqlsign
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(RejectedCountView)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(RejectedCountView)
+}
+
+var (
+	// TagReason is the reason of a rejected signature
+	TagReason = tag.MustNewKey("gql.signature_reason")
+
+	// RejectedCount tracks a count of requests rejected for their signature
+	RejectedCount = stats.Int64(
+		"gql/server/signature_rejected_count",
+		"Number of requests rejected for their signature",
+		stats.UnitDimensionless)
+
+	// RejectedCountView reports a count of requests rejected for their signature by reason
+	RejectedCountView = &view.View{
+		Name:        "gql/server/signature_rejected_count",
+		Description: "Count of GraphQL requests rejected for their signature by reason",
+		Measure:     RejectedCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagReason},
+	}
+)
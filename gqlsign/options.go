@@ -0,0 +1,84 @@
+package gqlsign
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the verifying extension
+	Option func(*config)
+
+	config struct {
+		keys         map[string][]byte
+		maxSkew      time.Duration
+		extensionKey string
+		optional     bool
+		replays      ReplayCache
+		failOpen     bool
+		clock        func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		keys:         make(map[string][]byte),
+		maxSkew:      5 * time.Minute,
+		extensionKey: "signature",
+		clock:        graphql.Now,
+	}
+}
+
+// WithKey adds a signing key. Several keys allow callers to be told apart, and keys to be rotated.
+func WithKey(keyID string, secret []byte) Option {
+	return func(c *config) {
+		c.keys[keyID] = secret
+	}
+}
+
+// WithMaxSkew sets the maximum difference between the timestamp of signatures and the clock of the server.
+// The default is 5m.
+func WithMaxSkew(maxSkew time.Duration) Option {
+	return func(c *config) {
+		c.maxSkew = maxSkew
+	}
+}
+
+// WithExtensionKey sets the key of signatures in the extensions of requests. The default is "signature".
+func WithExtensionKey(key string) Option {
+	return func(c *config) {
+		c.extensionKey = key
+	}
+}
+
+// WithOptional accepts unsigned requests, e.g. while callers are migrated. Signed requests are still verified.
+func WithOptional(enabled bool) Option {
+	return func(c *config) {
+		c.optional = enabled
+	}
+}
+
+// WithReplayCache sets the cache of signatures detecting replayed requests. The default is a MemoryReplayCache.
+func WithReplayCache(cache ReplayCache) Option {
+	return func(c *config) {
+		c.replays = cache
+	}
+}
+
+// WithReplayFailOpen accepts requests with a valid signature when the replay cache fails, e.g. when a shared
+// cache is unreachable. This trades the protection against replayed requests for availability.
+//
+// By default, such requests are rejected with the "unavailable" reason.
+func WithReplayFailOpen(enabled bool) Option {
+	return func(c *config) {
+		c.failOpen = enabled
+	}
+}
+
+// WithClock sets the clock of the extension. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
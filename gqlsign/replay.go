@@ -0,0 +1,56 @@
+package gqlsign
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// ReplayCache remembers the signatures of verified requests until they expire.
+	//
+	// Servers with several instances should share a ReplayCache, e.g. with Redis SET NX.
+	ReplayCache interface {
+		// Seen reports whether the signature was already seen, and remembers it until it expires otherwise
+		Seen(ctx context.Context, signature string, expires time.Time) (bool, error)
+	}
+
+	// MemoryReplayCache is an in-memory ReplayCache, for servers with a single instance
+	MemoryReplayCache struct {
+		clock func() time.Time
+
+		mx     sync.Mutex
+		seen   map[string]time.Time
+		pruned time.Time
+	}
+)
+
+// NewMemoryReplayCache builds an in-memory ReplayCache
+func NewMemoryReplayCache(clock func() time.Time) *MemoryReplayCache {
+	return &MemoryReplayCache{
+		clock: clock,
+		seen:  make(map[string]time.Time),
+	}
+}
+
+// Seen implements ReplayCache
+func (c *MemoryReplayCache) Seen(_ context.Context, signature string, expires time.Time) (bool, error) {
+	now := c.clock()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if now.Sub(c.pruned) > time.Minute {
+		for key, exp := range c.seen {
+			if !now.Before(exp) {
+				delete(c.seen, key)
+			}
+		}
+		c.pruned = now
+	}
+
+	if exp, ok := c.seen[signature]; ok && now.Before(exp) {
+		return true, nil
+	}
+	c.seen[signature] = expires
+	return false, nil
+}
@@ -0,0 +1,280 @@
+// Package gqlsign provides a gqlgen extension verifying requests signed with HMAC, for server-to-server
+// communication without mutual TLS.
+//
+// Callers sign the query, operation name and variables of requests together with a timestamp, with a secret key
+// shared with the server, and send the signature in the extensions of the request:
+//
+//   {
+//     "query": "query user($id: ID!) { user(id: $id) { name } }",
+//     "variables": {"id": "42"},
+//     "extensions": {
+//       "signature": {"keyId": "billing", "timestamp": 1600000000, "hmac": "5d41402abc4b2a76b9719d911017c592..."}
+//     }
+//   }
+//
+// The server rejects requests with an invalid signature, a timestamp too far from its clock,
+// or a signature already seen (replayed requests). When the cache of seen signatures fails, requests are rejected
+// as well, unless WithReplayFailOpen is set.
+//
+// Example:
+//
+//   srv.Use(extension.AutomaticPersistedQuery{Cache: cache})
+//   srv.Use(gqlsign.New(
+//     gqlsign.WithKey("billing", billingSecret),
+//     gqlsign.WithKey("search", searchSecret),
+//   ))
+//
+// Register this extension after automatic persisted queries, so that the query of persisted requests is known.
+//
+// Callers sign requests with Sign:
+//
+//   params := &graphql.RawParams{Query: query, Variables: variables}
+//   err := gqlsign.Sign(params, "billing", billingSecret, time.Now())
+package gqlsign
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	extensionName = "SignedQuery"
+
+	// CodeInvalidSignature is the code of errors rejecting requests
+	CodeInvalidSignature = "INVALID_SIGNATURE"
+)
+
+// Reasons of rejections, reported in the extensions of errors and as a tag of metrics
+const (
+	ReasonMissing    = "missing"
+	ReasonMalformed  = "malformed"
+	ReasonUnknownKey = "unknown_key"
+	ReasonExpired    = "expired"
+	ReasonMismatch   = "mismatch"
+	ReasonReplayed   = "replayed"
+
+	// ReasonUnavailable rejects requests which could not be checked against the replay cache
+	ReasonUnavailable = "unavailable"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = &Verifier{}
+
+type (
+	// Signature of a request
+	Signature struct {
+		KeyID     string `json:"keyId"`
+		Timestamp int64  `json:"timestamp"`
+		HMAC      string `json:"hmac"`
+	}
+
+	// Verifier is a gqlgen extension verifying signed requests
+	Verifier struct {
+		*config
+	}
+)
+
+// New verifying extension
+func New(opts ...Option) *Verifier {
+	v := &Verifier{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(v.config)
+	}
+	if v.config.replays == nil {
+		v.config.replays = NewMemoryReplayCache(v.config.clock)
+	}
+	return v
+}
+
+// ExtensionName yields the extension name: "SignedQuery"
+func (*Verifier) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the configuration: at least one key is required
+func (v *Verifier) Validate(schema graphql.ExecutableSchema) error {
+	if len(v.config.keys) == 0 {
+		return fmt.Errorf("no signing key configured")
+	}
+	return nil
+}
+
+// MutateOperationParameters implements graphql.OperationParameterMutator, verifying the signature of the request
+func (v *Verifier) MutateOperationParameters(ctx context.Context, params *graphql.RawParams) *gqlerror.Error {
+	raw, found := params.Extensions[v.config.extensionKey]
+	if !found {
+		if v.config.optional {
+			return nil
+		}
+		return v.reject(ctx, ReasonMissing, "request signature is required")
+	}
+
+	signature, err := parseSignature(raw)
+	if err != nil {
+		return v.reject(ctx, ReasonMalformed, "malformed request signature")
+	}
+	secret, ok := v.config.keys[signature.KeyID]
+	if !ok {
+		return v.reject(ctx, ReasonUnknownKey, "unknown signing key")
+	}
+
+	now := v.config.clock()
+	signed := time.Unix(signature.Timestamp, 0)
+	if signed.Before(now.Add(-v.config.maxSkew)) || signed.After(now.Add(v.config.maxSkew)) {
+		return v.reject(ctx, ReasonExpired, "request signature has expired")
+	}
+
+	expected, err := sign(secret, signature.KeyID, signature.Timestamp, params)
+	if err != nil {
+		return v.reject(ctx, ReasonMalformed, "malformed request variables")
+	}
+	mac, err := hex.DecodeString(signature.HMAC)
+	if err != nil || !hmac.Equal(mac, expected) {
+		return v.reject(ctx, ReasonMismatch, "invalid request signature")
+	}
+
+	// the cache is keyed on the canonical encoding of the signature: hex decoding is case-insensitive,
+	// so that the encoding sent by the caller could be altered to replay the request
+	seen, err := v.config.replays.Seen(ctx, hex.EncodeToString(mac), signed.Add(v.config.maxSkew))
+	switch {
+	case err != nil && v.config.failOpen:
+		return nil
+	case err != nil:
+		return v.reject(ctx, ReasonUnavailable, "request signature could not be checked for replays")
+	case seen:
+		return v.reject(ctx, ReasonReplayed, "request signature was already used")
+	default:
+		return nil
+	}
+}
+
+func (v *Verifier) reject(ctx context.Context, reason, message string) *gqlerror.Error {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagReason, reason)}, RejectedCount.M(1))
+	return &gqlerror.Error{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code":   CodeInvalidSignature,
+			"reason": reason,
+		},
+	}
+}
+
+// Sign a request with a key, adding its signature to the "signature" key of the extensions of the request
+func Sign(params *graphql.RawParams, keyID string, secret []byte, now time.Time) error {
+	return SignWithKey(params, "signature", keyID, secret, now)
+}
+
+// SignWithKey signs a request like Sign, adding its signature to a specific key of the extensions of the request
+func SignWithKey(params *graphql.RawParams, extensionKey, keyID string, secret []byte, now time.Time) error {
+	timestamp := now.Unix()
+	mac, err := sign(secret, keyID, timestamp, params)
+	if err != nil {
+		return err
+	}
+	if params.Extensions == nil {
+		params.Extensions = make(map[string]interface{})
+	}
+	params.Extensions[extensionKey] = Signature{
+		KeyID:     keyID,
+		Timestamp: timestamp,
+		HMAC:      hex.EncodeToString(mac),
+	}
+	return nil
+}
+
+// sign the key ID, timestamp, operation name, query and variables of a request.
+//
+// Variables are signed in their canonical JSON form, with sorted keys, so that the signature does not depend
+// on the way clients and servers encode them.
+//
+// Each field is prefixed with its length, so that no two requests yield the same signed payload
+// (e.g. by moving a line of the operation name into the query).
+func sign(secret []byte, keyID string, timestamp int64, params *graphql.RawParams) ([]byte, error) {
+	variables, err := canonical(params.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = io.WriteString(mac, "v2\n")
+	for _, field := range [][]byte{
+		[]byte(keyID),
+		[]byte(strconv.FormatInt(timestamp, 10)),
+		[]byte(params.OperationName),
+		[]byte(params.Query),
+		variables,
+	} {
+		_, _ = fmt.Fprintf(mac, "%d:", len(field))
+		_, _ = mac.Write(field)
+	}
+	return mac.Sum(nil), nil
+}
+
+func canonical(variables map[string]interface{}) ([]byte, error) {
+	if len(variables) == 0 {
+		return []byte("{}"), nil
+	}
+	buf, err := json.Marshal(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode and encode again, so that structs are encoded as maps with sorted keys
+	var decoded interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(decoded)
+}
+
+func parseSignature(raw interface{}) (Signature, error) {
+	if signature, ok := raw.(Signature); ok {
+		return signature, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return Signature{}, fmt.Errorf("unexpected signature: %T", raw)
+	}
+
+	var signature Signature
+	signature.KeyID, _ = m["keyId"].(string)
+	signature.HMAC, _ = m["hmac"].(string)
+	switch timestamp := m["timestamp"].(type) {
+	case json.Number:
+		signature.Timestamp, ok = parseInt(timestamp.String())
+	case float64:
+		signature.Timestamp, ok = int64(timestamp), true
+	case string:
+		signature.Timestamp, ok = parseInt(timestamp)
+	default:
+		ok = false
+	}
+	if !ok || signature.KeyID == "" || signature.HMAC == "" {
+		return Signature{}, fmt.Errorf("incomplete signature")
+	}
+	return signature, nil
+}
+
+func parseInt(s string) (int64, bool) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	return i, err == nil
+}
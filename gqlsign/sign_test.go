@@ -0,0 +1,126 @@
+package gqlsign
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTrip encodes and decodes parameters, as they would be sent to the server
+func roundTrip(t *testing.T, params *graphql.RawParams) *graphql.RawParams {
+	buf, err := json.Marshal(params)
+	require.NoError(t, err)
+	var decoded graphql.RawParams
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+	return &decoded
+}
+
+func TestVerifier(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	secret := []byte("s3cr3t")
+	v := New(WithKey("billing", secret), WithClock(func() time.Time { return now }))
+	require.Equal(t, extensionName, v.ExtensionName())
+
+	signed := func(variables map[string]interface{}, at time.Time) *graphql.RawParams {
+		params := &graphql.RawParams{
+			Query:         "query user($id: ID!) { user(id: $id) { name } }",
+			OperationName: "user",
+			Variables:     variables,
+		}
+		require.NoError(t, Sign(params, "billing", secret, at))
+		return roundTrip(t, params)
+	}
+	reason := func(params *graphql.RawParams) string {
+		err := v.MutateOperationParameters(context.Background(), params)
+		if err == nil {
+			return ""
+		}
+		assert.Equal(t, CodeInvalidSignature, err.Extensions["code"])
+		return err.Extensions["reason"].(string)
+	}
+
+	t.Run("valid signatures are accepted once", func(t *testing.T) {
+		params := signed(map[string]interface{}{"id": "42", "filter": map[string]interface{}{"b": 1, "a": 2.5}}, now.Add(-time.Minute))
+		assert.Equal(t, "", reason(params))
+		assert.Equal(t, ReasonReplayed, reason(params))
+	})
+
+	t.Run("re-encoded signatures are replays", func(t *testing.T) {
+		params := signed(map[string]interface{}{"id": "7"}, now)
+		assert.Equal(t, "", reason(params))
+
+		signature := params.Extensions["signature"].(map[string]interface{})
+		signature["hmac"] = strings.ToUpper(signature["hmac"].(string))
+		assert.Equal(t, ReasonReplayed, reason(params))
+	})
+
+	t.Run("fields are not ambiguous", func(t *testing.T) {
+		a, err := sign(secret, "billing", now.Unix(), &graphql.RawParams{OperationName: "a\nb", Query: "c"})
+		require.NoError(t, err)
+		b, err := sign(secret, "billing", now.Unix(), &graphql.RawParams{OperationName: "a", Query: "b\nc"})
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("tampered requests are rejected", func(t *testing.T) {
+		params := signed(map[string]interface{}{"id": "42"}, now)
+		params.Variables["id"] = "43"
+		assert.Equal(t, ReasonMismatch, reason(params))
+
+		params = signed(nil, now)
+		params.Query = "query { users { email } }"
+		assert.Equal(t, ReasonMismatch, reason(params))
+	})
+
+	t.Run("expired signatures are rejected", func(t *testing.T) {
+		assert.Equal(t, ReasonExpired, reason(signed(nil, now.Add(-time.Hour))))
+		assert.Equal(t, ReasonExpired, reason(signed(nil, now.Add(time.Hour))))
+	})
+
+	t.Run("invalid signatures are rejected", func(t *testing.T) {
+		assert.Equal(t, ReasonMissing, reason(&graphql.RawParams{Query: "{ a }"}))
+
+		params := &graphql.RawParams{Query: "{ a }"}
+		require.NoError(t, Sign(params, "search", secret, now))
+		assert.Equal(t, ReasonUnknownKey, reason(roundTrip(t, params)))
+
+		params = &graphql.RawParams{Query: "{ a }", Extensions: map[string]interface{}{"signature": "nope"}}
+		assert.Equal(t, ReasonMalformed, reason(params))
+	})
+}
+
+func TestOptional(t *testing.T) {
+	v := New(WithKey("billing", []byte("s3cr3t")), WithOptional(true))
+	assert.Nil(t, v.MutateOperationParameters(context.Background(), &graphql.RawParams{Query: "{ a }"}))
+
+	params := &graphql.RawParams{Query: "{ a }"}
+	require.NoError(t, Sign(params, "billing", []byte("wrong"), time.Now()))
+	assert.NotNil(t, v.MutateOperationParameters(context.Background(), roundTrip(t, params)))
+}
+
+type failingReplayCache struct{}
+
+func (failingReplayCache) Seen(context.Context, string, time.Time) (bool, error) {
+	return false, errors.New("unreachable")
+}
+
+func TestReplayCacheFailure(t *testing.T) {
+	secret := []byte("s3cr3t")
+	params := &graphql.RawParams{Query: "{ a }"}
+	require.NoError(t, Sign(params, "billing", secret, time.Now()))
+
+	v := New(WithKey("billing", secret), WithReplayCache(failingReplayCache{}))
+	err := v.MutateOperationParameters(context.Background(), roundTrip(t, params))
+	require.NotNil(t, err, "requests are rejected when the replay cache fails")
+	assert.Equal(t, ReasonUnavailable, err.Extensions["reason"])
+
+	v = New(WithKey("billing", secret), WithReplayCache(failingReplayCache{}), WithReplayFailOpen(true))
+	assert.Nil(t, v.MutateOperationParameters(context.Background(), roundTrip(t, params)))
+}
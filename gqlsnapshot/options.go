@@ -0,0 +1,91 @@
+package gqlsnapshot
+
+import (
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+)
+
+type (
+	// Option for the recorder
+	Option func(*config)
+
+	config struct {
+		filter    func(*graphql.OperationContext) bool
+		overwrite bool
+		onError   func(error)
+	}
+
+	// VerifyOption for the replay of snapshots in tests
+	VerifyOption func(*verifyConfig)
+
+	verifyConfig struct {
+		ignore   []string
+		server   []func(*handler.Server)
+		requests []func(*http.Request) *http.Request
+		update   bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+func defaultVerifyConfig() *verifyConfig {
+	return &verifyConfig{
+		update: updateFromEnv(),
+	}
+}
+
+// WithFilter records only the operations accepted by the filter, e.g. to exclude mutations or introspection.
+func WithFilter(filter func(*graphql.OperationContext) bool) Option {
+	return func(c *config) {
+		c.filter = filter
+	}
+}
+
+// WithOverwrite replaces existing golden files with new executions of the same operation and variables.
+// This is disabled by default: the first execution is kept.
+func WithOverwrite(enabled bool) Option {
+	return func(c *config) {
+		c.overwrite = enabled
+	}
+}
+
+// WithErrorHandler sets a handler of errors writing golden files. By default, these errors are ignored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// WithIgnore ignores fields of responses which legitimately change between executions, such as timestamps.
+// Fields are matched by their name (or alias) at any depth, as with gqldiff.IgnoreFields.
+func WithIgnore(paths ...string) VerifyOption {
+	return func(c *verifyConfig) {
+		c.ignore = append(c.ignore, paths...)
+	}
+}
+
+// WithServer configures the server executing snapshots, e.g. to add extensions required by resolvers.
+func WithServer(configure ...func(*handler.Server)) VerifyOption {
+	return func(c *verifyConfig) {
+		c.server = append(c.server, configure...)
+	}
+}
+
+// WithRequest prepares the requests executing snapshots, e.g. to set the context expected by resolvers.
+func WithRequest(prepare ...func(*http.Request) *http.Request) VerifyOption {
+	return func(c *verifyConfig) {
+		c.requests = append(c.requests, prepare...)
+	}
+}
+
+// WithUpdate updates golden files with the responses produced by tests, instead of comparing them.
+// By default, golden files are updated when the environment variable GQL_UPDATE_SNAPSHOTS is set.
+func WithUpdate(enabled bool) VerifyOption {
+	return func(c *verifyConfig) {
+		c.update = enabled
+	}
+}
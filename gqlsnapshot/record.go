@@ -0,0 +1,167 @@
+// Package gqlsnapshot makes resolver regression tests cheap to create: a Recorder captures the requests and responses
+// of operations served by a dev server into golden files, and Verify replays them against the executable schema in
+// tests, failing on response drift.
+//
+// Example, on a dev server:
+//
+//   srv.Use(gqlsnapshot.New("testdata/snapshots"))
+//
+// Then, in tests:
+//
+//   func TestSnapshots(t *testing.T) {
+//     es := generated.NewExecutableSchema(generated.Config{Resolvers: newTestResolver()})
+//     gqlsnapshot.Verify(t, es, "testdata/snapshots", gqlsnapshot.WithIgnore("createdAt"))
+//   }
+//
+// Golden files are updated with the responses produced by tests when the environment variable GQL_UPDATE_SNAPSHOTS
+// is set, e.g. after an intended change of resolvers.
+package gqlsnapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const extensionName = "SnapshotRecorder"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Recorder{}
+
+type (
+	// Snapshot of the execution of an operation, stored in a golden file
+	Snapshot struct {
+		OperationName string                 `json:"operationName,omitempty"`
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+		Response      json.RawMessage        `json:"response"`
+	}
+
+	// Recorder is a gqlgen extension capturing the execution of operations into golden files, one per operation
+	// and set of variables
+	Recorder struct {
+		*config
+		dir string
+	}
+)
+
+// New Recorder, storing golden files in a directory
+func New(dir string, opts ...Option) *Recorder {
+	r := &Recorder{
+		config: defaultConfig(),
+		dir:    dir,
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// ExtensionName yields the extension name: "SnapshotRecorder"
+func (*Recorder) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Recorder) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor.
+//
+// Subscriptions are not recorded. Errors writing golden files are handed over to the error handler.
+func (r *Recorder) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation == ast.Subscription {
+		return resp
+	}
+	if r.config.filter != nil && !r.config.filter(oc) {
+		return resp
+	}
+
+	if err := r.record(oc, resp); err != nil && r.config.onError != nil {
+		r.config.onError(err)
+	}
+	return resp
+}
+
+func (r *Recorder) record(oc *graphql.OperationContext, resp *graphql.Response) error {
+	response, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	snapshot := Snapshot{
+		OperationName: oc.OperationName,
+		Query:         oc.RawQuery,
+		Variables:     oc.Variables,
+		Response:      response,
+	}
+
+	path := filepath.Join(r.dir, snapshot.FileName())
+	if !r.config.overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	return writeSnapshot(path, snapshot)
+}
+
+// FileName yields the name of the golden file of a snapshot, from its operation name and a hash of its query
+// and variables, e.g. "GetTodos-3f2a9c1b.json"
+func (s Snapshot) FileName() string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return -1
+		}
+	}, s.OperationName)
+	if name == "" {
+		name = "anonymous"
+	}
+
+	variables, _ := json.Marshal(s.Variables)
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(s.Query))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write(variables)
+
+	return name + "-" + hex.EncodeToString(hash.Sum(nil))[:8] + ".json"
+}
+
+func readSnapshot(path string) (Snapshot, error) {
+	var snapshot Snapshot
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+func writeSnapshot(path string, snapshot Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
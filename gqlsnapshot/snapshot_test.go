@@ -0,0 +1,103 @@
+package gqlsnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+)
+
+const query = `query GetTodos { todos { id createdAt } }`
+
+func testSchema(data string) graphql.ExecutableSchema {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { todos: [Todo!]! }
+		type Todo { id: ID! createdAt: String! }
+	`})
+	return &graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return schema },
+		ExecFunc: func(context.Context) graphql.ResponseHandler {
+			return graphql.OneShot(&graphql.Response{Data: json.RawMessage(data)})
+		},
+	}
+}
+
+func TestRecordVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	recorder := New(dir, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	assert.Equal(t, extensionName, recorder.ExtensionName())
+
+	oc := &graphql.OperationContext{
+		RawQuery:      query,
+		OperationName: "GetTodos",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query, Name: "GetTodos"},
+	}
+	ctx := graphql.WithOperationContext(context.Background(), oc)
+	recorder.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+		return &graphql.Response{Data: json.RawMessage(`{"todos":[{"id":"1","createdAt":"yesterday"}]}`)}
+	})
+	require.Empty(t, errs)
+
+	snapshot := Snapshot{OperationName: "GetTodos", Query: query}
+	_, err = os.Stat(filepath.Join(dir, snapshot.FileName()))
+	require.NoError(t, err)
+
+	Verify(t, testSchema(`{"todos":[{"id":"1","createdAt":"today"}]}`), dir,
+		WithIgnore("createdAt"),
+		WithUpdate(false),
+	)
+}
+
+func TestSubscriptionsNotRecorded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Subscription},
+	})
+	New(dir).InterceptResponse(ctx, func(context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestDrift(t *testing.T) {
+	cfg := verifyConfig{ignore: []string{"createdAt"}}
+	recorded := []byte(`{"data":{"todos":[{"id":"1","createdAt":"a"},{"id":"2","createdAt":"a"}]}}`)
+
+	changes, err := gqldiff.Compare(recorded, []byte(`{"data":{"todos":[{"id":"1","createdAt":"b"},{"id":"2","createdAt":"b"}]}}`), cfg.diffOptions()...)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = gqldiff.Compare(recorded, []byte(`{"data":{"todos":[{"id":"2","createdAt":"a"},{"id":"1","createdAt":"a"}]}}`), cfg.diffOptions()...)
+	require.NoError(t, err)
+	assert.NotEmpty(t, changes)
+	assert.Contains(t, report(changes), "changed data.todos[0].id")
+}
+
+func TestFileName(t *testing.T) {
+	a := Snapshot{OperationName: "Get Todos!", Query: query}
+	b := Snapshot{OperationName: "Get Todos!", Query: query, Variables: map[string]interface{}{"first": 1}}
+
+	assert.Regexp(t, `^GetTodos-[0-9a-f]{8}\.json$`, a.FileName())
+	assert.NotEqual(t, a.FileName(), b.FileName())
+	assert.Regexp(t, `^anonymous-`, Snapshot{Query: query}.FileName())
+}
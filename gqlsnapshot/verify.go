@@ -0,0 +1,117 @@
+package gqlsnapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+
+	"github.com/99designs/gqlgen-contrib/gqldiff"
+)
+
+// UpdateEnvVar is the environment variable which, when set, updates golden files with the responses produced by tests
+const UpdateEnvVar = "GQL_UPDATE_SNAPSHOTS"
+
+// Verify replays the snapshots stored in the golden files of a directory against an executable schema, with a subtest
+// per golden file. A subtest fails when the response differs from the recorded one.
+func Verify(t *testing.T, es graphql.ExecutableSchema, dir string, opts ...VerifyOption) {
+	t.Helper()
+
+	cfg := defaultVerifyConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatalf("listing snapshots: %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no snapshot found in %s", dir)
+	}
+
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	for _, configure := range cfg.server {
+		configure(srv)
+	}
+
+	for _, path := range paths {
+		path := path
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			snapshot, err := readSnapshot(path)
+			if err != nil {
+				t.Fatalf("reading snapshot: %v", err)
+			}
+
+			actual, err := execute(srv, snapshot, cfg)
+			if err != nil {
+				t.Fatalf("executing snapshot: %v", err)
+			}
+
+			if cfg.update {
+				snapshot.Response = actual
+				if err := writeSnapshot(path, snapshot); err != nil {
+					t.Fatalf("updating snapshot: %v", err)
+				}
+				return
+			}
+
+			changes, err := gqldiff.Compare(snapshot.Response, actual, cfg.diffOptions()...)
+			if err != nil {
+				t.Fatalf("comparing responses: %v", err)
+			}
+			if len(changes) > 0 {
+				t.Errorf("response drift for %s (set %s=1 to update):%s", path, UpdateEnvVar, report(changes))
+			}
+		})
+	}
+}
+
+func execute(srv http.Handler, snapshot Snapshot, cfg *verifyConfig) (json.RawMessage, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"operationName": snapshot.OperationName,
+		"query":         snapshot.Query,
+		"variables":     snapshot.Variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for _, prepare := range cfg.requests {
+		req = prepare(req)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec.Body.Bytes(), nil
+}
+
+func (c verifyConfig) diffOptions() []gqldiff.Option {
+	return []gqldiff.Option{gqldiff.Ordered(true), gqldiff.IgnoreFields(c.ignore...)}
+}
+
+func report(changes []gqldiff.Change) string {
+	var b strings.Builder
+	for _, change := range changes {
+		left, _ := json.Marshal(change.Left)
+		right, _ := json.Marshal(change.Right)
+		fmt.Fprintf(&b, "\n  %s %s: %s => %s", change.Kind, change.Path, left, right)
+	}
+	return b.String()
+}
+
+func updateFromEnv() bool {
+	return os.Getenv(UpdateEnvVar) != ""
+}
@@ -0,0 +1,33 @@
+package gqlsql
+
+type (
+	// Option for the database wrapper
+	Option func(*config)
+
+	config struct {
+		statement bool
+		dbName    string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		statement: true,
+	}
+}
+
+// WithStatement adds the SQL statement to spans. This is enabled by default.
+//
+// Query arguments are never added to spans.
+func WithStatement(enabled bool) Option {
+	return func(c *config) {
+		c.statement = enabled
+	}
+}
+
+// WithDBName adds the name of the database to spans, as the "sql.db" attribute.
+func WithDBName(name string) Option {
+	return func(c *config) {
+		c.dbName = name
+	}
+}
@@ -0,0 +1,160 @@
+// Package gqlsql wraps database/sql so that queries executed by resolvers produce opencensus spans
+// tagged with the GraphQL operation and field path.
+//
+// This closes the gap between GraphQL traces and database traces.
+//
+// Example:
+//
+//   db := gqlsql.Wrap(sqlDB)
+//   rows, err := db.QueryContext(ctx, "SELECT id, text FROM todos WHERE user_id = $1", userID)
+package gqlsql
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlattr"
+	"github.com/99designs/gqlgen-contrib/gqlattr/ocattr"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+)
+
+type (
+	// DB wraps a *sql.DB with tracing of the context-aware methods
+	DB struct {
+		*sql.DB
+		*config
+	}
+
+	// Tx wraps a *sql.Tx with tracing of the context-aware methods
+	Tx struct {
+		*sql.Tx
+		*config
+	}
+
+	// Row wraps the *sql.Row yielded by QueryRowContext, so that its span covers the fetch of the row.
+	//
+	// The span ends when the row is scanned: as for *sql.Row, Scan must be called.
+	Row struct {
+		*sql.Row
+		span *trace.Span
+	}
+)
+
+// Wrap a database handle
+func Wrap(db *sql.DB, opts ...Option) *DB {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(cfg)
+	}
+	return &DB{DB: db, config: cfg}
+}
+
+// QueryContext executes a query that returns rows
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := db.startSpan(ctx, "sql:query", query)
+	defer span.End()
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	setStatus(span, err)
+	return rows, err
+}
+
+// QueryRowContext executes a query that is expected to return at most one row.
+//
+// The span of the query ends when the row is scanned.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	ctx, span := db.startSpan(ctx, "sql:query", query)
+
+	return &Row{Row: db.DB.QueryRowContext(ctx, query, args...), span: span}
+}
+
+// ExecContext executes a query without returning any rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := db.startSpan(ctx, "sql:exec", query)
+	defer span.End()
+
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	setStatus(span, err)
+	return res, err
+}
+
+// BeginTx starts a traced transaction
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	ctx, span := db.startSpan(ctx, "sql:begin", "")
+	defer span.End()
+
+	tx, err := db.DB.BeginTx(ctx, opts)
+	setStatus(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, config: db.config}, nil
+}
+
+// QueryContext executes a query that returns rows, within the transaction
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := tx.startSpan(ctx, "sql:query", query)
+	defer span.End()
+
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	setStatus(span, err)
+	return rows, err
+}
+
+// QueryRowContext executes a query that is expected to return at most one row, within the transaction.
+//
+// The span of the query ends when the row is scanned.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	ctx, span := tx.startSpan(ctx, "sql:query", query)
+
+	return &Row{Row: tx.Tx.QueryRowContext(ctx, query, args...), span: span}
+}
+
+// Scan copies the columns of the row into dest, then ends the span of the query with the error of the row, if any
+func (r *Row) Scan(dest ...interface{}) error {
+	defer r.span.End()
+
+	err := r.Row.Scan(dest...)
+	setStatus(r.span, err)
+	return err
+}
+
+// ExecContext executes a query without returning any rows, within the transaction
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := tx.startSpan(ctx, "sql:exec", query)
+	defer span.End()
+
+	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	setStatus(span, err)
+	return res, err
+}
+
+func (c config) startSpan(ctx context.Context, name, query string) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	if !span.IsRecordingEvents() {
+		return ctx, span
+	}
+
+	attrs := gqlopencensus.ContextKeyValues(ctx)
+	if c.statement && query != "" {
+		attrs = append(attrs, gqlattr.KeyValue{Key: "sql.query", Value: query})
+	}
+	if c.dbName != "" {
+		attrs = append(attrs, gqlattr.KeyValue{Key: "sql.db", Value: c.dbName})
+	}
+	span.AddAttributes(ocattr.Attributes(gqlattr.Default(), attrs)...)
+
+	return ctx, span
+}
+
+func setStatus(span *trace.Span, err error) {
+	if err == nil || err == sql.ErrNoRows {
+		return
+	}
+	span.SetStatus(trace.Status{
+		Code:    trace.StatusCodeUnknown,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,193 @@
+package gqlsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+)
+
+const failingQuery = "UPDATE todos SET done = true WHERE fail"
+
+type (
+	spanRecorder struct {
+		mx    sync.Mutex
+		spans []*trace.SpanData
+	}
+
+	// fakeDriver executes any statement, without any rows. Statements equal to failingQuery fail.
+	fakeDriver struct{}
+	fakeConn   struct{}
+	fakeTx     struct{}
+	fakeRows   struct{}
+	fakeStmt   struct {
+		query string
+	}
+)
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mx.Lock()
+	r.spans = append(r.spans, s)
+	r.mx.Unlock()
+}
+
+// recorded yields the spans recorded so far, and resets the recorder
+func (r *spanRecorder) recorded() []*trace.SpanData {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	spans := r.spans
+	r.spans = nil
+	return spans
+}
+
+// byName indexes the spans by name, retaining the last one for each name
+func byName(spans []*trace.SpanData) map[string]*trace.SpanData {
+	named := make(map[string]*trace.SpanData, len(spans))
+	for _, span := range spans {
+		named[span.Name] = span
+	}
+	return named
+}
+
+func (fakeDriver) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeDriver) Driver() driver.Driver                        { return fakeDriver{} }
+func (fakeDriver) Open(string) (driver.Conn, error)             { return fakeConn{}, nil }
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s.query == failingQuery {
+		return nil, errors.New("constraint violation")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if s.query == failingQuery {
+		return nil, errors.New("constraint violation")
+	}
+	return fakeRows{}, nil
+}
+
+func (fakeRows) Columns() []string         { return []string{"id"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+func TestDB(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	sqlDB := sql.OpenDB(fakeDriver{})
+	defer func() { _ = sqlDB.Close() }()
+
+	// resolver context, within a sampled trace
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "todos"})
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Object: "Query",
+		Field:  graphql.CollectedField{Field: &ast.Field{Name: "todos", Alias: "todos"}},
+	})
+	ctx, parent := trace.StartSpan(ctx, "resolver", trace.WithSampler(trace.AlwaysSample()))
+	defer parent.End()
+
+	t.Run("query", func(t *testing.T) {
+		db := Wrap(sqlDB, WithDBName("todos"))
+
+		rows, err := db.QueryContext(ctx, "SELECT id FROM todos WHERE user_id = $1", 42)
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+
+		row := db.QueryRowContext(ctx, "SELECT id FROM todos LIMIT 1")
+		require.Len(t, recorder.spans, 1, "the span of a row ends when it is scanned")
+		var id int
+		assert.Equal(t, sql.ErrNoRows, row.Scan(&id))
+
+		spans := recorder.recorded()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "sql:query", spans[0].Name)
+		assert.Equal(t, "SELECT id FROM todos WHERE user_id = $1", spans[0].Attributes["sql.query"])
+
+		span := spans[1]
+		assert.Equal(t, "sql:query", span.Name)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Equal(t, parent.SpanContext().TraceID, span.TraceID)
+		assert.Equal(t, int32(trace.StatusCodeOK), span.Status.Code, "no rows is not an error")
+		assert.Equal(t, map[string]interface{}{
+			"graphql.operation": "todos",
+			"graphql.field":     "todos",
+			"graphql.path":      "todos",
+			"sql.query":         "SELECT id FROM todos LIMIT 1",
+			"sql.db":            "todos",
+		}, span.Attributes)
+	})
+
+	t.Run("exec error", func(t *testing.T) {
+		db := Wrap(sqlDB, WithStatement(false))
+
+		_, err := db.ExecContext(ctx, failingQuery)
+		require.Error(t, err)
+
+		span := byName(recorder.recorded())["sql:exec"]
+		require.NotNil(t, span)
+		assert.Equal(t, int32(trace.StatusCodeUnknown), span.Status.Code)
+		assert.Equal(t, "constraint violation", span.Status.Message)
+		assert.NotContains(t, span.Attributes, "sql.query", "statements may be omitted")
+		assert.NotContains(t, span.Attributes, "sql.db")
+	})
+
+	t.Run("query row error", func(t *testing.T) {
+		db := Wrap(sqlDB)
+
+		var id int
+		require.Error(t, db.QueryRowContext(ctx, failingQuery).Scan(&id))
+
+		span := byName(recorder.recorded())["sql:query"]
+		require.NotNil(t, span)
+		assert.Equal(t, int32(trace.StatusCodeUnknown), span.Status.Code)
+		assert.Equal(t, "constraint violation", span.Status.Message)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		db := Wrap(sqlDB)
+
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		_, err = tx.ExecContext(ctx, "UPDATE todos SET done = true")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		spans := byName(recorder.recorded())
+		require.Contains(t, spans, "sql:begin")
+		assert.NotContains(t, spans["sql:begin"].Attributes, "sql.query")
+		require.Contains(t, spans, "sql:exec")
+		assert.Equal(t, "UPDATE todos SET done = true", spans["sql:exec"].Attributes["sql.query"])
+		assert.Equal(t, "todos", spans["sql:exec"].Attributes["graphql.path"])
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		db := Wrap(sqlDB)
+		unsampled, span := trace.StartSpan(context.Background(), "resolver", trace.WithSampler(trace.NeverSample()))
+		defer span.End()
+
+		_, err := db.ExecContext(unsampled, "UPDATE todos SET done = true")
+		require.NoError(t, err)
+		assert.Empty(t, recorder.recorded())
+	})
+}
@@ -0,0 +1,36 @@
+package gqlstatus
+
+type (
+	// Option for the error status extension
+	Option func(*config)
+
+	config struct {
+		statuses    map[string]int
+		partialData bool
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		statuses: DefaultStatuses(),
+	}
+}
+
+// WithStatus maps an error code to a HTTP status code. A status of 0 removes the mapping of the code.
+func WithStatus(code string, status int) Option {
+	return func(c *config) {
+		if status == 0 {
+			delete(c.statuses, code)
+			return
+		}
+		c.statuses[code] = status
+	}
+}
+
+// WithPartialData maps the errors of responses carrying data too, e.g. when some field is forbidden.
+// This is disabled by default: the status of a response with partial data is left untouched.
+func WithPartialData(enabled bool) Option {
+	return func(c *config) {
+		c.partialData = enabled
+	}
+}
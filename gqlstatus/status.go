@@ -0,0 +1,305 @@
+// Package gqlstatus provides a gqlgen extension mapping the error codes of GraphQL responses to HTTP status codes,
+// for clients and infrastructure which key off the status code, such as load balancers, API gateways or generic
+// HTTP clients. The GraphQL body of the response is preserved.
+//
+// The code of an error is the "code" of its extensions. By default, the following codes are mapped:
+//
+//   UNAUTHENTICATED       401 Unauthorized
+//   FORBIDDEN             403 Forbidden
+//   RATE_LIMITED          429 Too Many Requests
+//   INTERNAL              500 Internal Server Error
+//   SERVICE_OVERLOADED    503 Service Unavailable (operations shed by gqlqueue)
+//   SERVICE_UNAVAILABLE   503 Service Unavailable (operations rejected by gqlmaintenance)
+//   SERVER_SHUTTING_DOWN  503 Service Unavailable (operations rejected by gqldrain)
+//
+// The status is the one of the first error of the response with a mapped code. By default, only responses
+// without data are mapped: a response with partial data keeps the status set by the transport, usually 200 OK.
+//
+// The Middleware also sets the standard headers telling generic HTTP clients to back off, from the extensions
+// of the errors of the response, or from SetRateLimit:
+//
+//   "retryAfter": 30                                          Retry-After: 30
+//   "rateLimit": {"limit": 100, "remaining": 0, "reset": 30}  RateLimit-Limit: 100, RateLimit-Remaining: 0, RateLimit-Reset: 30
+//
+// Durations are in seconds.
+//
+// Example:
+//
+//   st := gqlstatus.New(gqlstatus.WithStatus("BAD_USER_INPUT", http.StatusBadRequest))
+//   srv.Use(st)
+//   http.Handle("/query", st.Middleware(srv))
+package gqlstatus
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlcode"
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+const extensionName = "ErrorStatus"
+
+// Error codes mapped by default
+const (
+	CodeUnauthenticated = gqlcode.Unauthenticated
+	CodeForbidden       = gqlcode.Forbidden
+	CodeRateLimited     = gqlcode.RateLimited
+	CodeInternal        = gqlcode.Internal
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Mapper{}
+
+type (
+	// Mapper is a gqlgen extension mapping the error codes of responses to HTTP status codes
+	Mapper struct {
+		*config
+	}
+
+	// RateLimit is the state of the rate limit of a client, reported with the RateLimit headers
+	RateLimit struct {
+		// Limit is the number of requests allowed in the current window
+		Limit int
+		// Remaining is the number of requests left in the current window
+		Remaining int
+		// Reset is the time until the current window resets
+		Reset time.Duration
+	}
+
+	// holder carries the status and headers computed by the extension to the Middleware.
+	//
+	// Resolvers may set the rate limit concurrently.
+	holder struct {
+		mx         sync.Mutex
+		status     int
+		computed   bool
+		retryAfter int
+		rateLimit  *RateLimit
+	}
+
+	holderKey struct{}
+)
+
+// DefaultStatuses yields the HTTP status codes of the error codes mapped by default
+func DefaultStatuses() map[string]int {
+	return map[string]int{
+		CodeUnauthenticated: http.StatusUnauthorized,
+		CodeForbidden:       http.StatusForbidden,
+		CodeRateLimited:     http.StatusTooManyRequests,
+		CodeInternal:        http.StatusInternalServerError,
+
+		gqlcode.ServiceOverloaded:  http.StatusServiceUnavailable,
+		gqlcode.ServiceUnavailable: http.StatusServiceUnavailable,
+		gqlcode.ServerShuttingDown: http.StatusServiceUnavailable,
+	}
+}
+
+// New error status extension
+func New(opts ...Option) *Mapper {
+	m := &Mapper{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(m.config)
+	}
+	return m
+}
+
+// ExtensionName yields the extension name: "ErrorStatus"
+func (*Mapper) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop.
+func (*Mapper) Validate(_ graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Middleware sets the status of responses, from the status computed by the extension, as well as
+// the Retry-After and RateLimit headers.
+//
+// Only responses served with 200 OK by the transport get a mapped status: request errors keep their status.
+// Headers already set by the handler are left untouched.
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func (m *Mapper) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := &holder{}
+		next.ServeHTTP(httpwriter.New(w, func(status int) int { return h.writeHeaders(w.Header(), status) }),
+			r.WithContext(context.WithValue(r.Context(), holderKey{}, h)))
+	})
+}
+
+// StatusFromContext yields the HTTP status computed for the response, once it has been executed.
+// The status is 0 when the response is not mapped.
+func StatusFromContext(ctx context.Context) int {
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok {
+		return 0
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return h.status
+}
+
+// SetRateLimit reports the state of the rate limit of the client in the RateLimit headers of the response,
+// e.g. from a rate limiter admitting the operation. The rate limit of a rejecting error takes precedence.
+//
+// This is a noop outside of the Middleware.
+func SetRateLimit(ctx context.Context, limit RateLimit) {
+	if h, ok := ctx.Value(holderKey{}).(*holder); ok {
+		h.mx.Lock()
+		h.rateLimit = &limit
+		h.mx.Unlock()
+	}
+}
+
+// InterceptResponse implements the gqlgen response interceptor, computing the status and headers of the response
+func (m *Mapper) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok {
+		return resp
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if h.computed {
+		// only the first response of a stream determines the status
+		return resp
+	}
+	h.computed = true
+	h.status = m.status(resp)
+	h.setHints(resp)
+	return resp
+}
+
+// status yields the HTTP status mapped from the errors of a response, or 0
+func (m *Mapper) status(resp *graphql.Response) int {
+	if !m.partialData && hasData(resp) {
+		return 0
+	}
+	for _, err := range resp.Errors {
+		if err == nil {
+			continue
+		}
+		code, ok := err.Extensions["code"].(string)
+		if !ok {
+			continue
+		}
+		if status, ok := m.statuses[code]; ok {
+			return status
+		}
+	}
+	return 0
+}
+
+// setHints retains the first retry and rate limit hints found in the extensions of the errors of a response
+func (h *holder) setHints(resp *graphql.Response) {
+	var retryFound, limitFound bool
+	for _, err := range resp.Errors {
+		if err == nil {
+			continue
+		}
+		if seconds, ok := toInt(err.Extensions["retryAfter"]); ok && !retryFound {
+			retryFound = true
+			h.retryAfter = seconds
+		}
+		if limit, ok := toRateLimit(err.Extensions["rateLimit"]); ok && !limitFound {
+			limitFound = true
+			h.rateLimit = &limit
+		}
+	}
+}
+
+func toRateLimit(value interface{}) (RateLimit, bool) {
+	var fields map[string]interface{}
+	switch v := value.(type) {
+	case RateLimit:
+		return v, true
+	case *RateLimit:
+		if v == nil {
+			return RateLimit{}, false
+		}
+		return *v, true
+	case map[string]interface{}:
+		fields = v
+	default:
+		return RateLimit{}, false
+	}
+
+	var limit RateLimit
+	var ok bool
+	if limit.Limit, ok = toInt(fields["limit"]); !ok {
+		return RateLimit{}, false
+	}
+	limit.Remaining, _ = toInt(fields["remaining"])
+	reset, _ := toInt(fields["reset"])
+	limit.Reset = time.Duration(reset) * time.Second
+	return limit, true
+}
+
+// toInt converts a number of an extension, as set in process or decoded from JSON
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(math.Ceil(v)), true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return int(math.Ceil(f)), true
+	default:
+		return 0, false
+	}
+}
+
+func hasData(resp *graphql.Response) bool {
+	return len(resp.Data) > 0 && string(resp.Data) != "null"
+}
+
+// writeHeaders sets the mapped status and headers before the response is written
+func (h *holder) writeHeaders(header http.Header, status int) int {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if status == http.StatusOK && h.status != 0 {
+		status = h.status
+	}
+	if h.retryAfter > 0 && header.Get("Retry-After") == "" {
+		header.Set("Retry-After", strconv.Itoa(h.retryAfter))
+	}
+
+	limit := h.rateLimit
+	if limit == nil || header.Get("RateLimit-Limit") != "" {
+		return status
+	}
+	remaining := limit.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(limit.Reset.Seconds()))))
+	return status
+}
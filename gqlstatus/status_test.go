@@ -0,0 +1,186 @@
+package gqlstatus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestMapper(t *testing.T) {
+	forbidden := &gqlerror.Error{Message: "forbidden", Extensions: map[string]interface{}{"code": CodeForbidden}}
+	badInput := &gqlerror.Error{Message: "bad input", Extensions: map[string]interface{}{"code": "BAD_USER_INPUT"}}
+	plain := &gqlerror.Error{Message: "boom"}
+
+	for _, tc := range []struct {
+		name     string
+		opts     []Option
+		response *graphql.Response
+		status   int
+		expected int
+	}{
+		{
+			name:     "no error",
+			response: &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "mapped code",
+			response: &graphql.Response{Errors: gqlerror.List{plain, forbidden}},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "null data",
+			response: &graphql.Response{Data: json.RawMessage(`null`), Errors: gqlerror.List{forbidden}},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "partial data",
+			response: &graphql.Response{Data: json.RawMessage(`{"todos":null}`), Errors: gqlerror.List{forbidden}},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "partial data mapped",
+			opts:     []Option{WithPartialData(true)},
+			response: &graphql.Response{Data: json.RawMessage(`{"todos":null}`), Errors: gqlerror.List{forbidden}},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "unmapped code",
+			response: &graphql.Response{Errors: gqlerror.List{badInput}},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "custom code",
+			opts:     []Option{WithStatus("BAD_USER_INPUT", http.StatusBadRequest)},
+			response: &graphql.Response{Errors: gqlerror.List{badInput, forbidden}},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "removed code",
+			opts:     []Option{WithStatus(CodeForbidden, 0)},
+			response: &graphql.Response{Errors: gqlerror.List{forbidden}},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "request error",
+			response: &graphql.Response{Errors: gqlerror.List{forbidden}},
+			status:   http.StatusUnprocessableEntity,
+			expected: http.StatusUnprocessableEntity,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := New(tc.opts...)
+			handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := m.InterceptResponse(r.Context(), graphql.OneShot(tc.response))
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				_ = json.NewEncoder(w).Encode(resp)
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", nil))
+			assert.Equal(t, tc.expected, rec.Code)
+
+			var body graphql.Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Len(t, body.Errors, len(tc.response.Errors), "the GraphQL body is preserved")
+		})
+	}
+
+	t.Run("without middleware", func(t *testing.T) {
+		resp := New().InterceptResponse(context.Background(), graphql.OneShot(&graphql.Response{Errors: gqlerror.List{forbidden}}))
+		assert.Len(t, resp.Errors, 1)
+		assert.Equal(t, 0, StatusFromContext(context.Background()))
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	m := New()
+	serve := func(resp *graphql.Response, before func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+		handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if before != nil {
+				before(w, r)
+			}
+			_ = json.NewEncoder(w).Encode(m.InterceptResponse(r.Context(), graphql.OneShot(resp)))
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/query", nil))
+		return rec
+	}
+
+	t.Run("shed operation", func(t *testing.T) {
+		rec := serve(&graphql.Response{Errors: gqlerror.List{{
+			Message:    "too many pending operations",
+			Extensions: map[string]interface{}{"code": "SERVICE_OVERLOADED", "retryAfter": 2},
+		}}}, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+		assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		rec := serve(&graphql.Response{Errors: gqlerror.List{{
+			Message: "rate limited",
+			Extensions: map[string]interface{}{
+				"code":       CodeRateLimited,
+				"retryAfter": json.Number("1.5"),
+				"rateLimit":  map[string]interface{}{"limit": 100, "remaining": 0, "reset": 30},
+			},
+		}}}, nil)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "100", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("admitted operation", func(t *testing.T) {
+		rec := serve(&graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, func(_ http.ResponseWriter, r *http.Request) {
+			SetRateLimit(r.Context(), RateLimit{Limit: 100, Remaining: 42, Reset: 1500 * time.Millisecond})
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+		assert.Equal(t, "42", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("rate limit set by parallel resolvers", func(t *testing.T) {
+		const n = 10
+		rec := serve(&graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, func(_ http.ResponseWriter, r *http.Request) {
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(remaining int) {
+					defer wg.Done()
+					SetRateLimit(r.Context(), RateLimit{Limit: n, Remaining: remaining, Reset: time.Second})
+					_ = StatusFromContext(r.Context())
+				}(i)
+			}
+			wg.Wait()
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "10", rec.Header().Get("RateLimit-Limit"))
+		assert.NotEmpty(t, rec.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("headers set by the handler", func(t *testing.T) {
+		rec := serve(&graphql.Response{Errors: gqlerror.List{{
+			Message:    "unavailable",
+			Extensions: map[string]interface{}{"code": "SERVICE_UNAVAILABLE", "retryAfter": 600},
+		}}}, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "60")
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	})
+}
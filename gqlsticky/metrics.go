@@ -0,0 +1,46 @@
+package gqlsticky
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the router.
+func Register() error {
+	return gqlmetrics.Register(StickyViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(StickyViews...)
+}
+
+var (
+	// StickyViews contains all opencensus stats views declared by the router
+	StickyViews = []*view.View{
+		StickyConnectionCountView,
+	}
+
+	// StickyConnectionCount tracks a count of websocket connections, by routing outcome
+	StickyConnectionCount = stats.Int64(
+		"gql/server/sticky_connection_count",
+		"Number of websocket connections by routing outcome",
+		stats.UnitDimensionless)
+
+	// StickyConnectionCountView reports the number of websocket connections by routing outcome
+	StickyConnectionCountView = &view.View{
+		Name:        "gql/server/sticky_connection_count",
+		Description: "Number of GraphQL websocket connections by routing outcome",
+		Measure:     StickyConnectionCount,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagRoute},
+	}
+
+	// TagRoute is the routing outcome of a connection: "new", "pinned", "rerouted" or "invalid"
+	TagRoute = tag.MustNewKey("gql.route")
+)
@@ -0,0 +1,76 @@
+package gqlsticky
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the router
+	Option func(*config)
+
+	config struct {
+		header     string
+		cookie     string
+		payloadKey string
+		maxAge     time.Duration
+		strict     bool
+		clock      func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		header:     "X-Gql-Route",
+		cookie:     "gql_route",
+		payloadKey: "routeToken",
+		maxAge:     24 * time.Hour,
+		clock:      graphql.Now,
+	}
+}
+
+// WithHeader sets the name of the header carrying tokens, in requests and responses. The default is "X-Gql-Route".
+// An empty name disables this header.
+func WithHeader(name string) Option {
+	return func(c *config) {
+		c.header = name
+	}
+}
+
+// WithCookie sets the name of the cookie carrying tokens. The default is "gql_route". An empty name disables this cookie.
+func WithCookie(name string) Option {
+	return func(c *config) {
+		c.cookie = name
+	}
+}
+
+// WithPayloadKey sets the key of the token in the payload of the websocket connection_init message.
+// The default is "routeToken".
+func WithPayloadKey(key string) Option {
+	return func(c *config) {
+		c.payloadKey = key
+	}
+}
+
+// WithMaxAge sets the maximum age of tokens. The default is 24h: zero means tokens never expire.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *config) {
+		c.maxAge = maxAge
+	}
+}
+
+// WithStrict rejects requests and connections presenting invalid or expired tokens. This is disabled by default:
+// such tokens are ignored.
+func WithStrict(enabled bool) Option {
+	return func(c *config) {
+		c.strict = enabled
+	}
+}
+
+// WithClock sets the clock, e.g. for tests. The default is graphql.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,232 @@
+// Package gqlsticky helps multi-instance deployments route subscription clients: it issues routing hints naming
+// the instance serving a client, and validates them when websockets connect.
+//
+// Hints are signed tokens, sent as a response header and a cookie. Load balancers may pin clients to an instance
+// with the cookie, and clients send the token back in the payload of the websocket connection_init message.
+//
+// When the instance named by a token is lost, clients are routed to another instance: the connection is accepted
+// and flagged as rerouted, so that subscription resolvers may transparently replay the events missed by the
+// client (e.g. with gqlresume).
+//
+// Example:
+//
+//   router := gqlsticky.New(os.Getenv("HOSTNAME"), secret)
+//
+//   srv.AddTransport(transport.Websocket{
+//     InitFunc: func(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+//       return router.InitFunc(ctx, payload)
+//     },
+//   })
+//   http.Handle("/query", router.Middleware(srv))
+//
+//   // in subscription resolvers
+//   if gqlsticky.Rerouted(ctx) {
+//     // resume the subscription from the last event received by the client
+//   }
+package gqlsticky
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+var (
+	// ErrInvalidToken is returned for malformed tokens, or tokens with an invalid signature
+	ErrInvalidToken = errors.New("invalid routing token")
+
+	// ErrExpiredToken is returned for tokens older than their maximum age
+	ErrExpiredToken = errors.New("expired routing token")
+)
+
+type (
+	// Route is the routing hint carried by a valid token
+	Route struct {
+		// Instance which issued the token
+		Instance string
+
+		// Issued is the time when the token was issued
+		Issued time.Time
+
+		// Rerouted tells if the client reached another instance than the one which issued the token
+		Rerouted bool
+	}
+
+	// Router issues and validates routing tokens for an instance
+	Router struct {
+		*config
+		instance string
+		secret   []byte
+	}
+
+	routeKey struct{}
+)
+
+// New Router for an instance. Instances share the secret signing tokens.
+//
+// Instance names are carried in plain text by tokens, for load balancers: they must be valid cookie values.
+func New(instance string, secret []byte, opts ...Option) *Router {
+	r := &Router{
+		config:   defaultConfig(),
+		instance: instance,
+		secret:   secret,
+	}
+	for _, apply := range opts {
+		apply(r.config)
+	}
+	return r
+}
+
+// FromContext yields the route of a client, when it presented a valid token
+func FromContext(ctx context.Context) (Route, bool) {
+	route, ok := ctx.Value(routeKey{}).(Route)
+	return route, ok
+}
+
+// Rerouted tells if a client presented a token issued by another instance, e.g. after the loss of this instance
+func Rerouted(ctx context.Context) bool {
+	route, ok := FromContext(ctx)
+	return ok && route.Rerouted
+}
+
+// Issue a token routing clients to this instance, formatted as "instance.issued.signature"
+func (r *Router) Issue() string {
+	hint := r.instance + "." + strconv.FormatInt(r.config.clock().Unix(), 10)
+	return hint + "." + r.sign(hint)
+}
+
+// Parse and validate a token
+func (r *Router) Parse(token string) (Route, error) {
+	sep := strings.LastIndexByte(token, '.')
+	if sep < 0 {
+		return Route{}, ErrInvalidToken
+	}
+	hint, signature := token[:sep], token[sep+1:]
+	if !hmac.Equal([]byte(signature), []byte(r.sign(hint))) {
+		return Route{}, ErrInvalidToken
+	}
+
+	sep = strings.LastIndexByte(hint, '.')
+	if sep < 0 {
+		return Route{}, ErrInvalidToken
+	}
+	issued, err := strconv.ParseInt(hint[sep+1:], 10, 64)
+	if err != nil {
+		return Route{}, ErrInvalidToken
+	}
+	route := Route{
+		Instance: hint[:sep],
+		Issued:   time.Unix(issued, 0),
+	}
+	route.Rerouted = route.Instance != r.instance
+	if r.config.maxAge > 0 && r.config.clock().Sub(route.Issued) > r.config.maxAge {
+		return Route{}, ErrExpiredToken
+	}
+	return route, nil
+}
+
+// Middleware validates the token presented by a request, in the routing header or cookie, and issues a new token
+// routing the client to this instance.
+//
+// Invalid tokens are ignored, unless WithStrict is enabled: then the request is rejected with 421 Misdirected Request.
+func (r *Router) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if token := r.requestToken(req); token != "" {
+			route, err := r.Parse(token)
+			if err != nil && r.config.strict {
+				r.record(ctx, "invalid")
+				http.Error(w, err.Error(), http.StatusMisdirectedRequest)
+				return
+			}
+			if err == nil {
+				ctx = context.WithValue(ctx, routeKey{}, route)
+				req = req.WithContext(ctx)
+			}
+		}
+
+		token := r.Issue()
+		if r.config.header != "" {
+			w.Header().Set(r.config.header, token)
+		}
+		if r.config.cookie != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     r.config.cookie,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   int(r.config.maxAge / time.Second),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// InitFunc validates the token sent in the payload of the websocket connection_init message, or the token presented
+// to the Middleware by the upgrade request. It suits the InitFunc of the gqlgen websocket transport.
+//
+// Connections without a token are accepted as new clients. Invalid tokens are ignored, unless WithStrict is enabled:
+// then the connection is rejected, and the client is expected to reconnect without a token.
+func (r *Router) InitFunc(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+	token, _ := payload[r.config.payloadKey].(string)
+	if token == "" {
+		if route, ok := FromContext(ctx); ok {
+			r.record(ctx, outcome(route))
+			return ctx, nil
+		}
+		r.record(ctx, "new")
+		return ctx, nil
+	}
+
+	route, err := r.Parse(token)
+	if err != nil {
+		r.record(ctx, "invalid")
+		if r.config.strict {
+			return ctx, err
+		}
+		return ctx, nil
+	}
+	r.record(ctx, outcome(route))
+	return context.WithValue(ctx, routeKey{}, route), nil
+}
+
+func (r *Router) requestToken(req *http.Request) string {
+	if r.config.header != "" {
+		if token := req.Header.Get(r.config.header); token != "" {
+			return token
+		}
+	}
+	if r.config.cookie != "" {
+		if cookie, err := req.Cookie(r.config.cookie); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func (r *Router) sign(hint string) string {
+	mac := hmac.New(sha256.New, r.secret)
+	_, _ = mac.Write([]byte(hint))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (r *Router) record(ctx context.Context, route string) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagRoute, route)}, StickyConnectionCount.M(1))
+}
+
+func outcome(route Route) string {
+	if route.Rerouted {
+		return "rerouted"
+	}
+	return "pinned"
+}
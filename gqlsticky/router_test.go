@@ -0,0 +1,82 @@
+package gqlsticky
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("secret")
+
+func TestToken(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	a := New("pod.a", secret, WithClock(clock), WithMaxAge(time.Hour))
+	b := New("pod.b", secret, WithClock(clock), WithMaxAge(time.Hour))
+
+	token := a.Issue()
+	route, err := a.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, "pod.a", route.Instance)
+	assert.False(t, route.Rerouted)
+
+	route, err = b.Parse(token)
+	require.NoError(t, err)
+	assert.True(t, route.Rerouted)
+
+	_, err = New("pod.a", []byte("other")).Parse(token)
+	assert.Equal(t, ErrInvalidToken, err)
+	_, err = a.Parse("garbage")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	now = now.Add(2 * time.Hour)
+	_, err = a.Parse(token)
+	assert.Equal(t, ErrExpiredToken, err)
+}
+
+func TestInitFunc(t *testing.T) {
+	a := New("a", secret)
+	b := New("b", secret, WithStrict(true))
+
+	ctx, err := b.InitFunc(context.Background(), map[string]interface{}{"routeToken": a.Issue()})
+	require.NoError(t, err)
+	assert.True(t, Rerouted(ctx))
+
+	ctx, err = b.InitFunc(context.Background(), map[string]interface{}{})
+	require.NoError(t, err)
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+
+	_, err = b.InitFunc(context.Background(), map[string]interface{}{"routeToken": "a.1.forged"})
+	assert.Equal(t, ErrInvalidToken, err)
+
+	_, err = a.InitFunc(context.Background(), map[string]interface{}{"routeToken": "a.1.forged"})
+	assert.NoError(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	a := New("a", secret)
+	b := New("b", secret)
+
+	var rerouted bool
+	handler := b.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rerouted = Rerouted(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.AddCookie(&http.Cookie{Name: "gql_route", Value: a.Issue()})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.True(t, rerouted)
+	route, err := b.Parse(rec.Header().Get("X-Gql-Route"))
+	require.NoError(t, err)
+	assert.Equal(t, "b", route.Instance)
+	require.Len(t, rec.Result().Cookies(), 1)
+	assert.Equal(t, "gql_route", rec.Result().Cookies()[0].Name)
+}
@@ -0,0 +1,61 @@
+package gqltiming
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/httpwriter"
+)
+
+// Middleware writes the Server-Timing header with the timings collected by the ServerTiming extension.
+//
+// The middleware must wrap the gqlgen handler using the extension.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tm := new(timings)
+		ctx := context.WithValue(r.Context(), timingsKey{}, tm)
+
+		next.ServeHTTP(httpwriter.New(w, func(status int) int {
+			if header := tm.header(); header != "" {
+				w.Header().Add("Server-Timing", header)
+			}
+			return status
+		}), r.WithContext(ctx))
+	})
+}
+
+// header formats the Server-Timing header. The serialization time is measured from the end of the execution,
+// with the clock of the extension.
+func (tm *timings) header() string {
+	tm.mx.Lock()
+	defer tm.mx.Unlock()
+
+	if !tm.set {
+		return ""
+	}
+
+	metrics := make([]string, 0, 4+len(tm.fields))
+	metrics = append(metrics,
+		metric("parse", "", tm.parse),
+		metric("validate", "", tm.validate),
+		metric("execute", "", tm.execute),
+	)
+	for i, field := range tm.fields {
+		metrics = append(metrics, metric("field-"+strconv.Itoa(i+1), field.path, field.duration))
+	}
+	metrics = append(metrics, metric("serialize", "", tm.clock().Sub(tm.execEnd)))
+
+	return strings.Join(metrics, ", ")
+}
+
+func metric(name, desc string, duration time.Duration) string {
+	dur := strconv.FormatFloat(float64(duration)/float64(time.Millisecond), 'f', 3, 64)
+	if desc == "" {
+		return fmt.Sprintf("%s;dur=%s", name, dur)
+	}
+	return fmt.Sprintf("%s;desc=%q;dur=%s", name, desc, dur)
+}
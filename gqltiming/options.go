@@ -0,0 +1,37 @@
+package gqltiming
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the ServerTiming extension
+	Option func(*config)
+
+	config struct {
+		slowestFields int
+		clock         func() time.Time
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		clock: graphql.Now,
+	}
+}
+
+// WithSlowestFields reports the n slowest fields resolved by resolver methods. This is disabled by default.
+func WithSlowestFields(n int) Option {
+	return func(c *config) {
+		c.slowestFields = n
+	}
+}
+
+// WithClock sets the clock used to measure durations. By default, this is graphql.Now
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
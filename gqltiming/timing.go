@@ -0,0 +1,146 @@
+// Package gqltiming emits a Server-Timing response header summarizing the durations of the phases of a GraphQL
+// operation: parsing, validation, execution and serialization, and optionally the slowest fields.
+//
+// Browser devtools display these timings, without the need for a tracing backend.
+//
+// Example:
+//
+//   timing := gqltiming.New(gqltiming.WithSlowestFields(3))
+//   srv.Use(timing)
+//   http.Handle("/query", gqltiming.Middleware(srv))
+//
+// This produces headers such as:
+//
+//   Server-Timing: parse;dur=0.120, validate;dur=0.080, execute;dur=12.500, field-1;desc="todos";dur=10.100, serialize;dur=0.400
+package gqltiming
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const extensionName = "ServerTiming"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &ServerTiming{}
+
+type (
+	// ServerTiming is a gqlgen extension collecting the timings reported by the Server-Timing header.
+	//
+	// The header itself is written by the Middleware wrapping the gqlgen handler.
+	ServerTiming struct {
+		*config
+	}
+
+	timingsKey struct{}
+
+	// timings of an operation, shared by the extension and the middleware
+	timings struct {
+		mx        sync.Mutex
+		set       bool
+		parse     time.Duration
+		validate  time.Duration
+		execute   time.Duration
+		execEnd   time.Time
+		clock     func() time.Time
+		maxFields int
+		fields    []fieldTiming
+	}
+
+	fieldTiming struct {
+		path     string
+		duration time.Duration
+	}
+)
+
+// New ServerTiming extension
+func New(opts ...Option) *ServerTiming {
+	t := &ServerTiming{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(t.config)
+	}
+	return t
+}
+
+// ExtensionName yields the extension name: "ServerTiming"
+func (ServerTiming) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (ServerTiming) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (t ServerTiming) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	tm, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	start := t.config.clock()
+	resp := next(ctx)
+	end := t.config.clock()
+
+	tm.mx.Lock()
+	if !tm.set {
+		tm.set = true
+		tm.parse = oc.Stats.Parsing.End.Sub(oc.Stats.Parsing.Start)
+		tm.validate = oc.Stats.Validation.End.Sub(oc.Stats.Validation.Start)
+		tm.execute = end.Sub(start)
+		tm.execEnd = end
+		tm.clock = t.config.clock
+		tm.maxFields = t.config.slowestFields
+	}
+	tm.mx.Unlock()
+
+	return resp
+}
+
+// InterceptField implements the gqlgen field interceptor
+func (t ServerTiming) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if t.config.slowestFields <= 0 {
+		return next(ctx)
+	}
+	tm, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsMethod {
+		return next(ctx)
+	}
+
+	start := t.config.clock()
+	defer func() {
+		tm.addField(fc.Path().String(), t.config.clock().Sub(start), t.config.slowestFields)
+	}()
+
+	return next(ctx)
+}
+
+// addField retains the n slowest fields
+func (tm *timings) addField(path string, duration time.Duration, n int) {
+	tm.mx.Lock()
+	defer tm.mx.Unlock()
+
+	if len(tm.fields) == n && tm.fields[n-1].duration >= duration {
+		return
+	}
+	if len(tm.fields) < n {
+		tm.fields = append(tm.fields, fieldTiming{})
+	}
+	tm.fields[len(tm.fields)-1] = fieldTiming{path: path, duration: duration}
+	sort.SliceStable(tm.fields, func(i, j int) bool {
+		return tm.fields[i].duration > tm.fields[j].duration
+	})
+}
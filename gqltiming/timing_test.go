@@ -0,0 +1,47 @@
+package gqltiming
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTiming(t *testing.T) {
+	now := time.Now()
+	clock := now.Add(3 * time.Millisecond)
+	ext := New(WithSlowestFields(2), WithClock(func() time.Time {
+		clock = clock.Add(10 * time.Millisecond)
+		return clock
+	}))
+	require.Equal(t, extensionName, ext.ExtensionName())
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		oc := &graphql.OperationContext{OperationName: "test"}
+		oc.Stats.Parsing = graphql.TraceTiming{Start: now, End: now.Add(time.Millisecond)}
+		oc.Stats.Validation = graphql.TraceTiming{Start: now.Add(time.Millisecond), End: now.Add(3 * time.Millisecond)}
+		ctx := graphql.WithOperationContext(r.Context(), oc)
+
+		tm := ctx.Value(timingsKey{}).(*timings)
+		tm.addField("todos", 5*time.Millisecond, 2)
+		tm.addField("todos[0].user", time.Millisecond, 2)
+		tm.addField("todos[1].user", 2*time.Millisecond, 2)
+
+		_ = ext.InterceptResponse(ctx, func(_ context.Context) *graphql.Response {
+			return &graphql.Response{}
+		})
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	w := httptest.NewRecorder()
+	Middleware(handler).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/query", nil))
+
+	header := w.Header().Get("Server-Timing")
+	assert.Equal(t, `parse;dur=1.000, validate;dur=2.000, execute;dur=10.000, `+
+		`field-1;desc="todos";dur=5.000, field-2;desc="todos[1].user";dur=2.000, serialize;dur=10.000`, header)
+}
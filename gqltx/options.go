@@ -0,0 +1,56 @@
+package gqltx
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type (
+	// Option for the saga extension
+	Option func(*config)
+
+	config struct {
+		failed       func(context.Context, *graphql.Response) bool
+		timeout      time.Duration
+		extensionKey string
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		failed:  HasErrors,
+		timeout: 30 * time.Second,
+	}
+}
+
+// HasErrors tells that an operation failed when its response has errors. This is the default failure policy.
+func HasErrors(_ context.Context, resp *graphql.Response) bool {
+	return resp == nil || len(resp.Errors) > 0
+}
+
+// WithFailurePolicy sets the function telling if an operation failed, and must be rolled back. The default is HasErrors.
+func WithFailurePolicy(failed func(context.Context, *graphql.Response) bool) Option {
+	return func(c *config) {
+		c.failed = failed
+	}
+}
+
+// WithTimeout sets the timeout of the whole rollback. The default is 30s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithExtensionKey reports the outcome of rollbacks in the response extensions, under this key. This is disabled by default.
+//
+// Example, with WithExtensionKey("rollback"):
+//
+//   "extensions": {"rollback": [{"name": "refund"}, {"name": "release-stock", "error": "timeout"}]}
+func WithExtensionKey(key string) Option {
+	return func(c *config) {
+		c.extensionKey = key
+	}
+}
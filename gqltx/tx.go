@@ -0,0 +1,205 @@
+// Package gqltx provides a gqlgen extension running compensations when a multi-step mutation fails,
+// following the saga pattern.
+//
+// Resolvers register a compensation for each step completed against the operation, e.g. to refund a payment
+// once the order is placed:
+//
+//   func (r *mutationResolver) PlaceOrder(ctx context.Context, input OrderInput) (*Order, error) {
+//     payment, err := r.payments.Charge(ctx, input.Amount)
+//     if err != nil {
+//       return nil, err
+//     }
+//     gqltx.Compensate(ctx, "refund", func(ctx context.Context) error {
+//       return r.payments.Refund(ctx, payment.ID)
+//     })
+//     ...
+//   }
+//
+// When the operation fails, compensations run in the reverse order of their registration. The outcome of the
+// rollback is annotated on the trace span of the operation, and may be reported in the response extensions.
+//
+// Compensations run with a context detached from the cancellation of the request.
+package gqltx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/internal/detach"
+)
+
+const extensionName = "Saga"
+
+// Span attributes
+const (
+	AttributeRolledBack    = "saga.rolled_back"
+	AttributeCompensations = "saga.compensations"
+	AttributeFailures      = "saga.compensation_failures"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Saga{}
+
+type (
+	// Compensation undoes a completed step of an operation
+	Compensation func(context.Context) error
+
+	// Outcome of a compensation
+	Outcome struct {
+		Name  string `json:"name"`
+		Error string `json:"error,omitempty"`
+	}
+
+	// Saga is a gqlgen extension running the compensations registered by resolvers when an operation fails
+	Saga struct {
+		*config
+	}
+
+	// saga holds the compensations registered during an operation
+	saga struct {
+		mx    sync.Mutex
+		steps []step
+	}
+
+	step struct {
+		name       string
+		compensate Compensation
+	}
+
+	sagaKey struct{}
+)
+
+// Compensate registers a compensation against the current operation, run if the operation fails.
+//
+// It returns false when the Saga extension is not in use, or the operation is not a mutation.
+func Compensate(ctx context.Context, name string, compensation Compensation) bool {
+	s, ok := ctx.Value(sagaKey{}).(*saga)
+	if !ok {
+		return false
+	}
+	s.mx.Lock()
+	s.steps = append(s.steps, step{name: name, compensate: compensation})
+	s.mx.Unlock()
+	return true
+}
+
+// New Saga extension
+func New(opts ...Option) *Saga {
+	s := &Saga{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(s.config)
+	}
+	return s
+}
+
+// ExtensionName yields the extension name: "Saga"
+func (*Saga) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Saga) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (s *Saga) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	tx := &saga{}
+	resp := next(context.WithValue(ctx, sagaKey{}, tx))
+	if !s.config.failed(ctx, resp) {
+		return resp
+	}
+
+	outcomes := s.rollback(ctx, tx.compensations())
+	if len(outcomes) == 0 {
+		return resp
+	}
+
+	s.annotate(ctx, outcomes)
+	if resp != nil && s.config.extensionKey != "" {
+		if resp.Extensions == nil {
+			resp.Extensions = make(map[string]interface{})
+		}
+		resp.Extensions[s.config.extensionKey] = outcomes
+	}
+	return resp
+}
+
+// rollback runs compensations in reverse order. A failed compensation does not stop the next ones.
+func (s *Saga) rollback(ctx context.Context, steps []step) []Outcome {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	rollbackCtx := detach.Context(ctx)
+	if s.config.timeout > 0 {
+		var cancel context.CancelFunc
+		rollbackCtx, cancel = context.WithTimeout(rollbackCtx, s.config.timeout)
+		defer cancel()
+	}
+
+	outcomes := make([]Outcome, 0, len(steps))
+	for i := len(steps) - 1; i >= 0; i-- {
+		outcome := Outcome{Name: steps[i].name}
+		if err := compensate(rollbackCtx, steps[i].compensate); err != nil {
+			outcome.Error = err.Error()
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// compensate runs a compensation, recovering from panics
+func compensate(ctx context.Context, compensation Compensation) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("compensation panicked: %v", r)
+		}
+	}()
+	return compensation(ctx)
+}
+
+func (s *Saga) annotate(ctx context.Context, outcomes []Outcome) {
+	span := trace.FromContext(ctx)
+	if span == nil || !span.IsRecordingEvents() {
+		return
+	}
+
+	var failures int64
+	for _, outcome := range outcomes {
+		attrs := []trace.Attribute{trace.StringAttribute("compensation", outcome.Name)}
+		if outcome.Error != "" {
+			failures++
+			attrs = append(attrs, trace.StringAttribute("error", outcome.Error))
+			span.Annotate(attrs, "compensation failed")
+			continue
+		}
+		span.Annotate(attrs, "compensation succeeded")
+	}
+
+	span.AddAttributes(
+		trace.BoolAttribute(AttributeRolledBack, failures == 0),
+		trace.Int64Attribute(AttributeCompensations, int64(len(outcomes))),
+		trace.Int64Attribute(AttributeFailures, failures),
+	)
+}
+
+func (s *saga) compensations() []step {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.steps
+}
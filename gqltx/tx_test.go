@@ -0,0 +1,65 @@
+package gqltx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSaga(t *testing.T) {
+	s := New(WithExtensionKey("rollback"))
+
+	mutation := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Mutation},
+	})
+
+	var compensated []string
+	steps := func(fail bool) graphql.ResponseHandler {
+		return func(ctx context.Context) *graphql.Response {
+			for _, name := range []string{"charge", "reserve", "notify"} {
+				name := name
+				require.True(t, Compensate(ctx, name, func(ctx context.Context) error {
+					compensated = append(compensated, name)
+					if name == "reserve" {
+						return errors.New("unavailable")
+					}
+					if name == "charge" {
+						panic("boom")
+					}
+					return nil
+				}))
+			}
+			if fail {
+				return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("failed")}}
+			}
+			return &graphql.Response{}
+		}
+	}
+
+	resp := s.InterceptResponse(mutation, steps(false))
+	assert.Empty(t, compensated)
+	assert.Nil(t, resp.Extensions)
+
+	resp = s.InterceptResponse(mutation, steps(true))
+	assert.Equal(t, []string{"notify", "reserve", "charge"}, compensated)
+	outcomes := resp.Extensions["rollback"].([]Outcome)
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, Outcome{Name: "notify"}, outcomes[0])
+	assert.Equal(t, Outcome{Name: "reserve", Error: "unavailable"}, outcomes[1])
+	assert.Equal(t, Outcome{Name: "charge", Error: "compensation panicked: boom"}, outcomes[2])
+
+	// compensations are not registered outside of mutations
+	query := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	})
+	s.InterceptResponse(query, func(ctx context.Context) *graphql.Response {
+		assert.False(t, Compensate(ctx, "noop", func(context.Context) error { return nil }))
+		return &graphql.Response{}
+	})
+}
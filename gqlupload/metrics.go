@@ -0,0 +1,76 @@
+package gqlupload
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Register views.
+//
+// Views must be registered before using the extension.
+func Register() error {
+	return gqlmetrics.Register(UploadViews...)
+}
+
+// Unregister views
+func Unregister() {
+	gqlmetrics.Unregister(UploadViews...)
+}
+
+var (
+	// UploadViews contains all opencensus stats views declared by the upload instrumentation
+	UploadViews = []*view.View{
+		UploadFileCountView,
+		UploadBytesView,
+		UploadReadLatencyView,
+	}
+
+	// UploadFileCount tracks the number of files uploaded per operation
+	UploadFileCount = stats.Int64(
+		"gql/server/upload_file_count",
+		"Number of files uploaded by a GraphQL operation",
+		stats.UnitDimensionless)
+
+	// UploadBytes tracks the total size of files uploaded per operation, in bytes
+	UploadBytes = stats.Int64(
+		"gql/server/upload_bytes",
+		"Total size of files uploaded by a GraphQL operation",
+		stats.UnitBytes)
+
+	// UploadReadLatency tracks the time spent reading an uploaded file, in milliseconds
+	UploadReadLatency = stats.Float64(
+		"gql/server/upload_read_latency",
+		"Time spent reading an uploaded file",
+		stats.UnitMilliseconds)
+
+	// UploadFileCountView reports a distribution of the number of files uploaded, by operation
+	UploadFileCountView = &view.View{
+		Name:        "gql/server/upload_file_count",
+		Description: "Distribution of the number of files uploaded by operation",
+		Measure:     UploadFileCount,
+		Aggregation: view.Distribution(1, 2, 5, 10, 20, 50),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// UploadBytesView reports a distribution of the total size of uploads, by operation
+	UploadBytesView = &view.View{
+		Name:        "gql/server/upload_bytes",
+		Description: "Distribution of the total size of uploads by operation",
+		Measure:     UploadBytes,
+		Aggregation: view.Distribution(1<<10, 16<<10, 256<<10, 1<<20, 16<<20, 256<<20),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// UploadReadLatencyView reports a distribution of the time spent reading uploaded files, by operation (in milliseconds)
+	UploadReadLatencyView = &view.View{
+		Name:        "gql/server/upload_read_latency",
+		Description: "Distribution of the time spent reading uploaded files by operation",
+		Measure:     UploadReadLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
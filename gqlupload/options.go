@@ -0,0 +1,66 @@
+package gqlupload
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the upload instrumentation extension
+	Option func(*config)
+
+	config struct {
+		maxFileSize int64
+		maxFiles    int
+		clock       func() time.Time
+		opLabel     gqllabel.OperationLabeler
+	}
+)
+
+func defaultConfig() *config {
+	return &config{
+		clock:   graphql.Now,
+		opLabel: gqllabel.OperationName,
+	}
+}
+
+// WithMaxFileSize sets the maximum size of an uploaded file, in bytes. By default, there is no limit.
+//
+// Operations uploading a file with a declared size exceeding the limit are rejected before execution.
+// Otherwise, reading an uploaded file fails with ErrFileTooLarge as soon as the limit is exceeded.
+//
+// Notice that this limit applies per file: the overall size of multipart requests may be capped
+// with the MaxUploadSize setting of the gqlgen transport.MultipartForm.
+func WithMaxFileSize(size int64) Option {
+	return func(c *config) {
+		c.maxFileSize = size
+	}
+}
+
+// WithMaxFiles sets the maximum number of files uploaded by an operation. By default, there is no limit.
+//
+// Operations uploading more files are rejected before execution.
+func WithMaxFiles(n int) Option {
+	return func(c *config) {
+		c.maxFiles = n
+	}
+}
+
+// WithClock sets the clock used to measure read durations. By default, this is graphql.Now
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithOperationLabel sets the function producing the operation tag of metrics. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this tag.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.opLabel = labeler
+	}
+}
@@ -0,0 +1,200 @@
+// Package gqlupload provides a gqlgen extension to instrument file uploads, as served by the multipart form transport.
+//
+// The extension collects the number of files and the total size of uploads, as well as the time spent reading
+// each file. It may also enforce a size limit per file, while the file is being streamed, and limit the number
+// of files uploaded by an operation.
+//
+// Collected figures are added as attributes to the current opencensus span, and recorded as opencensus metrics.
+// The extension must be used after the opencensus tracer extension in order to decorate the operation span.
+//
+// Example:
+//
+//   srv.AddTransport(transport.MultipartForm{})
+//   srv.Use(gqlopencensus.New())
+//   srv.Use(gqlupload.New(gqlupload.WithMaxFileSize(10 << 20)))
+package gqlupload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "UploadInstrumentation"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Instrumentation{}
+
+// ErrFileTooLarge is returned when reading an uploaded file exceeding the maximum file size
+var ErrFileTooLarge = errors.New("uploaded file exceeds the maximum file size")
+
+type (
+	// Instrumentation is a gqlgen extension instrumenting file uploads
+	Instrumentation struct {
+		*config
+	}
+
+	// fileReader instruments the reader of an uploaded file
+	fileReader struct {
+		reader   io.Reader
+		filename string
+		limit    int64
+		clock    func() time.Time
+
+		mx       sync.Mutex
+		read     int64
+		duration time.Duration
+	}
+)
+
+// New upload instrumentation extension
+func New(opts ...Option) *Instrumentation {
+	i := &Instrumentation{
+		config: defaultConfig(),
+	}
+	for _, apply := range opts {
+		apply(i.config)
+	}
+	return i
+}
+
+// ExtensionName yields the extension name: "UploadInstrumentation"
+func (*Instrumentation) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (*Instrumentation) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements the gqlgen response interceptor
+func (i *Instrumentation) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+
+	var (
+		readers   []*fileReader
+		totalSize int64
+		tooLarge  string
+	)
+	walkUploads(oc.Variables, func(upload *graphql.Upload) {
+		totalSize += upload.Size
+		if i.config.maxFileSize > 0 && upload.Size > i.config.maxFileSize {
+			tooLarge = upload.Filename
+		}
+		r := &fileReader{
+			reader:   upload.File,
+			filename: upload.Filename,
+			limit:    i.config.maxFileSize,
+			clock:    i.config.clock,
+		}
+		upload.File = r
+		readers = append(readers, r)
+	})
+
+	if len(readers) == 0 {
+		return next(ctx)
+	}
+
+	tags := []tag.Mutator{tag.Upsert(metrics.TagOperation, i.config.opLabel(oc))}
+	_ = stats.RecordWithTags(ctx, tags,
+		UploadFileCount.M(int64(len(readers))),
+		UploadBytes.M(totalSize),
+	)
+
+	span := trace.FromContext(ctx)
+	if span != nil && span.IsRecordingEvents() {
+		span.AddAttributes(
+			trace.Int64Attribute("upload.files", int64(len(readers))),
+			trace.Int64Attribute("upload.bytes", totalSize),
+		)
+	}
+
+	if i.config.maxFiles > 0 && len(readers) > i.config.maxFiles {
+		return graphql.ErrorResponse(ctx, "operation uploads %d files, exceeding the maximum of %d files", len(readers), i.config.maxFiles)
+	}
+
+	if tooLarge != "" {
+		// the declared size is known: abort before the file is read
+		return graphql.ErrorResponse(ctx, "uploaded file %q exceeds the maximum file size of %d bytes", tooLarge, i.config.maxFileSize)
+	}
+
+	resp := next(ctx)
+
+	for _, r := range readers {
+		read, duration := r.stats()
+		_ = stats.RecordWithTags(ctx, tags,
+			UploadReadLatency.M(float64(duration)/float64(time.Millisecond)),
+		)
+		if span != nil && span.IsRecordingEvents() {
+			span.Annotate([]trace.Attribute{
+				trace.StringAttribute("upload.filename", r.filename),
+				trace.Int64Attribute("upload.read_bytes", read),
+				trace.Int64Attribute("upload.read_ms", duration.Milliseconds()),
+			}, "upload read")
+		}
+	}
+
+	return resp
+}
+
+// walkUploads visits all uploads found in the variables of an operation
+func walkUploads(value interface{}, visit func(*graphql.Upload)) interface{} {
+	switch v := value.(type) {
+	case graphql.Upload:
+		visit(&v)
+		return v
+	case *graphql.Upload:
+		if v != nil {
+			visit(v)
+		}
+		return v
+	case map[string]interface{}:
+		for k, elem := range v {
+			v[k] = walkUploads(elem, visit)
+		}
+		return v
+	case []interface{}:
+		for k, elem := range v {
+			v[k] = walkUploads(elem, visit)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// Read implements io.Reader, accounting for the time spent reading and enforcing the size limit
+func (r *fileReader) Read(p []byte) (int, error) {
+	start := r.clock()
+	n, err := r.reader.Read(p)
+	elapsed := r.clock().Sub(start)
+
+	r.mx.Lock()
+	r.read += int64(n)
+	r.duration += elapsed
+	exceeded := r.limit > 0 && r.read > r.limit
+	r.mx.Unlock()
+
+	if exceeded {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+func (r *fileReader) stats() (int64, time.Duration) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.read, r.duration
+}
@@ -0,0 +1,132 @@
+package gqlupload
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+)
+
+func upload(name, content string) graphql.Upload {
+	return graphql.Upload{
+		File:     strings.NewReader(content),
+		Filename: name,
+		Size:     int64(len(content)),
+	}
+}
+
+// execute an operation with some variables, reading all uploaded files
+func execute(i *Instrumentation, variables map[string]interface{}) (*graphql.Response, map[string]string, error) {
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "upload",
+		Variables:     variables,
+	})
+
+	files := make(map[string]string)
+	var readErr error
+	resp := i.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		walkUploads(graphql.GetOperationContext(ctx).Variables, func(upload *graphql.Upload) {
+			content, err := ioutil.ReadAll(upload.File)
+			if err != nil {
+				readErr = err
+				return
+			}
+			files[upload.Filename] = string(content)
+		})
+		return &graphql.Response{}
+	})
+	return resp, files, readErr
+}
+
+func TestUpload(t *testing.T) {
+	require.NoError(t, Register())
+	defer Unregister()
+
+	i := New()
+	require.Equal(t, extensionName, i.ExtensionName())
+	require.NoError(t, i.Validate(nil))
+
+	resp, files, err := execute(i, map[string]interface{}{
+		"file": upload("a.txt", "abc"),
+		"files": []interface{}{
+			upload("b.txt", "defg"),
+			&graphql.Upload{File: strings.NewReader("hi"), Filename: "c.txt", Size: 2},
+		},
+		"input": map[string]interface{}{"name": "d"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]string{"a.txt": "abc", "b.txt": "defg", "c.txt": "hi"}, files, "uploads are read through the instrumentation")
+
+	rows, err := view.RetrieveData(UploadFileCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 3.0, rows[0].Data.(*view.DistributionData).Mean)
+
+	rows, err = view.RetrieveData(UploadBytesView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 9.0, rows[0].Data.(*view.DistributionData).Mean)
+
+	t.Run("no uploads", func(t *testing.T) {
+		resp, files, err := execute(i, map[string]interface{}{"name": "d"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Errors)
+		assert.Empty(t, files)
+	})
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("file size", func(t *testing.T) {
+		i := New(WithMaxFileSize(3))
+
+		resp, files, err := execute(i, map[string]interface{}{"file": upload("a.txt", "abc")})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Errors, "files up to the limit are accepted")
+		assert.Equal(t, "abc", files["a.txt"])
+
+		resp, files, err = execute(i, map[string]interface{}{"file": upload("b.txt", "abcd")})
+		require.NoError(t, err)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, `uploaded file "b.txt" exceeds the maximum file size of 3 bytes`, resp.Errors[0].Message)
+		assert.Nil(t, resp.Data)
+		assert.Empty(t, files, "the operation is rejected before execution")
+	})
+
+	t.Run("streamed file size", func(t *testing.T) {
+		i := New(WithMaxFileSize(3))
+
+		// the declared size may not be known, or lie
+		file := upload("a.txt", "abcdef")
+		file.Size = 0
+		resp, _, err := execute(i, map[string]interface{}{"file": file})
+		assert.Equal(t, ErrFileTooLarge, err, "reading fails as soon as the limit is exceeded")
+		assert.Empty(t, resp.Errors)
+	})
+
+	t.Run("file count", func(t *testing.T) {
+		i := New(WithMaxFiles(2))
+
+		resp, files, err := execute(i, map[string]interface{}{
+			"files": []interface{}{upload("a.txt", "a"), upload("b.txt", "b")},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Errors, "operations up to the limit are accepted")
+		assert.Len(t, files, 2)
+
+		resp, files, err = execute(i, map[string]interface{}{
+			"files": []interface{}{upload("a.txt", "a"), upload("b.txt", "b")},
+			"file":  upload("c.txt", "c"),
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "operation uploads 3 files, exceeding the maximum of 2 files", resp.Errors[0].Message)
+		assert.Nil(t, resp.Data)
+		assert.Empty(t, files, "the operation is rejected before execution")
+	})
+}
@@ -0,0 +1,35 @@
+// Package detach provides contexts carrying the values of a parent context, but not its deadline nor cancellation.
+//
+// This is used by extensions running work which must outlive the request that started it, such as shadow executions,
+// saga compensations, outbox publications or loads shared by several requests.
+package detach
+
+import (
+	"context"
+	"time"
+)
+
+type detachedContext struct {
+	parent context.Context
+}
+
+// Context yields a context carrying the values of ctx, which is never done
+func Context(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
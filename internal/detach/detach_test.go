@@ -0,0 +1,27 @@
+package detach
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type valueKey struct{}
+
+func TestContext(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), valueKey{}, "value"), time.Minute)
+	cancel()
+
+	ctx := Context(parent)
+	assert.Equal(t, "value", ctx.Value(valueKey{}))
+	assert.NoError(t, ctx.Err())
+	assert.Nil(t, ctx.Done())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	assert.NoError(t, child.Err(), "children of a detached context are not cancelled with the parent")
+}
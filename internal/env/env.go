@@ -0,0 +1,37 @@
+// Package env looks up the name of the deployment environment (e.g. "dev", "production") in an environment variable.
+//
+// Extensions use it to enable or enforce features in some environments only, such as the playground, the CSRF
+// protection, the masking of PII or the development mode of the operation registry.
+package env
+
+import "os"
+
+// DefaultVar is the default environment variable holding the name of the deployment environment
+const DefaultVar = "GQL_ENV"
+
+// Var is an environment variable holding the name of the deployment environment.
+//
+// The zero value stands for DefaultVar.
+type Var string
+
+// Current yields the name of the current environment, or "" when the variable is not set
+func (v Var) Current() string {
+	if v == "" {
+		v = DefaultVar
+	}
+	return os.Getenv(string(v))
+}
+
+// In tells if the current environment is one of the environments. An unset environment is in none of them.
+func (v Var) In(environments ...string) bool {
+	current := v.Current()
+	if current == "" {
+		return false
+	}
+	for _, env := range environments {
+		if env == current {
+			return true
+		}
+	}
+	return false
+}
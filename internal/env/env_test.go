@@ -0,0 +1,37 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVar(t *testing.T) {
+	previous, wasSet := os.LookupEnv(DefaultVar)
+	defer func() {
+		if wasSet {
+			_ = os.Setenv(DefaultVar, previous)
+		} else {
+			_ = os.Unsetenv(DefaultVar)
+		}
+	}()
+
+	require.NoError(t, os.Unsetenv(DefaultVar))
+	var v Var
+	assert.Equal(t, "", v.Current())
+	assert.False(t, v.In("dev", ""), "an unset environment is in none")
+
+	require.NoError(t, os.Setenv(DefaultVar, "dev"))
+	assert.Equal(t, "dev", v.Current(), "the zero value stands for the default variable")
+	assert.True(t, v.In("staging", "dev"))
+	assert.False(t, v.In("production"))
+	assert.False(t, v.In())
+
+	custom := Var("GQL_TEST_ENV_CUSTOM")
+	require.NoError(t, os.Setenv(string(custom), "production"))
+	defer os.Unsetenv(string(custom))
+	assert.Equal(t, "production", custom.Current())
+	assert.True(t, custom.In("production"))
+}
@@ -0,0 +1,50 @@
+// Package fieldtest builds the field contexts of gqlgen, for the tests of field interceptors.
+//
+// graphql.WithFieldContext sets the parent of a field context to the field context found in ctx:
+// the contexts of the ancestors of a field must be nested first, so that its path and parents are complete.
+package fieldtest
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Field builds the context of a field of an object, aliased after its name.
+//
+// The definition of the field is looked up in the schema, if any.
+func Field(schema *ast.Schema, object, name string) *graphql.FieldContext {
+	field := &ast.Field{Name: name, Alias: name}
+	if schema != nil {
+		if def := schema.Types[object]; def != nil {
+			field.Definition = def.Fields.ForName(name)
+		}
+	}
+	return &graphql.FieldContext{
+		Object: object,
+		Field:  graphql.CollectedField{Field: field},
+	}
+}
+
+// Element builds the context of the element of a list at some index
+func Element(index int) *graphql.FieldContext {
+	return &graphql.FieldContext{Index: &index}
+}
+
+// Context nests the field contexts in ctx, from the outermost to the innermost one,
+// and yields the context of the innermost field
+func Context(ctx context.Context, fields ...*graphql.FieldContext) context.Context {
+	for _, fc := range fields {
+		ctx = graphql.WithFieldContext(ctx, fc)
+	}
+	return ctx
+}
+
+// Nest nests the context of a field in ctx, after the contexts of its parents
+func Nest(ctx context.Context, fc *graphql.FieldContext) context.Context {
+	if fc.Parent != nil {
+		ctx = Nest(ctx, fc.Parent)
+	}
+	return graphql.WithFieldContext(ctx, fc)
+}
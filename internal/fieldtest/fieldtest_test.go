@@ -0,0 +1,35 @@
+package fieldtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestContext(t *testing.T) {
+	schema, gqlErr := gqlparser.LoadSchema(&ast.Source{Input: `
+type Query { users: [User!]! }
+type User { name: String! }
+`})
+	require.Nil(t, gqlErr)
+
+	users, name := Field(schema, "Query", "users"), Field(schema, "User", "name")
+	require.NotNil(t, name.Field.Definition)
+	assert.Equal(t, "String!", name.Field.Definition.Type.String())
+	assert.Nil(t, Field(schema, "Unknown", "name").Field.Definition)
+	assert.Nil(t, Field(nil, "User", "name").Field.Definition)
+
+	ctx := Context(context.Background(), users, Element(1), name)
+	fc := graphql.GetFieldContext(ctx)
+	assert.Same(t, name, fc)
+	assert.Equal(t, "users[1].name", fc.Path().String())
+	assert.Same(t, users, fc.Parent.Parent)
+
+	// nesting again from the innermost field keeps the same parents
+	assert.Equal(t, "users[1].name", graphql.GetFieldContext(Nest(context.Background(), name)).Path().String())
+}
@@ -0,0 +1,46 @@
+package httpwriter
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// Buffer is a http.ResponseWriter capturing a response in memory, e.g. to execute a handler out of band
+type Buffer struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+// NewBuffer builds an empty response Buffer, with status 200 OK
+func NewBuffer() *Buffer {
+	return &Buffer{
+		header: make(http.Header),
+		status: http.StatusOK,
+	}
+}
+
+// Header implements http.ResponseWriter
+func (w *Buffer) Header() http.Header {
+	return w.header
+}
+
+// Write implements http.ResponseWriter
+func (w *Buffer) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *Buffer) WriteHeader(status int) {
+	w.status = status
+}
+
+// Status yields the status code of the response
+func (w *Buffer) Status() int {
+	return w.status
+}
+
+// Bytes yields the body of the response
+func (w *Buffer) Bytes() []byte {
+	return w.body.Bytes()
+}
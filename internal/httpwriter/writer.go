@@ -0,0 +1,64 @@
+// Package httpwriter provides the http.ResponseWriter wrapper used by middlewares which set response headers
+// computed while the GraphQL handler executes, and a Buffer capturing responses in memory.
+package httpwriter
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Writer wraps a http.ResponseWriter, calling a hook right before the response headers are sent.
+//
+// Flush and Hijack are passed through to the wrapped writer, as required by streaming and websocket transports.
+type Writer struct {
+	http.ResponseWriter
+	before  func(status int) int
+	written bool
+}
+
+// New Writer, calling before once, right before the response headers are sent.
+// The hook may set headers, and yields the status code to send.
+func New(w http.ResponseWriter, before func(status int) int) *Writer {
+	return &Writer{ResponseWriter: w, before: before}
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *Writer) WriteHeader(status int) {
+	if w.written {
+		return
+	}
+	w.written = true
+	w.ResponseWriter.WriteHeader(w.before(status))
+}
+
+// Write implements http.ResponseWriter
+func (w *Writer) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *Writer) Flush() {
+	f, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+}
+
+// Hijack implements http.Hijacker
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return Hijack(w.ResponseWriter)
+}
+
+// Hijack the connection of a response writer, if it supports hijacking
+func Hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	return hj.Hijack()
+}
@@ -0,0 +1,33 @@
+package httpwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var calls int
+	rec := httptest.NewRecorder()
+	w := New(rec, func(status int) int {
+		calls++
+		rec.Header().Set("X-Status", http.StatusText(status))
+		return http.StatusTeapot
+	})
+
+	_, _ = w.Write([]byte("a"))
+	w.Flush()
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write([]byte("b"))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "OK", rec.Header().Get("X-Status"))
+	assert.Equal(t, "ab", rec.Body.String())
+	assert.True(t, rec.Flushed)
+
+	_, _, err := w.Hijack()
+	assert.EqualError(t, err, "the response writer does not support hijacking")
+}
@@ -0,0 +1,15 @@
+module github.com/99designs/gqlgen-contrib/prometheus
+
+go 1.13
+
+require (
+	github.com/99designs/gqlgen v0.11.3
+	github.com/99designs/gqlgen-contrib v0.1.0
+	github.com/prometheus/client_golang v1.6.0
+	github.com/prometheus/common v0.10.0 // indirect
+	github.com/stretchr/testify v1.4.0
+	github.com/vektah/gqlparser/v2 v2.0.1
+)
+
+// for local development, against the sibling root module: releases require the tagged root module
+replace github.com/99designs/gqlgen-contrib => ../
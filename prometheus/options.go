@@ -0,0 +1,51 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqllabel"
+)
+
+type (
+	// Option for the prometheus metrics extension.
+	Option func(*config)
+
+	config struct {
+		clock   func() time.Time
+		labeler gqllabel.OperationLabeler
+	}
+)
+
+func (c config) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+func (c config) opLabel(oc *graphql.OperationContext) string {
+	if c.labeler == nil {
+		return gqllabel.OperationName(oc)
+	}
+	return c.labeler(oc)
+}
+
+// WithClock sets the clock used to measure durations. By default, this is time.Now.
+//
+// This is useful to produce deterministic measurements in tests or replay tooling.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithOperationLabel sets the function producing the operation label. By default, this is gqllabel.OperationName.
+//
+// Use a gqllabel.Sanitizer to bound the cardinality of this label.
+func WithOperationLabel(labeler gqllabel.OperationLabeler) Option {
+	return func(c *config) {
+		c.labeler = labeler
+	}
+}
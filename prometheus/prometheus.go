@@ -6,32 +6,57 @@ import (
 
 	"github.com/99designs/gqlgen/graphql"
 	prometheusclient "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
 )
 
 const (
-	existStatusFailure = "failure"
-	exitStatusSuccess  = "success"
+	existStatusFailure  = "failure"
+	exitStatusSuccess   = "success"
+	exitStatusCancelled = "cancelled"
 )
 
 var (
 	timeToResolveField  *prometheusclient.HistogramVec
 	timeToHandleRequest *prometheusclient.HistogramVec
+
+	// positions of the labels kept on metrics among all their labels, as configured when registered
+	resolveFieldKept  []int
+	handleRequestKept []int
+)
+
+// all labels of metrics, in the order of their values
+var (
+	resolveFieldLabels  = []string{"exit_status", "object", "field"}
+	handleRequestLabels = []string{"exit_status", "operation"}
 )
 
 func Register() {
 	RegisterOn(prometheusclient.DefaultRegisterer)
 }
 
+// RegisterOn registers metrics on a registerer, named as configured by gqlmetrics.SetConfig.
+//
+// Changes of the configuration made afterwards do not apply to the registered metrics.
 func RegisterOn(registerer prometheusclient.Registerer) {
+	cfg := gqlmetrics.GetConfig()
+	fieldLabels := cfg.Labels(resolveFieldLabels)
+	requestLabels := cfg.Labels(handleRequestLabels)
+	resolveFieldKept = keptPositions(resolveFieldLabels, fieldLabels)
+	handleRequestKept = keptPositions(handleRequestLabels, requestLabels)
+
 	timeToResolveField = prometheusclient.NewHistogramVec(prometheusclient.HistogramOpts{
-		Name: "graphql_resolver_duration_ms",
-		Help: "The time taken to resolve a field by graphql server.",
-	}, []string{"exit_status", "object", "field"})
+		Name:        cfg.PrometheusName("graphql_resolver_duration_ms"),
+		Help:        "The time taken to resolve a field by graphql server.",
+		ConstLabels: cfg.ConstLabels,
+	}, fieldLabels)
 
 	timeToHandleRequest = prometheusclient.NewHistogramVec(prometheusclient.HistogramOpts{
-		Name: "graphql_request_duration_ms",
-		Help: "The time taken to handle a request by graphql server.",
-	}, []string{"exit_status", "operation"})
+		Name:        cfg.PrometheusName("graphql_request_duration_ms"),
+		Help:        "The time taken to handle a request by graphql server.",
+		ConstLabels: cfg.ConstLabels,
+	}, requestLabels)
 
 	registerer.MustRegister(
 		timeToResolveField,
@@ -48,7 +73,21 @@ func UnRegisterFrom(registerer prometheusclient.Registerer) {
 	registerer.Unregister(timeToHandleRequest)
 }
 
-type Metrics struct{}
+// Metrics is a gqlgen extension to collect prometheus metrics.
+//
+// The zero value is ready to use, with default options.
+type Metrics struct {
+	config
+}
+
+// New prometheus metrics extension
+func New(opts ...Option) *Metrics {
+	m := &Metrics{}
+	for _, apply := range opts {
+		apply(&m.config)
+	}
+	return m
+}
 
 var _ interface {
 	graphql.HandlerExtension
@@ -64,7 +103,7 @@ func (Metrics) Validate(schema graphql.ExecutableSchema) error {
 	return nil
 }
 
-func (Metrics) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+func (m Metrics) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
 	fieldCtx := graphql.GetFieldContext(ctx)
 
 	defer func(start time.Time) {
@@ -75,47 +114,71 @@ func (Metrics) InterceptField(ctx context.Context, next graphql.Resolver) (res i
 			exitStatus = exitStatusSuccess
 		}
 
-		timeToResolveField.WithLabelValues(exitStatus, fieldCtx.Object, fieldCtx.Field.Name).
-			Observe(float64(time.Since(start).Nanoseconds() / int64(time.Millisecond)))
-	}(time.Now())
+		values := [...]string{exitStatus, fieldCtx.Object, fieldCtx.Field.Name}
+		timeToResolveField.WithLabelValues(keptValues(resolveFieldKept, values[:])...).
+			Observe(float64(m.now().Sub(start).Nanoseconds() / int64(time.Millisecond)))
+	}(m.now())
 
 	res, err = next(ctx)
 	return res, err
 }
 
-func (Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) (res *graphql.Response) {
+func (m Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) (res *graphql.Response) {
 
 	opCtx := graphql.GetOperationContext(ctx)
 
 	defer func(start time.Time) {
-		if res == nil {
-			return
-		}
-
 		var exitStatus string
-		if res.Errors.Error() != "" {
+		switch {
+		case gqlcancel.ByClient(ctx):
+			// requests cancelled by the client are not failures
+			exitStatus = exitStatusCancelled
+		case res == nil:
+			return
+		case res.Errors.Error() != "":
 			exitStatus = existStatusFailure
-		} else {
+		default:
 			exitStatus = exitStatusSuccess
 		}
 
-		opName := ""
-		if opCtx.Operation != nil {
-			opName = opCtx.Operation.Name
-		}
-		if opName == "" && opCtx.Operation != nil {
-			//parent response case
-			opName = string(opCtx.Operation.Operation)
-		}
-		if opName == "" {
-			opName = opCtx.OperationName
-		}
+		opName := m.opLabel(opCtx)
 
-		timeToHandleRequest.WithLabelValues(exitStatus, opName).
-			Observe(float64(time.Since(start).Nanoseconds() / int64(time.Millisecond)))
+		values := [...]string{exitStatus, opName}
+		timeToHandleRequest.WithLabelValues(keptValues(handleRequestKept, values[:])...).
+			Observe(float64(m.now().Sub(start).Nanoseconds() / int64(time.Millisecond)))
 
-	}(time.Now())
+	}(m.now())
 
 	res = next(ctx)
 	return res
 }
+
+// keptPositions yields the positions of the kept labels among all labels, or nil when all labels are kept
+func keptPositions(all, kept []string) []int {
+	if len(kept) == len(all) {
+		return nil
+	}
+	positions := make([]int, 0, len(kept))
+	for i, name := range all {
+		for _, k := range kept {
+			if k == name {
+				positions = append(positions, i)
+				break
+			}
+		}
+	}
+	return positions
+}
+
+// keptValues removes the values of the labels dropped when metrics were registered.
+//
+// Values are compacted in place: unlike labels maps, this does not allocate when observing every field.
+func keptValues(kept []int, values []string) []string {
+	if kept == nil {
+		return values
+	}
+	for i, pos := range kept {
+		values[i] = values[pos]
+	}
+	return values[:len(kept)]
+}
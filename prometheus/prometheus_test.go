@@ -1,16 +1,24 @@
 package prometheus_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 
+	"github.com/99designs/gqlgen-contrib/gqlcancel"
+	"github.com/99designs/gqlgen-contrib/gqlmetrics"
 	"github.com/99designs/gqlgen-contrib/prometheus"
 	"github.com/99designs/gqlgen-contrib/prometheus/internal/graph"
 )
@@ -48,6 +56,92 @@ func TestPrometheus_ResolverMiddleware_RequestMiddleware(t *testing.T) {
 	assert.Contains(t, body, "graphql_resolver_duration_ms_sum")
 }
 
+func TestPrometheus_DropLabels(t *testing.T) {
+	require.NoError(t, gqlmetrics.SetConfig(gqlmetrics.Config{DropLabels: []string{"field"}}))
+	registry := prometheusclient.NewRegistry()
+	prometheus.RegisterOn(registry)
+	defer prometheus.UnRegisterFrom(registry)
+
+	// changes of the configuration after registration do not apply to registered metrics
+	require.NoError(t, gqlmetrics.SetConfig(gqlmetrics.Config{}))
+
+	gqlHandler := handler.NewDefaultServer(
+		graph.NewExecutableSchema(graph.Config{
+			Resolvers: &graph.Resolver{},
+		}),
+	)
+	gqlHandler.Use(&prometheus.Metrics{})
+
+	resp := doRequest(gqlHandler, http.MethodPost, "/query", `{"query":"{ todos { id text } }"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_resolver_duration_ms_count{exit_status="success",object="Query"}`)
+	assert.NotContains(t, body, `field="todos"`)
+}
+
+func TestPrometheus_WithClock(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	prometheus.RegisterOn(registry)
+	defer prometheus.UnRegisterFrom(registry)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := prometheus.New(prometheus.WithClock(func() time.Time {
+		now = now.Add(5 * time.Millisecond)
+		return now
+	}))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{OperationName: "clocked"})
+	m.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fctx := graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object: "Query",
+			Field:  graphql.CollectedField{Field: &ast.Field{Name: "clocked", Alias: "clocked"}},
+		})
+		_, _ = m.InterceptField(fctx, func(context.Context) (interface{}, error) { return nil, nil })
+		return &graphql.Response{}
+	})
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// the operation reads the clock before and after the field
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_request_duration_ms_sum{exit_status="success",operation="clocked"} 15`)
+	assert.Contains(t, body, `graphql_resolver_duration_ms_sum{exit_status="success",field="clocked",object="Query"} 5`)
+}
+
+func TestPrometheus_Cancelled(t *testing.T) {
+	registry := prometheusclient.NewRegistry()
+	prometheus.RegisterOn(registry)
+	defer prometheus.UnRegisterFrom(registry)
+
+	m := prometheus.New()
+	for _, opName := range []string{"client", "server"} {
+		var cancel context.CancelFunc
+		ctx := context.Background()
+		if opName == "client" {
+			ctx, cancel = context.WithCancel(ctx)
+		} else {
+			ctx, cancel = gqlcancel.WithCancel(ctx)
+		}
+		ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{OperationName: opName})
+		m.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			cancel()
+			return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("context canceled")}}
+		})
+	}
+
+	resp := doRequest(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `graphql_request_duration_ms_count{exit_status="cancelled",operation="client"} 1`)
+	assert.Contains(t, body, `graphql_request_duration_ms_count{exit_status="failure",operation="server"} 1`)
+}
+
 func doRequest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
 	r := httptest.NewRequest(method, target, strings.NewReader(body))
 	r.Header.Set("Content-Type", "application/json")